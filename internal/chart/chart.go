@@ -0,0 +1,214 @@
+// Package chart draws line charts of values over time as SVG or PNG, for
+// reports made from recordings (sstop rec graph).
+package chart
+
+import (
+	"errors"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Default image size in pixels.
+const (
+	DefaultWidth  = 960
+	DefaultHeight = 360
+)
+
+// Point is a value at a time.
+type Point struct {
+	At    time.Time
+	Value float64
+}
+
+// Series is one line of a chart.
+type Series struct {
+	Name   string
+	Color  color.RGBA
+	Points []Point // in time order
+}
+
+// Chart is a line chart of one or more series sharing a value axis.
+type Chart struct {
+	Title  string
+	Series []Series
+
+	// Format labels the value axis; nil prints the number.
+	Format func(float64) string
+
+	// Width and Height are the image size in pixels; 0 uses the default.
+	Width, Height int
+}
+
+// Margins around the plot area, leaving room for the title, legend and
+// axis labels.
+const (
+	marginLeft   = 100
+	marginRight  = 24
+	marginTop    = 48
+	marginBottom = 36
+)
+
+// layout is where a chart's parts go.
+type layout struct {
+	width, height            int
+	left, right, top, bottom float64 // plot area, in pixels
+	from, to                 time.Time
+	max                      float64
+	valueTicks               []float64
+	timeTicks                []time.Time
+	timeFormat               string
+}
+
+func (c *Chart) layout() (*layout, error) {
+	l := &layout{width: c.Width, height: c.Height}
+	if l.width <= 0 {
+		l.width = DefaultWidth
+	}
+	if l.height <= 0 {
+		l.height = DefaultHeight
+	}
+	if l.width < marginLeft+marginRight+100 || l.height < marginTop+marginBottom+60 {
+		return nil, errors.New("chart: image too small")
+	}
+	l.left, l.right = marginLeft, float64(l.width-marginRight)
+	l.top, l.bottom = marginTop, float64(l.height-marginBottom)
+
+	points := 0
+	for _, s := range c.Series {
+		for _, p := range s.Points {
+			if points == 0 || p.At.Before(l.from) {
+				l.from = p.At
+			}
+			if points == 0 || p.At.After(l.to) {
+				l.to = p.At
+			}
+			l.max = math.Max(l.max, p.Value)
+			points++
+		}
+	}
+	if points == 0 {
+		return nil, errors.New("chart: no data to draw")
+	}
+	if !l.to.After(l.from) {
+		l.to = l.from.Add(time.Second)
+	}
+	l.max, l.valueTicks = valueTicks(l.max)
+	l.timeTicks, l.timeFormat = timeTicks(l.from, l.to, int(l.right-l.left)/90)
+	return l, nil
+}
+
+// x and y map a time and a value to pixels.
+func (l *layout) x(t time.Time) float64 {
+	return l.left + (l.right-l.left)*float64(t.Sub(l.from))/float64(l.to.Sub(l.from))
+}
+
+func (l *layout) y(v float64) float64 {
+	return l.bottom - (l.bottom-l.top)*v/l.max
+}
+
+// segments returns a series' line in pixels, broken where samples are
+// missing: a gap over three times the usual interval, e.g. while sstop
+// wasn't recording.
+func (l *layout) segments(points []Point) [][][2]float64 {
+	if len(points) == 0 {
+		return nil
+	}
+	gaps := make([]time.Duration, 0, len(points)-1)
+	for i := 1; i < len(points); i++ {
+		gaps = append(gaps, points[i].At.Sub(points[i-1].At))
+	}
+	var maxGap time.Duration
+	if len(gaps) > 0 {
+		sorted := append([]time.Duration(nil), gaps...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		maxGap = max(3*sorted[len(sorted)/2], time.Second)
+	}
+
+	var segs [][][2]float64
+	var cur [][2]float64
+	for i, p := range points {
+		if i > 0 && gaps[i-1] > maxGap {
+			segs = append(segs, cur)
+			cur = nil
+		}
+		cur = append(cur, [2]float64{l.x(p.At), l.y(p.Value)})
+	}
+	return append(segs, cur)
+}
+
+// valueTicks rounds peak up to a whole number of steps of 1, 2 or 5
+// times a power of ten, in 1024-based units so that rate labels come out
+// round, and returns the ticks from 0 to it.
+func valueTicks(peak float64) (top float64, ticks []float64) {
+	if peak <= 0 {
+		peak = 1
+	}
+	unit := 1.0
+	for unit*1024 <= peak {
+		unit *= 1024
+	}
+	step := niceStep(peak/unit/5) * unit
+	top = math.Ceil(peak/step) * step
+	for i := 0; float64(i)*step <= top*(1+1e-9); i++ {
+		ticks = append(ticks, float64(i)*step)
+	}
+	return top, ticks
+}
+
+func niceStep(raw float64) float64 {
+	p := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5} {
+		if m*p >= raw {
+			return m * p
+		}
+	}
+	return 10 * p
+}
+
+var timeSteps = []time.Duration{
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// timeTicks returns at most n ticks on round times between from and to,
+// and the layout to label them with.
+func timeTicks(from, to time.Time, n int) ([]time.Time, string) {
+	n = max(n, 2)
+	span := to.Sub(from)
+	step := timeSteps[len(timeSteps)-1]
+	for _, s := range timeSteps {
+		if span/s < time.Duration(n) {
+			step = s
+			break
+		}
+	}
+	format := "15:04"
+	switch {
+	case span > 24*time.Hour:
+		format = "01-02 15:04"
+	case step < time.Minute:
+		format = "15:04:05"
+	}
+
+	// Round in from's zone, so hourly ticks fall on its hours
+	_, offset := from.Zone()
+	shift := time.Duration(offset) * time.Second
+	var ticks []time.Time
+	for t := from.Add(shift).Truncate(step).Add(-shift); !t.After(to); t = t.Add(step) {
+		if !t.Before(from) {
+			ticks = append(ticks, t)
+		}
+	}
+	return ticks, format
+}
+
+func (c *Chart) formatValue(v float64) string {
+	if c.Format != nil {
+		return c.Format(v)
+	}
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
@@ -0,0 +1,104 @@
+package chart
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testChart() *Chart {
+	t0 := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	var up, down []Point
+	for i := 0; i < 60; i++ {
+		at := t0.Add(time.Duration(i) * time.Second)
+		if i >= 20 && i < 30 {
+			continue // not recording
+		}
+		up = append(up, Point{At: at, Value: float64(i * 1024)})
+		down = append(down, Point{At: at, Value: float64(i * 2048)})
+	}
+	return &Chart{
+		Title: "nginx <up & down>",
+		Series: []Series{
+			{Name: "up", Color: color.RGBA{0x2f, 0x9e, 0x44, 0xff}, Points: up},
+			{Name: "down", Color: color.RGBA{0xe0, 0x31, 0x31, 0xff}, Points: down},
+		},
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	var b bytes.Buffer
+	if err := testChart().WriteSVG(&b); err != nil {
+		t.Fatal(err)
+	}
+	svg := b.String()
+	if !strings.HasPrefix(svg, "<svg ") || !strings.HasSuffix(svg, "</svg>\n") {
+		t.Fatalf("not an svg document:\n%s", svg)
+	}
+	if !strings.Contains(svg, "nginx &lt;up &amp; down&gt;") {
+		t.Error("title missing or not escaped")
+	}
+	// Each series breaks at the gap
+	if n := strings.Count(svg, "<polyline"); n != 4 {
+		t.Errorf("%d polylines, want 2 per series", n)
+	}
+	if !strings.Contains(svg, ">09:00:30<") {
+		t.Error("missing a time label")
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	c := testChart()
+	c.Width, c.Height = 640, 240
+	var b bytes.Buffer
+	if err := c.WritePNG(&b); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := img.Bounds().Size(); got.X != 640 || got.Y != 240 {
+		t.Errorf("size %v, want 640x240", got)
+	}
+}
+
+func TestChartErrors(t *testing.T) {
+	if err := (&Chart{}).WriteSVG(&bytes.Buffer{}); err == nil {
+		t.Error("want an error without points")
+	}
+	c := testChart()
+	c.Width = 50
+	if err := c.WritePNG(&bytes.Buffer{}); err == nil {
+		t.Error("want an error for a tiny image")
+	}
+}
+
+func TestValueTicks(t *testing.T) {
+	tests := []struct {
+		peak, top float64
+		ticks     int
+	}{
+		{0, 1, 6},
+		{900, 1000, 6},
+		{3 * 1024 * 1024, 3 * 1024 * 1024, 4},
+		{1.3 * 1024, 1.5 * 1024, 4},
+	}
+	for _, tt := range tests {
+		top, ticks := valueTicks(tt.peak)
+		if top != tt.top || len(ticks) != tt.ticks {
+			t.Errorf("valueTicks(%v) = %v, %d ticks; want %v, %d", tt.peak, top, len(ticks), tt.top, tt.ticks)
+		}
+	}
+}
+
+func TestGlyphs(t *testing.T) {
+	for r, g := range glyphs {
+		if len(g) != 15 {
+			t.Errorf("glyph %q has %d pixels, want 15", r, len(g))
+		}
+	}
+}
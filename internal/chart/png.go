@@ -0,0 +1,200 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+)
+
+// WritePNG writes the chart as a PNG image. Text is drawn in a small
+// built-in pixel font, upper case.
+func (c *Chart) WritePNG(w io.Writer) error {
+	l, err := c.layout()
+	if err != nil {
+		return err
+	}
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(colorBackground), image.Point{}, draw.Src)
+
+	left, right := int(l.left), int(l.right)
+	top, bottom := int(l.top), int(l.bottom)
+	for _, v := range l.valueTicks {
+		y := int(math.Round(l.y(v)))
+		hline(img, left, right, y, colorGrid)
+		drawText(img, left-8, y-glyphHeight/2, c.formatValue(v), colorAxis, alignRight)
+	}
+	for _, t := range l.timeTicks {
+		x := int(math.Round(l.x(t)))
+		vline(img, x, top, bottom, colorGrid)
+		drawText(img, x, bottom+8, t.Format(l.timeFormat), colorAxis, alignCenter)
+	}
+	hline(img, left, right, top, colorAxis)
+	hline(img, left, right, bottom, colorAxis)
+	vline(img, left, top, bottom, colorAxis)
+	vline(img, right, top, bottom, colorAxis)
+
+	if c.Title != "" {
+		drawText(img, left, 14, c.Title, colorText, alignLeft)
+	}
+	x := right
+	for i := len(c.Series) - 1; i >= 0; i-- {
+		s := c.Series[i]
+		x -= textWidth(s.Name)
+		drawText(img, x, 14, s.Name, colorText, alignLeft)
+		x -= 26
+		for dy := 0; dy < 3; dy++ {
+			hline(img, x, x+18, 14+glyphHeight/2-1+dy, s.Color)
+		}
+		x -= 16
+	}
+
+	for _, s := range c.Series {
+		for _, seg := range l.segments(s.Points) {
+			for i := 1; i < len(seg); i++ {
+				line(img, seg[i-1], seg[i], s.Color)
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
+
+func hline(img *image.RGBA, x0, x1, y int, c color.RGBA) {
+	for x := x0; x <= x1; x++ {
+		img.SetRGBA(x, y, c)
+	}
+}
+
+func vline(img *image.RGBA, x, y0, y1 int, c color.RGBA) {
+	for y := y0; y <= y1; y++ {
+		img.SetRGBA(x, y, c)
+	}
+}
+
+// line draws a line two pixels thick.
+func line(img *image.RGBA, from, to [2]float64, c color.RGBA) {
+	dx, dy := to[0]-from[0], to[1]-from[1]
+	steps := int(math.Ceil(math.Max(math.Abs(dx), math.Abs(dy))))
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		x := int(math.Round(from[0] + dx*t))
+		y := int(math.Round(from[1] + dy*t))
+		img.SetRGBA(x, y, c)
+		img.SetRGBA(x+1, y, c)
+		img.SetRGBA(x, y+1, c)
+		img.SetRGBA(x+1, y+1, c)
+	}
+}
+
+// The pixel font: 3×5 glyphs drawn at glyphScale, glyphGap apart.
+const (
+	glyphScale  = 2
+	glyphGap    = 2
+	glyphWidth  = 3*glyphScale + glyphGap
+	glyphHeight = 5 * glyphScale
+)
+
+type textAlign int
+
+const (
+	alignLeft textAlign = iota
+	alignCenter
+	alignRight
+)
+
+func textWidth(s string) int {
+	return len([]rune(s))*glyphWidth - glyphGap
+}
+
+// drawText draws s with its top at y, aligned on x.
+func drawText(img *image.RGBA, x, y int, s string, c color.RGBA, align textAlign) {
+	switch align {
+	case alignCenter:
+		x -= textWidth(s) / 2
+	case alignRight:
+		x -= textWidth(s)
+	}
+	for _, r := range strings.ToUpper(s) {
+		g, ok := glyphs[r]
+		if !ok {
+			g = glyphs['?']
+		}
+		for row := 0; row < 5; row++ {
+			for col := 0; col < 3; col++ {
+				if g[row*3+col] != '#' {
+					continue
+				}
+				for sy := 0; sy < glyphScale; sy++ {
+					for sx := 0; sx < glyphScale; sx++ {
+						img.SetRGBA(x+col*glyphScale+sx, y+row*glyphScale+sy, c)
+					}
+				}
+			}
+		}
+		x += glyphWidth
+	}
+}
+
+// glyphs are 3×5 bitmaps, row by row.
+var glyphs = map[rune]string{
+	'0': "####.##.##.####",
+	'1': ".#.##..#..#.###",
+	'2': "###..#####..###",
+	'3': "###..####..####",
+	'4': "#.##.####..#..#",
+	'5': "####..###..####",
+	'6': "####..####.####",
+	'7': "###..#..#..#..#",
+	'8': "####.#####.####",
+	'9': "####.####..####",
+	'A': ".#.#.#####.##.#",
+	'B': "##.#.###.#.###.",
+	'C': ".###..#..#...##",
+	'D': "##.#.##.##.###.",
+	'E': "####..##.#..###",
+	'F': "####..##.#..#..",
+	'G': ".###..#.##.#.##",
+	'H': "#.##.#####.##.#",
+	'I': "###.#..#..#.###",
+	'J': "..#..#..##.#.#.",
+	'K': "#.##.###.#.##.#",
+	'L': "#..#..#..#..###",
+	'M': "#.########.##.#",
+	'N': "##.#.##.##.##.#",
+	'O': ".#.#.##.##.#.#.",
+	'P': "##.#.###.#..#..",
+	'Q': ".#.#.##.###..##",
+	'R': "##.#.###.#.##.#",
+	'S': ".###...#...###.",
+	'T': "###.#..#..#..#.",
+	'U': "#.##.##.##.####",
+	'V': "#.##.##.##.#.#.",
+	'W': "#.##.########.#",
+	'X': "#.##.#.#.#.##.#",
+	'Y': "#.##.#.#..#..#.",
+	'Z': "###..#.#.#..###",
+	' ': "...............",
+	'.': ".............#.",
+	',': "..........#.#..",
+	':': "....#.....#....",
+	'/': "..#..#.#.#..#..",
+	'-': "......###......",
+	'+': "....#.###.#....",
+	'(': "..#.#..#..#...#",
+	')': "#...#..#..#.#..",
+	'%': "#.#..#.#.#..#.#",
+	'_': "............###",
+	'=': "...###...###...",
+	'[': "##.#..#..#..##.",
+	']': ".##..#..#..#.##",
+	'#': "#.#####.#####.#",
+	'?': "###..#.#.....#.",
+	'→': "....#.###.#....",
+	'–': "......###......",
+}
@@ -0,0 +1,77 @@
+package chart
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// Colours of the frame, shared with the PNG renderer.
+var (
+	colorBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	colorGrid       = color.RGBA{0xe5, 0xe7, 0xeb, 0xff}
+	colorAxis       = color.RGBA{0x6b, 0x72, 0x80, 0xff}
+	colorText       = color.RGBA{0x1f, 0x29, 0x37, 0xff}
+)
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// WriteSVG writes the chart as an SVG image.
+func (c *Chart) WriteSVG(w io.Writer) error {
+	l, err := c.layout()
+	if err != nil {
+		return err
+	}
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		l.width, l.height, l.width, l.height)
+	fmt.Fprintf(b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hex(colorBackground))
+	if c.Title != "" {
+		fmt.Fprintf(b, `<text x="%.0f" y="24" font-size="15" font-weight="bold" fill="%s">%s</text>`+"\n",
+			l.left, hex(colorText), html.EscapeString(c.Title))
+	}
+
+	// Legend, right-aligned on the title line
+	x := l.right
+	for i := len(c.Series) - 1; i >= 0; i-- {
+		s := c.Series[i]
+		x -= float64(7*len(s.Name) + 8)
+		fmt.Fprintf(b, `<text x="%.0f" y="24" fill="%s">%s</text>`+"\n", x, hex(colorText), html.EscapeString(s.Name))
+		x -= 24
+		fmt.Fprintf(b, `<line x1="%.0f" y1="20" x2="%.0f" y2="20" stroke="%s" stroke-width="3"/>`+"\n", x, x+18, hex(s.Color))
+		x -= 16
+	}
+
+	for _, v := range l.valueTicks {
+		y := l.y(v)
+		fmt.Fprintf(b, `<line x1="%.0f" y1="%.1f" x2="%.0f" y2="%.1f" stroke="%s"/>`+"\n", l.left, y, l.right, y, hex(colorGrid))
+		fmt.Fprintf(b, `<text x="%.0f" y="%.1f" text-anchor="end" fill="%s">%s</text>`+"\n",
+			l.left-8, y+4, hex(colorAxis), html.EscapeString(c.formatValue(v)))
+	}
+	for _, t := range l.timeTicks {
+		x := l.x(t)
+		fmt.Fprintf(b, `<line x1="%.1f" y1="%.0f" x2="%.1f" y2="%.0f" stroke="%s"/>`+"\n", x, l.top, x, l.bottom, hex(colorGrid))
+		fmt.Fprintf(b, `<text x="%.1f" y="%.0f" text-anchor="middle" fill="%s">%s</text>`+"\n",
+			x, l.bottom+18, hex(colorAxis), t.Format(l.timeFormat))
+	}
+	fmt.Fprintf(b, `<rect x="%.0f" y="%.0f" width="%.0f" height="%.0f" fill="none" stroke="%s"/>`+"\n",
+		l.left, l.top, l.right-l.left, l.bottom-l.top, hex(colorAxis))
+
+	for _, s := range c.Series {
+		for _, seg := range l.segments(s.Points) {
+			pts := make([]string, len(seg))
+			for i, p := range seg {
+				pts[i] = fmt.Sprintf("%.1f,%.1f", p[0], p[1])
+			}
+			fmt.Fprintf(b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="1.5" stroke-linejoin="round"/>`+"\n",
+				strings.Join(pts, " "), hex(s.Color))
+		}
+	}
+	fmt.Fprintln(b, "</svg>")
+	return b.Flush()
+}
@@ -0,0 +1,117 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// AppearRule watches for a process appearing: one whose name matches the
+// Process regular expression, one listening on any of Ports, or both when
+// both are set.
+type AppearRule struct {
+	Name    string
+	Process string
+	Ports   []uint16
+}
+
+// Appearance is a process a rule watches for appearing.
+type Appearance struct {
+	Rule    int // index of the rule in the list given to WatchAppear
+	At      time.Time
+	PID     uint32
+	Process string
+	Port    uint16 // the watched port it listens on; 0 for name-only rules
+}
+
+type appearRule struct {
+	process *regexp.Regexp // nil = any name
+	ports   map[uint16]bool
+}
+
+type appearKey struct {
+	rule int
+	pid  uint32
+}
+
+// appearWatch evaluates appear rules each poll.
+type appearWatch struct {
+	rules   []appearRule
+	fire    func(Appearance)
+	matched map[appearKey]bool // nil before the first poll
+}
+
+// WatchAppear calls fire for each process that newly matches one of the
+// rules. Processes already running at the first poll are the baseline and
+// do not fire; a process fires again only after it stopped matching. fire
+// runs on the collector's goroutine and must not block. Must be called
+// before Start.
+func (c *Collector) WatchAppear(rules []AppearRule, fire func(Appearance)) error {
+	w := &appearWatch{fire: fire}
+	for i, r := range rules {
+		if r.Process == "" && len(r.Ports) == 0 {
+			return fmt.Errorf("appear rule %d: a process or ports is required", i)
+		}
+		ar := appearRule{ports: make(map[uint16]bool)}
+		if r.Process != "" {
+			re, err := regexp.Compile(r.Process)
+			if err != nil {
+				return fmt.Errorf("appear rule %d: %w", i, err)
+			}
+			ar.process = re
+		}
+		for _, p := range r.Ports {
+			ar.ports[p] = true
+		}
+		w.rules = append(w.rules, ar)
+	}
+	c.appear = w
+	return nil
+}
+
+// match returns whether a rule matches p, and the watched port it
+// listens on.
+func (r *appearRule) match(p *model.ProcessSummary) (bool, uint16) {
+	if r.process != nil && !r.process.MatchString(p.Name) {
+		return false, 0
+	}
+	if len(r.ports) == 0 {
+		return true, 0
+	}
+	for _, lp := range p.ListenPorts {
+		if r.ports[lp.Port] {
+			return true, lp.Port
+		}
+	}
+	return false, 0
+}
+
+// observe checks a poll's processes and fires for new matches.
+func (w *appearWatch) observe(processes []model.ProcessSummary, now time.Time) {
+	baseline := w.matched == nil
+	matched := make(map[appearKey]bool)
+	for i := range processes {
+		p := &processes[i]
+		for ri := range w.rules {
+			ok, port := w.rules[ri].match(p)
+			if !ok {
+				continue
+			}
+			key := appearKey{rule: ri, pid: p.PID}
+			matched[key] = true
+			if baseline || w.matched[key] {
+				continue
+			}
+			w.fire(Appearance{
+				Rule:    ri,
+				At:      now,
+				PID:     p.PID,
+				Process: p.Name,
+				Port:    port,
+			})
+		}
+	}
+	w.matched = matched
+}
@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/platform"
+	"github.com/googlesky/sstop/internal/platform/platformtest"
+)
+
+func TestCollectorWatchAppear(t *testing.T) {
+	sshd := platformtest.Listen(model.ProtoTCP, 1, "sshd", "0.0.0.0:22")
+	redis := platformtest.Listen(model.ProtoTCP, 40, "redis-server", "0.0.0.0:6379")
+	miner := platformtest.Conn(model.ProtoTCP, 50, "xmrig", "127.0.0.1:50000", "127.0.0.9:3333", 0, 0)
+	c := New(platformtest.New(
+		platformtest.Step{Sockets: []platform.MappedSocket{sshd}},
+		platformtest.Step{Sockets: []platform.MappedSocket{sshd, redis, miner}},
+		platformtest.Step{Sockets: []platform.MappedSocket{sshd, redis, miner}},
+		platformtest.Step{Sockets: []platform.MappedSocket{sshd}},
+		platformtest.Step{Sockets: []platform.MappedSocket{sshd, redis}},
+	), time.Second)
+
+	var fired []Appearance
+	err := c.WatchAppear([]AppearRule{
+		{Name: "ssh", Ports: []uint16{22}},
+		{Name: "redis", Ports: []uint16{6379}},
+		{Name: "miner", Process: "^xmr"},
+	}, func(a Appearance) { fired = append(fired, a) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// sshd was running at the first poll: the baseline
+	pollOnce(t, c)
+	if len(fired) != 0 {
+		t.Fatalf("baseline fired %+v", fired)
+	}
+
+	pollOnce(t, c)
+	sort.Slice(fired, func(i, j int) bool { return fired[i].Rule < fired[j].Rule })
+	if len(fired) != 2 ||
+		fired[0].Rule != 1 || fired[0].PID != 40 || fired[0].Port != 6379 ||
+		fired[1].Rule != 2 || fired[1].Process != "xmrig" || fired[1].Port != 0 {
+		t.Fatalf("fired %+v", fired)
+	}
+
+	// Still running: no repeat; gone and back: fires again
+	pollOnce(t, c)
+	pollOnce(t, c)
+	pollOnce(t, c)
+	if len(fired) != 3 || fired[2].Rule != 1 {
+		t.Errorf("fired %+v, want redis again", fired)
+	}
+}
+
+func TestWatchAppearInvalid(t *testing.T) {
+	c := New(platformtest.New(), time.Second)
+	noop := func(Appearance) {}
+	if err := c.WatchAppear([]AppearRule{{Name: "x"}}, noop); err == nil {
+		t.Error("want an error for a rule without process or ports")
+	}
+	if err := c.WatchAppear([]AppearRule{{Process: "("}}, noop); err == nil {
+		t.Error("want an error for a bad regexp")
+	}
+}
@@ -4,7 +4,7 @@ package collector
 
 import "github.com/googlesky/sstop/internal/platform"
 
-func readCgroup(pid uint32) (containerID, serviceName string) {
+func readCgroup(pid uint32) (containerID, serviceName string, pod podRef) {
 	info := platform.ReadCgroup(pid)
-	return info.ContainerID, info.ServiceName
+	return info.ContainerID, info.ServiceName, podRef{uid: info.PodUID, containerID: info.KubeContainerID}
 }
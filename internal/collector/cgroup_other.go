@@ -2,6 +2,6 @@
 
 package collector
 
-func readCgroup(_ uint32) (containerID, serviceName string) {
-	return "", ""
+func readCgroup(_ uint32) (containerID, serviceName string, pod podRef) {
+	return "", "", podRef{}
 }
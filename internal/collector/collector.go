@@ -1,7 +1,12 @@
 package collector
 
 import (
+	"errors"
+	"maps"
+	"net"
+	"os/user"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -12,12 +17,24 @@ import (
 
 const (
 	emaAlpha = 0.3
+
+	// DefaultMaxConns is the default per-process cap on connections carried
+	// in a snapshot. Processes with more connections (load balancers, proxies)
+	// keep only the busiest ones plus an aggregate of the rest.
+	DefaultMaxConns = 500
+
+	// minRefreshGap is the minimum time between the end of a poll and an
+	// on-demand refresh; requests closer than this are dropped, as the
+	// data is already fresh.
+	minRefreshGap = 250 * time.Millisecond
 )
 
 // socketTracker tracks per-socket bandwidth over time.
 type socketTracker struct {
 	prevBytesSent uint64
 	prevBytesRecv uint64
+	cumSent       uint64 // bytes sent since first seen
+	cumRecv       uint64 // bytes received since first seen
 	upEMA         *EMA
 	downEMA       *EMA
 	firstSeen     time.Time
@@ -30,6 +47,8 @@ type ifaceTracker struct {
 	prevBytesRecv uint64
 	upEMA         *EMA
 	downEMA       *EMA
+	upHistory     *RingBuffer // for the interfaces view graphs
+	downHistory   *RingBuffer
 }
 
 // Collector periodically polls the platform and produces Snapshots.
@@ -37,24 +56,54 @@ type Collector struct {
 	platform platform.Platform
 	interval time.Duration
 	dns      *DNSCache
+	maxConns int // per-process connection cap; 0 = unlimited
+	redactor *Redactor
+	portMap  *portMapper
+	pods     *podResolver
+	appear   *appearWatch // nil: no appear rules
+
+	// startTime reads when a process started (zero if unknown); with the
+	// PID it tells a process from a later one reusing the PID.
+	startTime func(pid uint32) time.Time
 
 	mu           sync.Mutex
 	sockets      map[platform.SocketKey]*socketTracker
 	ifaces       map[string]*ifaceTracker
-	procHistory  map[uint32]*RingBuffer // PID → bandwidth history
+	procHistory  map[uint32]*RingBuffer // PID → bandwidth history, reset on PID reuse
+	procRates    map[uint32]*procRates  // PID → longer up/down history for the chart, reset on PID reuse
+	pollTimes    *RingBuffer            // Unix time of each poll, aligned with procRates
 	totalHistory *RingBuffer            // system-wide rate history for header sparkline
+	upHistory    *RingBuffer            // system-wide upload rate history
+	downHistory  *RingBuffer            // system-wide download rate history
 	lastPoll     time.Time
 
+	// Overrun accounting: ticks that fell inside a slow Collect are dropped
+	// rather than run back-to-back, so dt reflects real elapsed time.
+	lastPollEnd  time.Time
+	skippedPolls int
+
 	// Cumulative tracking (for exit summary + cumulative mode)
 	sessionStart time.Time
 	totalCumUp   uint64
 	totalCumDown uint64
-	cumByPID     map[uint32]*model.ProcessCumulative
+	cumByID      map[model.ProcessID]*model.ProcessCumulative
+	cumByKey     map[model.ProcessKey]*model.ProcessCumulative // all runs of a program, for the exit summary
+
+	// Processes seen last poll, and those that have since exited with
+	// traffic to their name (kept until dismissed, newest maxExited).
+	// The exited runs of a program still running are carried by its
+	// newest process instead, so a restart doesn't reset its totals.
+	lastProcs map[uint32]model.ProcessSummary
+	exited    map[model.ProcessKey]model.ProcessSummary
+	pastRuns  map[model.ProcessKey]map[model.ProcessID]bool // exited runs, until dismissed
+
+	userNames map[uint32]string // UID → username cache
 
 	stopOnce   sync.Once
 	stopCh     chan struct{}
 	snapCh     chan model.Snapshot
 	intervalCh chan time.Duration // dynamic interval changes
+	refreshCh  chan struct{}      // on-demand refresh requests
 }
 
 // New creates a new Collector.
@@ -63,15 +112,30 @@ func New(p platform.Platform, interval time.Duration) *Collector {
 		platform:     p,
 		interval:     interval,
 		dns:          NewDNSCache(),
+		maxConns:     DefaultMaxConns,
+		redactor:     defaultRedactor(),
+		portMap:      newPortMapper(),
+		pods:         newPodResolver(),
+		startTime:    readStartTime,
 		sockets:      make(map[platform.SocketKey]*socketTracker),
 		ifaces:       make(map[string]*ifaceTracker),
 		procHistory:  make(map[uint32]*RingBuffer),
+		procRates:    make(map[uint32]*procRates),
+		pollTimes:    NewRingBufferN(ProcRatesLen),
 		totalHistory: NewRingBufferN(60), // 60 samples = 1 min at 1s interval
+		upHistory:    NewRingBufferN(60),
+		downHistory:  NewRingBufferN(60),
 		sessionStart: time.Now(),
-		cumByPID:     make(map[uint32]*model.ProcessCumulative),
+		cumByID:      make(map[model.ProcessID]*model.ProcessCumulative),
+		cumByKey:     make(map[model.ProcessKey]*model.ProcessCumulative),
+		lastProcs:    make(map[uint32]model.ProcessSummary),
+		exited:       make(map[model.ProcessKey]model.ProcessSummary),
+		pastRuns:     make(map[model.ProcessKey]map[model.ProcessID]bool),
+		userNames:    make(map[uint32]string),
 		stopCh:       make(chan struct{}),
 		snapCh:       make(chan model.Snapshot, 1),
 		intervalCh:   make(chan time.Duration, 1),
+		refreshCh:    make(chan struct{}, 1),
 	}
 }
 
@@ -85,6 +149,7 @@ func (c *Collector) Start() <-chan model.Snapshot {
 func (c *Collector) Stop() {
 	c.stopOnce.Do(func() {
 		close(c.stopCh)
+		c.dns.Close()
 	})
 }
 
@@ -102,6 +167,47 @@ func (c *Collector) SetInterval(d time.Duration) {
 	}
 }
 
+// RefreshNow requests an immediate out-of-cycle poll, e.g. after killing a
+// process, instead of waiting out a slow interval. The regular schedule
+// restarts from the refresh. Requests arriving within minRefreshGap of the
+// previous poll are ignored.
+func (c *Collector) RefreshNow() {
+	select {
+	case c.refreshCh <- struct{}{}:
+	default:
+		// A refresh is already pending
+	}
+}
+
+// SetMaxConns sets the per-process connection cap. n <= 0 disables capping.
+// Must be called before Start.
+func (c *Collector) SetMaxConns(n int) {
+	if n < 0 {
+		n = 0
+	}
+	c.maxConns = n
+}
+
+// SetRedactor replaces the command-line redactor. nil disables redaction.
+// Must be called before Start.
+func (c *Collector) SetRedactor(r *Redactor) {
+	c.redactor = r
+}
+
+// SetKubeletURL sets the kubelet pod list used to name Kubernetes pods
+// their runtime's bundle annotations don't cover. "" disables it. Must be
+// called before Start.
+func (c *Collector) SetKubeletURL(url string) {
+	c.pods.kubeletURL = url
+}
+
+// LearnHostname records the name an application looked up for ip (see
+// DNSCache.Learn); remote hosts show it instead of the reverse DNS name.
+// Safe to call from any goroutine.
+func (c *Collector) LearnHostname(ip net.IP, name string, ttl time.Duration) {
+	c.dns.Learn(ip, name, ttl)
+}
+
 // Interval returns the current polling interval.
 func (c *Collector) Interval() time.Duration {
 	c.mu.Lock()
@@ -127,29 +233,68 @@ func (c *Collector) loop() {
 			c.interval = newInterval
 			c.mu.Unlock()
 			ticker.Reset(newInterval)
-		case <-ticker.C:
+		case t := <-ticker.C:
+			if c.lateTick(t) {
+				continue
+			}
+			c.poll()
+		case <-c.refreshCh:
+			if !c.refreshDue(time.Now()) {
+				continue
+			}
 			c.poll()
+			ticker.Reset(c.Interval())
 		}
 	}
 }
 
+// lateTick reports whether a tick was queued while the previous poll was
+// still running. Such ticks are already counted by recordOverrun.
+func (c *Collector) lateTick(t time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return t.Before(c.lastPollEnd)
+}
+
+// refreshDue reports whether an on-demand refresh at now is far enough
+// from the previous poll to run.
+func (c *Collector) refreshDue(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return now.Sub(c.lastPollEnd) >= minRefreshGap
+}
+
+// recordOverrun counts the intervals a poll ran past. Must hold c.mu.
+func (c *Collector) recordOverrun(took time.Duration) {
+	if c.interval > 0 && took > c.interval {
+		c.skippedPolls += int(took / c.interval)
+	}
+}
+
 func (c *Collector) poll() {
 	now := time.Now()
 
 	sockets, ifaces, err := c.platform.Collect()
-	if err != nil {
-		return
-	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	end := time.Now()
+	c.lastPollEnd = end
+	c.recordOverrun(end.Sub(now))
+
+	if err != nil {
+		return
+	}
+
 	dt := now.Sub(c.lastPoll).Seconds()
 	if dt <= 0 {
 		dt = 1.0
 	}
 	isFirstPoll := c.lastPoll.IsZero()
 	c.lastPoll = now
+	c.pollTimes.Push(float64(now.UnixNano()) / 1e9)
+	recentPolls := c.pollsSince(now.Add(-time.Minute))
 
 	// Track which socket keys are active this poll
 	activeKeys := make(map[platform.SocketKey]bool)
@@ -157,10 +302,16 @@ func (c *Collector) poll() {
 	// Per-process aggregation
 	type procData struct {
 		info     model.ProcessInfo
+		netns    string
 		conns    []model.Connection
 		listen   []model.ListenPort
 		upRate   float64
 		downRate float64
+
+		inUp, inDown   float64 // inbound (serving) traffic
+		outUp, outDown float64 // outbound (egress) traffic
+
+		unattributed map[string]int // UnattributedPID only: sockets by reason
 	}
 	procs := make(map[uint32]*procData)
 
@@ -171,7 +322,7 @@ func (c *Collector) poll() {
 				info: model.ProcessInfo{
 					PID:     pid,
 					Name:    name,
-					Cmdline: cmdline,
+					Cmdline: c.redactor.Redact(cmdline),
 				},
 			}
 			procs[pid] = pd
@@ -179,6 +330,22 @@ func (c *Collector) poll() {
 		return pd
 	}
 
+	// When each process started, read once per poll: cumulative bytes
+	// go to the process, not to whichever process last had its PID
+	starts := make(map[uint32]time.Time)
+	startOf := func(pid uint32) time.Time {
+		start, ok := starts[pid]
+		if !ok {
+			if pid != model.UnattributedPID {
+				start = c.startTime(pid)
+			}
+			starts[pid] = start
+		}
+		return start
+	}
+
+	listening := listeningPorts(sockets)
+
 	for i := range sockets {
 		s := &sockets[i]
 		key := platform.MakeSocketKey(s)
@@ -195,6 +362,7 @@ func (c *Collector) poll() {
 			}
 			c.sockets[key] = tracker
 		}
+		pd := getProc(s.PID, processName(s), s.Cmdline)
 
 		var upRate, downRate float64
 		if !isFirstPoll && exists {
@@ -206,20 +374,25 @@ func (c *Collector) poll() {
 			downRate = tracker.downEMA.Update(rawDown)
 
 			// Cumulative tracking
+			tracker.cumSent += deltaSent
+			tracker.cumRecv += deltaRecv
 			c.totalCumUp += deltaSent
 			c.totalCumDown += deltaRecv
-			if s.PID != 0 {
-				pc, ok := c.cumByPID[s.PID]
-				if !ok {
-					pc = &model.ProcessCumulative{PID: s.PID, Name: s.ProcessName}
-					c.cumByPID[s.PID] = pc
-				}
-				pc.BytesUp += deltaSent
-				pc.BytesDown += deltaRecv
-				if pc.Name == "" {
-					pc.Name = s.ProcessName
-				}
+			start := startOf(s.PID)
+			id := model.NewProcessID(s.PID, start)
+			pc, ok := c.cumByID[id]
+			if !ok {
+				pc = &model.ProcessCumulative{PID: s.PID, StartTime: start, Name: processName(s), Processes: 1}
+				c.cumByID[id] = pc
 			}
+			pc.BytesUp += deltaSent
+			pc.BytesDown += deltaRecv
+			if pc.Name == "" {
+				pc.Name = s.ProcessName
+			}
+			pk := c.programCumulative(model.NewProcessKey(pd.info.Name, pd.info.Cmdline), pc, !ok)
+			pk.BytesUp += deltaSent
+			pk.BytesDown += deltaRecv
 		}
 
 		tracker.prevBytesSent = s.BytesSent
@@ -227,7 +400,19 @@ func (c *Collector) poll() {
 		tracker.lastSeen = now
 
 		// Aggregate into process
-		pd := getProc(s.PID, s.ProcessName, s.Cmdline)
+		if s.PID == model.UnattributedPID {
+			if pd.unattributed == nil {
+				pd.unattributed = make(map[string]int)
+			}
+			reason := s.Unmapped
+			if reason == "" {
+				reason = model.UnattributedUnknown
+			}
+			pd.unattributed[reason]++
+		}
+		if s.NetNS != "" {
+			pd.netns = s.NetNS
+		}
 
 		if s.State == model.StateListen {
 			pd.listen = append(pd.listen, model.ListenPort{
@@ -236,6 +421,15 @@ func (c *Collector) poll() {
 				Port:  s.SrcPort,
 			})
 		} else {
+			dir := classifyDirection(s, listening)
+			switch dir {
+			case model.DirInbound:
+				pd.inUp += upRate
+				pd.inDown += downRate
+			case model.DirOutbound:
+				pd.outUp += upRate
+				pd.outDown += downRate
+			}
 			pd.conns = append(pd.conns, model.Connection{
 				Proto:      s.Proto,
 				SrcIP:      s.SrcIP,
@@ -245,9 +439,13 @@ func (c *Collector) poll() {
 				State:      s.State,
 				UpRate:     upRate,
 				DownRate:   downRate,
+				BytesUp:    tracker.cumSent,
+				BytesDown:  tracker.cumRecv,
 				Age:        now.Sub(tracker.firstSeen),
 				RemoteHost: c.dns.Resolve(s.DstIP),
 				Service:    model.ServiceName(s.DstPort, s.SrcPort),
+				Direction:  dir,
+				TCP:        s.TCP,
 			})
 		}
 		pd.upRate += upRate
@@ -274,6 +472,8 @@ func (c *Collector) poll() {
 				prevBytesRecv: iface.BytesRecv,
 				upEMA:         NewEMA(emaAlpha),
 				downEMA:       NewEMA(emaAlpha),
+				upHistory:     NewRingBufferN(60),
+				downHistory:   NewRingBufferN(60),
 			}
 			c.ifaces[iface.Name] = tracker
 		}
@@ -292,14 +492,15 @@ func (c *Collector) poll() {
 
 		tracker.prevBytesSent = iface.BytesSent
 		tracker.prevBytesRecv = iface.BytesRecv
+		tracker.upHistory.Push(upRate)
+		tracker.downHistory.Push(downRate)
 
-		ifaceStats = append(ifaceStats, model.InterfaceStats{
-			Name:      iface.Name,
-			BytesRecv: iface.BytesRecv,
-			BytesSent: iface.BytesSent,
-			RecvRate:  downRate,
-			SendRate:  upRate,
-		})
+		stats := iface
+		stats.RecvRate = downRate
+		stats.SendRate = upRate
+		stats.RecvHistory = tracker.downHistory.Samples()
+		stats.SendHistory = tracker.upHistory.Samples()
+		ifaceStats = append(ifaceStats, stats)
 	}
 
 	// Build process summaries + update history
@@ -308,6 +509,16 @@ func (c *Collector) poll() {
 	for _, pd := range procs {
 		pid := pd.info.PID
 		activePIDs[pid] = true
+		start := startOf(pid)
+		id := model.NewProcessID(pid, start)
+
+		// What was kept on the PID belongs to another process once it's reused
+		prev, known := c.lastProcs[pid]
+		if known && !prev.ID().Same(id) {
+			delete(c.procHistory, pid)
+			delete(c.procRates, pid)
+			known = false
+		}
 
 		// Update sparkline history
 		hist, ok := c.procHistory[pid]
@@ -316,32 +527,82 @@ func (c *Collector) poll() {
 			c.procHistory[pid] = hist
 		}
 		hist.Push(pd.upRate + pd.downRate)
+		rates, ok := c.procRates[pid]
+		if !ok {
+			rates = &procRates{up: NewRingBufferN(ProcRatesLen), down: NewRingBufferN(ProcRatesLen)}
+			c.procRates[pid] = rates
+		}
+		rates.up.Push(pd.upRate)
+		rates.down.Push(pd.downRate)
+		avgRate, peakRate := rates.recent(recentPolls)
 
 		// Populate cumulative bytes from tracking
 		var cumUp, cumDown uint64
-		if pc, ok := c.cumByPID[pid]; ok {
+		if pc, ok := c.cumByID[id]; ok {
 			cumUp = pc.BytesUp
 			cumDown = pc.BytesDown
 		}
 
-		containerID, serviceName := readCgroup(pid)
+		firstSeen := now
+		if known && !prev.FirstSeen.IsZero() {
+			firstSeen = prev.FirstSeen
+		}
+		// How a process was started doesn't change: classify it once
+		kind := ""
+		if known && prev.Name == pd.info.Name {
+			kind = prev.Kind
+		}
+		if kind == "" {
+			kind = readKind(pid)
+		}
+
+		containerID, serviceName, pod := readCgroup(pid)
+		podName, namespace := c.pods.resolve(pod, now)
+		var uid *uint32
+		var userName string
+		if id, ok := readUID(pid); ok {
+			uid, userName = &id, c.userName(id)
+		}
+		topDest, topDestIP, topDestCountry := topDestination(pd.conns)
+		conns, omitted := capConnections(pd.conns, c.maxConns)
 
 		ps := model.ProcessSummary{
-			PID:         pid,
-			PPID:        readPPID(pid),
-			Name:        pd.info.Name,
-			Cmdline:     pd.info.Cmdline,
-			UpRate:      pd.upRate,
-			DownRate:    pd.downRate,
-			Connections: pd.conns,
-			ListenPorts: pd.listen,
-			ConnCount:   len(pd.conns),
-			ListenCount: len(pd.listen),
-			CumUp:       cumUp,
-			CumDown:     cumDown,
-			ContainerID: containerID,
-			ServiceName: serviceName,
-			RateHistory: hist.Samples(),
+			PID:             pid,
+			PPID:            readPPID(pid),
+			Name:            pd.info.Name,
+			Cmdline:         pd.info.Cmdline,
+			UpRate:          pd.upRate,
+			DownRate:        pd.downRate,
+			InboundUp:       pd.inUp,
+			InboundDown:     pd.inDown,
+			OutboundUp:      pd.outUp,
+			OutboundDown:    pd.outDown,
+			AvgRate:         avgRate,
+			PeakRate:        peakRate,
+			Connections:     conns,
+			ListenPorts:     pd.listen,
+			ConnCount:       len(pd.conns),
+			ListenCount:     len(pd.listen),
+			OmittedConns:    omitted.count,
+			OmittedUpRate:   omitted.upRate,
+			OmittedDownRate: omitted.downRate,
+			CumUp:           cumUp,
+			CumDown:         cumDown,
+			UID:             uid,
+			User:            userName,
+			ContainerID:     containerID,
+			ServiceName:     serviceName,
+			PodName:         podName,
+			Namespace:       namespace,
+			NetNS:           pd.netns,
+			Kind:            kind,
+			TopDest:         topDest,
+			TopDestIP:       topDestIP,
+			TopDestCountry:  topDestCountry,
+			StartTime:       start,
+			FirstSeen:       firstSeen,
+			RateHistory:     hist.Samples(),
+			Unattributed:    pd.unattributed,
 		}
 		processes = append(processes, ps)
 	}
@@ -350,8 +611,13 @@ func (c *Collector) poll() {
 	for pid := range c.procHistory {
 		if !activePIDs[pid] {
 			delete(c.procHistory, pid)
+			delete(c.procRates, pid)
 		}
 	}
+	c.trackExited(processes, now)
+	if c.appear != nil {
+		c.appear.observe(processes, now)
+	}
 
 	// Aggregate remote hosts across all processes
 	type hostAgg struct {
@@ -398,14 +664,19 @@ func (c *Collector) poll() {
 		}
 		sort.Strings(prNames)
 		country := geo.Lookup(ha.rawIP)
+		asn := geo.LookupASN(ha.rawIP)
 		remoteHosts = append(remoteHosts, model.RemoteHostSummary{
-			Host:      ha.hostname,
-			IP:        ha.rawIP,
-			Country:   country.Format(),
-			UpRate:    ha.upRate,
-			DownRate:  ha.downRate,
-			ConnCount: ha.connCount,
-			Processes: prNames,
+			Host:        ha.hostname,
+			IP:          ha.rawIP,
+			Country:     country.Format(),
+			CountryCode: country.Code,
+			City:        country.City,
+			ASN:         asn.Number,
+			ASOrg:       asn.Org,
+			UpRate:      ha.upRate,
+			DownRate:    ha.downRate,
+			ConnCount:   ha.connCount,
+			Processes:   prNames,
 		})
 	}
 
@@ -429,6 +700,9 @@ func (c *Collector) poll() {
 			})
 		}
 	}
+	if c.portMap != nil {
+		listenPorts = applyPortMappings(listenPorts, c.portMap.current(now))
+	}
 	// Sort by port number, then proto
 	sort.Slice(listenPorts, func(i, j int) bool {
 		if listenPorts[i].Port != listenPorts[j].Port {
@@ -439,6 +713,8 @@ func (c *Collector) poll() {
 
 	// Update total rate history for header sparkline
 	c.totalHistory.Push(totalUp + totalDown)
+	c.upHistory.Push(totalUp)
+	c.downHistory.Push(totalDown)
 
 	snap := model.Snapshot{
 		Timestamp:        now,
@@ -449,7 +725,12 @@ func (c *Collector) poll() {
 		TotalUp:          totalUp,
 		TotalDown:        totalDown,
 		TotalRateHistory: c.totalHistory.Samples(),
+		UpRateHistory:    c.upHistory.Samples(),
+		DownRateHistory:  c.downHistory.Samples(),
+		SkippedPolls:     c.skippedPolls,
+		Exited:           c.exitedList(),
 	}
+	snap.CollectLatency = time.Since(now)
 
 	// Non-blocking send — drop oldest if consumer is slow
 	select {
@@ -477,9 +758,9 @@ func (c *Collector) SessionStats() model.SessionStats {
 		TotalDown: c.totalCumDown,
 	}
 
-	// Collect all process cumulatives
-	all := make([]model.ProcessCumulative, 0, len(c.cumByPID))
-	for _, pc := range c.cumByPID {
+	// Collect each program's cumulatives, its restarts together
+	all := make([]model.ProcessCumulative, 0, len(c.cumByKey))
+	for _, pc := range c.cumByKey {
 		all = append(all, *pc)
 	}
 
@@ -497,16 +778,417 @@ func (c *Collector) SessionStats() model.SessionStats {
 	return stats
 }
 
-// CumulativeByPID returns cumulative bytes for a specific PID.
+// programCumulative returns the totals of all runs of the program key,
+// counting pc as one more when it's a new process. They're listed under
+// the newest process.
+func (c *Collector) programCumulative(key model.ProcessKey, pc *model.ProcessCumulative, isNew bool) *model.ProcessCumulative {
+	pk, ok := c.cumByKey[key]
+	if !ok {
+		pk = &model.ProcessCumulative{Name: pc.Name}
+		c.cumByKey[key] = pk
+	}
+	if isNew {
+		pk.PID, pk.StartTime = pc.PID, pc.StartTime
+		pk.Processes++
+	}
+	return pk
+}
+
+// maxExited caps how many exited processes are retained.
+const maxExited = 100
+
+// trackExited moves processes that vanished since the last poll into the
+// exited set, keeping their session totals. So does a PID reused by a new
+// process. Programs are told apart by name and command line: while one is
+// still running, or once it starts again, its exited runs are carried by
+// its newest process rather than listed as exited.
+func (c *Collector) trackExited(processes []model.ProcessSummary, now time.Time) {
+	current := make(map[uint32]model.ProcessSummary, len(processes))
+	running := make(map[model.ProcessKey]bool, len(processes))
+	for _, p := range processes {
+		current[p.PID] = p
+		if p.PID == model.UnattributedPID {
+			continue
+		}
+		key := p.Key()
+		running[key] = true
+		// Back after a poll without sockets: it never exited
+		if runs := c.pastRuns[key]; runs[p.ID()] {
+			delete(runs, p.ID())
+			if len(runs) == 0 {
+				delete(c.pastRuns, key)
+			}
+		}
+	}
+	for key := range c.exited {
+		if running[key] {
+			delete(c.exited, key)
+		}
+	}
+
+	gone := make(map[model.ProcessKey]model.ProcessSummary)
+	for pid, p := range c.lastProcs {
+		if cur, ok := current[pid]; ok && cur.ID().Same(p.ID()) || pid == model.UnattributedPID {
+			continue
+		}
+		key, id := p.Key(), p.ID()
+		if _, ok := c.cumByID[id]; ok {
+			if c.pastRuns[key] == nil {
+				c.pastRuns[key] = make(map[model.ProcessID]bool)
+			}
+			c.pastRuns[key][id] = true
+		}
+		if last, ok := gone[key]; !ok || p.StartTime.After(last.StartTime) {
+			gone[key] = p
+		}
+	}
+	for key, p := range gone {
+		if running[key] {
+			continue
+		}
+		total := c.pastBytes(key)
+		if total.up+total.down == 0 {
+			delete(c.pastRuns, key)
+			continue
+		}
+		var own byteTotals
+		if pc, ok := c.cumByID[p.ID()]; ok {
+			own = byteTotals{pc.BytesUp, pc.BytesDown}
+		}
+		// Only the totals stay meaningful once the process is gone
+		c.exited[key] = model.ProcessSummary{
+			PID:         p.PID,
+			PPID:        p.PPID,
+			StartTime:   p.StartTime,
+			Name:        p.Name,
+			Cmdline:     p.Cmdline,
+			CumUp:       own.up,
+			CumDown:     own.down,
+			CarriedUp:   total.up - own.up,
+			CarriedDown: total.down - own.down,
+			UID:         p.UID,
+			User:        p.User,
+			ContainerID: p.ContainerID,
+			ServiceName: p.ServiceName,
+			PodName:     p.PodName,
+			Namespace:   p.Namespace,
+			NetNS:       p.NetNS,
+			RateHistory: p.RateHistory,
+			ExitedAt:    now,
+		}
+	}
+	c.lastProcs = current
+
+	for len(c.exited) > maxExited {
+		var oldest model.ProcessKey
+		first := true
+		for key, p := range c.exited {
+			if first || p.ExitedAt.Before(c.exited[oldest].ExitedAt) {
+				oldest, first = key, false
+			}
+		}
+		delete(c.exited, oldest)
+		delete(c.pastRuns, oldest)
+	}
+	c.carryTotals(processes)
+}
+
+// carryTotals sets the carried bytes of each program with exited runs on
+// its newest running process (latest start, then highest PID).
+func (c *Collector) carryTotals(processes []model.ProcessSummary) {
+	if len(c.pastRuns) == 0 {
+		return
+	}
+	heirs := make(map[model.ProcessKey]int, len(c.pastRuns))
+	for i := range processes {
+		p := &processes[i]
+		if p.PID == model.UnattributedPID {
+			continue
+		}
+		key := p.Key()
+		if len(c.pastRuns[key]) == 0 {
+			continue
+		}
+		j, ok := heirs[key]
+		if !ok || p.StartTime.After(processes[j].StartTime) ||
+			p.StartTime.Equal(processes[j].StartTime) && p.PID > processes[j].PID {
+			heirs[key] = i
+		}
+	}
+	for key, i := range heirs {
+		total := c.pastBytes(key)
+		processes[i].CarriedUp, processes[i].CarriedDown = total.up, total.down
+	}
+}
+
+// byteTotals is bytes uploaded and downloaded.
+type byteTotals struct {
+	up, down uint64
+}
+
+func (t byteTotals) add(up, down uint64) byteTotals {
+	return byteTotals{t.up + up, t.down + down}
+}
+
+// pastBytes sums the bytes of the program key's exited runs.
+func (c *Collector) pastBytes(key model.ProcessKey) byteTotals {
+	var total byteTotals
+	for id := range c.pastRuns[key] {
+		if pc, ok := c.cumByID[id]; ok {
+			total = total.add(pc.BytesUp, pc.BytesDown)
+		}
+	}
+	return total
+}
+
+// exitedList returns the retained exited processes, most recent first.
+func (c *Collector) exitedList() []model.ProcessSummary {
+	if len(c.exited) == 0 {
+		return nil
+	}
+	out := make([]model.ProcessSummary, 0, len(c.exited))
+	for _, p := range c.exited {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].ExitedAt.Equal(out[j].ExitedAt) {
+			return out[i].ExitedAt.After(out[j].ExitedAt)
+		}
+		if out[i].PID != out[j].PID {
+			return out[i].PID < out[j].PID
+		}
+		return out[i].StartTime.Before(out[j].StartTime)
+	})
+	return out
+}
+
+// DismissExited forgets the exited processes that ran as the given PIDs,
+// or all of them when called without PIDs. Their bytes still count towards
+// the session totals.
+func (c *Collector) DismissExited(pids ...uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(pids) == 0 {
+		for key := range c.exited {
+			delete(c.pastRuns, key)
+		}
+		clear(c.exited)
+		return
+	}
+	drop := make(map[uint32]bool, len(pids))
+	for _, pid := range pids {
+		drop[pid] = true
+	}
+	maps.DeleteFunc(c.exited, func(key model.ProcessKey, p model.ProcessSummary) bool {
+		if !drop[p.PID] {
+			return false
+		}
+		delete(c.pastRuns, key)
+		return true
+	})
+}
+
+// processName is the name s is listed under: its process's, or
+// model.UnattributedName for sockets no process could be found for.
+func processName(s *platform.MappedSocket) string {
+	if s.PID == model.UnattributedPID {
+		return model.UnattributedName
+	}
+	return s.ProcessName
+}
+
+// procRates is a process's upload and download rate per poll since it
+// was first seen, up to ProcRatesLen polls.
+type procRates struct {
+	up, down *RingBuffer
+}
+
+// recent returns the average and the peak of the up+down rate over the
+// newest n polls.
+func (r *procRates) recent(n int) (avg, peak float64) {
+	up, down := r.up.Recent(n), r.down.Recent(n)
+	if len(up) == 0 {
+		return 0, 0
+	}
+	for i := range up {
+		total := up[i] + down[i]
+		avg += total
+		peak = max(peak, total)
+	}
+	return avg / float64(len(up)), peak
+}
+
+// pollsSince counts the polls at or after t. Must hold c.mu.
+func (c *Collector) pollsSince(t time.Time) int {
+	times := c.pollTimes.Samples()
+	cutoff := float64(t.UnixNano()) / 1e9
+	n := 0
+	for i := len(times) - 1; i >= 0 && times[i] >= cutoff; i-- {
+		n++
+	}
+	return n
+}
+
+// ProcessRates returns the upload and download rate history of a running
+// process, up to ProcRatesLen polls; empty for an unknown PID.
+func (c *Collector) ProcessRates(pid uint32) model.RateSeries {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rates, ok := c.procRates[pid]
+	if !ok {
+		return model.RateSeries{}
+	}
+	series := model.RateSeries{Up: rates.up.Samples(), Down: rates.down.Samples()}
+	// The process was pushed every poll since it appeared: its samples
+	// line up with the newest poll times
+	times := c.pollTimes.Samples()
+	times = times[len(times)-len(series.Up):]
+	series.Times = make([]time.Time, len(times))
+	for i, t := range times {
+		series.Times[i] = time.Unix(0, int64(t*1e9))
+	}
+	return series
+}
+
+// DestroySocket closes one of process pid's sockets, leaving the process
+// running. Only some platforms can (Linux, with CAP_NET_ADMIN).
+func (c *Collector) DestroySocket(pid uint32, conn model.Connection) error {
+	d, ok := c.platform.(platform.SocketDestroyer)
+	if !ok {
+		return errors.New("closing a single socket is not supported on this platform")
+	}
+	return d.DestroySocket(pid, conn)
+}
+
+// CumulativeByPID returns cumulative bytes for the process running as pid.
 func (c *Collector) CumulativeByPID(pid uint32) (up, down uint64) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	if pc, ok := c.cumByPID[pid]; ok {
+	id := model.ProcessID{PID: pid}
+	if p, ok := c.lastProcs[pid]; ok {
+		id = p.ID()
+	}
+	if pc, ok := c.cumByID[id]; ok {
 		return pc.BytesUp, pc.BytesDown
 	}
 	return 0, 0
 }
 
+// userName resolves a UID to a username, caching results. Unknown UIDs
+// resolve to the numeric ID.
+func (c *Collector) userName(uid uint32) string {
+	if name, ok := c.userNames[uid]; ok {
+		return name
+	}
+	id := strconv.FormatUint(uint64(uid), 10)
+	name := id
+	if u, err := user.LookupId(id); err == nil && u.Username != "" {
+		name = u.Username
+	}
+	c.userNames[uid] = name
+	return name
+}
+
+// listenKey identifies a listening port independent of the bound address.
+type listenKey struct {
+	proto model.Protocol
+	port  uint16
+}
+
+// listeningPorts returns the set of (proto, port) pairs with a listening socket.
+func listeningPorts(sockets []platform.MappedSocket) map[listenKey]bool {
+	result := make(map[listenKey]bool)
+	for i := range sockets {
+		if sockets[i].State == model.StateListen {
+			result[listenKey{sockets[i].Proto, sockets[i].SrcPort}] = true
+		}
+	}
+	return result
+}
+
+// classifyDirection reports whether a connection was accepted on a local
+// listening port (inbound) or initiated by the local process (outbound).
+func classifyDirection(s *platform.MappedSocket, listening map[listenKey]bool) model.Direction {
+	if s.DstIP == nil || s.DstIP.IsUnspecified() {
+		return model.DirUnknown
+	}
+	if listening[listenKey{s.Proto, s.SrcPort}] {
+		return model.DirInbound
+	}
+	return model.DirOutbound
+}
+
+// topDestination returns the remote host (hostname, or IP when unresolved)
+// receiving the most traffic across conns, plus its address and country
+// code. Returns zero values when no connection has any traffic.
+func topDestination(conns []model.Connection) (host string, ip net.IP, country string) {
+	type destAgg struct {
+		ip   net.IP
+		host string
+		rate float64
+	}
+	dests := make(map[string]*destAgg)
+	var best *destAgg
+	for i := range conns {
+		c := &conns[i]
+		if c.DstIP == nil || c.DstIP.IsUnspecified() {
+			continue
+		}
+		key := c.DstIP.String()
+		d, ok := dests[key]
+		if !ok {
+			d = &destAgg{ip: c.DstIP, host: c.RemoteHost}
+			dests[key] = d
+		}
+		d.rate += c.UpRate + c.DownRate
+		if d.rate > 0 && (best == nil || d.rate > best.rate) {
+			best = d
+		}
+	}
+	if best == nil {
+		return "", nil, ""
+	}
+	host = best.host
+	if host == "" {
+		host = best.ip.String()
+	}
+	return host, best.ip, geo.Lookup(best.ip).Code
+}
+
+// omittedConns aggregates connections dropped by capConnections.
+type omittedConns struct {
+	count            int
+	upRate, downRate float64
+}
+
+// capConnections keeps the limit busiest connections (by up+down rate, older
+// connections first on ties) and aggregates the rest. limit <= 0 keeps all.
+// The input slice is not modified.
+func capConnections(conns []model.Connection, limit int) ([]model.Connection, omittedConns) {
+	if limit <= 0 || len(conns) <= limit {
+		return conns, omittedConns{}
+	}
+
+	sorted := make([]model.Connection, len(conns))
+	copy(sorted, conns)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri := sorted[i].UpRate + sorted[i].DownRate
+		rj := sorted[j].UpRate + sorted[j].DownRate
+		if ri != rj {
+			return ri > rj
+		}
+		return sorted[i].Age > sorted[j].Age
+	})
+
+	var om omittedConns
+	for _, conn := range sorted[limit:] {
+		om.count++
+		om.upRate += conn.UpRate
+		om.downRate += conn.DownRate
+	}
+	return sorted[:limit:limit], om
+}
+
 // safeDelta handles counter wraps (uint64 overflow).
 func safeDelta(current, previous uint64) uint64 {
 	if current >= previous {
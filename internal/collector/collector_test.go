@@ -0,0 +1,512 @@
+package collector
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/platform"
+	"github.com/googlesky/sstop/internal/platform/platformtest"
+	"github.com/googlesky/sstop/internal/usage"
+)
+
+// pollOnce runs one collection cycle and returns the snapshot it produced.
+func pollOnce(t *testing.T, c *Collector) model.Snapshot {
+	t.Helper()
+	c.poll()
+	select {
+	case snap := <-c.snapCh:
+		return snap
+	default:
+		t.Fatal("poll produced no snapshot")
+		return model.Snapshot{}
+	}
+}
+
+func findProc(snap model.Snapshot, pid uint32) *model.ProcessSummary {
+	for i := range snap.Processes {
+		if snap.Processes[i].PID == pid {
+			return &snap.Processes[i]
+		}
+	}
+	return nil
+}
+
+func TestCollectorWithFakePlatform(t *testing.T) {
+	// Loopback addresses keep reverse DNS out of the test
+	step := func(sent, recv uint64) platformtest.Step {
+		return platformtest.Step{
+			Sockets: []platform.MappedSocket{
+				platformtest.Listen(model.ProtoTCP, 10, "nginx", "0.0.0.0:80"),
+				platformtest.Conn(model.ProtoTCP, 10, "nginx", "127.0.0.1:80", "127.0.0.5:40000", sent, recv),
+				platformtest.Conn(model.ProtoTCP, 20, "curl", "127.0.0.1:50000", "127.0.0.9:443", 0, recv),
+			},
+			Interfaces: []model.InterfaceStats{platformtest.Iface("eth0", recv, sent)},
+		}
+	}
+	fake := platformtest.New(step(1000, 500), step(4000, 2500))
+	c := New(fake, time.Second)
+
+	first := pollOnce(t, c)
+	if len(first.Processes) != 2 {
+		t.Fatalf("expected 2 processes, got %d", len(first.Processes))
+	}
+
+	second := pollOnce(t, c)
+	nginx := findProc(second, 10)
+	if nginx == nil {
+		t.Fatal("nginx missing from snapshot")
+	}
+	if nginx.ConnCount != 1 || nginx.ListenCount != 1 {
+		t.Errorf("nginx conns/listen = %d/%d, want 1/1", nginx.ConnCount, nginx.ListenCount)
+	}
+	conn := nginx.Connections[0]
+	if conn.BytesUp != 3000 || conn.BytesDown != 2000 {
+		t.Errorf("cumulative bytes = %d/%d, want 3000/2000", conn.BytesUp, conn.BytesDown)
+	}
+	if conn.Direction != model.DirInbound {
+		t.Errorf("direction = %v, want in", conn.Direction)
+	}
+	if nginx.UpRate <= 0 || nginx.InboundUp != nginx.UpRate {
+		t.Errorf("expected all nginx upload to be inbound, got up=%.0f inbound=%.0f", nginx.UpRate, nginx.InboundUp)
+	}
+
+	curl := findProc(second, 20)
+	if curl == nil || curl.Connections[0].Direction != model.DirOutbound {
+		t.Errorf("expected curl connection classified outbound, got %+v", curl)
+	}
+
+	if len(second.UpRateHistory) != 2 || second.UpRateHistory[1] != second.TotalUp ||
+		second.DownRateHistory[1] != second.TotalDown {
+		t.Errorf("direction histories = %v / %v, want last sample = totals %.0f / %.0f",
+			second.UpRateHistory, second.DownRateHistory, second.TotalUp, second.TotalDown)
+	}
+
+	eth0 := second.Interfaces[0]
+	if len(eth0.SendHistory) != 2 || eth0.SendHistory[1] != eth0.SendRate || eth0.RecvHistory[1] != eth0.RecvRate {
+		t.Errorf("eth0 histories = %v / %v, want last sample = rates %.0f / %.0f",
+			eth0.SendHistory, eth0.RecvHistory, eth0.SendRate, eth0.RecvRate)
+	}
+
+	stats := c.SessionStats()
+	if stats.TotalUp != 3000 || stats.TotalDown != 4000 {
+		t.Errorf("session totals = %d/%d, want 3000/4000", stats.TotalUp, stats.TotalDown)
+	}
+}
+
+func TestCollectorMaxConns(t *testing.T) {
+	// Five connections; the higher the index, the busier the connection
+	step := func(scale uint64) platformtest.Step {
+		var socks []platform.MappedSocket
+		for i := 1; i <= 5; i++ {
+			socks = append(socks, platformtest.Conn(model.ProtoTCP, 1, "lb", "127.0.0.1:8080",
+				fmt.Sprintf("127.0.1.%d:9000", i), scale*uint64(i)*1000, 0))
+		}
+		return platformtest.Step{Sockets: socks}
+	}
+	c := New(platformtest.New(step(1), step(2)), time.Second)
+	c.SetMaxConns(2)
+
+	pollOnce(t, c)
+	snap := pollOnce(t, c)
+
+	lb := findProc(snap, 1)
+	if lb == nil {
+		t.Fatal("lb missing from snapshot")
+	}
+	if len(lb.Connections) != 2 || lb.ConnCount != 5 || lb.OmittedConns != 3 {
+		t.Fatalf("got %d conns (count %d, omitted %d), want 2 (5, 3)",
+			len(lb.Connections), lb.ConnCount, lb.OmittedConns)
+	}
+	if lb.Connections[0].DstIP.String() != "127.0.1.5" {
+		t.Errorf("expected busiest connection first, got %v", lb.Connections[0].DstIP)
+	}
+	if lb.OmittedUpRate <= 0 {
+		t.Errorf("expected omitted connections' rate aggregated, got %.0f", lb.OmittedUpRate)
+	}
+}
+
+// slowPlatform delays every Collect to simulate an overrunning poll.
+type slowPlatform struct {
+	platform.Platform
+	delay time.Duration
+}
+
+func (p *slowPlatform) Collect() ([]platform.MappedSocket, []model.InterfaceStats, error) {
+	time.Sleep(p.delay)
+	return p.Platform.Collect()
+}
+
+func TestCollectorSkippedPolls(t *testing.T) {
+	fake := platformtest.New(platformtest.Step{})
+	c := New(&slowPlatform{Platform: fake, delay: 35 * time.Millisecond}, 10*time.Millisecond)
+
+	start := time.Now()
+	snap := pollOnce(t, c)
+	if snap.SkippedPolls < 3 {
+		t.Errorf("SkippedPolls = %d, want >= 3 for a 35ms poll at 10ms interval", snap.SkippedPolls)
+	}
+	if snap.CollectLatency < 35*time.Millisecond {
+		t.Errorf("CollectLatency = %v, want the 35ms poll", snap.CollectLatency)
+	}
+
+	// A tick fired during the slow poll is dropped; one after it is not
+	if !c.lateTick(start.Add(10 * time.Millisecond)) {
+		t.Error("tick queued during poll should be late")
+	}
+	if c.lateTick(time.Now().Add(time.Millisecond)) {
+		t.Error("tick after poll end should not be late")
+	}
+}
+
+func TestCollectorNoSkippedPolls(t *testing.T) {
+	c := New(platformtest.New(platformtest.Step{}), time.Second)
+	if snap := pollOnce(t, c); snap.SkippedPolls != 0 {
+		t.Errorf("SkippedPolls = %d, want 0", snap.SkippedPolls)
+	}
+}
+
+func TestCollectorRefreshNow(t *testing.T) {
+	fake := platformtest.New(platformtest.Step{})
+	c := New(fake, time.Hour)
+	snapCh := c.Start()
+	defer c.Stop()
+
+	select {
+	case <-snapCh: // initial poll
+	case <-time.After(time.Second):
+		t.Fatal("no initial snapshot")
+	}
+
+	time.Sleep(minRefreshGap + 50*time.Millisecond)
+	c.RefreshNow()
+	c.RefreshNow() // coalesced with the pending request
+	select {
+	case <-snapCh:
+	case <-time.After(time.Second):
+		t.Fatal("RefreshNow did not trigger a poll")
+	}
+	if calls := fake.Calls(); calls != 2 {
+		t.Errorf("Collect calls = %d, want 2", calls)
+	}
+}
+
+func TestCollectorRefreshDue(t *testing.T) {
+	c := New(platformtest.New(platformtest.Step{}), time.Second)
+	pollOnce(t, c)
+	if c.refreshDue(time.Now()) {
+		t.Error("refresh right after a poll should be dropped")
+	}
+	if !c.refreshDue(time.Now().Add(minRefreshGap)) {
+		t.Error("refresh after minRefreshGap should run")
+	}
+}
+
+func TestCollectorExitedProcesses(t *testing.T) {
+	conn := func(pid uint32, name string, sent uint64) platform.MappedSocket {
+		return platformtest.Conn(model.ProtoTCP, pid, name, "127.0.0.1:50000", "127.0.0.9:443", sent, 0)
+	}
+	c := New(platformtest.New(
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(30, "wget", 0), conn(31, "idle", 0)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(30, "wget", 5000), conn(31, "idle", 0)}},
+		platformtest.Step{},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(31, "idle", 0)}},
+	), time.Second)
+
+	pollOnce(t, c)
+	pollOnce(t, c)
+	snap := pollOnce(t, c)
+
+	// idle moved no bytes, so only wget is kept
+	if len(snap.Exited) != 1 {
+		t.Fatalf("Exited = %+v, want wget only", snap.Exited)
+	}
+	wget := snap.Exited[0]
+	if wget.PID != 30 || wget.CumUp != 5000 || wget.ExitedAt.IsZero() {
+		t.Errorf("exited wget = %+v", wget)
+	}
+	if wget.Connections != nil || wget.UpRate != 0 {
+		t.Error("exited entry should carry totals only")
+	}
+
+	c.DismissExited(30)
+	if snap := pollOnce(t, c); len(snap.Exited) != 0 {
+		t.Errorf("Exited after dismiss = %+v", snap.Exited)
+	}
+	if stats := c.SessionStats(); stats.TotalUp != 5000 {
+		t.Errorf("session total up = %d, dismissing must not drop it", stats.TotalUp)
+	}
+}
+
+func TestCollectorPIDReuse(t *testing.T) {
+	conn := func(name, local string, sent uint64) platform.MappedSocket {
+		return platformtest.Conn(model.ProtoTCP, 40, name, local, "127.0.0.9:443", sent, 0)
+	}
+	c := New(platformtest.New(
+		platformtest.Step{Sockets: []platform.MappedSocket{conn("rsync", "127.0.0.1:50000", 0)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn("rsync", "127.0.0.1:50000", 3000)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn("ssh", "127.0.0.1:50001", 0)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn("ssh", "127.0.0.1:50001", 1000)}},
+	), time.Second)
+	boot := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	start := boot
+	c.startTime = func(uint32) time.Time { return start }
+
+	pollOnce(t, c)
+	old := pollOnce(t, c)
+	// PID 40 exits and is handed to a new process
+	start = boot.Add(time.Hour)
+	snap := pollOnce(t, c)
+
+	ssh := findProc(snap, 40)
+	if ssh == nil || ssh.Name != "ssh" || ssh.CumUp != 0 || !ssh.StartTime.Equal(start) {
+		t.Fatalf("new process on PID 40 = %+v, want ssh with nothing carried over", ssh)
+	}
+	if !ssh.FirstSeen.After(findProc(old, 40).FirstSeen) {
+		t.Error("FirstSeen carried over from the PID's previous process")
+	}
+	if len(snap.Exited) != 1 || snap.Exited[0].Name != "rsync" || snap.Exited[0].CumUp != 3000 {
+		t.Errorf("Exited = %+v, want rsync with its 3000 bytes", snap.Exited)
+	}
+
+	snap = pollOnce(t, c)
+	if ssh := findProc(snap, 40); ssh.CumUp != 1000 {
+		t.Errorf("ssh CumUp = %d, want its own 1000", ssh.CumUp)
+	}
+	if len(snap.Exited) != 1 {
+		t.Errorf("Exited = %+v, rsync should stay while ssh runs as PID 40", snap.Exited)
+	}
+	got := map[string]uint64{}
+	for _, p := range c.SessionStats().TopProcess {
+		got[p.Name] = p.BytesUp
+	}
+	if want := map[string]uint64{"rsync": 3000, "ssh": 1000}; !reflect.DeepEqual(got, want) {
+		t.Errorf("session top processes = %v, want %v", got, want)
+	}
+}
+
+func TestCollectorRestartKeepsTotals(t *testing.T) {
+	conn := func(pid uint32, local string, sent uint64) platform.MappedSocket {
+		return platformtest.Conn(model.ProtoTCP, pid, "nginx", local, "127.0.0.9:443", sent, 0)
+	}
+	c := New(platformtest.New(
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(50, "127.0.0.1:50000", 0)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(50, "127.0.0.1:50000", 3000)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(51, "127.0.0.1:50001", 0)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(51, "127.0.0.1:50001", 1000)}},
+		platformtest.Step{},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(52, "127.0.0.1:50002", 0)}},
+	), time.Second)
+	boot := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	c.startTime = func(pid uint32) time.Time { return boot.Add(time.Duration(pid) * time.Second) }
+
+	pollOnce(t, c)
+	pollOnce(t, c)
+	// nginx restarts as PID 51
+	snap := pollOnce(t, c)
+	if p := findProc(snap, 51); p == nil || p.CumUp != 0 || p.CarriedUp != 3000 {
+		t.Fatalf("restarted nginx = %+v, want the 3000 bytes carried over", p)
+	}
+	if len(snap.Exited) != 0 {
+		t.Errorf("Exited = %+v, nginx is still running", snap.Exited)
+	}
+
+	snap = pollOnce(t, c)
+	if p := findProc(snap, 51); p.CumUp != 1000 || p.ProgramCumUp() != 4000 {
+		t.Errorf("nginx CumUp = %d of %d, want 1000 of 4000 across both runs", p.CumUp, p.ProgramCumUp())
+	}
+
+	snap = pollOnce(t, c)
+	if len(snap.Exited) != 1 || snap.Exited[0].PID != 51 || snap.Exited[0].ProgramCumUp() != 4000 {
+		t.Errorf("Exited = %+v, want nginx once with 4000 bytes", snap.Exited)
+	}
+	// Starting again takes the exited totals back
+	snap = pollOnce(t, c)
+	if p := findProc(snap, 52); p == nil || p.ProgramCumUp() != 4000 || len(snap.Exited) != 0 {
+		t.Errorf("nginx = %+v, Exited = %+v, want 4000 bytes on PID 52", p, snap.Exited)
+	}
+
+	top := c.SessionStats().TopProcess
+	if len(top) != 1 || top[0].BytesUp != 4000 || top[0].Processes != 2 || top[0].PID != 51 {
+		t.Errorf("session top processes = %+v, want nginx once over 2 processes", top)
+	}
+}
+
+func TestCollectorSocketGap(t *testing.T) {
+	conn := func(sent uint64) platform.MappedSocket {
+		return platformtest.Conn(model.ProtoTCP, 50, "nginx", "127.0.0.1:50000", "127.0.0.9:443", sent, 0)
+	}
+	c := New(platformtest.New(
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(0)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(3000)}},
+		platformtest.Step{},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(3000)}},
+		platformtest.Step{},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(3000)}},
+	), time.Second)
+	boot := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	c.startTime = func(uint32) time.Time { return boot }
+
+	pollOnce(t, c)
+	pollOnce(t, c)
+	// The same process drops its sockets for a poll, twice
+	for range 2 {
+		snap := pollOnce(t, c)
+		if len(snap.Exited) != 1 || snap.Exited[0].ProgramCumUp() != 3000 {
+			t.Errorf("Exited = %+v, want nginx with its 3000 bytes", snap.Exited)
+		}
+		snap = pollOnce(t, c)
+		p := findProc(snap, 50)
+		if p == nil || p.CumUp != 3000 || p.CarriedUp != 0 || len(snap.Exited) != 0 {
+			t.Fatalf("nginx = %+v, Exited = %+v, want its own 3000 bytes, nothing carried", p, snap.Exited)
+		}
+	}
+	if top := c.SessionStats().TopProcess; len(top) != 1 || top[0].BytesUp != 3000 || top[0].Processes != 1 {
+		t.Errorf("session top processes = %+v, want nginx 3000 as 1 process", top)
+	}
+}
+
+func TestCollectorRestartUsage(t *testing.T) {
+	conn := func(pid uint32, local string, sent uint64) platform.MappedSocket {
+		return platformtest.Conn(model.ProtoTCP, pid, "nginx", local, "127.0.0.9:443", sent, 0)
+	}
+	c := New(platformtest.New(
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(50, "127.0.0.1:50000", 0)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(50, "127.0.0.1:50000", 3000)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(51, "127.0.0.1:50001", 0)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(51, "127.0.0.1:50001", 1000)}},
+	), time.Second)
+	boot := time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	c.startTime = func(pid uint32) time.Time { return boot.Add(time.Duration(pid) * time.Second) }
+	store, err := usage.Open(filepath.Join(t.TempDir(), "usage.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var snap model.Snapshot
+	for range 4 {
+		snap = pollOnce(t, c)
+		store.Observe(snap)
+	}
+	// The restart carries 3000 bytes; usage must not count them again
+	procs := store.Processes(usage.Day, snap.Timestamp)
+	if len(procs) != 1 || procs[0].Name != "nginx" || procs[0].Up != 4000 {
+		t.Errorf("usage = %+v, want nginx 4000", procs)
+	}
+}
+
+func TestCollectorUnattributed(t *testing.T) {
+	orphan := func(local string, reason string, sent uint64) platform.MappedSocket {
+		s := platformtest.Conn(model.ProtoTCP, 0, "", local, "127.0.0.9:443", sent, 0)
+		s.Unmapped = reason
+		return s
+	}
+	c := New(platformtest.New(
+		platformtest.Step{Sockets: []platform.MappedSocket{
+			orphan("127.0.0.1:50000", model.UnattributedNoOwner, 0),
+			orphan("127.0.0.1:50001", model.UnattributedHidden, 0),
+			orphan("127.0.0.1:50002", model.UnattributedHidden, 0),
+			orphan("127.0.0.1:50003", "", 0),
+		}},
+		platformtest.Step{Sockets: []platform.MappedSocket{orphan("127.0.0.1:50001", model.UnattributedHidden, 3000)}},
+		platformtest.Step{},
+	), time.Second)
+
+	snap := pollOnce(t, c)
+	p := findProc(snap, model.UnattributedPID)
+	if p == nil || p.Name != model.UnattributedName {
+		t.Fatalf("unattributed row = %+v, want %q", p, model.UnattributedName)
+	}
+	want := map[string]int{model.UnattributedNoOwner: 1, model.UnattributedHidden: 2, model.UnattributedUnknown: 1}
+	if !reflect.DeepEqual(p.Unattributed, want) {
+		t.Errorf("reasons = %v, want %v", p.Unattributed, want)
+	}
+
+	pollOnce(t, c)
+	if snap := pollOnce(t, c); len(snap.Exited) != 0 {
+		t.Errorf("Exited = %+v, the unattributed row is not a process", snap.Exited)
+	}
+	if stats := c.SessionStats(); stats.TotalUp != 3000 {
+		t.Errorf("session total up = %d, want the unattributed 3000", stats.TotalUp)
+	}
+}
+
+func TestCollectorProcessRates(t *testing.T) {
+	conn := func(pid uint32, name string, sent, recv uint64) platform.MappedSocket {
+		return platformtest.Conn(model.ProtoTCP, pid, name, "127.0.0.1:50000", "127.0.0.9:443", sent, recv)
+	}
+	c := New(platformtest.New(
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(30, "wget", 0, 0)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(30, "wget", 0, 0), conn(31, "curl", 0, 0)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(30, "wget", 1000, 4000), conn(31, "curl", 0, 0)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(31, "curl", 0, 0)}},
+	), time.Second)
+
+	pollOnce(t, c)
+	pollOnce(t, c)
+	snap := pollOnce(t, c)
+	wget := findProc(snap, 30)
+
+	rates := c.ProcessRates(30)
+	if len(rates.Up) != 3 || len(rates.Down) != 3 || len(rates.Times) != 3 {
+		t.Fatalf("wget rates = %+v, want 3 polls", rates)
+	}
+	if rates.Up[2] != wget.UpRate || rates.Down[2] != wget.DownRate || rates.Down[2] == 0 {
+		t.Errorf("last sample = %.0f/%.0f, want the snapshot's %.0f/%.0f",
+			rates.Up[2], rates.Down[2], wget.UpRate, wget.DownRate)
+	}
+	if !rates.Times[0].Before(rates.Times[2]) {
+		t.Errorf("times = %v, want oldest first", rates.Times)
+	}
+
+	// curl appeared a poll later: its samples line up with the newest times
+	if curl := c.ProcessRates(31); len(curl.Up) != 2 || !curl.Times[0].Equal(rates.Times[1]) {
+		t.Errorf("curl rates = %+v, want the last 2 polls", curl)
+	}
+
+	pollOnce(t, c)
+	if rates := c.ProcessRates(30); len(rates.Up) != 0 {
+		t.Errorf("rates after wget exited = %+v, want none", rates)
+	}
+}
+
+func TestCollectorAvgPeakRate(t *testing.T) {
+	conn := func(sent uint64) platform.MappedSocket {
+		return platformtest.Conn(model.ProtoTCP, 30, "wget", "127.0.0.1:50000", "127.0.0.9:443", sent, 0)
+	}
+	c := New(platformtest.New(
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(0)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(3000)}},
+		platformtest.Step{Sockets: []platform.MappedSocket{conn(3000)}},
+	), time.Second)
+
+	pollOnce(t, c)
+	pollOnce(t, c)
+	snap := pollOnce(t, c)
+	wget := findProc(snap, 30)
+	rates := c.ProcessRates(30)
+	if len(rates.Up) != 3 {
+		t.Fatalf("rates = %+v, want 3 polls", rates)
+	}
+	// Rates are smoothed: the burst fades over the following poll
+	peak := max(rates.Up[0], rates.Up[1], rates.Up[2])
+	avg := (rates.Up[0] + rates.Up[1] + rates.Up[2]) / 3
+	if peak == 0 || peak == wget.UpRate {
+		t.Fatalf("rates = %v, want a burst then a fall", rates.Up)
+	}
+	if wget.PeakRate != peak || wget.AvgRate != avg {
+		t.Errorf("avg %.1f peak %.1f, want %.1f and %.1f", wget.AvgRate, wget.PeakRate, avg, peak)
+	}
+
+	// Polls older than a minute drop out of the window
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	times := c.pollTimes.Samples()
+	newest := time.Unix(0, int64(times[len(times)-1]*1e9))
+	if n := c.pollsSince(newest.Add(-time.Nanosecond)); n != 1 {
+		t.Errorf("polls since just before the last = %d, want 1", n)
+	}
+}
@@ -8,8 +8,12 @@ import (
 )
 
 const (
-	dnsCacheTTL     = 5 * time.Minute
+	dnsCacheTTL      = 5 * time.Minute
+	dnsNegativeTTL   = time.Minute // retry failed lookups sooner
 	dnsLookupTimeout = 2 * time.Second
+	dnsLearnedMinTTL = time.Hour // sniffed names outlive short DNS TTLs
+	dnsWorkers       = 4
+	dnsQueueSize     = 256
 	maxCacheSize     = 4096
 )
 
@@ -18,22 +22,45 @@ type dnsEntry struct {
 	expires time.Time
 }
 
-// DNSCache provides async, cached reverse DNS resolution.
+// DNSCache provides async, cached reverse DNS resolution. Lookups run on a
+// small worker pool fed by a bounded queue, so Resolve never blocks: names
+// show up in the snapshots after they resolve.
 type DNSCache struct {
 	mu      sync.RWMutex
 	cache   map[string]dnsEntry
-	pending sync.Map // tracks in-flight lookups to avoid duplicates
+	learned map[string]dnsEntry // names applications looked up; win over PTR
+	pending map[string]bool     // queued or in-flight lookups, guarded by mu
+
+	queue     chan string
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+
+	// lookupAddr is net.Resolver.LookupAddr; replaced in tests.
+	lookupAddr func(ctx context.Context, addr string) ([]string, error)
 }
 
-// NewDNSCache creates a new DNS cache.
+// NewDNSCache creates a new DNS cache. Workers start on the first lookup.
 func NewDNSCache() *DNSCache {
 	return &DNSCache{
-		cache: make(map[string]dnsEntry),
+		cache:      make(map[string]dnsEntry),
+		learned:    make(map[string]dnsEntry),
+		pending:    make(map[string]bool),
+		queue:      make(chan string, dnsQueueSize),
+		stopCh:     make(chan struct{}),
+		lookupAddr: (&net.Resolver{}).LookupAddr,
 	}
 }
 
+// Close stops the workers. Lookups in flight finish but are not cached.
+func (d *DNSCache) Close() {
+	d.stopOnce.Do(func() {
+		close(d.stopCh)
+	})
+}
+
 // Resolve returns the cached hostname for an IP, or empty string if not cached.
-// It kicks off an async lookup if the IP is not in cache.
+// It queues a lookup if the IP is not in cache or its entry has expired.
 func (d *DNSCache) Resolve(ip net.IP) string {
 	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() {
 		return ""
@@ -42,73 +69,136 @@ func (d *DNSCache) Resolve(ip net.IP) string {
 	ipStr := ip.String()
 
 	d.mu.RLock()
+	if l, ok := d.learned[ipStr]; ok && (l.expires.IsZero() || time.Now().Before(l.expires)) {
+		d.mu.RUnlock()
+		return l.host
+	}
 	entry, ok := d.cache[ipStr]
+	queued := d.pending[ipStr]
 	d.mu.RUnlock()
 
-	if ok {
-		if time.Now().Before(entry.expires) {
-			return entry.host
-		}
-		// Expired — trigger refresh
+	if ok && time.Now().Before(entry.expires) {
+		return entry.host
 	}
+	if !queued {
+		d.enqueue(ipStr)
+	}
+	return entry.host // stale while refreshing, "" if never resolved
+}
 
-	// Async lookup (fire and forget, deduplicated)
-	if _, loaded := d.pending.LoadOrStore(ipStr, true); !loaded {
-		go d.lookup(ipStr)
+// Learn records the name an application resolved ip from (a sniffed DNS
+// answer or a hosts file entry). It is preferred over reverse DNS, which
+// for CDNs and clouds names the provider instead. ttl <= 0 never expires;
+// shorter TTLs are stretched, since connections outlive them.
+func (d *DNSCache) Learn(ip net.IP, name string, ttl time.Duration) {
+	if ip == nil || name == "" {
+		return
+	}
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(max(ttl, dnsLearnedMinTTL))
 	}
 
-	if ok {
-		return entry.host // return stale while refreshing
+	ipStr := ip.String()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.learned[ipStr]; !ok && len(d.learned) >= maxCacheSize {
+		evictOldest(d.learned)
 	}
-	return ""
+	d.learned[ipStr] = dnsEntry{host: name, expires: expires}
 }
 
-func (d *DNSCache) lookup(ipStr string) {
-	defer d.pending.Delete(ipStr)
+// enqueue hands ipStr to the workers. A full queue drops the request; the
+// next poll asks again.
+func (d *DNSCache) enqueue(ipStr string) {
+	d.startOnce.Do(func() {
+		for i := 0; i < dnsWorkers; i++ {
+			go d.worker()
+		}
+	})
 
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.pending[ipStr] {
+		return
+	}
+	select {
+	case d.queue <- ipStr:
+		d.pending[ipStr] = true
+	default:
+	}
+}
+
+func (d *DNSCache) worker() {
+	for {
+		select {
+		case <-d.stopCh:
+			return
+		case ipStr := <-d.queue:
+			d.lookup(ipStr)
+		}
+	}
+}
+
+func (d *DNSCache) lookup(ipStr string) {
 	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
 	defer cancel()
 
-	resolver := &net.Resolver{}
-	names, err := resolver.LookupAddr(ctx, ipStr)
+	names, err := d.lookupAddr(ctx, ipStr)
 
 	host := ""
+	ttl := dnsNegativeTTL
 	if err == nil && len(names) > 0 {
 		host = names[0]
 		// Remove trailing dot
 		if len(host) > 0 && host[len(host)-1] == '.' {
 			host = host[:len(host)-1]
 		}
+		ttl = dnsCacheTTL
 	}
 
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	delete(d.pending, ipStr)
+
+	select {
+	case <-d.stopCh:
+		return
+	default:
+	}
 
 	// Evict if cache is too large
-	if len(d.cache) >= maxCacheSize {
-		d.evictOldest()
+	if _, ok := d.cache[ipStr]; !ok && len(d.cache) >= maxCacheSize {
+		evictOldest(d.cache)
 	}
 
 	d.cache[ipStr] = dnsEntry{
 		host:    host,
-		expires: time.Now().Add(dnsCacheTTL),
+		expires: time.Now().Add(ttl),
 	}
 }
 
-func (d *DNSCache) evictOldest() {
-	var oldestKey string
+// evictOldest drops the entry expiring first; entries that never expire go
+// last.
+func evictOldest(cache map[string]dnsEntry) {
+	var oldestKey, permanentKey string
 	var oldestTime time.Time
-	first := true
 
-	for k, v := range d.cache {
-		if first || v.expires.Before(oldestTime) {
+	for k, v := range cache {
+		if v.expires.IsZero() {
+			permanentKey = k
+			continue
+		}
+		if oldestKey == "" || v.expires.Before(oldestTime) {
 			oldestKey = k
 			oldestTime = v.expires
-			first = false
 		}
 	}
 
+	if oldestKey == "" {
+		oldestKey = permanentKey
+	}
 	if oldestKey != "" {
-		delete(d.cache, oldestKey)
+		delete(cache, oldestKey)
 	}
 }
@@ -0,0 +1,134 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// waitResolve polls Resolve until it returns want or the deadline passes.
+func waitResolve(t *testing.T, d *DNSCache, ip net.IP, want string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if got := d.Resolve(ip); got == want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Resolve(%s) never returned %q", ip, want)
+}
+
+func TestDNSCacheNonBlocking(t *testing.T) {
+	d := NewDNSCache()
+	defer d.Close()
+
+	release := make(chan struct{})
+	d.lookupAddr = func(ctx context.Context, addr string) ([]string, error) {
+		<-release
+		return []string{"host.example."}, nil
+	}
+
+	ip := net.ParseIP("203.0.113.7")
+	start := time.Now()
+	if got := d.Resolve(ip); got != "" {
+		t.Errorf("first Resolve = %q, want empty while pending", got)
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Error("Resolve blocked on the lookup")
+	}
+	close(release)
+	waitResolve(t, d, ip, "host.example")
+}
+
+func TestDNSCacheNegativeEntry(t *testing.T) {
+	d := NewDNSCache()
+	defer d.Close()
+
+	calls := make(chan string, 10)
+	d.lookupAddr = func(ctx context.Context, addr string) ([]string, error) {
+		calls <- addr
+		return nil, errors.New("nxdomain")
+	}
+
+	ip := net.ParseIP("198.51.100.1")
+	d.Resolve(ip)
+	<-calls
+	// Wait for the failure to be cached
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		d.mu.RLock()
+		entry, ok := d.cache[ip.String()]
+		d.mu.RUnlock()
+		if ok {
+			if ttl := time.Until(entry.expires); ttl > dnsNegativeTTL {
+				t.Errorf("negative entry ttl = %v, want <= %v", ttl, dnsNegativeTTL)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("failed lookup was not cached")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	d.Resolve(ip)
+	select {
+	case <-calls:
+		t.Error("cached failure should not be looked up again before expiry")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestDNSCacheQueueFull(t *testing.T) {
+	d := NewDNSCache()
+	defer d.Close()
+
+	block := make(chan struct{})
+	defer close(block)
+	d.lookupAddr = func(ctx context.Context, addr string) ([]string, error) {
+		<-block
+		return nil, nil
+	}
+
+	// Workers stall; the queue fills and the rest are dropped, not blocked on
+	for i := 0; i < dnsWorkers+dnsQueueSize+50; i++ {
+		d.Resolve(net.IPv4(10, 1, byte(i>>8), byte(i)))
+	}
+	d.mu.RLock()
+	pending := len(d.pending)
+	d.mu.RUnlock()
+	if pending > dnsWorkers+dnsQueueSize {
+		t.Errorf("pending = %d, want at most %d", pending, dnsWorkers+dnsQueueSize)
+	}
+}
+
+func TestDNSCacheLearnedNamesWin(t *testing.T) {
+	d := NewDNSCache()
+	defer d.Close()
+	d.lookupAddr = func(ctx context.Context, addr string) ([]string, error) {
+		return []string{"server-203-0-113-10.cdn.example."}, nil
+	}
+
+	ip := net.ParseIP("203.0.113.10")
+	waitResolve(t, d, ip, "server-203-0-113-10.cdn.example")
+
+	d.Learn(ip, "static.example.com", 30*time.Second)
+	if got := d.Resolve(ip); got != "static.example.com" {
+		t.Errorf("Resolve = %q, want the sniffed name", got)
+	}
+	d.mu.RLock()
+	ttl := time.Until(d.learned[ip.String()].expires)
+	d.mu.RUnlock()
+	if ttl < dnsLearnedMinTTL-time.Minute {
+		t.Errorf("learned ttl = %v, want stretched to %v", ttl, dnsLearnedMinTTL)
+	}
+
+	// Hosts file entries never expire
+	d.Learn(net.ParseIP("10.0.0.5"), "db.internal", 0)
+	if got := d.Resolve(net.ParseIP("10.0.0.5")); got != "db.internal" {
+		t.Errorf("Resolve(hosts entry) = %q", got)
+	}
+}
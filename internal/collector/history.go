@@ -3,6 +3,10 @@ package collector
 // SparklineLen is the default number of samples kept for sparkline display.
 const SparklineLen = 16
 
+// ProcRatesLen is the number of polls of per-process upload and download
+// rates kept for the detail view's chart: 5 minutes at 1s interval.
+const ProcRatesLen = 300
+
 // RingBuffer is a fixed-size circular buffer of float64 values.
 type RingBuffer struct {
 	data  []float64
@@ -41,6 +45,20 @@ func (r *RingBuffer) Push(v float64) {
 	}
 }
 
+// Recent returns up to the n newest samples, oldest first.
+func (r *RingBuffer) Recent(n int) []float64 {
+	n = min(n, r.count)
+	if n <= 0 {
+		return nil
+	}
+	result := make([]float64, n)
+	start := (r.head - n + r.size) % r.size
+	for i := 0; i < n; i++ {
+		result[i] = r.data[(start+i)%r.size]
+	}
+	return result
+}
+
 // Samples returns all valid samples in chronological order (oldest first).
 func (r *RingBuffer) Samples() []float64 {
 	if r.count == 0 {
@@ -0,0 +1,23 @@
+package collector
+
+import "github.com/googlesky/sstop/internal/model"
+
+// classifyKind tells a desktop user's apps from the system's processes. A
+// controlling terminal means run from a shell; a desktop app unit means
+// launched by the session; any other systemd service is a daemon. Outside
+// those (an X11 session without app units), a process that can reach the
+// display is an app. displayEnv is only read in that last case, as reading
+// a process's environment costs more than the rest.
+func classifyKind(tty, desktopApp bool, service string, displayEnv func() bool) string {
+	switch {
+	case tty:
+		return model.KindShell
+	case desktopApp:
+		return model.KindApp
+	case service != "":
+		return model.KindDaemon
+	case displayEnv():
+		return model.KindApp
+	}
+	return model.KindDaemon
+}
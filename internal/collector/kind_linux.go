@@ -0,0 +1,12 @@
+//go:build linux
+
+package collector
+
+import "github.com/googlesky/sstop/internal/platform"
+
+// readKind classifies a process as an app, a daemon or a shell child.
+func readKind(pid uint32) string {
+	cg := platform.ReadCgroup(pid)
+	return classifyKind(platform.ReadTTY(pid), cg.DesktopApp, cg.ServiceName,
+		func() bool { return platform.ReadDisplayEnv(pid) })
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package collector
+
+func readKind(_ uint32) string {
+	return ""
+}
@@ -0,0 +1,36 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestClassifyKind(t *testing.T) {
+	tests := []struct {
+		name       string
+		tty        bool
+		desktopApp bool
+		service    string
+		display    bool
+		want       string
+	}{
+		{"shell in a terminal", true, false, "", true, model.KindShell},
+		{"terminal-launched in an app scope", true, true, "user@1000.service", true, model.KindShell},
+		{"desktop app unit", false, true, "user@1000.service", true, model.KindApp},
+		{"system service", false, false, "nginx.service", false, model.KindDaemon},
+		{"user service with the display in its environment", false, false, "user@1000.service", true, model.KindDaemon},
+		{"X11 session without app units", false, false, "", true, model.KindApp},
+		{"background process", false, false, "", false, model.KindDaemon},
+	}
+	for _, tt := range tests {
+		read := false
+		got := classifyKind(tt.tty, tt.desktopApp, tt.service, func() bool { read = true; return tt.display })
+		if got != tt.want {
+			t.Errorf("%s: kind %q, want %q", tt.name, got, tt.want)
+		}
+		if read && (tt.tty || tt.desktopApp || tt.service != "") {
+			t.Errorf("%s: read the environment when the cgroup or terminal already decided", tt.name)
+		}
+	}
+}
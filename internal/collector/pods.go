@@ -0,0 +1,178 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultKubeletURL is the pod list on the kubelet's read-only port.
+	DefaultKubeletURL = "http://127.0.0.1:10255/pods"
+
+	podListTTL     = 30 * time.Second // refresh interval while pods are unknown
+	podListBackoff = time.Minute      // retry interval when the kubelet doesn't answer
+	podListTimeout = 2 * time.Second
+)
+
+// podRef identifies a process's Kubernetes pod, as read from its cgroup.
+type podRef struct {
+	uid         string
+	containerID string // full CRI container ID; "" for the pod cgroup itself
+}
+
+type podInfo struct {
+	name      string
+	namespace string
+}
+
+// podBundles are the OCI bundle configs containerd and CRI-O keep per
+// container (the state behind their CRI sockets); %s is the container ID.
+var podBundles = []string{
+	"/run/containerd/io.containerd.runtime.v2.task/k8s.io/%s/config.json",
+	"/run/containers/storage/overlay-containers/%s/userdata/config.json",
+}
+
+// podResolver names the Kubernetes pods processes run in. The runtime's
+// bundle annotations are read directly; the kubelet pod list covers other
+// runtimes and is fetched in the background so it never stalls a poll.
+type podResolver struct {
+	bundles    []string
+	kubeletURL string // "" = bundle annotations only
+	client     *http.Client
+
+	mu          sync.Mutex
+	byUID       map[string]podInfo
+	byContainer map[string]podInfo // zero value: no bundle found
+	nextFetch   time.Time
+	refreshing  bool
+}
+
+func newPodResolver() *podResolver {
+	return &podResolver{
+		bundles:     podBundles,
+		kubeletURL:  DefaultKubeletURL,
+		client:      &http.Client{Timeout: podListTimeout},
+		byUID:       make(map[string]podInfo),
+		byContainer: make(map[string]podInfo),
+	}
+}
+
+// resolve returns the pod name and namespace for ref, or "" while unknown.
+// Processes outside Kubernetes cost nothing.
+func (r *podResolver) resolve(ref podRef, now time.Time) (name, namespace string) {
+	if ref.uid == "" {
+		return "", ""
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p, ok := r.byUID[ref.uid]; ok {
+		return p.name, p.namespace
+	}
+	if ref.containerID != "" {
+		p, seen := r.byContainer[ref.containerID]
+		if !seen {
+			if len(r.byContainer) >= maxCacheSize {
+				clear(r.byContainer) // container churn; entries are cheap to redo
+			}
+			p = r.readBundle(ref.containerID)
+			r.byContainer[ref.containerID] = p
+		}
+		if p.name != "" {
+			r.byUID[ref.uid] = p
+			return p.name, p.namespace
+		}
+	}
+	if r.kubeletURL != "" && !r.refreshing && !now.Before(r.nextFetch) {
+		r.refreshing = true
+		go r.refresh()
+	}
+	return "", ""
+}
+
+// readBundle reads the pod annotations from a container's bundle config.
+func (r *podResolver) readBundle(containerID string) podInfo {
+	for _, pattern := range r.bundles {
+		data, err := os.ReadFile(fmt.Sprintf(pattern, containerID))
+		if err != nil {
+			continue
+		}
+		var cfg struct {
+			Annotations map[string]string `json:"annotations"`
+		}
+		if json.Unmarshal(data, &cfg) != nil {
+			continue
+		}
+		a := cfg.Annotations
+		// containerd, then CRI-O
+		if name := a["io.kubernetes.cri.sandbox-name"]; name != "" {
+			return podInfo{name: name, namespace: a["io.kubernetes.cri.sandbox-namespace"]}
+		}
+		if name := a["io.kubernetes.pod.name"]; name != "" {
+			return podInfo{name: name, namespace: a["io.kubernetes.pod.namespace"]}
+		}
+	}
+	return podInfo{}
+}
+
+func (r *podResolver) refresh() {
+	pods, err := r.fetch()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshing = false
+	if err != nil {
+		r.nextFetch = time.Now().Add(podListBackoff)
+		return
+	}
+	if len(r.byUID)+len(pods) > maxCacheSize {
+		clear(r.byUID)
+	}
+	for uid, p := range pods {
+		r.byUID[uid] = p
+	}
+	r.nextFetch = time.Now().Add(podListTTL)
+}
+
+func (r *podResolver) fetch() (map[string]podInfo, error) {
+	resp, err := r.client.Get(r.kubeletURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", r.kubeletURL, resp.Status)
+	}
+	return parsePodList(resp.Body)
+}
+
+// parsePodList reads a kubelet /pods response (a v1 PodList) into pod UID
+// → name and namespace.
+func parsePodList(rd io.Reader) (map[string]podInfo, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+				UID       string `json:"uid"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(rd).Decode(&list); err != nil {
+		return nil, err
+	}
+	pods := make(map[string]podInfo, len(list.Items))
+	for _, item := range list.Items {
+		m := item.Metadata
+		if m.UID == "" || m.Name == "" {
+			continue
+		}
+		pods[m.UID] = podInfo{name: m.Name, namespace: m.Namespace}
+	}
+	return pods, nil
+}
@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const podListJSON = `{"kind": "PodList", "items": [
+  {"metadata": {"name": "web-7d4b9c", "namespace": "shop", "uid": "0f3a-12b4"}},
+  {"metadata": {"name": "", "namespace": "kube-system", "uid": "ffff"}}
+]}`
+
+func TestParsePodList(t *testing.T) {
+	got, err := parsePodList(strings.NewReader(podListJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d pods, want 1 (unnamed skipped): %+v", len(got), got)
+	}
+	if p := got["0f3a-12b4"]; p.name != "web-7d4b9c" || p.namespace != "shop" {
+		t.Errorf("pod = %+v", p)
+	}
+}
+
+func TestPodResolverBundleAnnotations(t *testing.T) {
+	dir := t.TempDir()
+	write := func(id, annotations string) {
+		path := filepath.Join(dir, id, "config.json")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(`{"annotations": {`+annotations+`}}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("c1", `"io.kubernetes.cri.sandbox-name": "api-0", "io.kubernetes.cri.sandbox-namespace": "prod"`)
+	write("c2", `"io.kubernetes.pod.name": "dns-x", "io.kubernetes.pod.namespace": "kube-system"`)
+
+	r := newPodResolver()
+	r.bundles = []string{filepath.Join(dir, "%s", "config.json")}
+	r.kubeletURL = ""
+	now := time.Now()
+
+	if name, ns := r.resolve(podRef{uid: "u1", containerID: "c1"}, now); name != "api-0" || ns != "prod" {
+		t.Errorf("containerd bundle = %q/%q, want prod/api-0", ns, name)
+	}
+	if name, ns := r.resolve(podRef{uid: "u2", containerID: "c2"}, now); name != "dns-x" || ns != "kube-system" {
+		t.Errorf("CRI-O bundle = %q/%q, want kube-system/dns-x", ns, name)
+	}
+	// Another process in the same pod without a container ID (pod cgroup)
+	if name, _ := r.resolve(podRef{uid: "u1"}, now); name != "api-0" {
+		t.Errorf("pod-level process = %q, want api-0 from the UID cache", name)
+	}
+	if name, _ := r.resolve(podRef{uid: "u3", containerID: "missing"}, now); name != "" {
+		t.Errorf("no bundle resolved to %q", name)
+	}
+	if name, _ := r.resolve(podRef{}, now); name != "" {
+		t.Errorf("non-pod process resolved to %q", name)
+	}
+}
+
+func TestPodResolverKubelet(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits++
+		w.Write([]byte(podListJSON))
+	}))
+	defer srv.Close()
+
+	r := newPodResolver()
+	r.bundles = nil
+	r.kubeletURL = srv.URL
+	ref := podRef{uid: "0f3a-12b4", containerID: "abc"}
+
+	// The first lookup starts a background fetch and returns nothing yet
+	if name, _ := r.resolve(ref, time.Now()); name != "" {
+		t.Fatalf("first resolve = %q, want empty while fetching", name)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		name, ns := r.resolve(ref, time.Now())
+		if name == "web-7d4b9c" && ns == "shop" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pod never resolved, got %q/%q", ns, name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Unknown pods don't refetch before the TTL
+	r.resolve(podRef{uid: "other"}, time.Now())
+	r.mu.Lock()
+	refreshing := r.refreshing
+	r.mu.Unlock()
+	if refreshing || hits != 1 {
+		t.Errorf("refetched within TTL: refreshing=%v hits=%d", refreshing, hits)
+	}
+}
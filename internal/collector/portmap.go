@@ -0,0 +1,227 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+const (
+	portMapTTL     = 10 * time.Second // refresh interval while the API answers
+	portMapBackoff = time.Minute      // retry interval when no API socket answers
+	portMapTimeout = 2 * time.Second
+)
+
+// portMapping is a published container port: host address → container port.
+type portMapping struct {
+	proto         model.Protocol
+	hostIP        net.IP // nil or unspecified = all addresses
+	hostPort      uint16
+	container     string
+	containerPort uint16
+}
+
+// portMapper resolves container port publishing from the Docker (or
+// Podman-compatible) Engine API over its unix socket. Lookups run in the
+// background so a slow or missing daemon never stalls a poll.
+type portMapper struct {
+	sockets []string
+	client  func(socket string) *http.Client
+
+	mu         sync.Mutex
+	mappings   []portMapping
+	nextFetch  time.Time
+	refreshing bool
+}
+
+// newPortMapper returns a mapper trying $DOCKER_HOST (if unix://), then the
+// standard Docker and rootful Podman sockets.
+func newPortMapper() *portMapper {
+	var sockets []string
+	if host := os.Getenv("DOCKER_HOST"); strings.HasPrefix(host, "unix://") {
+		sockets = append(sockets, strings.TrimPrefix(host, "unix://"))
+	}
+	sockets = append(sockets, "/var/run/docker.sock", "/run/podman/podman.sock")
+	return &portMapper{sockets: sockets, client: unixHTTPClient}
+}
+
+func unixHTTPClient(socket string) *http.Client {
+	return &http.Client{
+		Timeout: portMapTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+}
+
+// current returns the latest known mappings, starting a background refresh
+// when they are stale.
+func (pm *portMapper) current(now time.Time) []portMapping {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if !pm.refreshing && !now.Before(pm.nextFetch) {
+		pm.refreshing = true
+		go pm.refresh()
+	}
+	return pm.mappings
+}
+
+func (pm *portMapper) refresh() {
+	mappings, err := pm.fetch()
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.refreshing = false
+	if err != nil {
+		pm.mappings = nil
+		pm.nextFetch = time.Now().Add(portMapBackoff)
+		return
+	}
+	pm.mappings = mappings
+	pm.nextFetch = time.Now().Add(portMapTTL)
+}
+
+// fetch queries the first socket that exists.
+func (pm *portMapper) fetch() ([]portMapping, error) {
+	for _, socket := range pm.sockets {
+		if _, err := os.Stat(socket); err != nil {
+			continue
+		}
+		resp, err := pm.client(socket).Get("http://localhost/containers/json")
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s: %s", socket, resp.Status)
+		}
+		return parseContainerPorts(resp.Body)
+	}
+	return nil, os.ErrNotExist
+}
+
+// parseContainerPorts reads a /containers/json response, keeping only
+// ports published on the host.
+func parseContainerPorts(r io.Reader) ([]portMapping, error) {
+	var containers []struct {
+		ID    string   `json:"Id"`
+		Names []string `json:"Names"`
+		Ports []struct {
+			IP          string `json:"IP"`
+			PrivatePort uint16 `json:"PrivatePort"`
+			PublicPort  uint16 `json:"PublicPort"`
+			Type        string `json:"Type"`
+		} `json:"Ports"`
+	}
+	if err := json.NewDecoder(r).Decode(&containers); err != nil {
+		return nil, err
+	}
+
+	var out []portMapping
+	for _, c := range containers {
+		name := c.ID
+		if len(name) > 12 {
+			name = name[:12]
+		}
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		for _, p := range c.Ports {
+			if p.PublicPort == 0 {
+				continue // exposed, not published
+			}
+			proto := model.ProtoTCP
+			if p.Type == "udp" {
+				proto = model.ProtoUDP
+			} else if p.Type != "tcp" {
+				continue
+			}
+			out = append(out, portMapping{
+				proto:         proto,
+				hostIP:        net.ParseIP(p.IP),
+				hostPort:      p.PublicPort,
+				container:     name,
+				containerPort: p.PrivatePort,
+			})
+		}
+	}
+	return out, nil
+}
+
+// matches reports whether the mapping covers a listener on ip.
+func (m *portMapping) matches(proto model.Protocol, ip net.IP, port uint16) bool {
+	if m.proto != proto || m.hostPort != port {
+		return false
+	}
+	if m.hostIP == nil || m.hostIP.IsUnspecified() || ip == nil || ip.IsUnspecified() {
+		return true
+	}
+	return m.hostIP.Equal(ip)
+}
+
+// applyPortMappings annotates listeners that forward into a container, and
+// adds entries for published ports no process listens on (NAT-only
+// publishing, e.g. Docker with the userland proxy disabled).
+func applyPortMappings(ports []model.ListenPortEntry, mappings []portMapping) []model.ListenPortEntry {
+	if len(mappings) == 0 {
+		return ports
+	}
+	used := make([]bool, len(mappings))
+	for i := range ports {
+		lp := &ports[i]
+		for j := range mappings {
+			m := &mappings[j]
+			if m.matches(lp.Proto, lp.IP, lp.Port) {
+				lp.Container = m.container
+				lp.ContainerPort = m.containerPort
+				used[j] = true
+				break
+			}
+		}
+	}
+
+	type natKey struct {
+		proto model.Protocol
+		port  uint16
+	}
+	// Docker publishes 0.0.0.0 and :: separately; show one row per port
+	shown := make(map[natKey]bool)
+	for j := range mappings {
+		if used[j] {
+			shown[natKey{mappings[j].proto, mappings[j].hostPort}] = true
+		}
+	}
+	for j := range mappings {
+		m := &mappings[j]
+		k := natKey{m.proto, m.hostPort}
+		if shown[k] {
+			continue
+		}
+		shown[k] = true
+		ip := m.hostIP
+		if ip == nil {
+			ip = net.IPv4zero
+		}
+		ports = append(ports, model.ListenPortEntry{
+			Proto:         m.proto,
+			IP:            ip,
+			Port:          m.hostPort,
+			Process:       "(nat)",
+			Container:     m.container,
+			ContainerPort: m.containerPort,
+		})
+	}
+	return ports
+}
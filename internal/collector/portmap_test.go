@@ -0,0 +1,120 @@
+package collector
+
+import (
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+const containersJSON = `[
+  {"Id": "4f1c2a9e8b7d6c5a", "Names": ["/web"], "Ports": [
+    {"IP": "0.0.0.0", "PrivatePort": 80, "PublicPort": 8080, "Type": "tcp"},
+    {"IP": "::", "PrivatePort": 80, "PublicPort": 8080, "Type": "tcp"},
+    {"PrivatePort": 443, "Type": "tcp"}
+  ]},
+  {"Id": "9a8b7c6d5e4f3a2b1c0d", "Names": [], "Ports": [
+    {"IP": "127.0.0.1", "PrivatePort": 53, "PublicPort": 5353, "Type": "udp"},
+    {"IP": "0.0.0.0", "PrivatePort": 9, "PublicPort": 9, "Type": "sctp"}
+  ]}
+]`
+
+func TestParseContainerPorts(t *testing.T) {
+	got, err := parseContainerPorts(strings.NewReader(containersJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Exposed-only and non-TCP/UDP ports are dropped
+	if len(got) != 3 {
+		t.Fatalf("got %d mappings, want 3: %+v", len(got), got)
+	}
+	if got[0].container != "web" || got[0].hostPort != 8080 || got[0].containerPort != 80 {
+		t.Errorf("mapping[0] = %+v", got[0])
+	}
+	dns := got[2]
+	if dns.container != "9a8b7c6d5e4f" || dns.proto != model.ProtoUDP || !dns.hostIP.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("unnamed container mapping = %+v, want short ID, UDP, 127.0.0.1", dns)
+	}
+}
+
+func TestApplyPortMappings(t *testing.T) {
+	mappings, _ := parseContainerPorts(strings.NewReader(containersJSON))
+	ports := []model.ListenPortEntry{
+		{Proto: model.ProtoTCP, IP: net.IPv4zero, Port: 8080, PID: 900, Process: "docker-proxy"},
+		{Proto: model.ProtoTCP, IP: net.IPv6unspecified, Port: 8080, PID: 901, Process: "docker-proxy"},
+		{Proto: model.ProtoTCP, IP: net.IPv4zero, Port: 22, PID: 1, Process: "sshd"},
+	}
+
+	got := applyPortMappings(ports, mappings)
+
+	for _, i := range []int{0, 1} {
+		if got[i].Container != "web" || got[i].ContainerPort != 80 {
+			t.Errorf("docker-proxy[%d] = %q:%d, want web:80", i, got[i].Container, got[i].ContainerPort)
+		}
+	}
+	if got[2].Container != "" {
+		t.Errorf("sshd annotated with %q", got[2].Container)
+	}
+	// 127.0.0.1:5353/udp has no listener (NAT-only publishing)
+	if len(got) != 4 {
+		t.Fatalf("got %d entries, want 4 (one NAT-only): %+v", len(got), got)
+	}
+	nat := got[3]
+	if nat.Process != "(nat)" || nat.PID != 0 || nat.Port != 5353 || nat.Container != "9a8b7c6d5e4f" {
+		t.Errorf("NAT-only entry = %+v", nat)
+	}
+}
+
+func TestApplyPortMappingsHostIP(t *testing.T) {
+	mappings := []portMapping{{
+		proto: model.ProtoTCP, hostIP: net.ParseIP("127.0.0.1"), hostPort: 5432,
+		container: "db", containerPort: 5432,
+	}}
+	ports := []model.ListenPortEntry{
+		{Proto: model.ProtoTCP, IP: net.ParseIP("10.0.0.5"), Port: 5432, Process: "postgres"},
+	}
+	got := applyPortMappings(ports, mappings)
+	if got[0].Container != "" {
+		t.Error("mapping on 127.0.0.1 should not annotate a listener on 10.0.0.5")
+	}
+	if len(got) != 2 || got[1].Container != "db" {
+		t.Errorf("want a NAT-only row for 127.0.0.1:5432, got %+v", got)
+	}
+}
+
+func TestPortMapperFetch(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/containers/json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(containersJSON))
+	})}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	pm := &portMapper{
+		sockets: []string{filepath.Join(t.TempDir(), "missing.sock"), socket},
+		client:  unixHTTPClient,
+	}
+	got, err := pm.fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 {
+		t.Errorf("fetched %d mappings, want 3", len(got))
+	}
+
+	pm.sockets = pm.sockets[:1]
+	if _, err := pm.fetch(); err == nil {
+		t.Error("fetch with no socket should fail")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build linux
+
+package collector
+
+import (
+	"time"
+
+	"github.com/googlesky/sstop/internal/platform"
+)
+
+func readStartTime(pid uint32) time.Time {
+	return platform.ReadStartTime(pid)
+}
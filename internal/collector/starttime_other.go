@@ -0,0 +1,9 @@
+//go:build !linux
+
+package collector
+
+import "time"
+
+func readStartTime(_ uint32) time.Time {
+	return time.Time{}
+}
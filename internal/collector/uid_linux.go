@@ -0,0 +1,9 @@
+//go:build linux
+
+package collector
+
+import "github.com/googlesky/sstop/internal/platform"
+
+func readUID(pid uint32) (uint32, bool) {
+	return platform.ReadUID(pid)
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package collector
+
+func readUID(_ uint32) (uint32, bool) {
+	return 0, false
+}
@@ -0,0 +1,745 @@
+// Package config loads the optional sstop configuration file
+// (TOML, by default $XDG_CONFIG_HOME/sstop/config.toml).
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net/netip"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config is the top-level configuration file.
+type Config struct {
+	Alerts  Alerts        `toml:"alerts"`
+	Filters []SavedFilter `toml:"filters"`
+	Groups  []GroupRule   `toml:"groups"`
+	Metrics Metrics       `toml:"metrics"`
+	Presets []Preset      `toml:"presets"`
+	Redact  Redact        `toml:"redact"`
+	UI      UI            `toml:"ui"`
+
+	// Path is the file the config was read from, or would be at the
+	// default location when there is none yet; "" when no location is
+	// known. Saved filters are appended to it.
+	Path string `toml:"-"`
+}
+
+// GroupRule puts the processes it matches in a named group of the Groups
+// view ("databases", "build agents"), ahead of their pod, container or
+// systemd group. All given selectors must match; the first matching rule
+// wins.
+type GroupRule struct {
+	Name    string   `toml:"name"`
+	Process string   `toml:"process"` // regular expression on the process name
+	Cmdline string   `toml:"cmdline"` // regular expression on the command line
+	User    string   `toml:"user"`    // owner, by name or UID
+	Ports   []uint16 `toml:"ports"`   // a listening or connected local port
+}
+
+// UI holds display settings.
+type UI struct {
+	// Theme is "default" or "accessible" (monochrome, high contrast,
+	// direction shown by glyph and brightness). --accessible overrides it.
+	Theme string `toml:"theme"`
+
+	// Heat shades the process table's upload/download cells by rate
+	// instead of drawing bars beside them. --heat turns it on.
+	Heat bool `toml:"heat"`
+
+	// Columns are the process table columns, in order (TableColumns;
+	// "name" is required). Empty means the default set.
+	Columns []string `toml:"columns"`
+
+	// Hosts names remote hosts across the views and the streaming exports:
+	// "fqdn" (the resolved name, the default), "short" (its first label)
+	// or "ip". --host-format overrides it; d cycles it in the TUI.
+	Hosts string `toml:"hosts"`
+
+	// Idle is how the process table starts showing processes at zero rate
+	// for IdlePolls polls in a row: "show" (the default), "dim" or "hide".
+	// z cycles it in the TUI. IdlePolls 0 means 5.
+	Idle      string `toml:"idle"`
+	IdlePolls int    `toml:"idle_polls"`
+
+	// Refresh slows views that aggregate a whole snapshot to their own
+	// interval, by view name (RefreshViews) to a duration: "groups" =
+	// "5s" redraws the Groups view every 5 seconds while the process
+	// table keeps the poll interval.
+	Refresh map[string]string `toml:"refresh"`
+}
+
+// Metrics bounds the series --influx, --statsd and --graphite export, one
+// limit per dimension.
+type Metrics struct {
+	Processes  MetricLimit `toml:"processes"`
+	Hosts      MetricLimit `toml:"hosts"`
+	Interfaces MetricLimit `toml:"interfaces"`
+}
+
+// MetricLimit picks the entries of a dimension exported on their own;
+// the rest are summed into one named "other". Entries must match an Allow
+// pattern (if any) and no Exclude pattern, and only the Max busiest of
+// those are kept (0 = no cap). Patterns are regular expressions on the
+// process name, host or interface name.
+type MetricLimit struct {
+	Max     int      `toml:"max"`
+	Allow   []string `toml:"allow"`
+	Exclude []string `toml:"exclude"`
+}
+
+// UI themes.
+const (
+	ThemeDefault    = "default"
+	ThemeAccessible = "accessible"
+)
+
+// Redact configures masking of secrets in process command lines before
+// they reach the TUI, streaming output or recordings.
+type Redact struct {
+	// Defaults enables the built-in token patterns. nil means on.
+	Defaults *bool `toml:"defaults"`
+
+	// Patterns are extra regular expressions (RE2 syntax). Capture groups
+	// are masked if present, otherwise the whole match.
+	Patterns []string `toml:"patterns"`
+}
+
+// DefaultsEnabled reports whether the built-in patterns are used.
+func (r Redact) DefaultsEnabled() bool {
+	return r.Defaults == nil || *r.Defaults
+}
+
+// MaxPresets is the number of layout presets reachable with F1–F12.
+const MaxPresets = 12
+
+// Preset is a TUI layout bundle selected with an F-key. Every field is
+// applied, so a preset fully determines the layout it describes. A preset
+// named like a built-in one replaces it.
+type Preset struct {
+	Name       string `toml:"name"`
+	View       string `toml:"view"`   // processes, hosts, ports, groups, users
+	Sort       string `toml:"sort"`   // rate, down, up, pid, name, conns
+	Filter     string `toml:"filter"` // process table filter
+	TopDest    bool   `toml:"top_dest"`
+	Tree       bool   `toml:"tree"`
+	Cumulative bool   `toml:"cumulative"`
+	TCPInfo    bool   `toml:"tcp_info"`
+	Alert      string `toml:"alert"` // per-process alert threshold, "" = off
+}
+
+// Preset views and sort keys.
+var (
+	PresetViews = []string{"processes", "hosts", "ports", "groups", "users", "usage", "countries", "graph", "conns", "ifaces", "treemap", "flows"}
+	PresetSorts = []string{"rate", "down", "up", "pid", "name", "conns", "avg", "peak"}
+)
+
+// RefreshViews are the views [ui.refresh] can slow down.
+var RefreshViews = []string{"hosts", "groups", "countries", "users", "graph", "treemap", "flows"}
+
+// TableColumns are the process table columns [ui] columns can list.
+var TableColumns = []string{
+	"pid", "name", "graph", "up", "down", "conns", "listen", "dest",
+	"user", "cum_up", "cum_down", "container", "age", "country", "type",
+	"delta", "new_conns", "new_hosts",
+}
+
+// Flash styles for the header alert indicator.
+const (
+	FlashNone   = "none"   // no highlight
+	FlashSubtle = "subtle" // steady highlight (default)
+	FlashStrong = "strong" // highlight alternates every poll
+)
+
+// Notification channels a rule can use.
+const (
+	NotifyBell    = "bell"
+	NotifyFlash   = "flash"
+	NotifyCommand = "command" // run Alerts.Command
+	NotifyWebhook = "webhook" // POST to Alerts.Webhook
+	NotifyDesktop = "desktop" // desktop notification, when Alerts.Desktop
+)
+
+// Alerts configures how bandwidth alerts are signalled.
+type Alerts struct {
+	// Bell rings the terminal bell when a process starts exceeding a
+	// threshold. nil means the default (on).
+	Bell *bool `toml:"bell"`
+
+	// Flash is the header indicator style: none, subtle or strong.
+	Flash string `toml:"flash"`
+
+	// Command is run with sh -c (cmd /C on Windows) when an alert fires,
+	// with the alert in SSTOP_* environment variables.
+	Command string `toml:"command"`
+
+	// Webhook is a URL the alert is POSTed to as JSON.
+	Webhook string `toml:"webhook"`
+
+	// Desktop sends a desktop notification (notify-send, osascript).
+	Desktop bool `toml:"desktop"`
+
+	Rules      []AlertRule     `toml:"rules"`
+	Egress     []EgressRule    `toml:"egress"`
+	Interfaces []InterfaceRule `toml:"interfaces"`
+	Watch      Watch           `toml:"watch"`
+}
+
+// BellEnabled reports whether the terminal bell is enabled.
+func (a Alerts) BellEnabled() bool {
+	return a.Bell == nil || *a.Bell
+}
+
+// AlertRule fires when a single process exceeds Threshold (up+down).
+type AlertRule struct {
+	Name      string   `toml:"name"`
+	Threshold string   `toml:"threshold"` // e.g. "10M", "500K"
+	Notify    []string `toml:"notify"`    // channels; empty = all
+}
+
+// Notifies reports whether the rule uses the given channel.
+func (r AlertRule) Notifies(channel string) bool {
+	if len(r.Notify) == 0 {
+		return true
+	}
+	for _, n := range r.Notify {
+		if n == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// EgressRule fires when aggregate upload to matching remote hosts exceeds a
+// rate (MaxRate) or a volume within a sliding window (MaxBytes per Window).
+// Private, loopback and multicast destinations never match.
+type EgressRule struct {
+	Name string `toml:"name"`
+
+	// Destination selectors; all given selectors must match. "EU" in a
+	// country list expands to the EU member states.
+	Countries        []string `toml:"countries"`
+	ExcludeCountries []string `toml:"exclude_countries"`
+	ASNs             []uint32 `toml:"asns"`
+
+	MaxRate  string   `toml:"max_rate"`  // e.g. "5M" (bytes/sec)
+	MaxBytes string   `toml:"max_bytes"` // e.g. "1M", requires Window
+	Window   string   `toml:"window"`    // e.g. "1h"
+	Notify   []string `toml:"notify"`
+}
+
+// Notifies reports whether the rule uses the given channel.
+func (r EgressRule) Notifies(channel string) bool {
+	return AlertRule{Notify: r.Notify}.Notifies(channel)
+}
+
+// WindowDuration returns the parsed Window (0 if unset or invalid).
+func (r EgressRule) WindowDuration() time.Duration {
+	d, _ := time.ParseDuration(r.Window)
+	return d
+}
+
+// InterfaceRule fires when an interface's utilization, its busier
+// direction as a share of its capacity, stays above Threshold for For,
+// however the traffic is spread across processes. Interfaces with neither
+// a detected link speed nor a Capacity are skipped.
+type InterfaceRule struct {
+	Name      string   `toml:"name"`
+	Interface string   `toml:"interface"` // name or glob, e.g. "eth0", "wl*"; empty = all
+	Threshold string   `toml:"threshold"` // utilization, e.g. "90%"
+	Capacity  string   `toml:"capacity"`  // bits/sec, e.g. "100M"; empty = the link speed
+	For       string   `toml:"for"`       // e.g. "30s"; empty = the first poll above
+	Notify    []string `toml:"notify"`
+}
+
+// Notifies reports whether the rule uses the given channel.
+func (r InterfaceRule) Notifies(channel string) bool {
+	return AlertRule{Notify: r.Notify}.Notifies(channel)
+}
+
+// Matches reports whether the rule covers the named interface.
+func (r InterfaceRule) Matches(name string) bool {
+	if r.Interface == "" {
+		return true
+	}
+	ok, _ := path.Match(r.Interface, name)
+	return ok
+}
+
+// Utilization returns the parsed Threshold as a fraction (0.9 for "90%"),
+// 0 if invalid.
+func (r InterfaceRule) Utilization() float64 {
+	s := strings.TrimSuffix(strings.TrimSpace(r.Threshold), "%")
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || v <= 0 || v > 100 {
+		return 0
+	}
+	return v / 100
+}
+
+// CapacityBits returns the parsed Capacity in bits/sec, 0 if unset or
+// invalid.
+func (r InterfaceRule) CapacityBits() uint64 {
+	bps, _ := ParseLinkSpeed(r.Capacity)
+	return bps
+}
+
+// ForDuration returns the parsed For (0 if unset or invalid).
+func (r InterfaceRule) ForDuration() time.Duration {
+	d, _ := time.ParseDuration(r.For)
+	return d
+}
+
+// ParseLinkSpeed parses a link speed in bits/sec with an optional decimal
+// suffix, as link speeds are quoted: "1G", "2.5G", "100M", "100Mbit".
+func ParseLinkSpeed(s string) (uint64, error) {
+	t := strings.TrimSpace(s)
+	for _, unit := range []string{"bit", "bps", "b"} {
+		if len(t) > len(unit) && strings.EqualFold(t[len(t)-len(unit):], unit) {
+			t = t[:len(t)-len(unit)]
+			break
+		}
+	}
+	mult := 1.0
+	if t != "" {
+		switch t[len(t)-1] {
+		case 'k', 'K':
+			mult = 1e3
+		case 'm', 'M':
+			mult = 1e6
+		case 'g', 'G':
+			mult = 1e9
+		case 't', 'T':
+			mult = 1e12
+		}
+		if mult > 1 {
+			t = t[:len(t)-1]
+		}
+	}
+	v, err := strconv.ParseFloat(t, 64)
+	if err != nil || v <= 0 || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("%q is not a link speed (e.g. 1G, 100M)", s)
+	}
+	return uint64(v * mult), nil
+}
+
+// Watch is watch mode: security alerts on listening ports opened while
+// sstop runs and on connections to denied destinations.
+type Watch struct {
+	// Listen alerts when a process opens a listening port (protocol,
+	// address, port and process) not seen since the first snapshot.
+	Listen bool     `toml:"listen"`
+	Notify []string `toml:"notify"` // channels for listen alerts
+
+	Deny   []DenyRule   `toml:"deny"`
+	Appear []AppearRule `toml:"appear"`
+}
+
+// Notifies reports whether listen alerts use the given channel.
+func (w Watch) Notifies(channel string) bool {
+	return AlertRule{Notify: w.Notify}.Notifies(channel)
+}
+
+// DenyRule alerts when a process connects to a denied destination: one
+// of its countries ("EU" expands to the member states), ASNs or CIDRs.
+type DenyRule struct {
+	Name      string   `toml:"name"`
+	Countries []string `toml:"countries"`
+	ASNs      []uint32 `toml:"asns"`
+	CIDRs     []string `toml:"cidrs"` // e.g. "203.0.113.0/24", or a single address
+	Notify    []string `toml:"notify"`
+}
+
+// AppearRule runs alert actions when a process it matches appears: one
+// whose name matches Process, one listening on any of Ports, or both when
+// both are set. It is evaluated by the collector, so it works in every
+// mode, --json and sstop serve included.
+type AppearRule struct {
+	Name    string   `toml:"name"`
+	Process string   `toml:"process"` // regular expression on the process name
+	Ports   []uint16 `toml:"ports"`   // listening ports
+	Notify  []string `toml:"notify"`  // command, webhook, desktop; empty = all
+}
+
+// Notifies reports whether the rule uses the given channel.
+func (r AppearRule) Notifies(channel string) bool {
+	return AlertRule{Notify: r.Notify}.Notifies(channel)
+}
+
+// Notifies reports whether the rule uses the given channel.
+func (r DenyRule) Notifies(channel string) bool {
+	return AlertRule{Notify: r.Notify}.Notifies(channel)
+}
+
+// Prefixes returns the parsed CIDRs, single addresses as /32 or /128.
+// Invalid entries are skipped; Validate reports them.
+func (r DenyRule) Prefixes() []netip.Prefix {
+	var out []netip.Prefix
+	for _, c := range r.CIDRs {
+		if p, err := parsePrefix(c); err == nil {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		return p.Masked(), err
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	a = a.Unmap()
+	return netip.PrefixFrom(a, a.BitLen()), nil
+}
+
+// euCountries are the EU member states (ISO 3166-1 alpha-2).
+var euCountries = []string{
+	"AT", "BE", "BG", "CY", "CZ", "DE", "DK", "EE", "ES", "FI", "FR", "GR", "HR", "HU",
+	"IE", "IT", "LT", "LU", "LV", "MT", "NL", "PL", "PT", "RO", "SE", "SI", "SK",
+}
+
+// ExpandCountries upper-cases codes and expands the "EU" alias.
+func ExpandCountries(codes []string) []string {
+	var out []string
+	for _, c := range codes {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if c == "EU" {
+			out = append(out, euCountries...)
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// DefaultPath returns the default config file location, or "" if the user
+// config directory cannot be determined.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "sstop", "config.toml")
+}
+
+// Load reads and validates the config file at path. If path is empty the
+// default location is used, and a missing default file yields an empty
+// Config rather than an error.
+func Load(path string) (*Config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = DefaultPath()
+		if path == "" {
+			return &Config{}, nil
+		}
+	}
+
+	cfg := Config{Path: path}
+	md, err := toml.DecodeFile(path, &cfg)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return &Config{Path: path}, nil
+		}
+		return nil, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, k := range undecoded {
+			keys[i] = k.String()
+		}
+		sort.Strings(keys)
+		return nil, fmt.Errorf("%s: unknown keys: %s", path, strings.Join(keys, ", "))
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks enumerated values. Thresholds are parsed by the consumer.
+func (c *Config) Validate() error {
+	switch c.Alerts.Flash {
+	case "", FlashNone, FlashSubtle, FlashStrong:
+	default:
+		return fmt.Errorf("alerts.flash: %q is not one of none, subtle, strong", c.Alerts.Flash)
+	}
+	switch c.UI.Theme {
+	case "", ThemeDefault, ThemeAccessible:
+	default:
+		return fmt.Errorf("ui.theme: %q is not one of default, accessible", c.UI.Theme)
+	}
+	switch c.UI.Hosts {
+	case "", "fqdn", "short", "ip":
+	default:
+		return fmt.Errorf("ui.hosts: %q is not one of fqdn, short, ip", c.UI.Hosts)
+	}
+	switch c.UI.Idle {
+	case "", "show", "dim", "hide":
+	default:
+		return fmt.Errorf("ui.idle: %q is not one of show, dim, hide", c.UI.Idle)
+	}
+	if c.UI.IdlePolls < 0 {
+		return fmt.Errorf("ui.idle_polls: %d is negative", c.UI.IdlePolls)
+	}
+	if err := validateColumns(c.UI.Columns); err != nil {
+		return fmt.Errorf("ui.columns: %w", err)
+	}
+	if err := validateRefresh(c.UI.Refresh); err != nil {
+		return fmt.Errorf("ui.refresh: %w", err)
+	}
+	if c.Alerts.Webhook != "" {
+		u, err := url.Parse(c.Alerts.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("alerts.webhook: %q is not an http(s) URL", c.Alerts.Webhook)
+		}
+	}
+	for i, r := range c.Alerts.Rules {
+		if strings.TrimSpace(r.Threshold) == "" {
+			return fmt.Errorf("alerts.rules[%d]: threshold is required", i)
+		}
+		if err := validateNotify(r.Notify); err != nil {
+			return fmt.Errorf("alerts.rules[%d]: %w", i, err)
+		}
+	}
+	for i, r := range c.Alerts.Egress {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("alerts.egress[%d]: %w", i, err)
+		}
+	}
+	for i, r := range c.Alerts.Interfaces {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("alerts.interfaces[%d]: %w", i, err)
+		}
+	}
+	if err := validateNotify(c.Alerts.Watch.Notify); err != nil {
+		return fmt.Errorf("alerts.watch: %w", err)
+	}
+	for i, r := range c.Alerts.Watch.Deny {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("alerts.watch.deny[%d]: %w", i, err)
+		}
+	}
+	if len(c.Alerts.Watch.Appear) > 0 && c.Alerts.Command == "" && c.Alerts.Webhook == "" && !c.Alerts.Desktop {
+		return errors.New("alerts.watch.appear: set alerts.command, alerts.webhook or alerts.desktop for the rules to run")
+	}
+	for i, r := range c.Alerts.Watch.Appear {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("alerts.watch.appear[%d]: %w", i, err)
+		}
+	}
+	for i, p := range c.Redact.Patterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("redact.patterns[%d]: %w", i, err)
+		}
+	}
+	for i, p := range c.Presets {
+		if err := p.validate(); err != nil {
+			return fmt.Errorf("presets[%d]: %w", i, err)
+		}
+	}
+	for i, g := range c.Groups {
+		if err := g.validate(); err != nil {
+			return fmt.Errorf("groups[%d]: %w", i, err)
+		}
+	}
+	for i, f := range c.Filters {
+		if err := f.validate(); err != nil {
+			return fmt.Errorf("filters[%d]: %w", i, err)
+		}
+	}
+	for _, dim := range []struct {
+		name  string
+		limit MetricLimit
+	}{{"processes", c.Metrics.Processes}, {"hosts", c.Metrics.Hosts}, {"interfaces", c.Metrics.Interfaces}} {
+		if err := dim.limit.validate(); err != nil {
+			return fmt.Errorf("metrics.%s: %w", dim.name, err)
+		}
+	}
+	return nil
+}
+
+func (l MetricLimit) validate() error {
+	if l.Max < 0 {
+		return fmt.Errorf("max %d is negative", l.Max)
+	}
+	for i, p := range l.Allow {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("allow[%d]: %w", i, err)
+		}
+	}
+	for i, p := range l.Exclude {
+		if _, err := regexp.Compile(p); err != nil {
+			return fmt.Errorf("exclude[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (g GroupRule) validate() error {
+	if strings.TrimSpace(g.Name) == "" {
+		return errors.New("name is required")
+	}
+	if g.Process == "" && g.Cmdline == "" && g.User == "" && len(g.Ports) == 0 {
+		return errors.New("one of process, cmdline, user or ports is required")
+	}
+	for key, re := range map[string]string{"process": g.Process, "cmdline": g.Cmdline} {
+		if _, err := regexp.Compile(re); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (p Preset) validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("name is required")
+	}
+	if p.View != "" && !contains(PresetViews, p.View) {
+		return fmt.Errorf("view %q is not one of %s", p.View, strings.Join(PresetViews, ", "))
+	}
+	if p.Sort != "" && !contains(PresetSorts, p.Sort) {
+		return fmt.Errorf("sort %q is not one of %s", p.Sort, strings.Join(PresetSorts, ", "))
+	}
+	return nil
+}
+
+func validateRefresh(refresh map[string]string) error {
+	for view, every := range refresh {
+		if !contains(RefreshViews, view) {
+			return fmt.Errorf("%q is not one of %s", view, strings.Join(RefreshViews, ", "))
+		}
+		if d, err := time.ParseDuration(every); err != nil || d <= 0 {
+			return fmt.Errorf("%s: invalid interval %q", view, every)
+		}
+	}
+	return nil
+}
+
+func validateColumns(cols []string) error {
+	if len(cols) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(cols))
+	for _, c := range cols {
+		if !contains(TableColumns, c) {
+			return fmt.Errorf("%q is not one of %s", c, strings.Join(TableColumns, ", "))
+		}
+		if seen[c] {
+			return fmt.Errorf("%q is listed twice", c)
+		}
+		seen[c] = true
+	}
+	if !seen["name"] {
+		return errors.New(`"name" is required`)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (r EgressRule) validate() error {
+	if len(r.Countries) == 0 && len(r.ExcludeCountries) == 0 && len(r.ASNs) == 0 {
+		return errors.New("one of countries, exclude_countries or asns is required")
+	}
+	hasRate := strings.TrimSpace(r.MaxRate) != ""
+	hasBytes := strings.TrimSpace(r.MaxBytes) != ""
+	if hasRate == hasBytes {
+		return errors.New("exactly one of max_rate or max_bytes is required")
+	}
+	if hasBytes {
+		if r.Window == "" {
+			return errors.New("max_bytes requires window")
+		}
+		if d, err := time.ParseDuration(r.Window); err != nil || d <= 0 {
+			return fmt.Errorf("invalid window %q", r.Window)
+		}
+	} else if r.Window != "" {
+		return errors.New("window only applies to max_bytes")
+	}
+	return validateNotify(r.Notify)
+}
+
+func (r InterfaceRule) validate() error {
+	if strings.TrimSpace(r.Threshold) == "" {
+		return errors.New("threshold is required")
+	}
+	if r.Utilization() == 0 {
+		return fmt.Errorf("threshold %q is not a utilization between 0 and 100%%", r.Threshold)
+	}
+	if _, err := path.Match(r.Interface, ""); err != nil {
+		return fmt.Errorf("interface %q: %w", r.Interface, err)
+	}
+	if r.Capacity != "" {
+		if _, err := ParseLinkSpeed(r.Capacity); err != nil {
+			return fmt.Errorf("capacity: %w", err)
+		}
+	}
+	if r.For != "" {
+		if d, err := time.ParseDuration(r.For); err != nil || d < 0 {
+			return fmt.Errorf("invalid for %q", r.For)
+		}
+	}
+	return validateNotify(r.Notify)
+}
+
+func (r DenyRule) validate() error {
+	if len(r.Countries) == 0 && len(r.ASNs) == 0 && len(r.CIDRs) == 0 {
+		return errors.New("one of countries, asns or cidrs is required")
+	}
+	for _, c := range r.CIDRs {
+		if _, err := parsePrefix(c); err != nil {
+			return fmt.Errorf("invalid cidr %q", c)
+		}
+	}
+	return validateNotify(r.Notify)
+}
+
+func (r AppearRule) validate() error {
+	if r.Process == "" && len(r.Ports) == 0 {
+		return errors.New("one of process or ports is required")
+	}
+	if _, err := regexp.Compile(r.Process); err != nil {
+		return fmt.Errorf("process: %w", err)
+	}
+	for _, n := range r.Notify {
+		if n == NotifyBell || n == NotifyFlash {
+			return fmt.Errorf("notify channel %q is for the TUI; appear rules run command, webhook or desktop", n)
+		}
+	}
+	return validateNotify(r.Notify)
+}
+
+func validateNotify(channels []string) error {
+	for _, n := range channels {
+		switch n {
+		case NotifyBell, NotifyFlash, NotifyCommand, NotifyWebhook, NotifyDesktop:
+		default:
+			return fmt.Errorf("unknown notify channel %q", n)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadAlerts(t *testing.T) {
+	path := writeConfig(t, `
+[alerts]
+bell = false
+flash = "strong"
+
+[[alerts.rules]]
+name = "heavy"
+threshold = "10M"
+notify = ["flash"]
+
+[[alerts.rules]]
+threshold = "1G"
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Alerts.BellEnabled() {
+		t.Error("bell should be disabled")
+	}
+	if cfg.Alerts.Flash != FlashStrong {
+		t.Errorf("flash = %q, want strong", cfg.Alerts.Flash)
+	}
+	if len(cfg.Alerts.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(cfg.Alerts.Rules))
+	}
+	heavy := cfg.Alerts.Rules[0]
+	if heavy.Notifies(NotifyBell) || !heavy.Notifies(NotifyFlash) {
+		t.Errorf("heavy rule channels wrong: %v", heavy.Notify)
+	}
+	// No notify list means every channel
+	if !cfg.Alerts.Rules[1].Notifies(NotifyBell) {
+		t.Error("rule without notify should ring the bell")
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(writeConfig(t, ""))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !cfg.Alerts.BellEnabled() {
+		t.Error("bell should default to on")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"bad flash", "[alerts]\nflash = \"blink\"\n", "alerts.flash"},
+		{"bad webhook", "[alerts]\nwebhook = \"hooks.example.com\"\n", "alerts.webhook"},
+		{"bad channel", "[[alerts.rules]]\nthreshold = \"1M\"\nnotify = [\"sms\"]\n", "notify channel"},
+		{"no threshold", "[[alerts.rules]]\nname = \"x\"\n", "threshold is required"},
+		{"unknown key", "[alerts]\nbel = true\n", "unknown keys: alerts.bel"},
+		{"syntax", "[alerts\n", "toml"},
+		{"preset no name", "[[presets]]\nview = \"hosts\"\n", "name is required"},
+		{"preset bad view", "[[presets]]\nname = \"x\"\nview = \"map\"\n", "view \"map\""},
+		{"preset bad sort", "[[presets]]\nname = \"x\"\nsort = \"age\"\n", "sort \"age\""},
+		{"bad theme", "[ui]\ntheme = \"dark\"\n", "ui.theme"},
+		{"bad hosts", "[ui]\nhosts = \"name\"\n", "ui.hosts: \"name\" is not one of"},
+		{"bad idle", "[ui]\nidle = \"grey\"\n", "ui.idle: \"grey\" is not one of"},
+		{"negative idle polls", "[ui]\nidle_polls = -1\n", "ui.idle_polls"},
+		{"filter no name", "[[filters]]\nfilter = \"port:22\"\n", "filters[0]: name is required"},
+		{"bad column", "[ui]\ncolumns = [\"name\", \"rss\"]\n", "\"rss\" is not one of"},
+		{"columns without name", "[ui]\ncolumns = [\"pid\", \"up\"]\n", "\"name\" is required"},
+		{"refresh bad view", "[ui.refresh]\nprocesses = \"5s\"\n", "ui.refresh: \"processes\" is not one of"},
+		{"refresh bad interval", "[ui.refresh]\ngroups = \"5\"\n", "ui.refresh: groups: invalid interval"},
+		{"bad redact pattern", "[redact]\npatterns = [\"(\"]\n", "redact.patterns[0]"},
+		{"egress no selector", "[[alerts.egress]]\nmax_rate = \"1M\"\n", "countries, exclude_countries or asns"},
+		{"egress no limit", "[[alerts.egress]]\ncountries = [\"CN\"]\n", "exactly one of max_rate or max_bytes"},
+		{"egress no window", "[[alerts.egress]]\ncountries = [\"CN\"]\nmax_bytes = \"1M\"\n", "requires window"},
+		{"group no name", "[[groups]]\nprocess = \"pg\"\n", "groups[0]: name is required"},
+		{"group no selector", "[[groups]]\nname = \"db\"\n", "one of process, cmdline, user or ports"},
+		{"group bad regexp", "[[groups]]\nname = \"db\"\ncmdline = \"(\"\n", "groups[0]: cmdline"},
+		{"metrics negative max", "[metrics.processes]\nmax = -1\n", "metrics.processes: max -1"},
+		{"metrics bad allow", "[metrics.hosts]\nallow = [\"(\"]\n", "metrics.hosts: allow[0]"},
+		{"egress bad window", "[[alerts.egress]]\ncountries = [\"CN\"]\nmax_bytes = \"1M\"\nwindow = \"hour\"\n", "invalid window"},
+		{"interface no threshold", "[[alerts.interfaces]]\ninterface = \"eth0\"\n", "alerts.interfaces[0]: threshold is required"},
+		{"interface bad threshold", "[[alerts.interfaces]]\nthreshold = \"150%\"\n", "not a utilization"},
+		{"interface bad capacity", "[[alerts.interfaces]]\nthreshold = \"90%\"\ncapacity = \"fast\"\n", "capacity: \"fast\" is not a link speed"},
+		{"interface bad glob", "[[alerts.interfaces]]\nthreshold = \"90%\"\ninterface = \"eth[\"\n", "interface \"eth[\""},
+		{"interface bad for", "[[alerts.interfaces]]\nthreshold = \"90%\"\nfor = \"1 minute\"\n", "invalid for"},
+		{"deny no selector", "[[alerts.watch.deny]]\nname = \"x\"\n", "alerts.watch.deny[0]: one of countries, asns or cidrs"},
+		{"deny bad cidr", "[[alerts.watch.deny]]\ncidrs = [\"10.0.0.0/33\"]\n", "invalid cidr"},
+		{"appear without actions", "[[alerts.watch.appear]]\nprocess = \"nc\"\n", "alerts.watch.appear: set alerts.command"},
+		{"appear no selector", "[alerts]\ncommand = \"true\"\n[[alerts.watch.appear]]\nname = \"x\"\n", "alerts.watch.appear[0]: one of process or ports"},
+		{"appear bell", "[alerts]\ncommand = \"true\"\n[[alerts.watch.appear]]\nports = [6379]\nnotify = [\"bell\"]\n", "is for the TUI"},
+		{"watch bad channel", "[alerts.watch]\nlisten = true\nnotify = [\"sms\"]\n", "alerts.watch: unknown notify channel"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "nope.toml")); err == nil {
+		t.Error("explicit missing path should be an error")
+	}
+
+	// A missing default file is not an error
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("AppData", t.TempDir())
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load default: %v", err)
+	}
+	if len(cfg.Alerts.Rules) != 0 {
+		t.Error("expected empty config")
+	}
+	if cfg.Path != DefaultPath() {
+		t.Errorf("Path = %q, want the default location %q", cfg.Path, DefaultPath())
+	}
+}
+
+func TestAppendFilter(t *testing.T) {
+	path := writeConfig(t, "# my config\n[ui]\nheat = true\n")
+	if err := AppendFilter(path, SavedFilter{Name: "web", Filter: `port:443 OR name:"nginx"`}); err != nil {
+		t.Fatalf("AppendFilter: %v", err)
+	}
+	if err := AppendFilter(path, SavedFilter{Name: "dns"}); err == nil {
+		t.Error("a filter without an expression should be refused")
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cfg.Filters) != 1 || cfg.Filters[0].Filter != `port:443 OR name:"nginx"` || !cfg.UI.Heat {
+		t.Errorf("filters = %+v, heat = %v", cfg.Filters, cfg.UI.Heat)
+	}
+	if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), "# my config\n") {
+		t.Errorf("existing content not kept:\n%s", data)
+	}
+
+	// A new file in a new directory
+	fresh := filepath.Join(t.TempDir(), "sstop", "config.toml")
+	if err := AppendFilter(fresh, SavedFilter{Name: "ssh", Filter: "port:22"}); err != nil {
+		t.Fatalf("AppendFilter new file: %v", err)
+	}
+	if cfg, err := Load(fresh); err != nil || len(cfg.Filters) != 1 || cfg.Path != fresh {
+		t.Errorf("new file: %+v, %v", cfg, err)
+	}
+}
+
+func TestLoadEgress(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `
+[[alerts.egress]]
+name = "non-eu"
+exclude_countries = ["EU"]
+max_bytes = "1M"
+window = "1h"
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	r := cfg.Alerts.Egress[0]
+	if r.WindowDuration() != time.Hour {
+		t.Errorf("window = %v, want 1h", r.WindowDuration())
+	}
+	codes := ExpandCountries(r.ExcludeCountries)
+	if len(codes) != 27 || codes[0] != "AT" {
+		t.Errorf("EU expanded to %v", codes)
+	}
+}
+
+func TestLoadWatch(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `
+[alerts.watch]
+listen = true
+notify = ["desktop"]
+
+[[alerts.watch.deny]]
+name = "blocklist"
+cidrs = ["203.0.113.7", "198.51.100.0/24", "2001:db8::/32"]
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	w := cfg.Alerts.Watch
+	if !w.Listen || w.Notifies("bell") || !w.Notifies("desktop") {
+		t.Errorf("watch = %+v", w)
+	}
+	var got []string
+	for _, p := range w.Deny[0].Prefixes() {
+		got = append(got, p.String())
+	}
+	if strings.Join(got, " ") != "203.0.113.7/32 198.51.100.0/24 2001:db8::/32" {
+		t.Errorf("prefixes = %v", got)
+	}
+}
+
+func TestLoadRedact(t *testing.T) {
+	cfg, err := Load(writeConfig(t, "[redact]\ndefaults = false\npatterns = ['--dsn=(\\S+)']\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Redact.DefaultsEnabled() {
+		t.Error("defaults should be disabled")
+	}
+	if len(cfg.Redact.Patterns) != 1 || cfg.Redact.Patterns[0] != `--dsn=(\S+)` {
+		t.Errorf("patterns = %q", cfg.Redact.Patterns)
+	}
+
+	empty, err := Load(writeConfig(t, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !empty.Redact.DefaultsEnabled() {
+		t.Error("defaults should be on by default")
+	}
+}
+
+func TestLoadMetrics(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `
+[metrics.processes]
+max = 20
+exclude = ["^kworker"]
+
+[metrics.hosts]
+allow = ['\.example\.com$']
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Metrics.Processes.Max != 20 || len(cfg.Metrics.Processes.Exclude) != 1 || len(cfg.Metrics.Hosts.Allow) != 1 || cfg.Metrics.Interfaces.Max != 0 {
+		t.Errorf("metrics = %+v", cfg.Metrics)
+	}
+}
+
+func TestLoadGroups(t *testing.T) {
+	cfg, err := Load(writeConfig(t, `
+[[groups]]
+name = "databases"
+process = "^(postgres|mysqld|redis-server)$"
+ports = [5432, 6379]
+
+[[groups]]
+name = "build agents"
+user = "ci"
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cfg.Groups) != 2 || cfg.Groups[0].Name != "databases" || len(cfg.Groups[0].Ports) != 2 || cfg.Groups[1].User != "ci" {
+		t.Errorf("groups = %+v", cfg.Groups)
+	}
+}
+
+func TestParseLinkSpeed(t *testing.T) {
+	for in, want := range map[string]uint64{"1G": 1e9, "2.5G": 2.5e9, "100M": 1e8, "100Mbit": 1e8, "10gbps": 1e10, "56k": 56e3, "9600": 9600} {
+		if got, err := ParseLinkSpeed(in); err != nil || got != want {
+			t.Errorf("ParseLinkSpeed(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "G", "-1G", "fast"} {
+		if _, err := ParseLinkSpeed(in); err == nil {
+			t.Errorf("ParseLinkSpeed(%q) should fail", in)
+		}
+	}
+	r := InterfaceRule{Interface: "wl*", Threshold: "85 %"}
+	if r.Utilization() != 0.85 || !r.Matches("wlan0") || r.Matches("eth0") {
+		t.Errorf("rule %+v: utilization %v", r, r.Utilization())
+	}
+}
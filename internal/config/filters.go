@@ -0,0 +1,58 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// SavedFilter is a named TUI filter, recalled with the number keys (the
+// first nine, in order) or the F picker. A later filter with the same name
+// replaces an earlier one, so saving from the TUI can append.
+type SavedFilter struct {
+	Name   string `toml:"name"`
+	Filter string `toml:"filter"` // filter expression, as typed after /
+}
+
+func (f SavedFilter) validate() error {
+	if strings.TrimSpace(f.Name) == "" {
+		return errors.New("name is required")
+	}
+	if strings.TrimSpace(f.Filter) == "" {
+		return errors.New("filter is required")
+	}
+	return nil
+}
+
+// AppendFilter saves f to the config file at path as a new [[filters]]
+// entry, creating the file (and its directory) if needed. The rest of the
+// file, comments included, is left as it is.
+func AppendFilter(path string, f SavedFilter) error {
+	if err := f.validate(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	entry := struct {
+		Filters []SavedFilter `toml:"filters"`
+	}{[]SavedFilter{f}}
+	// A blank line keeps the entry apart from whatever ends the file
+	if _, err := fmt.Fprintln(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := toml.NewEncoder(file).Encode(entry); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
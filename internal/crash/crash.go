@@ -0,0 +1,55 @@
+// Package crash writes the report sstop leaves behind when the TUI
+// panics, so the state of a long session isn't lost with it.
+package crash
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/googlesky/sstop/internal/version"
+)
+
+// Report is what a crash saves.
+type Report struct {
+	Time      time.Time
+	Panic     any
+	Stack     []byte
+	Session   string   // exit summary of the session totals, "" if none
+	Recording string   // what became of the --record file, "" if not recording
+	Alerts    []string // alert log, oldest first
+}
+
+// String formats the report as plain text.
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s crashed at %s\n\n", version.Short(), r.Time.Format(time.RFC3339))
+	fmt.Fprintf(&b, "panic: %v\n\n%s\n", r.Panic, strings.TrimRight(string(r.Stack), "\n"))
+
+	if r.Recording != "" {
+		fmt.Fprintf(&b, "\nrecording: %s\n", r.Recording)
+	}
+	if r.Session != "" {
+		b.WriteString("\n" + strings.TrimLeft(r.Session, "\n"))
+	}
+	fmt.Fprintf(&b, "\nalert log (%d):\n", len(r.Alerts))
+	for _, a := range r.Alerts {
+		fmt.Fprintf(&b, "  %s\n", a)
+	}
+	return b.String()
+}
+
+// Write saves the report to a new file in dir (the temp directory if
+// empty) and returns its path.
+func Write(dir string, r Report) (string, error) {
+	f, err := os.CreateTemp(dir, "sstop-crash-*.txt")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(r.String()); err != nil {
+		f.Close()
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
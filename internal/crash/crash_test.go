@@ -0,0 +1,44 @@
+package crash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	r := Report{
+		Time:      time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Panic:     "index out of range [3] with length 3",
+		Stack:     []byte("goroutine 1 [running]:\nmain.main()\n"),
+		Session:   "\nsstop session: 2h0m0s\nTotal: ▲ 1.0 GB  ▼ 4.0 GB\n",
+		Recording: "/tmp/session.rec.gz",
+		Alerts:    []string{"2026-03-01 11:58:00  curl (pid 10) 12.0 MB/s > 1M/s"},
+	}
+	path, err := Write(dir, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(path) != dir || !strings.HasPrefix(filepath.Base(path), "sstop-crash-") {
+		t.Errorf("path = %s, want sstop-crash-* in %s", path, dir)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"crashed at 2026-03-01T12:00:00Z",
+		"panic: index out of range",
+		"main.main()",
+		"recording: /tmp/session.rec.gz",
+		"sstop session: 2h0m0s",
+		"alert log (1):\n  2026-03-01 11:58:00  curl (pid 10)",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("report missing %q:\n%s", want, data)
+		}
+	}
+}
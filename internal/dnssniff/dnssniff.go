@@ -0,0 +1,123 @@
+// Package dnssniff learns hostnames from the DNS answers applications
+// receive, so remote addresses can be shown by the name that was actually
+// requested (a CDN edge as "static.example.com") where reverse DNS fails or
+// returns a provider name.
+package dnssniff
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Answer maps an address to the name an application looked up.
+type Answer struct {
+	IP   net.IP
+	Name string
+	TTL  time.Duration // 0 = does not expire (hosts file)
+}
+
+// ParseResponse extracts the A and AAAA answers from a DNS response. Each
+// address is attributed to the question name, so CNAME chains resolve to
+// the name the application asked for. Malformed or failed responses yield
+// nothing.
+func ParseResponse(msg []byte) []Answer {
+	var p dnsmessage.Parser
+	h, err := p.Start(msg)
+	if err != nil || !h.Response || h.RCode != dnsmessage.RCodeSuccess {
+		return nil
+	}
+	q, err := p.Question()
+	if err != nil {
+		return nil
+	}
+	name := strings.TrimSuffix(q.Name.String(), ".")
+	if name == "" {
+		return nil
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil
+	}
+
+	var out []Answer
+	for {
+		rh, err := p.AnswerHeader()
+		if err != nil {
+			return out // section done, or truncated
+		}
+		ttl := time.Duration(rh.TTL) * time.Second
+		switch rh.Type {
+		case dnsmessage.TypeA:
+			r, err := p.AResource()
+			if err != nil {
+				return out
+			}
+			out = append(out, Answer{IP: net.IP(r.A[:]), Name: name, TTL: ttl})
+		case dnsmessage.TypeAAAA:
+			r, err := p.AAAAResource()
+			if err != nil {
+				return out
+			}
+			out = append(out, Answer{IP: net.IP(r.AAAA[:]), Name: name, TTL: ttl})
+		default:
+			if err := p.SkipAnswer(); err != nil {
+				return out
+			}
+		}
+	}
+}
+
+// ParseHosts reads a hosts(5) file, mapping each address to its canonical
+// (first) name. Entries don't expire.
+func ParseHosts(r io.Reader) []Answer {
+	var out []Answer
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		ip := net.ParseIP(fields[0])
+		if ip == nil {
+			continue
+		}
+		out = append(out, Answer{IP: ip, Name: fields[1]})
+	}
+	return out
+}
+
+// parseUDPResponse returns the DNS answers carried by an IP packet if it is
+// a UDP datagram from port 53.
+func parseUDPResponse(pkt []byte) []Answer {
+	if len(pkt) < 1 {
+		return nil
+	}
+	var udp []byte
+	switch pkt[0] >> 4 {
+	case 4:
+		if len(pkt) < 20 || pkt[9] != 17 {
+			return nil
+		}
+		ihl := int(pkt[0]&0x0f) * 4
+		if ihl < 20 || len(pkt) < ihl {
+			return nil
+		}
+		udp = pkt[ihl:]
+	case 6:
+		if len(pkt) < 40 || pkt[6] != 17 {
+			return nil // extension headers are rare on DNS traffic
+		}
+		udp = pkt[40:]
+	default:
+		return nil
+	}
+	if len(udp) < 8 || udp[0] != 0 || udp[1] != 53 {
+		return nil
+	}
+	return ParseResponse(udp[8:])
+}
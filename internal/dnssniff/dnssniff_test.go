@@ -0,0 +1,86 @@
+package dnssniff
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// response builds a DNS answer for static.example.com that goes through a
+// CDN CNAME, like most real ones do.
+func response(t *testing.T, rcode dnsmessage.RCode) []byte {
+	t.Helper()
+	q := dnsmessage.MustNewName("static.example.com.")
+	cdn := dnsmessage.MustNewName("edge.cdn.example.net.")
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true, RCode: rcode})
+	b.EnableCompression()
+	b.StartQuestions()
+	b.Question(dnsmessage.Question{Name: q, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET})
+	b.StartAnswers()
+	b.CNAMEResource(dnsmessage.ResourceHeader{Name: q, Class: dnsmessage.ClassINET, TTL: 300},
+		dnsmessage.CNAMEResource{CNAME: cdn})
+	b.AResource(dnsmessage.ResourceHeader{Name: cdn, Class: dnsmessage.ClassINET, TTL: 60},
+		dnsmessage.AResource{A: [4]byte{203, 0, 113, 10}})
+	b.AAAAResource(dnsmessage.ResourceHeader{Name: cdn, Class: dnsmessage.ClassINET, TTL: 60},
+		dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}})
+	msg, err := b.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestParseResponse(t *testing.T) {
+	answers := ParseResponse(response(t, dnsmessage.RCodeSuccess))
+	if len(answers) != 2 {
+		t.Fatalf("got %d answers, want 2: %+v", len(answers), answers)
+	}
+	for _, a := range answers {
+		if a.Name != "static.example.com" || a.TTL != time.Minute {
+			t.Errorf("answer %+v, want static.example.com ttl 1m", a)
+		}
+	}
+	if !answers[0].IP.Equal(net.ParseIP("203.0.113.10")) || !answers[1].IP.Equal(net.ParseIP("2001:db8::1")) {
+		t.Errorf("addresses = %v, %v", answers[0].IP, answers[1].IP)
+	}
+
+	if got := ParseResponse(response(t, dnsmessage.RCodeNameError)); got != nil {
+		t.Errorf("NXDOMAIN gave %+v", got)
+	}
+	if got := ParseResponse([]byte{1, 2, 3}); got != nil {
+		t.Errorf("garbage gave %+v", got)
+	}
+}
+
+func TestParseUDPResponse(t *testing.T) {
+	msg := response(t, dnsmessage.RCodeSuccess)
+	pkt := make([]byte, 20+8+len(msg))
+	pkt[0] = 0x45 // IPv4, 20-byte header
+	pkt[9] = 17   // UDP
+	pkt[21] = 53  // source port
+	copy(pkt[28:], msg)
+
+	if got := parseUDPResponse(pkt); len(got) != 2 {
+		t.Errorf("from port 53: %d answers, want 2", len(got))
+	}
+	pkt[21] = 54
+	if got := parseUDPResponse(pkt); got != nil {
+		t.Errorf("from port 54: %+v, want none", got)
+	}
+}
+
+func TestParseHosts(t *testing.T) {
+	hosts := `# comment
+127.0.0.1 localhost
+10.0.0.5   db.internal db   # primary
+bogus line
+fe80::1%lo0 ignored
+`
+	got := ParseHosts(strings.NewReader(hosts))
+	if len(got) != 2 || got[1].Name != "db.internal" || !got[1].IP.Equal(net.ParseIP("10.0.0.5")) || got[1].TTL != 0 {
+		t.Errorf("ParseHosts = %+v", got)
+	}
+}
@@ -0,0 +1,91 @@
+//go:build linux
+
+package dnssniff
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+)
+
+// Sniffer captures DNS responses on every interface (including loopback,
+// where local stub resolvers answer) and reports their answers.
+type Sniffer struct {
+	fd        int
+	stopCh    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// udpSrcPort53 is a classic BPF filter for cooked (SOCK_DGRAM) packets:
+// IPv4 or IPv6 UDP with source port 53. Jump offsets count from the next
+// instruction.
+var udpSrcPort53 = []syscall.SockFilter{
+	*syscall.LsfStmt(syscall.BPF_LD|syscall.BPF_B|syscall.BPF_ABS, 0),           // 0: A = version byte
+	*syscall.LsfStmt(syscall.BPF_ALU|syscall.BPF_AND|syscall.BPF_K, 0xf0),       // 1
+	*syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, 0x40, 0, 5), // 2: IPv4? else 8
+	*syscall.LsfStmt(syscall.BPF_LD|syscall.BPF_B|syscall.BPF_ABS, 9),           // 3: protocol
+	*syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, 17, 0, 9),   // 4: UDP? else drop
+	*syscall.LsfStmt(syscall.BPF_LDX|syscall.BPF_B|syscall.BPF_MSH, 0),          // 5: X = header length
+	*syscall.LsfStmt(syscall.BPF_LD|syscall.BPF_H|syscall.BPF_IND, 0),           // 6: source port
+	*syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, 53, 5, 6),   // 7: accept / drop
+	*syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, 0x60, 0, 5), // 8: IPv6? else drop
+	*syscall.LsfStmt(syscall.BPF_LD|syscall.BPF_B|syscall.BPF_ABS, 6),           // 9: next header
+	*syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, 17, 0, 3),   // 10: UDP? else drop
+	*syscall.LsfStmt(syscall.BPF_LD|syscall.BPF_H|syscall.BPF_ABS, 40),          // 11: source port
+	*syscall.LsfJump(syscall.BPF_JMP|syscall.BPF_JEQ|syscall.BPF_K, 53, 0, 1),   // 12: accept / drop
+	*syscall.LsfStmt(syscall.BPF_RET|syscall.BPF_K, 0xffff),                     // 13: accept
+	*syscall.LsfStmt(syscall.BPF_RET|syscall.BPF_K, 0),                          // 14: drop
+}
+
+// Start opens an AF_PACKET socket (root or CAP_NET_RAW) and calls onAnswer
+// from a background goroutine for every A/AAAA answer seen.
+func Start(onAnswer func(Answer)) (*Sniffer, error) {
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_DGRAM, int(htons(syscall.ETH_P_ALL)))
+	if err != nil {
+		return nil, fmt.Errorf("DNS sniffing needs root or CAP_NET_RAW: %w", err)
+	}
+	if err := syscall.AttachLsf(fd, udpSrcPort53); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("attach DNS filter: %w", err)
+	}
+	// Read timeout so the loop can notice Close
+	tv := syscall.Timeval{Usec: 200_000}
+	syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv)
+
+	s := &Sniffer{fd: fd, stopCh: make(chan struct{}), done: make(chan struct{})}
+	go s.loop(onAnswer)
+	return s, nil
+}
+
+// Close stops capturing.
+func (s *Sniffer) Close() {
+	s.closeOnce.Do(func() {
+		close(s.stopCh)
+		<-s.done
+		syscall.Close(s.fd)
+	})
+}
+
+func (s *Sniffer) loop(onAnswer func(Answer)) {
+	defer close(s.done)
+	buf := make([]byte, 65536)
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		default:
+		}
+		n, _, err := syscall.Recvfrom(s.fd, buf, 0)
+		if err != nil || n < 1 {
+			continue // timeout or interrupted
+		}
+		for _, a := range parseUDPResponse(buf[:n]) {
+			onAnswer(a)
+		}
+	}
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
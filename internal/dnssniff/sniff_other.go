@@ -0,0 +1,16 @@
+//go:build !linux
+
+package dnssniff
+
+import "errors"
+
+// Sniffer captures DNS responses; Linux only.
+type Sniffer struct{}
+
+// Start reports that DNS sniffing is unsupported on this platform.
+func Start(onAnswer func(Answer)) (*Sniffer, error) {
+	return nil, errors.New("DNS sniffing is only supported on Linux")
+}
+
+// Close is a no-op.
+func (s *Sniffer) Close() {}
@@ -0,0 +1,171 @@
+// Package doctor implements sstop doctor: a startup sanity check of the
+// kernel features, permissions, terminal and config sstop depends on,
+// with a suggested fix for anything that would leave the numbers at zero.
+package doctor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/googlesky/sstop/internal/config"
+)
+
+// Level is how much a finding matters.
+type Level int
+
+const (
+	OK   Level = iota
+	Warn       // works, but with less data or a degraded display
+	Fail       // sstop will show nothing useful until fixed
+)
+
+func (l Level) mark() string {
+	switch l {
+	case Warn:
+		return "!"
+	case Fail:
+		return "✗"
+	}
+	return "✓"
+}
+
+// Finding is the result of one check.
+type Finding struct {
+	Name   string
+	Level  Level
+	Detail string
+	Fix    string // what to do about a warning or failure
+}
+
+// Options are the inputs of a run.
+type Options struct {
+	ConfigPath string // --config; "" = the default location
+}
+
+// Run performs every check.
+func Run(opts Options) []Finding {
+	findings := systemChecks()
+	tty := term.IsTerminal(os.Stdout.Fd())
+	w, h := 0, 0
+	if tty {
+		w, h, _ = term.GetSize(os.Stdout.Fd())
+	}
+	findings = append(findings, terminalChecks(os.Getenv, tty, w, h)...)
+	return append(findings, configCheck(opts.ConfigPath))
+}
+
+// minWidth and minHeight are the smallest terminal showing the full
+// layout; below them columns drop or the header compacts.
+const (
+	minWidth  = 80
+	minHeight = 10
+)
+
+// terminalChecks checks the terminal sstop would draw on.
+func terminalChecks(getenv func(string) string, tty bool, width, height int) []Finding {
+	if !tty {
+		return []Finding{{
+			Name: "terminal", Level: Warn,
+			Detail: "stdout is not a terminal",
+			Fix:    "run sstop in a terminal, or use --json/--csv/--influx for pipes",
+		}}
+	}
+	var out []Finding
+	switch t := getenv("TERM"); {
+	case t == "" || t == "dumb":
+		out = append(out, Finding{
+			Name: "terminal", Level: Warn,
+			Detail: fmt.Sprintf("TERM=%q has no cursor addressing or colour", t),
+			Fix:    "set TERM to your terminal's type, e.g. xterm-256color",
+		})
+	case getenv("NO_COLOR") != "":
+		out = append(out, Finding{Name: "terminal", Level: OK, Detail: "TERM=" + t + ", NO_COLOR set: monochrome"})
+	case !strings.Contains(t, "256color") && getenv("COLORTERM") == "":
+		out = append(out, Finding{
+			Name: "terminal", Level: Warn,
+			Detail: "TERM=" + t + " advertises few colours; rate shading will be coarse",
+			Fix:    "use a 256-colour or truecolor TERM (e.g. xterm-256color), or --accessible",
+		})
+	default:
+		out = append(out, Finding{Name: "terminal", Level: OK, Detail: "TERM=" + t})
+	}
+
+	locale := getenv("LC_ALL")
+	if locale == "" {
+		locale = getenv("LC_CTYPE")
+	}
+	if locale == "" {
+		locale = getenv("LANG")
+	}
+	if l := strings.ToLower(locale); strings.Contains(l, "utf-8") || strings.Contains(l, "utf8") {
+		out = append(out, Finding{Name: "locale", Level: OK, Detail: locale})
+	} else {
+		out = append(out, Finding{
+			Name: "locale", Level: Warn,
+			Detail: fmt.Sprintf("%q is not UTF-8; bars, sparklines and flags may show as garbage", locale),
+			Fix:    "export LANG=C.UTF-8 (or another UTF-8 locale)",
+		})
+	}
+
+	if width > 0 && (width < minWidth || height < minHeight) {
+		out = append(out, Finding{
+			Name: "size", Level: Warn,
+			Detail: fmt.Sprintf("%dx%d; columns drop below %d wide and the header compacts below %d rows", width, height, minWidth, minHeight),
+			Fix:    "enlarge the window, or choose fewer columns with [ui] columns",
+		})
+	} else if width > 0 {
+		out = append(out, Finding{Name: "size", Level: OK, Detail: fmt.Sprintf("%dx%d", width, height)})
+	}
+	return out
+}
+
+// configCheck loads and validates the config file.
+func configCheck(path string) Finding {
+	shown := path
+	if shown == "" {
+		shown = config.DefaultPath()
+	}
+	if _, err := config.Load(path); err != nil {
+		return Finding{Name: "config", Level: Fail, Detail: err.Error(), Fix: "fix or remove " + shown}
+	}
+	if path == "" {
+		if _, err := os.Stat(shown); err != nil {
+			return Finding{Name: "config", Level: OK, Detail: "no config file (defaults)"}
+		}
+	}
+	return Finding{Name: "config", Level: OK, Detail: shown + " is valid"}
+}
+
+// Write prints findings, one per line with its fix indented below, and
+// reports whether any check failed.
+func Write(w io.Writer, findings []Finding) (failed bool) {
+	nameW := 0
+	for _, f := range findings {
+		nameW = max(nameW, len(f.Name))
+	}
+	warns, fails := 0, 0
+	for _, f := range findings {
+		fmt.Fprintf(w, "%s %-*s  %s\n", f.Level.mark(), nameW, f.Name, f.Detail)
+		if f.Fix != "" && f.Level != OK {
+			fmt.Fprintf(w, "  %-*s  → %s\n", nameW, "", f.Fix)
+		}
+		switch f.Level {
+		case Warn:
+			warns++
+		case Fail:
+			fails++
+		}
+	}
+	switch {
+	case fails > 0:
+		fmt.Fprintf(w, "\n%d problem(s), %d warning(s)\n", fails, warns)
+	case warns > 0:
+		fmt.Fprintf(w, "\nno problems, %d warning(s)\n", warns)
+	default:
+		fmt.Fprintln(w, "\nall good")
+	}
+	return fails > 0
+}
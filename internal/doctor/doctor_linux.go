@@ -0,0 +1,192 @@
+//go:build linux
+
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/googlesky/sstop/internal/platform"
+	"github.com/googlesky/sstop/internal/version"
+)
+
+// Swapped out by tests.
+var (
+	probeSockDiag = platform.ProbeSockDiag
+	geteuid       = os.Geteuid
+)
+
+// Capability bits of CapEff in /proc/self/status.
+const (
+	capNetRaw    = 13
+	capSysPtrace = 19
+)
+
+func systemChecks() []Finding {
+	return linuxChecks("/")
+}
+
+// linuxChecks checks the kernel and permissions, reading /proc and /sys
+// under root.
+func linuxChecks(root string) []Finding {
+	path := func(p string) string { return filepath.Join(root, p) }
+	isRoot := geteuid() == 0
+	caps := effectiveCaps(path("proc/self/status"))
+	hasCap := func(bit uint) bool { return isRoot || caps&(1<<bit) != 0 }
+
+	var out []Finding
+
+	// Permissions: mapping sockets to PIDs walks every process's fd table
+	switch _, err := os.ReadDir(path("proc/1/fd")); {
+	case err == nil:
+		detail := "can read other processes' sockets"
+		if isRoot {
+			detail = "root; " + detail
+		}
+		out = append(out, Finding{Name: "permissions", Level: OK, Detail: detail})
+	default:
+		out = append(out, Finding{
+			Name: "permissions", Level: Warn,
+			Detail: "not root: only your own processes' traffic can be attributed",
+			Fix:    "run with sudo, or: sudo setcap cap_sys_ptrace,cap_dac_read_search,cap_net_raw,cap_net_admin+ep $(command -v sstop)",
+		})
+	}
+
+	// /proc: the socket tables, and whether hidepid hides processes
+	if _, err := os.Stat(path("proc/net/tcp")); err != nil {
+		out = append(out, Finding{
+			Name: "/proc", Level: Fail,
+			Detail: "/proc/net/tcp is not readable: " + errText(err),
+			Fix:    "mount procfs (mount -t proc proc /proc); in a container, share the host's PID and network namespaces",
+		})
+	} else if opt := hidepid(path("proc/mounts")); opt != "" && !hasCap(capSysPtrace) {
+		out = append(out, Finding{
+			Name: "/proc", Level: Warn,
+			Detail: "mounted with " + opt + ": other users' processes are invisible",
+			Fix:    "run as root, or remount /proc without hidepid",
+		})
+	} else {
+		out = append(out, Finding{Name: "/proc", Level: OK, Detail: "socket tables and processes visible"})
+	}
+
+	// sock_diag gives per-socket byte counters; without it sstop counts
+	// packets itself, which needs a raw socket
+	if err := probeSockDiag(); err == nil {
+		out = append(out, Finding{Name: "sock_diag", Level: OK, Detail: "netlink INET_DIAG available (per-socket byte counters)"})
+	} else {
+		f := Finding{
+			Name: "sock_diag", Level: Warn,
+			Detail: fmt.Sprintf("INET_DIAG unavailable (%v); falling back to /proc/net plus packet capture", err),
+			Fix:    "sudo modprobe tcp_diag udp_diag (sstop tries this itself when run as root)",
+		}
+		if !hasCap(capNetRaw) {
+			f.Level = Fail
+			f.Detail = fmt.Sprintf("INET_DIAG unavailable (%v) and packet capture needs root: all rates will read zero", err)
+			f.Fix = "sudo modprobe tcp_diag udp_diag, or run sstop as root"
+		}
+		if _, err := os.Stat(path("sys/module/tcp_diag")); err == nil {
+			f.Fix = "tcp_diag is loaded but not answering; run sstop as root (needs CAP_NET_ADMIN in some containers)"
+		}
+		out = append(out, f)
+	}
+
+	out = append(out, ebpfCheck(path), cgroupCheck(path))
+	return out
+}
+
+// ebpfCheck reports eBPF readiness: whether this build has an eBPF backend,
+// and whether the kernel has BTF for one.
+func ebpfCheck(path func(string) string) Finding {
+	compiled := false
+	for _, b := range version.Backends() {
+		if b.Name == "ebpf" {
+			compiled = b.Enabled
+		}
+	}
+	_, btfErr := os.Stat(path("sys/kernel/btf/vmlinux"))
+	btf := "kernel BTF available"
+	if btfErr != nil {
+		btf = "no kernel BTF"
+	}
+	if !compiled {
+		return Finding{Name: "ebpf", Level: OK, Detail: "not used by this build (" + btf + ")"}
+	}
+	if btfErr != nil {
+		return Finding{
+			Name: "ebpf", Level: Warn,
+			Detail: "eBPF backend needs /sys/kernel/btf/vmlinux",
+			Fix:    "use a kernel built with CONFIG_DEBUG_INFO_BTF=y; sstop falls back to sock_diag meanwhile",
+		}
+	}
+	return Finding{Name: "ebpf", Level: OK, Detail: btf}
+}
+
+// cgroupCheck reports the cgroup version; container and service names come
+// from /proc/<pid>/cgroup under either.
+func cgroupCheck(path func(string) string) Finding {
+	if _, err := os.Stat(path("sys/fs/cgroup/cgroup.controllers")); err == nil {
+		return Finding{Name: "cgroup", Level: OK, Detail: "v2 (unified)"}
+	}
+	if _, err := os.Stat(path("sys/fs/cgroup/unified")); err == nil {
+		return Finding{Name: "cgroup", Level: OK, Detail: "v1 with v2 hybrid"}
+	}
+	if _, err := os.Stat(path("sys/fs/cgroup")); err == nil {
+		return Finding{Name: "cgroup", Level: OK, Detail: "v1"}
+	}
+	return Finding{
+		Name: "cgroup", Level: Warn,
+		Detail: "no cgroup filesystem: container, pod and service names unavailable",
+		Fix:    "mount cgroup2 on /sys/fs/cgroup",
+	}
+}
+
+// effectiveCaps returns the CapEff mask from a /proc/<pid>/status file.
+func effectiveCaps(statusPath string) uint64 {
+	data, err := os.ReadFile(statusPath)
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(line, "CapEff:"); ok {
+			caps, _ := strconv.ParseUint(strings.TrimSpace(v), 16, 64)
+			return caps
+		}
+	}
+	return 0
+}
+
+// hidepid returns the hidepid= option /proc is mounted with, "" if none
+// (or hidepid=0).
+func hidepid(mountsPath string) string {
+	data, err := os.ReadFile(mountsPath)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[1] != "/proc" {
+			continue
+		}
+		for _, opt := range strings.Split(fields[3], ",") {
+			if strings.HasPrefix(opt, "hidepid=") && opt != "hidepid=0" && opt != "hidepid=off" {
+				return opt
+			}
+		}
+	}
+	return ""
+}
+
+func errText(err error) string {
+	if errors.Is(err, fs.ErrNotExist) {
+		return "not found"
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		return "permission denied"
+	}
+	return err.Error()
+}
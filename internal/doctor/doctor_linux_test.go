@@ -0,0 +1,50 @@
+//go:build linux
+
+package doctor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/googlesky/sstop/internal/platform"
+)
+
+func TestLinuxChecksUnprivileged(t *testing.T) {
+	root := t.TempDir()
+	write := func(path, content string) {
+		p := filepath.Join(root, path)
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("proc/net/tcp", "")
+	write("proc/self/status", "Name:\tsstop\nCapEff:\t0000000000000000\n")
+	write("proc/mounts", "proc /proc proc rw,nosuid,hidepid=invisible 0 0\n")
+	write("sys/fs/cgroup/cgroup.controllers", "cpu memory\n")
+
+	probeSockDiag = func() error { return errors.New("no such file or directory") }
+	geteuid = func() int { return 1000 }
+	t.Cleanup(func() { probeSockDiag, geteuid = platform.ProbeSockDiag, os.Geteuid })
+
+	got := make(map[string]Finding)
+	for _, f := range linuxChecks(root) {
+		got[f.Name] = f
+	}
+	want := map[string]Level{
+		"permissions": Warn, // no proc/1/fd
+		"/proc":       Warn, // hidepid without CAP_SYS_PTRACE
+		"sock_diag":   Fail, // no diag and no CAP_NET_RAW: everything zero
+		"cgroup":      OK,
+	}
+	for name, level := range want {
+		if got[name].Level != level {
+			t.Errorf("%s = %+v, want level %v", name, got[name], level)
+		}
+	}
+	if got["cgroup"].Detail != "v2 (unified)" {
+		t.Errorf("cgroup = %q", got["cgroup"].Detail)
+	}
+}
@@ -0,0 +1,43 @@
+//go:build !linux
+
+package doctor
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+func systemChecks() []Finding {
+	switch runtime.GOOS {
+	case "darwin":
+		var out []Finding
+		for _, tool := range []string{"netstat", "lsof"} {
+			if _, err := exec.LookPath(tool); err != nil {
+				out = append(out, Finding{
+					Name: tool, Level: Fail,
+					Detail: tool + " not found in PATH; sstop reads sockets through it",
+					Fix:    "restore /usr/sbin (netstat) and /usr/sbin/lsof to PATH",
+				})
+			} else {
+				out = append(out, Finding{Name: tool, Level: OK, Detail: "found"})
+			}
+		}
+		if os.Geteuid() != 0 {
+			out = append(out, Finding{
+				Name: "permissions", Level: Warn,
+				Detail: "not root: lsof only attributes your own processes' sockets",
+				Fix:    "run with sudo",
+			})
+		} else {
+			out = append(out, Finding{Name: "permissions", Level: OK, Detail: "root"})
+		}
+		return out
+	case "windows":
+		return []Finding{{
+			Name: "permissions", Level: OK,
+			Detail: "per-connection byte counters need an Administrator prompt; without one rates read zero",
+		}}
+	}
+	return []Finding{{Name: "platform", Level: Warn, Detail: runtime.GOOS + " is not supported", Fix: "use Linux, macOS or Windows"}}
+}
@@ -0,0 +1,79 @@
+package doctor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(k string) string { return vars[k] }
+}
+
+func TestTerminalChecks(t *testing.T) {
+	levels := func(fs []Finding) map[string]Level {
+		m := make(map[string]Level)
+		for _, f := range fs {
+			m[f.Name] = f.Level
+		}
+		return m
+	}
+
+	good := levels(terminalChecks(env(map[string]string{"TERM": "xterm-256color", "LANG": "en_US.UTF-8"}), true, 120, 40))
+	for _, name := range []string{"terminal", "locale", "size"} {
+		if good[name] != OK {
+			t.Errorf("%s = %v on a good terminal", name, good[name])
+		}
+	}
+
+	bad := levels(terminalChecks(env(map[string]string{"TERM": "dumb", "LANG": "C"}), true, 60, 8))
+	for _, name := range []string{"terminal", "locale", "size"} {
+		if bad[name] != Warn {
+			t.Errorf("%s = %v, want a warning", name, bad[name])
+		}
+	}
+
+	// LC_ALL wins over LANG
+	if l := levels(terminalChecks(env(map[string]string{"TERM": "xterm-256color", "LANG": "C", "LC_ALL": "C.UTF-8"}), true, 0, 0)); l["locale"] != OK {
+		t.Error("LC_ALL=C.UTF-8 should pass")
+	}
+	if fs := terminalChecks(env(nil), false, 0, 0); len(fs) != 1 || fs[0].Level != Warn {
+		t.Errorf("no tty: %+v", fs)
+	}
+}
+
+func TestConfigCheck(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.toml")
+	os.WriteFile(bad, []byte("[ui]\ntheme = \"dark\"\n"), 0o644)
+	if f := configCheck(bad); f.Level != Fail || !strings.Contains(f.Detail, "ui.theme") {
+		t.Errorf("invalid config: %+v", f)
+	}
+	good := filepath.Join(dir, "good.toml")
+	os.WriteFile(good, []byte("[ui]\ntheme = \"accessible\"\n"), 0o644)
+	if f := configCheck(good); f.Level != OK {
+		t.Errorf("valid config: %+v", f)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	failed := Write(&buf, []Finding{
+		{Name: "sock_diag", Level: Fail, Detail: "unavailable", Fix: "modprobe tcp_diag"},
+		{Name: "ok", Level: OK, Detail: "fine", Fix: "not shown"},
+	})
+	out := buf.String()
+	if !failed {
+		t.Error("a failure should be reported")
+	}
+	for _, want := range []string{"✗ sock_diag  unavailable", "→ modprobe tcp_diag", "✓ ok", "1 problem(s)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "not shown") {
+		t.Errorf("fix shown for a passing check:\n%s", out)
+	}
+}
@@ -0,0 +1,311 @@
+// Package firewall blocks or rate-limits traffic to a remote IP, or caps
+// a process's bandwidth, with nftables rules kept in a table of sstop's
+// own so they can be listed and all removed when sstop exits.
+package firewall
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Table is the nftables table (family inet) holding sstop's rules.
+const Table = "sstop"
+
+// Action is what a rule does to traffic to and from its IP.
+type Action int
+
+const (
+	Block        Action = iota // drop everything
+	Limit                      // drop what exceeds Rate
+	LimitProcess               // drop a process's traffic beyond Rate
+)
+
+func (a Action) String() string {
+	switch a {
+	case Limit:
+		return "limit"
+	case LimitProcess:
+		return "limit process"
+	}
+	return "block"
+}
+
+// Rule is a rule sstop created: a pair of nftables rules, one per
+// direction.
+type Rule struct {
+	ID      int // sstop's own, for Remove
+	IP      net.IP
+	Action  Action
+	Rate    uint64 // bytes/sec, for Limit and LimitProcess
+	Created time.Time
+
+	// LimitProcess: the process asked for, and the cgroup v2 (relative to
+	// /sys/fs/cgroup) whose traffic is limited, every process in it
+	PID    uint32
+	Cgroup string
+
+	handles [2]uint64 // output, input
+}
+
+func (r Rule) String() string {
+	switch r.Action {
+	case Limit:
+		return fmt.Sprintf("limit %s to %d B/s each way", r.IP, r.Rate)
+	case LimitProcess:
+		return fmt.Sprintf("limit cgroup %s (PID %d) to %d B/s each way", r.Cgroup, r.PID, r.Rate)
+	}
+	return "block " + r.IP.String()
+}
+
+// Manager creates and removes sstop's rules. Safe for concurrent use.
+type Manager struct {
+	mu     sync.Mutex
+	rules  []Rule
+	nextID int
+	ready  bool // table and chains exist
+
+	// nft runs nft with args, returning its output.
+	nft func(args ...string) ([]byte, error)
+
+	// procCgroup returns /proc/<pid>/cgroup.
+	procCgroup func(pid uint32) ([]byte, error)
+}
+
+// New creates a manager running the nft command. Nothing is changed
+// until the first rule is added.
+func New() *Manager {
+	return &Manager{nft: runNft, procCgroup: readProcCgroup}
+}
+
+func readProcCgroup(pid uint32) ([]byte, error) {
+	return os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+}
+
+func runNft(args ...string) ([]byte, error) {
+	out, err := exec.Command("nft", args...).CombinedOutput()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, errors.New("nft not found: blocking needs nftables (Linux)")
+	}
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			return nil, err
+		}
+		if strings.Contains(msg, "Operation not permitted") {
+			return nil, errors.New("nft: operation not permitted; run sstop as root")
+		}
+		return nil, fmt.Errorf("nft: %s", firstLine(msg))
+	}
+	return out, nil
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// setup creates the table and its chains. Must hold m.mu.
+func (m *Manager) setup() error {
+	if m.ready {
+		return nil
+	}
+	for _, args := range [][]string{
+		{"add", "table", "inet", Table},
+		{"add", "chain", "inet", Table, "output", "{ type filter hook output priority 0 ; }"},
+		{"add", "chain", "inet", Table, "input", "{ type filter hook input priority 0 ; }"},
+	} {
+		if _, err := m.nft(args...); err != nil {
+			return err
+		}
+	}
+	m.ready = true
+	return nil
+}
+
+// Block drops all traffic to and from ip.
+func (m *Manager) Block(ip net.IP) (Rule, error) {
+	return m.add(Rule{IP: ip, Action: Block})
+}
+
+// Limit drops traffic to and from ip beyond rate bytes/sec each way.
+func (m *Manager) Limit(ip net.IP, rate uint64) (Rule, error) {
+	if rate == 0 {
+		return Rule{}, errors.New("rate must be above 0")
+	}
+	return m.add(Rule{IP: ip, Action: Limit, Rate: rate})
+}
+
+// LimitProcess drops the traffic of pid's cgroup beyond rate bytes/sec
+// each way: nftables polices, it doesn't queue, so TCP backs off to about
+// the rate. The cgroup is the process's own, which a service or container
+// has to itself, but which other processes of a desktop session or
+// terminal may share.
+func (m *Manager) LimitProcess(pid uint32, rate uint64) (Rule, error) {
+	if rate == 0 {
+		return Rule{}, errors.New("rate must be above 0")
+	}
+	data, err := m.procCgroup(pid)
+	if err != nil {
+		return Rule{}, err
+	}
+	cg, err := cgroupV2Path(string(data))
+	if err != nil {
+		return Rule{}, err
+	}
+	// Replace a limit the process's cgroup already has
+	for _, r := range m.Rules() {
+		if r.Action == LimitProcess && r.Cgroup == cg {
+			if err := m.Remove(r.ID); err != nil {
+				return Rule{}, err
+			}
+		}
+	}
+	return m.add(Rule{Action: LimitProcess, Rate: rate, PID: pid, Cgroup: cg})
+}
+
+// cgroupV2Path returns the cgroup v2 path in /proc/<pid>/cgroup content,
+// without the leading slash.
+func cgroupV2Path(content string) (string, error) {
+	for _, line := range strings.Split(content, "\n") {
+		if path, ok := strings.CutPrefix(strings.TrimSpace(line), "0::/"); ok {
+			if path == "" {
+				return "", errors.New("process is in the root cgroup; there is nothing of its own to limit")
+			}
+			return path, nil
+		}
+	}
+	return "", errors.New("no cgroup v2 (unified hierarchy) for the process")
+}
+
+func (m *Manager) add(r Rule) (Rule, error) {
+	if r.Action != LimitProcess && (r.IP == nil || r.IP.IsUnspecified()) {
+		return Rule{}, errors.New("no IP to block")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.setup(); err != nil {
+		return Rule{}, err
+	}
+	m.nextID++
+	id := m.nextID
+	for i, dir := range [2]struct{ chain, match string }{{"output", "daddr"}, {"input", "saddr"}} {
+		args := []string{"--echo", "--handle", "add", "rule", "inet", Table, dir.chain}
+		if r.Action == LimitProcess {
+			args = append(args, processExpr(r, id, dir.chain)...)
+		} else {
+			args = append(args, ruleExpr(r, dir.match)...)
+		}
+		out, err := m.nft(args...)
+		if err == nil {
+			r.handles[i], err = parseHandle(out)
+		}
+		if err != nil {
+			if i == 1 {
+				m.deleteHandle("output", r.handles[0])
+			}
+			return Rule{}, err
+		}
+	}
+	r.ID, r.Created = id, time.Now()
+	m.rules = append(m.rules, r)
+	return r, nil
+}
+
+// connMark marks the connections of LimitProcess rule id, so replies are
+// limited too: incoming packets carry no socket to match a cgroup on.
+func connMark(id int) string {
+	return fmt.Sprintf("0x%x", 0x55540000|id&0xffff)
+}
+
+// processExpr is the nft expression limiting r's cgroup in chain: output
+// marks the cgroup's connections and polices them, input polices what
+// comes back on them.
+func processExpr(r Rule, id int, chain string) []string {
+	limit := []string{"limit", "rate", "over", strconv.FormatUint(r.Rate, 10), "bytes/second", "drop", "comment", Table}
+	if chain == "input" {
+		return append([]string{"ct", "mark", connMark(id)}, limit...)
+	}
+	level := strconv.Itoa(strings.Count(r.Cgroup, "/") + 1)
+	expr := []string{"socket", "cgroupv2", "level", level, strconv.Quote(r.Cgroup), "ct", "mark", "set", connMark(id)}
+	return append(expr, limit...)
+}
+
+// ruleExpr is the nft expression matching r's IP at match (saddr or
+// daddr) and dropping its traffic.
+func ruleExpr(r Rule, match string) []string {
+	family := "ip"
+	if r.IP.To4() == nil {
+		family = "ip6"
+	}
+	expr := []string{family, match, r.IP.String()}
+	if r.Action == Limit {
+		expr = append(expr, "limit", "rate", "over", strconv.FormatUint(r.Rate, 10), "bytes/second")
+	}
+	return append(expr, "drop", "comment", Table)
+}
+
+var handleRe = regexp.MustCompile(`# handle (\d+)`)
+
+// parseHandle reads the handle nft --echo --handle prints for a new rule.
+func parseHandle(out []byte) (uint64, error) {
+	sm := handleRe.FindSubmatch(out)
+	if sm == nil {
+		return 0, fmt.Errorf("nft: no rule handle in %q", firstLine(string(out)))
+	}
+	return strconv.ParseUint(string(sm[1]), 10, 64)
+}
+
+func (m *Manager) deleteHandle(chain string, handle uint64) error {
+	_, err := m.nft("delete", "rule", "inet", Table, chain, "handle", strconv.FormatUint(handle, 10))
+	return err
+}
+
+// Remove deletes the rule with the given ID.
+func (m *Manager) Remove(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, r := range m.rules {
+		if r.ID != id {
+			continue
+		}
+		err := m.deleteHandle("output", r.handles[0])
+		if err2 := m.deleteHandle("input", r.handles[1]); err == nil {
+			err = err2
+		}
+		if err != nil {
+			return err
+		}
+		m.rules = append(m.rules[:i], m.rules[i+1:]...)
+		return nil
+	}
+	return fmt.Errorf("no rule %d", id)
+}
+
+// Rules returns the rules in place, oldest first.
+func (m *Manager) Rules() []Rule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Rule(nil), m.rules...)
+}
+
+// Close removes every rule by deleting sstop's table.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.ready {
+		return nil
+	}
+	_, err := m.nft("delete", "table", "inet", Table)
+	m.rules, m.ready = nil, false
+	return err
+}
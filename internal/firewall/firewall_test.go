@@ -0,0 +1,145 @@
+package firewall
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeNft records nft invocations and echoes rules with increasing handles.
+type fakeNft struct {
+	calls  []string
+	handle int
+	fail   string // calls containing this fail
+}
+
+func (f *fakeNft) run(args ...string) ([]byte, error) {
+	call := strings.Join(args, " ")
+	f.calls = append(f.calls, call)
+	if f.fail != "" && strings.Contains(call, f.fail) {
+		return nil, errors.New("nft: failed")
+	}
+	if strings.HasPrefix(call, "--echo") {
+		f.handle++
+		return []byte(fmt.Sprintf("%s # handle %d\n", strings.Join(args[2:], " "), f.handle)), nil
+	}
+	return nil, nil
+}
+
+func TestManager(t *testing.T) {
+	f := &fakeNft{}
+	m := &Manager{nft: f.run}
+
+	b, err := m.Block(net.ParseIP("192.0.2.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := m.Limit(net.ParseIP("2001:db8::1"), 125000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"add table inet sstop",
+		"add chain inet sstop output { type filter hook output priority 0 ; }",
+		"add chain inet sstop input { type filter hook input priority 0 ; }",
+		"--echo --handle add rule inet sstop output ip daddr 192.0.2.1 drop comment sstop",
+		"--echo --handle add rule inet sstop input ip saddr 192.0.2.1 drop comment sstop",
+		"--echo --handle add rule inet sstop output ip6 daddr 2001:db8::1 limit rate over 125000 bytes/second drop comment sstop",
+		"--echo --handle add rule inet sstop input ip6 saddr 2001:db8::1 limit rate over 125000 bytes/second drop comment sstop",
+	}
+	if strings.Join(f.calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("nft calls:\n%s\nwant:\n%s", strings.Join(f.calls, "\n"), strings.Join(want, "\n"))
+	}
+	if rules := m.Rules(); len(rules) != 2 || rules[0].ID != b.ID || rules[1].String() != "limit 2001:db8::1 to 125000 B/s each way" {
+		t.Errorf("rules = %v", rules)
+	}
+
+	f.calls = nil
+	if err := m.Remove(b.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(f.calls, "\n"); got != "delete rule inet sstop output handle 1\ndelete rule inet sstop input handle 2" {
+		t.Errorf("remove ran:\n%s", got)
+	}
+	if rules := m.Rules(); len(rules) != 1 || rules[0].ID != l.ID {
+		t.Errorf("after remove: %v", rules)
+	}
+
+	f.calls = nil
+	if err := m.Close(); err != nil || len(f.calls) != 1 || f.calls[0] != "delete table inet sstop" {
+		t.Errorf("close: %v, ran %q", err, f.calls)
+	}
+	if len(m.Rules()) != 0 {
+		t.Error("rules left after close")
+	}
+}
+
+func TestManagerAddFails(t *testing.T) {
+	// The input rule fails: the output one is taken back out
+	f := &fakeNft{fail: "input ip saddr"}
+	m := &Manager{nft: f.run}
+	if _, err := m.Block(net.ParseIP("192.0.2.1")); err == nil {
+		t.Fatal("Block should fail")
+	}
+	if last := f.calls[len(f.calls)-1]; last != "delete rule inet sstop output handle 1" {
+		t.Errorf("last call %q, want the output rule deleted", last)
+	}
+	if len(m.Rules()) != 0 {
+		t.Error("failed rule listed")
+	}
+
+	// Nothing was set up, so there is nothing to close
+	untouched := &fakeNft{}
+	if err := (&Manager{nft: untouched.run}).Close(); err != nil || len(untouched.calls) != 0 {
+		t.Errorf("close of an unused manager ran %q", untouched.calls)
+	}
+}
+
+func TestLimitProcess(t *testing.T) {
+	f := &fakeNft{}
+	m := &Manager{nft: f.run, procCgroup: func(pid uint32) ([]byte, error) {
+		return []byte("0::/system.slice/rsync@backup.service\n"), nil
+	}}
+	m.ready = true // chains are set up elsewhere in these tests
+
+	r, err := m.LimitProcess(42, 1000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Cgroup != "system.slice/rsync@backup.service" || r.PID != 42 {
+		t.Errorf("rule = %+v", r)
+	}
+	want := []string{
+		`--echo --handle add rule inet sstop output socket cgroupv2 level 2 "system.slice/rsync@backup.service" ct mark set 0x55540001 limit rate over 1000000 bytes/second drop comment sstop`,
+		`--echo --handle add rule inet sstop input ct mark 0x55540001 limit rate over 1000000 bytes/second drop comment sstop`,
+	}
+	if got := strings.Join(f.calls, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("nft calls:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	// A new limit on the same cgroup replaces the old one
+	if _, err := m.LimitProcess(43, 500000); err != nil {
+		t.Fatal(err)
+	}
+	if rules := m.Rules(); len(rules) != 1 || rules[0].Rate != 500000 {
+		t.Errorf("rules = %v, want only the new limit", rules)
+	}
+}
+
+func TestCgroupV2Path(t *testing.T) {
+	for content, want := range map[string]string{
+		"0::/user.slice/user-1000.slice/session-2.scope\n": "user.slice/user-1000.slice/session-2.scope",
+		"12:pids:/x\n0::/kubepods.slice/pod1\n":            "kubepods.slice/pod1",
+	} {
+		if got, err := cgroupV2Path(content); err != nil || got != want {
+			t.Errorf("cgroupV2Path(%q) = %q, %v, want %q", content, got, err, want)
+		}
+	}
+	for _, content := range []string{"0::/\n", "4:cpu:/foo\n", ""} {
+		if _, err := cgroupV2Path(content); err == nil {
+			t.Errorf("cgroupV2Path(%q) should fail", content)
+		}
+	}
+}
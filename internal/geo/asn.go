@@ -0,0 +1,110 @@
+package geo
+
+import (
+	"fmt"
+	"net"
+)
+
+// ASNInfo identifies the autonomous system announcing an IP.
+type ASNInfo struct {
+	Number uint32 // e.g. 15169
+	Org    string // e.g. "GOOGLE"
+}
+
+// LookupASN returns the autonomous system for an IP address.
+// Only major cloud/CDN providers are covered; returns empty ASNInfo otherwise.
+func LookupASN(ip net.IP) ASNInfo {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return ASNInfo{}
+	}
+
+	ipNum := ipToUint32(ip4)
+	for i := range asnRanges {
+		r := &asnRanges[i]
+		if ipNum >= r.start && ipNum <= r.end {
+			return ASNInfo{Number: r.asn, Org: asnOrgs[r.asn]}
+		}
+	}
+	return ASNInfo{}
+}
+
+// Format returns "AS15169 GOOGLE" style string, or "" if unknown.
+func (a ASNInfo) Format() string {
+	if a.Number == 0 {
+		return ""
+	}
+	return fmt.Sprintf("AS%d %s", a.Number, a.Org)
+}
+
+// asnRange represents a range of IPs announced by one AS.
+type asnRange struct {
+	start uint32
+	end   uint32
+	asn   uint32
+}
+
+// asnOrgs maps AS numbers to short organization names.
+var asnOrgs = map[uint32]string{
+	15169: "GOOGLE",
+	16509: "AMAZON-02",
+	8075:  "MICROSOFT-CORP",
+	13335: "CLOUDFLARENET",
+	32934: "FACEBOOK",
+	20940: "AKAMAI",
+	714:   "APPLE",
+}
+
+// asnRanges mirrors the provider ranges in ipRanges.
+var asnRanges = []asnRange{
+	// Google
+	{ipToU32(8, 8, 4, 0), ipToU32(8, 8, 8, 255), 15169},
+	{ipToU32(8, 34, 208, 0), ipToU32(8, 35, 207, 255), 15169},
+	{ipToU32(34, 0, 0, 0), ipToU32(34, 127, 255, 255), 15169},
+	{ipToU32(35, 184, 0, 0), ipToU32(35, 199, 255, 255), 15169},
+	{ipToU32(64, 233, 160, 0), ipToU32(64, 233, 191, 255), 15169},
+	{ipToU32(66, 102, 0, 0), ipToU32(66, 102, 15, 255), 15169},
+	{ipToU32(66, 249, 64, 0), ipToU32(66, 249, 95, 255), 15169},
+	{ipToU32(72, 14, 192, 0), ipToU32(72, 14, 255, 255), 15169},
+	{ipToU32(74, 125, 0, 0), ipToU32(74, 125, 255, 255), 15169},
+	{ipToU32(142, 250, 0, 0), ipToU32(142, 251, 255, 255), 15169},
+	{ipToU32(172, 217, 0, 0), ipToU32(172, 217, 255, 255), 15169},
+	{ipToU32(173, 194, 0, 0), ipToU32(173, 194, 255, 255), 15169},
+	{ipToU32(209, 85, 128, 0), ipToU32(209, 85, 255, 255), 15169},
+	{ipToU32(216, 58, 192, 0), ipToU32(216, 58, 223, 255), 15169},
+
+	// Amazon AWS
+	{ipToU32(3, 0, 0, 0), ipToU32(3, 127, 255, 255), 16509},
+	{ipToU32(13, 32, 0, 0), ipToU32(13, 35, 255, 255), 16509},
+	{ipToU32(13, 224, 0, 0), ipToU32(13, 255, 255, 255), 16509},
+	{ipToU32(52, 0, 0, 0), ipToU32(52, 95, 255, 255), 16509},
+	{ipToU32(54, 64, 0, 0), ipToU32(54, 95, 255, 255), 16509},
+	{ipToU32(54, 144, 0, 0), ipToU32(54, 255, 255, 255), 16509},
+
+	// Microsoft/Azure
+	{ipToU32(13, 64, 0, 0), ipToU32(13, 107, 255, 255), 8075},
+	{ipToU32(20, 0, 0, 0), ipToU32(20, 63, 255, 255), 8075},
+	{ipToU32(40, 64, 0, 0), ipToU32(40, 127, 255, 255), 8075},
+	{ipToU32(52, 96, 0, 0), ipToU32(52, 191, 255, 255), 8075},
+	{ipToU32(104, 40, 0, 0), ipToU32(104, 47, 255, 255), 8075},
+	{ipToU32(204, 79, 195, 0), ipToU32(204, 79, 197, 255), 8075},
+
+	// Cloudflare
+	{ipToU32(1, 0, 0, 0), ipToU32(1, 1, 1, 255), 13335},
+	{ipToU32(104, 16, 0, 0), ipToU32(104, 31, 255, 255), 13335},
+	{ipToU32(172, 64, 0, 0), ipToU32(172, 71, 255, 255), 13335},
+	{ipToU32(188, 114, 96, 0), ipToU32(188, 114, 99, 255), 13335},
+	{ipToU32(198, 41, 128, 0), ipToU32(198, 41, 255, 255), 13335},
+
+	// Meta/Facebook
+	{ipToU32(31, 13, 24, 0), ipToU32(31, 13, 31, 255), 32934},
+	{ipToU32(157, 240, 0, 0), ipToU32(157, 240, 255, 255), 32934},
+	{ipToU32(179, 60, 192, 0), ipToU32(179, 60, 195, 255), 32934},
+
+	// Akamai
+	{ipToU32(23, 0, 0, 0), ipToU32(23, 79, 255, 255), 20940},
+	{ipToU32(104, 64, 0, 0), ipToU32(104, 127, 255, 255), 20940},
+
+	// Apple
+	{ipToU32(17, 0, 0, 0), ipToU32(17, 255, 255, 255), 714},
+}
@@ -9,20 +9,35 @@ import (
 type CountryInfo struct {
 	Code string // e.g. "US"
 	Flag string // e.g. "🇺🇸"
+	City string // e.g. "Berlin"; only with a City database loaded
 }
 
-// Lookup returns the country for an IP address.
-// Returns empty CountryInfo for unknown IPs.
+// Lookup returns the country for an IP address, from the MaxMind database
+// if one is loaded (see OpenDB), else from the embedded ranges.
+// Returns empty CountryInfo for unknown IPs. Results are cached per address.
 func Lookup(ip net.IP) CountryInfo {
 	if ip == nil {
 		return CountryInfo{}
 	}
+	ip16 := ip.To16()
+	if ip16 == nil {
+		return CountryInfo{}
+	}
+	key := [16]byte(ip16)
+	if info, ok := lookupCache.get(key); ok {
+		return info
+	}
+	info := lookup(ip)
+	lookupCache.add(key, info)
+	return info
+}
 
+func lookup(ip net.IP) CountryInfo {
 	// Normalize to IPv4
 	ip4 := ip.To4()
 	if ip4 == nil {
-		// IPv6 — not supported in simple mode
-		return CountryInfo{}
+		// IPv6 — needs the database
+		return lookupIPv6(ip)
 	}
 
 	// Check private/special IPs first
@@ -36,22 +51,13 @@ func Lookup(ip net.IP) CountryInfo {
 		return CountryInfo{Code: "MC", Flag: "📡"}
 	}
 
-	// Find the most specific (smallest) matching range
-	ipNum := ipToUint32(ip4)
-	var bestMatch *ipRange
-	for i := range ipRanges {
-		r := &ipRanges[i]
-		if ipNum >= r.start && ipNum <= r.end {
-			if bestMatch == nil || (r.end-r.start) < (bestMatch.end-bestMatch.start) {
-				bestMatch = r
-			}
-		}
+	if info, ok := lookupDB(ip4); ok {
+		return info
 	}
 
-	if bestMatch != nil {
-		return CountryInfo{Code: bestMatch.country, Flag: countryFlag(bestMatch.country)}
+	if code := lookupRange(ipToUint32(ip4)); code != "" {
+		return CountryInfo{Code: code, Flag: countryFlag(code)}
 	}
-
 	return CountryInfo{}
 }
 
@@ -63,6 +69,22 @@ func (c CountryInfo) Format() string {
 	return c.Flag + " " + c.Code
 }
 
+func lookupIPv6(ip net.IP) CountryInfo {
+	if !hasDB() {
+		return CountryInfo{}
+	}
+	switch {
+	case ip.IsLoopback():
+		return CountryInfo{Code: "LO", Flag: "🏠"}
+	case ip.IsPrivate(), ip.IsLinkLocalUnicast():
+		return CountryInfo{Code: "LAN", Flag: "🏠"}
+	case ip.IsMulticast():
+		return CountryInfo{Code: "MC", Flag: "📡"}
+	}
+	info, _ := lookupDB(ip)
+	return info
+}
+
 func isPrivate(ip net.IP) bool {
 	privateRanges := []struct {
 		network string
@@ -109,8 +131,9 @@ type ipRange struct {
 	country string
 }
 
-// ipRanges is a sorted list of IP ranges for major cloud/CDN providers and countries.
-// Covers the most common IP ranges seen in network traffic.
+// ipRanges is a list of IP ranges for major cloud/CDN providers and countries.
+// Covers the most common IP ranges seen in network traffic. Ranges may
+// overlap; the most specific wins (see buildRangeTable).
 var ipRanges = []ipRange{
 	// Google (US)
 	{ipToU32(8, 8, 4, 0), ipToU32(8, 8, 8, 255), "US"},
@@ -77,3 +77,64 @@ func TestFormat(t *testing.T) {
 		t.Errorf("empty Format() = %q, want empty", empty.Format())
 	}
 }
+
+func TestLookupASN(t *testing.T) {
+	tests := []struct {
+		ip   string
+		asn  uint32
+		text string
+	}{
+		{"8.8.8.8", 15169, "AS15169 GOOGLE"},
+		{"1.1.1.1", 13335, "AS13335 CLOUDFLARENET"},
+		{"52.10.0.1", 16509, "AS16509 AMAZON-02"},
+		{"52.100.0.1", 8075, "AS8075 MICROSOFT-CORP"},
+		{"192.168.1.1", 0, ""},
+		{"2001:4860::8888", 0, ""},
+	}
+	for _, tt := range tests {
+		info := LookupASN(net.ParseIP(tt.ip))
+		if info.Number != tt.asn || info.Format() != tt.text {
+			t.Errorf("LookupASN(%s) = %d %q, want %d %q", tt.ip, info.Number, info.Format(), tt.asn, tt.text)
+		}
+	}
+}
+
+func TestRangeTableMatchesLinearScan(t *testing.T) {
+	for i := 1; i < len(rangeTable); i++ {
+		if rangeTable[i].start <= rangeTable[i-1].end {
+			t.Fatalf("rangeTable[%d] overlaps its predecessor", i)
+		}
+	}
+	// Probe every boundary and its neighbours
+	for _, r := range ipRanges {
+		for _, ipNum := range []uint32{r.start - 1, r.start, r.start + 1, r.end - 1, r.end, r.end + 1} {
+			want := ""
+			if best := mostSpecific(ipRanges, ipNum); best != nil {
+				want = best.country
+			}
+			if got := lookupRange(ipNum); got != want {
+				t.Errorf("lookupRange(%#x) = %q, want %q", ipNum, got, want)
+			}
+		}
+	}
+}
+
+func TestLookupCacheEvicts(t *testing.T) {
+	c := newLRU(2)
+	a, b, d := [16]byte{1}, [16]byte{2}, [16]byte{3}
+	c.add(a, CountryInfo{Code: "US"})
+	c.add(b, CountryInfo{Code: "DE"})
+	c.get(a) // a is now most recent
+	c.add(d, CountryInfo{Code: "JP"})
+
+	if _, ok := c.get(b); ok {
+		t.Error("least recently used entry should be evicted")
+	}
+	if info, ok := c.get(a); !ok || info.Code != "US" {
+		t.Errorf("get(a) = %v, %v", info, ok)
+	}
+	c.purge()
+	if _, ok := c.get(d); ok {
+		t.Error("purge should empty the cache")
+	}
+}
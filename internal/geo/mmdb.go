@@ -0,0 +1,121 @@
+package geo
+
+import (
+	"net"
+	"os"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/googlesky/sstop/internal/version"
+)
+
+func init() {
+	version.RegisterBackend("mmdb")
+}
+
+// DefaultDBPaths are the standard GeoLite2/GeoIP2 install locations searched
+// by OpenDefaultDB, City databases first (geoipupdate, distro packages,
+// Homebrew).
+var DefaultDBPaths = []string{
+	"/usr/share/GeoIP/GeoLite2-City.mmdb",
+	"/var/lib/GeoIP/GeoLite2-City.mmdb",
+	"/usr/local/share/GeoIP/GeoLite2-City.mmdb",
+	"/opt/homebrew/var/GeoIP/GeoLite2-City.mmdb",
+	"/usr/share/GeoIP/GeoLite2-Country.mmdb",
+	"/var/lib/GeoIP/GeoLite2-Country.mmdb",
+	"/usr/local/share/GeoIP/GeoLite2-Country.mmdb",
+	"/opt/homebrew/var/GeoIP/GeoLite2-Country.mmdb",
+}
+
+// The loaded MaxMind database; nil means the embedded ranges are used.
+var (
+	dbMu sync.RWMutex
+	db   *maxminddb.Reader
+)
+
+// mmdbRecord is the subset of the GeoIP2 Country/City schema sstop reads.
+type mmdbRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// OpenDB loads a GeoLite2/GeoIP2 Country or City .mmdb file. Lookups use it
+// from then on, falling back to the embedded ranges for addresses it doesn't
+// cover.
+func OpenDB(path string) error {
+	r, err := maxminddb.Open(path)
+	if err != nil {
+		return err
+	}
+	dbMu.Lock()
+	old := db
+	db = r
+	dbMu.Unlock()
+	lookupCache.purge()
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// OpenDefaultDB loads the first database found in DefaultDBPaths and returns
+// its path, or "" if none could be opened.
+func OpenDefaultDB() string {
+	for _, path := range DefaultDBPaths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if OpenDB(path) == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// CloseDB unloads the database, reverting to the embedded ranges.
+func CloseDB() {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	if db != nil {
+		db.Close()
+		db = nil
+	}
+	lookupCache.purge()
+}
+
+// lookupDB returns the country and city for ip from the loaded database.
+// ok is false when no database is loaded or it has no country for ip.
+func lookupDB(ip net.IP) (info CountryInfo, ok bool) {
+	dbMu.RLock()
+	defer dbMu.RUnlock()
+	if db == nil {
+		return info, false
+	}
+	var rec mmdbRecord
+	if err := db.Lookup(ip, &rec); err != nil {
+		return info, false
+	}
+	code := rec.Country.ISOCode
+	if code == "" {
+		code = rec.RegisteredCountry.ISOCode
+	}
+	if code == "" {
+		return info, false
+	}
+	return CountryInfo{Code: code, Flag: countryFlag(code), City: rec.City.Names["en"]}, true
+}
+
+// hasDB reports whether a database is loaded.
+func hasDB() bool {
+	dbMu.RLock()
+	defer dbMu.RUnlock()
+	return db != nil
+}
@@ -0,0 +1,241 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/googlesky/sstop/internal/version"
+)
+
+// Minimal MaxMind DB writer for tests: an IPv6 tree (IPv4 under ::/96) with
+// 24-bit records, and just the data types the GeoIP2 schema needs.
+
+type mmdbEntry struct {
+	cidr string
+	data map[string]any
+}
+
+func mmdbEncode(buf *bytes.Buffer, v any) {
+	ctrl := func(typ, size int) {
+		if typ > 7 {
+			buf.WriteByte(byte(size))
+			buf.WriteByte(byte(typ - 7))
+			return
+		}
+		buf.WriteByte(byte(typ<<5 | size))
+	}
+	switch v := v.(type) {
+	case string:
+		ctrl(2, len(v))
+		buf.WriteString(v)
+	case uint16:
+		ctrl(5, 2)
+		binary.Write(buf, binary.BigEndian, v)
+	case uint32:
+		ctrl(6, 4)
+		binary.Write(buf, binary.BigEndian, v)
+	case uint64:
+		ctrl(9, 8)
+		binary.Write(buf, binary.BigEndian, v)
+	case []string:
+		ctrl(11, len(v))
+		for _, s := range v {
+			mmdbEncode(buf, s)
+		}
+	case map[string]any:
+		ctrl(7, len(v))
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			mmdbEncode(buf, k)
+			mmdbEncode(buf, v[k])
+		}
+	default:
+		panic("mmdbEncode: unsupported type")
+	}
+}
+
+type mmdbNode struct {
+	child [2]*mmdbNode
+	data  int // data section offset for leaves, -1 otherwise
+}
+
+func writeTestMMDB(t *testing.T, entries []mmdbEntry) string {
+	t.Helper()
+
+	var data bytes.Buffer
+	root := &mmdbNode{data: -1}
+	for _, e := range entries {
+		_, ipnet, err := net.ParseCIDR(e.cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ones, bits := ipnet.Mask.Size()
+		ip := ipnet.IP.To16()
+		if bits == 32 {
+			ip = append(make(net.IP, 12), ipnet.IP.To4()...)
+			ones += 96
+		}
+		off := data.Len()
+		mmdbEncode(&data, e.data)
+
+		n := root
+		for i := 0; i < ones; i++ {
+			bit := ip[i/8] >> (7 - i%8) & 1
+			if n.child[bit] == nil {
+				n.child[bit] = &mmdbNode{data: -1}
+			}
+			n = n.child[bit]
+		}
+		n.data = off
+	}
+
+	// Number internal nodes breadth-first
+	var order []*mmdbNode
+	index := map[*mmdbNode]int{}
+	for queue := []*mmdbNode{root}; len(queue) > 0; queue = queue[1:] {
+		n := queue[0]
+		index[n] = len(order)
+		order = append(order, n)
+		for _, c := range n.child {
+			if c != nil && c.data < 0 {
+				queue = append(queue, c)
+			}
+		}
+	}
+	nodeCount := len(order)
+
+	var out bytes.Buffer
+	for _, n := range order {
+		for _, c := range n.child {
+			rec := nodeCount // empty
+			if c != nil && c.data >= 0 {
+				rec = nodeCount + 16 + c.data
+			} else if c != nil {
+				rec = index[c]
+			}
+			out.Write([]byte{byte(rec >> 16), byte(rec >> 8), byte(rec)})
+		}
+	}
+	out.Write(make([]byte, 16))
+	out.Write(data.Bytes())
+	out.WriteString("\xab\xcd\xefMaxMind.com")
+	mmdbEncode(&out, map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(0),
+		"database_type":               "GeoLite2-City",
+		"description":                 map[string]any{"en": "sstop test"},
+		"ip_version":                  uint16(6),
+		"languages":                   []string{"en"},
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+	})
+
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(path, out.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func city(code, name string) map[string]any {
+	m := map[string]any{"country": map[string]any{"iso_code": code}}
+	if name != "" {
+		m["city"] = map[string]any{"names": map[string]any{"en": name}}
+	}
+	return m
+}
+
+func TestLookupWithDB(t *testing.T) {
+	path := writeTestMMDB(t, []mmdbEntry{
+		{"8.8.8.0/24", city("US", "Mountain View")},
+		{"81.0.0.0/8", city("DE", "Berlin")},
+		{"2a00:1450::/32", city("IE", "Dublin")},
+		{"5.5.0.0/16", map[string]any{"registered_country": map[string]any{"iso_code": "NL"}}},
+	})
+	if err := OpenDB(path); err != nil {
+		t.Fatal(err)
+	}
+	defer CloseDB()
+
+	tests := []struct {
+		ip         string
+		code, city string
+	}{
+		{"8.8.8.8", "US", "Mountain View"},
+		{"81.2.3.4", "DE", "Berlin"},
+		{"2a00:1450::200e", "IE", "Dublin"},
+		{"5.5.1.1", "NL", ""},      // registered country fallback
+		{"1.1.1.1", "US", ""},      // not in the database: embedded ranges
+		{"192.168.1.1", "LAN", ""}, // private ranges never hit the database
+		{"fd00::1", "LAN", ""},
+		{"::1", "LO", ""},
+		{"2001:db8::1", "", ""},
+	}
+	for _, tt := range tests {
+		got := Lookup(net.ParseIP(tt.ip))
+		if got.Code != tt.code || got.City != tt.city {
+			t.Errorf("Lookup(%s) = %q %q, want %q %q", tt.ip, got.Code, got.City, tt.code, tt.city)
+		}
+	}
+
+	CloseDB()
+	if got := Lookup(net.ParseIP("81.2.3.4")); got.City != "" {
+		t.Errorf("after CloseDB city = %q, want embedded ranges only", got.City)
+	}
+	if got := Lookup(net.ParseIP("2a00:1450::200e")); got.Code != "" {
+		t.Errorf("IPv6 without database = %q, want unknown", got.Code)
+	}
+}
+
+func TestOpenDBErrors(t *testing.T) {
+	if err := OpenDB(filepath.Join(t.TempDir(), "missing.mmdb")); err == nil {
+		t.Error("OpenDB of a missing file should fail")
+	}
+	bad := filepath.Join(t.TempDir(), "bad.mmdb")
+	os.WriteFile(bad, []byte("not a database"), 0o644)
+	if err := OpenDB(bad); err == nil {
+		t.Error("OpenDB of a non-mmdb file should fail")
+	}
+	if hasDB() {
+		t.Error("failed OpenDB left a database loaded")
+	}
+}
+
+func TestOpenDefaultDB(t *testing.T) {
+	saved := DefaultDBPaths
+	defer func() { DefaultDBPaths = saved; CloseDB() }()
+
+	path := writeTestMMDB(t, []mmdbEntry{{"81.0.0.0/8", city("DE", "Berlin")}})
+	DefaultDBPaths = []string{filepath.Join(t.TempDir(), "missing.mmdb"), path}
+	if got := OpenDefaultDB(); got != path {
+		t.Errorf("OpenDefaultDB = %q, want %q", got, path)
+	}
+
+	CloseDB()
+	DefaultDBPaths = DefaultDBPaths[:1]
+	if got := OpenDefaultDB(); got != "" {
+		t.Errorf("OpenDefaultDB with nothing installed = %q", got)
+	}
+}
+
+func TestMMDBBackendRegistered(t *testing.T) {
+	for _, b := range version.Backends() {
+		if b.Name == "mmdb" {
+			if !b.Enabled {
+				t.Error("mmdb backend not registered")
+			}
+			return
+		}
+	}
+	t.Error("mmdb missing from the backend list")
+}
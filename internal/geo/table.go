@@ -0,0 +1,123 @@
+package geo
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+)
+
+// rangeTable is ipRanges flattened into sorted, non-overlapping intervals,
+// each carrying the country of the most specific range covering it.
+var rangeTable = buildRangeTable(ipRanges)
+
+// buildRangeTable resolves overlaps between ranges once, so lookups can
+// binary search. Adjacent intervals of the same country are merged.
+func buildRangeTable(ranges []ipRange) []ipRange {
+	// The answer can only change where some range starts or ends
+	bounds := make([]uint64, 0, 2*len(ranges))
+	for _, r := range ranges {
+		bounds = append(bounds, uint64(r.start), uint64(r.end)+1)
+	}
+	sort.Slice(bounds, func(i, j int) bool { return bounds[i] < bounds[j] })
+
+	var table []ipRange
+	for i := 0; i+1 < len(bounds); i++ {
+		lo, hi := bounds[i], bounds[i+1]-1
+		if bounds[i+1] == lo {
+			continue // duplicate boundary
+		}
+		best := mostSpecific(ranges, uint32(lo))
+		if best == nil {
+			continue
+		}
+		if n := len(table); n > 0 && table[n-1].country == best.country && uint64(table[n-1].end)+1 == lo {
+			table[n-1].end = uint32(hi)
+			continue
+		}
+		table = append(table, ipRange{uint32(lo), uint32(hi), best.country})
+	}
+	return table
+}
+
+// mostSpecific returns the smallest range containing ipNum, the first one
+// listed on ties, or nil.
+func mostSpecific(ranges []ipRange, ipNum uint32) *ipRange {
+	var best *ipRange
+	for i := range ranges {
+		r := &ranges[i]
+		if ipNum >= r.start && ipNum <= r.end {
+			if best == nil || (r.end-r.start) < (best.end-best.start) {
+				best = r
+			}
+		}
+	}
+	return best
+}
+
+// lookupRange returns the embedded country code for an IPv4 address, or "".
+func lookupRange(ipNum uint32) string {
+	i := sort.Search(len(rangeTable), func(i int) bool { return rangeTable[i].end >= ipNum })
+	if i < len(rangeTable) && rangeTable[i].start <= ipNum {
+		return rangeTable[i].country
+	}
+	return ""
+}
+
+// lookupCacheSize bounds the per-address cache; remote host counts beyond
+// it just evict the least recently seen.
+const lookupCacheSize = 4096
+
+var lookupCache = newLRU(lookupCacheSize)
+
+// lru is a fixed-size least-recently-used cache of lookup results keyed by
+// 16-byte IP. Safe for concurrent use.
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // front = most recent; values are *lruEntry
+	items map[[16]byte]*list.Element
+}
+
+type lruEntry struct {
+	key  [16]byte
+	info CountryInfo
+}
+
+func newLRU(size int) *lru {
+	return &lru{size: size, order: list.New(), items: make(map[[16]byte]*list.Element)}
+}
+
+func (c *lru) get(key [16]byte) (CountryInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return CountryInfo{}, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*lruEntry).info, true
+}
+
+func (c *lru) add(key [16]byte, info CountryInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value.(*lruEntry).info = info
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry{key, info})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// purge drops every entry; called when the database changes.
+func (c *lru) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.items)
+}
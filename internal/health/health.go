@@ -0,0 +1,157 @@
+// Package health serves liveness, readiness and version endpoints so that
+// long-running sstop processes can be supervised by systemd or Kubernetes
+// probes.
+package health
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/version"
+)
+
+// Server exposes /healthz, /readyz and /version over HTTP.
+//
+//   - /healthz reports 200 as long as the process is serving requests.
+//   - /readyz reports 200 once a snapshot has been produced and the latest
+//     one is fresher than the staleness limit, 503 otherwise.
+//   - /version reports build information as JSON.
+type Server struct {
+	mu       sync.Mutex
+	lastSnap time.Time
+	snaps    uint64
+	maxAge   time.Duration
+	now      func() time.Time
+
+	srv *http.Server
+}
+
+// New creates a health server. maxAge is how old the latest snapshot may be
+// before the server reports itself as not ready (typically a few poll intervals).
+func New(maxAge time.Duration) *Server {
+	s := &Server{
+		maxAge: maxAge,
+		now:    time.Now,
+	}
+	s.srv = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the HTTP handler serving the health endpoints.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	mux.HandleFunc("/version", s.handleVersion)
+	return mux
+}
+
+// ListenAndServe starts serving on addr in a background goroutine.
+// Returns an error if the address cannot be bound.
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go s.srv.Serve(ln)
+	return nil
+}
+
+// Close stops the HTTP server.
+func (s *Server) Close() error {
+	return s.srv.Close()
+}
+
+// Observe records that a snapshot was produced at the given time.
+func (s *Server) Observe(snap model.Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSnap = s.now()
+	s.snaps++
+}
+
+// Track wraps a snapshot channel, observing every snapshot while passing it through.
+func (s *Server) Track(snapCh <-chan model.Snapshot) <-chan model.Snapshot {
+	out := make(chan model.Snapshot, 1)
+	go func() {
+		defer close(out)
+		for snap := range snapCh {
+			s.Observe(snap)
+			select {
+			case out <- snap:
+			default:
+				select {
+				case <-out:
+				default:
+				}
+				out <- snap
+			}
+		}
+	}()
+	return out
+}
+
+// status is the JSON body returned by /healthz and /readyz.
+type status struct {
+	Status      string `json:"status"`
+	Snapshots   uint64 `json:"snapshots"`
+	LastSnapAge string `json:"last_snapshot_age,omitempty"`
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, status{Status: "ok", Snapshots: s.snapshotCount()})
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, _ *http.Request) {
+	ready, st := s.readiness()
+	code := http.StatusOK
+	if !ready {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, st)
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, version.Get())
+}
+
+func (s *Server) snapshotCount() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snaps
+}
+
+// readiness reports whether a fresh snapshot has been seen recently.
+func (s *Server) readiness() (bool, status) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := status{Snapshots: s.snaps}
+	if s.snaps == 0 {
+		st.Status = "waiting for first snapshot"
+		return false, st
+	}
+	age := s.now().Sub(s.lastSnap)
+	st.LastSnapAge = age.Truncate(time.Millisecond).String()
+	if s.maxAge > 0 && age > s.maxAge {
+		st.Status = "stale"
+		return false, st
+	}
+	st.Status = "ok"
+	return true, st
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
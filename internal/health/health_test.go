@@ -0,0 +1,92 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/version"
+)
+
+func get(t *testing.T, h http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+	return rr
+}
+
+func TestHealthzAlwaysOK(t *testing.T) {
+	s := New(3 * time.Second)
+	rr := get(t, s.Handler(), "/healthz")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("/healthz = %d, want 200", rr.Code)
+	}
+}
+
+func TestReadyzLifecycle(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := New(3 * time.Second)
+	s.now = func() time.Time { return now }
+	h := s.Handler()
+
+	// No snapshot yet → not ready
+	if rr := get(t, h, "/readyz"); rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz before first snapshot = %d, want 503", rr.Code)
+	}
+
+	s.Observe(model.Snapshot{})
+	if rr := get(t, h, "/readyz"); rr.Code != http.StatusOK {
+		t.Fatalf("/readyz after snapshot = %d, want 200", rr.Code)
+	}
+
+	// Collector stalls → stale
+	now = now.Add(10 * time.Second)
+	rr := get(t, h, "/readyz")
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz with stale snapshot = %d, want 503", rr.Code)
+	}
+	var st status
+	if err := json.Unmarshal(rr.Body.Bytes(), &st); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if st.Status != "stale" || st.Snapshots != 1 {
+		t.Errorf("status = %+v, want stale with 1 snapshot", st)
+	}
+}
+
+func TestVersionEndpoint(t *testing.T) {
+	s := New(time.Second)
+	rr := get(t, s.Handler(), "/version")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("/version = %d, want 200", rr.Code)
+	}
+	var info version.Info
+	if err := json.Unmarshal(rr.Body.Bytes(), &info); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if info.Version != version.Version {
+		t.Errorf("version = %q, want %q", info.Version, version.Version)
+	}
+}
+
+func TestTrackPassesSnapshotsThrough(t *testing.T) {
+	s := New(time.Second)
+	in := make(chan model.Snapshot)
+	out := s.Track(in)
+
+	in <- model.Snapshot{TotalUp: 42}
+	snap := <-out
+	if snap.TotalUp != 42 {
+		t.Errorf("TotalUp = %v, want 42", snap.TotalUp)
+	}
+	close(in)
+	if _, ok := <-out; ok {
+		t.Error("output channel should close when input closes")
+	}
+	if s.snapshotCount() != 1 {
+		t.Errorf("snapshots = %d, want 1", s.snapshotCount())
+	}
+}
@@ -0,0 +1,145 @@
+// Package history persists per-process and per-host traffic to a SQLite
+// database (--db) and answers "who used the most bandwidth" queries over it
+// (sstop query).
+package history
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // pure-Go driver, keeps cross-compiles cgo-free
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// schema is applied on every Open; statements are idempotent.
+const schema = `
+CREATE TABLE IF NOT EXISTS process_samples (
+	ts         INTEGER NOT NULL, -- unix milliseconds
+	pid        INTEGER NOT NULL,
+	name       TEXT    NOT NULL,
+	up_rate    REAL    NOT NULL, -- bytes/sec
+	down_rate  REAL    NOT NULL,
+	bytes_up   INTEGER NOT NULL, -- bytes since the previous sample
+	bytes_down INTEGER NOT NULL,
+	conns      INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS process_samples_ts ON process_samples(ts);
+CREATE INDEX IF NOT EXISTS process_samples_pid ON process_samples(pid, ts);
+
+CREATE TABLE IF NOT EXISTS host_samples (
+	ts         INTEGER NOT NULL,
+	host       TEXT    NOT NULL,
+	ip         TEXT    NOT NULL,
+	country    TEXT    NOT NULL,
+	up_rate    REAL    NOT NULL,
+	down_rate  REAL    NOT NULL,
+	bytes_up   INTEGER NOT NULL,
+	bytes_down INTEGER NOT NULL,
+	conns      INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS host_samples_ts ON host_samples(ts);
+CREATE INDEX IF NOT EXISTS host_samples_host ON host_samples(host, ts);
+`
+
+// maxSampleGap caps the interval a sample's rates are credited over, so a
+// laptop resuming from suspend doesn't book hours of traffic at the last rate.
+const maxSampleGap = time.Minute
+
+// DB is a traffic history database.
+type DB struct {
+	db   *sql.DB
+	last time.Time // timestamp of the previous written snapshot
+}
+
+// Open opens (creating if needed) the history database at path.
+func Open(path string) (*DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// A single connection serializes writers and keeps the pragmas in effect.
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		"PRAGMA journal_mode=WAL",
+		"PRAGMA busy_timeout=5000",
+		schema,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return &DB{db: db}, nil
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Write stores one snapshot: a row per process and per remote host with
+// traffic. Byte columns credit the rates over the time since the previous
+// snapshot, so the first snapshot written only sets the baseline.
+func (d *DB) Write(snap model.Snapshot) error {
+	ts := snap.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	prev := d.last
+	d.last = ts
+	if prev.IsZero() || !ts.After(prev) {
+		return nil
+	}
+	secs := min(ts.Sub(prev), maxSampleGap).Seconds()
+	ms := ts.UnixMilli()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	procStmt, err := tx.Prepare(`INSERT INTO process_samples
+		(ts, pid, name, up_rate, down_rate, bytes_up, bytes_down, conns)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer procStmt.Close()
+	for i := range snap.Processes {
+		p := &snap.Processes[i]
+		if p.UpRate == 0 && p.DownRate == 0 {
+			continue
+		}
+		if _, err := procStmt.Exec(ms, p.PID, p.Name, p.UpRate, p.DownRate,
+			int64(p.UpRate*secs), int64(p.DownRate*secs), p.ConnCount); err != nil {
+			return err
+		}
+	}
+
+	hostStmt, err := tx.Prepare(`INSERT INTO host_samples
+		(ts, host, ip, country, up_rate, down_rate, bytes_up, bytes_down, conns)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer hostStmt.Close()
+	for i := range snap.RemoteHosts {
+		h := &snap.RemoteHosts[i]
+		if h.UpRate == 0 && h.DownRate == 0 {
+			continue
+		}
+		ip := ""
+		if h.IP != nil {
+			ip = h.IP.String()
+		}
+		if _, err := hostStmt.Exec(ms, h.Host, ip, h.CountryCode, h.UpRate, h.DownRate,
+			int64(h.UpRate*secs), int64(h.DownRate*secs), h.ConnCount); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,177 @@
+package history
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func openTest(t *testing.T) *DB {
+	t.Helper()
+	d, err := Open(filepath.Join(t.TempDir(), "traffic.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+func snapAt(ts time.Time, procs []model.ProcessSummary, hosts []model.RemoteHostSummary) model.Snapshot {
+	return model.Snapshot{Timestamp: ts, Processes: procs, RemoteHosts: hosts}
+}
+
+func TestWriteAndTopProcesses(t *testing.T) {
+	d := openTest(t)
+	t0 := time.Now().Add(-time.Hour)
+
+	procs := []model.ProcessSummary{
+		{PID: 10, Name: "curl", UpRate: 100, DownRate: 1000, ConnCount: 1},
+		{PID: 20, Name: "firefox", UpRate: 10, DownRate: 50},
+		{PID: 30, Name: "idle"},
+	}
+	for i := 0; i < 3; i++ {
+		if err := d.Write(snapAt(t0.Add(time.Duration(i)*time.Second), procs, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// PID churn: a second curl adds to the same name
+	restarted := []model.ProcessSummary{{PID: 11, Name: "curl", UpRate: 0, DownRate: 2000}}
+	if err := d.Write(snapAt(t0.Add(3*time.Second), restarted, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	top, err := d.TopProcesses(t0.Add(-time.Minute), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 2 {
+		t.Fatalf("got %d processes, want 2 (idle has no traffic): %+v", len(top), top)
+	}
+	// First snapshot is the baseline; two 1s samples + one restarted sample
+	curl := top[0]
+	if curl.Name != "curl" || curl.PIDs != 2 || curl.BytesUp != 200 || curl.BytesDn != 4000 {
+		t.Errorf("curl = %+v, want 2 PIDs, 200 up, 4000 down", curl)
+	}
+	if curl.PeakRate != 2000 {
+		t.Errorf("curl peak = %v, want 2000", curl.PeakRate)
+	}
+	if top[1].Name != "firefox" || top[1].Total() != 120 {
+		t.Errorf("firefox = %+v, want 120 bytes", top[1])
+	}
+
+	if top, _ := d.TopProcesses(t0, 1); len(top) != 1 || top[0].Name != "curl" {
+		t.Errorf("limit 1 = %+v", top)
+	}
+	if top, _ := d.TopProcesses(t0.Add(time.Hour), 10); len(top) != 0 {
+		t.Errorf("future since returned %+v", top)
+	}
+}
+
+func TestWriteCapsGap(t *testing.T) {
+	d := openTest(t)
+	t0 := time.Now()
+	procs := []model.ProcessSummary{{PID: 1, Name: "sync", UpRate: 10}}
+
+	d.Write(snapAt(t0, procs, nil))
+	d.Write(snapAt(t0.Add(2*time.Hour), procs, nil)) // resume from suspend
+
+	top, err := d.TopProcesses(t0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 1 || top[0].BytesUp != 10*uint64(maxSampleGap.Seconds()) {
+		t.Errorf("got %+v, want gap capped at %v", top, maxSampleGap)
+	}
+}
+
+func TestTopHosts(t *testing.T) {
+	d := openTest(t)
+	t0 := time.Now()
+	hosts := []model.RemoteHostSummary{
+		{Host: "api.example.com", IP: net.ParseIP("192.0.2.1"), CountryCode: "US", UpRate: 5, DownRate: 500},
+		{Host: "cdn.example.net", IP: net.ParseIP("192.0.2.2"), CountryCode: "DE", DownRate: 50},
+	}
+	d.Write(snapAt(t0, nil, hosts))
+	d.Write(snapAt(t0.Add(2*time.Second), nil, hosts))
+
+	top, err := d.TopHosts(t0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 2 || top[0].Name != "api.example.com" || top[0].Country != "US" || top[0].Total() != 1010 {
+		t.Errorf("hosts = %+v", top)
+	}
+
+	if _, _, ok, err := d.Span(); err != nil || ok {
+		t.Errorf("Span with no process samples = ok %v, err %v", ok, err)
+	}
+}
+
+func TestReopenKeepsHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traffic.sqlite")
+	t0 := time.Now()
+	procs := []model.ProcessSummary{{PID: 1, Name: "curl", DownRate: 100}}
+
+	d, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Write(snapAt(t0, procs, nil))
+	d.Write(snapAt(t0.Add(time.Second), procs, nil))
+	d.Close()
+
+	d, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	top, err := d.TopProcesses(t0, 10)
+	if err != nil || len(top) != 1 || top[0].BytesDn != 100 {
+		t.Errorf("after reopen = %+v, %v", top, err)
+	}
+	first, last, ok, err := d.Span()
+	if err != nil || !ok || first.UnixMilli() != t0.Add(time.Second).UnixMilli() || !last.Equal(first) {
+		t.Errorf("Span = %v %v %v %v", first, last, ok, err)
+	}
+}
+
+func TestProcessTraffic(t *testing.T) {
+	d := openTest(t)
+	t0 := time.Date(2026, 10, 16, 3, 14, 0, 0, time.Local)
+	procs := []model.ProcessSummary{{PID: 10, Name: "curl", UpRate: 100, DownRate: 1000}}
+	for i := 0; i < 3; i++ {
+		if err := d.Write(snapAt(t0.Add(time.Duration(i)*time.Second), procs, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Write(snapAt(t0.Add(time.Hour), procs, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	type row struct {
+		start    time.Time
+		up, down uint64
+	}
+	var got []row
+	err := d.ProcessTraffic(t0.Add(-time.Minute), func(start time.Time, name string, up, down uint64) {
+		if name != "curl" {
+			t.Errorf("name = %q", name)
+		}
+		got = append(got, row{start, up, down})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d buckets, want 2: %+v", len(got), got)
+	}
+	if want := t0.Truncate(15 * time.Minute); !got[0].start.Equal(want) || got[0].down != 2000 {
+		t.Errorf("first bucket = %+v, want start %v, 2000 down", got[0], want)
+	}
+	if got[1].down != 60000 {
+		t.Errorf("second bucket down = %d, want 60000 (gap capped at a minute)", got[1].down)
+	}
+}
@@ -0,0 +1,109 @@
+package history
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Usage is the traffic total for one process name or remote host.
+type Usage struct {
+	Name     string
+	Country  string // hosts only
+	PIDs     int    // processes only: distinct PIDs seen under this name
+	BytesUp  uint64
+	BytesDn  uint64
+	PeakRate float64 // highest up+down rate in a single sample, bytes/sec
+}
+
+// Total returns up + down bytes.
+func (u Usage) Total() uint64 {
+	return u.BytesUp + u.BytesDn
+}
+
+// TopProcesses returns the limit heaviest process names since the given
+// time, by total bytes. Processes are grouped by name so restarts and PID
+// churn add up.
+func (d *DB) TopProcesses(since time.Time, limit int) ([]Usage, error) {
+	rows, err := d.db.Query(`SELECT name, COUNT(DISTINCT pid),
+		SUM(bytes_up), SUM(bytes_down), MAX(up_rate + down_rate)
+		FROM process_samples WHERE ts >= ?
+		GROUP BY name
+		ORDER BY SUM(bytes_up + bytes_down) DESC, name
+		LIMIT ?`, since.UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanUsage(rows, func(u *Usage) []any {
+		return []any{&u.Name, &u.PIDs, &u.BytesUp, &u.BytesDn, &u.PeakRate}
+	})
+}
+
+// TopHosts returns the limit heaviest remote hosts since the given time,
+// by total bytes.
+func (d *DB) TopHosts(since time.Time, limit int) ([]Usage, error) {
+	rows, err := d.db.Query(`SELECT host, MAX(country),
+		SUM(bytes_up), SUM(bytes_down), MAX(up_rate + down_rate)
+		FROM host_samples WHERE ts >= ?
+		GROUP BY host
+		ORDER BY SUM(bytes_up + bytes_down) DESC, host
+		LIMIT ?`, since.UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanUsage(rows, func(u *Usage) []any {
+		return []any{&u.Name, &u.Country, &u.BytesUp, &u.BytesDn, &u.PeakRate}
+	})
+}
+
+// trafficBucket is the granularity ProcessTraffic sums samples at. Every
+// timezone offset in use is a multiple of 15 minutes, so buckets never
+// straddle a local hour.
+const trafficBucket = 15 * time.Minute
+
+// ProcessTraffic calls fn with the bytes each process name moved in every
+// 15-minute bucket since the given time, oldest bucket first.
+func (d *DB) ProcessTraffic(since time.Time, fn func(start time.Time, name string, up, down uint64)) error {
+	bucket := trafficBucket.Milliseconds()
+	rows, err := d.db.Query(`SELECT ts / ? AS bucket, name, SUM(bytes_up), SUM(bytes_down)
+		FROM process_samples WHERE ts >= ?
+		GROUP BY bucket, name
+		ORDER BY bucket, name`, bucket, since.UnixMilli())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b int64
+		var name string
+		var up, down uint64
+		if err := rows.Scan(&b, &name, &up, &down); err != nil {
+			return err
+		}
+		fn(time.UnixMilli(b*bucket), name, up, down)
+	}
+	return rows.Err()
+}
+
+func scanUsage(rows *sql.Rows, dest func(*Usage) []any) ([]Usage, error) {
+	defer rows.Close()
+	var out []Usage
+	for rows.Next() {
+		var u Usage
+		if err := rows.Scan(dest(&u)...); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// Span returns the time range covered by the stored samples. ok is false
+// when the database is empty.
+func (d *DB) Span() (first, last time.Time, ok bool, err error) {
+	var lo, hi sql.NullInt64
+	err = d.db.QueryRow(`SELECT MIN(ts), MAX(ts) FROM process_samples`).Scan(&lo, &hi)
+	if err != nil || !lo.Valid {
+		return first, last, false, err
+	}
+	return time.UnixMilli(lo.Int64), time.UnixMilli(hi.Int64), true, nil
+}
@@ -0,0 +1,117 @@
+// Package hostfmt names remote hosts in one of three styles — the full
+// name, its first label, or the bare address — so the TUI's views and the
+// streaming exports agree on what a host is called.
+package hostfmt
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// Style is how remote hosts are named.
+type Style int
+
+const (
+	FQDN  Style = iota // the resolved name as is: api.github.com
+	Short              // its first label: api
+	IP                 // the address, names unused
+)
+
+// Styles are the config and flag names, in cycling order.
+var Styles = []string{"fqdn", "short", "ip"}
+
+// Parse parses a style name; empty is FQDN.
+func Parse(s string) (Style, error) {
+	if s == "" {
+		return FQDN, nil
+	}
+	for i, name := range Styles {
+		if strings.EqualFold(s, name) {
+			return Style(i), nil
+		}
+	}
+	return FQDN, fmt.Errorf("%q is not one of %s", s, strings.Join(Styles, ", "))
+}
+
+func (s Style) String() string {
+	if s < 0 || int(s) >= len(Styles) {
+		return "fqdn"
+	}
+	return Styles[s]
+}
+
+// Next returns the style after s: fqdn → short → ip → fqdn.
+func (s Style) Next() Style {
+	return (s + 1) % Style(len(Styles))
+}
+
+// Name returns host as the style names it: "" in IP style, else host
+// itself or its first label. IP literals (unresolved hosts) and single-label
+// names are left alone.
+func (s Style) Name(host string) string {
+	switch s {
+	case IP:
+		return ""
+	case Short:
+		if host == "" || net.ParseIP(host) != nil {
+			return host
+		}
+		if i := strings.IndexByte(host, '.'); i > 0 {
+			return host[:i]
+		}
+	}
+	return host
+}
+
+// Format returns the host's name in the style, or its address when the
+// style or an unresolved host has no name.
+func (s Style) Format(host string, ip net.IP) string {
+	if name := s.Name(host); name != "" {
+		return name
+	}
+	if ip != nil {
+		return ip.String()
+	}
+	return host
+}
+
+// Apply returns a copy of snap with its remote hosts named in the style:
+// connections' and host summaries' names, and processes' top destinations.
+// In IP style the name fields carry the address, so every consumer shows
+// it. The input is not modified; FQDN returns snap unchanged.
+func (s Style) Apply(snap model.Snapshot) model.Snapshot {
+	if s == FQDN {
+		return snap
+	}
+	procs := make([]model.ProcessSummary, len(snap.Processes))
+	for i, p := range snap.Processes {
+		if p.TopDest != "" {
+			p.TopDest = s.Format(p.TopDest, p.TopDestIP)
+		}
+		if p.Connections != nil {
+			conns := make([]model.Connection, len(p.Connections))
+			for j, c := range p.Connections {
+				if c.RemoteHost != "" {
+					c.RemoteHost = s.Format(c.RemoteHost, c.DstIP)
+				}
+				conns[j] = c
+			}
+			p.Connections = conns
+		}
+		procs[i] = p
+	}
+	snap.Processes = procs
+
+	if snap.RemoteHosts != nil {
+		hosts := make([]model.RemoteHostSummary, len(snap.RemoteHosts))
+		for i, h := range snap.RemoteHosts {
+			h.Host = s.Format(h.Host, h.IP)
+			hosts[i] = h
+		}
+		snap.RemoteHosts = hosts
+	}
+	return snap
+}
@@ -0,0 +1,77 @@
+package hostfmt
+
+import (
+	"net"
+	"testing"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]Style{"": FQDN, "fqdn": FQDN, "Short": Short, "ip": IP} {
+		got, err := Parse(in)
+		if err != nil || got != want {
+			t.Errorf("Parse(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := Parse("hostname"); err == nil {
+		t.Error("Parse(hostname) should fail")
+	}
+	if FQDN.Next() != Short || Short.Next() != IP || IP.Next() != FQDN {
+		t.Error("Next should cycle fqdn → short → ip → fqdn")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	ip := net.ParseIP("140.82.112.3")
+	tests := []struct {
+		style Style
+		host  string
+		want  string
+	}{
+		{FQDN, "lb-140-82-112-3.github.com", "lb-140-82-112-3.github.com"},
+		{Short, "lb-140-82-112-3.github.com", "lb-140-82-112-3"},
+		{IP, "lb-140-82-112-3.github.com", "140.82.112.3"},
+		{Short, "localhost", "localhost"},
+		{Short, "140.82.112.3", "140.82.112.3"},
+		{Short, "2a00:1450::1", "2a00:1450::1"},
+		{FQDN, "", "140.82.112.3"},
+		{Short, ".", "."},
+	}
+	for _, tt := range tests {
+		if got := tt.style.Format(tt.host, ip); got != tt.want {
+			t.Errorf("%v.Format(%q) = %q, want %q", tt.style, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	ip := net.ParseIP("10.20.30.40")
+	snap := model.Snapshot{
+		Processes: []model.ProcessSummary{{
+			Name:      "curl",
+			TopDest:   "api.internal.corp",
+			TopDestIP: ip,
+			Connections: []model.Connection{
+				{DstIP: ip, RemoteHost: "api.internal.corp"},
+				{DstIP: net.ParseIP("10.0.0.9")},
+			},
+		}},
+		RemoteHosts: []model.RemoteHostSummary{{Host: "api.internal.corp", IP: ip}},
+	}
+
+	short := Short.Apply(snap)
+	if p := short.Processes[0]; p.TopDest != "api" || p.Connections[0].RemoteHost != "api" || short.RemoteHosts[0].Host != "api" {
+		t.Errorf("short: top %q, conn %q, host %q", p.TopDest, p.Connections[0].RemoteHost, short.RemoteHosts[0].Host)
+	}
+	byIP := IP.Apply(snap)
+	if p := byIP.Processes[0]; p.TopDest != "10.20.30.40" || p.Connections[0].RemoteHost != "10.20.30.40" || byIP.RemoteHosts[0].Host != "10.20.30.40" {
+		t.Errorf("ip: top %q, conn %q, host %q", p.TopDest, p.Connections[0].RemoteHost, byIP.RemoteHosts[0].Host)
+	}
+	if got := byIP.Processes[0].Connections[1].RemoteHost; got != "" {
+		t.Errorf("unresolved connection named %q, want it left empty", got)
+	}
+	if snap.Processes[0].Connections[0].RemoteHost != "api.internal.corp" || snap.RemoteHosts[0].Host != "api.internal.corp" {
+		t.Error("Apply modified its input")
+	}
+}
@@ -0,0 +1,41 @@
+// Package journal reads a systemd unit's log from the journal with
+// journalctl.
+package journal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// DefaultLines is how many lines Tail is usually asked for.
+const DefaultLines = 100
+
+// Tail returns the last n lines the journal holds for unit, oldest first,
+// with ISO timestamps.
+func Tail(ctx context.Context, unit string, n int) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "journalctl",
+		"--unit="+unit, "--lines="+strconv.Itoa(n),
+		"--no-pager", "--quiet", "--output=short-iso")
+	out, err := cmd.Output()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, errors.New("journalctl not found: the unit's log is in the systemd journal")
+		}
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			if msg := strings.TrimSpace(string(exit.Stderr)); msg != "" {
+				return nil, fmt.Errorf("journalctl: %s", msg)
+			}
+		}
+		return nil, fmt.Errorf("journalctl: %w", err)
+	}
+	text := strings.TrimRight(string(out), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
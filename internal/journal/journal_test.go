@@ -0,0 +1,54 @@
+package journal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeJournalctl puts a journalctl script first in PATH.
+func fakeJournalctl(t *testing.T, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "journalctl"), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestTail(t *testing.T) {
+	fakeJournalctl(t, `for a in "$@"; do echo "$a"; done`)
+	lines, err := Tail(context.Background(), "nginx.service", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(lines, " ")
+	if !strings.Contains(got, "--unit=nginx.service") || !strings.Contains(got, "--lines=20") || !strings.Contains(got, "--no-pager") {
+		t.Errorf("journalctl ran with %q", lines)
+	}
+}
+
+func TestTailEmpty(t *testing.T) {
+	fakeJournalctl(t, "exit 0")
+	if lines, err := Tail(context.Background(), "idle.service", 20); lines != nil || err != nil {
+		t.Errorf("Tail = %q, %v; want nothing", lines, err)
+	}
+}
+
+func TestTailErrors(t *testing.T) {
+	fakeJournalctl(t, "echo 'No journal files were found.' >&2; exit 1")
+	if _, err := Tail(context.Background(), "x.service", 5); err == nil || !strings.Contains(err.Error(), "No journal files") {
+		t.Errorf("err = %v, want journalctl's message", err)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if _, err := Tail(context.Background(), "x.service", 5); err == nil || !strings.Contains(err.Error(), "journalctl not found") {
+		t.Errorf("err = %v, want journalctl not found", err)
+	}
+}
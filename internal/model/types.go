@@ -2,6 +2,7 @@ package model
 
 import (
 	"fmt"
+	"hash/fnv"
 	"net"
 	"strings"
 	"time"
@@ -66,6 +67,26 @@ func (s SocketState) String() string {
 	return "UNKNOWN"
 }
 
+// Direction classifies who initiated a connection.
+type Direction uint8
+
+const (
+	DirUnknown  Direction = iota
+	DirOutbound           // local process connected out (its own egress)
+	DirInbound            // remote peer connected to a local listening port
+)
+
+func (d Direction) String() string {
+	switch d {
+	case DirOutbound:
+		return "out"
+	case DirInbound:
+		return "in"
+	default:
+		return "?"
+	}
+}
+
 // Socket represents a single network socket with byte counters.
 type Socket struct {
 	Proto   Protocol    `json:"proto"`
@@ -75,10 +96,23 @@ type Socket struct {
 	DstPort uint16      `json:"dst_port"`
 	State   SocketState `json:"state"`
 	Inode   uint64      `json:"inode,omitempty"` // Linux only, 0 on macOS
+	NetNS   string      `json:"netns,omitempty"` // other network namespace; "" = sstop's own
 
 	// Byte counters (cumulative)
 	BytesSent uint64 `json:"bytes_sent"`
 	BytesRecv uint64 `json:"bytes_recv"`
+
+	// TCP internals (Linux netlink only, nil elsewhere)
+	TCP *TCPInfo `json:"tcp_info,omitempty"`
+}
+
+// TCPInfo holds connection-quality fields from the kernel's struct tcp_info.
+type TCPInfo struct {
+	RTT          time.Duration `json:"rtt"`           // smoothed round-trip time
+	RTTVar       time.Duration `json:"rtt_var"`       // round-trip time variance
+	Retrans      uint32        `json:"retrans"`       // total retransmitted segments
+	Cwnd         uint32        `json:"cwnd"`          // congestion window (segments)
+	DeliveryRate float64       `json:"delivery_rate"` // bytes/sec, 0 if unsupported by kernel
 }
 
 // AddrPort returns "ip:port" string for an address.
@@ -109,11 +143,21 @@ type Connection struct {
 	DownRate float64       `json:"down_rate"` // bytes/sec
 	Age      time.Duration `json:"age"`       // how long the connection has been tracked
 
+	// Cumulative bytes transferred since the connection was first seen
+	BytesUp   uint64 `json:"bytes_up"`
+	BytesDown uint64 `json:"bytes_down"`
+
 	// Resolved remote hostname (empty if not resolved yet)
 	RemoteHost string `json:"remote_host,omitempty"`
 
 	// Service name (e.g. HTTPS, SSH, DNS)
 	Service string `json:"service,omitempty"`
+
+	// Direction: inbound when the local port is a listening port, else outbound
+	Direction Direction `json:"direction"`
+
+	// TCP internals (RTT, retransmits, cwnd); nil when unavailable
+	TCP *TCPInfo `json:"tcp_info,omitempty"`
 }
 
 // ListenPort represents a port a process is listening on.
@@ -123,29 +167,195 @@ type ListenPort struct {
 	Port  uint16   `json:"port"`
 }
 
+// UnattributedPID is the pseudo-process that collects the sockets no
+// process could be found for, shown as UnattributedName.
+const (
+	UnattributedPID  = 0
+	UnattributedName = "kernel/unknown"
+)
+
+// Why a socket has no process, counted in ProcessSummary.Unattributed.
+const (
+	UnattributedNoOwner = "no-owner" // no longer held by a process: TIME_WAIT, orphaned
+	UnattributedHidden  = "hidden"   // held by a process sstop isn't allowed to inspect
+	UnattributedKernel  = "kernel"   // held by the kernel, or a process that just exited
+	UnattributedUnknown = "unknown"  // the platform doesn't say
+)
+
+// UnattributedReason explains an Unattributed* reason to the user.
+func UnattributedReason(reason string) string {
+	switch reason {
+	case UnattributedNoOwner:
+		return "closed by their process and lingering in the kernel (TIME_WAIT, orphaned)"
+	case UnattributedHidden:
+		return "owned by processes sstop may not inspect; run as root to attribute them"
+	case UnattributedKernel:
+		return "owned by the kernel (NFS, WireGuard, ...) or by a process that exited mid-poll"
+	default:
+		return "the platform reports no owning process"
+	}
+}
+
+// Process kinds (ProcessSummary.Kind).
+const (
+	KindApp    = "app"    // started by the desktop session: a GUI application
+	KindDaemon = "daemon" // a service or background process, no terminal
+	KindShell  = "shell"  // has a controlling terminal: run from a shell
+)
+
 // ProcessSummary aggregates network info for a single process.
 type ProcessSummary struct {
-	PID         uint32       `json:"pid"`
-	PPID        uint32       `json:"ppid,omitempty"`
-	Name        string       `json:"name"`
-	Cmdline     string       `json:"cmdline"`
-	UpRate      float64      `json:"up_rate"`  // bytes/sec aggregate
-	DownRate    float64      `json:"down_rate"` // bytes/sec aggregate
+	PID      uint32  `json:"pid"`
+	PPID     uint32  `json:"ppid,omitempty"`
+	Name     string  `json:"name"`
+	Cmdline  string  `json:"cmdline"`
+	UpRate   float64 `json:"up_rate"`   // bytes/sec aggregate
+	DownRate float64 `json:"down_rate"` // bytes/sec aggregate
+
+	// Rates split by connection direction (bytes/sec). Inbound is traffic on
+	// connections accepted by the process's listeners (serving traffic),
+	// outbound is traffic on connections the process initiated (egress).
+	InboundUp    float64 `json:"inbound_up"`
+	InboundDown  float64 `json:"inbound_down"`
+	OutboundUp   float64 `json:"outbound_up"`
+	OutboundDown float64 `json:"outbound_down"`
+
+	// Up+down rate averaged over, and at its highest in, the last minute:
+	// steadier than the current rate for ranking bursty processes
+	AvgRate  float64 `json:"avg_rate,omitempty"`
+	PeakRate float64 `json:"peak_rate,omitempty"`
+
 	Connections []Connection `json:"connections"`
 	ListenPorts []ListenPort `json:"listen_ports"`
-	ConnCount   int          `json:"conn_count"`
+	ConnCount   int          `json:"conn_count"` // total, including omitted connections
 	ListenCount int          `json:"listen_count"`
 
+	// Connections dropped from Connections when the per-process cap applies
+	// (only the busiest are kept), aggregated into a single "and N more" row.
+	OmittedConns    int     `json:"omitted_conns,omitempty"`
+	OmittedUpRate   float64 `json:"omitted_up_rate,omitempty"`
+	OmittedDownRate float64 `json:"omitted_down_rate,omitempty"`
+
 	// Cumulative bytes (populated when cumulative tracking is active)
 	CumUp   uint64 `json:"cum_up,omitempty"`
 	CumDown uint64 `json:"cum_down,omitempty"`
 
+	// Cumulative bytes of earlier, exited runs of the same program (see
+	// ProcessKey), carried by its newest process; not part of CumUp/CumDown
+	CarriedUp   uint64 `json:"carried_up,omitempty"`
+	CarriedDown uint64 `json:"carried_down,omitempty"`
+
+	// Process owner (Linux only; nil and empty when unknown, so JSON
+	// doesn't report an unknown owner as root)
+	UID  *uint32 `json:"uid,omitempty"`
+	User string  `json:"user,omitempty"`
+
 	// Container/service group info
 	ContainerID string `json:"container_id,omitempty"` // Docker/Podman short ID
 	ServiceName string `json:"service_name,omitempty"` // systemd service name
+	PodName     string `json:"pod_name,omitempty"`     // Kubernetes pod
+	Namespace   string `json:"namespace,omitempty"`    // Kubernetes pod namespace
+	NetNS       string `json:"netns,omitempty"`        // network namespace when not sstop's own
+
+	// How the process was started: KindApp, KindDaemon or KindShell ("" when
+	// not known, off Linux)
+	Kind string `json:"kind,omitempty"`
+
+	// Dominant destination: remote host (or IP) receiving the most traffic right now
+	TopDest        string `json:"top_dest,omitempty"`
+	TopDestIP      net.IP `json:"top_dest_ip,omitempty"`
+	TopDestCountry string `json:"top_dest_country,omitempty"` // country code (e.g. "US")
+
+	// When the process started (zero where the platform can't tell). With
+	// the PID it tells a process from a later one reusing its PID: see ID.
+	StartTime time.Time `json:"start_time,omitzero"`
+
+	// When sstop first saw the process with sockets (this session)
+	FirstSeen time.Time `json:"first_seen,omitempty"`
+
+	// On the UnattributedPID pseudo-process: socket count by why no
+	// process could be found for them
+	Unattributed map[string]int `json:"unattributed,omitempty"`
 
 	// Sparkline history (total rate = up+down, chronological, oldest first)
 	RateHistory []float64 `json:"-"`
+
+	// Set on entries of Snapshot.Exited: when the process was last seen
+	ExitedAt time.Time `json:"-"`
+
+	// Operator note from the TUI (see internal/notes)
+	Note string `json:"-"`
+
+	// Group from the config's [[groups]] rules, set by the TUI
+	Tag string `json:"-"`
+}
+
+// ProcessID identifies a process for as long as it runs: the PID alone
+// is handed to a new process once the old one exits, the PID and start
+// time are not.
+type ProcessID struct {
+	PID   uint32
+	Start int64 // start time, Unix nanoseconds; 0 = unknown
+}
+
+// NewProcessID returns the identity of the process pid that started at
+// start (zero if unknown).
+func NewProcessID(pid uint32, start time.Time) ProcessID {
+	id := ProcessID{PID: pid}
+	if !start.IsZero() {
+		id.Start = start.UnixNano()
+	}
+	return id
+}
+
+// ID returns the process's identity.
+func (p *ProcessSummary) ID() ProcessID {
+	return NewProcessID(p.PID, p.StartTime)
+}
+
+// Same reports whether id and o are the same process. A start time that
+// isn't known matches any, so platforms without one fall back to the PID.
+func (id ProcessID) Same(o ProcessID) bool {
+	return id.PID == o.PID && (id.Start == 0 || o.Start == 0 || id.Start == o.Start)
+}
+
+// ProcessKey identifies a program across its runs: its name and a hash
+// of its command line. A restarted service gets a new PID and start time
+// but keeps its key, so its totals can carry over to the new process.
+type ProcessKey struct {
+	Name    string
+	Cmdline uint64 // FNV-1a hash of the command line
+}
+
+// NewProcessKey returns the key of the program name run as cmdline.
+func NewProcessKey(name, cmdline string) ProcessKey {
+	h := fnv.New64a()
+	h.Write([]byte(cmdline))
+	return ProcessKey{Name: name, Cmdline: h.Sum64()}
+}
+
+// ProgramCumUp returns the bytes uploaded by the process and the earlier
+// runs it carries, what cumulative mode shows.
+func (p *ProcessSummary) ProgramCumUp() uint64 {
+	return p.CumUp + p.CarriedUp
+}
+
+// ProgramCumDown is ProgramCumUp for bytes downloaded.
+func (p *ProcessSummary) ProgramCumDown() uint64 {
+	return p.CumDown + p.CarriedDown
+}
+
+// Key returns the process's program key.
+func (p *ProcessSummary) Key() ProcessKey {
+	return NewProcessKey(p.Name, p.Cmdline)
+}
+
+// RateSeries is a rate history: the upload and download rates of each
+// poll (bytes/sec, oldest first) and when each poll ran.
+type RateSeries struct {
+	Times []time.Time
+	Up    []float64
+	Down  []float64
 }
 
 // InterfaceStats holds per-interface byte counters and rates.
@@ -155,17 +365,56 @@ type InterfaceStats struct {
 	BytesSent uint64  `json:"bytes_sent"`
 	RecvRate  float64 `json:"recv_rate"` // bytes/sec (computed by collector)
 	SendRate  float64 `json:"send_rate"` // bytes/sec (computed by collector)
+
+	// Packet, error and drop counters where the platform reports them
+	PacketsRecv uint64 `json:"packets_recv,omitempty"`
+	PacketsSent uint64 `json:"packets_sent,omitempty"`
+	ErrorsRecv  uint64 `json:"errors_recv,omitempty"`
+	ErrorsSent  uint64 `json:"errors_sent,omitempty"`
+	DropsRecv   uint64 `json:"drops_recv,omitempty"`
+	DropsSent   uint64 `json:"drops_sent,omitempty"`
+
+	Speed uint64   `json:"speed,omitempty"` // link speed, bits/sec; 0 = unknown
+	Addrs []net.IP `json:"addrs,omitempty"` // addresses assigned to the interface
+
+	// Rate history per direction (bytes/sec, oldest first)
+	RecvHistory []float64 `json:"recv_history,omitempty"`
+	SendHistory []float64 `json:"send_history,omitempty"`
+}
+
+// Utilization is the busier direction's rate as a fraction of the link
+// speed, 0 when the speed isn't known.
+func (s *InterfaceStats) Utilization() float64 {
+	if s.Speed == 0 {
+		return 0
+	}
+	return max(s.RecvRate, s.SendRate) * 8 / float64(s.Speed)
+}
+
+// HasAddr reports whether ip is one of the interface's addresses.
+func (s *InterfaceStats) HasAddr(ip net.IP) bool {
+	for _, a := range s.Addrs {
+		if a.Equal(ip) {
+			return true
+		}
+	}
+	return false
 }
 
 // RemoteHostSummary aggregates bandwidth by remote host across all processes.
 type RemoteHostSummary struct {
-	Host      string   `json:"host"`       // hostname or IP string
-	IP        net.IP   `json:"ip"`         // raw IP
-	UpRate    float64  `json:"up_rate"`    // bytes/sec
-	DownRate  float64  `json:"down_rate"`  // bytes/sec
-	ConnCount int      `json:"conn_count"` // number of connections
-	Processes []string `json:"processes"`  // process names connected to this host
+	Host      string   `json:"host"`              // hostname or IP string
+	IP        net.IP   `json:"ip"`                // raw IP
+	UpRate    float64  `json:"up_rate"`           // bytes/sec
+	DownRate  float64  `json:"down_rate"`         // bytes/sec
+	ConnCount int      `json:"conn_count"`        // number of connections
+	Processes []string `json:"processes"`         // process names connected to this host
 	Country   string   `json:"country,omitempty"` // country code (e.g. "US")
+	City      string   `json:"city,omitempty"`    // with a GeoIP City database (--geoip-db)
+
+	CountryCode string `json:"country_code,omitempty"` // bare code for matching (e.g. "US", "LAN")
+	ASN         uint32 `json:"asn,omitempty"`          // announcing AS (major providers only)
+	ASOrg       string `json:"as_org,omitempty"`       // e.g. "GOOGLE"
 }
 
 // ListenPortEntry is a system-wide listening port with its owning process.
@@ -176,6 +425,11 @@ type ListenPortEntry struct {
 	PID     uint32   `json:"pid"`
 	Process string   `json:"process"`
 	Cmdline string   `json:"cmdline"`
+
+	// Set when the port is published from a container (Docker/Podman):
+	// traffic to Port is forwarded to ContainerPort inside Container.
+	Container     string `json:"container,omitempty"`
+	ContainerPort uint16 `json:"container_port,omitempty"`
 }
 
 // SessionStats holds cumulative session statistics (shown on exit).
@@ -186,12 +440,15 @@ type SessionStats struct {
 	TopProcess []ProcessCumulative // top 5 by total bytes
 }
 
-// ProcessCumulative tracks cumulative bytes for a single process.
+// ProcessCumulative tracks cumulative bytes for a single process, or for
+// all runs of a program (see ProcessKey).
 type ProcessCumulative struct {
 	PID       uint32
+	StartTime time.Time // zero where the platform can't tell
 	Name      string
 	BytesUp   uint64
 	BytesDown uint64
+	Processes int // processes counted; more than 1 after restarts
 }
 
 // Summary returns a formatted string for terminal display on exit.
@@ -211,8 +468,12 @@ func (s SessionStats) Summary() string {
 			if p.BytesUp == 0 && p.BytesDown == 0 {
 				continue
 			}
+			name := p.Name
+			if p.Processes > 1 {
+				name = fmt.Sprintf("%s (%d processes)", name, p.Processes)
+			}
 			b.WriteString(fmt.Sprintf("  %d. %-16s ▲ %-10s ▼ %s\n",
-				i+1, p.Name, fmtBytes(p.BytesUp), fmtBytes(p.BytesDown)))
+				i+1, name, fmtBytes(p.BytesUp), fmtBytes(p.BytesDown)))
 		}
 	}
 	return b.String()
@@ -241,17 +502,51 @@ func fmtBytes(b uint64) string {
 
 // Snapshot is an immutable point-in-time view of all network activity.
 type Snapshot struct {
-	Timestamp    time.Time            `json:"timestamp"`
-	Processes    []ProcessSummary     `json:"processes"`
-	Interfaces   []InterfaceStats     `json:"interfaces"`
-	RemoteHosts  []RemoteHostSummary  `json:"remote_hosts"`
-	ListenPorts  []ListenPortEntry    `json:"listen_ports"`
-	TotalUp      float64              `json:"total_up"`   // bytes/sec
-	TotalDown    float64              `json:"total_down"` // bytes/sec
+	Timestamp   time.Time           `json:"timestamp"`
+	Processes   []ProcessSummary    `json:"processes"`
+	Interfaces  []InterfaceStats    `json:"interfaces"`
+	RemoteHosts []RemoteHostSummary `json:"remote_hosts"`
+	ListenPorts []ListenPortEntry   `json:"listen_ports"`
+	TotalUp     float64             `json:"total_up"`   // bytes/sec
+	TotalDown   float64             `json:"total_down"` // bytes/sec
+
+	// Host the snapshot came from when streamed from another machine
+	// (sstop --remote / connect); empty for local collection
+	Host string `json:"host,omitempty"`
+
+	// Fleet labels from --tag (role, datacenter, ...), copied into every
+	// output so many hosts can be aggregated downstream
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Set when the snapshot was cut down to fit an output size budget
+	// (--max-snapshot-bytes): connection detail is dropped first, then the
+	// lowest-rate processes (counted in OmittedProcesses).
+	Truncated        bool `json:"truncated,omitempty"`
+	OmittedProcesses int  `json:"omitted_processes,omitempty"`
+
+	// Polls skipped since start because a collection ran longer than the
+	// polling interval. Rates stay correct; graphs have gaps.
+	SkippedPolls int `json:"skipped_polls,omitempty"`
+
+	// How long the poll producing this snapshot took: the platform's
+	// collection plus aggregation into the snapshot. A gap between
+	// snapshots with a long latency is a stalled collector, not a quiet
+	// network. 0 in recordings made before it was kept.
+	CollectLatency time.Duration `json:"collect_latency_ns,omitempty"`
+
+	// Processes that exited this session with traffic, most recent first.
+	// Only totals (CumUp/CumDown and the carried bytes) are meaningful;
+	// shown in cumulative mode.
+	Exited []ProcessSummary `json:"-"`
 
 	// Total rate history for header sparkline (up+down combined)
 	TotalRateHistory []float64 `json:"-"`
 
+	// System-wide rate history per direction (bytes/sec, oldest first), for
+	// the two-tone header graph; recorded so playback can draw it too
+	UpRateHistory   []float64 `json:"up_rate_history,omitempty"`
+	DownRateHistory []float64 `json:"down_rate_history,omitempty"`
+
 	// Active interface name (empty = all)
 	ActiveIface string `json:"-"`
 }
@@ -13,7 +13,7 @@ func TestSessionStatsSummary(t *testing.T) {
 		TotalDown: 1288490189, // ~1.2 GB
 		TopProcess: []ProcessCumulative{
 			{PID: 1, Name: "firefox", BytesUp: 47395430, BytesDown: 933232640},
-			{PID: 2, Name: "curl", BytesUp: 96558285, BytesDown: 327680000},
+			{PID: 2, Name: "curl", BytesUp: 96558285, BytesDown: 327680000, Processes: 3},
 		},
 	}
 
@@ -31,8 +31,8 @@ func TestSessionStatsSummary(t *testing.T) {
 	if !strings.Contains(summary, "firefox") {
 		t.Errorf("expected firefox in top processes:\n%s", summary)
 	}
-	if !strings.Contains(summary, "curl") {
-		t.Errorf("expected curl in top processes:\n%s", summary)
+	if !strings.Contains(summary, "curl (3 processes)") {
+		t.Errorf("expected curl over its 3 processes in top processes:\n%s", summary)
 	}
 }
 
@@ -64,3 +64,19 @@ func TestFmtBytes(t *testing.T) {
 		}
 	}
 }
+
+func TestDirectionString(t *testing.T) {
+	tests := []struct {
+		dir  Direction
+		want string
+	}{
+		{DirUnknown, "?"},
+		{DirOutbound, "out"},
+		{DirInbound, "in"},
+	}
+	for _, tt := range tests {
+		if got := tt.dir.String(); got != tt.want {
+			t.Errorf("Direction(%d).String() = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Package notes keeps short operator notes ("investigating", "known-good")
+// attached to a process or a process name, persisted in a state file so they
+// survive restarts during long incidents.
+package notes
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// MaxLen is the longest note kept, in runes.
+const MaxLen = 40
+
+// pidNote is a note on one PID. The process name and start time are kept
+// with it so a reused PID doesn't inherit the note.
+type pidNote struct {
+	Name  string `json:"name"`
+	Start int64  `json:"start,omitempty"` // model.ProcessID.Start
+	Note  string `json:"note"`
+}
+
+// of reports whether the note is on process id, running name.
+func (n pidNote) of(id model.ProcessID, name string) bool {
+	return n.Name == name && id.Same(model.ProcessID{PID: id.PID, Start: n.Start})
+}
+
+// stateFile is the on-disk format.
+type stateFile struct {
+	Version int                `json:"version"`
+	PIDs    map[uint32]pidNote `json:"pids,omitempty"`
+	Names   map[string]string  `json:"names,omitempty"`
+}
+
+// Store holds notes by PID and by process name. A PID note wins over a
+// name note. Safe for concurrent use.
+type Store struct {
+	mu    sync.Mutex
+	path  string // "" = session only
+	pids  map[uint32]pidNote
+	names map[string]string
+}
+
+// DefaultPath returns $XDG_STATE_HOME/sstop/notes.json, falling back to
+// ~/.local/state. Returns "" if neither can be determined.
+func DefaultPath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "sstop", "notes.json")
+}
+
+// Open loads the state file at path. A missing file starts empty; an empty
+// path keeps notes in memory only.
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:  path,
+		pids:  make(map[uint32]pidNote),
+		names: make(map[string]string),
+	}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f stateFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, &os.PathError{Op: "parse", Path: path, Err: err}
+	}
+	for pid, n := range f.PIDs {
+		s.pids[pid] = n
+	}
+	for name, note := range f.Names {
+		s.names[name] = note
+	}
+	return s, nil
+}
+
+// Lookup returns the note for a process: its PID note if one was set on
+// the same process, else the note on its name, else "".
+func (s *Store) Lookup(id model.ProcessID, name string) string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n, ok := s.pids[id.PID]; ok && n.of(id, name) {
+		return n.Note
+	}
+	return s.names[name]
+}
+
+// PIDNote returns the note set on process id running name, or "".
+func (s *Store) PIDNote(id model.ProcessID, name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n, ok := s.pids[id.PID]; ok && n.of(id, name) {
+		return n.Note
+	}
+	return ""
+}
+
+// NameNote returns the note set on a process name, or "".
+func (s *Store) NameNote(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.names[name]
+}
+
+// SetPID notes process id running name; an empty note removes it. The
+// state file is saved right away.
+func (s *Store) SetPID(id model.ProcessID, name, note string) error {
+	note = clean(note)
+	s.mu.Lock()
+	if note == "" {
+		delete(s.pids, id.PID)
+	} else {
+		s.pids[id.PID] = pidNote{Name: name, Start: id.Start, Note: note}
+	}
+	s.mu.Unlock()
+	return s.Save()
+}
+
+// SetName notes every process with the given name; an empty note removes
+// it. The state file is saved right away.
+func (s *Store) SetName(name, note string) error {
+	note = clean(note)
+	s.mu.Lock()
+	if note == "" {
+		delete(s.names, name)
+	} else {
+		s.names[name] = note
+	}
+	s.mu.Unlock()
+	return s.Save()
+}
+
+// clean trims a note to one line of at most MaxLen runes.
+func clean(note string) string {
+	note = strings.Join(strings.Fields(note), " ")
+	if r := []rune(note); len(r) > MaxLen {
+		note = string(r[:MaxLen])
+	}
+	return note
+}
+
+// Save writes the state file, replacing it atomically. A no-op without a
+// path.
+func (s *Store) Save() error {
+	if s.path == "" {
+		return nil
+	}
+	s.mu.Lock()
+	data, err := json.Marshal(stateFile{Version: 1, PIDs: s.pids, Names: s.names})
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".notes-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
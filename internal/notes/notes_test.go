@@ -0,0 +1,89 @@
+package notes
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// pid is the identity of a process whose start time is unknown.
+func pid(p uint32) model.ProcessID {
+	return model.ProcessID{PID: p}
+}
+
+func TestLookupPrecedence(t *testing.T) {
+	s, err := Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetName("curl", "known-good"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetPID(pid(42), "curl", "  investigating \n now "); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := s.Lookup(pid(42), "curl"); got != "investigating now" {
+		t.Errorf("Lookup(pid(42), curl) = %q, want the PID note", got)
+	}
+	if got := s.Lookup(pid(7), "curl"); got != "known-good" {
+		t.Errorf("Lookup(pid(7), curl) = %q, want the name note", got)
+	}
+	// PID reused by another program: the note doesn't follow it
+	if got := s.Lookup(pid(42), "nginx"); got != "" {
+		t.Errorf("Lookup(pid(42), nginx) = %q, want none", got)
+	}
+
+	s.SetPID(pid(42), "curl", "")
+	if got := s.Lookup(pid(42), "curl"); got != "known-good" {
+		t.Errorf("after clearing PID note = %q, want the name note", got)
+	}
+	s.SetName("curl", strings.Repeat("x", 100))
+	if got := s.NameNote("curl"); len(got) != MaxLen {
+		t.Errorf("note length = %d, want %d", len(got), MaxLen)
+	}
+}
+
+func TestPIDNoteFollowsStartTime(t *testing.T) {
+	s, _ := Open("")
+	rsync := model.ProcessID{PID: 42, Start: 1000}
+	s.SetPID(rsync, "rsync", "backup, leave it")
+
+	if got := s.Lookup(rsync, "rsync"); got != "backup, leave it" {
+		t.Errorf("Lookup(rsync) = %q, want the PID note", got)
+	}
+	// Another rsync run later on the same PID
+	if got := s.Lookup(model.ProcessID{PID: 42, Start: 2000}, "rsync"); got != "" {
+		t.Errorf("Lookup(later rsync) = %q, want none", got)
+	}
+	// Where start times aren't known the name still guards the note
+	if got := s.Lookup(pid(42), "rsync"); got != "backup, leave it" {
+		t.Errorf("Lookup(42 without start) = %q, want the PID note", got)
+	}
+}
+
+func TestSaveAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "notes.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetPID(model.ProcessID{PID: 1234, Start: 5000}, "rsync", "backup, leave it")
+	s.SetName("sshd", "known-good")
+
+	s2, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s2.Lookup(model.ProcessID{PID: 1234, Start: 5000}, "rsync"); got != "backup, leave it" {
+		t.Errorf("reloaded PID note = %q", got)
+	}
+	if got := s2.Lookup(model.ProcessID{PID: 1234, Start: 6000}, "rsync"); got != "" {
+		t.Errorf("reloaded PID note on a later rsync = %q, want none", got)
+	}
+	if got := s2.Lookup(pid(1), "sshd"); got != "known-good" {
+		t.Errorf("reloaded name note = %q", got)
+	}
+}
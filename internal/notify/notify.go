@@ -0,0 +1,234 @@
+// Package notify runs the actions configured for alerts beyond the
+// terminal bell: a command, a webhook and a desktop notification.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googlesky/sstop/internal/config"
+)
+
+// actionTimeout bounds how long a command or webhook may take.
+const actionTimeout = 30 * time.Second
+
+// maxInFlight caps the actions running at once; a burst of alerts beyond
+// it is dropped rather than piling up processes and connections.
+const maxInFlight = 8
+
+// Event is an alert that fired.
+type Event struct {
+	At        time.Time
+	Rule      string  // the rule's name, "" for the A prompt's threshold
+	PID       uint32  // 0 when not about one process (egress rules)
+	Name      string  // the process name, or the interface (interface rules)
+	Rate      float64 // bytes/sec, 0 if not a rate alert
+	Threshold float64 // bytes/sec, 0 if not a rate alert
+	Text      string  // as in the alert log
+}
+
+// Channels picks the actions an alert runs, per its rule's notify list.
+type Channels struct {
+	Command, Webhook, Desktop bool
+}
+
+// Notifier runs the configured actions. Safe for concurrent use.
+type Notifier struct {
+	command string
+	webhook string
+	desktop bool
+
+	slots chan struct{}
+	wg    sync.WaitGroup
+
+	// run runs a command with extra environment variables.
+	run func(ctx context.Context, env []string, name string, args ...string) error
+
+	// post POSTs a JSON body to url.
+	post func(ctx context.Context, url string, body []byte) error
+}
+
+// New creates a notifier for the actions in cfg. It returns nil when
+// none are configured.
+func New(cfg config.Alerts) (*Notifier, error) {
+	if cfg.Command == "" && cfg.Webhook == "" && !cfg.Desktop {
+		return nil, nil
+	}
+	if cfg.Desktop {
+		if _, _, err := desktopCommand(Event{}); err != nil {
+			return nil, err
+		}
+	}
+	return &Notifier{
+		command: cfg.Command,
+		webhook: cfg.Webhook,
+		desktop: cfg.Desktop,
+		slots:   make(chan struct{}, maxInFlight),
+		run:     runCommand,
+		post:    postJSON,
+	}, nil
+}
+
+// Notify runs the actions ch enables for ev in the background. Failures
+// are logged.
+func (n *Notifier) Notify(ev Event, ch Channels) {
+	if ch.Command && n.command != "" {
+		n.start("command", func(ctx context.Context) error {
+			name, args := shell(n.command)
+			return n.run(ctx, eventEnv(ev), name, args...)
+		})
+	}
+	if ch.Webhook && n.webhook != "" {
+		n.start("webhook", func(ctx context.Context) error {
+			body, err := json.Marshal(payload(ev))
+			if err != nil {
+				return err
+			}
+			return n.post(ctx, n.webhook, body)
+		})
+	}
+	if ch.Desktop && n.desktop {
+		n.start("desktop", func(ctx context.Context) error {
+			name, args, err := desktopCommand(ev)
+			if err != nil {
+				return err
+			}
+			return n.run(ctx, nil, name, args...)
+		})
+	}
+}
+
+// start runs action in the background unless maxInFlight are running.
+func (n *Notifier) start(kind string, action func(ctx context.Context) error) {
+	select {
+	case n.slots <- struct{}{}:
+	default:
+		log.Printf("notify: %d alert actions still running; %s dropped", maxInFlight, kind)
+		return
+	}
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		defer func() { <-n.slots }()
+		ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+		defer cancel()
+		if err := action(ctx); err != nil {
+			log.Printf("notify: %s: %v", kind, err)
+		}
+	}()
+}
+
+// wait waits for the actions started to finish.
+func (n *Notifier) wait() {
+	n.wg.Wait()
+}
+
+// eventEnv is the environment a command learns the alert from.
+func eventEnv(ev Event) []string {
+	env := []string{
+		"SSTOP_ALERT=" + ev.Text,
+		"SSTOP_RULE=" + ev.Rule,
+		"SSTOP_TIME=" + ev.At.Format(time.RFC3339),
+		"SSTOP_NAME=" + ev.Name,
+	}
+	if ev.PID != 0 {
+		env = append(env, "SSTOP_PID="+strconv.FormatUint(uint64(ev.PID), 10))
+	}
+	if ev.Rate > 0 {
+		env = append(env,
+			"SSTOP_RATE="+strconv.FormatFloat(ev.Rate, 'f', 0, 64),
+			"SSTOP_THRESHOLD="+strconv.FormatFloat(ev.Threshold, 'f', 0, 64))
+	}
+	return env
+}
+
+// webhookPayload is the JSON a webhook receives. Text makes it a valid
+// Slack or Mattermost incoming webhook message as is.
+type webhookPayload struct {
+	Text      string  `json:"text"`
+	Time      string  `json:"time"`
+	Rule      string  `json:"rule,omitempty"`
+	PID       uint32  `json:"pid,omitempty"`
+	Name      string  `json:"name,omitempty"`
+	Rate      float64 `json:"rate,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+	Host      string  `json:"host,omitempty"`
+}
+
+func payload(ev Event) webhookPayload {
+	host, _ := os.Hostname()
+	return webhookPayload{
+		Text:      "sstop alert: " + ev.Text,
+		Time:      ev.At.Format(time.RFC3339),
+		Rule:      ev.Rule,
+		PID:       ev.PID,
+		Name:      ev.Name,
+		Rate:      ev.Rate,
+		Threshold: ev.Threshold,
+		Host:      host,
+	}
+}
+
+// shell returns how to run command line s in the system shell.
+func shell(s string) (string, []string) {
+	if runtime.GOOS == "windows" {
+		return "cmd", []string{"/C", s}
+	}
+	return "sh", []string{"-c", s}
+}
+
+// desktopCommand returns the command showing ev as a desktop notification.
+func desktopCommand(ev Event) (string, []string, error) {
+	title := "sstop alert"
+	switch runtime.GOOS {
+	case "darwin":
+		quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+		script := fmt.Sprintf(`display notification "%s" with title "%s"`, quote.Replace(ev.Text), title)
+		return "osascript", []string{"-e", script}, nil
+	case "windows":
+		return "", nil, errors.New("alerts.desktop: desktop notifications need notify-send (Linux) or osascript (macOS)")
+	}
+	return "notify-send", []string{"--app-name=sstop", title, ev.Text}, nil
+}
+
+func runCommand(ctx context.Context, env []string, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+func postJSON(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return nil
+}
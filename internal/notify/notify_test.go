@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/config"
+)
+
+func testEvent() Event {
+	return Event{
+		At:        time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC),
+		Rule:      "big upload",
+		PID:       4242,
+		Name:      "curl",
+		Rate:      12_000_000,
+		Threshold: 1_000_000,
+		Text:      "big upload: curl (pid 4242) 12.0 MB/s > 1M/s",
+	}
+}
+
+func TestNewNothingConfigured(t *testing.T) {
+	n, err := New(config.Alerts{})
+	if n != nil || err != nil {
+		t.Errorf("New(empty) = %v, %v; want nil", n, err)
+	}
+}
+
+func TestNotifyChannels(t *testing.T) {
+	n, err := New(config.Alerts{Command: "echo hi", Webhook: "http://example.com/hook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var ran []string
+	n.run = func(_ context.Context, env []string, name string, args ...string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, name+" "+strings.Join(args, " ")+" "+strings.Join(env, ","))
+		return nil
+	}
+	n.post = func(_ context.Context, url string, body []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		ran = append(ran, "POST "+url)
+		return nil
+	}
+
+	// Only the channels asked for, and only those configured
+	n.Notify(testEvent(), Channels{Command: true, Desktop: true})
+	n.wait()
+	if len(ran) != 1 || !strings.Contains(ran[0], "echo hi") ||
+		!strings.Contains(ran[0], "SSTOP_PID=4242") || !strings.Contains(ran[0], "SSTOP_NAME=curl") ||
+		!strings.Contains(ran[0], "SSTOP_RATE=12000000") {
+		t.Errorf("command channel ran %q", ran)
+	}
+
+	ran = nil
+	n.Notify(testEvent(), Channels{Webhook: true})
+	n.wait()
+	if len(ran) != 1 || ran[0] != "POST http://example.com/hook" {
+		t.Errorf("webhook channel ran %q", ran)
+	}
+}
+
+func TestEgressEventEnv(t *testing.T) {
+	env := strings.Join(eventEnv(Event{Rule: "non-eu", Text: "egress non-eu: 1.2 MB/1h > 1M"}), "\n")
+	if strings.Contains(env, "SSTOP_PID") || strings.Contains(env, "SSTOP_RATE") || !strings.Contains(env, "SSTOP_RULE=non-eu") {
+		t.Errorf("env:\n%s", env)
+	}
+}
+
+func TestWebhookPost(t *testing.T) {
+	got := make(chan webhookPayload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p webhookPayload
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Content-Type") != "application/json" || json.Unmarshal(body, &p) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		got <- p
+	}))
+	defer srv.Close()
+
+	n, _ := New(config.Alerts{Webhook: srv.URL})
+	n.Notify(testEvent(), Channels{Webhook: true})
+	n.wait()
+	select {
+	case p := <-got:
+		if p.PID != 4242 || p.Rule != "big upload" || !strings.HasPrefix(p.Text, "sstop alert: big upload") {
+			t.Errorf("payload = %+v", p)
+		}
+	default:
+		t.Fatal("webhook not called")
+	}
+
+	if err := postJSON(context.Background(), srv.URL+"/missing", []byte("{")); err == nil {
+		t.Error("postJSON: want an error for a non-2xx reply")
+	}
+}
+
+func TestRunCommandEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	name, args := shell(`test "$SSTOP_NAME" = curl && test "$SSTOP_PID" = 4242`)
+	if err := runCommand(context.Background(), eventEnv(testEvent()), name, args...); err != nil {
+		t.Errorf("command did not see the alert's environment: %v", err)
+	}
+	name, args = shell("echo oops >&2; exit 3")
+	if err := runCommand(context.Background(), nil, name, args...); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("err = %v, want the command's output", err)
+	}
+}
+
+func TestInFlightCap(t *testing.T) {
+	n, _ := New(config.Alerts{Command: "true"})
+	release := make(chan struct{})
+	var mu sync.Mutex
+	started := 0
+	n.run = func(context.Context, []string, string, ...string) error {
+		mu.Lock()
+		started++
+		mu.Unlock()
+		<-release
+		return nil
+	}
+	for i := 0; i < maxInFlight+3; i++ {
+		n.Notify(testEvent(), Channels{Command: true})
+	}
+	close(release)
+	n.wait()
+	if started != maxInFlight {
+		t.Errorf("%d actions ran, want %d", started, maxInFlight)
+	}
+}
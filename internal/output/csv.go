@@ -24,6 +24,7 @@ func (c *CSVWriter) Write(snap model.Snapshot) error {
 	if !c.wroteHeader {
 		if err := c.w.Write([]string{
 			"timestamp", "pid", "process", "upload_bps", "download_bps", "connections", "listen_ports",
+			"inbound_up_bps", "inbound_down_bps", "outbound_up_bps", "outbound_down_bps",
 		}); err != nil {
 			return err
 		}
@@ -40,6 +41,10 @@ func (c *CSVWriter) Write(snap model.Snapshot) error {
 			fmt.Sprintf("%.0f", p.DownRate),
 			fmt.Sprintf("%d", p.ConnCount),
 			fmt.Sprintf("%d", p.ListenCount),
+			fmt.Sprintf("%.0f", p.InboundUp),
+			fmt.Sprintf("%.0f", p.InboundDown),
+			fmt.Sprintf("%.0f", p.OutboundUp),
+			fmt.Sprintf("%.0f", p.OutboundDown),
 		}); err != nil {
 			return err
 		}
@@ -0,0 +1,26 @@
+package output
+
+import (
+	"github.com/googlesky/sstop/internal/hostfmt"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// hostsWriter names remote hosts in a style before writing.
+type hostsWriter struct {
+	w     SnapshotWriter
+	style hostfmt.Style
+}
+
+// HostNames wraps w so the snapshots it writes name remote hosts in style
+// (--host-format, [ui] hosts). FQDN, the collector's own naming, returns w
+// unchanged.
+func HostNames(w SnapshotWriter, style hostfmt.Style) SnapshotWriter {
+	if style == hostfmt.FQDN {
+		return w
+	}
+	return hostsWriter{w: w, style: style}
+}
+
+func (hw hostsWriter) Write(snap model.Snapshot) error {
+	return hw.w.Write(hw.style.Apply(snap))
+}
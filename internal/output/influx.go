@@ -0,0 +1,155 @@
+package output
+
+import (
+	"bufio"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// InfluxWriter writes snapshots as InfluxDB line protocol, one point per
+// process, interface and remote host plus a system-wide total:
+//
+//	sstop_process,pid=1234,name=firefox,user=alice up=1024,down=2048,conns=3i,listen=0i 1736937000000000000
+//	sstop_interface,iface=eth0 up=512,down=4096,bytes_sent=1000i,bytes_recv=9000i ...
+//	sstop_host,host=google.com,ip=142.250.80.46,country=US up=512,down=1024,conns=1i ...
+//	sstop_total up=1536,down=5120 ...
+//
+// Rates are bytes/sec; timestamps are nanoseconds. --tag labels are added
+// to every point.
+type InfluxWriter struct {
+	w *bufio.Writer
+}
+
+// NewInfluxWriter creates a line protocol writer.
+func NewInfluxWriter(w io.Writer) *InfluxWriter {
+	return &InfluxWriter{w: bufio.NewWriter(w)}
+}
+
+// Write writes one snapshot as a batch of points.
+func (iw *InfluxWriter) Write(snap model.Snapshot) error {
+	ts := strconv.FormatInt(snap.Timestamp.UnixNano(), 10)
+
+	for i := range snap.Processes {
+		p := &snap.Processes[i]
+		var l line
+		l.measurement("sstop_process")
+		l.tag("pid", strconv.FormatUint(uint64(p.PID), 10))
+		l.tag("name", p.Name)
+		l.tag("user", p.User)
+		l.fleetTags(snap.Tags)
+		l.floatField("up", p.UpRate)
+		l.floatField("down", p.DownRate)
+		l.intField("conns", int64(p.ConnCount))
+		l.intField("listen", int64(p.ListenCount))
+		iw.writeLine(&l, ts)
+	}
+
+	for i := range snap.Interfaces {
+		ifc := &snap.Interfaces[i]
+		var l line
+		l.measurement("sstop_interface")
+		l.tag("iface", ifc.Name)
+		l.fleetTags(snap.Tags)
+		l.floatField("up", ifc.SendRate)
+		l.floatField("down", ifc.RecvRate)
+		l.intField("bytes_sent", int64(ifc.BytesSent))
+		l.intField("bytes_recv", int64(ifc.BytesRecv))
+		iw.writeLine(&l, ts)
+	}
+
+	for i := range snap.RemoteHosts {
+		h := &snap.RemoteHosts[i]
+		var l line
+		l.measurement("sstop_host")
+		l.tag("host", h.Host)
+		if h.IP != nil {
+			l.tag("ip", h.IP.String())
+		}
+		l.tag("country", h.CountryCode)
+		l.fleetTags(snap.Tags)
+		l.floatField("up", h.UpRate)
+		l.floatField("down", h.DownRate)
+		l.intField("conns", int64(h.ConnCount))
+		iw.writeLine(&l, ts)
+	}
+
+	var l line
+	l.measurement("sstop_total")
+	l.fleetTags(snap.Tags)
+	l.floatField("up", snap.TotalUp)
+	l.floatField("down", snap.TotalDown)
+	iw.writeLine(&l, ts)
+
+	return iw.w.Flush()
+}
+
+func (iw *InfluxWriter) writeLine(l *line, ts string) {
+	iw.w.WriteString(l.b.String())
+	iw.w.WriteByte(' ')
+	iw.w.WriteString(ts)
+	iw.w.WriteByte('\n')
+}
+
+// line builds one line protocol point: measurement, tags, then fields.
+type line struct {
+	b      strings.Builder
+	fields int
+	tags   []string // keys written so far
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	tagEscaper         = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+)
+
+func (l *line) measurement(name string) {
+	l.b.WriteString(measurementEscaper.Replace(name))
+}
+
+// tag appends a tag; empty values are omitted (line protocol forbids them).
+// Tags must be added before any field.
+func (l *line) tag(key, value string) {
+	if value == "" {
+		return
+	}
+	l.tags = append(l.tags, key)
+	l.b.WriteByte(',')
+	l.b.WriteString(tagEscaper.Replace(key))
+	l.b.WriteByte('=')
+	l.b.WriteString(tagEscaper.Replace(value))
+}
+
+// fleetTags appends the --tag labels, skipping keys the point already has.
+func (l *line) fleetTags(tags Tags) {
+	for _, k := range tags.keys() {
+		if !slices.Contains(l.tags, k) {
+			l.tag(k, tags[k])
+		}
+	}
+}
+
+func (l *line) fieldKey(key string) {
+	if l.fields == 0 {
+		l.b.WriteByte(' ')
+	} else {
+		l.b.WriteByte(',')
+	}
+	l.fields++
+	l.b.WriteString(tagEscaper.Replace(key))
+	l.b.WriteByte('=')
+}
+
+func (l *line) floatField(key string, v float64) {
+	l.fieldKey(key)
+	l.b.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+func (l *line) intField(key string, v int64) {
+	l.fieldKey(key)
+	l.b.WriteString(strconv.FormatInt(v, 10))
+	l.b.WriteByte('i')
+}
@@ -1,8 +1,10 @@
 package output
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
+	"sort"
 
 	"github.com/googlesky/sstop/internal/model"
 )
@@ -13,3 +15,128 @@ func WriteJSON(w io.Writer, snap model.Snapshot) error {
 	enc.SetEscapeHTML(false)
 	return enc.Encode(snap)
 }
+
+// WriteProcesses writes processes as one indented JSON array, for saving
+// a selection of the process table to a file.
+func WriteProcesses(w io.Writer, procs []model.ProcessSummary) error {
+	if procs == nil {
+		procs = []model.ProcessSummary{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(procs)
+}
+
+// JSONWriter writes snapshots as NDJSON lines, optionally keeping each line
+// within a byte budget.
+type JSONWriter struct {
+	w        io.Writer
+	maxBytes int // 0 = unlimited
+}
+
+// NewJSONWriter creates a JSON writer. maxBytes <= 0 disables the budget.
+func NewJSONWriter(w io.Writer, maxBytes int) *JSONWriter {
+	if maxBytes < 0 {
+		maxBytes = 0
+	}
+	return &JSONWriter{w: w, maxBytes: maxBytes}
+}
+
+// Write writes one snapshot as a JSON line. When the line would exceed the
+// budget, the snapshot is truncated deterministically (see fitSnapshot) and
+// marked with "truncated": true.
+func (j *JSONWriter) Write(snap model.Snapshot) error {
+	line, err := encodeJSON(snap)
+	if err != nil {
+		return err
+	}
+	if j.maxBytes > 0 && len(line) > j.maxBytes {
+		if line, err = fitSnapshot(snap, j.maxBytes); err != nil {
+			return err
+		}
+	}
+	_, err = j.w.Write(line)
+	return err
+}
+
+// fitSnapshot encodes snap within maxBytes (including the trailing newline):
+//
+//  1. drop every process's connection list (counted in OmittedConns)
+//  2. drop processes from the lowest rate up (ties: higher PID first)
+//
+// If the snapshot is still too large with no processes left, it is written
+// as-is; interfaces and the other system-wide lists are never dropped.
+func fitSnapshot(snap model.Snapshot, maxBytes int) ([]byte, error) {
+	snap.Truncated = true
+
+	// Stage 1: connection detail
+	procs := make([]model.ProcessSummary, len(snap.Processes))
+	for i, p := range snap.Processes {
+		for _, c := range p.Connections {
+			p.OmittedConns++
+			p.OmittedUpRate += c.UpRate
+			p.OmittedDownRate += c.DownRate
+		}
+		p.Connections = nil
+		procs[i] = p
+	}
+	snap.Processes = procs
+
+	line, err := encodeJSON(snap)
+	if err != nil || len(line) <= maxBytes {
+		return line, err
+	}
+
+	// Stage 2: low-rate processes
+	sort.SliceStable(procs, func(a, b int) bool {
+		ra := procs[a].UpRate + procs[a].DownRate
+		rb := procs[b].UpRate + procs[b].DownRate
+		if ra != rb {
+			return ra > rb
+		}
+		return procs[a].PID < procs[b].PID
+	})
+
+	// Size of the snapshot with an empty process list; counting every process
+	// as omitted gives an upper bound for the omitted_processes field.
+	snap.Processes = []model.ProcessSummary{}
+	snap.OmittedProcesses = len(procs)
+	base, err := encodeJSON(snap)
+	if err != nil {
+		return nil, err
+	}
+
+	size := len(base)
+	keep := 0
+	for i := range procs {
+		p, err := encodeJSON(procs[i])
+		if err != nil {
+			return nil, err
+		}
+		add := len(p) - 1 // without newline
+		if i > 0 {
+			add++ // separating comma
+		}
+		if size+add > maxBytes {
+			break
+		}
+		size += add
+		keep++
+	}
+
+	snap.Processes = procs[:keep]
+	snap.OmittedProcesses = len(procs) - keep
+	return encodeJSON(snap)
+}
+
+// encodeJSON encodes v as one JSON line, matching WriteJSON.
+func encodeJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
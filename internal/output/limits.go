@@ -0,0 +1,204 @@
+package output
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/googlesky/sstop/internal/config"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// OtherName names the entry the processes, hosts or interfaces left out by
+// Limits are summed into.
+const OtherName = "other"
+
+// limit is a compiled config.MetricLimit.
+type limit struct {
+	max            int
+	allow, exclude []*regexp.Regexp
+}
+
+func newLimit(cfg config.MetricLimit) (limit, error) {
+	l := limit{max: cfg.Max}
+	compile := func(patterns []string) ([]*regexp.Regexp, error) {
+		var out []*regexp.Regexp
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, re)
+		}
+		return out, nil
+	}
+	var err error
+	if l.allow, err = compile(cfg.Allow); err != nil {
+		return l, err
+	}
+	l.exclude, err = compile(cfg.Exclude)
+	return l, err
+}
+
+func (l limit) active() bool {
+	return l.max > 0 || len(l.allow) > 0 || len(l.exclude) > 0
+}
+
+// wanted reports whether name passes the allow and exclude lists.
+func (l limit) wanted(name string) bool {
+	if len(l.allow) > 0 {
+		allowed := false
+		for _, re := range l.allow {
+			if re.MatchString(name) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	for _, re := range l.exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// split returns the indexes of the n entries kept, in their original
+// order, and of the rest. Wanted entries are kept, the busiest first
+// when there are more than max.
+func (l limit) split(n int, name func(int) string, rate func(int) float64) (kept, rest []int) {
+	for i := 0; i < n; i++ {
+		if l.wanted(name(i)) {
+			kept = append(kept, i)
+		} else {
+			rest = append(rest, i)
+		}
+	}
+	if l.max > 0 && len(kept) > l.max {
+		busiest := append([]int(nil), kept...)
+		sort.SliceStable(busiest, func(a, b int) bool { return rate(busiest[a]) > rate(busiest[b]) })
+		rest = append(rest, busiest[l.max:]...)
+		kept = busiest[:l.max]
+		sort.Ints(kept)
+	}
+	return kept, rest
+}
+
+// Limits bounds the cardinality of exported metrics: per dimension, the
+// processes, hosts or interfaces not allowed, excluded or past the cap
+// are summed into one entry named OtherName, so totals still add up.
+type Limits struct {
+	processes, hosts, interfaces limit
+}
+
+// NewLimits compiles the config's [metrics] limits. It returns nil when
+// none are set.
+func NewLimits(cfg config.Metrics) (*Limits, error) {
+	var l Limits
+	var err error
+	if l.processes, err = newLimit(cfg.Processes); err != nil {
+		return nil, fmt.Errorf("metrics.processes: %w", err)
+	}
+	if l.hosts, err = newLimit(cfg.Hosts); err != nil {
+		return nil, fmt.Errorf("metrics.hosts: %w", err)
+	}
+	if l.interfaces, err = newLimit(cfg.Interfaces); err != nil {
+		return nil, fmt.Errorf("metrics.interfaces: %w", err)
+	}
+	if !l.processes.active() && !l.hosts.active() && !l.interfaces.active() {
+		return nil, nil
+	}
+	return &l, nil
+}
+
+// Apply returns snap with its processes, remote hosts and interfaces
+// limited. The snapshot's slices are not modified; sinks share them.
+func (l *Limits) Apply(snap model.Snapshot) model.Snapshot {
+	if l.processes.active() {
+		procs := snap.Processes
+		kept, rest := l.processes.split(len(procs),
+			func(i int) string { return procs[i].Name },
+			func(i int) float64 { return procs[i].UpRate + procs[i].DownRate })
+		out := make([]model.ProcessSummary, 0, len(kept)+1)
+		for _, i := range kept {
+			out = append(out, procs[i])
+		}
+		if len(rest) > 0 {
+			other := model.ProcessSummary{Name: OtherName}
+			for _, i := range rest {
+				other.UpRate += procs[i].UpRate
+				other.DownRate += procs[i].DownRate
+				other.ConnCount += procs[i].ConnCount
+				other.ListenCount += procs[i].ListenCount
+			}
+			out = append(out, other)
+		}
+		snap.Processes = out
+	}
+
+	if l.hosts.active() {
+		hosts := snap.RemoteHosts
+		kept, rest := l.hosts.split(len(hosts),
+			func(i int) string { return hosts[i].Host },
+			func(i int) float64 { return hosts[i].UpRate + hosts[i].DownRate })
+		out := make([]model.RemoteHostSummary, 0, len(kept)+1)
+		for _, i := range kept {
+			out = append(out, hosts[i])
+		}
+		if len(rest) > 0 {
+			other := model.RemoteHostSummary{Host: OtherName}
+			for _, i := range rest {
+				other.UpRate += hosts[i].UpRate
+				other.DownRate += hosts[i].DownRate
+				other.ConnCount += hosts[i].ConnCount
+			}
+			out = append(out, other)
+		}
+		snap.RemoteHosts = out
+	}
+
+	if l.interfaces.active() {
+		ifaces := snap.Interfaces
+		kept, rest := l.interfaces.split(len(ifaces),
+			func(i int) string { return ifaces[i].Name },
+			func(i int) float64 { return ifaces[i].SendRate + ifaces[i].RecvRate })
+		out := make([]model.InterfaceStats, 0, len(kept)+1)
+		for _, i := range kept {
+			out = append(out, ifaces[i])
+		}
+		if len(rest) > 0 {
+			other := model.InterfaceStats{Name: OtherName}
+			for _, i := range rest {
+				other.SendRate += ifaces[i].SendRate
+				other.RecvRate += ifaces[i].RecvRate
+				other.BytesSent += ifaces[i].BytesSent
+				other.BytesRecv += ifaces[i].BytesRecv
+			}
+			out = append(out, other)
+		}
+		snap.Interfaces = out
+	}
+	return snap
+}
+
+// limitedWriter applies Limits before writing.
+type limitedWriter struct {
+	w SnapshotWriter
+	l *Limits
+}
+
+// Limit wraps w so the snapshots it writes are limited by l. A nil l
+// returns w unchanged.
+func Limit(w SnapshotWriter, l *Limits) SnapshotWriter {
+	if l == nil {
+		return w
+	}
+	return limitedWriter{w: w, l: l}
+}
+
+func (lw limitedWriter) Write(snap model.Snapshot) error {
+	return lw.w.Write(lw.l.Apply(snap))
+}
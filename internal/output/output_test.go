@@ -8,6 +8,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/googlesky/sstop/internal/config"
+	"github.com/googlesky/sstop/internal/hostfmt"
 	"github.com/googlesky/sstop/internal/model"
 )
 
@@ -16,11 +18,13 @@ func testSnapshot() model.Snapshot {
 		Timestamp: time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC),
 		Processes: []model.ProcessSummary{
 			{
-				PID:      1234,
-				Name:     "firefox",
-				Cmdline:  "/usr/bin/firefox",
-				UpRate:   1024,
-				DownRate: 2048,
+				PID:          1234,
+				Name:         "firefox",
+				Cmdline:      "/usr/bin/firefox",
+				UpRate:       1024,
+				DownRate:     2048,
+				OutboundUp:   1024,
+				OutboundDown: 2048,
 				Connections: []model.Connection{
 					{
 						Proto:      model.ProtoTCP,
@@ -108,6 +112,50 @@ func TestWriteJSON(t *testing.T) {
 	if p0["up_rate"] != float64(1024) {
 		t.Errorf("expected up_rate 1024, got %v", p0["up_rate"])
 	}
+	for _, key := range []string{"inbound_up", "inbound_down", "outbound_up", "outbound_down"} {
+		if _, ok := p0[key]; !ok {
+			t.Errorf("missing %s field", key)
+		}
+	}
+	if p0["outbound_down"] != float64(2048) {
+		t.Errorf("expected outbound_down 2048, got %v", p0["outbound_down"])
+	}
+}
+
+func TestWriteProcesses(t *testing.T) {
+	procs := testSnapshot().Processes
+	procs[0].StartTime = time.Date(2025, 1, 15, 9, 0, 0, 0, time.UTC)
+	root := uint32(0)
+	procs[0].UID, procs[0].User = &root, "root"
+	var buf bytes.Buffer
+	if err := WriteProcesses(&buf, procs); err != nil {
+		t.Fatalf("WriteProcesses: %v", err)
+	}
+
+	var decoded []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v\noutput: %s", err, buf.String())
+	}
+	if len(decoded) != 2 || decoded[0]["name"] != "firefox" || decoded[1]["pid"] != float64(22) {
+		t.Fatalf("decoded = %v, want firefox and sshd", decoded)
+	}
+	if decoded[0]["start_time"] != "2025-01-15T09:00:00Z" {
+		t.Errorf("start_time = %v", decoded[0]["start_time"])
+	}
+	if _, ok := decoded[1]["start_time"]; ok {
+		t.Error("unknown start_time should be left out")
+	}
+	if decoded[0]["uid"] != float64(0) {
+		t.Errorf("uid = %v, want root's 0", decoded[0]["uid"])
+	}
+	if _, ok := decoded[1]["uid"]; ok {
+		t.Error("unknown uid should be left out, not reported as root")
+	}
+
+	buf.Reset()
+	if err := WriteProcesses(&buf, nil); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("no processes: %q, %v; want []", buf.String(), err)
+	}
 }
 
 func TestWriteJSON_MultipleSnapshots(t *testing.T) {
@@ -133,6 +181,97 @@ func TestWriteJSON_MultipleSnapshots(t *testing.T) {
 	}
 }
 
+func TestJSONWriter_UnderBudget(t *testing.T) {
+	snap := testSnapshot()
+	var want, got bytes.Buffer
+	if err := WriteJSON(&want, snap); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+
+	w := NewJSONWriter(&got, want.Len())
+	if err := w.Write(snap); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("snapshot within budget should be written unchanged:\ngot  %s\nwant %s", got.String(), want.String())
+	}
+}
+
+func TestJSONWriter_DropsConnectionsFirst(t *testing.T) {
+	snap := testSnapshot()
+	var full bytes.Buffer
+	if err := WriteJSON(&full, snap); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w := NewJSONWriter(&buf, full.Len()-1)
+	if err := w.Write(snap); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if buf.Len() > full.Len()-1 {
+		t.Errorf("line is %d bytes, budget %d", buf.Len(), full.Len()-1)
+	}
+
+	var decoded model.Snapshot
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !decoded.Truncated {
+		t.Error("expected truncated marker")
+	}
+	if len(decoded.Processes) != 2 || decoded.OmittedProcesses != 0 {
+		t.Fatalf("expected both processes kept, got %d (omitted %d)", len(decoded.Processes), decoded.OmittedProcesses)
+	}
+	ff := decoded.Processes[0]
+	if len(ff.Connections) != 0 {
+		t.Errorf("expected connection detail dropped, got %d", len(ff.Connections))
+	}
+	if ff.OmittedConns != 1 || ff.OmittedUpRate != 512 || ff.OmittedDownRate != 1024 {
+		t.Errorf("expected dropped connection aggregated, got %d conns %.0f/%.0f",
+			ff.OmittedConns, ff.OmittedUpRate, ff.OmittedDownRate)
+	}
+
+	// Caller's snapshot must not be modified
+	if len(snap.Processes[0].Connections) != 1 {
+		t.Error("input snapshot was modified")
+	}
+}
+
+func TestJSONWriter_DropsLowRateProcesses(t *testing.T) {
+	snap := testSnapshot()
+
+	// Just below the size with connection detail already dropped
+	stage1, err := fitSnapshot(snap, 1<<30)
+	if err != nil {
+		t.Fatalf("fitSnapshot: %v", err)
+	}
+	budget := len(stage1) - 1
+
+	var buf bytes.Buffer
+	w := NewJSONWriter(&buf, budget)
+	if err := w.Write(snap); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if buf.Len() > budget {
+		t.Errorf("line is %d bytes, budget %d:\n%s", buf.Len(), budget, buf.String())
+	}
+
+	var decoded model.Snapshot
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !decoded.Truncated {
+		t.Error("expected truncated marker")
+	}
+	if len(decoded.Processes) != 1 || decoded.Processes[0].Name != "firefox" {
+		t.Fatalf("expected only the busiest process (firefox) kept, got %+v", decoded.Processes)
+	}
+	if decoded.OmittedProcesses != 1 {
+		t.Errorf("expected 1 omitted process, got %d", decoded.OmittedProcesses)
+	}
+}
+
 func TestCSVWriter(t *testing.T) {
 	snap := testSnapshot()
 	var buf bytes.Buffer
@@ -149,7 +288,8 @@ func TestCSVWriter(t *testing.T) {
 	}
 
 	// Check header
-	if lines[0] != "timestamp,pid,process,upload_bps,download_bps,connections,listen_ports" {
+	if lines[0] != "timestamp,pid,process,upload_bps,download_bps,connections,listen_ports,"+
+		"inbound_up_bps,inbound_down_bps,outbound_up_bps,outbound_down_bps" {
 		t.Errorf("unexpected header: %s", lines[0])
 	}
 
@@ -206,3 +346,388 @@ func TestCSVWriter_EmptySnapshot(t *testing.T) {
 		t.Fatalf("expected 1 CSV line (header only), got %d", len(lines))
 	}
 }
+
+func TestInfluxWriter(t *testing.T) {
+	snap := testSnapshot()
+	snap.Processes[0].User = "alice"
+	snap.RemoteHosts[0].CountryCode = "US"
+	var buf bytes.Buffer
+
+	if err := NewInfluxWriter(&buf).Write(snap); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	// 2 processes + 1 interface + 1 host + total
+	if len(lines) != 5 {
+		t.Fatalf("expected 5 lines, got %d:\n%s", len(lines), buf.String())
+	}
+
+	ts := " 1736937000000000000"
+	want := []string{
+		"sstop_process,pid=1234,name=firefox,user=alice up=1024,down=2048,conns=1i,listen=0i" + ts,
+		"sstop_process,pid=22,name=sshd up=0,down=0,conns=0i,listen=1i" + ts,
+		"sstop_interface,iface=eth0 up=1024,down=2048,bytes_sent=50000i,bytes_recv=100000i" + ts,
+		"sstop_host,host=google.com,ip=142.250.80.46,country=US up=512,down=1024,conns=1i" + ts,
+		"sstop_total up=1024,down=2048" + ts,
+	}
+	for i, w := range want {
+		if lines[i] != w {
+			t.Errorf("line %d:\n got  %s\n want %s", i, lines[i], w)
+		}
+	}
+}
+
+func TestInfluxWriter_EscapesTags(t *testing.T) {
+	snap := model.Snapshot{
+		Timestamp: time.Unix(0, 1),
+		Processes: []model.ProcessSummary{{PID: 1, Name: "Web Content,1=a"}},
+	}
+	var buf bytes.Buffer
+	if err := NewInfluxWriter(&buf).Write(snap); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !strings.Contains(buf.String(), `name=Web\ Content\,1\=a up=`) {
+		t.Errorf("tag not escaped:\n%s", buf.String())
+	}
+}
+
+func TestSnapshotMetrics(t *testing.T) {
+	snap := testSnapshot()
+	snap.Processes = append(snap.Processes, model.ProcessSummary{PID: 99, Name: "firefox", UpRate: 1, DownRate: 2, ConnCount: 4})
+	snap.Processes = append(snap.Processes, model.ProcessSummary{PID: 7, Name: "Web Content.1"})
+
+	got := make(map[string]float64)
+	for _, m := range snapshotMetrics(snap, "host1.sstop") {
+		got[m.name] = m.value
+	}
+
+	want := map[string]float64{
+		"host1.sstop.process.firefox.up":       1025, // two firefox processes summed
+		"host1.sstop.process.firefox.down":     2050,
+		"host1.sstop.process.firefox.conns":    5,
+		"host1.sstop.process.Web_Content_1.up": 0,
+		"host1.sstop.process.sshd.conns":       0,
+		"host1.sstop.interface.eth0.up":        1024,
+		"host1.sstop.interface.eth0.down":      2048,
+		"host1.sstop.total.up":                 1024,
+		"host1.sstop.total.down":               2048,
+	}
+	for name, v := range want {
+		if gv, ok := got[name]; !ok || gv != v {
+			t.Errorf("%s = %v (present %v), want %v", name, gv, ok, v)
+		}
+	}
+}
+
+func TestStatsdPackets(t *testing.T) {
+	metrics := []metric{{"a.b", 1}, {"a.c", 2.5}, {"a.d", 3}}
+	packets := statsdPackets(metrics, nil, 1000)
+	if len(packets) != 1 || string(packets[0]) != "a.b:1|g\na.c:2.5|g\na.d:3|g" {
+		t.Errorf("packets = %q", packets)
+	}
+
+	// "a.b:1|g\na.c:2.5|g" is 17 bytes, so an 18-byte limit splits before a.d
+	packets = statsdPackets(metrics, nil, 18)
+	if len(packets) != 2 {
+		t.Fatalf("expected 2 packets, got %q", packets)
+	}
+	for _, p := range packets {
+		if len(p) > 18 {
+			t.Errorf("packet over limit: %q", p)
+		}
+	}
+}
+
+func TestStatsdWriter(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("udp unavailable: %v", err)
+	}
+	defer pc.Close()
+
+	w, err := NewStatsdWriter(pc.LocalAddr().String(), "sstop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Write(testSnapshot()); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	buf := make([]byte, 2048)
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	if !strings.Contains(string(buf[:n]), "sstop.process.firefox.up:1024|g") {
+		t.Errorf("unexpected packet:\n%s", buf[:n])
+	}
+}
+
+func TestGraphiteWriter(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("tcp unavailable: %v", err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 4096)
+		n, _ := conn.Read(buf)
+		received <- string(buf[:n])
+	}()
+
+	w, err := NewGraphiteWriter(ln.Addr().String(), "sstop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Write(testSnapshot()); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	select {
+	case got := <-received:
+		if !strings.Contains(got, "sstop.total.down 2048 1736937000\n") {
+			t.Errorf("unexpected payload:\n%s", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no data received")
+	}
+}
+
+func TestGraphiteWriterReconnects(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("tcp unavailable: %v", err)
+	}
+	defer ln.Close()
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+
+	w, err := NewGraphiteWriter(ln.Addr().String(), "sstop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.backoff.min = 10 * time.Millisecond
+	(<-conns).Close()
+
+	// The dropped connection fails a write once; until the redial, writes
+	// are skipped instead of dialling inline
+	var werr error
+	for i := 0; i < 100 && werr == nil; i++ {
+		werr = w.Write(testSnapshot())
+		time.Sleep(5 * time.Millisecond)
+	}
+	if werr == nil {
+		t.Fatal("writes to a closed connection never failed")
+	}
+	start := time.Now()
+	if err := w.Write(testSnapshot()); err != nil || time.Since(start) > time.Second {
+		t.Errorf("write while reconnecting: %v after %v", err, time.Since(start))
+	}
+
+	var conn net.Conn
+	select {
+	case conn = <-conns:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no reconnect")
+	}
+	defer conn.Close()
+	received := make(chan string, 1)
+	go func() {
+		buf := make([]byte, 4096)
+		n, _ := conn.Read(buf)
+		received <- string(buf[:n])
+	}()
+	deadline := time.After(2 * time.Second)
+	for {
+		if err := w.Write(testSnapshot()); err != nil {
+			t.Fatalf("Write after reconnect: %v", err)
+		}
+		select {
+		case got := <-received:
+			if !strings.Contains(got, "sstop.total.down 2048 1736937000\n") {
+				t.Errorf("unexpected payload:\n%s", got)
+			}
+			return
+		case <-deadline:
+			t.Fatal("no data after reconnect")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+type failingWriter struct{ calls int }
+
+func (f *failingWriter) Write(model.Snapshot) error {
+	f.calls++
+	return net.ErrClosed
+}
+
+func TestTeeContinuesOnError(t *testing.T) {
+	in := make(chan model.Snapshot, 2)
+	in <- testSnapshot()
+	in <- testSnapshot()
+	close(in)
+
+	fw := &failingWriter{}
+	errs := 0
+	out := Tee(in, fw, func(error) { errs++ })
+	n := 0
+	for range out {
+		n++
+	}
+	if n != 2 || fw.calls != 2 || errs != 2 {
+		t.Errorf("passed %d, writes %d, errors %d; want 2 each", n, fw.calls, errs)
+	}
+}
+
+func TestTagsFlag(t *testing.T) {
+	tags := Tags{}
+	for _, s := range []string{"role=web", "dc=fra1", "env=prod"} {
+		if err := tags.Set(s); err != nil {
+			t.Fatalf("Set(%q): %v", s, err)
+		}
+	}
+	if got := tags.String(); got != "dc=fra1,env=prod,role=web" {
+		t.Errorf("String() = %q", got)
+	}
+	for _, bad := range []string{"role", "=web", "1st=x", "role=", "role=a b", "role=a,b", "ro le=x"} {
+		if err := tags.Set(bad); err == nil {
+			t.Errorf("Set(%q) accepted", bad)
+		}
+	}
+}
+
+func TestTagsInOutputs(t *testing.T) {
+	snap := testSnapshot()
+	snap.Tags = Tags{"role": "web", "dc": "fra1", "name": "clash"}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, snap); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"tags":{"dc":"fra1","name":"clash","role":"web"}`) {
+		t.Errorf("JSON missing tags:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := NewInfluxWriter(&buf).Write(snap); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	// A fleet tag never overrides the point's own tag
+	if want := "sstop_process,pid=1234,name=firefox,dc=fra1,role=web up="; !strings.HasPrefix(lines[0], want) {
+		t.Errorf("influx process line = %q, want prefix %q", lines[0], want)
+	}
+	if want := "sstop_total,dc=fra1,name=clash,role=web up="; !strings.HasPrefix(lines[len(lines)-2], want) {
+		t.Errorf("influx total line = %q, want prefix %q", lines[len(lines)-2], want)
+	}
+
+	packets := statsdPackets([]metric{{"a.b", 1}}, Tags{"role": "web", "dc": "fra1"}, 1000)
+	if len(packets) != 1 || string(packets[0]) != "a.b:1|g|#dc:fra1,role:web" {
+		t.Errorf("statsd packets = %q", packets)
+	}
+}
+
+func TestTagStage(t *testing.T) {
+	in := make(chan model.Snapshot, 1)
+	out := Tag(in, Tags{"role": "web"})
+	in <- model.Snapshot{}
+	close(in)
+	if snap := <-out; snap.Tags["role"] != "web" {
+		t.Errorf("tags = %v", snap.Tags)
+	}
+	if _, ok := <-out; ok {
+		t.Error("output channel not closed")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	snap := testSnapshot()
+	snap.Processes = append(snap.Processes,
+		model.ProcessSummary{PID: 3, Name: "kworker/0:1", UpRate: 5000, ConnCount: 2},
+		model.ProcessSummary{PID: 4, Name: "curl", UpRate: 10, DownRate: 10, ConnCount: 1},
+	)
+	l, err := NewLimits(config.Metrics{
+		Processes: config.MetricLimit{Max: 2, Exclude: []string{"^kworker"}},
+		Hosts:     config.MetricLimit{Allow: []string{`\.example\.com$`}},
+	})
+	if err != nil {
+		t.Fatalf("NewLimits: %v", err)
+	}
+	got := l.Apply(snap)
+
+	var names []string
+	for _, p := range got.Processes {
+		names = append(names, p.Name)
+	}
+	// firefox and curl are the busiest kept; sshd is past the cap and
+	// kworker excluded
+	if strings.Join(names, ",") != "firefox,curl,other" {
+		t.Fatalf("processes = %v", names)
+	}
+	other := got.Processes[2]
+	if other.UpRate != 5000 || other.ConnCount != 2 || other.ListenCount != 1 {
+		t.Errorf("other = %+v", other)
+	}
+	if len(got.RemoteHosts) != 1 || got.RemoteHosts[0].Host != OtherName || got.RemoteHosts[0].DownRate != 1024 {
+		t.Errorf("hosts = %+v", got.RemoteHosts)
+	}
+	if len(got.Interfaces) != 1 || got.Interfaces[0].Name != "eth0" {
+		t.Errorf("interfaces = %+v, want untouched", got.Interfaces)
+	}
+	if len(snap.Processes) != 4 || snap.Processes[2].Name != "kworker/0:1" {
+		t.Error("Apply modified the snapshot")
+	}
+
+	if l, err := NewLimits(config.Metrics{}); l != nil || err != nil {
+		t.Errorf("NewLimits(empty) = %v, %v; want nil", l, err)
+	}
+	if _, err := NewLimits(config.Metrics{Interfaces: config.MetricLimit{Allow: []string{"("}}}); err == nil {
+		t.Error("NewLimits accepted a bad pattern")
+	}
+}
+
+func TestLimitWriter(t *testing.T) {
+	l, _ := NewLimits(config.Metrics{Processes: config.MetricLimit{Max: 1}})
+	var buf bytes.Buffer
+	if err := Limit(NewInfluxWriter(&buf), l).Write(testSnapshot()); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "name=firefox") || strings.Contains(out, "name=sshd") || !strings.Contains(out, "pid=0,name=other") {
+		t.Errorf("influx output:\n%s", out)
+	}
+}
+
+func TestHostNamesWriter(t *testing.T) {
+	var buf bytes.Buffer
+	if err := HostNames(NewInfluxWriter(&buf), hostfmt.IP).Write(testSnapshot()); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "host=142.250.80.46") || strings.Contains(out, "google.com") {
+		t.Errorf("influx output:\n%s", out)
+	}
+	if w := NewCSVWriter(&buf); HostNames(w, hostfmt.FQDN) != SnapshotWriter(w) {
+		t.Error("fqdn should leave the writer unwrapped")
+	}
+}
@@ -0,0 +1,265 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// DefaultMetricPrefix is the default statsd/Graphite metric prefix.
+const DefaultMetricPrefix = "sstop"
+
+// statsdMaxPacket keeps UDP datagrams under a typical 1500-byte MTU.
+const statsdMaxPacket = 1432
+
+// metric is one named gauge value.
+type metric struct {
+	name  string
+	value float64
+}
+
+// snapshotMetrics flattens a snapshot into gauges:
+//
+//	<prefix>.process.<name>.up / .down / .conns
+//	<prefix>.interface.<iface>.up / .down
+//	<prefix>.total.up / .down
+//
+// Processes are keyed by name (summing processes that share one) so metric
+// names survive restarts and PID churn. Rates are bytes/sec.
+func snapshotMetrics(snap model.Snapshot, prefix string) []metric {
+	type procAgg struct{ up, down, conns float64 }
+	procs := make(map[string]*procAgg)
+	for i := range snap.Processes {
+		p := &snap.Processes[i]
+		key := metricKey(p.Name)
+		a, ok := procs[key]
+		if !ok {
+			a = &procAgg{}
+			procs[key] = a
+		}
+		a.up += p.UpRate
+		a.down += p.DownRate
+		a.conns += float64(p.ConnCount)
+	}
+
+	names := make([]string, 0, len(procs))
+	for name := range procs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []metric
+	for _, name := range names {
+		a := procs[name]
+		base := prefix + ".process." + name
+		out = append(out,
+			metric{base + ".up", a.up},
+			metric{base + ".down", a.down},
+			metric{base + ".conns", a.conns},
+		)
+	}
+	for i := range snap.Interfaces {
+		ifc := &snap.Interfaces[i]
+		base := prefix + ".interface." + metricKey(ifc.Name)
+		out = append(out,
+			metric{base + ".up", ifc.SendRate},
+			metric{base + ".down", ifc.RecvRate},
+		)
+	}
+	out = append(out,
+		metric{prefix + ".total.up", snap.TotalUp},
+		metric{prefix + ".total.down", snap.TotalDown},
+	)
+	return out
+}
+
+// metricKey makes a name safe as a single statsd/Graphite path component.
+func metricKey(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+func formatMetricValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// StatsdWriter sends snapshot metrics as statsd gauges over UDP.
+type StatsdWriter struct {
+	conn   net.Conn
+	prefix string
+}
+
+// NewStatsdWriter dials the statsd endpoint (host:port, usually :8125).
+func NewStatsdWriter(addr, prefix string) (*StatsdWriter, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsdWriter{conn: conn, prefix: prefix}, nil
+}
+
+// Write sends one snapshot as a batch of gauge packets.
+func (s *StatsdWriter) Write(snap model.Snapshot) error {
+	for _, pkt := range statsdPackets(snapshotMetrics(snap, s.prefix), snap.Tags, statsdMaxPacket) {
+		if _, err := s.conn.Write(pkt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the UDP socket.
+func (s *StatsdWriter) Close() error {
+	return s.conn.Close()
+}
+
+// statsdPackets packs "name:value|g" lines into datagrams of at most maxSize
+// bytes. Tags use the DogStatsD extension ("|#role:web,dc:fra1"), which
+// Telegraf and the Datadog agent understand.
+func statsdPackets(metrics []metric, tags Tags, maxSize int) [][]byte {
+	suffix := ""
+	for i, k := range tags.keys() {
+		if i == 0 {
+			suffix = "|#"
+		} else {
+			suffix += ","
+		}
+		suffix += k + ":" + tags[k]
+	}
+
+	var packets [][]byte
+	var buf bytes.Buffer
+	for _, m := range metrics {
+		line := m.name + ":" + formatMetricValue(m.value) + "|g" + suffix
+		if buf.Len() > 0 && buf.Len()+1+len(line) > maxSize {
+			packets = append(packets, append([]byte(nil), buf.Bytes()...))
+			buf.Reset()
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(line)
+	}
+	if buf.Len() > 0 {
+		packets = append(packets, buf.Bytes())
+	}
+	return packets
+}
+
+// GraphiteWriter sends snapshot metrics using the Graphite plaintext
+// protocol over TCP. A connection that drops is redialled in the
+// background, with backoff; snapshots written meanwhile are skipped rather
+// than holding up the pipeline.
+type GraphiteWriter struct {
+	addr    string
+	prefix  string
+	backoff struct{ min, max time.Duration } // between reconnect attempts
+
+	mu     sync.Mutex
+	conn   net.Conn // nil while reconnecting
+	closed bool
+	done   chan struct{} // closed by Close, ending a reconnect
+}
+
+// NewGraphiteWriter dials the Graphite endpoint (host:port, usually :2003).
+func NewGraphiteWriter(addr, prefix string) (*GraphiteWriter, error) {
+	g := &GraphiteWriter{addr: addr, prefix: prefix, done: make(chan struct{})}
+	g.backoff.min, g.backoff.max = time.Second, 30*time.Second
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	g.conn = conn
+	return g, nil
+}
+
+// reconnect dials until it succeeds or the writer is closed.
+func (g *GraphiteWriter) reconnect() {
+	backoff := g.backoff.min
+	for {
+		select {
+		case <-g.done:
+			return
+		case <-time.After(backoff):
+		}
+		conn, err := net.DialTimeout("tcp", g.addr, 5*time.Second)
+		if err != nil {
+			backoff = min(backoff*2, g.backoff.max)
+			continue
+		}
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		if g.closed {
+			conn.Close()
+			return
+		}
+		g.conn = conn
+		return
+	}
+}
+
+// Write sends one snapshot as "name value timestamp" lines. While the
+// connection is being redialled it does nothing.
+func (g *GraphiteWriter) Write(snap model.Snapshot) error {
+	g.mu.Lock()
+	conn := g.conn
+	g.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+
+	// Tags use the Graphite 1.1 tagged series syntax: name;key=value
+	tags := ""
+	for _, k := range Tags(snap.Tags).keys() {
+		tags += ";" + k + "=" + snap.Tags[k]
+	}
+
+	var buf bytes.Buffer
+	ts := snap.Timestamp.Unix()
+	for _, m := range snapshotMetrics(snap, g.prefix) {
+		fmt.Fprintf(&buf, "%s%s %s %d\n", m.name, tags, formatMetricValue(m.value), ts)
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		conn.Close()
+		g.mu.Lock()
+		g.conn = nil
+		if !g.closed {
+			go g.reconnect()
+		}
+		g.mu.Unlock()
+		return fmt.Errorf("%w (reconnecting, skipping snapshots until then)", err)
+	}
+	return nil
+}
+
+// Close closes the TCP connection and stops reconnecting.
+func (g *GraphiteWriter) Close() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return nil
+	}
+	g.closed = true
+	close(g.done)
+	if g.conn == nil {
+		return nil
+	}
+	return g.conn.Close()
+}
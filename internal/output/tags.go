@@ -0,0 +1,79 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// Tags are fleet labels (--tag key=value) attached to every snapshot. It
+// implements flag.Value, so the flag can be repeated.
+type Tags map[string]string
+
+// String renders the tags as sorted key=value pairs.
+func (t Tags) String() string {
+	var parts []string
+	for _, k := range t.keys() {
+		parts = append(parts, k+"="+t[k])
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set parses one key=value pair. Keys are letters, digits, '_', '-' and
+// '.', starting with a letter or '_'; values may not contain whitespace or
+// any of ,;=|# so they pass unescaped through every output format.
+func (t Tags) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("tag %q: want key=value", s)
+	}
+	if !validTagKey(key) {
+		return fmt.Errorf("tag %q: invalid key", s)
+	}
+	if value == "" || strings.ContainsAny(value, " \t\n,;=|#") {
+		return fmt.Errorf("tag %q: value must be non-empty without spaces or ,;=|#", s)
+	}
+	t[key] = value
+	return nil
+}
+
+func validTagKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// keys returns the tag keys in sorted order, for stable output.
+func (t Tags) keys() []string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Tag sets the tags on every snapshot passing through the channel. Place it
+// before the writers and sinks.
+func Tag(snapCh <-chan model.Snapshot, tags Tags) <-chan model.Snapshot {
+	out := make(chan model.Snapshot, 1)
+	go func() {
+		defer close(out)
+		for snap := range snapCh {
+			snap.Tags = tags
+			out <- snap
+		}
+	}()
+	return out
+}
@@ -0,0 +1,25 @@
+package output
+
+import "github.com/googlesky/sstop/internal/model"
+
+// SnapshotWriter is implemented by every writer in this package.
+type SnapshotWriter interface {
+	Write(snap model.Snapshot) error
+}
+
+// Tee writes every snapshot passing through the channel to w, for sinks
+// that run alongside the TUI or another output mode. Write errors go to
+// onErr and do not interrupt the stream.
+func Tee(snapCh <-chan model.Snapshot, w SnapshotWriter, onErr func(error)) <-chan model.Snapshot {
+	out := make(chan model.Snapshot, 1)
+	go func() {
+		defer close(out)
+		for snap := range snapCh {
+			if err := w.Write(snap); err != nil && onErr != nil {
+				onErr(err)
+			}
+			out <- snap
+		}
+	}()
+	return out
+}
@@ -0,0 +1,293 @@
+package platform
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+	"golang.org/x/net/bpf"
+)
+
+// PacketCapture is a running capture of one process's traffic to a pcap
+// file, started by StartCapture. Write refreshes the connections it follows
+// from each snapshot, so it can sit in the snapshot pipeline like any other
+// writer.
+type PacketCapture interface {
+	Write(snap model.Snapshot) error
+	PID() uint32
+	Path() string
+	Packets() int // packets written so far
+	Close() error
+}
+
+// CaptureFileName is the default capture file for a process:
+// sstop-<name>-<pid>-<time>.pcap, without the name if it isn't known.
+func CaptureFileName(name string, pid uint32, t time.Time) string {
+	stamp := t.Format("20060102-150405")
+	if name == "" {
+		return fmt.Sprintf("sstop-%d-%s.pcap", pid, stamp)
+	}
+	return fmt.Sprintf("sstop-%s-%d-%s.pcap", strings.ReplaceAll(name, "/", "_"), pid, stamp)
+}
+
+// pcap file format constants. The capture reads from the network layer
+// up, so frames are written as raw IP.
+const (
+	pcapMagic     = 0xa1b2c3d4 // microsecond timestamps
+	pcapSnapLen   = 65535
+	linkTypeRaw   = 101 // LINKTYPE_RAW: IPv4 or IPv6, no link header
+	ipProtoTCP    = 6
+	ipProtoUDP    = 17
+	maxFilterPort = 48 // beyond this the kernel filter takes all TCP/UDP
+)
+
+// writePcapHeader writes the pcap global header.
+func writePcapHeader(w io.Writer) error {
+	var h [24]byte
+	binary.LittleEndian.PutUint32(h[0:], pcapMagic)
+	binary.LittleEndian.PutUint16(h[4:], 2)
+	binary.LittleEndian.PutUint16(h[6:], 4)
+	binary.LittleEndian.PutUint32(h[16:], pcapSnapLen)
+	binary.LittleEndian.PutUint32(h[20:], linkTypeRaw)
+	_, err := w.Write(h[:])
+	return err
+}
+
+// writePcapRecord writes one captured packet.
+func writePcapRecord(w io.Writer, ts time.Time, pkt []byte) error {
+	var h [16]byte
+	binary.LittleEndian.PutUint32(h[0:], uint32(ts.Unix()))
+	binary.LittleEndian.PutUint32(h[4:], uint32(ts.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(h[8:], uint32(len(pkt)))
+	binary.LittleEndian.PutUint32(h[12:], uint32(len(pkt)))
+	if _, err := w.Write(h[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(pkt)
+	return err
+}
+
+// captureFlow is a socket a capture follows. A zero remote (listening or
+// unconnected UDP sockets) matches any peer, a zero local IP any address.
+type captureFlow struct {
+	proto         uint8
+	local, remote [16]byte
+	lport, rport  uint16
+}
+
+var zeroIP [16]byte
+
+// protoNumber maps a protocol to its IP protocol number.
+func protoNumber(p model.Protocol) uint8 {
+	if p == model.ProtoUDP {
+		return ipProtoUDP
+	}
+	return ipProtoTCP
+}
+
+// normIP returns ip as 16 bytes (IPv4 as ::ffff:a.b.c.d), unspecified
+// addresses as zero.
+func normIP(ip net.IP) [16]byte {
+	var b [16]byte
+	if ip16 := ip.To16(); ip16 != nil && !ip.IsUnspecified() {
+		copy(b[:], ip16)
+	}
+	return b
+}
+
+// processFlows returns the sockets of process pid in snap, and false if the
+// process isn't in it.
+func processFlows(snap model.Snapshot, pid uint32) ([]captureFlow, bool) {
+	for i := range snap.Processes {
+		p := &snap.Processes[i]
+		if p.PID != pid {
+			continue
+		}
+		flows := make([]captureFlow, 0, len(p.Connections)+len(p.ListenPorts))
+		for _, c := range p.Connections {
+			f := captureFlow{proto: protoNumber(c.Proto), local: normIP(c.SrcIP), lport: c.SrcPort}
+			if c.DstPort != 0 {
+				f.remote, f.rport = normIP(c.DstIP), c.DstPort
+			}
+			flows = append(flows, f)
+		}
+		for _, l := range p.ListenPorts {
+			flows = append(flows, captureFlow{proto: protoNumber(l.Proto), local: normIP(l.IP), lport: l.Port})
+		}
+		return flows, true
+	}
+	return nil, false
+}
+
+// flowPorts returns the distinct local ports of flows, sorted.
+func flowPorts(flows []captureFlow) []uint16 {
+	seen := make(map[uint16]bool)
+	var ports []uint16
+	for _, f := range flows {
+		if !seen[f.lport] {
+			seen[f.lport] = true
+			ports = append(ports, f.lport)
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+	return ports
+}
+
+// matchSide reports whether a packet from src to dst is on flow f's local
+// side (local → remote).
+func (f captureFlow) matchSide(proto uint8, src [16]byte, sport uint16, dst [16]byte, dport uint16) bool {
+	if f.proto != proto || f.lport != sport {
+		return false
+	}
+	if f.local != zeroIP && f.local != src {
+		return false
+	}
+	if f.rport == 0 {
+		return true
+	}
+	return f.rport == dport && (f.remote == zeroIP || f.remote == dst)
+}
+
+// matchFlows reports whether a packet belongs to one of flows, either way.
+func matchFlows(flows []captureFlow, proto uint8, src [16]byte, sport uint16, dst [16]byte, dport uint16) bool {
+	for _, f := range flows {
+		if f.matchSide(proto, src, sport, dst, dport) || f.matchSide(proto, dst, dport, src, sport) {
+			return true
+		}
+	}
+	return false
+}
+
+// bpfAsm assembles a classic BPF program with symbolic jump targets.
+type bpfAsm struct {
+	ins    []bpf.Instruction
+	labels map[string]int
+	jumps  []bpfJump
+}
+
+type bpfJump struct {
+	at          int
+	true, false string // "" = next instruction
+}
+
+func (a *bpfAsm) op(i bpf.Instruction) { a.ins = append(a.ins, i) }
+
+func (a *bpfAsm) label(name string) { a.labels[name] = len(a.ins) }
+
+// jumpIf compares A with val, going to t if true and f if false.
+func (a *bpfAsm) jumpIf(cond bpf.JumpTest, val uint32, t, f string) {
+	a.jumps = append(a.jumps, bpfJump{at: len(a.ins), true: t, false: f})
+	a.op(bpf.JumpIf{Cond: cond, Val: val})
+}
+
+func (a *bpfAsm) jump(to string) {
+	a.jumps = append(a.jumps, bpfJump{at: len(a.ins), true: to})
+	a.op(bpf.Jump{})
+}
+
+func (a *bpfAsm) assemble() ([]bpf.Instruction, error) {
+	skip := func(from int, to string) (int, error) {
+		if to == "" {
+			return 0, nil
+		}
+		at, ok := a.labels[to]
+		if !ok {
+			return 0, fmt.Errorf("bpf: undefined label %q", to)
+		}
+		return at - from - 1, nil
+	}
+	for _, j := range a.jumps {
+		t, err := skip(j.at, j.true)
+		if err != nil {
+			return nil, err
+		}
+		switch ins := a.ins[j.at].(type) {
+		case bpf.Jump:
+			ins.Skip = uint32(t)
+			a.ins[j.at] = ins
+		case bpf.JumpIf:
+			f, err := skip(j.at, j.false)
+			if err != nil {
+				return nil, err
+			}
+			if t > 255 || f > 255 {
+				return nil, fmt.Errorf("bpf: jump too far")
+			}
+			ins.SkipTrue, ins.SkipFalse = uint8(t), uint8(f)
+			a.ins[j.at] = ins
+		}
+	}
+	return a.ins, nil
+}
+
+// buildCaptureFilter returns a socket filter for raw IP packets (the
+// network header at offset 0) that passes TCP and UDP to or from any of
+// ports. Without ports, or with too many for one program, it passes all
+// TCP and UDP and leaves the rest to userspace.
+func buildCaptureFilter(ports []uint16) ([]bpf.Instruction, error) {
+	if len(ports) > maxFilterPort {
+		ports = nil
+	}
+	a := &bpfAsm{labels: make(map[string]int)}
+	matchPorts := func() {
+		if len(ports) == 0 {
+			a.jump("accept")
+			return
+		}
+		for i, p := range ports {
+			f := ""
+			if i == len(ports)-1 {
+				f = "drop"
+			}
+			a.jumpIf(bpf.JumpEqual, uint32(p), "accept", f)
+		}
+	}
+
+	a.op(bpf.LoadAbsolute{Off: 0, Size: 1})
+	a.op(bpf.ALUOpConstant{Op: bpf.ALUOpShiftRight, Val: 4})
+	a.jumpIf(bpf.JumpEqual, 4, "", "ipv6")
+
+	// IPv4: TCP/UDP, first fragment, ports after the variable header
+	a.op(bpf.LoadAbsolute{Off: 9, Size: 1})
+	a.jumpIf(bpf.JumpEqual, ipProtoTCP, "ipv4-ports", "")
+	a.jumpIf(bpf.JumpEqual, ipProtoUDP, "", "drop")
+	a.label("ipv4-ports")
+	a.op(bpf.LoadAbsolute{Off: 6, Size: 2})
+	a.jumpIf(bpf.JumpBitsSet, 0x1fff, "drop", "")
+	a.op(bpf.LoadMemShift{Off: 0})
+	a.op(bpf.LoadIndirect{Off: 0, Size: 2})
+	if len(ports) > 0 {
+		for _, p := range ports {
+			a.jumpIf(bpf.JumpEqual, uint32(p), "accept", "")
+		}
+		a.op(bpf.LoadIndirect{Off: 2, Size: 2})
+	}
+	matchPorts()
+
+	// IPv6 without extension headers
+	a.label("ipv6")
+	a.jumpIf(bpf.JumpEqual, 6, "", "drop")
+	a.op(bpf.LoadAbsolute{Off: 6, Size: 1})
+	a.jumpIf(bpf.JumpEqual, ipProtoTCP, "ipv6-ports", "")
+	a.jumpIf(bpf.JumpEqual, ipProtoUDP, "", "drop")
+	a.label("ipv6-ports")
+	a.op(bpf.LoadAbsolute{Off: 40, Size: 2})
+	if len(ports) > 0 {
+		for _, p := range ports {
+			a.jumpIf(bpf.JumpEqual, uint32(p), "accept", "")
+		}
+		a.op(bpf.LoadAbsolute{Off: 42, Size: 2})
+	}
+	matchPorts()
+
+	a.label("accept")
+	a.op(bpf.RetConstant{Val: pcapSnapLen})
+	a.label("drop")
+	a.op(bpf.RetConstant{Val: 0})
+	return a.assemble()
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package platform
+
+import (
+	"errors"
+	"runtime"
+)
+
+// StartCapture starts capturing the traffic of process pid to a pcap file.
+// Only Linux is supported.
+func StartCapture(path string, pid uint32) (PacketCapture, error) {
+	return nil, errors.New("packet capture is not supported on " + runtime.GOOS)
+}
@@ -0,0 +1,162 @@
+package platform
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+	"golang.org/x/net/bpf"
+)
+
+// testIPv4 builds an IPv4 packet with a 4-byte transport header.
+func testIPv4(proto uint8, src, dst string, sport, dport uint16, ihl int) []byte {
+	pkt := make([]byte, ihl*4+8)
+	pkt[0] = 0x40 | byte(ihl)
+	binary.BigEndian.PutUint16(pkt[2:], uint16(len(pkt)))
+	pkt[9] = proto
+	copy(pkt[12:], net.ParseIP(src).To4())
+	copy(pkt[16:], net.ParseIP(dst).To4())
+	binary.BigEndian.PutUint16(pkt[ihl*4:], sport)
+	binary.BigEndian.PutUint16(pkt[ihl*4+2:], dport)
+	return pkt
+}
+
+func testIPv6(proto uint8, src, dst string, sport, dport uint16) []byte {
+	pkt := make([]byte, 48)
+	pkt[0] = 0x60
+	binary.BigEndian.PutUint16(pkt[4:], 8)
+	pkt[6] = proto
+	copy(pkt[8:], net.ParseIP(src).To16())
+	copy(pkt[24:], net.ParseIP(dst).To16())
+	binary.BigEndian.PutUint16(pkt[40:], sport)
+	binary.BigEndian.PutUint16(pkt[42:], dport)
+	return pkt
+}
+
+func TestCaptureFilter(t *testing.T) {
+	prog, err := buildCaptureFilter([]uint16{443, 5353})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm, err := bpf.NewVM(prog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fragment := testIPv4(ipProtoTCP, "10.0.0.1", "10.0.0.2", 443, 50000, 5)
+	binary.BigEndian.PutUint16(fragment[6:], 0x00b9) // offset != 0
+
+	tests := []struct {
+		name string
+		pkt  []byte
+		want bool
+	}{
+		{"v4 tcp src port", testIPv4(ipProtoTCP, "10.0.0.1", "10.0.0.2", 443, 50000, 5), true},
+		{"v4 tcp dst port", testIPv4(ipProtoTCP, "10.0.0.2", "10.0.0.1", 50000, 443, 5), true},
+		{"v4 udp", testIPv4(ipProtoUDP, "10.0.0.1", "224.0.0.251", 5353, 5353, 5), true},
+		{"v4 options", testIPv4(ipProtoTCP, "10.0.0.1", "10.0.0.2", 50000, 443, 7), true},
+		{"v4 other port", testIPv4(ipProtoTCP, "10.0.0.1", "10.0.0.2", 50000, 80, 5), false},
+		{"v4 icmp", testIPv4(1, "10.0.0.1", "10.0.0.2", 443, 443, 5), false},
+		{"v4 fragment", fragment, false},
+		{"v6 tcp", testIPv6(ipProtoTCP, "2001:db8::1", "2001:db8::2", 50000, 443), true},
+		{"v6 other port", testIPv6(ipProtoUDP, "2001:db8::1", "2001:db8::2", 50000, 53), false},
+		{"not ip", []byte{0x00, 0x01, 0x02}, false},
+	}
+	for _, tt := range tests {
+		n, err := vm.Run(tt.pkt)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := n > 0; got != tt.want {
+			t.Errorf("%s: accepted = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Too many ports to list: all TCP and UDP pass
+	many := make([]uint16, maxFilterPort+1)
+	for i := range many {
+		many[i] = uint16(1000 + i)
+	}
+	prog, err = buildCaptureFilter(many)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm, _ = bpf.NewVM(prog)
+	if n, _ := vm.Run(testIPv4(ipProtoTCP, "10.0.0.1", "10.0.0.2", 1, 2, 5)); n == 0 {
+		t.Error("with too many ports, TCP was dropped")
+	}
+	if n, _ := vm.Run(testIPv4(1, "10.0.0.1", "10.0.0.2", 1, 2, 5)); n != 0 {
+		t.Error("with too many ports, ICMP passed")
+	}
+}
+
+func TestProcessFlows(t *testing.T) {
+	snap := model.Snapshot{Processes: []model.ProcessSummary{{
+		PID: 7,
+		Connections: []model.Connection{
+			{Proto: model.ProtoTCP, SrcIP: net.ParseIP("10.0.0.1"), SrcPort: 50000, DstIP: net.ParseIP("93.184.216.34"), DstPort: 443},
+		},
+		ListenPorts: []model.ListenPort{{Proto: model.ProtoUDP, IP: net.IPv4zero, Port: 5353}},
+	}}}
+	if _, ok := processFlows(snap, 8); ok {
+		t.Fatal("found flows for a missing process")
+	}
+	flows, ok := processFlows(snap, 7)
+	if !ok || len(flows) != 2 {
+		t.Fatalf("flows = %+v", flows)
+	}
+	if ports := flowPorts(flows); len(ports) != 2 || ports[0] != 5353 || ports[1] != 50000 {
+		t.Errorf("ports = %v", ports)
+	}
+
+	ip := func(s string) [16]byte { return normIP(net.ParseIP(s)) }
+	tests := []struct {
+		name         string
+		proto        uint8
+		src, dst     string
+		sport, dport uint16
+		want         bool
+	}{
+		{"outgoing", ipProtoTCP, "10.0.0.1", "93.184.216.34", 50000, 443, true},
+		{"reply", ipProtoTCP, "93.184.216.34", "10.0.0.1", 443, 50000, true},
+		{"other peer", ipProtoTCP, "10.0.0.1", "93.184.216.35", 50000, 443, false},
+		{"other proto", ipProtoUDP, "10.0.0.1", "93.184.216.34", 50000, 443, false},
+		{"listener, any peer", ipProtoUDP, "192.168.1.9", "10.0.0.1", 5353, 5353, true},
+		{"listener reply", ipProtoUDP, "10.0.0.1", "192.168.1.9", 5353, 40000, true},
+		{"unrelated", ipProtoTCP, "10.0.0.1", "10.0.0.2", 40000, 22, false},
+	}
+	for _, tt := range tests {
+		if got := matchFlows(flows, tt.proto, ip(tt.src), tt.sport, ip(tt.dst), tt.dport); got != tt.want {
+			t.Errorf("%s: match = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPcapWriter(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writePcapHeader(&buf); err != nil {
+		t.Fatal(err)
+	}
+	pkt := testIPv4(ipProtoTCP, "10.0.0.1", "10.0.0.2", 1, 2, 5)
+	ts := time.Unix(1700000000, 123456000)
+	if err := writePcapRecord(&buf, ts, pkt); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	if len(b) != 24+16+len(pkt) {
+		t.Fatalf("file is %d bytes", len(b))
+	}
+	le := binary.LittleEndian
+	if le.Uint32(b[0:]) != 0xa1b2c3d4 || le.Uint16(b[4:]) != 2 || le.Uint16(b[6:]) != 4 || le.Uint32(b[20:]) != 101 {
+		t.Errorf("header = % x", b[:24])
+	}
+	rec := b[24:]
+	if le.Uint32(rec[0:]) != 1700000000 || le.Uint32(rec[4:]) != 123456 || le.Uint32(rec[8:]) != uint32(len(pkt)) {
+		t.Errorf("record header = % x", rec[:16])
+	}
+	if !bytes.Equal(rec[16:], pkt) {
+		t.Error("packet bytes differ")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"os"
 	"os/exec"
 	"time"
 
@@ -62,7 +63,9 @@ func (p *DarwinPlatform) Collect() ([]MappedSocket, []model.InterfaceStats, erro
 		lsofMap[key] = e
 	}
 
-	// 4. Match netstat sockets with lsof entries
+	// 4. Match netstat sockets with lsof entries. lsof only lists other
+	// users' processes to root.
+	privileged := os.Geteuid() == 0
 	var mapped []MappedSocket
 	for _, ns := range allNetstat {
 		ms := MappedSocket{
@@ -86,6 +89,8 @@ func (p *DarwinPlatform) Collect() ([]MappedSocket, []model.InterfaceStats, erro
 		if e, ok := lsofMap[key]; ok {
 			ms.PID = e.pid
 			ms.ProcessName = e.command
+		} else {
+			ms.Unmapped = unmappedReason(&ms.Socket, privileged)
 		}
 
 		mapped = append(mapped, ms)
@@ -96,6 +101,7 @@ func (p *DarwinPlatform) Collect() ([]MappedSocket, []model.InterfaceStats, erro
 	if err != nil {
 		ifaces = nil
 	}
+	fillInterfaceAddrs(ifaces)
 
 	return mapped, ifaces, nil
 }
@@ -422,13 +422,21 @@ func parseNetstatInterfaces(output string) []model.InterfaceStats {
 		}
 		seen[name] = true
 
+		ipkts, _ := strconv.ParseUint(fields[4], 10, 64)
+		ierrs, _ := strconv.ParseUint(fields[5], 10, 64)
 		ibytes, _ := strconv.ParseUint(fields[6], 10, 64)
+		opkts, _ := strconv.ParseUint(fields[7], 10, 64)
+		oerrs, _ := strconv.ParseUint(fields[8], 10, 64)
 		obytes, _ := strconv.ParseUint(fields[9], 10, 64)
 
 		result = append(result, model.InterfaceStats{
-			Name:      name,
-			BytesRecv: ibytes,
-			BytesSent: obytes,
+			Name:        name,
+			BytesRecv:   ibytes,
+			BytesSent:   obytes,
+			PacketsRecv: ipkts,
+			PacketsSent: opkts,
+			ErrorsRecv:  ierrs,
+			ErrorsSent:  oerrs,
 		})
 	}
 
@@ -1,6 +1,10 @@
 package platform
 
-import "net"
+import (
+	"net"
+
+	"github.com/googlesky/sstop/internal/model"
+)
 
 // DetectDefaultInterface returns the name of the interface used for the default route.
 // Falls back to the first non-loopback interface with a valid IP.
@@ -70,3 +74,34 @@ func fallbackInterface() string {
 	}
 	return ""
 }
+
+// fillInterfaceAddrs sets the addresses of each interface in ifaces, for
+// telling which interface a connection's local address is on.
+func fillInterfaceAddrs(ifaces []model.InterfaceStats) {
+	if len(ifaces) == 0 {
+		return
+	}
+	byName := make(map[string]*model.InterfaceStats, len(ifaces))
+	for i := range ifaces {
+		byName[ifaces[i].Name] = &ifaces[i]
+	}
+	nics, err := net.Interfaces()
+	if err != nil {
+		return
+	}
+	for _, nic := range nics {
+		s, ok := byName[nic.Name]
+		if !ok {
+			continue
+		}
+		addrs, err := nic.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok {
+				s.Addrs = append(s.Addrs, ipnet.IP)
+			}
+		}
+	}
+}
@@ -8,8 +8,10 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"os"
 	"os/exec"
 	"syscall"
+	"time"
 	"unsafe"
 
 	"github.com/googlesky/sstop/internal/model"
@@ -82,6 +84,11 @@ type LinuxPlatform struct {
 	// pcap tracks per-connection bytes via AF_PACKET when inet_diag is unavailable.
 	// nil when using netlink (not needed) or when AF_PACKET is not available.
 	pcap *packetCounter
+
+	// namespaces enables collection from other network namespaces, each
+	// through its own netlink connection keyed by namespace inode.
+	namespaces bool
+	netns      map[uint64]*netnsConn
 }
 
 // NewPlatform creates a new Linux platform collector.
@@ -157,6 +164,17 @@ func probeNetlinkDiag(conn *netlink.Conn) error {
 	return err
 }
 
+// ProbeSockDiag reports whether the kernel answers INET_DIAG queries, without
+// trying to load modules (sstop doctor).
+func ProbeSockDiag() error {
+	conn, err := netlink.Dial(4, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return probeNetlinkDiag(conn)
+}
+
 // isNetlinkModuleError returns true if the error indicates that the kernel
 // module for sock_diag is not available (ENOENT = "no such file or directory").
 func isNetlinkModuleError(err error) bool {
@@ -173,6 +191,7 @@ func isNetlinkModuleError(err error) bool {
 }
 
 func (p *LinuxPlatform) Close() error {
+	p.closeNamespaces()
 	if p.pcap != nil {
 		p.pcap.close()
 	}
@@ -189,7 +208,7 @@ func (p *LinuxPlatform) Collect() ([]MappedSocket, []model.InterfaceStats, error
 	if p.useProc {
 		sockets, err = querySocketsFromProc()
 	} else {
-		sockets, err = p.queryAllSockets()
+		sockets, err = queryAllSockets(p.conn)
 		// If netlink fails at runtime (e.g. module unloaded), try /proc fallback
 		if err != nil && isNetlinkModuleError(err) {
 			log.Printf("sstop: netlink query failed at runtime, falling back to /proc + AF_PACKET: %v", err)
@@ -207,6 +226,9 @@ func (p *LinuxPlatform) Collect() ([]MappedSocket, []model.InterfaceStats, error
 	if err != nil {
 		return nil, nil, fmt.Errorf("query sockets: %w", err)
 	}
+	if p.namespaces && !p.useProc {
+		sockets = append(sockets, p.queryNamespaces()...)
+	}
 
 	// 2. Scan /proc for inode->PID mapping
 	inodeMap, err := ScanProcesses()
@@ -221,12 +243,18 @@ func (p *LinuxPlatform) Collect() ([]MappedSocket, []model.InterfaceStats, error
 		activeFlows = make(map[flowKey]bool)
 	}
 
+	privileged := os.Geteuid() == 0
 	for i := range sockets {
 		ms := MappedSocket{Socket: sockets[i]}
 		if info, ok := inodeMap[sockets[i].Inode]; ok {
 			ms.PID = info.PID
 			ms.ProcessName = info.Name
 			ms.Cmdline = info.Cmdline
+		} else if sockets[i].Inode == 0 {
+			// The kernel drops the inode when the process closes the socket
+			ms.Unmapped = model.UnattributedNoOwner
+		} else {
+			ms.Unmapped = unmappedReason(&ms.Socket, privileged)
 		}
 
 		// Fill byte counters from packet capture when inet_diag is unavailable
@@ -262,16 +290,17 @@ func (p *LinuxPlatform) Collect() ([]MappedSocket, []model.InterfaceStats, error
 		// Non-fatal; return sockets without interface stats
 		ifaces = nil
 	}
+	fillInterfaceAddrs(ifaces)
 
 	return mapped, ifaces, nil
 }
 
-func (p *LinuxPlatform) queryAllSockets() ([]model.Socket, error) {
+func queryAllSockets(conn *netlink.Conn) ([]model.Socket, error) {
 	var all []model.Socket
 
 	// Query TCP (IPv4 + IPv6)
 	for _, af := range []uint8{afINET, afINET6} {
-		socks, err := p.querySockets(af, ipprotoTCP, model.ProtoTCP)
+		socks, err := querySockets(conn, af, ipprotoTCP, model.ProtoTCP)
 		if err != nil {
 			return nil, fmt.Errorf("query TCP af=%d: %w", af, err)
 		}
@@ -280,7 +309,7 @@ func (p *LinuxPlatform) queryAllSockets() ([]model.Socket, error) {
 
 	// Query UDP (IPv4 + IPv6)
 	for _, af := range []uint8{afINET, afINET6} {
-		socks, err := p.querySockets(af, ipprotoUDP, model.ProtoUDP)
+		socks, err := querySockets(conn, af, ipprotoUDP, model.ProtoUDP)
 		if err != nil {
 			// UDP query may fail on some kernels, non-fatal
 			continue
@@ -291,7 +320,7 @@ func (p *LinuxPlatform) queryAllSockets() ([]model.Socket, error) {
 	return all, nil
 }
 
-func (p *LinuxPlatform) querySockets(family, protocol uint8, proto model.Protocol) ([]model.Socket, error) {
+func querySockets(conn *netlink.Conn, family, protocol uint8, proto model.Protocol) ([]model.Socket, error) {
 	req := inetDiagReqV2{
 		Family:   family,
 		Protocol: protocol,
@@ -311,7 +340,7 @@ func (p *LinuxPlatform) querySockets(family, protocol uint8, proto model.Protoco
 		Data: reqBytes,
 	}
 
-	msgs, err := p.conn.Execute(msg)
+	msgs, err := conn.Execute(msg)
 	if err != nil {
 		return nil, err
 	}
@@ -373,19 +402,43 @@ func parseTCPInfoFromAttrs(data []byte, s *model.Socket) {
 
 	for _, attr := range attrs {
 		if int(attr.Type) == inetDiagInfo {
-			// This is struct tcp_info
-			// bytes_acked at offset 120 (uint64)
-			// bytes_received at offset 128 (uint64)
-			tcpInfoData := attr.Data
-			if len(tcpInfoData) >= 136 {
-				s.BytesSent = binary.LittleEndian.Uint64(tcpInfoData[120:128])
-				s.BytesRecv = binary.LittleEndian.Uint64(tcpInfoData[128:136])
-			}
+			parseTCPInfo(attr.Data, s)
 			break
 		}
 	}
 }
 
+// parseTCPInfo extracts byte counters and connection-quality fields from a
+// struct tcp_info. Older kernels send a shorter struct; fields past the end
+// are left zero.
+func parseTCPInfo(b []byte, s *model.Socket) {
+	// tcpi_rtt at 68, tcpi_rttvar at 72, tcpi_snd_cwnd at 80 (uint32, usec/segments)
+	// tcpi_total_retrans at 100 (uint32)
+	if len(b) < 104 {
+		return
+	}
+	info := &model.TCPInfo{
+		RTT:     time.Duration(binary.LittleEndian.Uint32(b[68:72])) * time.Microsecond,
+		RTTVar:  time.Duration(binary.LittleEndian.Uint32(b[72:76])) * time.Microsecond,
+		Cwnd:    binary.LittleEndian.Uint32(b[80:84]),
+		Retrans: binary.LittleEndian.Uint32(b[100:104]),
+	}
+
+	// bytes_acked at offset 120 (uint64)
+	// bytes_received at offset 128 (uint64)
+	if len(b) >= 136 {
+		s.BytesSent = binary.LittleEndian.Uint64(b[120:128])
+		s.BytesRecv = binary.LittleEndian.Uint64(b[128:136])
+	}
+
+	// tcpi_delivery_rate at 160 (uint64, bytes/sec; Linux 4.9+)
+	if len(b) >= 168 {
+		info.DeliveryRate = float64(binary.LittleEndian.Uint64(b[160:168]))
+	}
+
+	s.TCP = info
+}
+
 // mapTCPState maps kernel TCP state values to our SocketState.
 func mapTCPState(kernelState uint8) model.SocketState {
 	// Kernel TCP states match our enum values 1:1 for 1-11
@@ -0,0 +1,237 @@
+//go:build linux
+
+package platform
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+	"golang.org/x/net/bpf"
+)
+
+const packetOutgoing = 4 // PACKET_OUTGOING
+
+// packetCapture writes the packets of one process's sockets to a pcap
+// file. An AF_PACKET socket with a port filter does the coarse cut in the
+// kernel; exact 5-tuple matching happens here.
+type packetCapture struct {
+	fd       int
+	pid      uint32
+	path     string
+	file     *os.File
+	loopback map[int]bool // ifindexes; their outgoing copies are skipped
+
+	mu      sync.Mutex
+	w       *bufio.Writer
+	flows   []captureFlow
+	ports   []uint16
+	packets int
+	err     error // first write error
+	closed  bool
+
+	stopCh    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// StartCapture starts capturing the traffic of process pid to a new pcap
+// file at path. It follows no connections until the first Write. Needs
+// root or CAP_NET_RAW.
+func StartCapture(path string, pid uint32) (PacketCapture, error) {
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_DGRAM, int(htons(syscall.ETH_P_ALL)))
+	if err != nil {
+		return nil, fmt.Errorf("packet capture needs root or CAP_NET_RAW: %w", err)
+	}
+	// Nothing passes until the first snapshot names some ports
+	if err := attachFilter(fd, []bpf.Instruction{bpf.RetConstant{Val: 0}}); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_RCVBUF, 4*1024*1024)
+	tv := syscall.Timeval{Sec: 0, Usec: 200_000} // so captureLoop sees stopCh
+	syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv)
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	c := &packetCapture{
+		fd:       fd,
+		pid:      pid,
+		path:     path,
+		file:     f,
+		loopback: make(map[int]bool),
+		w:        bufio.NewWriter(f),
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if ifaces, err := net.Interfaces(); err == nil {
+		for _, iface := range ifaces {
+			if iface.Flags&net.FlagLoopback != 0 {
+				c.loopback[iface.Index] = true
+			}
+		}
+	}
+	if err := writePcapHeader(c.w); err != nil {
+		syscall.Close(fd)
+		f.Close()
+		return nil, err
+	}
+	go c.captureLoop()
+	return c, nil
+}
+
+func (c *packetCapture) PID() uint32  { return c.pid }
+func (c *packetCapture) Path() string { return c.path }
+
+func (c *packetCapture) Packets() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.packets
+}
+
+// Write follows the process's sockets in snap, re-filtering in the kernel
+// when its ports change, and flushes what was captured so far. A process
+// missing from snap keeps its last sockets: late packets of a closing
+// connection still land in the file.
+func (c *packetCapture) Write(snap model.Snapshot) error {
+	flows, ok := processFlows(snap, c.pid)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	if ok {
+		c.flows = flows
+		if ports := flowPorts(flows); !slices.Equal(ports, c.ports) {
+			prog, err := buildCaptureFilter(ports)
+			if err == nil {
+				err = attachFilter(c.fd, prog)
+			}
+			if err != nil {
+				return fmt.Errorf("capture filter: %w", err)
+			}
+			c.ports = ports
+		}
+	}
+	if c.err != nil {
+		return c.err
+	}
+	return c.w.Flush()
+}
+
+// Close stops the capture and closes the file.
+func (c *packetCapture) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.stopCh)
+		<-c.done
+		syscall.Close(c.fd)
+		c.mu.Lock()
+		c.closed = true
+		err = c.w.Flush()
+		c.mu.Unlock()
+		if cerr := c.file.Close(); err == nil {
+			err = cerr
+		}
+	})
+	return err
+}
+
+func (c *packetCapture) captureLoop() {
+	defer close(c.done)
+	buf := make([]byte, pcapSnapLen)
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		default:
+		}
+		n, from, err := syscall.Recvfrom(c.fd, buf, 0)
+		if err != nil || n < 1 {
+			continue // timeout: check stopCh
+		}
+		if ll, ok := from.(*syscall.SockaddrLinklayer); ok && ll.Pkttype == packetOutgoing && c.loopback[ll.Ifindex] {
+			continue // loopback packets are seen leaving and arriving
+		}
+		c.handlePacket(time.Now(), buf[:n])
+	}
+}
+
+func (c *packetCapture) handlePacket(ts time.Time, pkt []byte) {
+	proto, src, dst, sport, dport, ok := parseTuple(pkt)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil || !matchFlows(c.flows, proto, src, sport, dst, dport) {
+		return
+	}
+	if err := writePcapRecord(c.w, ts, pkt); err != nil {
+		c.err = err
+		return
+	}
+	c.packets++
+}
+
+// attachFilter replaces the socket's filter with prog.
+func attachFilter(fd int, prog []bpf.Instruction) error {
+	raw, err := bpf.Assemble(prog)
+	if err != nil {
+		return err
+	}
+	filter := make([]syscall.SockFilter, len(raw))
+	for i, ins := range raw {
+		filter[i] = syscall.SockFilter{Code: ins.Op, Jt: ins.Jt, Jf: ins.Jf, K: ins.K}
+	}
+	return syscall.AttachLsf(fd, filter)
+}
+
+// parseTuple extracts the transport 5-tuple of a raw IP packet. ok is false
+// for anything but TCP or UDP.
+func parseTuple(pkt []byte) (proto uint8, src, dst [16]byte, sport, dport uint16, ok bool) {
+	if len(pkt) < 1 {
+		return
+	}
+	var off int
+	switch pkt[0] >> 4 {
+	case 4:
+		if len(pkt) < 20 {
+			return
+		}
+		off = int(pkt[0]&0x0f) * 4
+		if binary.BigEndian.Uint16(pkt[6:8])&0x1fff != 0 {
+			return // a later fragment: no transport header
+		}
+		proto = pkt[9]
+		src[10], src[11] = 0xff, 0xff
+		copy(src[12:], pkt[12:16])
+		dst[10], dst[11] = 0xff, 0xff
+		copy(dst[12:], pkt[16:20])
+	case 6:
+		if len(pkt) < 40 {
+			return
+		}
+		copy(src[:], pkt[8:24])
+		copy(dst[:], pkt[24:40])
+		proto, off = walkIPv6ExtHeaders(pkt, pkt[6], 40)
+	default:
+		return
+	}
+	if (proto != ipProtoTCP && proto != ipProtoUDP) || len(pkt) < off+4 {
+		return
+	}
+	sport = binary.BigEndian.Uint16(pkt[off:])
+	dport = binary.BigEndian.Uint16(pkt[off+2:])
+	return proto, src, dst, sport, dport, true
+}
@@ -0,0 +1,47 @@
+//go:build linux
+
+package platform
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestParseTuple(t *testing.T) {
+	proto, src, dst, sport, dport, ok := parseTuple(testIPv4(ipProtoUDP, "10.0.0.1", "10.0.0.2", 53, 40000, 6))
+	if !ok || proto != ipProtoUDP || sport != 53 || dport != 40000 ||
+		src != normIP(net.ParseIP("10.0.0.1")) || dst != normIP(net.ParseIP("10.0.0.2")) {
+		t.Errorf("v4 = %d %v %v %d %d %v", proto, src, dst, sport, dport, ok)
+	}
+	proto, src, _, sport, _, ok = parseTuple(testIPv6(ipProtoTCP, "2001:db8::1", "2001:db8::2", 443, 50000))
+	if !ok || proto != ipProtoTCP || sport != 443 || src != normIP(net.ParseIP("2001:db8::1")) {
+		t.Errorf("v6 = %d %v %d %v", proto, src, sport, ok)
+	}
+	if _, _, _, _, _, ok := parseTuple(testIPv4(1, "10.0.0.1", "10.0.0.2", 0, 0, 5)); ok {
+		t.Error("ICMP parsed as TCP/UDP")
+	}
+}
+
+func TestCaptureHandlePacket(t *testing.T) {
+	var buf bytes.Buffer
+	c := &packetCapture{pid: 7, w: bufio.NewWriter(&buf)}
+	c.flows, _ = processFlows(model.Snapshot{Processes: []model.ProcessSummary{{
+		PID: 7,
+		Connections: []model.Connection{
+			{Proto: model.ProtoTCP, SrcIP: net.ParseIP("10.0.0.1"), SrcPort: 50000, DstIP: net.ParseIP("10.0.0.2"), DstPort: 443},
+		},
+	}}}, 7)
+
+	now := time.Now()
+	c.handlePacket(now, testIPv4(ipProtoTCP, "10.0.0.2", "10.0.0.1", 443, 50000, 5))
+	c.handlePacket(now, testIPv4(ipProtoTCP, "10.0.0.2", "10.0.0.1", 443, 50001, 5)) // another process
+	c.w.Flush()
+	if c.Packets() != 1 || buf.Len() != 16+28 {
+		t.Errorf("packets = %d, %d bytes written", c.Packets(), buf.Len())
+	}
+}
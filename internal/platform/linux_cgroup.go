@@ -12,6 +12,12 @@ import (
 type CgroupInfo struct {
 	ContainerID string // Docker/Podman container short ID (12 chars)
 	ServiceName string // systemd service name (e.g. "nginx.service")
+	DesktopApp  bool   // started by the desktop session (systemd app-*.scope, app.slice)
+
+	// Kubernetes pods: the pod UID and full CRI container ID, from which
+	// the collector resolves the pod name and namespace
+	PodUID          string
+	KubeContainerID string
 }
 
 // ReadCgroup reads /proc/<pid>/cgroup and extracts container/service info.
@@ -39,83 +45,199 @@ func parseCgroup(content string) CgroupInfo {
 		if len(parts) < 3 {
 			continue
 		}
-		cgPath := parts[2]
+		segs := cgroupSegments(parts[2])
 
-		// Docker: path contains /docker/<container-id>
-		if info.ContainerID == "" {
-			if id := extractDockerID(cgPath); id != "" {
-				info.ContainerID = id
+		// Kubernetes: kubepods slice or hierarchy with a pod<uid> level
+		if info.PodUID == "" {
+			if uid, id := extractKubePod(segs); uid != "" {
+				info.PodUID = uid
+				info.KubeContainerID = id
 			}
 		}
 
-		// Podman: path contains /libpod-<container-id>
+		// Docker, Podman, containerd, CRI-O: the innermost container, so
+		// a container nested in another (DinD, a pod's sidecar running
+		// its own runtime) is grouped as itself
 		if info.ContainerID == "" {
-			if id := extractPodmanID(cgPath); id != "" {
-				info.ContainerID = id
+			if id := extractContainerID(segs); id != "" {
+				info.ContainerID = shortID(id)
+			} else if info.KubeContainerID != "" {
+				info.ContainerID = shortID(info.KubeContainerID)
 			}
 		}
 
 		// Systemd service: path contains /<name>.service
 		if info.ServiceName == "" {
-			if svc := extractSystemdService(cgPath); svc != "" {
+			if svc := extractSystemdService(segs); svc != "" {
 				info.ServiceName = svc
 			}
 		}
+		info.DesktopApp = info.DesktopApp || isDesktopApp(segs)
 	}
 
 	return info
 }
 
-// extractDockerID extracts short container ID from docker cgroup paths.
-// Handles:
-//   - /docker/<id>
-//   - /docker-<id>.scope
-//   - /system.slice/docker-<id>.scope
-func extractDockerID(cgPath string) string {
-	// /docker/<full-id>
-	if idx := strings.Index(cgPath, "/docker/"); idx >= 0 {
-		id := cgPath[idx+len("/docker/"):]
-		// Strip anything after the id (sub-paths)
-		if slashIdx := strings.Index(id, "/"); slashIdx >= 0 {
-			id = id[:slashIdx]
+// cgroupSegments splits a cgroup path into its components. Paths outside
+// the reader's cgroup namespace (sstop in a container watching the host's
+// processes) start with "/.." steps up to the common ancestor; those are
+// dropped, leaving the names that identify the cgroup.
+func cgroupSegments(cgPath string) []string {
+	var segs []string
+	for _, seg := range strings.Split(cgPath, "/") {
+		if seg != "" && seg != ".." && seg != "." {
+			segs = append(segs, seg)
 		}
-		return shortID(id)
 	}
+	return segs
+}
 
-	// docker-<full-id>.scope
-	for _, seg := range strings.Split(cgPath, "/") {
-		if strings.HasPrefix(seg, "docker-") && strings.HasSuffix(seg, ".scope") {
-			id := strings.TrimPrefix(seg, "docker-")
-			id = strings.TrimSuffix(id, ".scope")
-			return shortID(id)
+// containerScopePrefixes name a container's cgroup under the systemd
+// driver: "<prefix><id>.scope".
+var containerScopePrefixes = []string{"docker-", "cri-containerd-", "crio-", "libpod-", "nerdctl-"}
+
+// extractContainerID returns the full ID of the innermost container in a
+// cgroup path. Handles:
+//   - systemd driver: docker-<id>.scope, cri-containerd-<id>.scope,
+//     crio-<id>.scope, libpod-<id>.scope, nerdctl-<id>.scope
+//   - cgroupfs driver: /docker/<id>, and a bare 64-hex ID under any parent
+//     (/moby/<id>, containerd's /<namespace>/<id>, /kubepods/.../<id>)
+//
+// Nested runtimes stack these (/docker/<outer>/docker/<inner>); the last
+// one is the container the process runs in.
+func extractContainerID(segs []string) string {
+	for i := len(segs) - 1; i >= 0; i-- {
+		parent := ""
+		if i > 0 {
+			parent = segs[i-1]
+		}
+		if id := containerSegmentID(parent, segs[i]); id != "" {
+			return id
 		}
 	}
-
 	return ""
 }
 
-// extractPodmanID extracts short container ID from podman cgroup paths.
-// Handles: /libpod-<id>
-func extractPodmanID(cgPath string) string {
-	for _, seg := range strings.Split(cgPath, "/") {
-		if strings.HasPrefix(seg, "libpod-") {
-			id := strings.TrimPrefix(seg, "libpod-")
-			// May have suffix like .scope
-			if dotIdx := strings.Index(id, "."); dotIdx >= 0 {
-				id = id[:dotIdx]
+// containerSegmentID returns the container ID a cgroup path segment names,
+// "" if it doesn't name one.
+func containerSegmentID(parent, seg string) string {
+	for _, prefix := range containerScopePrefixes {
+		if !strings.HasPrefix(seg, prefix) {
+			continue
+		}
+		id := strings.TrimPrefix(seg, prefix)
+		if strings.HasPrefix(id, "conmon-") {
+			return "" // CRI-O's and Podman's monitor process
+		}
+		if dot := strings.Index(id, "."); dot >= 0 {
+			if id[dot:] != ".scope" {
+				return "" // e.g. docker-<x>.service
 			}
-			return shortID(id)
+			id = id[:dot]
+		} else if prefix != "libpod-" {
+			continue // a slice or service that merely starts alike
 		}
+		return id
+	}
+	if parent == "docker" || isContainerID(seg) {
+		return seg
 	}
 	return ""
 }
 
+// isContainerID reports whether s is a full container ID: 64 hex digits,
+// as Docker, containerd and CRI-O all use.
+func isContainerID(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// extractKubePod extracts the pod UID and container ID from kubelet cgroup
+// paths. Handles:
+//   - systemd driver: /kubepods.slice/kubepods-burstable.slice/
+//     kubepods-burstable-pod<uid_with_underscores>.slice/cri-containerd-<id>.scope
+//     (also crio-<id>.scope and docker-<id>.scope)
+//   - cgroupfs driver: /kubepods/burstable/pod<uid>/<id>
+//
+// A path relative to a cgroup namespace may have lost the kubepods levels
+// (/../pod<uid>/<id>); a pod<uid> level still counts when the UID is a
+// well-formed UUID. The container ID is empty for the pod-level cgroup
+// itself, and is the pod's container even when another runtime nests
+// containers inside it.
+func extractKubePod(segs []string) (podUID, containerID string) {
+	kube := false
+	for _, seg := range segs {
+		kube = kube || strings.Contains(seg, "kubepods")
+	}
+	for i, seg := range segs {
+		switch {
+		case strings.HasSuffix(seg, ".slice") && strings.HasPrefix(seg, "kubepods"):
+			idx := strings.LastIndex(seg, "-pod")
+			if idx < 0 {
+				continue
+			}
+			uid := strings.TrimSuffix(seg[idx+len("-pod"):], ".slice")
+			podUID = strings.ReplaceAll(uid, "_", "-")
+		case strings.HasPrefix(seg, "pod") && (kube || isUUID(seg[len("pod"):])):
+			podUID = strings.TrimPrefix(seg, "pod")
+		default:
+			continue
+		}
+		if i+1 < len(segs) {
+			containerID = criContainerID(segs[i+1])
+		}
+		return podUID, containerID
+	}
+	return "", ""
+}
+
+// isUUID reports whether s has the 8-4-4-4-12 hex digit form of a pod UID.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, r := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdef", r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// criContainerID strips the runtime prefix and .scope suffix from a
+// container cgroup name ("cri-containerd-<id>.scope", "crio-<id>.scope",
+// "docker-<id>.scope" or a bare "<id>").
+func criContainerID(seg string) string {
+	seg = strings.TrimSuffix(seg, ".scope")
+	for _, prefix := range []string{"cri-containerd-", "crio-", "docker-"} {
+		seg = strings.TrimPrefix(seg, prefix)
+	}
+	if strings.HasPrefix(seg, "conmon-") {
+		return "" // CRI-O's monitor process, not the container
+	}
+	return seg
+}
+
 // extractSystemdService extracts service name from systemd cgroup paths.
 // Handles:
 //   - /system.slice/nginx.service
 //   - /user.slice/user-1000.slice/...
-func extractSystemdService(cgPath string) string {
-	for _, seg := range strings.Split(cgPath, "/") {
+func extractSystemdService(segs []string) string {
+	for _, seg := range segs {
 		if strings.HasSuffix(seg, ".service") {
 			// Skip docker-*.service as those are container entries
 			if strings.HasPrefix(seg, "docker-") {
@@ -127,6 +249,24 @@ func extractSystemdService(cgPath string) string {
 	return ""
 }
 
+// isDesktopApp reports whether a cgroup path is an application launched
+// by a systemd-managed desktop session, which puts each in its own unit
+// under app.slice:
+//   - /user.slice/user-1000.slice/user@1000.service/app.slice/app-gnome-firefox-4242.scope
+//   - .../app.slice/app-flatpak-org.mozilla.firefox-1234.scope
+//   - .../app.slice/app-org.kde.konsole@a1b2.service
+func isDesktopApp(segs []string) bool {
+	for _, seg := range segs {
+		if seg == "app.slice" {
+			return true
+		}
+		if strings.HasPrefix(seg, "app-") && (strings.HasSuffix(seg, ".scope") || strings.HasSuffix(seg, ".service")) {
+			return true
+		}
+	}
+	return false
+}
+
 // shortID returns first 12 chars of a container ID (standard short format).
 func shortID(id string) string {
 	if len(id) > 12 {
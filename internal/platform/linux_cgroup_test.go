@@ -82,6 +82,24 @@ func TestParseCgroup_UserScope(t *testing.T) {
 	}
 }
 
+func TestParseCgroup_DesktopApp(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/user.slice/user-1000.slice/user@1000.service/app.slice/app-gnome-firefox-4242.scope", true},
+		{"/user.slice/user-1000.slice/user@1000.service/app.slice/app-org.kde.konsole@a1b2.service", true},
+		{"/user.slice/user-1000.slice/user@1000.service/session.slice/pipewire.service", false},
+		{"/user.slice/user-1000.slice/session-1.scope", false},
+		{"/system.slice/nginx.service", false},
+	}
+	for _, tt := range tests {
+		if got := parseCgroup("0::" + tt.path).DesktopApp; got != tt.want {
+			t.Errorf("%s: DesktopApp = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
 func TestParseCgroup_EmptyContent(t *testing.T) {
 	info := parseCgroup("")
 	if info.ContainerID != "" {
@@ -127,69 +145,157 @@ func TestShortID(t *testing.T) {
 	}
 }
 
-func TestExtractDockerID(t *testing.T) {
+func TestExtractContainerID(t *testing.T) {
+	const outer = "1111111111111111111111111111111111111111111111111111111111111111"
+	const inner = "2222222222222222222222222222222222222222222222222222222222222222"
 	tests := []struct {
 		name   string
 		cgPath string
 		want   string
 	}{
-		{"docker path", "/docker/abc123def456789", "abc123def456"},
-		{"docker path with subpath", "/docker/abc123def456789/subpath", "abc123def456"},
-		{"docker scope", "/system.slice/docker-abc123def456789.scope", "abc123def456"},
-		{"no docker", "/system.slice/nginx.service", ""},
+		{"docker path", "/docker/abc123def456789", "abc123def456789"},
+		{"docker path with subpath", "/docker/abc123def456789/subpath", "abc123def456789"},
+		{"docker scope", "/system.slice/docker-abc123def456789.scope", "abc123def456789"},
+		{"libpod scope", "/machine.slice/libpod-xyz789abc123.scope", "xyz789abc123"},
+		{"libpod no suffix", "/machine.slice/libpod-xyz789abc123def456", "xyz789abc123def456"},
+		{"podman conmon", "/machine.slice/libpod-conmon-xyz789abc123.scope", ""},
+		{"containerd scope", "/system.slice/cri-containerd-" + outer + ".scope", outer},
+		{"nerdctl scope", "/system.slice/nerdctl-" + outer + ".scope", outer},
+		{"containerd namespace", "/k8s.io/" + outer, outer},
+		{"dind", "/docker/" + outer + "/docker/" + inner, inner},
+		{"dind under systemd", "/system.slice/docker-" + outer + ".scope/docker/" + inner, inner},
+		{"dind with moby", "/system.slice/docker-" + outer + ".scope/moby/" + inner, inner},
+		{"cgroup namespace relative", "/../../system.slice/docker-" + outer + ".scope", outer},
+		{"docker service", "/system.slice/docker-abc.service", ""},
+		{"no container", "/system.slice/nginx.service", ""},
 		{"empty", "", ""},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := extractDockerID(tt.cgPath)
+			got := extractContainerID(cgroupSegments(tt.cgPath))
 			if got != tt.want {
-				t.Errorf("extractDockerID(%q) = %q, want %q", tt.cgPath, got, tt.want)
+				t.Errorf("extractContainerID(%q) = %q, want %q", tt.cgPath, got, tt.want)
 			}
 		})
 	}
 }
 
-func TestExtractPodmanID(t *testing.T) {
+func TestExtractSystemdService(t *testing.T) {
 	tests := []struct {
 		name   string
 		cgPath string
 		want   string
 	}{
-		{"libpod scope", "/machine.slice/libpod-xyz789abc123.scope", "xyz789abc123"},
-		{"libpod no suffix", "/machine.slice/libpod-xyz789abc123def456", "xyz789abc123"},
-		{"no podman", "/system.slice/nginx.service", ""},
+		{"nginx service", "/system.slice/nginx.service", "nginx.service"},
+		{"docker service skipped", "/system.slice/docker-abc.service", ""},
+		{"no service", "/user.slice/user-1000.slice/session-1.scope", ""},
 		{"empty", "", ""},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := extractPodmanID(tt.cgPath)
+			got := extractSystemdService(cgroupSegments(tt.cgPath))
 			if got != tt.want {
-				t.Errorf("extractPodmanID(%q) = %q, want %q", tt.cgPath, got, tt.want)
+				t.Errorf("extractSystemdService(%q) = %q, want %q", tt.cgPath, got, tt.want)
 			}
 		})
 	}
 }
 
-func TestExtractSystemdService(t *testing.T) {
+func TestExtractKubePod(t *testing.T) {
 	tests := []struct {
-		name   string
-		cgPath string
-		want   string
+		name      string
+		cgPath    string
+		wantUID   string
+		wantCtrID string
 	}{
-		{"nginx service", "/system.slice/nginx.service", "nginx.service"},
-		{"docker service skipped", "/system.slice/docker-abc.service", ""},
-		{"no service", "/user.slice/user-1000.slice/session-1.scope", ""},
-		{"empty", "", ""},
+		{
+			"systemd containerd",
+			"/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod0f3a_12b4_9c.slice/cri-containerd-abc123def4567890.scope",
+			"0f3a-12b4-9c", "abc123def4567890",
+		},
+		{
+			"systemd guaranteed crio",
+			"/kubepods.slice/kubepods-pod77aa_bb.slice/crio-feed1234beef5678.scope",
+			"77aa-bb", "feed1234beef5678",
+		},
+		{
+			"crio conmon",
+			"/kubepods.slice/kubepods-pod77aa_bb.slice/crio-conmon-feed1234.scope",
+			"77aa-bb", "",
+		},
+		{
+			"cgroupfs",
+			"/kubepods/besteffort/pod1e2d-3c4b/0123456789abcdef",
+			"1e2d-3c4b", "0123456789abcdef",
+		},
+		{"pod level", "/kubepods/burstable/pod1e2d-3c4b", "1e2d-3c4b", ""},
+		{"qos slice only", "/kubepods.slice/kubepods-burstable.slice", "", ""},
+		{"not kubernetes", "/system.slice/docker-abc.scope", "", ""},
+		{
+			"cgroup namespace relative",
+			"/../../kubepods-besteffort-pod77aa_bb.slice/cri-containerd-feed1234.scope",
+			"77aa-bb", "feed1234",
+		},
+		{
+			"relative cgroupfs, kubepods level gone",
+			"/../pod0f3a12b4-9c8d-4e5f-a6b7-c8d9e0f1a2b3/feed1234",
+			"0f3a12b4-9c8d-4e5f-a6b7-c8d9e0f1a2b3", "feed1234",
+		},
+		{"pod-like name outside kubernetes", "/system.slice/podman.service", "", ""},
+		{
+			"sidecar running its own containers",
+			"/kubepods/burstable/pod1e2d-3c4b/0123456789abcdef/docker/feed1234",
+			"1e2d-3c4b", "0123456789abcdef",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := extractSystemdService(tt.cgPath)
-			if got != tt.want {
-				t.Errorf("extractSystemdService(%q) = %q, want %q", tt.cgPath, got, tt.want)
+			uid, id := extractKubePod(cgroupSegments(tt.cgPath))
+			if uid != tt.wantUID || id != tt.wantCtrID {
+				t.Errorf("extractKubePod(%q) = %q, %q, want %q, %q", tt.cgPath, uid, id, tt.wantUID, tt.wantCtrID)
 			}
 		})
 	}
 }
+
+func TestParseCgroup_KubernetesPod(t *testing.T) {
+	content := "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod0f3a_12b4.slice/cri-containerd-abc123def4567890.scope"
+	info := parseCgroup(content)
+
+	if info.PodUID != "0f3a-12b4" {
+		t.Errorf("PodUID = %q, want %q", info.PodUID, "0f3a-12b4")
+	}
+	if info.KubeContainerID != "abc123def4567890" {
+		t.Errorf("KubeContainerID = %q, want full ID", info.KubeContainerID)
+	}
+	if info.ContainerID != "abc123def456" {
+		t.Errorf("ContainerID = %q, want %q", info.ContainerID, "abc123def456")
+	}
+}
+
+func TestParseCgroup_Nested(t *testing.T) {
+	const outer = "1111111111111111111111111111111111111111111111111111111111111111"
+	const inner = "2222222222222222222222222222222222222222222222222222222222222222"
+
+	// Docker-in-Docker: the inner container, not the DinD host container
+	info := parseCgroup("0::/system.slice/docker-" + outer + ".scope/docker/" + inner)
+	if info.ContainerID != "222222222222" || info.ServiceName != "" {
+		t.Errorf("dind: %+v, want the inner container", info)
+	}
+
+	// A pod's container running its own runtime: the pod is the outer
+	// one, the container the inner one
+	info = parseCgroup("0::/kubepods.slice/kubepods-pod77aa_bb.slice/cri-containerd-" + outer + ".scope/docker/" + inner)
+	if info.PodUID != "77aa-bb" || info.KubeContainerID != outer || info.ContainerID != "222222222222" {
+		t.Errorf("nested in pod: %+v", info)
+	}
+
+	// cgroup v1 line ordering doesn't matter, nor does a namespace-relative path
+	info = parseCgroup("12:pids:/../../docker/" + outer + "\n0::/../../docker/" + outer + "\n")
+	if info.ContainerID != "111111111111" {
+		t.Errorf("relative: %+v", info)
+	}
+}
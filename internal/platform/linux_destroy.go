@@ -0,0 +1,82 @@
+//go:build linux
+
+package platform
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"unsafe"
+
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/mdlayher/netlink"
+)
+
+const (
+	sockDestroy      = 21         // SOCK_DESTROY
+	inetDiagNoCookie = 0xFFFFFFFF // INET_DIAG_NOCOOKIE: look the socket up by address
+)
+
+// DestroySocket closes one of process pid's sockets with SOCK_DESTROY, as
+// "ss -K" does: the process sees ECONNABORTED and keeps running. The
+// kernel finds the socket by its addresses, in the process's network
+// namespace (sstop's own for model.UnattributedPID); it needs
+// CAP_NET_ADMIN and CONFIG_INET_DIAG_DESTROY.
+func (p *LinuxPlatform) DestroySocket(pid uint32, c model.Connection) error {
+	ns := fmt.Sprintf("/proc/%d/ns/net", pid)
+	if pid == model.UnattributedPID {
+		ns = "/proc/self/ns/net"
+	}
+	conn, err := dialNetNS(ns)
+	if err != nil {
+		return fmt.Errorf("open sock_diag: %w", err)
+	}
+	defer conn.Close()
+
+	req := destroyRequest(c)
+	_, err = conn.Execute(netlink.Message{
+		Header: netlink.Header{
+			Type:  sockDestroy,
+			Flags: netlink.Request | netlink.Acknowledge,
+		},
+		Data: (*[unsafe.Sizeof(req)]byte)(unsafe.Pointer(&req))[:],
+	})
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, syscall.EPERM), errors.Is(err, syscall.EACCES):
+		return errors.New("permission denied: closing another process's socket needs root or CAP_NET_ADMIN")
+	case errors.Is(err, syscall.EOPNOTSUPP):
+		return errors.New("the kernel can't close sockets (built without CONFIG_INET_DIAG_DESTROY)")
+	case errors.Is(err, syscall.ENOENT):
+		return errors.New("socket not found: already closed")
+	}
+	return err
+}
+
+// destroyRequest builds the sock_diag request naming c. Addresses are
+// 4 bytes long for AF_INET sockets and 16 for AF_INET6 ones (IPv4-mapped
+// included), as parseDiagMsg reads them.
+func destroyRequest(c model.Connection) inetDiagReqV2 {
+	req := inetDiagReqV2{
+		Family:   afINET6,
+		Protocol: ipprotoTCP,
+		States:   allTCPStates,
+	}
+	if c.Proto == model.ProtoUDP {
+		req.Protocol = ipprotoUDP
+	}
+	src, dst := c.SrcIP.To16(), c.DstIP.To16()
+	if len(c.SrcIP) == net.IPv4len {
+		req.Family = afINET
+		src, dst = c.SrcIP, c.DstIP.To4()
+	}
+	binary.BigEndian.PutUint16(req.ID.SPort[:], c.SrcPort)
+	binary.BigEndian.PutUint16(req.ID.DPort[:], c.DstPort)
+	copy(req.ID.Src[:], src)
+	copy(req.ID.Dst[:], dst)
+	req.ID.Cookie = [2]uint32{inetDiagNoCookie, inetDiagNoCookie}
+	return req
+}
@@ -0,0 +1,164 @@
+//go:build linux
+
+package platform
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/mdlayher/netlink"
+)
+
+// netnsRef is a network namespace found on the system.
+type netnsRef struct {
+	path  string // file to open to enter it
+	named string // "ip netns" name, if any
+	pid   uint32 // a process inside it, when not named
+}
+
+// netnsConn is a SOCK_DIAG connection opened inside another network
+// namespace. The socket stays in that namespace for its lifetime.
+type netnsConn struct {
+	name string
+	conn *netlink.Conn // nil when the namespace couldn't be entered
+}
+
+// SetNamespaces turns on collection from the other network namespaces on
+// the host (containers, "ip netns"). Needs root and netlink INET_DIAG.
+func (p *LinuxPlatform) SetNamespaces(on bool) {
+	p.namespaces = on
+	if on && p.useProc {
+		log.Printf("sstop: network namespaces need netlink INET_DIAG; only the current namespace is monitored")
+	}
+	if !on {
+		p.closeNamespaces()
+	}
+}
+
+func (p *LinuxPlatform) closeNamespaces() {
+	for ino, nc := range p.netns {
+		if nc.conn != nil {
+			nc.conn.Close()
+		}
+		delete(p.netns, ino)
+	}
+}
+
+// queryNamespaces returns the sockets of every other network namespace,
+// labelled with its name. Connections are kept across polls and closed
+// when their namespace disappears.
+func (p *LinuxPlatform) queryNamespaces() []model.Socket {
+	refs := listNetNamespaces("/proc", "/run/netns")
+	if p.netns == nil {
+		p.netns = make(map[uint64]*netnsConn)
+	}
+	for ino, nc := range p.netns {
+		if _, ok := refs[ino]; !ok {
+			if nc.conn != nil {
+				nc.conn.Close()
+			}
+			delete(p.netns, ino)
+		}
+	}
+
+	var all []model.Socket
+	for ino, ref := range refs {
+		nc, ok := p.netns[ino]
+		if !ok {
+			// A failure is remembered too, so it isn't retried every poll
+			nc = &netnsConn{name: netnsLabel(ino, ref)}
+			nc.conn, _ = dialNetNS(ref.path)
+			p.netns[ino] = nc
+		}
+		if nc.conn == nil {
+			continue
+		}
+		socks, err := queryAllSockets(nc.conn)
+		if err != nil {
+			continue
+		}
+		for i := range socks {
+			socks[i].NetNS = nc.name
+		}
+		all = append(all, socks...)
+	}
+	return all
+}
+
+// dialNetNS opens a SOCK_DIAG netlink connection inside the namespace at path.
+func dialNetNS(path string) (*netlink.Conn, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	// NETLINK_SOCK_DIAG = 4
+	return netlink.Dial(4, &netlink.Config{NetNS: int(f.Fd())})
+}
+
+// listNetNamespaces finds the network namespaces other than our own, by
+// inode: those named under runDir ("ip netns add") and those processes
+// under procDir run in (containers).
+func listNetNamespaces(procDir, runDir string) map[uint64]netnsRef {
+	self, ok := nsInode(filepath.Join(procDir, "self", "ns", "net"))
+	if !ok {
+		return nil
+	}
+	refs := make(map[uint64]netnsRef)
+
+	if entries, err := os.ReadDir(runDir); err == nil {
+		for _, e := range entries {
+			path := filepath.Join(runDir, e.Name())
+			if ino, ok := nsInode(path); ok && ino != self {
+				refs[ino] = netnsRef{path: path, named: e.Name()}
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return refs
+	}
+	for _, e := range entries {
+		pid, err := strconv.ParseUint(e.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(procDir, e.Name(), "ns", "net")
+		ino, ok := nsInode(path)
+		if !ok || ino == self {
+			continue
+		}
+		if _, seen := refs[ino]; !seen {
+			refs[ino] = netnsRef{path: path, pid: uint32(pid)}
+		}
+	}
+	return refs
+}
+
+func nsInode(path string) (uint64, bool) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return 0, false
+	}
+	return st.Ino, true
+}
+
+// netnsLabel names a namespace: its "ip netns" name, else the container
+// its processes run in, else the kernel's net:[inode].
+func netnsLabel(ino uint64, ref netnsRef) string {
+	if ref.named != "" {
+		return ref.named
+	}
+	if ref.pid != 0 {
+		if id := ReadCgroup(ref.pid).ContainerID; id != "" {
+			return id
+		}
+	}
+	return fmt.Sprintf("net:[%d]", ino)
+}
@@ -0,0 +1,55 @@
+//go:build linux
+
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListNetNamespaces(t *testing.T) {
+	root := t.TempDir()
+	procDir := filepath.Join(root, "proc")
+	runDir := filepath.Join(root, "netns")
+	mk := func(path string) string {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	// Namespaces are files whose inode identifies them; hard links share one
+	link := func(target, path string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Link(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	self := mk(filepath.Join(procDir, "self", "ns", "net"))
+	link(self, filepath.Join(procDir, "1", "ns", "net"))
+	container := mk(filepath.Join(procDir, "400", "ns", "net"))
+	link(container, filepath.Join(procDir, "401", "ns", "net"))
+	blue := mk(filepath.Join(runDir, "blue"))
+	link(blue, filepath.Join(procDir, "500", "ns", "net"))
+
+	refs := listNetNamespaces(procDir, runDir)
+	if len(refs) != 2 {
+		t.Fatalf("got %d namespaces, want 2 (own skipped, links deduped): %+v", len(refs), refs)
+	}
+	containerIno, _ := nsInode(container)
+	if ref := refs[containerIno]; ref.pid != 400 || ref.named != "" {
+		t.Errorf("container namespace = %+v, want pid 400", ref)
+	}
+	blueIno, _ := nsInode(blue)
+	if ref := refs[blueIno]; ref.named != "blue" || netnsLabel(blueIno, ref) != "blue" {
+		t.Errorf("ip netns namespace = %+v, want named blue", ref)
+	}
+	if got := netnsLabel(7, netnsRef{}); got != "net:[7]" {
+		t.Errorf("unnamed label = %q", got)
+	}
+}
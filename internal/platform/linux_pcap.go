@@ -9,8 +9,14 @@ import (
 	"sync"
 	"syscall"
 	"unsafe"
+
+	"github.com/googlesky/sstop/internal/version"
 )
 
+func init() {
+	version.RegisterBackend("pcap")
+}
+
 // packetCounter uses AF_PACKET raw sockets to track per-flow byte counters.
 // This is the fallback for systems without the inet_diag kernel module,
 // where /proc/net/tcp has no per-socket byte counters.
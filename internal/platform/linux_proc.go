@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/googlesky/sstop/internal/model"
 )
@@ -104,24 +106,31 @@ func readProcessInfo(pid uint32) (name, cmdline string) {
 	return
 }
 
-// ReadPPID reads the parent PID of a process from /proc/<pid>/stat.
-func ReadPPID(pid uint32) uint32 {
+// readStatFields returns the fields of /proc/<pid>/stat after the command
+// name: state, ppid, pgrp, session, tty_nr, ... nil if unreadable.
+func readStatFields(pid uint32) []string {
 	pidStr := strconv.FormatUint(uint64(pid), 10)
 	data, err := os.ReadFile(filepath.Join("/proc", pidStr, "stat"))
 	if err != nil {
-		return 0
+		return nil
 	}
+	return parseStatFields(string(data))
+}
 
+// parseStatFields splits /proc/<pid>/stat content after the command name.
+func parseStatFields(s string) []string {
 	// /proc/<pid>/stat format: pid (comm) state ppid ...
 	// comm can contain spaces and parens, so find last ')' first
-	s := string(data)
 	lastParen := strings.LastIndex(s, ")")
 	if lastParen < 0 || lastParen+2 >= len(s) {
-		return 0
+		return nil
 	}
+	return strings.Fields(s[lastParen+2:])
+}
 
-	// After ") " comes: state ppid ...
-	fields := strings.Fields(s[lastParen+2:])
+// ReadPPID reads the parent PID of a process from /proc/<pid>/stat.
+func ReadPPID(pid uint32) uint32 {
+	fields := readStatFields(pid)
 	if len(fields) < 2 {
 		return 0
 	}
@@ -133,6 +142,113 @@ func ReadPPID(pid uint32) uint32 {
 	return uint32(ppid)
 }
 
+// clockTicks is USER_HZ, the unit of the times in /proc/<pid>/stat: 100
+// on every architecture Linux runs on.
+const clockTicks = 100
+
+var (
+	bootOnce sync.Once
+	bootTime time.Time
+)
+
+// ReadStartTime returns when a process started, from its start time after
+// boot in /proc/<pid>/stat. Zero if either can't be read.
+func ReadStartTime(pid uint32) time.Time {
+	fields := readStatFields(pid)
+	if len(fields) < 20 {
+		return time.Time{}
+	}
+	ticks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	bootOnce.Do(func() {
+		if data, err := os.ReadFile("/proc/stat"); err == nil {
+			bootTime = parseBootTime(string(data))
+		}
+	})
+	if bootTime.IsZero() {
+		return time.Time{}
+	}
+	return bootTime.Add(time.Duration(ticks) * (time.Second / clockTicks))
+}
+
+// parseBootTime returns the boot time from /proc/stat content's btime
+// line, zero if there is none.
+func parseBootTime(content string) time.Time {
+	for line := range strings.Lines(content) {
+		if v, ok := strings.CutPrefix(line, "btime "); ok {
+			secs, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return time.Time{}
+			}
+			return time.Unix(secs, 0)
+		}
+	}
+	return time.Time{}
+}
+
+// ReadTTY reports whether a process has a controlling terminal: it runs
+// in a shell, over SSH or in a terminal emulator.
+func ReadTTY(pid uint32) bool {
+	fields := readStatFields(pid)
+	return len(fields) > 4 && fields[4] != "0"
+}
+
+// ReadDisplayEnv reports whether a process's environment names an X11 or
+// Wayland display (DISPLAY, WAYLAND_DISPLAY). False when the environment
+// can't be read, which needs the same user or root.
+func ReadDisplayEnv(pid uint32) bool {
+	pidStr := strconv.FormatUint(uint64(pid), 10)
+	data, err := os.ReadFile(filepath.Join("/proc", pidStr, "environ"))
+	if err != nil {
+		return false
+	}
+	return hasDisplayEnv(data)
+}
+
+// hasDisplayEnv reports whether NUL-separated environ content sets
+// DISPLAY or WAYLAND_DISPLAY.
+func hasDisplayEnv(environ []byte) bool {
+	for _, kv := range bytes.Split(environ, []byte{0}) {
+		if k, v, ok := bytes.Cut(kv, []byte{'='}); ok && len(v) > 0 &&
+			(string(k) == "DISPLAY" || string(k) == "WAYLAND_DISPLAY") {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadUID reads the real UID of a process from /proc/<pid>/status.
+func ReadUID(pid uint32) (uint32, bool) {
+	pidStr := strconv.FormatUint(uint64(pid), 10)
+	data, err := os.ReadFile(filepath.Join("/proc", pidStr, "status"))
+	if err != nil {
+		return 0, false
+	}
+	return parseStatusUID(string(data))
+}
+
+// parseStatusUID extracts the real UID from /proc/<pid>/status content.
+// The line looks like "Uid:\t1000\t1000\t1000\t1000" (real, effective, saved, fs).
+func parseStatusUID(content string) (uint32, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(line, "Uid:") {
+			continue
+		}
+		fields := strings.Fields(line[len("Uid:"):])
+		if len(fields) == 0 {
+			return 0, false
+		}
+		uid, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			return 0, false
+		}
+		return uint32(uid), true
+	}
+	return 0, false
+}
+
 // ParseNetDev reads /proc/net/dev and returns interface stats.
 func ParseNetDev() ([]model.InterfaceStats, error) {
 	f, err := os.Open("/proc/net/dev")
@@ -159,7 +275,7 @@ func ParseNetDev() ([]model.InterfaceStats, error) {
 
 		ifaceName := strings.TrimSpace(line[:colonIdx])
 		fields := strings.Fields(line[colonIdx+1:])
-		if len(fields) < 10 {
+		if len(fields) < 12 {
 			continue
 		}
 
@@ -168,15 +284,39 @@ func ParseNetDev() ([]model.InterfaceStats, error) {
 			continue
 		}
 
-		recvBytes, _ := strconv.ParseUint(fields[0], 10, 64)
-		sentBytes, _ := strconv.ParseUint(fields[8], 10, 64)
+		// recv: bytes packets errs drop ...; sent from field 8 likewise
+		n := func(i int) uint64 {
+			v, _ := strconv.ParseUint(fields[i], 10, 64)
+			return v
+		}
 
 		result = append(result, model.InterfaceStats{
-			Name:      ifaceName,
-			BytesRecv: recvBytes,
-			BytesSent: sentBytes,
+			Name:        ifaceName,
+			BytesRecv:   n(0),
+			BytesSent:   n(8),
+			PacketsRecv: n(1),
+			PacketsSent: n(9),
+			ErrorsRecv:  n(2),
+			ErrorsSent:  n(10),
+			DropsRecv:   n(3),
+			DropsSent:   n(11),
+			Speed:       linkSpeed(ifaceName),
 		})
 	}
 
 	return result, scanner.Err()
 }
+
+// linkSpeed returns an interface's link speed in bits/sec from sysfs, 0
+// when the driver doesn't report one (virtual interfaces, link down).
+func linkSpeed(name string) uint64 {
+	data, err := os.ReadFile("/sys/class/net/" + name + "/speed")
+	if err != nil {
+		return 0
+	}
+	mbps, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil || mbps <= 0 {
+		return 0
+	}
+	return uint64(mbps) * 1_000_000
+}
@@ -0,0 +1,158 @@
+//go:build linux
+
+package platform
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestParseTCPInfo(t *testing.T) {
+	b := make([]byte, 232)                          // struct tcp_info on a recent kernel
+	binary.LittleEndian.PutUint32(b[68:], 38500)    // tcpi_rtt (usec)
+	binary.LittleEndian.PutUint32(b[72:], 1200)     // tcpi_rttvar (usec)
+	binary.LittleEndian.PutUint32(b[80:], 10)       // tcpi_snd_cwnd
+	binary.LittleEndian.PutUint32(b[100:], 7)       // tcpi_total_retrans
+	binary.LittleEndian.PutUint64(b[120:], 4096)    // tcpi_bytes_acked
+	binary.LittleEndian.PutUint64(b[128:], 8192)    // tcpi_bytes_received
+	binary.LittleEndian.PutUint64(b[160:], 1250000) // tcpi_delivery_rate
+
+	var s model.Socket
+	parseTCPInfo(b, &s)
+
+	if s.BytesSent != 4096 || s.BytesRecv != 8192 {
+		t.Errorf("bytes = %d/%d, want 4096/8192", s.BytesSent, s.BytesRecv)
+	}
+	if s.TCP == nil {
+		t.Fatal("expected TCP info")
+	}
+	want := model.TCPInfo{
+		RTT:          38500 * time.Microsecond,
+		RTTVar:       1200 * time.Microsecond,
+		Retrans:      7,
+		Cwnd:         10,
+		DeliveryRate: 1250000,
+	}
+	if *s.TCP != want {
+		t.Errorf("TCP info = %+v, want %+v", *s.TCP, want)
+	}
+}
+
+func TestParseTCPInfoOldKernel(t *testing.T) {
+	// Pre-4.9 struct without delivery rate
+	b := make([]byte, 136)
+	binary.LittleEndian.PutUint32(b[68:], 500)
+	binary.LittleEndian.PutUint64(b[120:], 1)
+
+	var s model.Socket
+	parseTCPInfo(b, &s)
+	if s.TCP == nil || s.TCP.RTT != 500*time.Microsecond || s.TCP.DeliveryRate != 0 {
+		t.Errorf("unexpected TCP info: %+v", s.TCP)
+	}
+	if s.BytesSent != 1 {
+		t.Errorf("BytesSent = %d, want 1", s.BytesSent)
+	}
+
+	// Truncated struct: nothing parsed
+	var short model.Socket
+	parseTCPInfo(make([]byte, 64), &short)
+	if short.TCP != nil {
+		t.Error("expected no TCP info from truncated struct")
+	}
+}
+
+func TestParseStatFields(t *testing.T) {
+	// A command name with spaces and parens; tty_nr 34816 is /dev/pts/0
+	fields := parseStatFields("4242 (my (odd) prog) S 4100 4242 4100 34816 4242 4194304")
+	if len(fields) < 5 || fields[1] != "4100" || fields[4] != "34816" {
+		t.Errorf("fields = %q", fields)
+	}
+	if parseStatFields("4242 (truncated") != nil {
+		t.Error("expected no fields without the closing paren")
+	}
+}
+
+func TestParseBootTime(t *testing.T) {
+	stat := "cpu  1 2 3 4\nintr 1 2\nctxt 99\nbtime 1736935200\nprocesses 42\n"
+	if got := parseBootTime(stat); !got.Equal(time.Unix(1736935200, 0)) {
+		t.Errorf("parseBootTime = %v", got)
+	}
+	if got := parseBootTime("cpu  1 2 3 4\n"); !got.IsZero() {
+		t.Errorf("no btime: got %v, want zero", got)
+	}
+}
+
+func TestReadStartTime(t *testing.T) {
+	self := ReadStartTime(uint32(os.Getpid()))
+	if self.IsZero() {
+		t.Skip("/proc not readable")
+	}
+	if self.After(time.Now()) || time.Since(self) > time.Hour {
+		t.Errorf("own start time = %v, want shortly before now", self)
+	}
+	if again := ReadStartTime(uint32(os.Getpid())); !again.Equal(self) {
+		t.Errorf("start time changed between reads: %v, then %v", self, again)
+	}
+}
+
+func TestHasDisplayEnv(t *testing.T) {
+	tests := []struct {
+		environ string
+		want    bool
+	}{
+		{"HOME=/home/me\x00DISPLAY=:0\x00", true},
+		{"WAYLAND_DISPLAY=wayland-0\x00LANG=C", true},
+		{"DISPLAY=\x00", false},
+		{"XDISPLAY=:0\x00MY_DISPLAY=:1", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := hasDisplayEnv([]byte(tt.environ)); got != tt.want {
+			t.Errorf("hasDisplayEnv(%q) = %v, want %v", tt.environ, got, tt.want)
+		}
+	}
+}
+
+func TestParseStatusUID(t *testing.T) {
+	status := "Name:\tnginx\nUmask:\t0022\nState:\tS (sleeping)\nTgid:\t812\nPid:\t812\nPPid:\t1\n" +
+		"Uid:\t33\t33\t33\t33\nGid:\t33\t33\t33\t33\n"
+	uid, ok := parseStatusUID(status)
+	if !ok || uid != 33 {
+		t.Errorf("parseStatusUID = %d, %v; want 33, true", uid, ok)
+	}
+
+	if _, ok := parseStatusUID("Name:\tx\n"); ok {
+		t.Error("expected no UID without a Uid: line")
+	}
+	if _, ok := parseStatusUID("Uid:\tabc\n"); ok {
+		t.Error("expected no UID from malformed line")
+	}
+}
+
+func TestDestroyRequest(t *testing.T) {
+	req := destroyRequest(model.Connection{Proto: model.ProtoTCP,
+		SrcIP: net.ParseIP("10.0.0.2").To4(), SrcPort: 40000, DstIP: net.ParseIP("192.0.2.1"), DstPort: 443})
+	if req.Family != afINET || req.Protocol != ipprotoTCP {
+		t.Errorf("family %d proto %d, want AF_INET TCP", req.Family, req.Protocol)
+	}
+	if binary.BigEndian.Uint16(req.ID.SPort[:]) != 40000 || binary.BigEndian.Uint16(req.ID.DPort[:]) != 443 {
+		t.Errorf("ports = %v/%v", req.ID.SPort, req.ID.DPort)
+	}
+	if !net.IP(req.ID.Src[:4]).Equal(net.ParseIP("10.0.0.2")) || !net.IP(req.ID.Dst[:4]).Equal(net.ParseIP("192.0.2.1")) {
+		t.Errorf("addresses = %v → %v, want IPv4 in the first 4 bytes", req.ID.Src, req.ID.Dst)
+	}
+	if req.ID.Cookie != [2]uint32{inetDiagNoCookie, inetDiagNoCookie} {
+		t.Errorf("cookie = %v, want none", req.ID.Cookie)
+	}
+
+	req = destroyRequest(model.Connection{Proto: model.ProtoUDP,
+		SrcIP: net.ParseIP("2001:db8::2"), SrcPort: 5353, DstIP: net.ParseIP("2001:db8::1"), DstPort: 53})
+	if req.Family != afINET6 || req.Protocol != ipprotoUDP || !net.IP(req.ID.Dst[:]).Equal(net.ParseIP("2001:db8::1")) {
+		t.Errorf("IPv6 UDP request = %+v", req)
+	}
+}
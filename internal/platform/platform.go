@@ -13,6 +13,7 @@ type MappedSocket struct {
 	PID         uint32
 	ProcessName string
 	Cmdline     string
+	Unmapped    string // with PID 0: why, a model.Unattributed* reason
 }
 
 // Platform abstracts OS-specific network data collection.
@@ -24,12 +25,25 @@ type Platform interface {
 	Close() error
 }
 
+// NamespaceCollector is implemented by platforms that can also collect
+// sockets from the host's other network namespaces (Linux).
+type NamespaceCollector interface {
+	SetNamespaces(on bool)
+}
+
+// SocketDestroyer is implemented by platforms that can close a single
+// socket of a running process (Linux).
+type SocketDestroyer interface {
+	DestroySocket(pid uint32, c model.Connection) error
+}
+
 // SocketKey uniquely identifies a socket for delta tracking across polls.
 // Cross-platform: does not use inode.
 type SocketKey struct {
 	Proto   model.Protocol
 	SrcAddr string // "ip:port"
 	DstAddr string // "ip:port"
+	NetNS   string // containers can reuse the same addresses
 }
 
 // MakeSocketKey builds a SocketKey from a MappedSocket.
@@ -38,6 +52,7 @@ func MakeSocketKey(s *MappedSocket) SocketKey {
 		Proto:   s.Proto,
 		SrcAddr: formatAddr(s.SrcIP, s.SrcPort),
 		DstAddr: formatAddr(s.DstIP, s.DstPort),
+		NetNS:   s.NetNS,
 	}
 }
 
@@ -47,3 +62,17 @@ func formatAddr(ip net.IP, port uint16) string {
 	}
 	return model.AddrPort(ip, port)
 }
+
+// unmappedReason guesses why no process holds s: sockets in TIME_WAIT are
+// no longer held by one, an unprivileged sstop can't see other users'
+// processes, and the rest belong to the kernel.
+func unmappedReason(s *model.Socket, privileged bool) string {
+	switch {
+	case s.State == model.StateTimeWait:
+		return model.UnattributedNoOwner
+	case !privileged:
+		return model.UnattributedHidden
+	default:
+		return model.UnattributedKernel
+	}
+}
@@ -0,0 +1,201 @@
+// Package platformtest provides a deterministic, in-memory platform.Platform
+// for tests. It needs no root privileges and no OS network APIs, so the
+// collector, UI and recorder can be exercised from scripted traffic.
+package platformtest
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/platform"
+)
+
+// ErrClosed is returned by Collect after Close.
+var ErrClosed = errors.New("platformtest: platform closed")
+
+// Step is what one Collect call returns.
+type Step struct {
+	Sockets    []platform.MappedSocket
+	Interfaces []model.InterfaceStats
+	Err        error
+}
+
+// FakePlatform replays a script of Steps, one per Collect call.
+// After the last step it keeps returning the last step (steady state), or
+// starts over when looping is enabled. It is safe for concurrent use.
+type FakePlatform struct {
+	mu     sync.Mutex
+	steps  []Step
+	pos    int
+	loop   bool
+	calls  int
+	closed bool
+}
+
+// New creates a FakePlatform that replays steps in order.
+func New(steps ...Step) *FakePlatform {
+	return &FakePlatform{steps: steps}
+}
+
+var _ platform.Platform = (*FakePlatform)(nil)
+
+// Append adds steps to the end of the script.
+func (f *FakePlatform) Append(steps ...Step) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.steps = append(f.steps, steps...)
+}
+
+// SetLoop makes the script restart from the first step after the last one.
+func (f *FakePlatform) SetLoop(loop bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.loop = loop
+}
+
+// Collect returns a copy of the current step and advances the script.
+// An empty script returns no sockets and no interfaces.
+func (f *FakePlatform) Collect() ([]platform.MappedSocket, []model.InterfaceStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil, nil, ErrClosed
+	}
+	f.calls++
+	if len(f.steps) == 0 {
+		return nil, nil, nil
+	}
+
+	step := f.steps[f.pos]
+	switch {
+	case f.pos < len(f.steps)-1:
+		f.pos++
+	case f.loop:
+		f.pos = 0
+	}
+
+	if step.Err != nil {
+		return nil, nil, step.Err
+	}
+	return copySockets(step.Sockets), copyInterfaces(step.Interfaces), nil
+}
+
+// Close marks the platform closed; later Collect calls return ErrClosed.
+func (f *FakePlatform) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+// Calls returns how many times Collect has been called (excluding calls after Close).
+func (f *FakePlatform) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+// Closed reports whether Close has been called.
+func (f *FakePlatform) Closed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+// Conn builds an established connection owned by pid. local and remote are
+// "ip:port" strings ("[v6]:port" for IPv6); sent and recv are the cumulative
+// byte counters. It panics on malformed addresses.
+func Conn(proto model.Protocol, pid uint32, name, local, remote string, sent, recv uint64) platform.MappedSocket {
+	srcIP, srcPort := mustAddr(local)
+	dstIP, dstPort := mustAddr(remote)
+	state := model.StateEstablished
+	if proto == model.ProtoUDP {
+		state = model.StateClose
+	}
+	return platform.MappedSocket{
+		Socket: model.Socket{
+			Proto:     proto,
+			SrcIP:     srcIP,
+			SrcPort:   srcPort,
+			DstIP:     dstIP,
+			DstPort:   dstPort,
+			State:     state,
+			BytesSent: sent,
+			BytesRecv: recv,
+		},
+		PID:         pid,
+		ProcessName: name,
+		Cmdline:     name,
+	}
+}
+
+// Listen builds a listening socket owned by pid on addr ("ip:port").
+func Listen(proto model.Protocol, pid uint32, name, addr string) platform.MappedSocket {
+	ip, port := mustAddr(addr)
+	return platform.MappedSocket{
+		Socket: model.Socket{
+			Proto:   proto,
+			SrcIP:   ip,
+			SrcPort: port,
+			DstIP:   net.IPv4(0, 0, 0, 0).To4(),
+			State:   model.StateListen,
+		},
+		PID:         pid,
+		ProcessName: name,
+		Cmdline:     name,
+	}
+}
+
+// Iface builds interface counters.
+func Iface(name string, recv, sent uint64) model.InterfaceStats {
+	return model.InterfaceStats{Name: name, BytesRecv: recv, BytesSent: sent}
+}
+
+func mustAddr(s string) (net.IP, uint16) {
+	host, portStr, err := net.SplitHostPort(s)
+	if err != nil {
+		panic(fmt.Sprintf("platformtest: bad address %q: %v", s, err))
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		panic(fmt.Sprintf("platformtest: bad IP in %q", s))
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		panic(fmt.Sprintf("platformtest: bad port in %q: %v", s, err))
+	}
+	return ip, uint16(port)
+}
+
+// copySockets copies sockets so callers can't mutate the script.
+func copySockets(in []platform.MappedSocket) []platform.MappedSocket {
+	if in == nil {
+		return nil
+	}
+	out := make([]platform.MappedSocket, len(in))
+	for i, s := range in {
+		s.SrcIP = append(net.IP(nil), s.SrcIP...)
+		s.DstIP = append(net.IP(nil), s.DstIP...)
+		if s.TCP != nil {
+			tcp := *s.TCP
+			s.TCP = &tcp
+		}
+		out[i] = s
+	}
+	return out
+}
+
+func copyInterfaces(in []model.InterfaceStats) []model.InterfaceStats {
+	if in == nil {
+		return nil
+	}
+	return append([]model.InterfaceStats(nil), in...)
+}
@@ -0,0 +1,82 @@
+package platformtest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/platform"
+)
+
+func TestFakePlatformScript(t *testing.T) {
+	errBoom := errors.New("boom")
+	f := New(
+		Step{Sockets: []platform.MappedSocket{Conn(model.ProtoTCP, 1, "curl", "10.0.0.1:5000", "10.0.0.2:443", 100, 200)}},
+		Step{Err: errBoom},
+		Step{Interfaces: []model.InterfaceStats{Iface("eth0", 1000, 500)}},
+	)
+
+	socks, _, err := f.Collect()
+	if err != nil || len(socks) != 1 || socks[0].BytesSent != 100 || socks[0].DstPort != 443 {
+		t.Fatalf("step 1: got %+v, %v", socks, err)
+	}
+	if _, _, err := f.Collect(); !errors.Is(err, errBoom) {
+		t.Fatalf("step 2: expected scripted error, got %v", err)
+	}
+
+	// Last step repeats
+	for i := 0; i < 2; i++ {
+		_, ifaces, err := f.Collect()
+		if err != nil || len(ifaces) != 1 || ifaces[0].Name != "eth0" {
+			t.Fatalf("step 3 (repeat %d): got %+v, %v", i, ifaces, err)
+		}
+	}
+	if f.Calls() != 4 {
+		t.Errorf("Calls() = %d, want 4", f.Calls())
+	}
+
+	f.Close()
+	if _, _, err := f.Collect(); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed after Close, got %v", err)
+	}
+	if !f.Closed() {
+		t.Error("Closed() = false after Close")
+	}
+}
+
+func TestFakePlatformLoop(t *testing.T) {
+	f := New(
+		Step{Sockets: []platform.MappedSocket{Listen(model.ProtoTCP, 1, "sshd", "0.0.0.0:22")}},
+		Step{},
+	)
+	f.SetLoop(true)
+
+	want := []int{1, 0, 1, 0}
+	for i, n := range want {
+		socks, _, _ := f.Collect()
+		if len(socks) != n {
+			t.Fatalf("call %d: got %d sockets, want %d", i, len(socks), n)
+		}
+		if n > 0 {
+			if socks[0].SrcPort != 22 || socks[0].SrcIP.String() != "0.0.0.0" {
+				t.Errorf("call %d: script was mutated: %+v", i, socks[0].Socket)
+			}
+			// Mutating the result must not change the script
+			socks[0].SrcIP[0] = 99
+			socks[0].SrcPort = 1
+		}
+	}
+}
+
+func TestConnAddresses(t *testing.T) {
+	s := Conn(model.ProtoUDP, 7, "dns", "[::1]:5353", "[2001:db8::1]:53", 0, 0)
+	if s.SrcIP.String() != "::1" || s.SrcPort != 5353 || s.DstPort != 53 {
+		t.Errorf("unexpected addresses: %+v", s.Socket)
+	}
+	if s.State != model.StateClose {
+		t.Errorf("UDP socket state = %v, want CLOSE", s.State)
+	}
+	if len(Conn(model.ProtoTCP, 1, "x", "10.0.0.1:1", "10.0.0.2:2", 0, 0).SrcIP) != 4 {
+		t.Error("IPv4 addresses should be 4-byte")
+	}
+}
@@ -0,0 +1,167 @@
+//go:build windows
+
+package platform
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// WindowsPlatform collects network data using the IP Helper API.
+//
+//   - GetExtendedTcpTable / GetExtendedUdpTable enumerate sockets with owning PIDs
+//   - GetPerTcpConnectionEStats provides per-connection byte counters
+//     (requires Administrator; counters stay zero otherwise)
+//   - GetIfTable2 provides interface byte counters
+//   - OpenProcess + QueryFullProcessImageName resolve process names
+type WindowsPlatform struct {
+	// estatsEnabled tracks connections for which ESTATS data collection has
+	// been switched on, so SetPerTcpConnectionEStats is called once per connection.
+	estatsEnabled map[SocketKey]bool
+
+	// estatsDenied is set after the first access-denied error, to avoid
+	// retrying per-connection stats on every poll when not elevated.
+	estatsDenied bool
+
+	// estatsLogged is set once another per-connection stats error has
+	// been logged, so a failing call doesn't flood the log every poll.
+	estatsLogged bool
+}
+
+// NewPlatform creates a new Windows platform collector.
+func NewPlatform() (Platform, error) {
+	if err := procGetExtendedTcpTable.Find(); err != nil {
+		return nil, fmt.Errorf("iphlpapi.dll: %w", err)
+	}
+	return &WindowsPlatform{
+		estatsEnabled: make(map[SocketKey]bool),
+	}, nil
+}
+
+func (p *WindowsPlatform) Close() error {
+	return nil
+}
+
+func (p *WindowsPlatform) Collect() ([]MappedSocket, []model.InterfaceStats, error) {
+	// 1. Enumerate sockets (TCP is required, UDP is best-effort)
+	var rows []ownedSocket
+	for _, af := range []uint32{afINET, afINET6} {
+		tcp, err := getTCPTable(af)
+		if err != nil {
+			return nil, nil, fmt.Errorf("GetExtendedTcpTable af=%d: %w", af, err)
+		}
+		rows = append(rows, tcp...)
+
+		udp, err := getUDPTable(af)
+		if err != nil {
+			continue
+		}
+		rows = append(rows, udp...)
+	}
+
+	// 2. Map to processes, filling byte counters for TCP connections
+	names := make(map[uint32]processName)
+	active := make(map[SocketKey]bool)
+	var mapped []MappedSocket
+	for i := range rows {
+		r := &rows[i]
+		ms := MappedSocket{Socket: r.socket, PID: r.pid}
+		if r.pid == 0 {
+			// The socket tables list every owner, whatever the privileges
+			ms.Unmapped = unmappedReason(&ms.Socket, true)
+		}
+
+		pn, ok := names[r.pid]
+		if !ok {
+			pn = lookupProcessName(r.pid)
+			names[r.pid] = pn
+		}
+		ms.ProcessName = pn.name
+		ms.Cmdline = pn.path
+
+		if ms.Proto == model.ProtoTCP && ms.State == model.StateEstablished && !p.estatsDenied {
+			key := MakeSocketKey(&ms)
+			active[key] = true
+			p.fillTCPBytes(r, key, &ms)
+		}
+
+		mapped = append(mapped, ms)
+	}
+
+	// Forget ESTATS state for connections that are gone
+	for key := range p.estatsEnabled {
+		if !active[key] {
+			delete(p.estatsEnabled, key)
+		}
+	}
+
+	// 3. Interface stats
+	ifaces, err := getIfTable()
+	if err != nil {
+		// Non-fatal; return sockets without interface stats
+		ifaces = nil
+	}
+	fillInterfaceAddrs(ifaces)
+
+	return mapped, ifaces, nil
+}
+
+// fillTCPBytes enables (once) and reads per-connection data statistics.
+func (p *WindowsPlatform) fillTCPBytes(r *ownedSocket, key SocketKey, ms *MappedSocket) {
+	if !p.estatsEnabled[key] {
+		if err := enableTCPEStats(r); err != nil {
+			p.estatsError(err)
+			return
+		}
+		p.estatsEnabled[key] = true
+	}
+	sent, recv, err := getTCPEStats(r)
+	if err != nil {
+		p.estatsError(err)
+		return
+	}
+	ms.BytesSent = sent
+	ms.BytesRecv = recv
+}
+
+// estatsError reports a failed per-connection stats call: access denied
+// stops further calls, the first other error is logged.
+func (p *WindowsPlatform) estatsError(err error) {
+	switch {
+	case isAccessDenied(err):
+		log.Printf("sstop: per-connection TCP stats need Administrator; bandwidth will be zero: %v", err)
+		p.estatsDenied = true
+	case !p.estatsLogged:
+		log.Printf("sstop: reading per-connection TCP stats: %v", err)
+		p.estatsLogged = true
+	}
+}
+
+// processName holds the resolved name and image path of a process.
+type processName struct {
+	name string
+	path string
+}
+
+// lookupProcessName resolves a PID to its executable name, e.g. "firefox".
+func lookupProcessName(pid uint32) processName {
+	switch pid {
+	case 0:
+		return processName{name: "System Idle"}
+	case 4:
+		return processName{name: "System"}
+	}
+	path, err := queryFullProcessImageName(pid)
+	if err != nil || path == "" {
+		return processName{name: "?"}
+	}
+	name := filepath.Base(path)
+	if strings.EqualFold(filepath.Ext(name), ".exe") {
+		name = name[:len(name)-len(".exe")]
+	}
+	return processName{name: name, path: path}
+}
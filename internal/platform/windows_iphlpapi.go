@@ -0,0 +1,413 @@
+//go:build windows
+
+package platform
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"syscall"
+	"unsafe"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+var (
+	modIphlpapi = syscall.NewLazyDLL("iphlpapi.dll")
+	modKernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+	procGetExtendedTcpTable        = modIphlpapi.NewProc("GetExtendedTcpTable")
+	procGetExtendedUdpTable        = modIphlpapi.NewProc("GetExtendedUdpTable")
+	procGetIfTable2                = modIphlpapi.NewProc("GetIfTable2")
+	procFreeMibTable               = modIphlpapi.NewProc("FreeMibTable")
+	procSetPerTcpConnectionEStats  = modIphlpapi.NewProc("SetPerTcpConnectionEStats")
+	procGetPerTcpConnectionEStats  = modIphlpapi.NewProc("GetPerTcpConnectionEStats")
+	procSetPerTcp6ConnectionEStats = modIphlpapi.NewProc("SetPerTcp6ConnectionEStats")
+	procGetPerTcp6ConnectionEStats = modIphlpapi.NewProc("GetPerTcp6ConnectionEStats")
+	procQueryFullProcessImageNameW = modKernel32.NewProc("QueryFullProcessImageNameW")
+)
+
+const (
+	// Address families (ws2def.h)
+	afINET  = 2  // AF_INET
+	afINET6 = 23 // AF_INET6 (differs from Linux)
+
+	// Table classes
+	tcpTableOwnerPIDAll = 5 // TCP_TABLE_OWNER_PID_ALL
+	udpTableOwnerPID    = 1 // UDP_TABLE_OWNER_PID
+
+	// Row sizes of the *_OWNER_PID structures
+	tcpRowSize  = 24 // MIB_TCPROW_OWNER_PID
+	tcp6RowSize = 56 // MIB_TCP6ROW_OWNER_PID
+	udpRowSize  = 12 // MIB_UDPROW_OWNER_PID
+	udp6RowSize = 28 // MIB_UDP6ROW_OWNER_PID
+
+	// MIB_IF_ROW2 layout (netioapi.h)
+	ifRowSize      = 1352
+	ifRowAlias     = 28   // WCHAR[257]
+	ifRowType      = 1128 // IFTYPE
+	ifRowFlags     = 1152 // InterfaceAndOperStatusFlags bitfield
+	ifRowOper      = 1156 // IF_OPER_STATUS
+	ifRowSpeed     = 1192 // TransmitLinkSpeed, bits/sec
+	ifRowInOctets  = 1208
+	ifRowInPkts    = 1216 // InUcastPkts, then InNUcastPkts
+	ifRowInDrops   = 1232
+	ifRowInErrors  = 1240
+	ifRowOutOctets = 1280
+	ifRowOutPkts   = 1288 // OutUcastPkts, then OutNUcastPkts
+	ifRowOutDrops  = 1304
+	ifRowOutErrors = 1312
+
+	ifTypeLoopback     = 24   // IF_TYPE_SOFTWARE_LOOPBACK
+	ifOperStatusUp     = 1    // IfOperStatusUp
+	ifFlagFilter       = 0x02 // FilterInterface bit
+	ifAliasMaxChars    = 257
+	estatsTypeData     = 1      // TcpConnectionEstatsData
+	estatsDataRodSize  = 96     // TCP_ESTATS_DATA_ROD_v0, ThruBytesReceived last at 88
+	processQueryLimInf = 0x1000 // PROCESS_QUERY_LIMITED_INFORMATION
+
+	errInsufficientBuffer = 122 // ERROR_INSUFFICIENT_BUFFER
+	errAccessDenied       = 5   // ERROR_ACCESS_DENIED
+)
+
+// ownedSocket is a socket table row with its owning PID and the raw fields
+// needed to query per-connection statistics.
+type ownedSocket struct {
+	socket model.Socket
+	pid    uint32
+	family uint32
+
+	// Raw (network byte order) fields for MIB_TCPROW / MIB_TCP6ROW
+	localAddr, remoteAddr   [16]byte
+	localScope, remoteScope uint32
+	localPort, remotePort   uint32
+	state                   uint32
+}
+
+// getTable calls one of the GetExtended*Table functions, growing the buffer
+// until the table fits.
+func getTable(proc *syscall.LazyProc, af uint32, class uintptr) ([]byte, error) {
+	size := uint32(16 * 1024)
+	for attempt := 0; attempt < 5; attempt++ {
+		buf := make([]byte, size)
+		r, _, _ := proc.Call(
+			uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(unsafe.Pointer(&size)),
+			0, // unsorted
+			uintptr(af),
+			class,
+			0,
+		)
+		switch r {
+		case 0:
+			return buf[:size], nil
+		case errInsufficientBuffer:
+			size += 4096 // table may grow between calls
+			continue
+		default:
+			return nil, syscall.Errno(r)
+		}
+	}
+	return nil, syscall.Errno(errInsufficientBuffer)
+}
+
+func getTCPTable(af uint32) ([]ownedSocket, error) {
+	buf, err := getTable(procGetExtendedTcpTable, af, tcpTableOwnerPIDAll)
+	if err != nil {
+		return nil, err
+	}
+	return parseTCPTable(buf, af), nil
+}
+
+func getUDPTable(af uint32) ([]ownedSocket, error) {
+	buf, err := getTable(procGetExtendedUdpTable, af, udpTableOwnerPID)
+	if err != nil {
+		return nil, err
+	}
+	return parseUDPTable(buf, af), nil
+}
+
+// parseTCPTable parses a MIB_TCPTABLE_OWNER_PID / MIB_TCP6TABLE_OWNER_PID buffer.
+func parseTCPTable(buf []byte, af uint32) []ownedSocket {
+	if len(buf) < 4 {
+		return nil
+	}
+	n := int(binary.LittleEndian.Uint32(buf[0:4]))
+	rowSize := tcpRowSize
+	if af == afINET6 {
+		rowSize = tcp6RowSize
+	}
+
+	var result []ownedSocket
+	for i := 0; i < n; i++ {
+		off := 4 + i*rowSize
+		if off+rowSize > len(buf) {
+			break
+		}
+		row := buf[off : off+rowSize]
+		var o ownedSocket
+		o.family = af
+		if af == afINET {
+			o.state = binary.LittleEndian.Uint32(row[0:4])
+			copy(o.localAddr[:4], row[4:8])
+			o.localPort = binary.LittleEndian.Uint32(row[8:12])
+			copy(o.remoteAddr[:4], row[12:16])
+			o.remotePort = binary.LittleEndian.Uint32(row[16:20])
+			o.pid = binary.LittleEndian.Uint32(row[20:24])
+			o.socket.SrcIP = net.IP(append([]byte(nil), row[4:8]...))
+			o.socket.DstIP = net.IP(append([]byte(nil), row[12:16]...))
+			o.socket.SrcPort = binary.BigEndian.Uint16(row[8:10])
+			o.socket.DstPort = binary.BigEndian.Uint16(row[16:18])
+		} else {
+			copy(o.localAddr[:], row[0:16])
+			o.localScope = binary.LittleEndian.Uint32(row[16:20])
+			o.localPort = binary.LittleEndian.Uint32(row[20:24])
+			copy(o.remoteAddr[:], row[24:40])
+			o.remoteScope = binary.LittleEndian.Uint32(row[40:44])
+			o.remotePort = binary.LittleEndian.Uint32(row[44:48])
+			o.state = binary.LittleEndian.Uint32(row[48:52])
+			o.pid = binary.LittleEndian.Uint32(row[52:56])
+			o.socket.SrcIP = net.IP(append([]byte(nil), row[0:16]...))
+			o.socket.DstIP = net.IP(append([]byte(nil), row[24:40]...))
+			o.socket.SrcPort = binary.BigEndian.Uint16(row[20:22])
+			o.socket.DstPort = binary.BigEndian.Uint16(row[44:46])
+		}
+		o.socket.Proto = model.ProtoTCP
+		o.socket.State = mapWinTCPState(o.state)
+		result = append(result, o)
+	}
+	return result
+}
+
+// parseUDPTable parses a MIB_UDPTABLE_OWNER_PID / MIB_UDP6TABLE_OWNER_PID buffer.
+func parseUDPTable(buf []byte, af uint32) []ownedSocket {
+	if len(buf) < 4 {
+		return nil
+	}
+	n := int(binary.LittleEndian.Uint32(buf[0:4]))
+	rowSize := udpRowSize
+	if af == afINET6 {
+		rowSize = udp6RowSize
+	}
+
+	var result []ownedSocket
+	for i := 0; i < n; i++ {
+		off := 4 + i*rowSize
+		if off+rowSize > len(buf) {
+			break
+		}
+		row := buf[off : off+rowSize]
+		var o ownedSocket
+		o.family = af
+		if af == afINET {
+			o.socket.SrcIP = net.IP(append([]byte(nil), row[0:4]...))
+			o.socket.SrcPort = binary.BigEndian.Uint16(row[4:6])
+			o.pid = binary.LittleEndian.Uint32(row[8:12])
+			o.socket.DstIP = net.IPv4zero
+		} else {
+			o.socket.SrcIP = net.IP(append([]byte(nil), row[0:16]...))
+			o.socket.SrcPort = binary.BigEndian.Uint16(row[20:22])
+			o.pid = binary.LittleEndian.Uint32(row[24:28])
+			o.socket.DstIP = net.IPv6unspecified
+		}
+		o.socket.Proto = model.ProtoUDP
+		o.socket.State = model.StateClose
+		result = append(result, o)
+	}
+	return result
+}
+
+// mapWinTCPState maps MIB_TCP_STATE values to our SocketState.
+func mapWinTCPState(s uint32) model.SocketState {
+	switch s {
+	case 1:
+		return model.StateClose
+	case 2:
+		return model.StateListen
+	case 3:
+		return model.StateSynSent
+	case 4:
+		return model.StateSynRecv
+	case 5:
+		return model.StateEstablished
+	case 6:
+		return model.StateFinWait1
+	case 7:
+		return model.StateFinWait2
+	case 8:
+		return model.StateCloseWait
+	case 9:
+		return model.StateClosing
+	case 10:
+		return model.StateLastAck
+	case 11:
+		return model.StateTimeWait
+	default:
+		return model.StateUnknown
+	}
+}
+
+// tcpRow builds the MIB_TCPROW or MIB_TCP6ROW identifying a connection.
+func (o *ownedSocket) tcpRow() []byte {
+	if o.family == afINET {
+		row := make([]byte, 20)
+		binary.LittleEndian.PutUint32(row[0:4], o.state)
+		copy(row[4:8], o.localAddr[:4])
+		binary.LittleEndian.PutUint32(row[8:12], o.localPort)
+		copy(row[12:16], o.remoteAddr[:4])
+		binary.LittleEndian.PutUint32(row[16:20], o.remotePort)
+		return row
+	}
+	row := make([]byte, 52)
+	binary.LittleEndian.PutUint32(row[0:4], o.state)
+	copy(row[4:20], o.localAddr[:])
+	binary.LittleEndian.PutUint32(row[20:24], o.localScope)
+	binary.LittleEndian.PutUint32(row[24:28], o.localPort)
+	copy(row[28:44], o.remoteAddr[:])
+	binary.LittleEndian.PutUint32(row[44:48], o.remoteScope)
+	binary.LittleEndian.PutUint32(row[48:52], o.remotePort)
+	return row
+}
+
+// enableTCPEStats switches on data statistics collection for a connection.
+func enableTCPEStats(o *ownedSocket) error {
+	proc := procSetPerTcpConnectionEStats
+	if o.family == afINET6 {
+		proc = procSetPerTcp6ConnectionEStats
+	}
+	row := o.tcpRow()
+	rw := [1]byte{1} // TCP_ESTATS_DATA_RW_v0{EnableCollection: TRUE}
+	r, _, _ := proc.Call(
+		uintptr(unsafe.Pointer(&row[0])),
+		estatsTypeData,
+		uintptr(unsafe.Pointer(&rw[0])),
+		0,
+		uintptr(len(rw)),
+		0,
+	)
+	if r != 0 {
+		return syscall.Errno(r)
+	}
+	return nil
+}
+
+// getTCPEStats reads cumulative bytes out/in for a connection
+// (TCP_ESTATS_DATA_ROD_v0.DataBytesOut / DataBytesIn).
+func getTCPEStats(o *ownedSocket) (sent, recv uint64, err error) {
+	proc := procGetPerTcpConnectionEStats
+	if o.family == afINET6 {
+		proc = procGetPerTcp6ConnectionEStats
+	}
+	row := o.tcpRow()
+	rod := make([]byte, estatsDataRodSize)
+	r, _, _ := proc.Call(
+		uintptr(unsafe.Pointer(&row[0])),
+		estatsTypeData,
+		0, 0, 0, // Rw
+		0, 0, 0, // Ros
+		uintptr(unsafe.Pointer(&rod[0])),
+		0,
+		uintptr(len(rod)),
+	)
+	if r != 0 {
+		return 0, 0, syscall.Errno(r)
+	}
+	return binary.LittleEndian.Uint64(rod[0:8]), binary.LittleEndian.Uint64(rod[16:24]), nil
+}
+
+func isAccessDenied(err error) bool {
+	var errno syscall.Errno
+	return errors.As(err, &errno) && errno == errAccessDenied
+}
+
+// ifTable2 mirrors the MIB_IF_TABLE2 header; rows follow at offset 8.
+type ifTable2 struct {
+	NumEntries uint32
+	_          uint32
+}
+
+// getIfTable reads interface byte counters via GetIfTable2.
+func getIfTable() ([]model.InterfaceStats, error) {
+	var table *ifTable2
+	r, _, _ := procGetIfTable2.Call(uintptr(unsafe.Pointer(&table)))
+	if r != 0 {
+		return nil, syscall.Errno(r)
+	}
+	defer procFreeMibTable.Call(uintptr(unsafe.Pointer(table)))
+
+	n := int(table.NumEntries)
+	buf := unsafe.Slice((*byte)(unsafe.Pointer(table)), 8+n*ifRowSize)
+	return parseIfTable(buf[8:], n), nil
+}
+
+// parseIfTable extracts up, non-loopback, non-filter interfaces from MIB_IF_ROW2 rows.
+func parseIfTable(rows []byte, n int) []model.InterfaceStats {
+	var result []model.InterfaceStats
+	for i := 0; i < n; i++ {
+		off := i * ifRowSize
+		if off+ifRowSize > len(rows) {
+			break
+		}
+		row := rows[off : off+ifRowSize]
+
+		if binary.LittleEndian.Uint32(row[ifRowType:]) == ifTypeLoopback {
+			continue
+		}
+		if row[ifRowFlags]&ifFlagFilter != 0 {
+			continue
+		}
+		if binary.LittleEndian.Uint32(row[ifRowOper:]) != ifOperStatusUp {
+			continue
+		}
+
+		alias := make([]uint16, ifAliasMaxChars)
+		for j := range alias {
+			alias[j] = binary.LittleEndian.Uint16(row[ifRowAlias+2*j:])
+		}
+		name := syscall.UTF16ToString(alias)
+		if name == "" {
+			continue
+		}
+
+		u64 := func(off int) uint64 { return binary.LittleEndian.Uint64(row[off:]) }
+		speed := u64(ifRowSpeed)
+		if speed == ^uint64(0) { // unknown
+			speed = 0
+		}
+		result = append(result, model.InterfaceStats{
+			Name:        name,
+			BytesRecv:   u64(ifRowInOctets),
+			BytesSent:   u64(ifRowOutOctets),
+			PacketsRecv: u64(ifRowInPkts) + u64(ifRowInPkts+8),
+			PacketsSent: u64(ifRowOutPkts) + u64(ifRowOutPkts+8),
+			ErrorsRecv:  u64(ifRowInErrors),
+			ErrorsSent:  u64(ifRowOutErrors),
+			DropsRecv:   u64(ifRowInDrops),
+			DropsSent:   u64(ifRowOutDrops),
+			Speed:       speed,
+		})
+	}
+	return result
+}
+
+// queryFullProcessImageName returns the full executable path for a PID.
+func queryFullProcessImageName(pid uint32) (string, error) {
+	h, err := syscall.OpenProcess(processQueryLimInf, false, pid)
+	if err != nil {
+		return "", err
+	}
+	defer syscall.CloseHandle(h)
+
+	buf := make([]uint16, syscall.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	r, _, e := procQueryFullProcessImageNameW.Call(
+		uintptr(h),
+		0,
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(unsafe.Pointer(&size)),
+	)
+	if r == 0 {
+		return "", e
+	}
+	return syscall.UTF16ToString(buf[:size]), nil
+}
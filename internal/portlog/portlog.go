@@ -0,0 +1,211 @@
+// Package portlog keeps a compact log of the listening-port inventory
+// (--ports-log) and reports what changed in it (sstop ports-diff): a
+// lightweight audit of exposed services, independent of full recordings.
+package portlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// DefaultEvery is how often the inventory is sampled.
+const DefaultEvery = time.Minute
+
+// Port is one listening socket. PIDs are kept for reference but a service
+// restarting under a new PID is not a change.
+type Port struct {
+	Proto     string `json:"proto"`
+	Addr      string `json:"addr"` // ip:port
+	Process   string `json:"process"`
+	PID       uint32 `json:"pid,omitempty"`
+	Container string `json:"container,omitempty"`
+}
+
+func (p Port) key() string {
+	return p.Proto + " " + p.Addr + " " + p.Process + " " + p.Container
+}
+
+// Record is the inventory at one time. A record is only written when the
+// inventory differs from the previous one.
+type Record struct {
+	Time  time.Time `json:"time"`
+	Ports []Port    `json:"ports"`
+}
+
+// Log samples snapshots' listen ports every so often and appends a record
+// to a JSONL file whenever the inventory changed.
+type Log struct {
+	f     *os.File
+	every time.Duration
+
+	lastSample time.Time
+	last       map[string]bool // inventory of the last record written
+}
+
+// Open appends to the log at path, sampling every interval. The last
+// record already in the file is the baseline, so a restart with unchanged
+// ports writes nothing.
+func Open(path string, every time.Duration) (*Log, error) {
+	l := &Log{every: every}
+	if f, err := os.Open(path); err == nil {
+		recs, _ := ReadRecords(f) // a torn last line just means no baseline
+		f.Close()
+		if len(recs) > 0 {
+			l.last = keys(recs[len(recs)-1].Ports)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	l.f = f
+	return l, nil
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	return l.f.Close()
+}
+
+// Write samples the snapshot's listen ports if a sampling interval has
+// passed, and logs them if they changed.
+func (l *Log) Write(snap model.Snapshot) error {
+	ts := snap.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	if !l.lastSample.IsZero() && ts.Sub(l.lastSample) < l.every {
+		return nil
+	}
+	l.lastSample = ts
+
+	ports := Inventory(snap.ListenPorts)
+	cur := keys(ports)
+	if l.last != nil && sameKeys(cur, l.last) {
+		return nil
+	}
+	line, err := json.Marshal(Record{Time: ts, Ports: ports})
+	if err != nil {
+		return err
+	}
+	if _, err := l.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	l.last = cur
+	return nil
+}
+
+// Inventory converts listen port entries into a sorted, deduplicated
+// inventory.
+func Inventory(entries []model.ListenPortEntry) []Port {
+	seen := make(map[string]bool, len(entries))
+	ports := make([]Port, 0, len(entries))
+	for _, e := range entries {
+		p := Port{
+			Proto:     e.Proto.String(),
+			Addr:      model.AddrPort(e.IP, e.Port),
+			Process:   e.Process,
+			PID:       e.PID,
+			Container: e.Container,
+		}
+		if k := p.key(); !seen[k] {
+			seen[k] = true
+			ports = append(ports, p)
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].key() < ports[j].key() })
+	return ports
+}
+
+// ReadRecords reads a log. It stops at the first malformed line (a write
+// cut short) and returns what came before it with the error.
+func ReadRecords(r io.Reader) ([]Record, error) {
+	var recs []Record
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return recs, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, sc.Err()
+}
+
+// Change is a port opening or closing between two records.
+type Change struct {
+	Time   time.Time
+	Opened bool // false = closed
+	Port   Port
+}
+
+// Diff returns the changes between consecutive records from since on
+// (zero = all), in time order; closings first within a record. The
+// baseline is the last record before since.
+func Diff(recs []Record, since time.Time) []Change {
+	var changes []Change
+	var prev []Port
+	started := false
+	for _, rec := range recs {
+		if rec.Time.Before(since) {
+			prev, started = rec.Ports, true
+			continue
+		}
+		if !started {
+			// First record of the log: its ports are the baseline
+			prev, started = rec.Ports, true
+			continue
+		}
+		before, after := byKey(prev), byKey(rec.Ports)
+		for _, p := range prev {
+			if _, ok := after[p.key()]; !ok {
+				changes = append(changes, Change{Time: rec.Time, Port: p})
+			}
+		}
+		for _, p := range rec.Ports {
+			if _, ok := before[p.key()]; !ok {
+				changes = append(changes, Change{Time: rec.Time, Opened: true, Port: p})
+			}
+		}
+		prev = rec.Ports
+	}
+	return changes
+}
+
+func keys(ports []Port) map[string]bool {
+	m := make(map[string]bool, len(ports))
+	for _, p := range ports {
+		m[p.key()] = true
+	}
+	return m
+}
+
+func byKey(ports []Port) map[string]Port {
+	m := make(map[string]Port, len(ports))
+	for _, p := range ports {
+		m[p.key()] = p
+	}
+	return m
+}
+
+func sameKeys(a, b map[string]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k := range a {
+		if !b[k] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package portlog
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func snapAt(ts time.Time, ports ...model.ListenPortEntry) model.Snapshot {
+	return model.Snapshot{Timestamp: ts, ListenPorts: ports}
+}
+
+func TestLogWritesChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ports.jsonl")
+	t0 := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ssh := model.ListenPortEntry{Proto: model.ProtoTCP, IP: net.IPv4zero, Port: 22, PID: 1, Process: "sshd"}
+	web := model.ListenPortEntry{Proto: model.ProtoTCP, IP: net.IPv4zero, Port: 8080, PID: 40, Process: "python3"}
+
+	l, err := Open(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, snap := range []model.Snapshot{
+		snapAt(t0, ssh),                          // first sample: written
+		snapAt(t0.Add(10*time.Second), ssh, web), // within the interval: not sampled
+		snapAt(t0.Add(time.Minute), ssh),         // unchanged
+		snapAt(t0.Add(2*time.Minute), ssh, web),  // opened
+	} {
+		if err := l.Write(snap); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Close()
+
+	// Reopened with the same ports: the last record is the baseline
+	web.PID = 41 // a restart under a new PID is no change
+	l, err = Open(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Write(snapAt(t0.Add(3*time.Minute), ssh, web))
+	l.Write(snapAt(t0.Add(4*time.Minute), web)) // sshd gone
+	l.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	recs, err := ReadRecords(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 3 {
+		t.Fatalf("%d records, want 3: %+v", len(recs), recs)
+	}
+
+	changes := Diff(recs, time.Time{})
+	want := []struct {
+		at     time.Duration
+		opened bool
+		addr   string
+	}{
+		{2 * time.Minute, true, "0.0.0.0:8080"},
+		{4 * time.Minute, false, "0.0.0.0:22"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %+v, want %d", changes, len(want))
+	}
+	for i, w := range want {
+		c := changes[i]
+		if !c.Time.Equal(t0.Add(w.at)) || c.Opened != w.opened || c.Port.Addr != w.addr {
+			t.Errorf("change %d = %+v, want %+v", i, c, w)
+		}
+	}
+
+	// From 3m on, the 2m record is the baseline
+	if got := Diff(recs, t0.Add(3*time.Minute)); len(got) != 1 || got[0].Port.Process != "sshd" {
+		t.Errorf("Diff since 3m = %+v, want sshd closing", got)
+	}
+}
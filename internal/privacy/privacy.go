@@ -0,0 +1,228 @@
+// Package privacy masks IP addresses, hostnames and command lines in
+// snapshots with stable pseudonyms, for demos, screenshots and shared
+// exports.
+package privacy
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// Masker replaces identifying details with pseudonyms that stay the same
+// for the lifetime of the Masker, so the same host reads the same across
+// views and polls.
+//
+//   - IPv4 addresses map into the documentation ranges (203.0.113.0/24,
+//     198.51.100.0/24, 192.0.2.0/24, then 240.0.0.0/4)
+//   - IPv6 addresses map into 2001:db8::/32, addresses sharing a /64 into
+//     one pseudonym /64, so grouping by prefix still works
+//   - hostnames become host-N.example
+//   - command lines keep only the executable name
+//
+// Loopback, unspecified and multicast addresses are left as-is. Masking is
+// idempotent: pseudonyms issued by a Masker pass through it unchanged, so a
+// stream masked for recording can be shown by a TUI sharing the Masker.
+type Masker struct {
+	mu     sync.Mutex
+	ips    map[string]net.IP
+	hosts  map[string]string
+	issued map[string]bool     // pseudonyms handed out (IP strings and hostnames)
+	nets6  map[[8]byte][4]byte // IPv6 /64 → its pseudonym's third and fourth groups
+	next4  uint32
+	next6  uint32
+}
+
+// New creates a Masker with an empty pseudonym table.
+func New() *Masker {
+	return &Masker{
+		ips:    make(map[string]net.IP),
+		hosts:  make(map[string]string),
+		issued: make(map[string]bool),
+		nets6:  make(map[[8]byte][4]byte),
+	}
+}
+
+// Apply returns a masked copy of snap. The input is not modified.
+func (m *Masker) Apply(snap model.Snapshot) model.Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	procs := make([]model.ProcessSummary, len(snap.Processes))
+	for i, p := range snap.Processes {
+		p.Cmdline = maskCmdline(p.Cmdline)
+		p.TopDest = m.host(p.TopDest)
+		p.TopDestIP = m.ip(p.TopDestIP)
+
+		if p.Connections != nil {
+			conns := make([]model.Connection, len(p.Connections))
+			for j, c := range p.Connections {
+				c.SrcIP = m.ip(c.SrcIP)
+				c.DstIP = m.ip(c.DstIP)
+				c.RemoteHost = m.host(c.RemoteHost)
+				conns[j] = c
+			}
+			p.Connections = conns
+		}
+		if p.ListenPorts != nil {
+			ports := make([]model.ListenPort, len(p.ListenPorts))
+			for j, lp := range p.ListenPorts {
+				lp.IP = m.ip(lp.IP)
+				ports[j] = lp
+			}
+			p.ListenPorts = ports
+		}
+		procs[i] = p
+	}
+	snap.Processes = procs
+
+	if snap.Exited != nil {
+		exited := make([]model.ProcessSummary, len(snap.Exited))
+		for i, p := range snap.Exited {
+			p.Cmdline = maskCmdline(p.Cmdline)
+			exited[i] = p
+		}
+		snap.Exited = exited
+	}
+
+	if snap.RemoteHosts != nil {
+		hosts := make([]model.RemoteHostSummary, len(snap.RemoteHosts))
+		for i, h := range snap.RemoteHosts {
+			h.Host = m.host(h.Host)
+			h.IP = m.ip(h.IP)
+			hosts[i] = h
+		}
+		snap.RemoteHosts = hosts
+	}
+
+	if snap.ListenPorts != nil {
+		ports := make([]model.ListenPortEntry, len(snap.ListenPorts))
+		for i, lp := range snap.ListenPorts {
+			lp.IP = m.ip(lp.IP)
+			lp.Cmdline = maskCmdline(lp.Cmdline)
+			ports[i] = lp
+		}
+		snap.ListenPorts = ports
+	}
+
+	if snap.Interfaces != nil {
+		ifaces := make([]model.InterfaceStats, len(snap.Interfaces))
+		for i, ifc := range snap.Interfaces {
+			if ifc.Addrs != nil {
+				addrs := make([]net.IP, len(ifc.Addrs))
+				for j, a := range ifc.Addrs {
+					addrs[j] = m.ip(a)
+				}
+				ifc.Addrs = addrs
+			}
+			ifaces[i] = ifc
+		}
+		snap.Interfaces = ifaces
+	}
+
+	return snap
+}
+
+// Filter masks every snapshot passing through the channel.
+func (m *Masker) Filter(snapCh <-chan model.Snapshot) <-chan model.Snapshot {
+	out := make(chan model.Snapshot, 1)
+	go func() {
+		defer close(out)
+		for snap := range snapCh {
+			out <- m.Apply(snap)
+		}
+	}()
+	return out
+}
+
+// ip returns the pseudonym for an address. Must hold m.mu.
+func (m *Masker) ip(ip net.IP) net.IP {
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() || ip.IsMulticast() {
+		return ip
+	}
+	key := ip.String()
+	if p, ok := m.ips[key]; ok {
+		return p
+	}
+	if m.issued[key] {
+		return ip
+	}
+
+	var p net.IP
+	if ip4 := ip.To4(); ip4 != nil {
+		p = pseudoIPv4(m.next4)
+		m.next4++
+	} else {
+		var prefix [8]byte
+		copy(prefix[:], ip.To16())
+		n, ok := m.nets6[prefix]
+		if !ok {
+			k := len(m.nets6)
+			n = [4]byte{byte(k >> 24), byte(k >> 16), byte(k >> 8), byte(k)}
+			m.nets6[prefix] = n
+		}
+		m.next6++
+		p = make(net.IP, net.IPv6len)
+		copy(p, net.ParseIP("2001:db8::"))
+		copy(p[4:8], n[:])
+		p[12] = byte(m.next6 >> 24)
+		p[13] = byte(m.next6 >> 16)
+		p[14] = byte(m.next6 >> 8)
+		p[15] = byte(m.next6)
+	}
+	m.ips[key] = p
+	m.issued[p.String()] = true
+	return p
+}
+
+// docNets are the IPv4 documentation ranges (RFC 5737), used in order.
+var docNets = [][3]byte{{203, 0, 113}, {198, 51, 100}, {192, 0, 2}}
+
+// pseudoIPv4 returns the n-th pseudonym address, hosts .1–.254 of each
+// documentation range, then the reserved 240.0.0.0/4 block.
+func pseudoIPv4(n uint32) net.IP {
+	const perNet = 254
+	if n < uint32(len(docNets))*perNet {
+		net3 := docNets[n/perNet]
+		return net.IPv4(net3[0], net3[1], net3[2], byte(n%perNet+1)).To4()
+	}
+	n -= uint32(len(docNets)) * perNet
+	return net.IPv4(240|byte(n>>24&0x0f), byte(n>>16), byte(n>>8), byte(n)).To4()
+}
+
+// host returns the pseudonym for a hostname or IP string. Must hold m.mu.
+func (m *Masker) host(h string) string {
+	if h == "" {
+		return h
+	}
+	if ip := net.ParseIP(h); ip != nil {
+		return m.ip(ip).String()
+	}
+	if p, ok := m.hosts[h]; ok {
+		return p
+	}
+	if m.issued[h] {
+		return h
+	}
+	p := fmt.Sprintf("host-%d.example", len(m.hosts)+1)
+	m.hosts[h] = p
+	m.issued[p] = true
+	return p
+}
+
+// maskCmdline keeps only the executable name: "/usr/bin/curl -H x url" → "curl …".
+func maskCmdline(cmdline string) string {
+	fields := strings.Fields(cmdline)
+	if len(fields) == 0 {
+		return cmdline
+	}
+	exe := filepath.Base(fields[0])
+	if len(fields) > 1 {
+		return exe + " …"
+	}
+	return exe
+}
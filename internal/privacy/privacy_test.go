@@ -0,0 +1,146 @@
+package privacy
+
+import (
+	"net"
+	"testing"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func testSnapshot() model.Snapshot {
+	return model.Snapshot{
+		Processes: []model.ProcessSummary{{
+			PID:     1,
+			Name:    "curl",
+			Cmdline: "/usr/bin/curl -H secret https://api.internal.corp",
+			TopDest: "api.internal.corp",
+			Connections: []model.Connection{{
+				SrcIP:      net.ParseIP("192.168.1.5"),
+				DstIP:      net.ParseIP("10.20.30.40"),
+				RemoteHost: "api.internal.corp",
+			}, {
+				SrcIP:      net.ParseIP("127.0.0.1"),
+				DstIP:      net.ParseIP("2a00:1450::1"),
+				RemoteHost: "2a00:1450::1",
+			}},
+			ListenPorts: []model.ListenPort{{IP: net.IPv4zero, Port: 80}},
+		}},
+		RemoteHosts: []model.RemoteHostSummary{
+			{Host: "api.internal.corp", IP: net.ParseIP("10.20.30.40")},
+		},
+		ListenPorts: []model.ListenPortEntry{
+			{IP: net.ParseIP("192.168.1.5"), Port: 22, Cmdline: "/usr/sbin/sshd -D"},
+		},
+		Interfaces: []model.InterfaceStats{
+			{Name: "eth0", Addrs: []net.IP{net.ParseIP("192.168.1.5")}},
+		},
+	}
+}
+
+func TestApplyStablePseudonyms(t *testing.T) {
+	m := New()
+	snap := testSnapshot()
+	got := m.Apply(snap)
+
+	p := got.Processes[0]
+	if p.Cmdline != "curl …" {
+		t.Errorf("cmdline = %q, want %q", p.Cmdline, "curl …")
+	}
+	c := p.Connections[0]
+	if c.SrcIP.String() != "203.0.113.1" || c.DstIP.String() != "203.0.113.2" {
+		t.Errorf("IPs = %s → %s, want 203.0.113.1 → 203.0.113.2", c.SrcIP, c.DstIP)
+	}
+	if c.RemoteHost != "host-1.example" || p.TopDest != "host-1.example" {
+		t.Errorf("hostname pseudonyms differ: %q / %q", c.RemoteHost, p.TopDest)
+	}
+
+	// Loopback untouched; IPv6 hostname-as-IP maps to the same pseudonym as the IP
+	c6 := p.Connections[1]
+	if !c6.SrcIP.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("loopback masked: %s", c6.SrcIP)
+	}
+	if c6.DstIP.String() != "2001:db8::1" || c6.RemoteHost != "2001:db8::1" {
+		t.Errorf("IPv6 = %s / %q, want 2001:db8::1", c6.DstIP, c6.RemoteHost)
+	}
+
+	// Same pseudonyms across views and polls
+	h := got.RemoteHosts[0]
+	if h.Host != "host-1.example" || !h.IP.Equal(c.DstIP) {
+		t.Errorf("remote host = %q %s, want consistent with connections", h.Host, h.IP)
+	}
+	if lp := got.ListenPorts[0]; !lp.IP.Equal(c.SrcIP) || lp.Cmdline != "sshd …" {
+		t.Errorf("listen port = %s %q", lp.IP, lp.Cmdline)
+	}
+	if a := got.Interfaces[0].Addrs[0]; !a.Equal(c.SrcIP) {
+		t.Errorf("interface address = %s, want %s like its connections", a, c.SrcIP)
+	}
+	again := m.Apply(testSnapshot())
+	if !again.Processes[0].Connections[0].DstIP.Equal(c.DstIP) {
+		t.Error("pseudonym changed between polls")
+	}
+
+	// Input is not modified
+	if snap.Processes[0].Connections[0].DstIP.String() != "10.20.30.40" || snap.RemoteHosts[0].Host != "api.internal.corp" {
+		t.Error("Apply modified its input")
+	}
+}
+
+func TestApplyIdempotent(t *testing.T) {
+	m := New()
+	once := m.Apply(testSnapshot())
+	twice := m.Apply(once)
+
+	c1, c2 := once.Processes[0].Connections[0], twice.Processes[0].Connections[0]
+	if !c1.DstIP.Equal(c2.DstIP) || c1.RemoteHost != c2.RemoteHost {
+		t.Errorf("re-masking changed pseudonyms: %s %q → %s %q", c1.DstIP, c1.RemoteHost, c2.DstIP, c2.RemoteHost)
+	}
+	if twice.Processes[0].Cmdline != once.Processes[0].Cmdline {
+		t.Errorf("re-masking changed cmdline: %q", twice.Processes[0].Cmdline)
+	}
+}
+
+func TestPseudoIPv4Ranges(t *testing.T) {
+	tests := []struct {
+		n    uint32
+		want string
+	}{
+		{0, "203.0.113.1"},
+		{253, "203.0.113.254"},
+		{254, "198.51.100.1"},
+		{508, "192.0.2.1"},
+		{762, "240.0.0.0"},
+		{763, "240.0.0.1"},
+	}
+	for _, tt := range tests {
+		if got := pseudoIPv4(tt.n).String(); got != tt.want {
+			t.Errorf("pseudoIPv4(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestPseudoIPv6Prefixes(t *testing.T) {
+	m := New()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a := m.ip(net.ParseIP("2a02:1810:4d02:aa00:1c5e:9f0b:3e11:7a20"))
+	b := m.ip(net.ParseIP("2a02:1810:4d02:aa00:8d2:44f1:c0de:1"))
+	c := m.ip(net.ParseIP("2a02:1810:4d02:bb00::1"))
+	if a.String() != "2001:db8::1" || b.String() != "2001:db8::2" || c.String() != "2001:db8:0:1::3" {
+		t.Errorf("pseudonyms = %s, %s, %s; want the first two sharing a /64", a, b, c)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	in := make(chan model.Snapshot, 1)
+	in <- testSnapshot()
+	close(in)
+
+	out := New().Filter(in)
+	snap, ok := <-out
+	if !ok || snap.Processes[0].Cmdline != "curl …" {
+		t.Errorf("snapshot not masked: %+v", snap.Processes)
+	}
+	if _, ok := <-out; ok {
+		t.Error("output channel should close with input")
+	}
+}
@@ -0,0 +1,112 @@
+package recorder
+
+import (
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// AlertRecorder keeps the last window of snapshots in memory and, when an
+// alert triggers it, writes them to a new recording followed by the
+// window of snapshots after the alert: evidence of what led up to an
+// alert and what followed, without recording around the clock.
+type AlertRecorder struct {
+	mu     sync.Mutex
+	dir    string
+	window time.Duration
+	buf    []model.Snapshot // the last window, while no recording is open
+	rec    *Recorder        // the recording being written, nil when idle
+	until  time.Time        // snapshots after this end the recording
+}
+
+// NewAlertRecorder creates an alert recorder writing its recordings to dir.
+func NewAlertRecorder(dir string, window time.Duration) *AlertRecorder {
+	return &AlertRecorder{dir: dir, window: window}
+}
+
+// AlertFileName is the name of the recording of an alert at t.
+func AlertFileName(t time.Time) string {
+	return "sstop-alert-" + t.Format("20060102-150405") + ext
+}
+
+// Observe adds a snapshot: to the recording while one is open, else to
+// the in-memory window.
+func (a *AlertRecorder) Observe(snap model.Snapshot) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.rec != nil {
+		if !snap.Timestamp.After(a.until) {
+			if err := a.rec.Write(snap); err != nil {
+				log.Printf("recorder: alert recording write error: %v", err)
+			}
+			return
+		}
+		a.finish()
+	}
+	a.buf = append(a.buf, snap)
+	cutoff := snap.Timestamp.Add(-a.window)
+	drop := 0
+	for drop < len(a.buf) && a.buf[drop].Timestamp.Before(cutoff) {
+		drop++
+	}
+	a.buf = a.buf[drop:]
+}
+
+// Trigger starts a recording of the window before at, which goes on for
+// the window after it. An alert while a recording is open extends it
+// instead. It returns the path of the recording started, "" when one was
+// extended.
+func (a *AlertRecorder) Trigger(at time.Time) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.rec != nil {
+		if end := at.Add(a.window); end.After(a.until) {
+			a.until = end
+		}
+		return "", nil
+	}
+	path := filepath.Join(a.dir, AlertFileName(at))
+	rec, err := NewRecorder(path)
+	if err != nil {
+		return "", err
+	}
+	for _, snap := range a.buf {
+		if err := rec.Write(snap); err != nil {
+			rec.Close()
+			return "", err
+		}
+	}
+	a.buf = nil
+	a.rec, a.until = rec, at.Add(a.window)
+	return path, nil
+}
+
+// finish closes the open recording. Must hold a.mu.
+func (a *AlertRecorder) finish() {
+	if err := a.rec.Close(); err != nil {
+		log.Printf("recorder: alert recording close error: %v", err)
+	}
+	a.rec = nil
+}
+
+// Close finishes a recording still open, cut short at the last snapshot.
+func (a *AlertRecorder) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.rec == nil {
+		return nil
+	}
+	err := a.rec.Close()
+	a.rec = nil
+	return err
+}
+
+// RecordOnAlert wraps a snapshot channel, passing its snapshots through an
+// AlertRecorder that writes the recordings it is triggered for to dir.
+func RecordOnAlert(snapCh <-chan model.Snapshot, dir string, window time.Duration) (<-chan model.Snapshot, *AlertRecorder) {
+	a := NewAlertRecorder(dir, window)
+	return tap(snapCh, a.Observe, func() { a.Close() }), a
+}
@@ -0,0 +1,56 @@
+package recorder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAlertRecorder(t *testing.T) {
+	dir := t.TempDir()
+	t0 := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	at := func(s int) time.Time { return t0.Add(time.Duration(s) * time.Second) }
+	a := NewAlertRecorder(dir, 10*time.Second)
+
+	// 30s of history, of which only the last 10s are kept
+	for s := 0; s <= 30; s++ {
+		a.Observe(makeTestSnapshot(at(s), 1))
+	}
+	path, err := a.Trigger(at(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "sstop-alert-20260102-030435.ssrec"); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+
+	// A second alert extends the recording by its own window
+	a.Observe(makeTestSnapshot(at(35), 1))
+	if again, _ := a.Trigger(at(35)); again != "" {
+		t.Errorf("second trigger started %q, want the open recording extended", again)
+	}
+	for s := 36; s <= 50; s++ {
+		a.Observe(makeTestSnapshot(at(s), 1))
+	}
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewPlayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, end := p.TimeRange()
+	if !start.Equal(at(20)) || !end.Equal(at(45)) || p.Len() != 11+11 {
+		t.Errorf("recording %v to %v, %d snapshots; want 20s to 45s, 22", start, end, p.Len())
+	}
+
+	// Later snapshots went back to the window
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("%d files, want 1", len(entries))
+	}
+	if len(a.buf) != 5 {
+		t.Errorf("window holds %d snapshots after the recording, want 46s to 50s", len(a.buf))
+	}
+}
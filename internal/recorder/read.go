@@ -0,0 +1,130 @@
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// openAttempts and openBackoff retry opening a recording that a network
+// filesystem reports locked or busy (SMB sharing violations, NFS lock
+// recovery) before giving up.
+const (
+	openAttempts = 4
+	openBackoff  = 250 * time.Millisecond
+)
+
+// ext is the file extension of recordings.
+const ext = ".ssrec"
+
+// openRead opens path read-only, retrying errors other than the file
+// not existing.
+func openRead(path string) (*os.File, error) {
+	var err error
+	for i := 0; i < openAttempts; i++ {
+		if i > 0 {
+			time.Sleep(openBackoff << (i - 1))
+		}
+		var f *os.File
+		if f, err = os.Open(path); err == nil {
+			return f, nil
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+	}
+	return nil, err
+}
+
+// readFile reads the records of one recording. What can't be read is
+// reported in the warnings rather than failing the whole file: a record
+// that doesn't decode is skipped, and a stream that ends mid-frame — a
+// recording still being written, or copied while it was — keeps the
+// records before it.
+func readFile(path string) (records []record, warnings []string, err error) {
+	f, err := openRead(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	name := filepath.Base(path)
+	gz, err := gzip.NewReader(f)
+	switch {
+	case err == io.EOF:
+		return nil, nil, nil // nothing written yet
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return nil, []string{name + ": ends inside the gzip header (still being written?)"}, nil
+	case err != nil:
+		return nil, nil, err
+	}
+	defer gz.Close()
+
+	skipped := 0
+	br := bufio.NewReaderSize(gz, 64*1024)
+	for {
+		line, readErr := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var rec record
+			if err := json.Unmarshal(line, &rec); err == nil {
+				records = append(records, rec)
+			} else if readErr == nil {
+				skipped++
+			}
+			// else: the partial last line of a cut-off stream
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: stops after %d snapshots (still being written, or cut short): %v",
+				name, len(records), readErr))
+			break
+		}
+	}
+	if skipped > 0 {
+		warnings = append(warnings, fmt.Sprintf("%s: skipped %d snapshots that failed to decode", name, skipped))
+	}
+	return records, warnings, nil
+}
+
+// readDir reads every recording in dir, e.g. a --record-on-alert
+// directory, as one timeline. Files that can't be opened are warned
+// about and left out.
+func readDir(dir string) (records []record, warnings []string, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	found := 0
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ext) {
+			continue
+		}
+		found++
+		recs, warns, err := readFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", e.Name(), err))
+			continue
+		}
+		records = append(records, recs...)
+		warnings = append(warnings, warns...)
+	}
+	if found == 0 {
+		return nil, nil, fmt.Errorf("%s: no %s recordings", dir, ext)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	return records, warnings, nil
+}
@@ -20,10 +20,11 @@ type record struct {
 
 // Recorder writes snapshots to a gzipped JSONL file.
 type Recorder struct {
-	mu   sync.Mutex
-	file *os.File
-	gz   *gzip.Writer
-	enc  *json.Encoder
+	mu     sync.Mutex
+	file   *os.File
+	gz     *gzip.Writer
+	enc    *json.Encoder
+	closed bool
 }
 
 // NewRecorder creates a new recorder writing to the given file path.
@@ -38,20 +39,33 @@ func NewRecorder(path string) (*Recorder, error) {
 	return &Recorder{file: f, gz: gz, enc: enc}, nil
 }
 
-// Write records a single snapshot.
+// Write records a single snapshot. It is flushed to the file, so the
+// recording can be played back while it is still being written.
 func (r *Recorder) Write(snap model.Snapshot) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	return r.enc.Encode(record{
+	if r.closed {
+		return os.ErrClosed
+	}
+	if err := r.enc.Encode(record{
 		Timestamp: snap.Timestamp,
 		Snapshot:  snap,
-	})
+	}); err != nil {
+		return err
+	}
+	return r.gz.Flush()
 }
 
-// Close flushes and closes the recorder.
+// Close flushes and closes the recorder. Later writes fail and later
+// closes do nothing, so a crash handler can flush the file while the
+// session still writes to it.
 func (r *Recorder) Close() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
 	if err := r.gz.Close(); err != nil {
 		r.file.Close()
 		return err
@@ -65,15 +79,23 @@ func RecordSession(snapCh <-chan model.Snapshot, path string) (<-chan model.Snap
 	if err != nil {
 		return nil, nil, err
 	}
+	write := func(snap model.Snapshot) {
+		if err := rec.Write(snap); err != nil {
+			log.Printf("recorder: write error: %v", err)
+		}
+	}
+	return tap(snapCh, write, func() { rec.Close() }), rec, nil
+}
 
+// tap passes snapshots through, handing each to observe first, and calls
+// done when snapCh closes. A slow reader gets the newest snapshot.
+func tap(snapCh <-chan model.Snapshot, observe func(model.Snapshot), done func()) <-chan model.Snapshot {
 	out := make(chan model.Snapshot, 1)
 	go func() {
 		defer close(out)
-		defer rec.Close()
+		defer done()
 		for snap := range snapCh {
-			if err := rec.Write(snap); err != nil {
-				log.Printf("recorder: write error: %v", err)
-			}
+			observe(snap)
 			select {
 			case out <- snap:
 			default:
@@ -85,8 +107,7 @@ func RecordSession(snapCh <-chan model.Snapshot, path string) (<-chan model.Snap
 			}
 		}
 	}()
-
-	return out, rec, nil
+	return out
 }
 
 // Player reads recorded snapshots from a gzipped JSONL file.
@@ -97,44 +118,53 @@ type Player struct {
 	records []record
 	idx     int
 
+	warnings []string // parts of the recording that could not be read
+
 	mu     sync.Mutex
 	speed  float64 // playback speed multiplier
 	paused bool
+	pos    int // index of the snapshot last handed out, -1 before the first
 }
 
-// NewPlayer opens a recording file for playback.
+// NewPlayer opens a recording for playback: a file, or a directory whose
+// recordings are played as one timeline. It only reads, so recordings
+// on a network share can be played while another sstop writes them.
 func NewPlayer(path string) (*Player, error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	gz, err := gzip.NewReader(f)
+	info, err := os.Stat(path)
 	if err != nil {
-		f.Close()
 		return nil, err
 	}
 
 	// Read all records into memory
-	dec := json.NewDecoder(gz)
 	var records []record
-	for {
-		var rec record
-		if err := dec.Decode(&rec); err != nil {
-			break
-		}
-		records = append(records, rec)
+	var warnings []string
+	if info.IsDir() {
+		records, warnings, err = readDir(path)
+	} else {
+		records, warnings, err = readFile(path)
+	}
+	if err != nil {
+		return nil, err
 	}
-
-	gz.Close()
-	f.Close()
 
 	return &Player{
-		records: records,
-		speed:   1.0,
+		records:  records,
+		warnings: warnings,
+		speed:    1.0,
+		pos:      -1,
 	}, nil
 }
 
+// Warnings describes the parts of the recording that could not be read:
+// snapshots that failed to decode, a tail cut off mid-frame, or files of
+// a directory that could not be opened. The rest plays normally.
+func (p *Player) Warnings() []string {
+	return p.warnings
+}
+
 // Play feeds snapshots to a channel at the original recording speed.
+// They keep their recorded timestamps, so time-windowed alert rules and
+// the alert log follow the recording rather than the wall clock.
 func (p *Player) Play() <-chan model.Snapshot {
 	ch := make(chan model.Snapshot, 1)
 
@@ -147,7 +177,10 @@ func (p *Player) Play() <-chan model.Snapshot {
 			}
 
 			snap := p.records[i].Snapshot
-			snap.Timestamp = time.Now()
+			snap.Timestamp = p.records[i].Timestamp
+			p.mu.Lock()
+			p.pos = i
+			p.mu.Unlock()
 			ch <- snap
 
 			// Wait for the delta between this and next snapshot
@@ -212,11 +245,38 @@ func (p *Player) IsPaused() bool {
 	return p.paused
 }
 
+// Position returns the index of the snapshot last handed to the UI, -1
+// before the first.
+func (p *Player) Position() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pos
+}
+
+// TimeRange returns when the first and last snapshots were recorded.
+func (p *Player) TimeRange() (start, end time.Time) {
+	if len(p.records) == 0 {
+		return time.Time{}, time.Time{}
+	}
+	return p.records[0].Timestamp, p.records[len(p.records)-1].Timestamp
+}
+
 // Len returns the number of recorded snapshots.
 func (p *Player) Len() int {
 	return len(p.records)
 }
 
+// Snapshots returns the recorded snapshots with their original
+// timestamps, for offline reports.
+func (p *Player) Snapshots() []model.Snapshot {
+	snaps := make([]model.Snapshot, len(p.records))
+	for i, rec := range p.records {
+		snaps[i] = rec.Snapshot
+		snaps[i].Timestamp = rec.Timestamp
+	}
+	return snaps
+}
+
 // Close releases resources.
 func (p *Player) Close() error {
 	return nil
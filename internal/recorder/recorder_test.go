@@ -1,9 +1,13 @@
 package recorder
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -57,6 +61,7 @@ func TestRecordAndPlaybackRoundTrip(t *testing.T) {
 	snaps := make([]model.Snapshot, 5)
 	for i := 0; i < 5; i++ {
 		snaps[i] = makeTestSnapshot(baseTime.Add(time.Duration(i)*time.Second), i+1)
+		snaps[i].CollectLatency = time.Duration(i+1) * time.Millisecond
 		if err := rec.Write(snaps[i]); err != nil {
 			t.Fatalf("Write[%d]: %v", i, err)
 		}
@@ -97,12 +102,24 @@ func TestRecordAndPlaybackRoundTrip(t *testing.T) {
 	if len(results) != 5 {
 		t.Fatalf("got %d snapshots, want 5", len(results))
 	}
+	if pos := player.Position(); pos != 4 {
+		t.Errorf("Position after playback = %d, want 4", pos)
+	}
+	if start, end := player.TimeRange(); !start.Equal(baseTime) || !end.Equal(baseTime.Add(4*time.Second)) {
+		t.Errorf("TimeRange = %v–%v, want the recorded first and last times", start, end)
+	}
 
 	// Verify data fidelity
 	for i, snap := range results {
 		if len(snap.Processes) != i+1 {
 			t.Errorf("snap[%d]: got %d procs, want %d", i, len(snap.Processes), i+1)
 		}
+		if !snap.Timestamp.Equal(snaps[i].Timestamp) {
+			t.Errorf("snap[%d]: Timestamp got %v, want the recorded %v", i, snap.Timestamp, snaps[i].Timestamp)
+		}
+		if snap.CollectLatency != snaps[i].CollectLatency {
+			t.Errorf("snap[%d]: CollectLatency got %v, want the recorded %v", i, snap.CollectLatency, snaps[i].CollectLatency)
+		}
 		if snap.TotalUp != 500.0 {
 			t.Errorf("snap[%d]: TotalUp got %f, want 500", i, snap.TotalUp)
 		}
@@ -172,6 +189,40 @@ func TestRecordSession(t *testing.T) {
 	}
 }
 
+func TestRecorderCloseMidSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crash.ssrec")
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	base := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		if err := rec.Write(makeTestSnapshot(base.Add(time.Duration(i)*time.Second), 1)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	// A crash handler flushes the file while the session is still writing
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := rec.Write(makeTestSnapshot(base.Add(2*time.Second), 1)); err == nil {
+		t.Error("Write after Close should fail")
+	}
+	if err := rec.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+
+	player, err := NewPlayer(path)
+	if err != nil {
+		t.Fatalf("NewPlayer: %v", err)
+	}
+	defer player.Close()
+	if player.Len() != 2 {
+		t.Errorf("player Len: got %d, want the 2 snapshots written before the flush", player.Len())
+	}
+}
+
 func TestPlayerSpeedBounds(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "speed.ssrec")
@@ -276,3 +327,110 @@ func TestEmptyRecording(t *testing.T) {
 		t.Errorf("empty playback: got %d snapshots, want 0", count)
 	}
 }
+
+func TestPlayerGrowingRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "live.ssrec")
+	rec, err := NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Close()
+
+	// Nothing written yet: empty, not an error
+	player, err := NewPlayer(path)
+	if err != nil || player.Len() != 0 {
+		t.Fatalf("NewPlayer(new file) = %v, %v", player, err)
+	}
+
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		if err := rec.Write(makeTestSnapshot(base.Add(time.Duration(i)*time.Second), 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The recorder is still open: the stream has no gzip trailer yet
+	player, err = NewPlayer(path)
+	if err != nil {
+		t.Fatalf("NewPlayer: %v", err)
+	}
+	if player.Len() != 3 {
+		t.Errorf("Len = %d, want the 3 snapshots written so far", player.Len())
+	}
+	if w := player.Warnings(); len(w) != 1 || !strings.Contains(w[0], "stops after 3 snapshots") {
+		t.Errorf("Warnings = %q", w)
+	}
+}
+
+func TestPlayerDamagedRecording(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(gz)
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	enc.Encode(record{Timestamp: base, Snapshot: makeTestSnapshot(base, 1)})
+	gz.Write([]byte("{\"ts\": garbage\n"))
+	enc.Encode(record{Timestamp: base.Add(time.Second), Snapshot: makeTestSnapshot(base, 1)})
+	gz.Close()
+
+	path := filepath.Join(t.TempDir(), "damaged.ssrec")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	player, err := NewPlayer(path)
+	if err != nil {
+		t.Fatalf("NewPlayer: %v", err)
+	}
+	if player.Len() != 2 {
+		t.Errorf("Len = %d, want the 2 records around the bad one", player.Len())
+	}
+	if w := player.Warnings(); len(w) != 1 || !strings.Contains(w[0], "skipped 1 snapshots") {
+		t.Errorf("Warnings = %q", w)
+	}
+
+	// Cut the stream off before its end
+	if err := os.WriteFile(path, buf.Bytes()[:buf.Len()-20], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	player, err = NewPlayer(path)
+	if err != nil {
+		t.Fatalf("NewPlayer(truncated): %v", err)
+	}
+	if player.Len() == 0 || len(player.Warnings()) == 0 {
+		t.Errorf("truncated: Len = %d, Warnings = %q; want the records before the cut and a warning",
+			player.Len(), player.Warnings())
+	}
+}
+
+func TestPlayerDirectory(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	write := func(name string, offsets ...int) {
+		rec, err := NewRecorder(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, o := range offsets {
+			rec.Write(makeTestSnapshot(base.Add(time.Duration(o)*time.Second), 1))
+		}
+		rec.Close()
+	}
+	write(AlertFileName(base.Add(time.Minute)), 60, 61)
+	write(AlertFileName(base), 0, 1)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a recording"), 0o644)
+
+	player, err := NewPlayer(dir)
+	if err != nil {
+		t.Fatalf("NewPlayer(dir): %v", err)
+	}
+	start, end := player.TimeRange()
+	if player.Len() != 4 || !start.Equal(base) || !end.Equal(base.Add(61*time.Second)) {
+		t.Errorf("Len = %d, range %v..%v", player.Len(), start, end)
+	}
+	if len(player.Warnings()) != 0 {
+		t.Errorf("Warnings = %q", player.Warnings())
+	}
+
+	if _, err := NewPlayer(t.TempDir()); err == nil || !strings.Contains(err.Error(), "no .ssrec recordings") {
+		t.Errorf("empty directory: err = %v", err)
+	}
+}
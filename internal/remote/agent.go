@@ -0,0 +1,230 @@
+package remote
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/output"
+)
+
+const (
+	// DefaultAgentPort is where "sstop serve" listens and "sstop connect"
+	// looks when a host is given without a port.
+	DefaultAgentPort = "9103"
+
+	snapshotsPath = "/snapshots"
+	subscriberBuf = 4 // snapshots queued per slow client before it misses some
+)
+
+// Server is the "sstop serve" agent: it streams every snapshot to the
+// clients connected to /snapshots as NDJSON, the --json format.
+type Server struct {
+	token string // required bearer token; "" = none
+
+	mu   sync.Mutex
+	last []byte // latest snapshot line, sent to clients as they connect
+	subs map[chan []byte]struct{}
+
+	srv *http.Server
+}
+
+// NewServer creates an agent. A non-empty token must be sent by clients as
+// "Authorization: Bearer <token>".
+func NewServer(token string) *Server {
+	s := &Server{
+		token: token,
+		subs:  make(map[chan []byte]struct{}),
+	}
+	s.srv = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the HTTP handler serving the snapshot stream.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(snapshotsPath, s.handleSnapshots)
+	return mux
+}
+
+// ListenAndServe starts serving on addr in a background goroutine.
+// Returns an error if the address cannot be bound, or if it isn't a
+// loopback address and the server has no token: the stream carries every
+// process's command line, user and connections in plain HTTP.
+func (s *Server) ListenAndServe(addr string) error {
+	if s.token == "" && !isLoopback(addr) {
+		return fmt.Errorf("refusing to serve on %s without a token: anyone who can reach it could read every process's command line, user and connections", addr)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go s.srv.Serve(ln)
+	return nil
+}
+
+// isLoopback reports whether the listen address addr ("host:port") only
+// accepts local connections. An empty host listens on every interface.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Close stops the HTTP server, ending every client's stream.
+func (s *Server) Close() error {
+	return s.srv.Close()
+}
+
+// Write sends a snapshot to every connected client. A client that can't
+// keep up misses snapshots rather than holding up the others.
+func (s *Server) Write(snap model.Snapshot) error {
+	var line strings.Builder
+	if err := output.WriteJSON(&line, snap); err != nil {
+		return err
+	}
+	b := []byte(line.String())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = b
+	for ch := range s.subs {
+		select {
+		case ch <- b:
+		default:
+		}
+	}
+	return nil
+}
+
+func (s *Server) subscribe() (chan []byte, []byte) {
+	ch := make(chan []byte, subscriberBuf)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs[ch] = struct{}{}
+	return ch, s.last
+}
+
+func (s *Server) unsubscribe(ch chan []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subs, ch)
+}
+
+func (s *Server) handleSnapshots(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" {
+		got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+	ch, last := s.subscribe()
+	defer s.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	send := func(line []byte) bool {
+		if _, err := w.Write(line); err != nil {
+			return false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	}
+	if last != nil && !send(last) {
+		return
+	}
+	for {
+		select {
+		case line := <-ch:
+			if !send(line) {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// NewAgent creates a source streaming from the "sstop serve" agent at addr
+// (host or host:port, default port DefaultAgentPort). The agent's own
+// interval applies; SetInterval only reconnects.
+func NewAgent(addr, token string) *Source {
+	s := &Source{
+		target: addr,
+		done:   make(chan struct{}),
+	}
+	s.backoff.min, s.backoff.max = minBackoff, maxBackoff
+	url := agentURL(addr)
+	s.connect = func(ctx context.Context, _ time.Duration) (io.ReadCloser, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("agent: %s", resp.Status)
+		}
+		return resp.Body, nil
+	}
+	return s
+}
+
+// agentURL returns the snapshot stream URL for an agent address.
+func agentURL(addr string) string {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	scheme, hostport, _ := strings.Cut(addr, "://")
+	hostport = strings.TrimSuffix(hostport, "/")
+	if _, _, err := net.SplitHostPort(hostport); err != nil {
+		hostport = net.JoinHostPort(strings.Trim(hostport, "[]"), DefaultAgentPort)
+	}
+	return scheme + "://" + hostport + snapshotsPath
+}
+
+// Merge combines the snapshots of several sources into one channel, closed
+// once all of them are.
+func Merge(chs ...<-chan model.Snapshot) <-chan model.Snapshot {
+	out := make(chan model.Snapshot, len(chs))
+	var wg sync.WaitGroup
+	for _, ch := range chs {
+		wg.Add(1)
+		go func(ch <-chan model.Snapshot) {
+			defer wg.Done()
+			for snap := range ch {
+				out <- snap
+			}
+		}(ch)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
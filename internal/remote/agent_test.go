@@ -0,0 +1,90 @@
+package remote
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestAgentStream(t *testing.T) {
+	srv := NewServer("s3cret")
+	hs := httptest.NewServer(srv.Handler())
+	defer hs.Close()
+	addr := strings.TrimPrefix(hs.URL, "http://")
+
+	// A snapshot from before the client connected is sent straight away
+	srv.Write(model.Snapshot{Processes: []model.ProcessSummary{{PID: 1, Name: "nginx", UpRate: 10}}})
+
+	src := NewAgent(addr, "s3cret")
+	ch := Merge(src.Start())
+	defer src.Stop()
+
+	next := func() model.Snapshot {
+		t.Helper()
+		select {
+		case snap := <-ch:
+			return snap
+		case <-time.After(5 * time.Second):
+			t.Fatal("no snapshot from the agent")
+		}
+		return model.Snapshot{}
+	}
+	if snap := next(); snap.Host != addr || len(snap.Processes) != 1 || snap.Processes[0].Name != "nginx" {
+		t.Fatalf("first snapshot = %+v, want nginx from %s", snap, addr)
+	}
+	srv.Write(model.Snapshot{Processes: []model.ProcessSummary{{PID: 2, Name: "redis"}}})
+	if snap := next(); len(snap.Processes) != 1 || snap.Processes[0].Name != "redis" {
+		t.Errorf("second snapshot = %+v, want redis", snap)
+	}
+
+	resp, err := http.Get(hs.URL + snapshotsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("request without token: %s, want 401", resp.Status)
+	}
+}
+
+func TestAgentListenNeedsToken(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1:9103": true,
+		"[::1]:9103":     true,
+		"localhost:9103": true,
+		":9103":          false,
+		"0.0.0.0:9103":   false,
+		"10.0.0.5:9103":  false,
+	} {
+		if got := isLoopback(addr); got != want {
+			t.Errorf("isLoopback(%q) = %v, want %v", addr, got, want)
+		}
+	}
+
+	if err := NewServer("").ListenAndServe(":0"); err == nil || !strings.Contains(err.Error(), "without a token") {
+		t.Errorf("serving on every interface without a token: err = %v, want it refused", err)
+	}
+	srv := NewServer("")
+	if err := srv.ListenAndServe("127.0.0.1:0"); err != nil {
+		t.Errorf("serving on loopback without a token: %v", err)
+	}
+	srv.Close()
+}
+
+func TestAgentURL(t *testing.T) {
+	for addr, want := range map[string]string{
+		"web1":                  "http://web1:9103/snapshots",
+		"web1:8000":             "http://web1:8000/snapshots",
+		"10.0.0.5":              "http://10.0.0.5:9103/snapshots",
+		"[fd00::5]":             "http://[fd00::5]:9103/snapshots",
+		"https://web1.example/": "https://web1.example:9103/snapshots",
+	} {
+		if got := agentURL(addr); got != want {
+			t.Errorf("agentURL(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// Package remote streams snapshots between machines: from sstop run over
+// SSH (sstop --remote), or from "sstop serve" agents to "sstop connect", so
+// headless servers can be watched from a local terminal.
+package remote
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googlesky/sstop/internal/collector"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+const (
+	// DefaultCommand is the sstop run on the remote host. Use something like
+	// "sudo sstop" when it needs root to see every process.
+	DefaultCommand = "sstop"
+
+	minBackoff = time.Second
+	maxBackoff = 30 * time.Second
+)
+
+// sshOptions keep ssh from prompting behind the TUI (keys or an agent are
+// needed) and notice a dead link within seconds.
+var sshOptions = []string{
+	"-T",
+	"-o", "BatchMode=yes",
+	"-o", "ServerAliveInterval=5",
+	"-o", "ServerAliveCountMax=3",
+}
+
+// Source streams snapshots from a remote host, labelled with its name
+// (Snapshot.Host). A session that ends is reconnected with backoff; the UI
+// marks the last snapshot stale meanwhile.
+type Source struct {
+	target  string // [user@]host as given to ssh, or an agent address
+	command string
+
+	// connect opens a session's NDJSON snapshot stream; closing it ends
+	// the session and reports why it ended
+	connect func(ctx context.Context, interval time.Duration) (io.ReadCloser, error)
+
+	// dial starts an SSH session; replaced in tests
+	dial func(ctx context.Context, interval time.Duration) *exec.Cmd
+
+	backoff struct{ min, max time.Duration }
+
+	mu       sync.Mutex
+	interval time.Duration
+	restart  bool               // session cancelled to apply a new interval
+	cancel   context.CancelFunc // ends the current session
+	stopped  bool
+	done     chan struct{}
+}
+
+// New creates a source running sstop --json over SSH on target
+// ([user@]host), polling every interval.
+func New(target string, interval time.Duration) *Source {
+	s := &Source{
+		target:   target,
+		command:  DefaultCommand,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+	s.backoff.min, s.backoff.max = minBackoff, maxBackoff
+	s.dial = s.ssh
+	s.connect = s.execStream
+	return s
+}
+
+// SetCommand sets the remote command run with --json (default "sstop").
+func (s *Source) SetCommand(command string) {
+	s.command = command
+}
+
+// ssh returns the ssh command running the remote sstop.
+func (s *Source) ssh(ctx context.Context, interval time.Duration) *exec.Cmd {
+	remote := fmt.Sprintf("%s --json --interval %s", s.command, interval)
+	args := append(append([]string{}, sshOptions...), s.target, remote)
+	return exec.CommandContext(ctx, "ssh", args...)
+}
+
+// execStream starts an SSH session and returns its output.
+func (s *Source) execStream(ctx context.Context, interval time.Duration) (io.ReadCloser, error) {
+	cmd := s.dial(ctx, interval)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cs := &cmdStream{ReadCloser: stdout, cmd: cmd}
+	cmd.Stderr = &cs.stderr
+	cmd.WaitDelay = time.Second // don't hang on pipes a killed session left open
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// cmdStream is the output of a running command; Close waits for it and
+// returns its exit status with the last line it logged.
+type cmdStream struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr tail
+}
+
+func (c *cmdStream) Close() error {
+	err := c.cmd.Wait()
+	if msg := c.stderr.String(); msg != "" {
+		if err == nil {
+			return errors.New(msg)
+		}
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
+
+// SetInterval restarts the remote session with a new polling interval.
+func (s *Source) SetInterval(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interval = d
+	if s.cancel != nil {
+		s.restart = true
+		s.cancel()
+	}
+}
+
+// Start connects and returns the snapshot channel, closed after Stop.
+func (s *Source) Start() <-chan model.Snapshot {
+	ch := make(chan model.Snapshot, 1)
+	go s.run(ch)
+	return ch
+}
+
+// Stop ends the remote session and stops reconnecting.
+func (s *Source) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.stopped = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+	close(s.done)
+}
+
+func (s *Source) run(ch chan<- model.Snapshot) {
+	defer close(ch)
+	hist := newHistories()
+	backoff := s.backoff.min
+	for {
+		s.mu.Lock()
+		if s.stopped {
+			s.mu.Unlock()
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		interval := s.interval
+		s.mu.Unlock()
+
+		got, err := s.session(ctx, interval, hist, ch)
+		cancel()
+
+		s.mu.Lock()
+		restart := s.restart
+		s.restart = false
+		s.cancel = nil
+		s.mu.Unlock()
+		if restart {
+			continue
+		}
+		if err != nil {
+			log.Printf("sstop: remote %s: %v", s.target, err)
+		}
+		if got {
+			backoff = s.backoff.min
+		}
+		select {
+		case <-s.done:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, s.backoff.max)
+	}
+}
+
+// session runs one remote session until it ends, forwarding snapshots. It
+// reports whether any arrived, and why the session ended.
+func (s *Source) session(ctx context.Context, interval time.Duration, hist *histories, ch chan<- model.Snapshot) (got bool, err error) {
+	stream, err := s.connect(ctx, interval)
+	if err != nil {
+		return false, err
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(stream))
+	for {
+		var snap model.Snapshot
+		if err = dec.Decode(&snap); err != nil {
+			break
+		}
+		snap.Host = s.target
+		hist.fill(&snap)
+		got = true
+		select {
+		case ch <- snap:
+		case <-s.done:
+			stream.Close()
+			return got, nil
+		}
+	}
+	closeErr := stream.Close()
+	if err == io.EOF {
+		err = closeErr
+	}
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	return got, err
+}
+
+// histories rebuilds the per-process rate history the JSON stream leaves
+// out, so process sparklines work as they do locally.
+type histories struct {
+	byPID map[uint32]*collector.RingBuffer
+}
+
+func newHistories() *histories {
+	return &histories{byPID: make(map[uint32]*collector.RingBuffer)}
+}
+
+func (h *histories) fill(snap *model.Snapshot) {
+	seen := make(map[uint32]bool, len(snap.Processes))
+	for i := range snap.Processes {
+		p := &snap.Processes[i]
+		seen[p.PID] = true
+		r, ok := h.byPID[p.PID]
+		if !ok {
+			r = collector.NewRingBuffer()
+			h.byPID[p.PID] = r
+		}
+		r.Push(p.UpRate + p.DownRate)
+		p.RateHistory = r.Samples()
+	}
+	for pid := range h.byPID {
+		if !seen[pid] {
+			delete(h.byPID, pid)
+		}
+	}
+}
+
+// tail keeps the last line written to it: ssh's or the remote sstop's
+// reason for exiting.
+type tail struct {
+	mu   sync.Mutex
+	last string
+}
+
+func (t *tail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, line := range strings.Split(string(p), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			t.last = line
+		}
+	}
+	return len(p), nil
+}
+
+func (t *tail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.last
+}
@@ -0,0 +1,76 @@
+package remote
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRemote makes s run a shell script instead of ssh, recording the
+// interval of every session.
+func fakeRemote(s *Source, script string) *[]time.Duration {
+	var mu sync.Mutex
+	var dials []time.Duration
+	s.backoff.min, s.backoff.max = time.Millisecond, 10*time.Millisecond
+	s.dial = func(ctx context.Context, interval time.Duration) *exec.Cmd {
+		mu.Lock()
+		dials = append(dials, interval)
+		mu.Unlock()
+		return exec.CommandContext(ctx, "sh", "-c", script)
+	}
+	return &dials
+}
+
+func TestSourceReconnects(t *testing.T) {
+	s := New("host", time.Second)
+	dials := fakeRemote(s, `
+echo '{"timestamp":"2026-01-02T03:04:05Z","processes":[{"pid":7,"name":"nginx","up_rate":100,"down_rate":50}]}'
+echo 'lost connection' >&2
+exit 255`)
+	ch := s.Start()
+	defer s.Stop()
+
+	for i := 1; i <= 2; i++ {
+		select {
+		case snap := <-ch:
+			if len(snap.Processes) != 1 || snap.Processes[0].Name != "nginx" {
+				t.Fatalf("snapshot %d = %+v", i, snap)
+			}
+			// Rate history is rebuilt locally, across reconnects
+			if h := snap.Processes[0].RateHistory; len(h) == 0 || h[len(h)-1] != 150 {
+				t.Errorf("snapshot %d rate history = %v, want ending in 150", i, h)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no snapshot %d; a dropped session should reconnect", i)
+		}
+	}
+	s.Stop()
+	for range ch {
+	}
+	if len(*dials) < 2 {
+		t.Errorf("%d sessions, want a reconnect", len(*dials))
+	}
+}
+
+func TestSourceSetInterval(t *testing.T) {
+	s := New("host", time.Second)
+	dials := fakeRemote(s, `
+echo '{"timestamp":"2026-01-02T03:04:05Z","processes":[]}'
+exec sleep 10`)
+	ch := s.Start()
+	<-ch
+	s.SetInterval(250 * time.Millisecond)
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no snapshot after changing the interval")
+	}
+	s.Stop()
+	for range ch {
+	}
+	if got := *dials; len(got) < 2 || got[1] != 250*time.Millisecond {
+		t.Errorf("session intervals = %v, want a restart at 250ms", got)
+	}
+}
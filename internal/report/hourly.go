@@ -0,0 +1,129 @@
+// Package report builds offline traffic reports (sstop report, sstop rec
+// graph) from a history database or a recording.
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// maxSampleGap caps the interval a recorded snapshot's rates are credited
+// over, as history does for --db, so gaps in a recording don't book
+// traffic at the last rate.
+const maxSampleGap = time.Minute
+
+// Bytes is the traffic of one process name.
+type Bytes struct {
+	Name string
+	Up   uint64
+	Down uint64
+}
+
+// Total returns up + down.
+func (b Bytes) Total() uint64 {
+	return b.Up + b.Down
+}
+
+// Hourly is traffic per process name by local hour of day, summed over
+// every day covered, answering "what runs overnight".
+type Hourly struct {
+	hours [24]map[string]*Bytes
+
+	// First and Last bound the traffic added; zero while empty.
+	First, Last time.Time
+}
+
+// NewHourly returns an empty report.
+func NewHourly() *Hourly {
+	h := &Hourly{}
+	for i := range h.hours {
+		h.hours[i] = make(map[string]*Bytes)
+	}
+	return h
+}
+
+// Add books traffic for a process name at time t (local hour).
+func (h *Hourly) Add(t time.Time, name string, up, down uint64) {
+	if up == 0 && down == 0 {
+		return
+	}
+	t = t.Local()
+	b, ok := h.hours[t.Hour()][name]
+	if !ok {
+		b = &Bytes{Name: name}
+		h.hours[t.Hour()][name] = b
+	}
+	b.Up += up
+	b.Down += down
+	if h.First.IsZero() || t.Before(h.First) {
+		h.First = t
+	}
+	if t.After(h.Last) {
+		h.Last = t
+	}
+}
+
+// AddSnapshots books recorded snapshots from since on (zero = all). Each
+// snapshot credits its rates over the time since the previous one, so the
+// first only sets the baseline.
+func (h *Hourly) AddSnapshots(snaps []model.Snapshot, since time.Time) {
+	var prev time.Time
+	for i := range snaps {
+		s := &snaps[i]
+		ts := s.Timestamp
+		if prev.IsZero() || !ts.After(prev) || ts.Before(since) {
+			prev = ts
+			continue
+		}
+		secs := min(ts.Sub(prev), maxSampleGap).Seconds()
+		prev = ts
+		for j := range s.Processes {
+			p := &s.Processes[j]
+			h.Add(ts, p.Name, uint64(p.UpRate*secs), uint64(p.DownRate*secs))
+		}
+	}
+}
+
+// Empty reports whether no traffic was added.
+func (h *Hourly) Empty() bool {
+	return h.First.IsZero()
+}
+
+// Hour returns the total for an hour of day (0-23) and its processes,
+// heaviest first.
+func (h *Hourly) Hour(hour int) (total Bytes, procs []Bytes) {
+	for _, b := range h.hours[hour] {
+		total.Up += b.Up
+		total.Down += b.Down
+		procs = append(procs, *b)
+	}
+	sort.Slice(procs, func(i, j int) bool {
+		if procs[i].Total() != procs[j].Total() {
+			return procs[i].Total() > procs[j].Total()
+		}
+		return procs[i].Name < procs[j].Name
+	})
+	return total, procs
+}
+
+// WriteCSV writes one row per hour and process: hour,process,bytes_up,bytes_down.
+func (h *Hourly) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"hour", "process", "bytes_up", "bytes_down"})
+	for hour := range h.hours {
+		_, procs := h.Hour(hour)
+		for _, p := range procs {
+			cw.Write([]string{
+				strconv.Itoa(hour), p.Name,
+				strconv.FormatUint(p.Up, 10), strconv.FormatUint(p.Down, 10),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
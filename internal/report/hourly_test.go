@@ -0,0 +1,67 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestHourlyAddSnapshots(t *testing.T) {
+	t0 := time.Date(2026, 10, 16, 2, 59, 58, 0, time.Local)
+	snap := func(d time.Duration, up, down float64) model.Snapshot {
+		return model.Snapshot{Timestamp: t0.Add(d), Processes: []model.ProcessSummary{
+			{PID: 1, Name: "backupd", UpRate: up, DownRate: down},
+			{PID: 2, Name: "idle"},
+		}}
+	}
+	h := NewHourly()
+	h.AddSnapshots([]model.Snapshot{
+		snap(0, 0, 1000),                      // baseline only
+		snap(time.Second, 10, 1000),           // 02:59:59: 1s
+		snap(2*time.Second, 10, 1000),         // 03:00:00: 1s
+		snap(2*time.Second+time.Hour, 0, 100), // 04:00:00: gap capped at a minute
+	}, time.Time{})
+
+	total, procs := h.Hour(2)
+	if total.Up != 10 || total.Down != 1000 || len(procs) != 1 {
+		t.Errorf("02:00 = %+v %+v, want 10 up, 1000 down, backupd only", total, procs)
+	}
+	if total, _ := h.Hour(3); total.Down != 1000 {
+		t.Errorf("03:00 down = %d, want 1000", total.Down)
+	}
+	if total, _ := h.Hour(4); total.Down != 6000 {
+		t.Errorf("04:00 down = %d, want 6000 (60s cap)", total.Down)
+	}
+	if !h.First.Equal(t0.Add(time.Second)) || !h.Last.Equal(t0.Add(2*time.Second+time.Hour)) {
+		t.Errorf("span = %v – %v", h.First, h.Last)
+	}
+
+	// --since drops earlier snapshots
+	h = NewHourly()
+	h.AddSnapshots([]model.Snapshot{snap(0, 0, 1), snap(time.Second, 0, 1)}, t0.Add(time.Hour))
+	if !h.Empty() {
+		t.Error("snapshots before since were counted")
+	}
+}
+
+func TestHourlyWriteCSV(t *testing.T) {
+	h := NewHourly()
+	at := time.Date(2026, 10, 16, 23, 30, 0, 0, time.Local)
+	h.Add(at, "firefox", 5, 50)
+	h.Add(at, "rsync, nightly", 0, 900)
+	h.Add(at.Add(-22*time.Hour), "firefox", 1, 2)
+
+	var b strings.Builder
+	if err := h.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "hour,process,bytes_up,bytes_down\n" +
+		"1,firefox,1,2\n" +
+		"23,\"rsync, nightly\",0,900\n" +
+		"23,firefox,5,50\n"
+	if b.String() != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,39 @@
+package report
+
+import (
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// RatePoint is a rate sample of a recording.
+type RatePoint struct {
+	At       time.Time
+	Up, Down float64 // bytes/s
+}
+
+// Rates returns, for each snapshot, the summed rates of the processes
+// match accepts (all of a name's PIDs, say), or the machine's total when
+// match is nil. A snapshot in which none is running counts as zero; found
+// is false when none ever was.
+func Rates(snaps []model.Snapshot, match func(*model.ProcessSummary) bool) (points []RatePoint, found bool) {
+	points = make([]RatePoint, 0, len(snaps))
+	for i := range snaps {
+		s := &snaps[i]
+		pt := RatePoint{At: s.Timestamp}
+		if match == nil {
+			pt.Up, pt.Down = s.TotalUp, s.TotalDown
+			found = true
+		} else {
+			for j := range s.Processes {
+				if p := &s.Processes[j]; match(p) {
+					pt.Up += p.UpRate
+					pt.Down += p.DownRate
+					found = true
+				}
+			}
+		}
+		points = append(points, pt)
+	}
+	return points, found
+}
@@ -0,0 +1,40 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestRates(t *testing.T) {
+	t0 := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	snaps := []model.Snapshot{
+		{Timestamp: t0, TotalUp: 30, TotalDown: 300, Processes: []model.ProcessSummary{
+			{PID: 1, Name: "nginx", UpRate: 10, DownRate: 100},
+			{PID: 2, Name: "nginx", UpRate: 20, DownRate: 200},
+		}},
+		{Timestamp: t0.Add(time.Second), TotalUp: 5, TotalDown: 50, Processes: []model.ProcessSummary{
+			{PID: 3, Name: "curl", UpRate: 5, DownRate: 50},
+		}},
+	}
+	nginx := func(p *model.ProcessSummary) bool { return p.Name == "nginx" }
+
+	points, found := Rates(snaps, nginx)
+	if !found || len(points) != 2 {
+		t.Fatalf("found %v, %d points", found, len(points))
+	}
+	if points[0].Up != 30 || points[0].Down != 300 || !points[0].At.Equal(t0) {
+		t.Errorf("first point %+v, want both workers summed", points[0])
+	}
+	if points[1].Up != 0 || points[1].Down != 0 {
+		t.Errorf("second point %+v, want zero while not running", points[1])
+	}
+
+	if total, _ := Rates(snaps, nil); total[1].Down != 50 {
+		t.Errorf("total %+v, want the snapshot totals", total)
+	}
+	if _, found := Rates(snaps, func(p *model.ProcessSummary) bool { return p.Name == "redis" }); found {
+		t.Error("found a process that never ran")
+	}
+}
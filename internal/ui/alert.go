@@ -4,21 +4,93 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/config"
 	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/notify"
 )
 
+// flashStyle controls how a firing alert is highlighted in the header.
+type flashStyle int
+
+const (
+	flashSubtle flashStyle = iota // steady highlight (default)
+	flashNone                     // no highlight
+	flashStrong                   // highlight alternates every poll
+)
+
+// alertRule is a configured per-process threshold with its own
+// notification channels.
+type alertRule struct {
+	name      string
+	threshold float64 // bytes/sec
+	bell      bool
+	flash     bool
+	actions   notify.Channels
+	triggered map[model.ProcessID]bool // processes that have already triggered
+}
+
 // alertOverlay manages bandwidth threshold alerts.
 type alertOverlay struct {
 	active         bool
 	editing        bool
 	input          textinput.Model
-	threshold      float64 // bytes/sec, 0 = disabled
-	alertTriggered map[uint32]bool // PIDs that have already triggered bell
-	flashOn        bool // toggle for flash animation
+	threshold      float64                  // bytes/sec, 0 = disabled
+	alertTriggered map[model.ProcessID]bool // processes that have already triggered bell
+	flashOn        bool                     // toggle for flash animation
+	steady         bool                     // test mode: strong flash drawn steady
+
+	bellEnabled bool
+	flash       flashStyle
+	rules       []*alertRule      // from config, always active
+	egress      []*egressRule     // aggregate upload limits by destination
+	saturation  []*saturationRule // interface utilization limits
+	watch       *watcher          // watch mode; nil when off
+
+	log   []alertEvent // firings this session, oldest first, newest maxAlertLog
+	fired int          // events logged this session, uncapped
+
+	trigger  AlertTrigger  // nil: none
+	notifier AlertNotifier // nil: no alert actions configured
+}
+
+// AlertNotifier runs the config's alert actions (command, webhook,
+// desktop notification) for an alert that fired; see internal/notify.
+type AlertNotifier interface {
+	Notify(ev notify.Event, ch notify.Channels)
+}
+
+// alertChannels returns the alert actions a rule's notify list enables.
+func alertChannels(notifies func(channel string) bool) notify.Channels {
+	return notify.Channels{
+		Command: notifies(config.NotifyCommand),
+		Webhook: notifies(config.NotifyWebhook),
+		Desktop: notifies(config.NotifyDesktop),
+	}
+}
+
+// allChannels is what the A prompt's threshold notifies, like the bell.
+var allChannels = notify.Channels{Command: true, Webhook: true, Desktop: true}
+
+// AlertTrigger is called on the poll an alert fires, with the snapshot's
+// time. A note it returns (e.g. a file it started writing) is logged.
+type AlertTrigger func(at time.Time) string
+
+// maxAlertLog caps the alert log.
+const maxAlertLog = 1000
+
+// alertEvent is an alert firing in the alert log.
+type alertEvent struct {
+	at   time.Time
+	text string // what fired, e.g. "curl (pid 10) 12.0 MB/s > 1M/s"
+}
+
+func (e alertEvent) String() string {
+	return e.at.Format("2006-01-02 15:04:05") + "  " + e.text
 }
 
 func newAlertOverlay() alertOverlay {
@@ -28,10 +100,55 @@ func newAlertOverlay() alertOverlay {
 	ti.CharLimit = 16
 	return alertOverlay{
 		input:          ti,
-		alertTriggered: make(map[uint32]bool),
+		alertTriggered: make(map[model.ProcessID]bool),
+		bellEnabled:    true,
 	}
 }
 
+// configure applies alert settings from the config file.
+func (a *alertOverlay) configure(cfg config.Alerts) error {
+	a.bellEnabled = cfg.BellEnabled()
+	switch cfg.Flash {
+	case config.FlashNone:
+		a.flash = flashNone
+	case config.FlashStrong:
+		a.flash = flashStrong
+	default:
+		a.flash = flashSubtle
+	}
+
+	a.rules = nil
+	for i, r := range cfg.Rules {
+		threshold := parseSize(r.Threshold)
+		if threshold <= 0 {
+			return fmt.Errorf("alerts.rules[%d]: invalid threshold %q", i, r.Threshold)
+		}
+		a.rules = append(a.rules, &alertRule{
+			name:      r.Name,
+			threshold: threshold,
+			bell:      r.Notifies(config.NotifyBell),
+			flash:     r.Notifies(config.NotifyFlash),
+			actions:   alertChannels(r.Notifies),
+			triggered: make(map[model.ProcessID]bool),
+		})
+	}
+
+	a.egress = nil
+	for i, r := range cfg.Egress {
+		e, err := newEgressRule(r)
+		if err != nil {
+			return fmt.Errorf("alerts.egress[%d]: %w", i, err)
+		}
+		a.egress = append(a.egress, e)
+	}
+	a.saturation = nil
+	for _, r := range cfg.Interfaces {
+		a.saturation = append(a.saturation, newSaturationRule(r))
+	}
+	a.watch = newWatcher(cfg.Watch)
+	return nil
+}
+
 func (a *alertOverlay) open() {
 	a.active = true
 	a.editing = true
@@ -49,7 +166,7 @@ func (a *alertOverlay) close() {
 
 func (a *alertOverlay) disable() {
 	a.threshold = 0
-	a.alertTriggered = make(map[uint32]bool)
+	a.alertTriggered = make(map[model.ProcessID]bool)
 	a.close()
 }
 
@@ -65,47 +182,186 @@ func (a *alertOverlay) confirm() {
 		return
 	}
 	a.threshold = parsed
-	a.alertTriggered = make(map[uint32]bool)
+	a.alertTriggered = make(map[model.ProcessID]bool)
 	a.close()
 }
 
-// checkAlerts returns PIDs exceeding threshold and whether bell should ring.
-func (a *alertOverlay) checkAlerts(procs []model.ProcessSummary) (exceeding []uint32, bell bool) {
-	if a.threshold <= 0 {
-		return nil, false
+// checkAlerts returns PIDs exceeding any threshold and whether the bell
+// should ring, logging new crossings at now. It also advances the flash
+// animation.
+func (a *alertOverlay) checkAlerts(procs []model.ProcessSummary, now time.Time) (exceeding []uint32, bell bool) {
+	seen := make(map[uint32]bool)
+	collect := func(pids []uint32) {
+		for _, pid := range pids {
+			if !seen[pid] {
+				seen[pid] = true
+				exceeding = append(exceeding, pid)
+			}
+		}
 	}
 
-	for _, p := range procs {
+	fresh := false
+	if a.threshold > 0 {
+		pids, newly := evalThreshold(procs, a.threshold, a.alertTriggered)
+		collect(pids)
+		if len(newly) > 0 {
+			fresh = true
+			bell = true
+			a.logCrossings(procs, newly, "", a.threshold, allChannels, now)
+		}
+	}
+	for _, r := range a.rules {
+		pids, newly := evalThreshold(procs, r.threshold, r.triggered)
+		collect(pids)
+		if len(newly) > 0 {
+			fresh = true
+			bell = bell || r.bell
+			a.logCrossings(procs, newly, r.name, r.threshold, r.actions, now)
+		}
+	}
+
+	// Restart the flash on a new trigger so it is visible immediately
+	if fresh {
+		a.flashOn = true
+	} else {
+		a.flashOn = !a.flashOn
+	}
+
+	return exceeding, bell && a.bellEnabled
+}
+
+// checkEgress evaluates egress rules against the remote hosts aggregation
+// and reports whether the bell should ring.
+func (a *alertOverlay) checkEgress(hosts []model.RemoteHostSummary, now time.Time) (bell bool) {
+	for _, e := range a.egress {
+		if e.observe(hosts, now) {
+			text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(e.headerText()), "⚠"))
+			a.logEvent(now, "egress "+text)
+			ev := notify.Event{At: now, Rule: e.name, Text: "egress " + text}
+			if e.maxRate > 0 {
+				ev.Rate, ev.Threshold = e.current, e.maxRate
+			}
+			a.notify(ev, e.actions)
+			bell = bell || e.bell
+		}
+	}
+	return bell && a.bellEnabled
+}
+
+// checkInterfaces evaluates interface utilization rules against the
+// snapshot's interfaces and reports whether the bell should ring.
+func (a *alertOverlay) checkInterfaces(ifaces []model.InterfaceStats, now time.Time) (bell bool) {
+	for _, s := range a.saturation {
+		for _, e := range s.observe(ifaces, now) {
+			text := s.eventText(e)
+			a.logEvent(now, text)
+			a.notify(notify.Event{At: now, Rule: s.name, Name: e.iface,
+				Rate: e.rate, Threshold: e.threshold, Text: text}, s.actions)
+			bell = bell || s.bell
+		}
+	}
+	return bell && a.bellEnabled
+}
+
+// checkWatch runs watch mode over a snapshot, logging and acting on the
+// alerts it raises, and reports whether the bell should ring.
+func (a *alertOverlay) checkWatch(snap *model.Snapshot) (bell bool) {
+	if a.watch == nil {
+		return false
+	}
+	for _, e := range a.watch.observe(snap) {
+		a.logEvent(snap.Timestamp, e.text)
+		a.notify(notify.Event{At: snap.Timestamp, Rule: e.rule, PID: e.pid, Name: e.name, Text: e.text}, e.actions)
+		bell = bell || e.bell
+	}
+	return bell && a.bellEnabled
+}
+
+// logCrossings logs the processes in ids newly crossing threshold, for
+// the rule named rule ("" for the A prompt's threshold), and runs the
+// alert actions in ch.
+func (a *alertOverlay) logCrossings(procs []model.ProcessSummary, ids []model.ProcessID, rule string, threshold float64, ch notify.Channels, now time.Time) {
+	crossed := make(map[model.ProcessID]bool, len(ids))
+	for _, id := range ids {
+		crossed[id] = true
+	}
+	for i := range procs {
+		p := &procs[i]
+		if !crossed[p.ID()] {
+			continue
+		}
+		text := fmt.Sprintf("%s (pid %d) %s > %s/s", p.Name, p.PID, FormatRate(p.UpRate+p.DownRate), formatThreshold(threshold))
+		if rule != "" {
+			text = rule + ": " + text
+		}
+		a.logEvent(now, text)
+		a.notify(notify.Event{At: now, Rule: rule, PID: p.PID, Name: p.Name,
+			Rate: p.UpRate + p.DownRate, Threshold: threshold, Text: text}, ch)
+	}
+}
+
+// notify runs the alert actions in ch for ev, if any are configured.
+func (a *alertOverlay) notify(ev notify.Event, ch notify.Channels) {
+	if a.notifier != nil {
+		a.notifier.Notify(ev, ch)
+	}
+}
+
+func (a *alertOverlay) logEvent(at time.Time, text string) {
+	a.fired++
+	a.log = append(a.log, alertEvent{at: at, text: text})
+	if n := len(a.log) - maxAlertLog; n > 0 {
+		a.log = a.log[n:]
+	}
+}
+
+// runTrigger calls the trigger if alerts were logged since the fired count
+// was since, and logs its note.
+func (a *alertOverlay) runTrigger(since int, at time.Time) {
+	if a.trigger == nil || a.fired == since {
+		return
+	}
+	if note := a.trigger(at); note != "" {
+		a.logEvent(at, note)
+	}
+}
+
+// evalThreshold returns PIDs whose total rate exceeds threshold and the
+// processes of them that newly crossed it. triggered is updated in place;
+// it tracks processes, not PIDs, so a process reusing the PID of one that
+// was exceeding alerts afresh.
+func evalThreshold(procs []model.ProcessSummary, threshold float64, triggered map[model.ProcessID]bool) (exceeding []uint32, newly []model.ProcessID) {
+	activeSet := make(map[model.ProcessID]bool)
+	for i := range procs {
+		p := &procs[i]
 		total := p.UpRate + p.DownRate
-		if total > a.threshold {
+		if total > threshold {
+			id := p.ID()
 			exceeding = append(exceeding, p.PID)
-			if !a.alertTriggered[p.PID] {
-				a.alertTriggered[p.PID] = true
-				bell = true
+			activeSet[id] = true
+			if !triggered[id] {
+				triggered[id] = true
+				newly = append(newly, id)
 			}
 		}
 	}
 
-	// Clean up: remove triggered PIDs that are no longer exceeding
-	activeSet := make(map[uint32]bool)
-	for _, pid := range exceeding {
-		activeSet[pid] = true
-	}
-	for pid := range a.alertTriggered {
-		if !activeSet[pid] {
-			delete(a.alertTriggered, pid)
+	// Clean up: remove triggered processes that are no longer exceeding
+	for id := range triggered {
+		if !activeSet[id] {
+			delete(triggered, id)
 		}
 	}
 
-	return exceeding, bell
+	return exceeding, newly
 }
 
-// isExceeding returns true if the PID is currently exceeding threshold.
-func (a *alertOverlay) isExceeding(pid uint32) bool {
+// isExceeding returns true if p is currently exceeding threshold.
+func (a *alertOverlay) isExceeding(p *model.ProcessSummary) bool {
 	if a.threshold <= 0 {
 		return false
 	}
-	return a.alertTriggered[pid]
+	return a.alertTriggered[p.ID()]
 }
 
 func (a *alertOverlay) render(width, height int) string {
@@ -150,21 +406,73 @@ func (a *alertOverlay) update(msg tea.KeyMsg) tea.Cmd {
 	}
 }
 
-// alertHeaderText returns the alert indicator for the header.
+// alertHeaderText returns the styled alert indicator for the header.
 func (a *alertOverlay) alertHeaderText(procs []model.ProcessSummary) string {
-	if a.threshold <= 0 {
-		return ""
+	var parts []string
+	if a.threshold > 0 {
+		count := countExceeding(procs, a.threshold)
+		if count > 0 {
+			parts = append(parts, a.tagStyle(true).Render(fmt.Sprintf(" ⚠ %d > %s/s ", count, formatThreshold(a.threshold))))
+		} else {
+			parts = append(parts, a.tagStyle(false).Render(fmt.Sprintf(" ✓ < %s/s ", formatThreshold(a.threshold))))
+		}
 	}
+
+	// Configured rules only show while firing
+	for _, r := range a.rules {
+		count := countExceeding(procs, r.threshold)
+		if count == 0 {
+			continue
+		}
+		label := ""
+		if r.name != "" {
+			label = r.name + ": "
+		}
+		parts = append(parts, a.tagStyle(r.flash).Render(fmt.Sprintf(" ⚠ %s%d > %s/s ", label, count, formatThreshold(r.threshold))))
+	}
+
+	for _, e := range a.egress {
+		if e.firing {
+			parts = append(parts, a.tagStyle(e.flash).Render(e.headerText()))
+		}
+	}
+
+	for _, s := range a.saturation {
+		if tag := s.headerText(); tag != "" {
+			parts = append(parts, a.tagStyle(s.flash).Render(tag))
+		}
+	}
+
+	if a.watch != nil {
+		tags, flash := a.watch.headerTags()
+		for i, tag := range tags {
+			parts = append(parts, a.tagStyle(flash[i]).Render(tag))
+		}
+	}
+
+	return strings.Join(parts, "")
+}
+
+// tagStyle returns the header style for an alert tag. Tags whose rule does
+// not use the flash channel are drawn plain.
+func (a *alertOverlay) tagStyle(flash bool) lipgloss.Style {
+	if !flash || a.flash == flashNone {
+		return styleDetailLabel
+	}
+	if a.flash == flashStrong && a.flashOn && !a.steady {
+		return styleAlertFlash
+	}
+	return styleAlertTag
+}
+
+func countExceeding(procs []model.ProcessSummary, threshold float64) int {
 	count := 0
 	for _, p := range procs {
-		if p.UpRate+p.DownRate > a.threshold {
+		if p.UpRate+p.DownRate > threshold {
 			count++
 		}
 	}
-	if count > 0 {
-		return fmt.Sprintf(" ⚠ %d > %s/s ", count, formatThreshold(a.threshold))
-	}
-	return fmt.Sprintf(" ✓ < %s/s ", formatThreshold(a.threshold))
+	return count
 }
 
 func formatThreshold(t float64) string {
@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/config"
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/notify"
+)
+
+func alertProcs(rates ...float64) []model.ProcessSummary {
+	procs := make([]model.ProcessSummary, len(rates))
+	for i, r := range rates {
+		procs[i] = model.ProcessSummary{PID: uint32(i + 1), DownRate: r}
+	}
+	return procs
+}
+
+func TestAlertBellOncePerCrossing(t *testing.T) {
+	a := newAlertOverlay()
+	a.threshold = 1000
+
+	if _, bell := a.checkAlerts(alertProcs(2000), time.Now()); !bell {
+		t.Error("first crossing should ring the bell")
+	}
+	if _, bell := a.checkAlerts(alertProcs(2000), time.Now()); bell {
+		t.Error("sustained crossing should not ring again")
+	}
+	a.checkAlerts(alertProcs(10), time.Now())
+	if _, bell := a.checkAlerts(alertProcs(2000), time.Now()); !bell {
+		t.Error("re-crossing should ring again")
+	}
+}
+
+func TestAlertPIDReuse(t *testing.T) {
+	a := newAlertOverlay()
+	a.threshold = 1000
+	procs := alertProcs(2000)
+	procs[0].StartTime = time.Unix(1000, 0)
+
+	if _, bell := a.checkAlerts(procs, time.Now()); !bell {
+		t.Error("first crossing should ring the bell")
+	}
+	// The PID is handed to another process that is over the threshold too
+	procs[0].StartTime = time.Unix(2000, 0)
+	if _, bell := a.checkAlerts(procs, time.Now()); !bell {
+		t.Error("a new process on the same PID should ring again")
+	}
+	if len(a.log) != 2 {
+		t.Errorf("log = %+v, want both processes' crossings", a.log)
+	}
+}
+
+func TestAlertBellDisabled(t *testing.T) {
+	no := false
+	a := newAlertOverlay()
+	if err := a.configure(config.Alerts{Bell: &no}); err != nil {
+		t.Fatal(err)
+	}
+	a.threshold = 1000
+	if _, bell := a.checkAlerts(alertProcs(2000), time.Now()); bell {
+		t.Error("bell disabled in config should never ring")
+	}
+}
+
+func TestAlertRuleChannels(t *testing.T) {
+	a := newAlertOverlay()
+	err := a.configure(config.Alerts{Rules: []config.AlertRule{
+		{Name: "quiet", Threshold: "1K", Notify: []string{config.NotifyFlash}},
+		{Name: "loud", Threshold: "1M"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exceeding, bell := a.checkAlerts(alertProcs(2048), time.Now())
+	if len(exceeding) != 1 {
+		t.Errorf("expected 1 exceeding PID, got %v", exceeding)
+	}
+	if bell {
+		t.Error("flash-only rule should not ring the bell")
+	}
+	if _, bell := a.checkAlerts(alertProcs(2048, 2<<20), time.Now()); !bell {
+		t.Error("rule with default channels should ring the bell")
+	}
+
+	text := a.alertHeaderText(alertProcs(2048, 2<<20))
+	if !strings.Contains(text, "quiet: 2 > 1K/s") || !strings.Contains(text, "loud: 1 > 1M/s") {
+		t.Errorf("header text missing rule tags: %q", text)
+	}
+	if got := a.alertHeaderText(alertProcs(10)); got != "" {
+		t.Errorf("rules that are not firing should be hidden, got %q", got)
+	}
+}
+
+func TestAlertConfigureInvalidThreshold(t *testing.T) {
+	a := newAlertOverlay()
+	err := a.configure(config.Alerts{Rules: []config.AlertRule{{Threshold: "lots"}}})
+	if err == nil {
+		t.Error("expected error for invalid threshold")
+	}
+}
+
+func TestAlertFlashStyles(t *testing.T) {
+	a := newAlertOverlay()
+
+	a.flash = flashSubtle
+	a.flashOn = true
+	if a.tagStyle(true).GetBackground() != styleAlertTag.GetBackground() {
+		t.Error("subtle flash should use the steady alert style")
+	}
+
+	a.flash = flashStrong
+	on := a.tagStyle(true)
+	a.flashOn = false
+	off := a.tagStyle(true)
+	if on.GetBackground() == off.GetBackground() {
+		t.Error("strong flash should alternate between phases")
+	}
+
+	a.flash = flashNone
+	a.flashOn = true
+	if a.tagStyle(true).GetForeground() != styleDetailLabel.GetForeground() {
+		t.Error("flash none should draw the tag plain")
+	}
+}
+
+func TestAlertTrigger(t *testing.T) {
+	var calls []time.Time
+	m := New(nil)
+	m.SetAlertTrigger(func(at time.Time) string {
+		calls = append(calls, at)
+		return "recording the alert to x.ssrec"
+	})
+	m.alert.threshold = 1000
+	t0 := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	poll := func(at time.Time, rates ...float64) {
+		next, _ := m.Update(SnapshotMsg(model.Snapshot{Timestamp: at, Processes: alertProcs(rates...)}))
+		m = next.(Model)
+	}
+
+	poll(t0, 10)
+	poll(t0.Add(time.Second), 2000, 3000) // two crossings, one trigger
+	poll(t0.Add(2*time.Second), 2000, 3000)
+	if len(calls) != 1 || !calls[0].Equal(t0.Add(time.Second)) {
+		t.Fatalf("trigger calls = %v, want one at the crossing", calls)
+	}
+	log := m.AlertLog()
+	if len(log) != 3 || !strings.HasSuffix(log[2], "recording the alert to x.ssrec") {
+		t.Errorf("alert log = %q, want the 2 crossings and the note", log)
+	}
+}
+
+// fakeNotifier records the alert actions asked for.
+type fakeNotifier struct {
+	events   []notify.Event
+	channels []notify.Channels
+}
+
+func (f *fakeNotifier) Notify(ev notify.Event, ch notify.Channels) {
+	f.events = append(f.events, ev)
+	f.channels = append(f.channels, ch)
+}
+
+func TestAlertActions(t *testing.T) {
+	a := newAlertOverlay()
+	err := a.configure(config.Alerts{
+		Rules: []config.AlertRule{{Name: "hook", Threshold: "1K", Notify: []string{config.NotifyWebhook}}},
+		Egress: []config.EgressRule{
+			{Name: "de", Countries: []string{"DE"}, MaxRate: "1K", Notify: []string{config.NotifyDesktop, config.NotifyCommand}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeNotifier{}
+	a.notifier = f
+	a.threshold = 1500
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	procs := alertProcs(2048)
+	procs[0].Name = "curl"
+	a.checkAlerts(procs, now)
+	if len(f.events) != 2 {
+		t.Fatalf("events = %+v, want the prompt's threshold and the rule", f.events)
+	}
+	if ev := f.events[0]; ev.PID != 1 || ev.Name != "curl" || ev.Rate != 2048 || ev.Threshold != 1500 || ev.Rule != "" || f.channels[0] != allChannels {
+		t.Errorf("prompt alert = %+v via %+v", ev, f.channels[0])
+	}
+	if ev := f.events[1]; ev.Rule != "hook" || !strings.HasPrefix(ev.Text, "hook: curl") || f.channels[1] != (notify.Channels{Webhook: true}) {
+		t.Errorf("rule alert = %+v via %+v", ev, f.channels[1])
+	}
+
+	// Sustained: nothing new
+	a.checkAlerts(procs, now.Add(time.Second))
+	if len(f.events) != 2 {
+		t.Errorf("sustained crossing notified again: %+v", f.events[2:])
+	}
+
+	a.checkEgress(egressHosts(), now)
+	if len(f.events) != 3 {
+		t.Fatalf("egress did not notify: %+v", f.events)
+	}
+	if ev := f.events[2]; ev.Rule != "de" || ev.PID != 0 || ev.Rate != 2000 || f.channels[2] != (notify.Channels{Command: true, Desktop: true}) {
+		t.Errorf("egress alert = %+v via %+v", ev, f.channels[2])
+	}
+}
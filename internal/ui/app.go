@@ -9,8 +9,13 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/config"
+	"github.com/googlesky/sstop/internal/hostfmt"
 	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/notes"
+	"github.com/googlesky/sstop/internal/privacy"
 	"github.com/googlesky/sstop/internal/recorder"
+	"github.com/googlesky/sstop/internal/usage"
 )
 
 // ViewMode tracks which view is active.
@@ -22,6 +27,15 @@ const (
 	ViewRemoteHosts
 	ViewListenPorts
 	ViewGroups
+	ViewUsers
+	ViewUsage
+	ViewCountries
+	ViewGraph
+	ViewConnections
+	ViewInterfaces
+	ViewRateChart // full-screen rate chart of the detail view's process
+	ViewTreemap
+	ViewFlows // process → remote host Sankey diagram
 )
 
 // SnapshotMsg delivers a new snapshot to the UI.
@@ -30,11 +44,34 @@ type SnapshotMsg model.Snapshot
 // playbackEndedMsg signals that playback has finished.
 type playbackEndedMsg struct{}
 
+// refreshMsg requests an immediate collector poll.
+type refreshMsg struct{}
+
+// killRefreshDelay gives a signalled process time to exit before the
+// refresh that shows the result.
+const killRefreshDelay = 300 * time.Millisecond
+
 // IntervalSetter is implemented by the collector to allow dynamic interval changes.
 type IntervalSetter interface {
 	SetInterval(d time.Duration)
 }
 
+// Refresher is implemented by collectors that can poll on demand.
+type Refresher interface {
+	RefreshNow()
+}
+
+// SocketDestroyer is implemented by collectors that can close a single
+// socket of a process.
+type SocketDestroyer interface {
+	DestroySocket(pid uint32, c model.Connection) error
+}
+
+// ExitedDismisser is implemented by collectors that retain exited processes.
+type ExitedDismisser interface {
+	DismissExited(pids ...uint32)
+}
+
 // Preset refresh interval steps (sorted fastest→slowest)
 var intervalPresets = []time.Duration{
 	100 * time.Millisecond,
@@ -57,22 +94,69 @@ type Model struct {
 	table       processTable
 	detail      processDetail
 	remoteHosts remoteHostsView
+	countries   countriesView
+	graph       graphView
 	listenPorts listenPortsView
+	connections connectionsView
+	interfaces  interfacesView
+	treemap     treemapView
+	flows       flowView
 	groups      groupsView
+	users       usersView
+	usage       usageView
 
 	// Help overlay
 	showHelp bool
 
+	// Debug HUD (`): collection latency and snapshot gaps
+	hud debugHUD
+
 	// Kill process overlay
 	kill killOverlay
 
+	// Block/rate-limit overlay (B) and the firewall behind it, nil when
+	// not available
+	block    blockOverlay
+	firewall Firewall
+
+	// Per-process bandwidth limit overlay (L), through the firewall
+	limit limitOverlay
+
+	// Journal overlay of a systemd group (J in the Groups view), and the
+	// reader behind it, nil when not available
+	journal       journalOverlay
+	journalReader JournalReader
+
+	// Packet capture of the detail view's process
+	capture captureState
+
+	// Saving the process table's marked processes (e)
+	export exportState
+
 	// Alert overlay
 	alert alertOverlay
 
+	// Operator notes on PIDs / process names, and the overlay editing them
+	note  noteOverlay
+	notes *notes.Store
+
+	// Views slowed to their own refresh interval ([ui.refresh])
+	held map[ViewMode]*heldSnapshot
+
+	// Config [[groups]] rules naming groups of processes
+	groupRules []groupRule
+
 	// Search
 	searching   bool
 	searchInput textinput.Model
 
+	// Saved filters (1–9, F picker), how to persist new ones, and the
+	// filters confirmed this session (up/down while searching)
+	savedFilters  []config.SavedFilter
+	filterSaver   FilterSaver
+	filters       filterPicker
+	filterHistory filterHistory
+
 	// Pause
 	paused         bool
 	pausedSnapshot model.Snapshot
@@ -85,6 +169,37 @@ type Model struct {
 	ifaceIdx    int      // -1 = all, 0..N = specific interface
 	activeIface string   // "" = all
 
+	// Interface the process table is limited to, picked in the interfaces
+	// view: "" = all
+	ifaceFilter string
+
+	// Network namespace shown in the process table (--netns): "" = all,
+	// netnsHost or a namespace name
+	activeNetNS string
+
+	// Privacy mode: mask IPs, hostnames and cmdlines with stable pseudonyms
+	privacyOn bool
+	masker    *privacy.Masker
+
+	// Mouse capture; off leaves the terminal's own select/copy working
+	mouseOn bool
+
+	// How remote hosts are named in every view: full name, first label or
+	// address (d cycles)
+	hostNames hostfmt.Style
+
+	// Terminal size as last reported; width/height follow it once a
+	// resize settles (resizeSeq identifies the latest)
+	termWidth, termHeight int
+	resizeSeq             int
+
+	// Persisted day/week/month totals (nil when tracking is off)
+	usageStore *usage.Store
+
+	// Layout presets (F1–F12)
+	presets      []layoutPreset
+	activePreset string // name of the last applied preset
+
 	// Refresh interval
 	intervalIdx int            // index into intervalPresets
 	collector   IntervalSetter // callback to change collector interval
@@ -92,6 +207,21 @@ type Model struct {
 	// Snapshot channel (for tea.Cmd polling)
 	snapCh <-chan model.Snapshot
 
+	// Source health: when the last snapshot arrived, and how long the
+	// source has been silent once that exceeds a few intervals (0 = fresh)
+	lastSnapAt time.Time
+	testMode   bool // SetTestMode: no bell, clock or ticking
+	staleAge   time.Duration
+
+	// Host streaming the snapshots (--remote); "" = this machine
+	remoteHost string
+
+	// Multi-host dashboard (sstop connect): the hosts in order, the latest
+	// snapshot of each, and the one on screen. hosts is nil otherwise.
+	hosts      []string
+	hostSnaps  map[string]hostSnap
+	activeHost string
+
 	// Playback mode
 	player       *recorder.Player
 	playbackFile string // non-empty when in playback mode
@@ -100,6 +230,7 @@ type Model struct {
 
 // New creates a new UI model.
 func New(snapCh <-chan model.Snapshot) Model {
+	sessionNotes, _ := notes.Open("") // in memory; SetNotes persists them
 	ti := textinput.New()
 	ti.Prompt = "/"
 	ti.CharLimit = 64
@@ -108,11 +239,21 @@ func New(snapCh <-chan model.Snapshot) Model {
 		table:       newProcessTable(),
 		remoteHosts: newRemoteHostsView(),
 		listenPorts: newListenPortsView(),
+		connections: newConnectionsView(),
 		alert:       newAlertOverlay(),
+		note:        newNoteOverlay(),
+		kill:        newKillOverlay(),
+		block:       newBlockOverlay(),
+		limit:       newLimitOverlay(),
+		filters:     newFilterPicker(),
+		notes:       sessionNotes,
+		presets:     builtinPresets,
+		masker:      privacy.New(),
 		searchInput: ti,
 		snapCh:      snapCh,
 		ifaceIdx:    -1, // all interfaces
 		intervalIdx: 3,  // default 1s (index into intervalPresets)
+		mouseOn:     true,
 	}
 }
 
@@ -121,6 +262,111 @@ func (m *Model) SetCollector(c IntervalSetter) {
 	m.collector = c
 }
 
+// SetUsage sets the store behind the day/week/month usage view.
+func (m *Model) SetUsage(s *usage.Store) {
+	m.usageStore = s
+}
+
+// SetNotes sets the store behind process notes (n / N).
+func (m *Model) SetNotes(s *notes.Store) {
+	m.notes = s
+}
+
+// SetAlertConfig applies bell, flash and rule settings from the config file.
+func (m *Model) SetAlertConfig(cfg config.Alerts) error {
+	return m.alert.configure(cfg)
+}
+
+// SetFirewall sets the firewall behind the block overlay (B).
+func (m *Model) SetFirewall(f Firewall) {
+	m.firewall = f
+}
+
+// SetGroupRules sets the config's [[groups]] rules, which put the
+// processes they match in named groups.
+func (m *Model) SetGroupRules(cfg []config.GroupRule) error {
+	rules, err := newGroupRules(cfg)
+	if err != nil {
+		return err
+	}
+	m.groupRules = rules
+	return nil
+}
+
+// SetAlertTrigger sets a function to call when an alert fires, e.g. to
+// record the minutes around it (--record-on-alert).
+func (m *Model) SetAlertTrigger(f AlertTrigger) {
+	m.alert.trigger = f
+}
+
+// SetAlertNotifier sets what runs the config's alert actions (command,
+// webhook, desktop notification) when an alert fires.
+func (m *Model) SetAlertNotifier(n AlertNotifier) {
+	m.alert.notifier = n
+}
+
+// SetMasker shares a privacy masker with other consumers (e.g. a masked
+// recording), so pseudonyms match between them.
+func (m *Model) SetMasker(mk *privacy.Masker) {
+	m.masker = mk
+}
+
+// SetPrivacy turns privacy mode on or off.
+func (m *Model) SetPrivacy(on bool) {
+	m.privacyOn = on
+	m.releaseHeld() // slowed views follow the change with the others
+	if on {
+		// Mask what is on screen now rather than waiting for the next poll
+		m.snapshot = m.masker.Apply(m.snapshot)
+		m.pausedSnapshot = m.masker.Apply(m.pausedSnapshot)
+		m.table.update(m.tableRows())
+	}
+}
+
+// SetMouse records whether the program starts with mouse capture
+// (--no-mouse), so M toggles from the right state.
+func (m *Model) SetMouse(on bool) {
+	m.mouseOn = on
+}
+
+// SetHostStyle sets how remote hosts are named (--host-format, [ui] hosts).
+func (m *Model) SetHostStyle(s hostfmt.Style) {
+	m.hostNames = s
+	m.table.hosts = s
+}
+
+// SetHeat shades the process table's rate cells by rate instead of drawing
+// bandwidth bars (--heat, [ui] heat).
+func (m *Model) SetHeat(on bool) {
+	m.table.heat = on
+}
+
+// SetColumns sets the process table columns and their order ([ui]
+// columns); nil keeps the default. Listing "dest" shows TOP DEST from the
+// start.
+func (m *Model) SetColumns(names []string) error {
+	if len(names) == 0 {
+		m.table.columns = nil
+		return nil
+	}
+	cols, err := parseColumns(names)
+	if err != nil {
+		return err
+	}
+	m.table.columns = cols
+	for _, c := range cols {
+		if c == columnDest {
+			m.table.showTopDest = true
+		}
+	}
+	return nil
+}
+
+// SetRemote names the host whose snapshots are shown (--remote).
+func (m *Model) SetRemote(host string) {
+	m.remoteHost = host
+}
+
 // SetPlayback configures playback mode with the given player and filename.
 func (m *Model) SetPlayback(p *recorder.Player, filename string) {
 	m.player = p
@@ -148,7 +394,13 @@ func WaitForSnapshot(ch <-chan model.Snapshot) tea.Cmd {
 }
 
 func (m Model) Init() tea.Cmd {
-	return m.waitForNextSnapshot()
+	if m.player != nil {
+		return m.waitForNextSnapshot()
+	}
+	if m.testMode {
+		return m.waitForNextSnapshot()
+	}
+	return tea.Batch(m.waitForNextSnapshot(), staleTick())
 }
 
 // waitForNextSnapshot returns the appropriate Cmd for waiting on the next snapshot.
@@ -179,41 +431,70 @@ func waitForPlaybackSnapshot(ch <-chan model.Snapshot, p *recorder.Player) tea.C
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		m.height = msg.Height
+		return m.resize(msg)
+
+	case resizeSettledMsg:
+		return m.settleResize(msg)
+
+	case refreshMsg:
+		m.refreshNow()
 		return m, nil
 
+	case staleTickMsg:
+		if m.testMode {
+			return m, nil
+		}
+		m.staleAge = m.staleAgeAt(time.Time(msg))
+		return m, staleTick()
+
 	case SnapshotMsg:
 		snap := model.Snapshot(msg)
+		if !m.keepHostSnap(snap) {
+			// Another host of the fleet; shown when selected
+			return m, m.waitForNextSnapshot()
+		}
+		m.lastSnapAt, m.staleAge = time.Now(), 0
+		m.hud.observe(snap)
+		if m.privacyOn {
+			snap = m.masker.Apply(snap)
+		}
 		snap.ActiveIface = m.activeIface
+		annotateNotes(&snap, m.notes)
+		annotateTags(&snap, m.groupRules)
 
 		// Update available interfaces list
 		m.updateIfaceList(snap.Interfaces)
 
 		if !m.paused {
 			m.snapshot = snap
-			m.table.update(m.snapshot.Processes)
+			m.holdSnapshots(snap)
+			m.table.idle.observe(snap.Processes)
+			m.table.update(m.tableRows())
 
 			// Check alerts
-			_, bell := m.alert.checkAlerts(m.snapshot.Processes)
-			if bell {
-				m.alert.flashOn = true
+			fired := m.alert.fired
+			_, bell := m.alert.checkAlerts(m.snapshot.Processes, m.snapshot.Timestamp)
+			if m.alert.checkEgress(m.snapshot.RemoteHosts, m.snapshot.Timestamp) {
+				bell = true
+			}
+			if m.alert.checkInterfaces(m.snapshot.Interfaces, m.snapshot.Timestamp) {
+				bell = true
+			}
+			if m.alert.checkWatch(&m.snapshot) {
+				bell = true
+			}
+			m.alert.runTrigger(fired, m.snapshot.Timestamp)
+			if bell && !m.testMode {
 				// Terminal bell
 				fmt.Fprint(os.Stderr, "\a")
-			} else {
-				m.alert.flashOn = !m.alert.flashOn // toggle flash
 			}
 
 			// If in detail view, check process still exists
-			if m.mode == ViewProcessDetail {
-				found := false
-				for _, p := range m.snapshot.Processes {
-					if p.PID == m.detail.pid {
-						found = true
-						break
-					}
-				}
-				if !found {
+			if m.viewingProcess() {
+				if proc := m.findProcess(m.detail.pid); proc != nil && m.detail.follows(proc) {
+					m.detail.recordHistory(proc)
+					m.detail.recordRates(proc, snap.Timestamp)
+				} else {
 					m.mode = ViewProcessTable
 				}
 			}
@@ -221,6 +502,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		return m, m.waitForNextSnapshot()
 
+	case journalMsg:
+		m.journal.receive(msg)
+		return m, nil
+
 	case playbackEndedMsg:
 		// Playback finished — pause UI so user can review last frame
 		m.paused = true
@@ -267,25 +552,45 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	}
 
+	// Note overlay — intercept all keys while editing
+	if m.note.active {
+		cmd, saved := m.note.update(msg, m.notes)
+		if saved {
+			annotateNotes(&m.snapshot, m.notes)
+			annotateNotes(&m.pausedSnapshot, m.notes)
+			m.table.update(m.tableRows())
+		}
+		return m, cmd
+	}
+
 	// Kill overlay — intercept all keys when active
 	if m.kill.active {
-		if m.kill.showResult {
-			// Any key closes the result
-			m.kill.close()
-			return m, nil
-		}
-		action := matchKey(msg)
-		switch action {
-		case keyUp:
-			m.kill.moveUp()
-		case keyDown:
-			m.kill.moveDown()
-		case keyEnter:
-			m.kill.sendSignal()
-		case keyEsc:
-			m.kill.close()
+		d, _ := m.collector.(SocketDestroyer)
+		done, cmd := m.kill.update(msg, d)
+		if done {
+			return m, tea.Tick(killRefreshDelay, func(time.Time) tea.Msg { return refreshMsg{} })
 		}
-		return m, nil
+		return m, cmd
+	}
+
+	// Block overlay — intercept all keys when active
+	if m.block.active {
+		return m, m.block.update(msg, m.firewall)
+	}
+
+	// Limit overlay — intercept all keys when active
+	if m.limit.active {
+		return m, m.limit.update(msg, m.firewall)
+	}
+
+	// Journal overlay — intercept all keys when active
+	if m.journal.active {
+		return m, m.journal.update(msg, journalRows(m.height), m.journalReader)
+	}
+
+	// Saved filter picker — intercept all keys when active
+	if m.filters.active {
+		return m, m.updateFilterPicker(msg)
 	}
 
 	// Help overlay — ? toggles, any key closes
@@ -301,25 +606,58 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.searching = false
 			if msg.String() == "esc" {
 				m.searchInput.SetValue("")
-				m.table.filter = ""
-				m.table.applyFilterAndSort()
-			} else {
-				m.table.filter = m.searchInput.Value()
-				m.table.applyFilterAndSort()
 			}
+			m.setViewFilter(m.searchInput.Value())
+			m.filterHistory.push(m.searchInput.Value())
 			m.searchInput.Blur()
 			return m, nil
+		case "up", "down":
+			var f string
+			var ok bool
+			if msg.String() == "up" {
+				f, ok = m.filterHistory.prev(m.searchInput.Value())
+			} else {
+				f, ok = m.filterHistory.next()
+			}
+			if ok {
+				m.searchInput.SetValue(f)
+				m.searchInput.CursorEnd()
+				m.setViewFilter(f)
+			}
+			return m, nil
 		default:
 			var cmd tea.Cmd
 			m.searchInput, cmd = m.searchInput.Update(msg)
-			m.table.filter = m.searchInput.Value()
-			m.table.applyFilterAndSort()
+			m.setViewFilter(m.searchInput.Value())
 			return m, cmd
 		}
 	}
 
 	action := matchKey(msg)
 
+	// Tree mode: left/h collapse the selected subtree, right/l expand it.
+	// During playback the arrows keep changing the speed.
+	if m.mode == ViewProcessTable && m.table.treeMode {
+		switch key := msg.String(); {
+		case key == "h" || key == "left" && m.player == nil:
+			m.table.collapseSelected()
+			return m, nil
+		case key == "l" || key == "right" && m.player == nil:
+			m.table.expandSelected()
+			return m, nil
+		}
+	}
+
+	// Select mode: space and m mark rows instead of pausing and opening
+	// the treemap
+	if m.mode == ViewProcessTable && m.table.marks.active {
+		switch msg.String() {
+		case " ", "m":
+			m.table.toggleMark()
+			return m, nil
+		}
+	}
+
 	// Global actions (work in any mode)
 	switch action {
 	case keyHelp:
@@ -337,6 +675,14 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case keyNextIface:
 		m.cycleInterface()
 		return m, nil
+	case keyRefresh:
+		// r toggles TCP stats in the detail view; ctrl+r refreshes there
+		if m.mode == ViewProcessDetail && msg.String() == "r" {
+			action = keyTCPInfo
+			break
+		}
+		m.refreshNow()
+		return m, nil
 	case keyIntervalUp:
 		m.changeInterval(-1) // faster = lower index
 		return m, nil
@@ -346,12 +692,48 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case keyCumulative:
 		m.cumulativeMode = !m.cumulativeMode
 		m.table.cumulativeMode = m.cumulativeMode
-		m.table.applyFilterAndSort()
+		m.table.update(m.tableRows())
 		return m, nil
 	case keyTreeToggle:
 		m.table.treeMode = !m.table.treeMode
 		m.table.applyFilterAndSort()
 		return m, nil
+	case keyIdle:
+		m.table.idleMode = m.table.idleMode.next()
+		m.table.update(m.tableRows())
+		return m, nil
+	case keyPrivacy:
+		m.SetPrivacy(!m.privacyOn)
+		return m, nil
+	case keyNextHost:
+		m.cycleHost()
+		return m, nil
+	case keyHUD:
+		m.hud.on = !m.hud.on
+		return m, nil
+	case keyHostStyle:
+		m.SetHostStyle(m.hostNames.Next())
+		return m, nil
+	case keyFilterSlot:
+		if m.recallFilter(int(msg.String()[0] - '1')) {
+			return m, nil
+		}
+	case keyFilters:
+		if filter, ok := m.viewFilter(); ok {
+			m.filters.open(filter)
+			return m, nil
+		}
+	case keyMouse:
+		m.mouseOn = !m.mouseOn
+		if m.mouseOn {
+			return m, tea.EnableMouseCellMotion
+		}
+		return m, tea.DisableMouse
+	case keyPreset:
+		if i, ok := presetKeyIndex(msg.String()); ok {
+			m.applyPreset(i)
+		}
+		return m, nil
 	case keySetAlert:
 		if m.alert.threshold > 0 {
 			m.alert.disable()
@@ -371,6 +753,17 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if action == keySearch {
+		if filter, ok := m.viewFilter(); ok {
+			m.searching = true
+			m.filterHistory.reset()
+			m.searchInput.SetValue(filter)
+			m.searchInput.CursorEnd()
+			m.searchInput.Focus()
+			return m, m.searchInput.Cursor.BlinkCmd()
+		}
+	}
+
 	switch m.mode {
 	case ViewProcessTable:
 		switch action {
@@ -389,32 +782,96 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case keyEnd:
 			m.table.goEnd()
 		case keyEnter:
-			if sel := m.table.selected(); sel != nil {
+			if sel := m.table.selectedLive(); sel != nil {
 				m.mode = ViewProcessDetail
 				m.detail = newProcessDetail(sel.PID)
 			}
 		case keySortNext:
 			m.table.nextSort()
-		case keySearch:
-			m.searching = true
-			m.searchInput.Focus()
-			return m, m.searchInput.Cursor.BlinkCmd()
+		case keySortReverse:
+			m.table.reverseSort()
+		case keyDismiss:
+			if sel := m.table.selected(); sel != nil && !sel.ExitedAt.IsZero() {
+				m.dismissExited(sel.PID)
+			}
+		case keyDismissAll:
+			m.dismissExited()
+		case keyNote, keyNoteName:
+			if sel := m.table.selected(); sel != nil {
+				byName := action == keyNoteName
+				current := m.notes.PIDNote(sel.ID(), sel.Name)
+				if byName {
+					current = m.notes.NameNote(sel.Name)
+				}
+				m.note.open(sel.ID(), sel.Name, current, byName)
+				return m, m.note.input.Cursor.BlinkCmd()
+			}
 		case keyRemoteHosts:
 			m.mode = ViewRemoteHosts
-			m.remoteHosts.cursor = 0
-			m.remoteHosts.offset = 0
+			if m.remoteHosts.scope.active {
+				// Leaving a drill-down: the full list starts at the top
+				m.remoteHosts.cursor, m.remoteHosts.offset = 0, 0
+				m.remoteHosts.scope = hostScope{}
+			}
+		case keyCountries:
+			m.mode = ViewCountries
+		case keyGraphView:
+			m.mode = ViewGraph
 		case keyListenPorts:
 			m.mode = ViewListenPorts
-			m.listenPorts.cursor = 0
-			m.listenPorts.offset = 0
+		case keyConnections:
+			m.mode = ViewConnections
+			if m.connections.scope.active {
+				// Leaving a drill-down: the full list starts at the top
+				m.connections.cursor, m.connections.offset = 0, 0
+				m.connections.scope = connScope{}
+			}
+		case keyInterfaces:
+			m.mode = ViewInterfaces
+		case keyTreemap:
+			m.mode = ViewTreemap
+		case keyFlows:
+			m.mode = ViewFlows
 		case keyKillProcess:
-			if sel := m.table.selected(); sel != nil {
-				m.kill.open(sel.PID, sel.Name)
+			if m.table.marks.active {
+				m.killMarked()
+			} else if sel := m.table.selectedLive(); sel != nil {
+				m.kill.openProcess(sel)
+			}
+		case keySelect:
+			if m.table.marks.active {
+				m.table.stopMarking()
+			} else {
+				m.table.startMarking()
+			}
+			m.export.status = ""
+		case keyEsc:
+			if m.table.marks.active {
+				m.table.stopMarking()
+				m.export.status = ""
+			}
+		case keyExport:
+			m.exportMarked()
+		case keyOnlyMarked:
+			m.filterMarked()
+		case keyBaseline:
+			m.toggleBaseline()
+		case keyBlock:
+			m.openBlock(nil, "")
+		case keyLimit:
+			if sel := m.table.selectedLive(); sel != nil {
+				return m, m.limit.open(sel, m.firewall)
 			}
 		case keyGroupView:
 			m.mode = ViewGroups
-			m.groups.cursor = 0
-			m.groups.offset = 0
+		case keyUsersView:
+			m.mode = ViewUsers
+		case keyUsageView:
+			m.mode = ViewUsage
+		case keyTopDest:
+			m.table.showTopDest = !m.table.showTopDest
+		case keyNetNS:
+			m.cycleNetNS()
 		}
 
 	case ViewProcessDetail:
@@ -437,33 +894,177 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if proc != nil {
 				m.detail.pageDown(len(proc.Connections) - 1)
 			}
-		case keyToggleDNS:
-			m.detail.toggleDNS()
+		case keyTCPInfo:
+			m.detail.toggleTCP()
 		case keyKillProcess:
 			proc := m.findProcess(m.detail.pid)
 			if proc != nil {
-				m.kill.open(proc.PID, proc.Name)
+				m.kill.openProcess(proc)
+			}
+		case keyDismiss:
+			// x: close just the selected connection's socket
+			proc := m.findProcess(m.detail.pid)
+			if proc != nil && m.detail.cursor < len(proc.Connections) {
+				m.openSocket(proc, proc.Connections[m.detail.cursor])
+			}
+		case keyBlock:
+			proc := m.findProcess(m.detail.pid)
+			if proc != nil && m.detail.cursor < len(proc.Connections) {
+				c := proc.Connections[m.detail.cursor]
+				m.openBlock(c.DstIP, c.RemoteHost)
+			}
+		case keyLimit:
+			if proc := m.findProcess(m.detail.pid); proc != nil {
+				return m, m.limit.open(proc, m.firewall)
+			}
+		case keyCapture:
+			m.toggleCapture()
+		case keyRateChart:
+			m.mode = ViewRateChart
+		}
+
+	case ViewRateChart:
+		switch action {
+		case keyQuit:
+			return m, tea.Quit
+		case keyEsc, keyRateChart:
+			m.mode = ViewProcessDetail
+		case keyKillProcess:
+			if proc := m.findProcess(m.detail.pid); proc != nil {
+				m.kill.openProcess(proc)
 			}
 		}
 
+	case ViewTreemap:
+		items := m.treemapItems()
+		switch action {
+		case keyQuit:
+			return m, tea.Quit
+		case keyEsc, keyTreemap:
+			m.mode = ViewProcessTable
+		case keyUp:
+			m.treemap.moveUp()
+		case keyDown:
+			m.treemap.moveDown(len(items) - 1)
+		case keyHome:
+			m.treemap.cursor = 0
+		case keyEnd:
+			m.treemap.cursor = max(len(items)-1, 0)
+		case keySortNext:
+			m.treemap.nextGroup()
+		case keyEnter:
+			if m.treemap.cursor < len(items) {
+				m.openTreemapItem(items[m.treemap.cursor])
+			}
+		}
+
+	case ViewFlows:
+		procs := m.flowLayoutFor(m.contentHeight()).procs
+		switch action {
+		case keyQuit:
+			return m, tea.Quit
+		case keyEsc, keyFlows:
+			m.mode = ViewProcessTable
+		case keyUp:
+			m.flows.moveUp()
+		case keyDown:
+			m.flows.moveDown(len(procs) - 1)
+		case keyHome:
+			m.flows.cursor = 0
+		case keyEnd:
+			m.flows.cursor = max(len(procs)-1, 0)
+		case keyEnter:
+			m.openFlowProc(procs)
+		}
+
 	case ViewRemoteHosts:
+		hosts := m.remoteHostRows()
 		switch action {
 		case keyQuit:
 			return m, tea.Quit
 		case keyEsc:
-			m.mode = ViewProcessTable
+			if m.remoteHosts.scope.active {
+				// Back up the drill-down
+				m.remoteHosts.scope = hostScope{}
+				m.mode = ViewCountries
+			} else {
+				m.mode = ViewProcessTable
+			}
 		case keyUp:
 			m.remoteHosts.moveUp()
 		case keyDown:
-			m.remoteHosts.moveDown(len(m.snapshot.RemoteHosts) - 1)
+			m.remoteHosts.moveDown(len(hosts) - 1)
 		case keyPageUp:
 			m.remoteHosts.pageUp()
 		case keyPageDown:
-			m.remoteHosts.pageDown(len(m.snapshot.RemoteHosts) - 1)
+			m.remoteHosts.pageDown(len(hosts) - 1)
 		case keyHome:
 			m.remoteHosts.goHome()
 		case keyEnd:
-			m.remoteHosts.goEnd(len(m.snapshot.RemoteHosts) - 1)
+			m.remoteHosts.goEnd(len(hosts) - 1)
+		case keyTopDest:
+			m.remoteHosts.showCountry = !m.remoteHosts.showCountry
+		case keyPrefixGroup:
+			m.remoteHosts.byPrefix = !m.remoteHosts.byPrefix
+			m.remoteHosts.cursor = 0
+			m.remoteHosts.offset = 0
+		case keyBlock:
+			if m.remoteHosts.cursor < len(hosts) {
+				h := hosts[m.remoteHosts.cursor]
+				m.openBlock(h.IP, h.Host)
+			}
+		}
+
+	case ViewCountries:
+		entries := buildCountries(m.viewSnapshot(ViewCountries).RemoteHosts, m.countries.byASN)
+		switch action {
+		case keyQuit:
+			return m, tea.Quit
+		case keyEsc:
+			m.mode = ViewProcessTable
+		case keyUp:
+			m.countries.moveUp()
+		case keyDown:
+			m.countries.moveDown(len(entries) - 1)
+		case keyPageUp:
+			m.countries.pageUp()
+		case keyPageDown:
+			m.countries.pageDown(len(entries) - 1)
+		case keyHome:
+			m.countries.goHome()
+		case keyEnd:
+			m.countries.goEnd(len(entries) - 1)
+		case keyToggleASN:
+			m.countries.toggleGrouping()
+		case keyEnter:
+			if m.countries.cursor < len(entries) {
+				m.drillIntoCountry(entries[m.countries.cursor])
+			}
+		}
+
+	case ViewGraph:
+		rows := m.serviceGraphRows()
+		switch action {
+		case keyQuit:
+			return m, tea.Quit
+		case keyEsc:
+			m.mode = ViewProcessTable
+		case keyUp:
+			m.graph.moveUp()
+		case keyDown:
+			m.graph.moveDown(len(rows) - 1)
+		case keyPageUp:
+			m.graph.pageUp()
+		case keyPageDown:
+			m.graph.pageDown(len(rows) - 1)
+		case keyHome:
+			m.graph.goHome()
+		case keyEnd:
+			m.graph.goEnd(len(rows) - 1)
+		case keyEnter:
+			if m.graph.cursor < len(rows) {
+				m.openGraphRow(rows[m.graph.cursor])
+			}
 		}
 
 	case ViewListenPorts:
@@ -475,19 +1076,88 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case keyUp:
 			m.listenPorts.moveUp()
 		case keyDown:
-			m.listenPorts.moveDown(len(m.snapshot.ListenPorts) - 1)
+			m.listenPorts.moveDown(len(m.listenRows()) - 1)
 		case keyPageUp:
 			m.listenPorts.pageUp()
 		case keyPageDown:
-			m.listenPorts.pageDown(len(m.snapshot.ListenPorts) - 1)
+			m.listenPorts.pageDown(len(m.listenRows()) - 1)
 		case keyHome:
 			m.listenPorts.goHome()
 		case keyEnd:
-			m.listenPorts.goEnd(len(m.snapshot.ListenPorts) - 1)
+			m.listenPorts.goEnd(len(m.listenRows()) - 1)
+		case keyEnter:
+			if rows := m.listenRows(); m.listenPorts.cursor < len(rows) {
+				m.openListenRow(&rows[m.listenPorts.cursor])
+			}
+		}
+
+	case ViewConnections:
+		conns := m.connectionRows()
+		switch action {
+		case keyQuit:
+			return m, tea.Quit
+		case keyEsc:
+			if m.connections.scope.active {
+				// Back up the drill-down
+				m.connections.scope = connScope{}
+				m.mode = ViewListenPorts
+			} else {
+				m.mode = ViewProcessTable
+			}
+		case keyUp:
+			m.connections.moveUp()
+		case keyDown:
+			m.connections.moveDown(len(conns) - 1)
+		case keyPageUp:
+			m.connections.pageUp()
+		case keyPageDown:
+			m.connections.pageDown(len(conns) - 1)
+		case keyHome:
+			m.connections.goHome()
+		case keyEnd:
+			m.connections.goEnd(len(conns) - 1)
+		case keySortNext:
+			m.connections.nextSort()
+		case keyEnter:
+			if m.connections.cursor < len(conns) {
+				m.openConnection(conns[m.connections.cursor])
+			}
+		case keyBlock:
+			if m.connections.cursor < len(conns) {
+				c := conns[m.connections.cursor]
+				m.openBlock(c.DstIP, c.RemoteHost)
+			}
+		}
+
+	case ViewInterfaces:
+		ifaces := m.interfaceRows()
+		switch action {
+		case keyQuit:
+			return m, tea.Quit
+		case keyEsc:
+			m.mode = ViewProcessTable
+		case keyUp:
+			m.interfaces.moveUp()
+		case keyDown:
+			m.interfaces.moveDown(len(ifaces) - 1)
+		case keyPageUp:
+			m.interfaces.pageUp()
+		case keyPageDown:
+			m.interfaces.pageDown(len(ifaces) - 1)
+		case keyHome:
+			m.interfaces.goHome()
+		case keyEnd:
+			m.interfaces.goEnd(len(ifaces) - 1)
+		case keySortNext:
+			m.interfaces.nextSort()
+		case keyEnter:
+			if m.interfaces.cursor < len(ifaces) {
+				m.selectInterface(ifaces[m.interfaces.cursor].Name)
+			}
 		}
 
 	case ViewGroups:
-		groups := buildGroups(m.snapshot.Processes)
+		groups := m.groupRows()
 		switch action {
 		case keyQuit:
 			return m, tea.Quit
@@ -515,14 +1185,93 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.table.applyFilterAndSort()
 				m.mode = ViewProcessTable
 			}
+		case keyJournal:
+			if m.groups.cursor < len(groups) {
+				return m, m.journal.open(groups[m.groups.cursor], m.journalReader)
+			}
+		}
+
+	case ViewUsers:
+		users := buildUsers(m.viewSnapshot(ViewUsers).Processes, m.users.sortBy)
+		switch action {
+		case keyQuit:
+			return m, tea.Quit
+		case keyEsc:
+			m.mode = ViewProcessTable
+		case keyUp:
+			m.users.moveUp()
+		case keyDown:
+			m.users.moveDown(len(users) - 1)
+		case keyPageUp:
+			m.users.pageUp()
+		case keyPageDown:
+			m.users.pageDown(len(users) - 1)
+		case keyHome:
+			m.users.goHome()
+		case keyEnd:
+			m.users.goEnd(len(users) - 1)
+		case keySortNext:
+			m.users.nextSort()
+		case keyEnter:
+			// Filter process table to selected user
+			if m.users.cursor < len(users) {
+				m.filterByUser(users[m.users.cursor])
+			}
+		}
+
+	case ViewUsage:
+		n := m.usageRowCount()
+		switch action {
+		case keyQuit:
+			return m, tea.Quit
+		case keyEsc:
+			m.mode = ViewProcessTable
+		case keyUp:
+			m.usage.moveUp()
+		case keyDown:
+			m.usage.moveDown(n - 1)
+		case keyPageUp:
+			m.usage.pageUp()
+		case keyPageDown:
+			m.usage.pageDown(n - 1)
+		case keyHome:
+			m.usage.goHome()
+		case keyEnd:
+			m.usage.goEnd(n - 1)
+		case keySortNext:
+			m.usage.nextPeriod()
 		}
 	}
 
 	return m, nil
 }
 
+// usageRowCount returns the number of process rows in the usage view.
+func (m *Model) usageRowCount() int {
+	if m.usageStore == nil {
+		return 0
+	}
+	return len(m.usageStore.Processes(m.usage.period, m.now()))
+}
+
+// drillIntoCountry switches to the remote hosts in one country or AS.
+func (m *Model) drillIntoCountry(e countryEntry) {
+	m.remoteHosts.scope = hostScope{active: true, byASN: m.countries.byASN, key: e.Key, label: e.Label}
+	m.remoteHosts.cursor, m.remoteHosts.offset = 0, 0
+	m.mode = ViewRemoteHosts
+}
+
+// filterByUser switches to the process table filtered to one user's processes.
+func (m *Model) filterByUser(u userEntry) {
+	filterStr := "user:" + u.Name
+	m.table.filter = filterStr
+	m.searchInput.SetValue(filterStr)
+	m.table.applyFilterAndSort()
+	m.mode = ViewProcessTable
+}
+
 func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
-	if m.kill.active || m.showHelp {
+	if m.kill.active || m.block.active || m.limit.active || m.journal.active || m.filters.active || m.showHelp {
 		return m, nil
 	}
 
@@ -541,6 +1290,22 @@ func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 				m.listenPorts.moveUp()
 			case ViewGroups:
 				m.groups.moveUp()
+			case ViewUsers:
+				m.users.moveUp()
+			case ViewUsage:
+				m.usage.moveUp()
+			case ViewCountries:
+				m.countries.moveUp()
+			case ViewGraph:
+				m.graph.moveUp()
+			case ViewConnections:
+				m.connections.moveUp()
+			case ViewInterfaces:
+				m.interfaces.moveUp()
+			case ViewTreemap:
+				m.treemap.moveUp()
+			case ViewFlows:
+				m.flows.moveUp()
 			}
 		case tea.MouseButtonWheelDown:
 			switch m.mode {
@@ -552,12 +1317,30 @@ func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 					m.detail.moveDown(len(proc.Connections) - 1)
 				}
 			case ViewRemoteHosts:
-				m.remoteHosts.moveDown(len(m.snapshot.RemoteHosts) - 1)
+				m.remoteHosts.moveDown(len(m.remoteHostRows()) - 1)
 			case ViewListenPorts:
-				m.listenPorts.moveDown(len(m.snapshot.ListenPorts) - 1)
+				m.listenPorts.moveDown(len(m.listenRows()) - 1)
 			case ViewGroups:
-				groups := buildGroups(m.snapshot.Processes)
+				groups := m.groupRows()
 				m.groups.moveDown(len(groups) - 1)
+			case ViewUsers:
+				users := buildUsers(m.viewSnapshot(ViewUsers).Processes, m.users.sortBy)
+				m.users.moveDown(len(users) - 1)
+			case ViewUsage:
+				m.usage.moveDown(m.usageRowCount() - 1)
+			case ViewCountries:
+				entries := buildCountries(m.viewSnapshot(ViewCountries).RemoteHosts, m.countries.byASN)
+				m.countries.moveDown(len(entries) - 1)
+			case ViewGraph:
+				m.graph.moveDown(len(m.serviceGraphRows()) - 1)
+			case ViewConnections:
+				m.connections.moveDown(len(m.connectionRows()) - 1)
+			case ViewInterfaces:
+				m.interfaces.moveDown(len(m.snapshot.Interfaces) - 1)
+			case ViewTreemap:
+				m.treemap.moveDown(len(m.treemapItems()) - 1)
+			case ViewFlows:
+				m.flows.moveDown(len(m.flowLayoutFor(m.contentHeight()).procs) - 1)
 			}
 		case tea.MouseButtonLeft:
 			return m.handleMouseClick(msg)
@@ -569,11 +1352,10 @@ func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 
 func (m Model) handleMouseClick(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 	// Calculate header height to determine content area
-	snap := m.snapshot
-	alertText := m.alert.alertHeaderText(snap.Processes)
-	playbackInfo := m.playbackInfoText()
-	header := renderHeader(snap, m.width, m.paused, m.activeIface, m.cumulativeMode, alertText, playbackInfo)
-	headerHeight := strings.Count(header, "\n") + 1
+	headerHeight := strings.Count(m.renderTop(), "\n") + 1
+	if m.compact() {
+		headerHeight = 1
+	}
 
 	contentY := msg.Y - headerHeight
 
@@ -583,11 +1365,19 @@ func (m Model) handleMouseClick(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		// row 0 is header, row 1+ are data
+		if contentY == 0 {
+			// Header click: sort by that column, again to reverse
+			lay := computeTableLayout(m.width, m.table.visibleColumns())
+			if c, ok := lay.columnAt(msg.X); ok && columnSpecs[c].sort >= 0 {
+				m.table.sortBy(columnSpecs[c].sort)
+			}
+			return m, nil
+		}
 		rowIdx := contentY - 1 + m.table.offset
 		if rowIdx >= 0 && rowIdx < len(m.table.filtered) {
 			if rowIdx == m.table.cursor {
 				// Double-click effect: enter detail
-				if sel := m.table.selected(); sel != nil {
+				if sel := m.table.selectedLive(); sel != nil {
 					m.mode = ViewProcessDetail
 					m.detail = newProcessDetail(sel.PID)
 				}
@@ -611,22 +1401,28 @@ func (m Model) handleMouseClick(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		rowIdx := contentY - 1 + m.remoteHosts.offset
-		if rowIdx >= 0 && rowIdx < len(m.snapshot.RemoteHosts) {
+		if rowIdx >= 0 && rowIdx < len(m.remoteHostRows()) {
 			m.remoteHosts.cursor = rowIdx
 		}
 	case ViewListenPorts:
 		if contentY < 0 {
 			return m, nil
 		}
+		rows := m.listenRows()
 		rowIdx := contentY - 2 + m.listenPorts.offset // -2 for title + header
-		if rowIdx >= 0 && rowIdx < len(m.snapshot.ListenPorts) {
-			m.listenPorts.cursor = rowIdx
+		if rowIdx >= 0 && rowIdx < len(rows) {
+			if rowIdx == m.listenPorts.cursor {
+				// Double-click: show the port's clients, or its sockets
+				m.openListenRow(&rows[rowIdx])
+			} else {
+				m.listenPorts.cursor = rowIdx
+			}
 		}
 	case ViewGroups:
 		if contentY < 0 {
 			return m, nil
 		}
-		groups := buildGroups(m.snapshot.Processes)
+		groups := m.groupRows()
 		rowIdx := contentY - 2 + m.groups.offset // -2 for title + header
 		if rowIdx >= 0 && rowIdx < len(groups) {
 			if rowIdx == m.groups.cursor {
@@ -641,11 +1437,165 @@ func (m Model) handleMouseClick(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 				m.groups.cursor = rowIdx
 			}
 		}
+	case ViewUsers:
+		if contentY < 0 {
+			return m, nil
+		}
+		users := buildUsers(m.viewSnapshot(ViewUsers).Processes, m.users.sortBy)
+		rowIdx := contentY - 2 + m.users.offset // -2 for title + header
+		if rowIdx >= 0 && rowIdx < len(users) {
+			if rowIdx == m.users.cursor {
+				// Double-click: filter by user
+				m.filterByUser(users[rowIdx])
+			} else {
+				m.users.cursor = rowIdx
+			}
+		}
+	case ViewUsage:
+		if contentY < 0 || m.usageStore == nil {
+			return m, nil
+		}
+		ifaces := m.usageStore.Interfaces(m.usage.period, m.now())
+		rowIdx := contentY - usageRowsTop(ifaces) + m.usage.offset
+		if rowIdx >= 0 && rowIdx < m.usageRowCount() {
+			m.usage.cursor = rowIdx
+		}
+	case ViewCountries:
+		if contentY < 0 {
+			return m, nil
+		}
+		entries := buildCountries(m.viewSnapshot(ViewCountries).RemoteHosts, m.countries.byASN)
+		rowIdx := contentY - 2 + m.countries.offset // -2 for title + header
+		if rowIdx >= 0 && rowIdx < len(entries) {
+			if rowIdx == m.countries.cursor {
+				// Double-click: drill into the hosts
+				m.drillIntoCountry(entries[rowIdx])
+			} else {
+				m.countries.cursor = rowIdx
+			}
+		}
+	case ViewGraph:
+		if contentY < 0 {
+			return m, nil
+		}
+		rows := m.serviceGraphRows()
+		rowIdx := contentY - 2 + m.graph.offset // -2 for title + header
+		if rowIdx >= 0 && rowIdx < len(rows) {
+			if rowIdx == m.graph.cursor {
+				// Double-click: open the process
+				m.openGraphRow(rows[rowIdx])
+			} else {
+				m.graph.cursor = rowIdx
+			}
+		}
+	case ViewConnections:
+		if contentY < 0 {
+			return m, nil
+		}
+		conns := m.connectionRows()
+		rowIdx := contentY - 2 + m.connections.offset // -2 for title + header
+		if rowIdx >= 0 && rowIdx < len(conns) {
+			if rowIdx == m.connections.cursor {
+				// Double-click: open the process
+				m.openConnection(conns[rowIdx])
+			} else {
+				m.connections.cursor = rowIdx
+			}
+		}
+	case ViewInterfaces:
+		if contentY < 0 {
+			return m, nil
+		}
+		ifaces := m.interfaceRows()
+		rowIdx := contentY - 2 + m.interfaces.offset // -2 for title + header
+		if rowIdx >= 0 && rowIdx < len(ifaces) {
+			if rowIdx == m.interfaces.cursor {
+				// Double-click: filter the process table
+				m.selectInterface(ifaces[rowIdx].Name)
+			} else {
+				m.interfaces.cursor = rowIdx
+			}
+		}
+	case ViewTreemap:
+		if contentY < 1 {
+			return m, nil
+		}
+		items := m.treemapItems()
+		tiles := layoutTreemap(items, m.width, max(m.contentHeight()-1, 1)) // less the title
+		if i := treemapTileAt(tiles, msg.X, contentY-1); i >= 0 {
+			if i == m.treemap.cursor {
+				// Double-click: open what the tile stands for
+				m.openTreemapItem(items[i])
+			} else {
+				m.treemap.cursor = i
+			}
+		}
+	case ViewFlows:
+		if contentY < 1 {
+			return m, nil
+		}
+		procs := m.flowLayoutFor(m.contentHeight()).procs
+		if i := flowNodeAt(procs, contentY-1); i >= 0 {
+			if i == m.flows.cursor {
+				// Double-click: open the process
+				m.openFlowProc(procs)
+			} else {
+				m.flows.cursor = i
+			}
+		}
 	}
 
 	return m, nil
 }
 
+// tableRows returns the process table's rows: live processes, plus exited
+// ones in cumulative mode so their session totals stay reviewable.
+func (m *Model) tableRows() []model.ProcessSummary {
+	rows := m.snapshot.Processes
+	if m.cumulativeMode && len(m.snapshot.Exited) > 0 {
+		rows = make([]model.ProcessSummary, 0, len(m.snapshot.Processes)+len(m.snapshot.Exited))
+		rows = append(rows, m.snapshot.Processes...)
+		rows = append(rows, m.snapshot.Exited...)
+	}
+	if m.activeNetNS != "" {
+		rows = inNetNS(rows, m.activeNetNS)
+	}
+	if m.ifaceFilter != "" {
+		rows = onInterface(rows, m.snapshot.Interfaces, m.ifaceFilter)
+	}
+	return rows
+}
+
+// dismissExited removes exited processes from the table (all of them when
+// no PIDs are given), here and in the collector.
+func (m *Model) dismissExited(pids ...uint32) {
+	if d, ok := m.collector.(ExitedDismisser); ok {
+		d.DismissExited(pids...)
+	}
+	var kept []model.ProcessSummary
+	if len(pids) > 0 {
+		drop := make(map[uint32]bool, len(pids))
+		for _, pid := range pids {
+			drop[pid] = true
+		}
+		for _, p := range m.snapshot.Exited {
+			if !drop[p.PID] {
+				kept = append(kept, p)
+			}
+		}
+	}
+	m.snapshot.Exited = kept
+	m.table.update(m.tableRows())
+}
+
+// refreshNow asks the collector for an out-of-cycle poll (no-op in playback).
+func (m *Model) refreshNow() {
+	if r, ok := m.collector.(Refresher); ok {
+		r.RefreshNow()
+	}
+	m.releaseHeld()
+}
+
 func (m *Model) changeInterval(delta int) {
 	newIdx := m.intervalIdx + delta
 	if newIdx < 0 {
@@ -680,21 +1630,25 @@ func (m *Model) cycleInterface() {
 	}
 }
 
+// contentHeight is the number of lines the view gets between the header
+// and the footer.
+func (m Model) contentHeight() int {
+	return max(m.height-(strings.Count(m.renderTop(), "\n")+1)-1, 1)
+}
+
 func (m Model) View() string {
 	if m.width == 0 || m.height == 0 {
 		return "Initializing..."
 	}
+	if m.compact() {
+		return m.fitTerminal(m.renderCompact())
+	}
 
-	snap := m.snapshot
-
-	// Header: 2-4 lines
-	alertText := m.alert.alertHeaderText(snap.Processes)
-	playbackInfo := m.playbackInfoText()
-	header := renderHeader(snap, m.width, m.paused, m.activeIface, m.cumulativeMode, alertText, playbackInfo)
+	// Header: 2-4 lines, plus the host selector with sstop connect
+	header := m.renderTop()
 	headerHeight := strings.Count(header, "\n") + 1
 
 	// Footer: 1 line
-	footer := m.renderFooter()
 	footerHeight := 1
 
 	// Content area
@@ -703,20 +1657,12 @@ func (m Model) View() string {
 		contentHeight = 1
 	}
 
-	var content string
-	switch m.mode {
-	case ViewProcessTable:
-		content = m.table.render(m.width, contentHeight, m.cumulativeMode)
-	case ViewProcessDetail:
-		proc := m.findProcess(m.detail.pid)
-		content = m.detail.render(proc, m.width, contentHeight)
-	case ViewRemoteHosts:
-		content = m.remoteHosts.render(m.snapshot.RemoteHosts, m.width, contentHeight)
-	case ViewListenPorts:
-		content = m.listenPorts.render(m.snapshot.ListenPorts, m.width, contentHeight)
-	case ViewGroups:
-		content = m.groups.render(m.snapshot.Processes, m.width, contentHeight)
+	// Content first: the footer shows where its list is scrolled to
+	content := m.renderContent(contentHeight)
+	if m.staleAge > 0 {
+		content = greyOut(content)
 	}
+	footer := m.renderFooter()
 
 	// Pad content to fill available height so footer stays at bottom
 	contentLines := strings.Count(content, "\n") + 1
@@ -727,6 +1673,11 @@ func (m Model) View() string {
 	// Search bar (replaces footer when active)
 	if m.searching {
 		footer = styleSearchPrompt.Render("Filter: ") + m.searchInput.View()
+		if err := ParseFilter(m.searchInput.Value()).Err(); err != nil {
+			footer += styleDetailLabel.Render("  " + err.Error() + "; searching as text")
+		} else if len(m.filterHistory.entries) > 0 {
+			footer += styleDetailLabel.Render("  ↑↓ history")
+		}
 	}
 
 	result := lipgloss.JoinVertical(lipgloss.Left,
@@ -738,13 +1689,84 @@ func (m Model) View() string {
 	// Overlays on top of everything
 	if m.alert.active {
 		result = m.alert.render(m.width, m.height)
+	} else if m.note.active {
+		result = m.note.render(m.width, m.height)
 	} else if m.kill.active {
 		result = m.kill.render(m.width, m.height)
+	} else if m.block.active {
+		result = m.block.render(m.width, m.height)
+	} else if m.limit.active {
+		result = m.limit.render(m.width, m.height)
+	} else if m.journal.active {
+		result = m.journal.render(m.width, m.height)
+	} else if m.filters.active {
+		result = m.filters.render(m.savedFilters, m.filterSaver != nil, m.width, m.height)
 	} else if m.showHelp {
 		result = renderHelp(m.width, m.height)
 	}
 
-	return result
+	return m.fitTerminal(result)
+}
+
+// renderContent renders the current view in height lines.
+func (m *Model) renderContent(height int) string {
+	switch m.mode {
+	case ViewProcessTable:
+		return m.table.render(m.width, height, m.cumulativeMode)
+	case ViewProcessDetail:
+		proc := m.findProcess(m.detail.pid)
+		return m.detail.render(proc, m.hostNames, m.width, height)
+	case ViewRemoteHosts:
+		return m.remoteHosts.render(m.remoteHostRows(), m.hostNames, m.width, height)
+	case ViewListenPorts:
+		return m.listenPorts.render(m.listenPortRows(), m.width, height)
+	case ViewGroups:
+		return m.groups.render(m.groupRows(), m.width, height)
+	case ViewUsers:
+		return m.users.render(m.viewSnapshot(ViewUsers).Processes, m.width, height)
+	case ViewUsage:
+		return m.usage.render(m.usageStore, m.now(), m.width, height)
+	case ViewCountries:
+		return m.countries.render(m.viewSnapshot(ViewCountries).RemoteHosts, m.width, height)
+	case ViewGraph:
+		return m.graph.render(m.serviceGraphRows(), m.width, height)
+	case ViewConnections:
+		return m.connections.render(m.connectionRows(), m.hostNames, m.width, height)
+	case ViewInterfaces:
+		return m.interfaces.render(m.interfaceRows(), m.ifaceFilter, m.width, height)
+	case ViewRateChart:
+		return renderRateChart(m.findProcess(m.detail.pid), m.processRates(m.detail.pid), m.width, height)
+	case ViewTreemap:
+		return m.treemap.render(m.treemapItems(), m.width, height)
+	case ViewFlows:
+		return m.flows.render(m.flowLayoutFor(height), m.width, height)
+	}
+	return ""
+}
+
+// renderTop renders the header, then the host selector in fleet mode or
+// the timeline in playback.
+func (m Model) renderTop() string {
+	snap := m.snapshot
+	alertText := m.alert.alertHeaderText(snap.Processes)
+	if m.testMode {
+		snap.Timestamp = time.Time{}
+	}
+	header := renderHeader(snap, m.width, m.paused, m.activeIface, m.cumulativeMode, alertText, m.sourceInfoText())
+	if m.hosts != nil {
+		header += "\n" + m.renderHostBar(m.width)
+	}
+	if m.player != nil {
+		header += "\n" + m.renderPlaybackBar(m.width)
+	}
+	if m.hud.on {
+		var interval time.Duration
+		if m.collector != nil {
+			interval = intervalPresets[m.intervalIdx]
+		}
+		header += "\n" + m.hud.render(m.width, interval)
+	}
+	return header
 }
 
 func (m Model) renderFooter() string {
@@ -755,40 +1777,182 @@ func (m Model) renderFooter() string {
 		parts = append(parts,
 			styleFooterKey.Render("esc")+styleFooter.Render(" back"),
 			styleFooterKey.Render("enter")+styleFooter.Render(" filter by group"),
+			styleFooterKey.Render("J")+styleFooter.Render(" journal"),
+			styleFooterKey.Render("/")+styleFooter.Render(" filter"),
+			styleFooterKey.Render("?")+styleFooter.Render(" help"),
+			styleFooterKey.Render("q")+styleFooter.Render(" quit"),
+		)
+	case ViewUsers:
+		parts = append(parts,
+			styleFooterKey.Render("esc")+styleFooter.Render(" back"),
+			styleFooterKey.Render("enter")+styleFooter.Render(" filter by user"),
+			styleFooterKey.Render("s")+styleFooter.Render(" sort"),
+			styleFooterKey.Render("?")+styleFooter.Render(" help"),
+			styleFooterKey.Render("q")+styleFooter.Render(" quit"),
+		)
+	case ViewUsage:
+		parts = append(parts,
+			styleFooterKey.Render("esc")+styleFooter.Render(" back"),
+			styleFooterKey.Render("s")+styleFooter.Render(" today/week/month"),
+			styleFooterKey.Render("?")+styleFooter.Render(" help"),
+			styleFooterKey.Render("q")+styleFooter.Render(" quit"),
+		)
+	case ViewGraph:
+		parts = append(parts,
+			styleFooterKey.Render("esc")+styleFooter.Render(" back"),
+			styleFooterKey.Render("enter")+styleFooter.Render(" process detail"),
+			styleFooterKey.Render("?")+styleFooter.Render(" help"),
+			styleFooterKey.Render("q")+styleFooter.Render(" quit"),
+		)
+	case ViewCountries:
+		parts = append(parts,
+			styleFooterKey.Render("esc")+styleFooter.Render(" back"),
+			styleFooterKey.Render("enter")+styleFooter.Render(" hosts"),
+			styleFooterKey.Render("a")+styleFooter.Render(" country/AS"),
 			styleFooterKey.Render("?")+styleFooter.Render(" help"),
 			styleFooterKey.Render("q")+styleFooter.Render(" quit"),
 		)
 	case ViewRemoteHosts:
 		parts = append(parts,
 			styleFooterKey.Render("esc")+styleFooter.Render(" back"),
+			styleFooterKey.Render("T")+styleFooter.Render(" country column"),
+			styleFooterKey.Render("p")+styleFooter.Render(" group /64"),
+			styleFooterKey.Render("d")+styleFooter.Render(" names"),
+			styleFooterKey.Render("B")+styleFooter.Render(" block"),
+			styleFooterKey.Render("/")+styleFooter.Render(" filter"),
+			styleFooterKey.Render("?")+styleFooter.Render(" help"),
+			styleFooterKey.Render("q")+styleFooter.Render(" quit"),
+		)
+	case ViewConnections:
+		parts = append(parts,
+			styleFooterKey.Render("esc")+styleFooter.Render(" back"),
+			styleFooterKey.Render("enter")+styleFooter.Render(" process detail"),
+			styleFooterKey.Render("s")+styleFooter.Render(" sort"),
+			styleFooterKey.Render("d")+styleFooter.Render(" names"),
+			styleFooterKey.Render("B")+styleFooter.Render(" block"),
+			styleFooterKey.Render("/")+styleFooter.Render(" filter"),
+			styleFooterKey.Render("?")+styleFooter.Render(" help"),
+			styleFooterKey.Render("q")+styleFooter.Render(" quit"),
+		)
+	case ViewInterfaces:
+		parts = append(parts,
+			styleFooterKey.Render("esc")+styleFooter.Render(" back"),
+			styleFooterKey.Render("enter")+styleFooter.Render(" filter processes"),
+			styleFooterKey.Render("s")+styleFooter.Render(" sort"),
+			styleFooterKey.Render("?")+styleFooter.Render(" help"),
+			styleFooterKey.Render("q")+styleFooter.Render(" quit"),
+		)
+	case ViewTreemap:
+		open := map[treemapGroup]string{
+			treemapByProcess: " process detail",
+			treemapByGroup:   " filter by group",
+			treemapByHost:    " host",
+		}[m.treemap.by]
+		parts = append(parts,
+			styleFooterKey.Render("esc")+styleFooter.Render(" back"),
+			styleFooterKey.Render("enter")+styleFooter.Render(open),
+			styleFooterKey.Render("s")+styleFooter.Render(" process/group/host"),
+			styleFooterKey.Render("?")+styleFooter.Render(" help"),
+			styleFooterKey.Render("q")+styleFooter.Render(" quit"),
+		)
+	case ViewFlows:
+		parts = append(parts,
+			styleFooterKey.Render("esc")+styleFooter.Render(" back"),
+			styleFooterKey.Render("enter")+styleFooter.Render(" process detail"),
 			styleFooterKey.Render("?")+styleFooter.Render(" help"),
 			styleFooterKey.Render("q")+styleFooter.Render(" quit"),
 		)
 	case ViewListenPorts:
 		parts = append(parts,
 			styleFooterKey.Render("esc")+styleFooter.Render(" back"),
+			styleFooterKey.Render("enter")+styleFooter.Render(" clients / expand"),
+			styleFooterKey.Render("/")+styleFooter.Render(" filter"),
 			styleFooterKey.Render("?")+styleFooter.Render(" help"),
 			styleFooterKey.Render("q")+styleFooter.Render(" quit"),
 		)
 	case ViewProcessDetail:
 		parts = append(parts,
 			styleFooterKey.Render("esc")+styleFooter.Render(" back"),
-			styleFooterKey.Render("d")+styleFooter.Render(" dns"),
+			styleFooterKey.Render("d")+styleFooter.Render(" names"),
+			styleFooterKey.Render("K")+styleFooter.Render(" kill"),
+			styleFooterKey.Render("x")+styleFooter.Render(" close conn"),
+			styleFooterKey.Render("B")+styleFooter.Render(" block"),
+			styleFooterKey.Render("w")+styleFooter.Render(" pcap"),
+			styleFooterKey.Render("b")+styleFooter.Render(" chart"),
+			styleFooterKey.Render("?")+styleFooter.Render(" help"),
+			styleFooterKey.Render("q")+styleFooter.Render(" quit"),
+		)
+	case ViewRateChart:
+		parts = append(parts,
+			styleFooterKey.Render("esc")+styleFooter.Render(" back"),
 			styleFooterKey.Render("K")+styleFooter.Render(" kill"),
 			styleFooterKey.Render("?")+styleFooter.Render(" help"),
 			styleFooterKey.Render("q")+styleFooter.Render(" quit"),
 		)
 	default:
+		if m.mode == ViewProcessTable && m.table.marks.active {
+			parts = append(parts,
+				stylePaused.Render(fmt.Sprintf("SELECT %d marked", len(m.table.marks.ids))),
+				styleFooterKey.Render("space/m")+styleFooter.Render(" mark"),
+				styleFooterKey.Render("K")+styleFooter.Render(" kill"),
+				styleFooterKey.Render("e")+styleFooter.Render(" export"),
+				styleFooterKey.Render("O")+styleFooter.Render(" only marked"),
+				styleFooterKey.Render("esc")+styleFooter.Render(" done"),
+			)
+			break
+		}
 		parts = append(parts,
 			styleFooterKey.Render("?")+styleFooter.Render(" help"),
 			styleFooterKey.Render("/")+styleFooter.Render(" filter"),
 			styleFooterKey.Render("q")+styleFooter.Render(" quit"),
 		)
+		if sel := m.table.selected(); m.mode == ViewProcessTable && sel != nil && sel.PID == model.UnattributedPID {
+			parts = append(parts, styleFooter.Render(model.UnattributedName+": sockets with no owning process, ")+
+				styleFooterKey.Render("enter")+styleFooter.Render(" for why"))
+		}
+	}
+
+	if w, ok := m.listWindow(); ok && w.counter() != "" {
+		parts = append(parts, styleFooter.Render(w.counter()))
 	}
 
-	if m.table.filter != "" && !m.searching && m.mode == ViewProcessTable {
+	if m.hostNames != hostfmt.FQDN {
+		parts = append(parts, styleSearchPrompt.Render("hosts:")+styleFooter.Render(m.hostNames.String()))
+	}
+
+	if filter, _ := m.viewFilter(); filter != "" && !m.searching {
+		parts = append(parts,
+			styleSearchPrompt.Render("filter:")+styleFooter.Render(filter),
+		)
+	}
+
+	if m.table.idleMode != idleShow && m.mode == ViewProcessTable {
+		idle := m.table.idleMode.String()
+		if m.table.idleHidden > 0 {
+			idle += fmt.Sprintf(" (%d hidden)", m.table.idleHidden)
+		}
+		parts = append(parts, styleSearchPrompt.Render("idle:")+styleFooter.Render(idle))
+	}
+
+	if text := m.baselineFooterText(); text != "" {
+		parts = append(parts, text)
+	}
+
+	if m.activeNetNS != "" && m.mode == ViewProcessTable {
+		parts = append(parts,
+			styleSearchPrompt.Render("netns:")+styleFooter.Render(m.activeNetNS),
+		)
+	}
+
+	if m.ifaceFilter != "" && m.mode == ViewProcessTable {
 		parts = append(parts,
-			styleSearchPrompt.Render("filter:")+styleFooter.Render(m.table.filter),
+			styleSearchPrompt.Render("iface:")+styleFooter.Render(m.ifaceFilter),
+		)
+	}
+
+	if m.activePreset != "" {
+		parts = append(parts,
+			styleSearchPrompt.Render("preset:")+styleFooter.Render(m.activePreset),
 		)
 	}
 
@@ -796,9 +1960,28 @@ func (m Model) renderFooter() string {
 		parts = append(parts, stylePaused.Render("PAUSED"))
 	}
 
+	if m.privacyOn {
+		parts = append(parts, stylePaused.Render("PRIVACY"))
+	}
+
+	if text := m.captureFooterText(); text != "" {
+		parts = append(parts, text)
+	}
+
+	if text := m.exportFooterText(); text != "" {
+		parts = append(parts, text)
+	}
+
+	if !m.mouseOn {
+		parts = append(parts, styleFooterKey.Render("M")+styleFooter.Render(" mouse off"))
+	}
+
 	// Refresh interval indicator
 	interval := intervalPresets[m.intervalIdx]
 	intervalStr := formatInterval(interval)
+	if h := m.held[m.mode]; h != nil {
+		intervalStr += " (view " + formatInterval(h.every) + ")"
+	}
 	parts = append(parts,
 		styleFooterKey.Render("+/-")+styleFooter.Render(" ")+
 			styleHeaderValue.Render(intervalStr),
@@ -811,7 +1994,16 @@ func (m Model) renderFooter() string {
 		)
 	}
 
-	return "  " + strings.Join(parts, "  ")
+	// Keep to one line: drop trailing hints that don't fit
+	var footer string
+	for _, part := range parts {
+		next := footer + "  " + part
+		if m.width > 0 && lipgloss.Width(next) > m.width {
+			break
+		}
+		footer = next
+	}
+	return footer
 }
 
 func formatInterval(d time.Duration) string {
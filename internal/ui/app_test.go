@@ -0,0 +1,661 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+type fakeCollector struct {
+	refreshes int
+	dismissed []uint32
+	destroyed []model.Connection
+}
+
+func (f *fakeCollector) SetInterval(time.Duration)    {}
+func (f *fakeCollector) RefreshNow()                  { f.refreshes++ }
+func (f *fakeCollector) DismissExited(pids ...uint32) { f.dismissed = append(f.dismissed, pids...) }
+func (f *fakeCollector) DestroySocket(pid uint32, c model.Connection) error {
+	f.destroyed = append(f.destroyed, c)
+	return nil
+}
+
+func press(m Model, key tea.KeyMsg) Model {
+	next, _ := m.handleKey(key)
+	return next.(Model)
+}
+
+func TestRefreshKey(t *testing.T) {
+	fc := &fakeCollector{}
+	m := New(nil)
+	m.SetCollector(fc)
+
+	r := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")}
+	ctrlR := tea.KeyMsg{Type: tea.KeyCtrlR}
+
+	m = press(m, r)
+	if fc.refreshes != 1 {
+		t.Errorf("r in process table: %d refreshes, want 1", fc.refreshes)
+	}
+
+	// In the detail view r keeps toggling TCP stats; ctrl+r refreshes
+	m.mode = ViewProcessDetail
+	m.detail = newProcessDetail(1)
+	m = press(m, r)
+	if fc.refreshes != 1 || !m.detail.showTCP {
+		t.Errorf("r in detail: refreshes=%d showTCP=%v, want 1 true", fc.refreshes, m.detail.showTCP)
+	}
+	m = press(m, ctrlR)
+	if fc.refreshes != 2 {
+		t.Errorf("ctrl+r in detail: %d refreshes, want 2", fc.refreshes)
+	}
+
+	// Playback has no collector: no-op
+	pm := New(nil)
+	press(pm, r)
+}
+
+func TestCloseConnection(t *testing.T) {
+	conn := func(port uint16) model.Connection {
+		return model.Connection{Proto: model.ProtoTCP, SrcIP: net.ParseIP("10.0.0.2").To4(), SrcPort: port,
+			DstIP: net.ParseIP("192.0.2.1").To4(), DstPort: 443}
+	}
+	snap := SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{
+		{PID: 10, Name: "curl", Connections: []model.Connection{conn(40000), conn(40001)}},
+	}})
+	fc := &fakeCollector{}
+	m := New(nil)
+	m.SetCollector(fc)
+	m.width, m.height = 120, 30
+	next, _ := m.Update(snap)
+	m = next.(Model)
+
+	x := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	m = press(m, x)
+	if !m.kill.active || m.kill.conn == nil || m.kill.conn.SrcPort != 40001 {
+		t.Fatalf("x: overlay %+v, want the selected connection", m.kill)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "TCP 10.0.0.2:40001 → 192.0.2.1:443") {
+		t.Errorf("overlay should name the socket:\n%s", view)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(fc.destroyed) != 1 || fc.destroyed[0].SrcPort != 40001 || !strings.HasPrefix(m.kill.result, "Closed") {
+		t.Errorf("enter: destroyed %+v, result %q", fc.destroyed, m.kill.result)
+	}
+
+	// K afterwards is the signal overlay again
+	m = press(m, x)
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("K")})
+	if m.kill.conn != nil {
+		t.Error("K should signal the process, not close a socket")
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+
+	// Privacy mode masks addresses: nothing to close
+	m.privacyOn = true
+	m = press(m, x)
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(fc.destroyed) != 1 || !strings.Contains(m.kill.result, "privacy") {
+		t.Errorf("privacy: destroyed %+v, result %q", fc.destroyed, m.kill.result)
+	}
+	m.privacyOn = false
+
+	// Playback can't close sockets
+	pm := New(nil)
+	next, _ = pm.Update(snap)
+	pm = next.(Model)
+	pm = press(pm, tea.KeyMsg{Type: tea.KeyEnter})
+	pm = press(pm, x)
+	pm = press(pm, tea.KeyMsg{Type: tea.KeyEnter})
+	if !strings.HasPrefix(pm.kill.result, "Failed") {
+		t.Errorf("playback: result %q, want a failure", pm.kill.result)
+	}
+}
+
+func TestExitedProcessesInCumulativeMode(t *testing.T) {
+	fc := &fakeCollector{}
+	m := New(nil)
+	m.SetCollector(fc)
+	m.width, m.height = 120, 30
+
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{
+		Processes: []model.ProcessSummary{{PID: 1, Name: "sshd", CumUp: 10}},
+		Exited: []model.ProcessSummary{
+			{PID: 2, Name: "wget", CumDown: 5 << 20, ExitedAt: time.Now().Add(-12 * time.Second)},
+			{PID: 3, Name: "curl", CumDown: 1 << 10, ExitedAt: time.Now()},
+		},
+	}))
+	m = next.(Model)
+	if len(m.table.filtered) != 1 {
+		t.Fatalf("rate mode shows %d rows, want live processes only", len(m.table.filtered))
+	}
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	if len(m.table.filtered) != 3 {
+		t.Fatalf("cumulative mode shows %d rows, want 3", len(m.table.filtered))
+	}
+	if sel := m.table.selected(); sel.PID != 2 {
+		t.Fatalf("top row = %d, want wget (largest total)", sel.PID)
+	}
+	if out := m.table.render(m.width, 10, true); !strings.Contains(out, "wget (exited 12s ago)") {
+		t.Errorf("exited row not marked:\n%s", out)
+	}
+
+	// Exited rows can't be opened or killed
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ViewProcessTable {
+		t.Error("enter on an exited process opened the detail view")
+	}
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if len(fc.dismissed) != 1 || fc.dismissed[0] != 2 || len(m.table.filtered) != 2 {
+		t.Errorf("x: dismissed %v, %d rows left", fc.dismissed, len(m.table.filtered))
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("X")})
+	if len(m.table.filtered) != 1 {
+		t.Errorf("X left %d rows, want 1", len(m.table.filtered))
+	}
+}
+
+func TestProcessNotes(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 120, 30
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{
+		Processes: []model.ProcessSummary{
+			{PID: 10, Name: "curl", UpRate: 200},
+			{PID: 11, Name: "curl", UpRate: 100},
+			{PID: 12, Name: "sshd"},
+		},
+	}))
+	m = next.(Model)
+
+	typeText := func(m Model, s string) Model {
+		for _, r := range s {
+			m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		return press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	}
+
+	// n notes the selected PID only
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if !m.note.active {
+		t.Fatal("n did not open the note overlay")
+	}
+	m = typeText(m, "investigating")
+	if m.note.active {
+		t.Fatal("enter did not close the note overlay")
+	}
+	if out := m.table.render(m.width, 10, true); !strings.Contains(out, "curl [investigating]") {
+		t.Errorf("note not shown after the name:\n%s", out)
+	}
+
+	// N notes every process with the name; the PID note still wins
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("N")})
+	m = typeText(m, "known-good")
+	notes := map[uint32]string{}
+	for _, p := range m.table.filtered {
+		notes[p.PID] = p.Note
+	}
+	if notes[10] != "investigating" || notes[11] != "known-good" || notes[12] != "" {
+		t.Errorf("notes = %v", notes)
+	}
+
+	// Notes are searchable
+	m.table.filter = "note:any"
+	m.table.applyFilterAndSort()
+	if len(m.table.filtered) != 2 {
+		t.Errorf("note:any matched %d rows, want 2", len(m.table.filtered))
+	}
+	m.table.filter = "investig"
+	m.table.applyFilterAndSort()
+	if len(m.table.filtered) != 1 || m.table.filtered[0].PID != 10 {
+		t.Errorf("plain search on note matched %v", m.table.filtered)
+	}
+
+	// Notes stick to later snapshots
+	next, _ = m.Update(SnapshotMsg(model.Snapshot{
+		Processes: []model.ProcessSummary{{PID: 10, Name: "curl"}},
+	}))
+	m = next.(Model)
+	if got := m.snapshot.Processes[0].Note; got != "investigating" {
+		t.Errorf("next snapshot note = %q", got)
+	}
+}
+
+func TestStaleSnapshotBadge(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 120, 30
+
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{
+		Processes: []model.ProcessSummary{{PID: 10, Name: "curl", UpRate: 1000}},
+	}))
+	m = next.(Model)
+	start := m.lastSnapAt
+
+	// Within a few intervals (default 1s) the data counts as fresh
+	next, _ = m.Update(staleTickMsg(start.Add(2 * time.Second)))
+	m = next.(Model)
+	if m.staleAge != 0 || strings.Contains(m.View(), "STALE") {
+		t.Fatalf("fresh data marked stale (age %v)", m.staleAge)
+	}
+
+	// The source goes quiet: last snapshot stays, flagged stale
+	next, _ = m.Update(staleTickMsg(start.Add(12 * time.Second)))
+	m = next.(Model)
+	view := m.View()
+	if !strings.Contains(view, "STALE 12s") || !strings.Contains(view, "curl") {
+		t.Errorf("stale view missing badge or last snapshot:\n%s", view)
+	}
+
+	// The next snapshot clears it
+	next, _ = m.Update(SnapshotMsg(model.Snapshot{}))
+	m = next.(Model)
+	if m.staleAge != 0 || strings.Contains(m.View(), "STALE") {
+		t.Error("new snapshot didn't clear stale state")
+	}
+}
+
+func TestNetNSSelector(t *testing.T) {
+	m := New(nil)
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{
+		Processes: []model.ProcessSummary{
+			{PID: 1, Name: "sshd"},
+			{PID: 400, Name: "nginx", NetNS: "4f1c2a9e8b7d"},
+			{PID: 500, Name: "dnsmasq", NetNS: "blue"},
+		},
+	}))
+	m = next.(Model)
+	o := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")}
+
+	for _, want := range []struct {
+		ns   string
+		pids []uint32
+	}{
+		{netnsHost, []uint32{1}},
+		{"4f1c2a9e8b7d", []uint32{400}},
+		{"blue", []uint32{500}},
+		{"", []uint32{1, 400, 500}},
+	} {
+		m = press(m, o)
+		if m.activeNetNS != want.ns {
+			t.Fatalf("selector = %q, want %q", m.activeNetNS, want.ns)
+		}
+		var pids []uint32
+		for _, p := range m.table.filtered {
+			pids = append(pids, p.PID)
+		}
+		if len(pids) != len(want.pids) {
+			t.Errorf("netns %q: table %v, want %v", want.ns, pids, want.pids)
+		}
+	}
+
+	if !ParseFilter("netns:host").Match(&model.ProcessSummary{}) ||
+		ParseFilter("netns:host").Match(&model.ProcessSummary{NetNS: "blue"}) {
+		t.Error("netns:host should match only sstop's own namespace")
+	}
+}
+
+func TestMouseToggle(t *testing.T) {
+	m := New(nil)
+	m.SetMouse(false) // --no-mouse
+	if !strings.Contains(m.renderFooter(), "mouse off") {
+		t.Error("footer doesn't show that the mouse is released")
+	}
+
+	mk := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("M")}
+	next, cmd := m.handleKey(mk)
+	m = next.(Model)
+	if !m.mouseOn || cmd == nil {
+		t.Fatalf("M: mouseOn=%v cmd=%v, want capture back on", m.mouseOn, cmd)
+	}
+	next, cmd = m.handleKey(mk)
+	if next.(Model).mouseOn || cmd == nil {
+		t.Error("second M didn't release the mouse")
+	}
+}
+
+func TestHostSelector(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 120, 30
+	m.SetHosts([]string{"web1", "db1"})
+	for _, snap := range []model.Snapshot{
+		{Host: "db1", TotalUp: 2048, Processes: []model.ProcessSummary{{PID: 9, Name: "postgres"}}},
+		{Host: "web1", Processes: []model.ProcessSummary{{PID: 7, Name: "nginx"}}},
+	} {
+		next, _ := m.Update(SnapshotMsg(snap))
+		m = next.(Model)
+	}
+
+	view := m.View()
+	if !strings.Contains(view, "nginx") || strings.Contains(view, "postgres") {
+		t.Fatalf("web1 not shown alone:\n%s", view)
+	}
+	if !strings.Contains(view, "db1 ▲2.0 KB/s") {
+		t.Errorf("host bar missing db1's rate:\n%s", view)
+	}
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("H")})
+	if m.activeHost != "db1" || !strings.Contains(m.View(), "postgres") {
+		t.Errorf("H: showing %q, want db1 with postgres", m.activeHost)
+	}
+}
+
+func TestPerViewFilters(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 120, 30
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{
+		Processes: []model.ProcessSummary{
+			{PID: 1, Name: "curl", UpRate: 10},
+			{PID: 2, Name: "sshd", UpRate: 5},
+		},
+		RemoteHosts: []model.RemoteHostSummary{
+			{Host: "example.com", UpRate: 30},
+			{Host: "github.com", UpRate: 20},
+			{Host: "golang.org", UpRate: 10},
+		},
+		ListenPorts: []model.ListenPortEntry{
+			{Proto: model.ProtoTCP, Port: 22, PID: 2, Process: "sshd"},
+			{Proto: model.ProtoTCP, Port: 8080, PID: 3, Process: "python3"},
+		},
+	}))
+	m = next.(Model)
+	typeText := func(m Model, s string) Model {
+		for _, r := range s {
+			m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		return m
+	}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	esc := tea.KeyMsg{Type: tea.KeyEsc}
+
+	m = press(typeText(m, "/ssh"), enter)
+	if m.table.filter != "ssh" {
+		t.Fatalf("table filter = %q", m.table.filter)
+	}
+
+	// The hosts view starts unfiltered and keeps its own filter and cursor
+	m = typeText(m, "h")
+	if m.mode != ViewRemoteHosts || len(m.remoteHostRows()) != 3 {
+		t.Fatalf("hosts: mode %v, %d rows", m.mode, len(m.remoteHostRows()))
+	}
+	m = press(typeText(m, "/go"), enter)
+	if rows := m.remoteHostRows(); len(rows) != 1 || rows[0].Host != "golang.org" {
+		t.Errorf("hosts filtered to %+v, want golang.org", rows)
+	}
+	bs := tea.KeyMsg{Type: tea.KeyBackspace}
+	m = press(press(typeText(m, "/"), bs), bs) // the prompt opens on "go"
+	m = press(typeText(m, "o"), enter)         // "o" matches all three
+	m = press(press(m, tea.KeyMsg{Type: tea.KeyDown}), tea.KeyMsg{Type: tea.KeyDown})
+	if m.remoteHosts.filter != "o" || m.remoteHosts.cursor != 2 {
+		t.Fatalf("hosts filter %q cursor %d", m.remoteHosts.filter, m.remoteHosts.cursor)
+	}
+
+	m = press(m, esc)
+	if m.table.filter != "ssh" || len(m.table.filtered) != 1 {
+		t.Errorf("process table filter = %q (%d rows) after visiting hosts", m.table.filter, len(m.table.filtered))
+	}
+	m = typeText(m, "l")
+	if m.mode != ViewListenPorts || len(m.listenPortRows()) != 2 {
+		t.Errorf("ports view: mode %v, %d rows; want its own, empty filter", m.mode, len(m.listenPortRows()))
+	}
+	m = press(m, esc)
+
+	m = typeText(m, "h")
+	if m.remoteHosts.filter != "o" || m.remoteHosts.cursor != 2 {
+		t.Errorf("back in hosts: filter %q cursor %d, want o and 2", m.remoteHosts.filter, m.remoteHosts.cursor)
+	}
+	if footer := m.renderFooter(); !strings.Contains(footer, "filter:") {
+		t.Errorf("footer should show the hosts filter: %q", footer)
+	}
+}
+
+func TestSortReverseAndHeaderClick(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 120, 30
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{
+		{PID: 3, Name: "b", UpRate: 10, ConnCount: 1},
+		{PID: 1, Name: "c", UpRate: 30, ConnCount: 3},
+		{PID: 2, Name: "a", UpRate: 20, ConnCount: 2},
+	}}))
+	m = next.(Model)
+	order := func() (pids []uint32) {
+		for _, p := range m.table.filtered {
+			pids = append(pids, p.PID)
+		}
+		return pids
+	}
+	want := func(step string, pids ...uint32) {
+		t.Helper()
+		if got := order(); fmt.Sprint(got) != fmt.Sprint(pids) {
+			t.Errorf("%s: order %v, want %v", step, got, pids)
+		}
+	}
+	want("by rate", 1, 2, 3)
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	want("R", 3, 2, 1)
+	if m.table.sortDescending() {
+		t.Error("R: rate should sort ascending")
+	}
+
+	// Click the PROCESS header: by name, ascending; again: descending
+	click := func() {
+		t.Helper()
+		for y, line := range strings.Split(ansi.Strip(m.View()), "\n") {
+			if x := strings.Index(line, "PROCESS"); x >= 0 && strings.Contains(line, "PID") {
+				next, _ := m.handleMouse(tea.MouseMsg{X: utf8.RuneCountInString(line[:x]), Y: y,
+					Action: tea.MouseActionPress, Button: tea.MouseButtonLeft})
+				m = next.(Model)
+				return
+			}
+		}
+		t.Fatal("no table header on screen")
+	}
+	click()
+	want("click PROCESS", 2, 3, 1)
+	if m.table.sortCol != SortByName || !strings.Contains(ansi.Strip(m.View()), "PROCESS▴") {
+		t.Errorf("click: sort %v, header should mark PROCESS ascending", m.table.sortCol)
+	}
+	click()
+	want("click PROCESS again", 1, 3, 2)
+	if !strings.Contains(ansi.Strip(m.View()), "PROCESS▾") {
+		t.Error("second click: header should mark PROCESS descending")
+	}
+
+	// s moves on to the next column in its natural direction
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	if m.table.sortCol != SortByConns || m.table.sortReverse {
+		t.Errorf("s: sort %v reverse %v", m.table.sortCol, m.table.sortReverse)
+	}
+	want("s", 1, 2, 3)
+}
+
+func TestTreeCollapse(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 120, 30
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{
+		{PID: 1, Name: "init", UpRate: 1},
+		{PID: 10, PPID: 1, Name: "nginx", UpRate: 100, ConnCount: 1},
+		{PID: 11, PPID: 10, Name: "worker1", UpRate: 50, ConnCount: 2},
+		{PID: 12, PPID: 10, Name: "worker2", UpRate: 20, ConnCount: 3},
+	}}))
+	m = next.(Model)
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	if len(m.table.filtered) != 4 {
+		t.Fatalf("tree shows %d rows, want 4", len(m.table.filtered))
+	}
+
+	// ← on worker1 collapses nginx's subtree and selects nginx
+	m.table.selectPID(11)
+	m = press(m, tea.KeyMsg{Type: tea.KeyLeft})
+	if len(m.table.filtered) != 2 {
+		t.Fatalf("collapsed: %d rows, want 2", len(m.table.filtered))
+	}
+	sel := m.table.selected()
+	if sel.PID != 10 || sel.UpRate != 170 || sel.ConnCount != 6 {
+		t.Errorf("collapsed row = PID %d up %v conns %d, want nginx with workers rolled in", sel.PID, sel.UpRate, sel.ConnCount)
+	}
+	if !strings.Contains(ansi.Strip(m.View()), "nginx +2") {
+		t.Error("collapsed row should show +2")
+	}
+	if m.mode != ViewProcessTable {
+		t.Errorf("← switched to mode %v", m.mode)
+	}
+
+	// Collapsed across snapshots, until l expands it
+	next, _ = m.Update(SnapshotMsg(m.snapshot))
+	m = next.(Model)
+	if len(m.table.filtered) != 2 {
+		t.Error("collapse lost on the next snapshot")
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	if len(m.table.filtered) != 4 || m.mode != ViewProcessTable {
+		t.Errorf("l: %d rows in mode %v, want 4 in the table", len(m.table.filtered), m.mode)
+	}
+
+	// Out of tree mode h switches views again
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	if m.mode != ViewRemoteHosts {
+		t.Errorf("h without tree: mode %v", m.mode)
+	}
+}
+
+func TestSortByHistory(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 120, 30
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{
+		{PID: 1, Name: "bursty", UpRate: 900, AvgRate: 100, PeakRate: 5000},
+		{PID: 2, Name: "steady", UpRate: 400, AvgRate: 400, PeakRate: 450},
+		{PID: 3, Name: "old", UpRate: 200}, // no history: its current rate
+	}}))
+	m = next.(Model)
+	order := func() string {
+		var pids []uint32
+		for _, p := range m.table.filtered {
+			pids = append(pids, p.PID)
+		}
+		return fmt.Sprint(pids)
+	}
+
+	m.table.sortBy(SortByAvg)
+	if got := order(); got != "[2 3 1]" {
+		t.Errorf("by avg: %s, want steady, old, bursty", got)
+	}
+	if header := ansi.Strip(m.View()); !strings.Contains(header, "AVG 1m▾") {
+		t.Errorf("graph header should mark the avg sort:\n%s", header)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	if m.table.sortCol != SortByPeak || order() != "[1 2 3]" {
+		t.Errorf("s: sort %v order %s, want peak with bursty first", m.table.sortCol, order())
+	}
+	if header := ansi.Strip(m.View()); !strings.Contains(header, "PEAK 1m▾") {
+		t.Errorf("graph header should mark the peak sort:\n%s", header)
+	}
+}
+
+func TestUnattributedRow(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 160, 30
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{
+		Processes: []model.ProcessSummary{{
+			PID: model.UnattributedPID, Name: model.UnattributedName, DownRate: 2048,
+			Unattributed: map[string]int{model.UnattributedHidden: 3, model.UnattributedNoOwner: 1},
+		}},
+	}))
+	m = next.(Model)
+
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "no owning process") {
+		t.Errorf("footer doesn't explain the %s row:\n%s", model.UnattributedName, view)
+	}
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ViewProcessDetail {
+		t.Fatalf("enter: mode = %v, want detail", m.mode)
+	}
+	view := ansi.Strip(m.View())
+	hidden := strings.Index(view, model.UnattributedReason(model.UnattributedHidden))
+	noOwner := strings.Index(view, model.UnattributedReason(model.UnattributedNoOwner))
+	if hidden < 0 || noOwner < 0 || hidden > noOwner {
+		t.Errorf("detail should list the reasons, most common first:\n%s", view)
+	}
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("K")})
+	if !m.kill.showResult || !strings.HasPrefix(m.kill.result, "Failed") {
+		t.Errorf("kill on %s: result = %q, want a refusal", model.UnattributedName, m.kill.result)
+	}
+}
+
+func TestDetailLeavesOnPIDReuse(t *testing.T) {
+	snap := func(name string, start int64) SnapshotMsg {
+		return SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{
+			{PID: 40, Name: name, StartTime: time.Unix(start, 0), DownRate: 1024},
+		}})
+	}
+	m := New(nil)
+	m.width, m.height = 120, 30
+	next, _ := m.Update(snap("rsync", 1000))
+	m = next.(Model)
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+
+	next, _ = m.Update(snap("rsync", 1000))
+	m = next.(Model)
+	if m.mode != ViewProcessDetail {
+		t.Fatalf("mode = %v, want the detail view while rsync runs", m.mode)
+	}
+	// rsync exits and PID 40 is reused
+	next, _ = m.Update(snap("ssh", 5000))
+	m = next.(Model)
+	if m.mode != ViewProcessTable {
+		t.Errorf("mode = %v, the detail view should not follow the PID to ssh", m.mode)
+	}
+}
+
+func TestKillConfirmsImportant(t *testing.T) {
+	for _, p := range []model.ProcessSummary{
+		{PID: 1, Name: "systemd"},
+		{PID: 20, Name: "nginx", ServiceName: "nginx.service"},
+	} {
+		m := New(nil)
+		m.width, m.height = 120, 30
+		next, _ := m.Update(SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{p}}))
+		m = next.(Model)
+
+		m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("K")})
+		m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+		if !m.kill.confirming || m.kill.showResult {
+			t.Fatalf("%s: enter should ask to confirm, overlay %+v", p.Name, m.kill)
+		}
+		if view := ansi.Strip(m.View()); !strings.Contains(view, "Send SIGTERM to "+p.Name) {
+			t.Errorf("%s: confirmation not shown:\n%s", p.Name, view)
+		}
+		// Anything but y backs out without sending
+		m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+		if m.kill.confirming || m.kill.showResult || !m.kill.active {
+			t.Errorf("%s: n should return to the list, overlay %+v", p.Name, m.kill)
+		}
+	}
+
+	// A user's session isn't a system service
+	if r := importantReason(&model.ProcessSummary{PID: 30, Name: "bash", ServiceName: "user@1000.service"}); r != "" {
+		t.Errorf("user session process: reason %q, want none", r)
+	}
+}
+
+func TestExplainKillError(t *testing.T) {
+	if got := explainKillError(syscall.ESRCH, 42); !strings.Contains(got, "already exited") {
+		t.Errorf("ESRCH: %q", got)
+	}
+	if got := explainKillError(syscall.EPERM, 42); !strings.Contains(got, "another user") {
+		t.Errorf("EPERM: %q", got)
+	}
+}
@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// baseline is the snapshot Z froze, for the process table to show what
+// changed since: each process's rate change and the connections and
+// remote hosts it didn't have then. Processes are told apart by PID and
+// start time, so a PID reused since is a new process.
+type baseline struct {
+	at    time.Time
+	procs map[model.ProcessID]baseProcess
+	hosts map[string]bool // every remote host reached then
+}
+
+// baseProcess is a process as the baseline saw it.
+type baseProcess struct {
+	rate  float64
+	conns map[baseConn]bool
+	hosts map[string]bool
+}
+
+// baseConn identifies a connection across snapshots. A reconnect takes a
+// new local port, so it counts as a new connection.
+type baseConn struct {
+	proto      model.Protocol
+	localPort  uint16
+	remote     string
+	remotePort uint16
+}
+
+func baseConnOf(c *model.Connection) baseConn {
+	return baseConn{c.Proto, c.SrcPort, c.DstIP.String(), c.DstPort}
+}
+
+// remoteHost is the host a connection reaches, "" for none.
+func remoteHost(c *model.Connection) string {
+	if c.DstIP == nil || c.DstIP.IsUnspecified() {
+		return ""
+	}
+	return c.DstIP.String()
+}
+
+func newBaseline(procs []model.ProcessSummary, at time.Time) *baseline {
+	b := &baseline{at: at, procs: make(map[model.ProcessID]baseProcess, len(procs)), hosts: make(map[string]bool)}
+	for i := range procs {
+		p := &procs[i]
+		bp := baseProcess{rate: p.UpRate + p.DownRate, conns: make(map[baseConn]bool), hosts: make(map[string]bool)}
+		for j := range p.Connections {
+			c := &p.Connections[j]
+			bp.conns[baseConnOf(c)] = true
+			if h := remoteHost(c); h != "" {
+				bp.hosts[h] = true
+				b.hosts[h] = true
+			}
+		}
+		b.procs[p.ID()] = bp
+	}
+	return b
+}
+
+// procDelta is how a process changed since the baseline.
+type procDelta struct {
+	isNew    bool    // not running then
+	rate     float64 // bytes/sec, up and down together
+	newConns int
+	newHosts int
+}
+
+// changed reports whether there is anything to highlight.
+func (d procDelta) changed() bool {
+	return d.isNew || d.newConns > 0 || d.newHosts > 0
+}
+
+func (b *baseline) delta(p *model.ProcessSummary) procDelta {
+	bp, ok := b.procs[p.ID()]
+	d := procDelta{isNew: !ok, rate: p.UpRate + p.DownRate - bp.rate}
+	hosts := make(map[string]bool)
+	for j := range p.Connections {
+		c := &p.Connections[j]
+		if !bp.conns[baseConnOf(c)] {
+			d.newConns++
+		}
+		if h := remoteHost(c); h != "" && !bp.hosts[h] && !hosts[h] {
+			hosts[h] = true
+			d.newHosts++
+		}
+	}
+	return d
+}
+
+// summary counts what appeared since the baseline across procs:
+// "3 new processes, 12 new connections, 2 new hosts".
+func (b *baseline) summary(procs []model.ProcessSummary) string {
+	var newProcs, newConns int
+	hosts := make(map[string]bool)
+	for i := range procs {
+		p := &procs[i]
+		if !p.ExitedAt.IsZero() {
+			continue
+		}
+		d := b.delta(p)
+		if d.isNew {
+			newProcs++
+		}
+		newConns += d.newConns
+		for j := range p.Connections {
+			if h := remoteHost(&p.Connections[j]); h != "" && !b.hosts[h] {
+				hosts[h] = true
+			}
+		}
+	}
+	parts := []string{
+		countNoun(newProcs, "new process", "new processes"),
+		countNoun(newConns, "new connection", "new connections"),
+		countNoun(len(hosts), "new host", "new hosts"),
+	}
+	return strings.Join(parts, ", ")
+}
+
+func countNoun(n int, one, many string) string {
+	if n == 1 {
+		return "1 " + one
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// formatRateDelta formats a rate change for the ΔRATE column: "+1.2M",
+// "-300K", or "0" for no change.
+func formatRateDelta(d float64) string {
+	switch {
+	case d >= 1:
+		return "+" + strings.TrimSpace(FormatRateCompact(d))
+	case d <= -1:
+		return "-" + strings.TrimSpace(FormatRateCompact(-d))
+	}
+	return "0"
+}
+
+// toggleBaseline freezes the process table's snapshot as the baseline,
+// or drops the baseline set before.
+func (m *Model) toggleBaseline() {
+	if m.table.base != nil {
+		m.table.base = nil
+		return
+	}
+	m.table.base = newBaseline(m.table.processes, m.now())
+}
+
+// baselineFooterText is the footer's summary of the changes since the
+// baseline, in the process table.
+func (m Model) baselineFooterText() string {
+	b := m.table.base
+	if b == nil || m.mode != ViewProcessTable {
+		return ""
+	}
+	return styleSearchPrompt.Render("baseline:") +
+		styleFooter.Render(FormatAge(m.now().Sub(b.at))+" ago, "+b.summary(m.table.processes))
+}
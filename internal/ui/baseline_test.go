@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestBaseline(t *testing.T) {
+	conn := func(port uint16, remote string) model.Connection {
+		return model.Connection{Proto: model.ProtoTCP, SrcIP: net.ParseIP("10.0.0.2"), SrcPort: port,
+			DstIP: net.ParseIP(remote), DstPort: 443}
+	}
+	m := New(nil)
+	m.width, m.height = 140, 30
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{
+		{PID: 10, Name: "firefox", DownRate: 4096, Connections: []model.Connection{conn(40000, "192.0.2.1")}},
+		{PID: 11, Name: "sshd", UpRate: 2048, StartTime: time.Unix(1000, 0)},
+	}}))
+	m = next.(Model)
+	m = typeKeys(m, "Z")
+	if m.table.base == nil {
+		t.Fatal("Z should set a baseline")
+	}
+
+	// firefox opens a connection to a new host and one to a known host;
+	// sshd's PID is reused by a new process; curl starts
+	next, _ = m.Update(SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{
+		{PID: 10, Name: "firefox", DownRate: 14336, Connections: []model.Connection{
+			conn(40000, "192.0.2.1"), conn(40001, "192.0.2.1"), conn(40002, "198.51.100.7"),
+		}},
+		{PID: 11, Name: "nc", UpRate: 100, StartTime: time.Unix(5000, 0)},
+		{PID: 12, Name: "curl", DownRate: 1024, Connections: []model.Connection{conn(50000, "192.0.2.1")}},
+	}}))
+	m = next.(Model)
+
+	b := m.table.base
+	for _, tt := range []struct {
+		pid  uint32
+		want procDelta
+	}{
+		{10, procDelta{rate: 10240, newConns: 2, newHosts: 1}},
+		{11, procDelta{isNew: true, rate: 100}},
+		{12, procDelta{isNew: true, rate: 1024, newConns: 1, newHosts: 1}},
+	} {
+		for i := range m.table.processes {
+			if p := &m.table.processes[i]; p.PID == tt.pid {
+				if got := b.delta(p); got != tt.want {
+					t.Errorf("PID %d: delta %+v, want %+v", tt.pid, got, tt.want)
+				}
+			}
+		}
+	}
+
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"ΔRATE", "+CONNS", "+HOSTS", "+10K", "new",
+		"baseline:0s ago, 2 new processes, 3 new connections, 1 new host"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+
+	m = typeKeys(m, "Z")
+	if view := ansi.Strip(m.View()); m.table.base != nil || strings.Contains(view, "ΔRATE") || strings.Contains(view, "baseline:") {
+		t.Errorf("a second Z should drop the baseline:\n%s", view)
+	}
+}
+
+func TestFormatRateDelta(t *testing.T) {
+	for d, want := range map[float64]string{0: "0", 0.5: "0", 2048: "+2.0K", -307200: "-300K"} {
+		if got := formatRateDelta(d); got != want {
+			t.Errorf("formatRateDelta(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
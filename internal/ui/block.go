@@ -0,0 +1,249 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/firewall"
+)
+
+// Firewall blocks or rate-limits traffic to remote IPs (B) and caps
+// processes' bandwidth (L) with rules it removes when sstop exits; see
+// internal/firewall.
+type Firewall interface {
+	Block(ip net.IP) (firewall.Rule, error)
+	Limit(ip net.IP, rate uint64) (firewall.Rule, error)
+	LimitProcess(pid uint32, rate uint64) (firewall.Rule, error)
+	Remove(id int) error
+	Rules() []firewall.Rule
+}
+
+// blockOverlay blocks or rate-limits a remote IP and lists the rules
+// sstop created, each removable. Opened without an IP it only lists them.
+type blockOverlay struct {
+	active     bool
+	ip         net.IP // nil: list only
+	host       string // what the IP was shown as
+	cursor     int    // block, limit, then the rules
+	editing    bool   // typing the limit
+	input      textinput.Model
+	rules      []firewall.Rule // as of the last change
+	result     string
+	showResult bool
+}
+
+func newBlockOverlay() blockOverlay {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.Placeholder = "e.g. 100K, 1M"
+	ti.CharLimit = 16
+	return blockOverlay{input: ti}
+}
+
+// open opens the overlay on ip (nil to list the rules), or with why it
+// can't when fw is nil.
+func (b *blockOverlay) open(ip net.IP, host string, fw Firewall) {
+	*b = blockOverlay{active: true, ip: ip, host: host, input: b.input}
+	if fw == nil {
+		b.result = "Failed: blocking needs a live local capture (nftables, root)"
+		b.showResult = true
+		return
+	}
+	b.rules = fw.Rules()
+}
+
+func (b *blockOverlay) close() {
+	b.active = false
+	b.editing = false
+	b.input.Blur()
+}
+
+// actions is the number of rows above the rules: block and limit when
+// there is an IP.
+func (b *blockOverlay) actions() int {
+	if b.ip == nil {
+		return 0
+	}
+	return 2
+}
+
+func (b *blockOverlay) fail(err error) {
+	b.result = "Failed: " + err.Error()
+	b.showResult = true
+}
+
+// update handles a key while the overlay is open.
+func (b *blockOverlay) update(msg tea.KeyMsg, fw Firewall) tea.Cmd {
+	switch {
+	case b.showResult:
+		// Any key closes the result
+		b.close()
+		return nil
+	case b.editing:
+		switch msg.String() {
+		case "enter":
+			b.editing = false
+			b.input.Blur()
+			rate := parseSize(b.input.Value())
+			if rate < 1 {
+				b.fail(fmt.Errorf("invalid rate %q", b.input.Value()))
+				return nil
+			}
+			r, err := fw.Limit(b.ip, uint64(rate))
+			if err != nil {
+				b.fail(err)
+				return nil
+			}
+			b.result = "Added: " + ruleLabel(r) + " (removed when sstop exits)"
+			b.showResult = true
+			return nil
+		case "esc":
+			b.editing = false
+			b.input.Blur()
+			return nil
+		}
+		var cmd tea.Cmd
+		b.input, cmd = b.input.Update(msg)
+		return cmd
+	}
+
+	switch matchKey(msg) {
+	case keyUp:
+		if b.cursor > 0 {
+			b.cursor--
+		}
+	case keyDown:
+		if b.cursor < b.actions()+len(b.rules)-1 {
+			b.cursor++
+		}
+	case keyEsc:
+		b.close()
+	case keyEnter:
+		switch {
+		case b.cursor == 0 && b.ip != nil:
+			r, err := fw.Block(b.ip)
+			if err != nil {
+				b.fail(err)
+				return nil
+			}
+			b.result = "Added: " + ruleLabel(r) + " (removed when sstop exits)"
+			b.showResult = true
+		case b.cursor == 1 && b.ip != nil:
+			b.editing = true
+			b.input.SetValue("")
+			return b.input.Focus()
+		case b.cursor-b.actions() < len(b.rules):
+			if err := fw.Remove(b.rules[b.cursor-b.actions()].ID); err != nil {
+				b.fail(err)
+				return nil
+			}
+			b.rules = fw.Rules()
+			b.cursor = min(b.cursor, max(b.actions()+len(b.rules)-1, 0))
+		}
+	}
+	return nil
+}
+
+// ruleLabel describes a rule, e.g. "limit 192.0.2.1 to 1.0 MB/s".
+func ruleLabel(r firewall.Rule) string {
+	rate := strings.TrimSpace(FormatRate(float64(r.Rate)))
+	switch r.Action {
+	case firewall.Limit:
+		return fmt.Sprintf("limit %s to %s", r.IP, rate)
+	case firewall.LimitProcess:
+		return fmt.Sprintf("limit PID %d's cgroup %s to %s", r.PID, r.Cgroup, rate)
+	}
+	return "block " + r.IP.String()
+}
+
+func (b *blockOverlay) render(width, height int) string {
+	if b.showResult {
+		resultStyle := styleKillResult
+		if strings.HasPrefix(b.result, "Failed") {
+			resultStyle = styleKillResultErr
+		}
+		content := resultStyle.Render(b.result) + "\n\n" +
+			styleDetailLabel.Render("Press any key to close")
+		box := styleKillBorder.Render(content)
+		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+	}
+
+	row := func(i int, text string) string {
+		if i == b.cursor {
+			return styleKillSignalSelected.Render(" ▸ " + text + " ")
+		}
+		return "   " + styleKillSignal.Render(text)
+	}
+
+	var lines []string
+	title := "  Firewall rules"
+	if b.ip != nil {
+		title = "  Block " + b.host
+		if b.host != b.ip.String() {
+			title += " (" + b.ip.String() + ")"
+		}
+		lines = append(lines, row(0, "Block all traffic"))
+		if b.editing {
+			lines = append(lines, styleKillSignalSelected.Render(" ▸ Limit to ")+b.input.View()+styleKillDesc.Render(" /s each way"))
+		} else {
+			lines = append(lines, row(1, "Limit to a rate…"))
+		}
+		lines = append(lines, "")
+	}
+	if len(b.rules) == 0 {
+		lines = append(lines, styleKillDesc.Render("   No rules added this session"))
+	} else {
+		lines = append(lines, styleKillDesc.Render("   Added this session (enter removes):"))
+		for i, r := range b.rules {
+			lines = append(lines, row(b.actions()+i, ruleLabel(r)))
+		}
+	}
+
+	hint := styleDetailLabel.Render("  j/k navigate  enter select  esc cancel")
+	box := styleKillBorder.Render(styleKillTitle.Render(title) + "\n\n" + strings.Join(lines, "\n") + "\n\n" + hint)
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// compactLine renders the overlay as the one-line prompt of a short
+// terminal.
+func (b *blockOverlay) compactLine() string {
+	switch {
+	case b.showResult:
+		return styleKillResult.Render(b.result) + styleDetailLabel.Render("  any key")
+	case b.editing:
+		return styleKillTitle.Render("Limit "+b.host+" to: ") + b.input.View()
+	}
+	sel := "no rules"
+	switch {
+	case b.cursor == 0 && b.ip != nil:
+		sel = "block all traffic"
+	case b.cursor == 1 && b.ip != nil:
+		sel = "limit to a rate"
+	case b.cursor-b.actions() < len(b.rules):
+		sel = "remove " + ruleLabel(b.rules[b.cursor-b.actions()])
+	}
+	title := "Firewall: "
+	if b.ip != nil {
+		title = "Block " + b.host + ": "
+	}
+	return styleKillTitle.Render(title) + styleKillSignalSelected.Render(sel) + styleDetailLabel.Render("  j/k enter esc")
+}
+
+// openBlock opens the block overlay on ip, shown as host; nil ip lists
+// the rules only. Masked addresses aren't real ones, so privacy mode
+// refuses.
+func (m *Model) openBlock(ip net.IP, host string) {
+	if m.privacyOn && ip != nil {
+		m.block.open(nil, "", nil)
+		m.block.result = "Failed: addresses are masked; turn privacy mode off (P) to block"
+		return
+	}
+	if host == "" && ip != nil {
+		host = ip.String()
+	}
+	m.block.open(ip, host, m.firewall)
+}
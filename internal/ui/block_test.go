@@ -0,0 +1,159 @@
+package ui
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/firewall"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// fakeFirewall keeps rules in memory.
+type fakeFirewall struct {
+	rules []firewall.Rule
+}
+
+func (f *fakeFirewall) add(r firewall.Rule) (firewall.Rule, error) {
+	r.ID = len(f.rules) + 1
+	f.rules = append(f.rules, r)
+	return r, nil
+}
+
+func (f *fakeFirewall) Block(ip net.IP) (firewall.Rule, error) {
+	return f.add(firewall.Rule{IP: ip, Action: firewall.Block})
+}
+
+func (f *fakeFirewall) Limit(ip net.IP, rate uint64) (firewall.Rule, error) {
+	return f.add(firewall.Rule{IP: ip, Action: firewall.Limit, Rate: rate})
+}
+
+func (f *fakeFirewall) LimitProcess(pid uint32, rate uint64) (firewall.Rule, error) {
+	for _, r := range f.rules {
+		if r.PID == pid {
+			f.Remove(r.ID)
+		}
+	}
+	return f.add(firewall.Rule{Action: firewall.LimitProcess, PID: pid, Rate: rate, Cgroup: "system.slice/rsync.service"})
+}
+
+func (f *fakeFirewall) Remove(id int) error {
+	for i, r := range f.rules {
+		if r.ID == id {
+			f.rules = append(f.rules[:i], f.rules[i+1:]...)
+		}
+	}
+	return nil
+}
+
+func (f *fakeFirewall) Rules() []firewall.Rule { return f.rules }
+
+func TestBlockOverlay(t *testing.T) {
+	fw := &fakeFirewall{}
+	m := New(nil)
+	m.SetFirewall(fw)
+	m.width, m.height = 120, 30
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{
+		Processes: []model.ProcessSummary{{PID: 10, Name: "curl"}},
+		RemoteHosts: []model.RemoteHostSummary{
+			{Host: "example.com", IP: net.ParseIP("192.0.2.1"), DownRate: 2000},
+			{Host: "198.51.100.7", IP: net.ParseIP("198.51.100.7"), DownRate: 1000},
+		},
+	}))
+	m = next.(Model)
+
+	B := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("B")}
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	m = press(m, B)
+	if !m.block.active || !m.block.ip.Equal(net.ParseIP("192.0.2.1")) {
+		t.Fatalf("B: overlay %+v, want example.com", m.block)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Block example.com (192.0.2.1)") {
+		t.Errorf("overlay should name the host:\n%s", view)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(fw.rules) != 1 || fw.rules[0].Action != firewall.Block || !strings.HasPrefix(m.block.result, "Added: block 192.0.2.1") {
+		t.Fatalf("enter: rules %v, result %q", fw.rules, m.block.result)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+
+	// Limit the second host to 100K/s
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	m = press(m, B)
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("100K")})
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(fw.rules) != 2 || fw.rules[1].Rate != 100*1024 || !fw.rules[1].IP.Equal(net.ParseIP("198.51.100.7")) {
+		t.Fatalf("limit: rules %v", fw.rules)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+
+	// From the table B lists the rules; enter removes one
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	m = press(m, B)
+	view := ansi.Strip(m.View())
+	if !strings.Contains(view, "block 192.0.2.1") || !strings.Contains(view, "limit 198.51.100.7 to") {
+		t.Errorf("rule list:\n%s", view)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(fw.rules) != 1 || fw.rules[0].Action != firewall.Limit || len(m.block.rules) != 1 {
+		t.Errorf("enter on the first rule: rules %v", fw.rules)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+
+	// Privacy mode masks addresses: nothing to block
+	m.privacyOn = true
+	m.mode = ViewRemoteHosts
+	m = press(m, B)
+	if !m.block.showResult || !strings.Contains(m.block.result, "privacy") {
+		t.Errorf("privacy: result %q", m.block.result)
+	}
+}
+
+func TestLimitOverlay(t *testing.T) {
+	fw := &fakeFirewall{}
+	m := New(nil)
+	m.SetFirewall(fw)
+	m.width, m.height = 120, 30
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{{PID: 10, Name: "rsync", UpRate: 5e6}}}))
+	m = next.(Model)
+
+	L := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("L")}
+	m = press(m, L)
+	if !m.limit.active || m.limit.pid != 10 {
+		t.Fatalf("L: overlay %+v, want rsync", m.limit)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "Limit bandwidth: rsync (PID 10)") {
+		t.Errorf("overlay should name the process:\n%s", view)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1M")})
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(fw.rules) != 1 || fw.rules[0].Rate != 1<<20 || !strings.Contains(m.limit.result, "cgroup system.slice/rsync.service") {
+		t.Fatalf("enter: rules %v, result %q", fw.rules, m.limit.result)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+
+	// Opening it again shows the limit; clearing the rate removes it
+	m = press(m, L)
+	if m.limit.current == nil || m.limit.input.Value() != "1M" {
+		t.Fatalf("reopen: current %v, input %q", m.limit.current, m.limit.input.Value())
+	}
+	for range "1M" {
+		m = press(m, tea.KeyMsg{Type: tea.KeyBackspace})
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(fw.rules) != 0 || !strings.HasPrefix(m.limit.result, "Removed") {
+		t.Errorf("clear: rules %v, result %q", fw.rules, m.limit.result)
+	}
+
+	// No firewall (playback, remote): refused
+	pm := New(nil)
+	next, _ = pm.Update(SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{{PID: 10, Name: "rsync"}}}))
+	pm = press(next.(Model), L)
+	if !pm.limit.showResult || !strings.HasPrefix(pm.limit.result, "Failed") {
+		t.Errorf("without a firewall: result %q", pm.limit.result)
+	}
+}
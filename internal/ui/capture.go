@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// PacketCapture is a running capture of one process's traffic to a pcap
+// file (platform.PacketCapture). The snapshot pipeline keeps it fed; the
+// UI only starts, shows and stops it.
+type PacketCapture interface {
+	Write(snap model.Snapshot) error
+	PID() uint32
+	Path() string
+	Packets() int
+	Close() error
+}
+
+// CaptureStarter starts capturing the traffic of process pid, named name.
+type CaptureStarter func(pid uint32, name string) (PacketCapture, error)
+
+// captureState is the detail view's packet capture (w).
+type captureState struct {
+	start  CaptureStarter // nil when capture isn't available
+	active PacketCapture
+	status string // result of the last start or stop
+	failed bool
+}
+
+// SetCaptureStarter enables w in the detail view.
+func (m *Model) SetCaptureStarter(start CaptureStarter) {
+	m.capture.start = start
+}
+
+// SetCapture shows a capture started outside the UI (--capture-pid), so w
+// can stop it.
+func (m *Model) SetCapture(c PacketCapture) {
+	m.capture.active = c
+}
+
+// toggleCapture starts capturing the detail view's process, or stops the
+// running capture if it is of that process.
+func (m *Model) toggleCapture() {
+	c := &m.capture
+	pid := m.detail.pid
+	if c.active != nil {
+		stopped := c.active
+		c.active = nil
+		c.status, c.failed = fmt.Sprintf("pcap saved: %s (%d pkts)", stopped.Path(), stopped.Packets()), false
+		if err := stopped.Close(); err != nil {
+			c.status, c.failed = "pcap: "+err.Error(), true
+		}
+		if stopped.PID() == pid {
+			return
+		}
+	}
+	if c.start == nil {
+		c.status, c.failed = "packet capture is not available here", true
+		return
+	}
+	name := ""
+	if proc := m.findProcess(pid); proc != nil {
+		name = proc.Name
+	}
+	started, err := c.start(pid, name)
+	if err != nil {
+		c.status, c.failed = "pcap: "+err.Error(), true
+		return
+	}
+	c.active, c.status, c.failed = started, "", false
+}
+
+// captureFooterText is the footer's capture indicator: the running
+// capture anywhere, the last result in the detail view.
+func (m Model) captureFooterText() string {
+	c := m.capture
+	switch {
+	case c.active != nil:
+		return stylePaused.Render("● pcap") + styleFooter.Render(fmt.Sprintf(" %d pkts → %s", c.active.Packets(), c.active.Path()))
+	case c.status != "" && m.mode == ViewProcessDetail:
+		if c.failed {
+			return styleKillResultErr.Render(c.status)
+		}
+		return styleFooter.Render(c.status)
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+type fakeCapture struct {
+	pid     uint32
+	packets int
+	closed  bool
+}
+
+func (f *fakeCapture) Write(model.Snapshot) error { return nil }
+func (f *fakeCapture) PID() uint32                { return f.pid }
+func (f *fakeCapture) Path() string               { return "test.pcap" }
+func (f *fakeCapture) Packets() int               { return f.packets }
+func (f *fakeCapture) Close() error               { f.closed = true; return nil }
+
+func TestCaptureKey(t *testing.T) {
+	w := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")}
+	m := New(nil)
+	m.width, m.height = 160, 30
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{{PID: 42, Name: "curl"}}}))
+	m = next.(Model)
+	m.mode = ViewProcessDetail
+	m.detail = newProcessDetail(42)
+
+	// Without a starter (remote, playback, --privacy) w only explains
+	m = press(m, w)
+	if m.capture.active != nil || !strings.Contains(m.renderFooter(), "not available") {
+		t.Errorf("w without a starter: footer %q", m.renderFooter())
+	}
+
+	var started []*fakeCapture
+	var names []string
+	m.SetCaptureStarter(func(pid uint32, name string) (PacketCapture, error) {
+		c := &fakeCapture{pid: pid, packets: 7}
+		started, names = append(started, c), append(names, name)
+		return c, nil
+	})
+	m = press(m, w)
+	if len(started) != 1 || started[0].pid != 42 || names[0] != "curl" {
+		t.Fatalf("w started %+v (%v), want pid 42 named curl", started, names)
+	}
+	if footer := m.renderFooter(); !strings.Contains(footer, "7 pkts → test.pcap") {
+		t.Errorf("footer while capturing: %q", footer)
+	}
+
+	m = press(m, w)
+	if !started[0].closed || m.capture.active != nil || len(started) != 1 {
+		t.Fatalf("second w should stop the capture: %+v", m.capture)
+	}
+	if footer := m.renderFooter(); !strings.Contains(footer, "pcap saved: test.pcap (7 pkts)") {
+		t.Errorf("footer after stopping: %q", footer)
+	}
+
+	// A start error is shown, and nothing runs
+	m.SetCaptureStarter(func(uint32, string) (PacketCapture, error) {
+		return nil, errors.New("needs root")
+	})
+	m = press(m, w)
+	if m.capture.active != nil || !strings.Contains(m.renderFooter(), "pcap: needs root") {
+		t.Errorf("failed start: footer %q", m.renderFooter())
+	}
+}
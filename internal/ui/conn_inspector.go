@@ -0,0 +1,162 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/googlesky/sstop/internal/hostfmt"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+const (
+	connHistoryLen  = 60 // samples kept per connection (1 min at 1s)
+	inspectorRows   = 3  // graph height
+	inspectorLines  = inspectorRows + 3
+	inspectorMinTbl = 5 // connection rows the table keeps before the inspector hides
+)
+
+// connSamples is the recent rate history of one connection.
+type connSamples struct {
+	up, down []float64
+}
+
+func (s *connSamples) push(up, down float64) {
+	s.up = append(s.up, up)
+	s.down = append(s.down, down)
+	if len(s.up) > connHistoryLen {
+		s.up = s.up[1:]
+		s.down = s.down[1:]
+	}
+}
+
+// total returns up+down per sample.
+func (s *connSamples) total() []float64 {
+	out := make([]float64, len(s.up))
+	for i := range s.up {
+		out[i] = s.up[i] + s.down[i]
+	}
+	return out
+}
+
+// connID identifies a connection across snapshots.
+func connID(c *model.Connection) string {
+	return c.Proto.String() + " " + model.AddrPort(c.SrcIP, c.SrcPort) + " " + model.AddrPort(c.DstIP, c.DstPort)
+}
+
+// recordHistory appends the rates of the process's connections to their
+// histories, starting one for new connections and dropping closed ones.
+// History builds up while the detail view is open.
+func (d *processDetail) recordHistory(proc *model.ProcessSummary) {
+	if d.history == nil {
+		d.history = make(map[string]*connSamples)
+	}
+	seen := make(map[string]bool, len(proc.Connections))
+	for i := range proc.Connections {
+		c := &proc.Connections[i]
+		id := connID(c)
+		seen[id] = true
+		s, ok := d.history[id]
+		if !ok {
+			s = &connSamples{}
+			d.history[id] = s
+		}
+		s.push(c.UpRate, c.DownRate)
+	}
+	for id := range d.history {
+		if !seen[id] {
+			delete(d.history, id)
+		}
+	}
+}
+
+// renderInspector renders the connection inspector for the selected
+// connection: a labeled graph of its recent rate, then its totals and TCP
+// counters. It is inspectorLines lines tall.
+func (d *processDetail) renderInspector(c *model.Connection, hosts hostfmt.Style, width int) []string {
+	lines := []string{styleBorder.Render(strings.Repeat("─", width))}
+
+	title := fmt.Sprintf("  %s %s → %s", c.Proto, formatConnAddr(c.SrcIP, c.SrcPort), formatRemote(c, hosts))
+	lines = append(lines, styleTitle.Render(Truncate(title, width)))
+
+	var samples []float64
+	if s := d.history[connID(c)]; s != nil {
+		samples = s.total()
+	}
+	peak := 0.0
+	for _, v := range samples {
+		peak = max(peak, v)
+	}
+	labels := []string{FormatRate(peak), "", "0"}
+	labelW := max(len(labels[0]), 1)
+	graphW := width - labelW - 5 // indent + " ┤ "
+	if graphW < 1 {
+		graphW = 1
+	}
+	for i, row := range tallSparkline(samples, graphW, inspectorRows) {
+		lines = append(lines,
+			styleDetailLabel.Render(fmt.Sprintf("  %*s ┤", labelW, labels[i]))+" "+
+				styleSparklineActive.Render(row))
+	}
+
+	stats := []string{
+		styleHeaderUp.Render("▲ "+FormatRate(c.UpRate)) + "  " + styleHeaderDown.Render("▼ "+FormatRate(c.DownRate)),
+		styleDetailLabel.Render("total ") + styleHeaderUp.Render("▲ "+FormatBytes(c.BytesUp)) + " " +
+			styleHeaderDown.Render("▼ "+FormatBytes(c.BytesDown)),
+	}
+	if c.TCP != nil {
+		stats = append(stats,
+			styleDetailLabel.Render("retrans ")+styleHeaderValue.Render(fmt.Sprint(c.TCP.Retrans)),
+			styleDetailLabel.Render("rtt ")+styleHeaderValue.Render(formatRTT(c.TCP.RTT)),
+		)
+	}
+	lines = append(lines, "  "+strings.Join(stats, "   "))
+	return lines
+}
+
+// tallSparkline renders values as a graph rows lines tall, top line
+// first, scaled to the largest value. Like Sparkline it keeps the newest
+// width values and pads on the left.
+func tallSparkline(values []float64, width, rows int) []string {
+	blocks := []rune{'▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	peak := 0.0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+
+	grid := make([][]rune, rows)
+	for r := range grid {
+		grid[r] = []rune(strings.Repeat(" ", width))
+	}
+	pad := width - len(values)
+	for i, v := range values {
+		if peak <= 0 || v <= 0 {
+			continue
+		}
+		// Eighths of a row, at least one so any traffic shows
+		level := max(int(v/peak*float64(rows*len(blocks))+0.5), 1)
+		for r := 0; r < rows; r++ {
+			fill := level - (rows-1-r)*len(blocks)
+			switch {
+			case fill >= len(blocks):
+				grid[r][pad+i] = blocks[len(blocks)-1]
+			case fill > 0:
+				grid[r][pad+i] = blocks[fill-1]
+			}
+		}
+	}
+
+	out := make([]string, rows)
+	for r := range grid {
+		out[r] = string(grid[r])
+	}
+	return out
+}
+
+// inspectorFits reports whether the inspector fits below a connection
+// table given availRows rows for it.
+func inspectorFits(availRows int) bool {
+	return availRows-inspectorLines >= inspectorMinTbl
+}
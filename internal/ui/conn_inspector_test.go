@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/hostfmt"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestTallSparkline(t *testing.T) {
+	got := tallSparkline([]float64{0, 1, 12, 24}, 6, 3)
+	want := []string{
+		"     █",
+		"    ▄█", // 12 of 24 fills the bottom row and half the middle
+		"   ▁██", // a tiny value still shows one eighth
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("row %d = %q, want %q\nall: %q", i, got[i], want[i], got)
+		}
+	}
+
+	for _, row := range tallSparkline(nil, 4, 3) {
+		if row != "    " {
+			t.Errorf("empty history row = %q, want blanks", row)
+		}
+	}
+}
+
+func TestConnectionInspector(t *testing.T) {
+	conn := func(up float64) model.Connection {
+		return model.Connection{
+			Proto: model.ProtoTCP, SrcIP: net.ParseIP("10.0.0.2"), SrcPort: 51234,
+			DstIP: net.ParseIP("93.184.216.34"), DstPort: 443, State: model.StateEstablished,
+			UpRate: up, BytesUp: 3 << 20, BytesDown: 120 << 20,
+			TCP: &model.TCPInfo{Retrans: 17},
+		}
+	}
+	d := newProcessDetail(10)
+	for _, up := range []float64{100, 5000, 2500} {
+		d.recordHistory(&model.ProcessSummary{PID: 10, Connections: []model.Connection{conn(up)}})
+	}
+	c := conn(0)
+	if s := d.history[connID(&c)]; s == nil || len(s.up) != 3 {
+		t.Fatalf("history = %+v, want 3 samples", s)
+	}
+
+	proc := &model.ProcessSummary{PID: 10, Name: "curl", Connections: []model.Connection{conn(2500)}}
+	out := d.render(proc, hostfmt.FQDN, 120, 30)
+	for _, want := range []string{"┤", "retrans 17", "120.0 MB"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("inspector missing %q:\n%s", want, out)
+		}
+	}
+	if h := lipgloss.Height(out); h > 30 {
+		t.Errorf("detail view is %d lines, want <= 30", h)
+	}
+
+	// Too short for both: the table wins
+	if out := d.render(proc, hostfmt.FQDN, 120, 10); strings.Contains(out, "retrans") {
+		t.Errorf("inspector shown in a 10-line view:\n%s", out)
+	}
+
+	// Closed connections drop out of the history
+	d.recordHistory(&model.ProcessSummary{PID: 10})
+	if len(d.history) != 0 {
+		t.Errorf("history kept %d closed connections", len(d.history))
+	}
+}
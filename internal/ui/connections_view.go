@@ -0,0 +1,322 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/hostfmt"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// connEntry is one connection in the system-wide connections view.
+type connEntry struct {
+	model.Connection
+	PID     uint32
+	Process string
+}
+
+// remote is the connection's peer: its host named in the style with the
+// port, or the address.
+func (e *connEntry) remote(hosts hostfmt.Style) string {
+	return formatRemote(&e.Connection, hosts)
+}
+
+// state is the TCP state, "-" for UDP.
+func (e *connEntry) state() string {
+	if e.Proto == model.ProtoUDP {
+		return "-"
+	}
+	return e.State.String()
+}
+
+// connSort defines the connections view sort order.
+type connSort int
+
+const (
+	connSortRate    connSort = iota // total bandwidth (default)
+	connSortRemote                  // remote host or address
+	connSortState                   // TCP state
+	connSortProcess                 // process name
+	connSortCount
+)
+
+// connectionsView lists every connection of every process, like ss -tunp
+// with live rates.
+type connectionsView struct {
+	cursor     int
+	offset     int
+	viewHeight int
+	window     listWindow // rows last drawn
+	sortBy     connSort
+	scope      connScope // drill-down from the Listen Ports view
+	filter     string    // / in this view
+}
+
+// connScope limits the connections view to the clients of one listening
+// port.
+type connScope struct {
+	active bool
+	proto  model.Protocol
+	ip     net.IP // nil or unspecified: any local address
+	port   uint16
+	label  string
+}
+
+// newConnScope scopes the connections view to a listening port.
+func newConnScope(lp *model.ListenPortEntry) connScope {
+	return connScope{active: true, proto: lp.Proto, ip: lp.IP, port: lp.Port,
+		label: lp.Proto.String() + " " + formatListenAddr(lp)}
+}
+
+// filter returns the connections in scope (all of them when no scope is
+// set): inbound ones to the port.
+func (s connScope) filter(conns []connEntry) []connEntry {
+	if !s.active {
+		return conns
+	}
+	var out []connEntry
+	for _, c := range conns {
+		if c.Direction != model.DirInbound || c.Proto != s.proto || c.SrcPort != s.port {
+			continue
+		}
+		if s.ip != nil && !s.ip.IsUnspecified() && !s.ip.Equal(c.SrcIP) {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func newConnectionsView() connectionsView {
+	return connectionsView{}
+}
+
+func (v *connectionsView) moveUp() {
+	if v.cursor > 0 {
+		v.cursor--
+	}
+}
+
+func (v *connectionsView) moveDown(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	if v.cursor < maxIdx {
+		v.cursor++
+	}
+}
+
+func (v *connectionsView) pageUp() {
+	v.cursor -= v.viewHeight / 2
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+}
+
+func (v *connectionsView) pageDown(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	v.cursor += v.viewHeight / 2
+	if v.cursor > maxIdx {
+		v.cursor = maxIdx
+	}
+}
+
+func (v *connectionsView) goHome() {
+	v.cursor = 0
+}
+
+func (v *connectionsView) goEnd(maxIdx int) {
+	if maxIdx < 0 {
+		v.cursor = 0
+		return
+	}
+	v.cursor = maxIdx
+}
+
+func (v *connectionsView) nextSort() {
+	v.sortBy = (v.sortBy + 1) % connSortCount
+	v.cursor, v.offset = 0, 0
+}
+
+// buildConnections flattens the processes' connections, sorted.
+func buildConnections(procs []model.ProcessSummary, sortBy connSort, hosts hostfmt.Style) []connEntry {
+	var out []connEntry
+	for i := range procs {
+		p := &procs[i]
+		for _, c := range p.Connections {
+			out = append(out, connEntry{Connection: c, PID: p.PID, Process: p.Name})
+		}
+	}
+	rate := func(e *connEntry) float64 { return e.UpRate + e.DownRate }
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := &out[i], &out[j]
+		switch sortBy {
+		case connSortRemote:
+			if ra, rb := a.remote(hosts), b.remote(hosts); ra != rb {
+				return ra < rb
+			}
+		case connSortState:
+			if sa, sb := a.state(), b.state(); sa != sb {
+				return sa < sb
+			}
+		case connSortProcess:
+			if a.Process != b.Process {
+				return strings.ToLower(a.Process) < strings.ToLower(b.Process)
+			}
+			if a.PID != b.PID {
+				return a.PID < b.PID
+			}
+		}
+		return rate(a) > rate(b)
+	})
+	return out
+}
+
+// connectionRows returns the connections view's rows matching its filter
+// (protocol, addresses, hostname, service, state, process or PID; or a
+// connection term: proto:, state:, ip:, age>).
+func (m *Model) connectionRows() []connEntry {
+	conns := m.connections.scope.filter(buildConnections(m.snapshot.Processes, m.connections.sortBy, m.hostNames))
+	f := ParseFilter(m.connections.filter)
+	if f.IsEmpty() {
+		return conns
+	}
+	var out []connEntry
+	for _, c := range conns {
+		if f.eval(func(t Filter) bool {
+			if t.isConnTerm() {
+				return t.matchConn(&c.Connection)
+			}
+			return containsFold(textTerm(t), c.Proto.String(), formatConnAddr(c.SrcIP, c.SrcPort), formatConnAddr(c.DstIP, c.DstPort),
+				c.RemoteHost, c.Service, c.state(), c.Process, fmt.Sprint(c.PID))
+		}) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// openConnection shows the detail view of a connection's process.
+func (m *Model) openConnection(c connEntry) {
+	if m.findProcess(c.PID) == nil {
+		return
+	}
+	m.mode = ViewProcessDetail
+	m.detail = newProcessDetail(c.PID)
+}
+
+// Column widths
+const (
+	cvProtoW = 5
+	cvStateW = 11 // ESTABLISHED
+	cvProcW  = 16
+	cvPidW   = 7
+	cvRateW  = 8
+)
+
+func (v *connectionsView) render(conns []connEntry, hosts hostfmt.Style, width, height int) string {
+	v.viewHeight = height
+	v.window = listWindow{}
+
+	if len(conns) > 0 && v.cursor >= len(conns) {
+		v.cursor = len(conns) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+
+	titleText := fmt.Sprintf("  Connections (%d)", len(conns))
+	if v.scope.active {
+		titleText += " — clients of " + v.scope.label
+	}
+	titleLine := styleTitle.Render(titleText)
+
+	// PROTO | LOCAL | REMOTE | STATE | PROCESS | PID | UP/s | DOWN/s; the
+	// addresses share what's left, remote getting the larger part
+	fixedW := cvProtoW + cvStateW + cvProcW + cvPidW + 2*cvRateW + 9 // indent + 7 gaps
+	addrW := rowsWidth(width, len(conns), height-2) - fixedW
+	if addrW < 30 {
+		addrW = 30
+	}
+	localW := addrW * 2 / 5
+	remoteW := addrW - localW - 1
+
+	label := func(name string, s connSort) string {
+		if v.sortBy == s {
+			return name + "▾"
+		}
+		return name
+	}
+	headerLine := fmt.Sprintf("  %-*s %-*s %-*s %-*s %-*s %-*s %*s %*s",
+		cvProtoW, "PROTO",
+		localW, "LOCAL",
+		remoteW, label("REMOTE", connSortRemote),
+		cvStateW, label("STATE", connSortState),
+		cvProcW, label("PROCESS", connSortProcess),
+		cvPidW, "PID",
+		cvRateW, label("UP/s", connSortRate),
+		cvRateW, label("DOWN/s", connSortRate),
+	)
+	headerStyled := styleTableHeader.Render(headerLine)
+
+	rowsAvail := height - 2 // title + header
+	if rowsAvail < 1 {
+		rowsAvail = 1
+	}
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+rowsAvail {
+		v.offset = v.cursor - rowsAvail + 1
+	}
+
+	if len(conns) == 0 {
+		msg := "  No connections"
+		if v.scope.active {
+			msg = "  No clients connected"
+		}
+		empty := styleDetailLabel.Render(msg)
+		return strings.Join([]string{titleLine, headerStyled, empty}, "\n")
+	}
+
+	var rows []string
+	end := v.offset + rowsAvail
+	if end > len(conns) {
+		end = len(conns)
+	}
+	v.window = listWindow{offset: v.offset, rows: end - v.offset, total: len(conns)}
+	for idx := v.offset; idx < end; idx++ {
+		c := &conns[idx]
+		proto := protoLabel(c.Proto, c.SrcPort, c.DstPort)
+		line := fmt.Sprintf("%-*s %-*s %-*s %-*s %-*d %*s %*s",
+			localW, truncateStr(formatConnAddr(c.SrcIP, c.SrcPort), localW),
+			remoteW, truncateStr(c.remote(hosts), remoteW),
+			cvStateW, c.state(),
+			cvProcW, truncateStr(c.Process, cvProcW),
+			cvPidW, c.PID,
+			cvRateW, FormatRateCompact(c.UpRate),
+			cvRateW, FormatRateCompact(c.DownRate),
+		)
+
+		var rowStyle lipgloss.Style
+		if idx == v.cursor {
+			rowStyle = styleTableRowSelected
+		} else if idx%2 == 1 {
+			rowStyle = styleZebraRow
+		} else {
+			rowStyle = styleTableRow
+		}
+		rows = append(rows, rowStyle.Render("  ")+
+			protoStyle(proto).Inherit(rowStyle).Render(fmt.Sprintf("%-*s ", cvProtoW, proto))+
+			rowStyle.Render(line))
+	}
+
+	parts := []string{titleLine, headerStyled}
+	parts = append(parts, withScrollbar(rows, v.window, width)...)
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/googlesky/sstop/internal/hostfmt"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func testConnectionsSnapshot() model.Snapshot {
+	local := net.ParseIP("10.0.0.2")
+	conn := func(sport uint16, dst string, dport uint16, host string, state model.SocketState, up float64) model.Connection {
+		return model.Connection{
+			Proto: model.ProtoTCP, SrcIP: local, SrcPort: sport, DstIP: net.ParseIP(dst), DstPort: dport,
+			RemoteHost: host, State: state, UpRate: up,
+		}
+	}
+	return model.Snapshot{Processes: []model.ProcessSummary{
+		{PID: 10, Name: "curl", Connections: []model.Connection{
+			conn(50000, "93.184.216.34", 443, "example.com", model.StateEstablished, 500),
+		}},
+		{PID: 20, Name: "sshd", Connections: []model.Connection{
+			conn(22, "198.51.100.7", 61000, "", model.StateEstablished, 2000),
+			conn(22, "198.51.100.8", 61001, "", model.StateTimeWait, 0),
+		}},
+		{PID: 30, Name: "dnsmasq", Connections: []model.Connection{
+			{Proto: model.ProtoUDP, SrcIP: local, SrcPort: 53, DstIP: net.ParseIP("10.0.0.9"), DstPort: 40000, DownRate: 100},
+		}},
+	}}
+}
+
+func TestBuildConnections(t *testing.T) {
+	procs := testConnectionsSnapshot().Processes
+	pids := func(conns []connEntry) (out []uint32) {
+		for _, c := range conns {
+			out = append(out, c.PID)
+		}
+		return out
+	}
+	tests := []struct {
+		sort connSort
+		want []uint32
+	}{
+		{connSortRate, []uint32{20, 10, 30, 20}},
+		{connSortRemote, []uint32{30, 20, 20, 10}}, // addresses sort before names
+		{connSortState, []uint32{30, 20, 10, 20}},  // "-" (UDP), ESTABLISHED, TIME_WAIT
+		{connSortProcess, []uint32{10, 30, 20, 20}},
+	}
+	for _, tt := range tests {
+		if got := pids(buildConnections(procs, tt.sort, hostfmt.FQDN)); !slices.Equal(got, tt.want) {
+			t.Errorf("sort %d: %v, want %v", tt.sort, got, tt.want)
+		}
+	}
+}
+
+func TestConnectionsView(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 140, 20
+	next, _ := m.Update(SnapshotMsg(testConnectionsSnapshot()))
+	m = next.(Model)
+	key := func(k string) { m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}) }
+
+	key("v")
+	if m.mode != ViewConnections {
+		t.Fatalf("v: mode %v, want the connections view", m.mode)
+	}
+	view := m.View()
+	for _, want := range []string{"Connections (4)", "example.com:443", "TIME_WAIT", "dnsmasq"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	// d cycles hostnames: short, then addresses, then back
+	key("d")
+	if view := m.View(); strings.Contains(view, "example.com") || !strings.Contains(view, "example:443") {
+		t.Errorf("d should show the short hostname:\n%s", view)
+	}
+	key("d")
+	if view := m.View(); strings.Contains(view, "example:443") || !strings.Contains(view, "93.184.216.34:443") {
+		t.Errorf("d d should show the remote address:\n%s", view)
+	}
+	key("d")
+
+	// Filter by hostname, then open its process
+	key("/")
+	for _, r := range "example" {
+		m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if rows := m.connectionRows(); len(rows) != 1 || rows[0].PID != 10 {
+		t.Fatalf("filtered rows = %+v", rows)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ViewProcessDetail || m.detail.pid != 10 {
+		t.Errorf("enter: mode %v pid %d, want curl's detail", m.mode, m.detail.pid)
+	}
+}
+
+func TestProtoBadgesAndFilters(t *testing.T) {
+	snap := testConnectionsSnapshot()
+	local := net.ParseIP("10.0.0.2")
+	snap.Processes = append(snap.Processes, model.ProcessSummary{PID: 40, Name: "chrome", Connections: []model.Connection{
+		{Proto: model.ProtoUDP, SrcIP: local, SrcPort: 51000, DstIP: net.ParseIP("142.250.80.46"), DstPort: 443, DownRate: 9000},
+	}})
+	snap.RemoteHosts = []model.RemoteHostSummary{
+		{Host: "example.com", IP: net.ParseIP("93.184.216.34")},
+		{Host: "142.250.80.46", IP: net.ParseIP("142.250.80.46")},
+	}
+	snap.ListenPorts = []model.ListenPortEntry{
+		{Proto: model.ProtoTCP, Port: 22, PID: 20, Process: "sshd"},
+		{Proto: model.ProtoUDP, Port: 53, PID: 30, Process: "dnsmasq"},
+	}
+	m := New(nil)
+	m.width, m.height = 140, 30
+	next, _ := m.Update(SnapshotMsg(snap))
+	m = next.(Model)
+
+	if got := protoLabel(model.ProtoUDP, 51000, 443); got != "QUIC" {
+		t.Errorf("UDP to 443 labelled %q, want QUIC", got)
+	}
+
+	m.mode = ViewConnections
+	count := func(filter string, rows func() int) int {
+		m.setViewFilter(filter)
+		return rows()
+	}
+	conns := func() int { return len(m.connectionRows()) }
+	if n := count("proto:udp", conns); n != 2 {
+		t.Errorf("proto:udp: %d connections, want dnsmasq's and chrome's", n)
+	}
+	if n := count("proto:quic", conns); n != 1 {
+		t.Errorf("proto:quic: %d connections, want chrome's", n)
+	}
+	if n := count("proto:tcp", conns); n != 3 {
+		t.Errorf("proto:tcp: %d connections, want 3", n)
+	}
+	m.setViewFilter("")
+	if view := m.View(); !strings.Contains(view, "QUIC") || !strings.Contains(view, "UDP") {
+		t.Error("connections view should badge UDP and QUIC rows")
+	}
+
+	m.mode = ViewRemoteHosts
+	hosts := count("proto:quic", func() int { return len(m.remoteHostRows()) })
+	if hosts != 1 || m.remoteHostRows()[0].Host != "142.250.80.46" {
+		t.Errorf("proto:quic hosts = %v", m.remoteHostRows())
+	}
+
+	m.mode = ViewListenPorts
+	if n := count("proto:udp", func() int { return len(m.listenPortRows()) }); n != 1 {
+		t.Errorf("proto:udp: %d listening ports, want dnsmasq's", n)
+	}
+
+	m.mode = ViewProcessTable
+	m.setViewFilter("proto:quic")
+	if len(m.table.filtered) != 1 || m.table.filtered[0].Name != "chrome" {
+		t.Errorf("process table proto:quic = %v", m.table.filtered)
+	}
+}
@@ -0,0 +1,261 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// countryEntry is remote host bandwidth aggregated by country, or by the
+// autonomous system announcing the hosts.
+type countryEntry struct {
+	Key       string // country code ("US", "LAN") or "AS15169"; "" = unknown
+	Label     string // "🇺🇸 US" or "AS15169 GOOGLE"
+	Hosts     int
+	ConnCount int
+	UpRate    float64
+	DownRate  float64
+	Top       string // busiest network (by country) or country (by AS)
+}
+
+// countriesView manages the country/ASN aggregation view.
+type countriesView struct {
+	cursor     int
+	offset     int
+	viewHeight int
+	window     listWindow // rows last drawn
+	byASN      bool       // group by autonomous system instead of country
+}
+
+func (v *countriesView) moveUp() {
+	if v.cursor > 0 {
+		v.cursor--
+	}
+}
+
+func (v *countriesView) moveDown(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	if v.cursor < maxIdx {
+		v.cursor++
+	}
+}
+
+func (v *countriesView) pageUp() {
+	v.cursor -= v.viewHeight / 2
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+}
+
+func (v *countriesView) pageDown(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	v.cursor += v.viewHeight / 2
+	if v.cursor > maxIdx {
+		v.cursor = maxIdx
+	}
+}
+
+func (v *countriesView) goHome() {
+	v.cursor = 0
+}
+
+func (v *countriesView) goEnd(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	v.cursor = maxIdx
+}
+
+func (v *countriesView) toggleGrouping() {
+	v.byASN = !v.byASN
+	v.cursor, v.offset = 0, 0
+}
+
+// countryKey returns the group a remote host falls in.
+func countryKey(h *model.RemoteHostSummary, byASN bool) string {
+	if !byASN {
+		return h.CountryCode
+	}
+	if h.ASN == 0 {
+		return ""
+	}
+	return "AS" + strconv.FormatUint(uint64(h.ASN), 10)
+}
+
+// buildCountries aggregates remote hosts by country (or AS), busiest first.
+func buildCountries(hosts []model.RemoteHostSummary, byASN bool) []countryEntry {
+	groups := make(map[string]*countryEntry)
+	tops := make(map[string]map[string]float64) // group → sub-label → rate
+
+	for i := range hosts {
+		h := &hosts[i]
+		key := countryKey(h, byASN)
+		e, ok := groups[key]
+		if !ok {
+			e = &countryEntry{Key: key, Label: "unknown"}
+			switch {
+			case key == "":
+			case byASN:
+				e.Label = strings.TrimSpace(key + " " + h.ASOrg)
+			case h.Country != "":
+				e.Label = h.Country
+			default:
+				e.Label = key
+			}
+			groups[key] = e
+			tops[key] = make(map[string]float64)
+		}
+		e.Hosts++
+		e.ConnCount += h.ConnCount
+		e.UpRate += h.UpRate
+		e.DownRate += h.DownRate
+
+		sub := h.ASOrg
+		if byASN {
+			sub = h.CountryCode
+		}
+		if sub != "" {
+			tops[key][sub] += h.UpRate + h.DownRate
+		}
+	}
+
+	result := make([]countryEntry, 0, len(groups))
+	for key, e := range groups {
+		best := -1.0
+		for sub, rate := range tops[key] {
+			if rate > best || (rate == best && sub < e.Top) {
+				e.Top, best = sub, rate
+			}
+		}
+		result = append(result, *e)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := &result[i], &result[j]
+		// Unknown last: it's a catch-all, not a place
+		if (a.Key == "") != (b.Key == "") {
+			return b.Key == ""
+		}
+		ta, tb := a.UpRate+a.DownRate, b.UpRate+b.DownRate
+		if ta != tb {
+			return ta > tb
+		}
+		if a.ConnCount != b.ConnCount {
+			return a.ConnCount > b.ConnCount
+		}
+		return a.Key < b.Key
+	})
+	return result
+}
+
+func (v *countriesView) render(hosts []model.RemoteHostSummary, width, height int) string {
+	entries := buildCountries(hosts, v.byASN)
+
+	v.viewHeight = height
+	v.window = listWindow{}
+
+	if len(entries) > 0 && v.cursor >= len(entries) {
+		v.cursor = len(entries) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+
+	title := "  Countries"
+	groupCol, topCol := "COUNTRY", "TOP NETWORK"
+	if v.byASN {
+		title = "  Networks (AS)"
+		groupCol, topCol = "NETWORK", "TOP COUNTRY"
+	}
+	titleLine := styleTitle.Render(title)
+
+	// Column widths
+	// COUNTRY | HOSTS | CONNS | UP/s | DOWN/s | TOP
+	hostsW := 6
+	connsW := 6
+	upW := 8
+	downW := 8
+	topW := 16
+	fixedW := hostsW + connsW + upW + downW + topW + 8 // 8 for indent/separators
+	labelW := rowsWidth(width, len(entries), height-2) - fixedW
+	if labelW < 12 {
+		labelW = 12
+	}
+
+	headerLine := fmt.Sprintf("  %-*s %*s %*s %*s %*s %-*s",
+		labelW, groupCol,
+		hostsW, "HOSTS",
+		connsW, "CONNS",
+		upW, "UP/s▾",
+		downW, "DOWN/s",
+		topW, topCol,
+	)
+	headerStyled := styleTableHeader.Render(headerLine)
+
+	rowsAvail := height - 2 // title + header
+	if rowsAvail < 1 {
+		rowsAvail = 1
+	}
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+rowsAvail {
+		v.offset = v.cursor - rowsAvail + 1
+	}
+
+	if len(entries) == 0 {
+		empty := styleDetailLabel.Render("  No remote host connections")
+		return strings.Join([]string{titleLine, headerStyled, empty}, "\n")
+	}
+
+	var rows []string
+	end := v.offset + rowsAvail
+	if end > len(entries) {
+		end = len(entries)
+	}
+	v.window = listWindow{offset: v.offset, rows: end - v.offset, total: len(entries)}
+
+	for idx := v.offset; idx < end; idx++ {
+		e := entries[idx]
+
+		top := e.Top
+		if top == "" {
+			top = "-"
+		}
+		// Flags are double-width: pad by cells, not runes
+		line := "  " + padCells(Truncate(e.Label, labelW), labelW) +
+			fmt.Sprintf(" %*d %*d %*s %*s %-*s",
+				hostsW, e.Hosts,
+				connsW, e.ConnCount,
+				upW, FormatRateCompact(e.UpRate),
+				downW, FormatRateCompact(e.DownRate),
+				topW, truncateStr(top, topW),
+			)
+
+		var rowStyle lipgloss.Style
+		if idx == v.cursor {
+			rowStyle = styleTableRowSelected
+		} else if idx%2 == 1 {
+			rowStyle = styleZebraRow
+		} else {
+			rowStyle = styleTableRow
+		}
+
+		rows = append(rows, rowStyle.Render(line))
+	}
+
+	var parts []string
+	parts = append(parts, titleLine)
+	parts = append(parts, headerStyled)
+	parts = append(parts, withScrollbar(rows, v.window, width)...)
+
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/hostfmt"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func testRemoteHosts() []model.RemoteHostSummary {
+	return []model.RemoteHostSummary{
+		{Host: "dns.google", Country: "🇺🇸 US", CountryCode: "US", ASN: 15169, ASOrg: "GOOGLE", UpRate: 100, DownRate: 900, ConnCount: 3},
+		{Host: "github.com", Country: "🇺🇸 US", CountryCode: "US", UpRate: 50, DownRate: 50, ConnCount: 1},
+		{Host: "hetzner.de", Country: "🇩🇪 DE", CountryCode: "DE", UpRate: 400, DownRate: 0, ConnCount: 2},
+		{Host: "gstatic.de", Country: "🇩🇪 DE", CountryCode: "DE", ASN: 15169, ASOrg: "GOOGLE", UpRate: 10, DownRate: 10, ConnCount: 1},
+		{Host: "10.0.0.1", Country: "🏠 LAN", CountryCode: "LAN", ConnCount: 5},
+		{Host: "?", UpRate: 5000, ConnCount: 1},
+	}
+}
+
+func TestBuildCountries(t *testing.T) {
+	got := buildCountries(testRemoteHosts(), false)
+
+	// Busiest first, unknown last whatever its rate
+	wantOrder := []string{"US", "DE", "LAN", ""}
+	if len(got) != len(wantOrder) {
+		t.Fatalf("got %d countries, want %d: %+v", len(got), len(wantOrder), got)
+	}
+	for i, want := range wantOrder {
+		if got[i].Key != want {
+			t.Errorf("countries[%d] = %q, want %q", i, got[i].Key, want)
+		}
+	}
+	us := got[0]
+	if us.Hosts != 2 || us.ConnCount != 4 || us.UpRate != 150 || us.DownRate != 950 {
+		t.Errorf("US = %+v", us)
+	}
+	if us.Label != "🇺🇸 US" || us.Top != "GOOGLE" {
+		t.Errorf("US label/top = %q/%q", us.Label, us.Top)
+	}
+	if got[3].Label != "unknown" {
+		t.Errorf("unknown bucket label = %q", got[3].Label)
+	}
+
+	byAS := buildCountries(testRemoteHosts(), true)
+	if byAS[0].Key != "AS15169" || byAS[0].Label != "AS15169 GOOGLE" || byAS[0].Hosts != 2 {
+		t.Errorf("AS grouping first = %+v", byAS[0])
+	}
+	if byAS[0].Top != "US" {
+		t.Errorf("AS15169 top country = %q, want US", byAS[0].Top)
+	}
+	if len(byAS) != 2 || byAS[1].Key != "" || byAS[1].Hosts != 4 {
+		t.Errorf("AS grouping = %+v, want GOOGLE + unknown(4)", byAS)
+	}
+}
+
+func TestCountriesDrillDown(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 120, 30
+	m.snapshot.RemoteHosts = testRemoteHosts()
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("C")})
+	if m.mode != ViewCountries {
+		t.Fatalf("C: mode = %v, want countries", m.mode)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyDown})
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ViewRemoteHosts {
+		t.Fatalf("enter: mode = %v, want remote hosts", m.mode)
+	}
+	hosts := m.remoteHostRows()
+	if len(hosts) != 2 || hosts[0].CountryCode != "DE" || hosts[1].CountryCode != "DE" {
+		t.Errorf("drill-down into DE shows %+v", hosts)
+	}
+	if out := m.remoteHosts.render(hosts, m.hostNames, m.width, 10); !strings.Contains(out, "Remote Hosts — 🇩🇪 DE") {
+		t.Errorf("scoped title missing:\n%s", out)
+	}
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.mode != ViewCountries || m.remoteHosts.scope.active {
+		t.Errorf("esc from drill-down: mode = %v scope = %+v", m.mode, m.remoteHosts.scope)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	if len(m.remoteHostRows()) != len(testRemoteHosts()) {
+		t.Error("h should show all hosts")
+	}
+}
+
+func TestRemoteHostsCountryColumn(t *testing.T) {
+	v := remoteHostsView{showCountry: true}
+	out := v.render(testRemoteHosts(), hostfmt.FQDN, 100, 10)
+	lines := strings.Split(out, "\n")
+	if !strings.Contains(lines[1], "COUNTRY/AS") {
+		t.Fatalf("header = %q", lines[1])
+	}
+	for _, line := range lines[2:] {
+		if w := lipgloss.Width(line); w > 100 {
+			t.Errorf("row is %d cells wide, want <= 100: %q", w, line)
+		}
+	}
+	if !strings.Contains(out, "🇺🇸 US GOOGLE") {
+		t.Errorf("country column missing AS org:\n%s", out)
+	}
+}
+
+func TestHostStyleAcrossViews(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 140, 20
+	ip := net.ParseIP("140.82.112.3")
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{
+		Processes: []model.ProcessSummary{{PID: 1, Name: "git", TopDest: "lb.github.com", TopDestIP: ip}},
+		RemoteHosts: []model.RemoteHostSummary{
+			{Host: "lb.github.com", IP: ip, DownRate: 100, ConnCount: 1, Processes: []string{"git"}},
+		},
+	}))
+	m = next.(Model)
+
+	hostsView := func() string { return m.remoteHosts.render(m.remoteHostRows(), m.hostNames, m.width, 10) }
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if out := hostsView(); strings.Contains(out, "lb.github.com") || !strings.Contains(out, "lb ") {
+		t.Errorf("short style should show lb:\n%s", out)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if out := hostsView(); !strings.Contains(out, "140.82.112.3") {
+		t.Errorf("ip style should show the address:\n%s", out)
+	}
+	if got := formatTopDest(&m.snapshot.Processes[0], m.table.hosts); got != "140.82.112.3" {
+		t.Errorf("TOP DEST = %q, want the address", got)
+	}
+	if footer := m.renderFooter(); !strings.Contains(footer, "hosts:ip") {
+		t.Errorf("footer should show the host style: %s", footer)
+	}
+}
@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"runtime/debug"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Crash is a panic caught in the TUI, with the last model Update returned
+// before it.
+type Crash struct {
+	Value any
+	Stack []byte
+	Last  Model
+}
+
+// Guarded runs a Model and keeps the panic that ends it for a crash
+// report. The panic still propagates, so Bubble Tea restores the terminal
+// and Program.Run returns an error.
+type Guarded struct {
+	model Model
+	crash *Crash // shared by the copies Bubble Tea keeps
+}
+
+// Guard wraps m to catch its panics.
+func Guard(m Model) Guarded {
+	return Guarded{model: m, crash: new(Crash)}
+}
+
+// Crash returns the panic that ended the program, nil if it didn't panic.
+func (g Guarded) Crash() *Crash {
+	if g.crash.Value == nil {
+		return nil
+	}
+	return g.crash
+}
+
+func (g Guarded) recover() {
+	if r := recover(); r != nil {
+		if g.crash.Value == nil {
+			*g.crash = Crash{Value: r, Stack: debug.Stack(), Last: g.model}
+		}
+		panic(r)
+	}
+}
+
+func (g Guarded) Init() tea.Cmd {
+	defer g.recover()
+	return g.model.Init()
+}
+
+func (g Guarded) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer g.recover()
+	next, cmd := g.model.Update(msg)
+	g.model = next.(Model)
+	return g, cmd
+}
+
+func (g Guarded) View() string {
+	defer g.recover()
+	return g.model.View()
+}
+
+// AlertLog returns the alerts that fired this session, oldest first.
+func (m Model) AlertLog() []string {
+	out := make([]string, len(m.alert.log))
+	for i, e := range m.alert.log {
+		out[i] = e.String()
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// panickyCollector panics when the refresh interval changes.
+type panickyCollector struct{}
+
+func (panickyCollector) SetInterval(time.Duration) { panic("boom") }
+
+func TestGuardKeepsPanic(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 100, 20
+	m.alert.threshold = 1000
+	m.SetCollector(panickyCollector{})
+	g := Guard(m)
+
+	snap := model.Snapshot{
+		Timestamp: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Processes: []model.ProcessSummary{{PID: 10, Name: "curl", DownRate: 5000}},
+	}
+	next, _ := g.Update(SnapshotMsg(snap))
+	g = next.(Guarded)
+	if g.Crash() != nil {
+		t.Fatal("no panic yet, Crash should be nil")
+	}
+	if v := g.View(); v == "" {
+		t.Error("a guarded model should still render")
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recovered %v, want the panic to propagate", r)
+			}
+		}()
+		g.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")})
+	}()
+
+	c := g.Crash()
+	if c == nil || c.Value != "boom" || !strings.Contains(string(c.Stack), "SetInterval") {
+		t.Fatalf("crash = %+v, want the panic and its stack", c)
+	}
+	log := c.Last.AlertLog()
+	if len(log) != 1 || !strings.Contains(log[0], "2026-03-01 12:00:00  curl (pid 10)") {
+		t.Errorf("alert log = %q, want the crossing from the last good model", log)
+	}
+}
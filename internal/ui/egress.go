@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/googlesky/sstop/internal/config"
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/notify"
+)
+
+// maxEgressStep caps the time credited to one poll, so a pause or a stalled
+// collector does not inflate windowed volume. Matches the slowest refresh.
+const maxEgressStep = 10 * time.Second
+
+// egressRule limits aggregate upload to a set of destinations (countries,
+// ASNs), evaluated from the remote hosts aggregation each poll.
+type egressRule struct {
+	name      string
+	countries map[string]bool // empty = any
+	exclude   map[string]bool
+	asns      map[uint32]bool // empty = any
+	maxRate   float64         // bytes/sec; 0 when maxBytes is used
+	maxBytes  float64         // bytes within window
+	window    time.Duration
+	bell      bool
+	flash     bool
+	actions   notify.Channels
+
+	samples  []egressSample // per-poll upload volume within window
+	total    float64        // sum of samples
+	lastTime time.Time
+	current  float64 // last rate (maxRate) or windowed volume (maxBytes)
+	firing   bool
+}
+
+type egressSample struct {
+	at    time.Time
+	bytes float64
+}
+
+func newEgressRule(r config.EgressRule) (*egressRule, error) {
+	e := &egressRule{
+		name:      r.Name,
+		countries: make(map[string]bool),
+		exclude:   make(map[string]bool),
+		asns:      make(map[uint32]bool),
+		window:    r.WindowDuration(),
+		bell:      r.Notifies(config.NotifyBell),
+		flash:     r.Notifies(config.NotifyFlash),
+		actions:   alertChannels(r.Notifies),
+	}
+	if e.name == "" {
+		e.name = "egress"
+	}
+	for _, c := range config.ExpandCountries(r.Countries) {
+		e.countries[c] = true
+	}
+	for _, c := range config.ExpandCountries(r.ExcludeCountries) {
+		e.exclude[c] = true
+	}
+	for _, a := range r.ASNs {
+		e.asns[a] = true
+	}
+
+	if r.MaxRate != "" {
+		e.maxRate = parseSize(r.MaxRate)
+		if e.maxRate <= 0 {
+			return nil, fmt.Errorf("invalid max_rate %q", r.MaxRate)
+		}
+	} else {
+		e.maxBytes = parseSize(r.MaxBytes)
+		if e.maxBytes <= 0 {
+			return nil, fmt.Errorf("invalid max_bytes %q", r.MaxBytes)
+		}
+	}
+	return e, nil
+}
+
+// matches reports whether upload to the host counts against the rule.
+// Hosts with an unknown country match exclude-only rules, so "non-EU"
+// budgets err on the side of counting.
+func (e *egressRule) matches(h *model.RemoteHostSummary) bool {
+	switch h.CountryCode {
+	case "LAN", "LO", "MC":
+		return false
+	}
+	if len(e.countries) > 0 && !e.countries[h.CountryCode] {
+		return false
+	}
+	if e.exclude[h.CountryCode] {
+		return false
+	}
+	if len(e.asns) > 0 && !e.asns[h.ASN] {
+		return false
+	}
+	return true
+}
+
+// observe evaluates one poll and reports whether the rule newly fired.
+func (e *egressRule) observe(hosts []model.RemoteHostSummary, now time.Time) bool {
+	rate := 0.0
+	for i := range hosts {
+		if e.matches(&hosts[i]) {
+			rate += hosts[i].UpRate
+		}
+	}
+
+	wasFiring := e.firing
+	if e.maxRate > 0 {
+		e.current = rate
+		e.firing = rate > e.maxRate
+	} else {
+		if !e.lastTime.IsZero() {
+			step := now.Sub(e.lastTime)
+			if step > maxEgressStep {
+				step = maxEgressStep
+			}
+			if step > 0 {
+				b := rate * step.Seconds()
+				e.samples = append(e.samples, egressSample{at: now, bytes: b})
+				e.total += b
+			}
+		}
+
+		// Slide the window
+		cutoff := now.Add(-e.window)
+		drop := 0
+		for drop < len(e.samples) && !e.samples[drop].at.After(cutoff) {
+			e.total -= e.samples[drop].bytes
+			drop++
+		}
+		e.samples = e.samples[drop:]
+		if len(e.samples) == 0 {
+			e.total = 0 // clear float drift
+		}
+
+		e.current = e.total
+		e.firing = e.total > e.maxBytes
+	}
+	e.lastTime = now
+
+	return e.firing && !wasFiring
+}
+
+// headerText returns the unstyled header tag, e.g. " ⚠ non-eu: 1.2 MB/1h > 1M ".
+func (e *egressRule) headerText() string {
+	if e.maxRate > 0 {
+		return fmt.Sprintf(" ⚠ %s: %s > %s/s ", e.name, FormatRate(e.current), formatThreshold(e.maxRate))
+	}
+	return fmt.Sprintf(" ⚠ %s: %s/%s > %s ", e.name, FormatBytes(uint64(e.current)), formatWindow(e.window), formatThreshold(e.maxBytes))
+}
+
+// formatWindow renders a duration compactly: 1h, 1h30m, 15m, 30s.
+func formatWindow(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
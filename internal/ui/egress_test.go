@@ -0,0 +1,137 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/config"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func egressHosts() []model.RemoteHostSummary {
+	return []model.RemoteHostSummary{
+		{Host: "dns.google", CountryCode: "US", ASN: 15169, UpRate: 1000},
+		{Host: "hetzner", CountryCode: "DE", UpRate: 2000},
+		{Host: "nas", CountryCode: "LAN", UpRate: 50000},
+		{Host: "unknown", UpRate: 300},
+	}
+}
+
+func mustEgressRule(t *testing.T, r config.EgressRule) *egressRule {
+	t.Helper()
+	e, err := newEgressRule(r)
+	if err != nil {
+		t.Fatalf("newEgressRule: %v", err)
+	}
+	return e
+}
+
+func TestEgressRuleMatching(t *testing.T) {
+	tests := []struct {
+		name string
+		rule config.EgressRule
+		want float64
+	}{
+		{"country", config.EgressRule{Countries: []string{"us"}, MaxRate: "1M"}, 1000},
+		{"non-EU", config.EgressRule{ExcludeCountries: []string{"EU"}, MaxRate: "1M"}, 1300},
+		{"asn", config.EgressRule{ASNs: []uint32{15169}, MaxRate: "1M"}, 1000},
+		{"country and asn", config.EgressRule{Countries: []string{"DE"}, ASNs: []uint32{15169}, MaxRate: "1M"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := mustEgressRule(t, tt.rule)
+			e.observe(egressHosts(), time.Now())
+			if e.current != tt.want {
+				t.Errorf("current = %v, want %v", e.current, tt.want)
+			}
+		})
+	}
+}
+
+func TestEgressRuleRate(t *testing.T) {
+	e := mustEgressRule(t, config.EgressRule{Name: "us", Countries: []string{"US"}, MaxRate: "500"})
+	now := time.Now()
+	if !e.observe(egressHosts(), now) {
+		t.Error("expected rule to fire when rate exceeds max_rate")
+	}
+	if e.observe(egressHosts(), now.Add(time.Second)) {
+		t.Error("sustained firing should not count as a new trigger")
+	}
+	if !strings.Contains(e.headerText(), "us:") {
+		t.Errorf("header text = %q", e.headerText())
+	}
+}
+
+func TestEgressRuleWindow(t *testing.T) {
+	// 1000 B/s to US against a 5000 B budget per 10s window
+	e := mustEgressRule(t, config.EgressRule{Countries: []string{"US"}, MaxBytes: "5000", Window: "10s"})
+	start := time.Now()
+
+	fired := -1
+	for i := 0; i <= 8; i++ {
+		if e.observe(egressHosts(), start.Add(time.Duration(i)*time.Second)) {
+			fired = i
+		}
+	}
+	if fired != 6 {
+		t.Errorf("fired at poll %d, want 6 (6000 B > 5000 B)", fired)
+	}
+
+	// Traffic stops; the window drains and the rule clears
+	idle := []model.RemoteHostSummary{}
+	for i := 9; i <= 20; i++ {
+		e.observe(idle, start.Add(time.Duration(i)*time.Second))
+	}
+	if e.firing || e.current != 0 {
+		t.Errorf("expected drained window, firing=%v current=%v", e.firing, e.current)
+	}
+	if got := e.headerText(); !strings.Contains(got, "/10s") {
+		t.Errorf("header text = %q, want window suffix", got)
+	}
+}
+
+func TestEgressRuleStepCapped(t *testing.T) {
+	e := mustEgressRule(t, config.EgressRule{Countries: []string{"US"}, MaxBytes: "1G", Window: "1h"})
+	start := time.Now()
+	e.observe(egressHosts(), start)
+	e.observe(egressHosts(), start.Add(time.Hour/2))
+	if want := 1000 * maxEgressStep.Seconds(); e.current != want {
+		t.Errorf("current = %v, want %v after a long gap", e.current, want)
+	}
+}
+
+func TestEgressAlertBell(t *testing.T) {
+	a := newAlertOverlay()
+	err := a.configure(config.Alerts{Egress: []config.EgressRule{
+		{Name: "de", Countries: []string{"DE"}, MaxRate: "1K"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.checkEgress(egressHosts(), time.Now()) {
+		t.Error("expected bell when egress rule fires")
+	}
+	if len(a.log) != 1 || !strings.HasPrefix(a.log[0].text, "egress de:") {
+		t.Errorf("alert log = %+v, want the egress firing", a.log)
+	}
+	if text := a.alertHeaderText(nil); !strings.Contains(text, "de:") {
+		t.Errorf("header text missing egress tag: %q", text)
+	}
+}
+
+func TestFormatWindow(t *testing.T) {
+	tests := map[time.Duration]string{
+		time.Hour:                   "1h",
+		90 * time.Minute:            "1h30m",
+		15 * time.Minute:            "15m",
+		30 * time.Second:            "30s",
+		24 * time.Hour:              "24h",
+		time.Minute + 5*time.Second: "1m5s",
+	}
+	for d, want := range tests {
+		if got := formatWindow(d); got != want {
+			t.Errorf("formatWindow(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"net/netip"
 	"strconv"
 	"strings"
 
@@ -15,16 +16,36 @@ type Filter struct {
 	op       string  // ":", ">", "<"
 	value    string
 	numValue float64
+	prefix   netip.Prefix // ip: as a CIDR; invalid for plain text
+
+	// A boolean expression instead of a single term: "and" or "or" over
+	// sub, "not" over sub[0]
+	logic string
+	sub   []Filter
+	err   error // why the input is not a valid expression; searched as text
 }
 
 // ParseFilter parses a filter string into a Filter.
-// Supports: plain text, key:value, key>value, key<value.
+// Supports: plain text, key:value, key>value, key<value, and those
+// combined with AND, OR, NOT and parentheses (see parseExpr).
 func ParseFilter(input string) Filter {
 	input = strings.TrimSpace(input)
 	if input == "" {
 		return Filter{}
 	}
+	if toks := tokenizeFilter(input); isExpr(toks) {
+		f, err := parseExpr(toks)
+		if err != nil {
+			return Filter{raw: input, err: err}
+		}
+		f.raw = input
+		return f
+	}
+	return parseTerm(input)
+}
 
+// parseTerm parses a single term.
+func parseTerm(input string) Filter {
 	// Try to find operator
 	for _, op := range []string{">", "<", ":"} {
 		idx := strings.Index(input, op)
@@ -32,7 +53,12 @@ func ParseFilter(input string) Filter {
 			key := strings.ToLower(input[:idx])
 			value := input[idx+1:]
 			f := Filter{raw: input, key: key, op: op, value: value}
-			if op == ">" || op == "<" {
+			switch {
+			case key == "age":
+				f.numValue = parseAge(value)
+			case key == "ip":
+				f.prefix = parseIPValue(value)
+			case op == ">" || op == "<":
 				f.numValue = parseSize(value)
 			}
 			return f
@@ -48,17 +74,28 @@ func (f Filter) IsEmpty() bool {
 	return f.raw == ""
 }
 
+// Err returns why the filter is not a valid expression, nil if it is.
+// An invalid expression is searched for as plain text.
+func (f Filter) Err() error {
+	return f.err
+}
+
 // Match returns true if the process matches the filter.
 func (f Filter) Match(proc *model.ProcessSummary) bool {
 	if f.raw == "" {
 		return true
 	}
+	return f.eval(func(t Filter) bool { return t.matchTerm(proc) })
+}
 
+// matchTerm reports whether the process matches a single term.
+func (f Filter) matchTerm(proc *model.ProcessSummary) bool {
 	// Plain text search (backward compatible)
 	if f.key == "" {
 		lower := strings.ToLower(f.raw)
 		return strings.Contains(strings.ToLower(proc.Name), lower) ||
 			strings.Contains(strings.ToLower(proc.Cmdline), lower) ||
+			strings.Contains(strings.ToLower(proc.Note), lower) ||
 			strings.Contains(fmt.Sprintf("%d", proc.PID), f.raw)
 	}
 
@@ -71,6 +108,8 @@ func (f Filter) Match(proc *model.ProcessSummary) bool {
 		return f.matchNumeric(proc.DownRate)
 	case "proto":
 		return f.matchProto(proc)
+	case "state", "ip", "age":
+		return f.matchAnyConn(proc)
 	case "host":
 		return f.matchHost(proc)
 	case "conns":
@@ -81,6 +120,16 @@ func (f Filter) Match(proc *model.ProcessSummary) bool {
 		return f.matchService(proc)
 	case "group":
 		return f.matchGroup(proc)
+	case "user":
+		return f.matchUser(proc)
+	case "note":
+		return f.matchNote(proc)
+	case "netns":
+		return matchNetNS(proc, f.value)
+	case "type", "kind":
+		return proc.Kind != "" && strings.HasPrefix(proc.Kind, strings.ToLower(f.value))
+	case "pid":
+		return f.matchPID(proc)
 	default:
 		// Unknown key — fall back to plain text search
 		lower := strings.ToLower(f.raw)
@@ -108,6 +157,17 @@ func (f Filter) matchPort(proc *model.ProcessSummary) bool {
 	return false
 }
 
+// matchPID reports whether the process has one of the PIDs in a
+// comma-separated list: pid:42 or pid:42,43,50.
+func (f Filter) matchPID(proc *model.ProcessSummary) bool {
+	for _, v := range strings.Split(f.value, ",") {
+		if pid, err := strconv.ParseUint(strings.TrimSpace(v), 10, 32); err == nil && uint32(pid) == proc.PID {
+			return true
+		}
+	}
+	return false
+}
+
 func (f Filter) matchNumeric(val float64) bool {
 	switch f.op {
 	case ">":
@@ -121,9 +181,13 @@ func (f Filter) matchNumeric(val float64) bool {
 }
 
 func (f Filter) matchProto(proc *model.ProcessSummary) bool {
-	want := strings.ToUpper(f.value)
 	for _, c := range proc.Connections {
-		if c.Proto.String() == want {
+		if protoMatches(f.value, c.Proto, c.SrcPort, c.DstPort) {
+			return true
+		}
+	}
+	for _, lp := range proc.ListenPorts {
+		if protoMatches(f.value, lp.Proto, lp.Port, 0) {
 			return true
 		}
 	}
@@ -163,7 +227,13 @@ func (f Filter) matchService(proc *model.ProcessSummary) bool {
 
 func (f Filter) matchGroup(proc *model.ProcessSummary) bool {
 	lower := strings.ToLower(f.value)
-	// Match against container ID or service name
+	// Match against configured group, pod, container ID or service name
+	if proc.Tag != "" && strings.Contains(strings.ToLower(proc.Tag), lower) {
+		return true
+	}
+	if proc.PodName != "" && strings.Contains(strings.ToLower(podLabel(proc)), lower) {
+		return true
+	}
 	if proc.ContainerID != "" && strings.Contains(strings.ToLower(proc.ContainerID), lower) {
 		return true
 	}
@@ -171,12 +241,32 @@ func (f Filter) matchGroup(proc *model.ProcessSummary) bool {
 		return true
 	}
 	// Match "other" for ungrouped processes
-	if lower == "other" && proc.ContainerID == "" && proc.ServiceName == "" {
+	if lower == "other" && proc.Tag == "" && proc.PodName == "" && proc.ContainerID == "" && proc.ServiceName == "" {
 		return true
 	}
 	return false
 }
 
+// matchUser matches the process owner by username or numeric UID.
+// "unknown" matches processes whose owner could not be read.
+func (f Filter) matchUser(proc *model.ProcessSummary) bool {
+	if proc.User == "" {
+		return strings.EqualFold(f.value, unknownUser)
+	}
+	if strings.EqualFold(proc.User, f.value) {
+		return true
+	}
+	return proc.UID != nil && f.value == strconv.FormatUint(uint64(*proc.UID), 10)
+}
+
+// matchNote matches the operator note; "note:any" matches every noted process.
+func (f Filter) matchNote(proc *model.ProcessSummary) bool {
+	if strings.EqualFold(f.value, "any") {
+		return proc.Note != ""
+	}
+	return proc.Note != "" && strings.Contains(strings.ToLower(proc.Note), strings.ToLower(f.value))
+}
+
 // parseSize parses a human-readable size string like "1M", "100K", "1G".
 func parseSize(s string) float64 {
 	s = strings.TrimSpace(s)
@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"math"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// Connection terms test one connection: proto:udp, state:established,
+// ip:10.0.0.0/8 (the remote address), age>5m. The process table keeps a
+// process with any such connection; the Connections and Remote Hosts
+// views match them per connection.
+
+// isConnTerm reports whether f is a single connection term.
+func (f Filter) isConnTerm() bool {
+	if f.logic != "" {
+		return false
+	}
+	switch f.key {
+	case "proto", "state", "ip":
+		return f.op == ":"
+	case "age":
+		return true
+	}
+	return false
+}
+
+// matchConn reports whether a connection matches a connection term.
+func (f Filter) matchConn(c *model.Connection) bool {
+	switch f.key {
+	case "proto":
+		return protoMatches(f.value, c.Proto, c.SrcPort, c.DstPort)
+	case "state":
+		return c.Proto != model.ProtoUDP && stateMatches(f.value, c.State)
+	case "ip":
+		return f.matchIP(c.DstIP)
+	case "age":
+		return f.matchNumeric(c.Age.Seconds())
+	}
+	return false
+}
+
+// matchAnyConn reports whether any of a process's connections matches a
+// connection term. state:listen asks for a TCP listening socket instead.
+func (f Filter) matchAnyConn(proc *model.ProcessSummary) bool {
+	if f.key == "state" && stateMatches(f.value, model.StateListen) {
+		for _, lp := range proc.ListenPorts {
+			if lp.Proto == model.ProtoTCP {
+				return true
+			}
+		}
+	}
+	for i := range proc.Connections {
+		if f.matchConn(&proc.Connections[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// stateMatches reports whether a TCP state is the one named, by its name
+// or a prefix of it, any case, - for _: "established", "syn" (SYN_SENT or
+// SYN_RECV), "time-wait".
+func stateMatches(want string, s model.SocketState) bool {
+	want = strings.ReplaceAll(strings.ToUpper(want), "-", "_")
+	return want != "" && strings.HasPrefix(s.String(), want)
+}
+
+// matchIP reports whether ip is in the term's CIDR, is its address, or —
+// for a value that is neither — contains it as text ("ip:10.0.").
+func (f Filter) matchIP(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+	if f.prefix.IsValid() {
+		return f.prefix.Contains(addr)
+	}
+	return strings.Contains(addr.String(), f.value)
+}
+
+// parseIPValue parses an ip: value as a CIDR or a single address, which
+// is its own /32 or /128.
+func parseIPValue(s string) netip.Prefix {
+	if p, err := netip.ParsePrefix(s); err == nil {
+		return p.Masked()
+	}
+	if a, err := netip.ParseAddr(s); err == nil {
+		a = a.Unmap()
+		return netip.PrefixFrom(a, a.BitLen())
+	}
+	return netip.Prefix{}
+}
+
+// parseAge parses an age: value in seconds: a Go duration ("90s", "5m",
+// "1h30m"), days ("2d") or bare seconds. NaN when invalid, so that the
+// term matches nothing.
+func parseAge(s string) float64 {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.ParseFloat(days, 64); err == nil {
+			return n * 24 * 3600
+		}
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d.Seconds()
+	}
+	return math.NaN()
+}
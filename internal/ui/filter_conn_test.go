@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFilterConnTerms(t *testing.T) {
+	p := testProc() // firefox: TCP to 142.250.80.46:443, UDP to 8.8.8.8:53, listens on TCP 8080
+	p.Connections[0].Age = 2 * time.Hour
+	p.Connections[1].Age = 30 * time.Second
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{"state:established", true},
+		{"state:ESTAB", true},
+		{"state:time-wait", false},
+		{"state:syn", false},
+		{"state:listen", true},
+		{"ip:142.250.0.0/16", true},
+		{"ip:8.8.8.8", true},
+		{"ip:8.8.4.4/32", false},
+		{"ip:10.0.0.0/8", false},
+		{"ip:142.250.", true},
+		{"age>1h", true},
+		{"age>1d", false},
+		{"age<1m", true},
+		{"age>90", true},
+		{"age>soon", false},
+		{"state:established AND age>1h", true},
+		{"NOT ip:192.168.0.0/16", true},
+	}
+	for _, tt := range tests {
+		f := ParseFilter(tt.filter)
+		if got := f.Match(&p); got != tt.want {
+			t.Errorf("%q matched = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"90", 90},
+		{"5m", 300},
+		{"1h30m", 5400},
+		{"2d", 172800},
+		{"0.5d", 43200},
+	}
+	for _, tt := range tests {
+		if got := parseAge(tt.in); got != tt.want {
+			t.Errorf("parseAge(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	if !math.IsNaN(parseAge("5 minutes")) {
+		t.Error("invalid age should be NaN")
+	}
+}
+
+func TestViewFilterConnTerms(t *testing.T) {
+	m := New(nil)
+	snap := testConnectionsSnapshot()
+	snap.Processes[1].Connections[0].Age = 3 * time.Hour // sshd's established session
+	next, _ := m.Update(SnapshotMsg(snap))
+	m = next.(Model)
+
+	count := func(filter string) int {
+		m.setViewFilter(filter)
+		return len(m.connectionRows())
+	}
+	m.mode = ViewConnections
+	if n := count("state:time_wait"); n != 1 {
+		t.Errorf("state:time_wait: %d connections, want 1", n)
+	}
+	if n := count("ip:198.51.100.0/24"); n != 2 {
+		t.Errorf("ip:198.51.100.0/24: %d connections, want 2", n)
+	}
+	// Per connection: the same connection must be both
+	if n := count("ip:198.51.100.0/24 AND age>1h"); n != 1 {
+		t.Errorf("ip AND age: %d connections, want 1", n)
+	}
+	if n := count("state:established"); n != 2 {
+		t.Errorf("state:established: %d connections, want 2 (UDP has no state)", n)
+	}
+}
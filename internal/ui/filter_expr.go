@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Operator words of a filter expression. They are upper case so that
+// "and" in a plain search stays text.
+const (
+	exprAnd = "AND"
+	exprOr  = "OR"
+	exprNot = "NOT"
+)
+
+// tokenizeFilter splits a filter into words and parentheses.
+func tokenizeFilter(input string) []string {
+	var toks []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			toks = append(toks, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range input {
+		switch {
+		case r == '(' || r == ')':
+			flush()
+			toks = append(toks, string(r))
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return toks
+}
+
+// isExpr reports whether tokens use an operator or parentheses; a filter
+// without is one term, spaces and all.
+func isExpr(toks []string) bool {
+	for _, t := range toks {
+		if isOperator(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func isOperator(tok string) bool {
+	switch tok {
+	case exprAnd, exprOr, exprNot, "(", ")":
+		return true
+	}
+	return false
+}
+
+// parseExpr parses a boolean filter expression. NOT binds tightest, then
+// AND, then OR; parentheses group:
+//
+//	expr    = and { "OR" and }
+//	and     = unary { "AND" unary }
+//	unary   = "NOT" unary | primary
+//	primary = "(" expr ")" | term
+//
+// A term is a run of words without operators, e.g. "note:needs review".
+func parseExpr(toks []string) (Filter, error) {
+	p := exprParser{toks: toks}
+	f, err := p.or()
+	if err != nil {
+		return Filter{}, err
+	}
+	if tok := p.peek(); tok != "" {
+		return Filter{}, fmt.Errorf("unexpected %s", tok)
+	}
+	return f, nil
+}
+
+type exprParser struct {
+	toks []string
+	pos  int
+}
+
+// peek returns the next token, "" at the end.
+func (p *exprParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *exprParser) or() (Filter, error) {
+	return p.chain(exprOr, "or", p.and)
+}
+
+func (p *exprParser) and() (Filter, error) {
+	return p.chain(exprAnd, "and", p.unary)
+}
+
+// chain parses operands joined by op into one node of logic.
+func (p *exprParser) chain(op, logic string, operand func() (Filter, error)) (Filter, error) {
+	first, err := operand()
+	if err != nil {
+		return Filter{}, err
+	}
+	subs := []Filter{first}
+	for p.peek() == op {
+		p.pos++
+		next, err := operand()
+		if err != nil {
+			return Filter{}, err
+		}
+		subs = append(subs, next)
+	}
+	if len(subs) == 1 {
+		return first, nil
+	}
+	return Filter{logic: logic, sub: subs}, nil
+}
+
+func (p *exprParser) unary() (Filter, error) {
+	if p.peek() != exprNot {
+		return p.primary()
+	}
+	p.pos++
+	f, err := p.unary()
+	if err != nil {
+		return Filter{}, err
+	}
+	return Filter{logic: "not", sub: []Filter{f}}, nil
+}
+
+func (p *exprParser) primary() (Filter, error) {
+	switch tok := p.peek(); tok {
+	case "":
+		return Filter{}, errors.New("expected a term at the end")
+	case "(":
+		p.pos++
+		f, err := p.or()
+		if err != nil {
+			return Filter{}, err
+		}
+		if p.peek() != ")" {
+			return Filter{}, errors.New("missing )")
+		}
+		p.pos++
+		return f, nil
+	case exprAnd, exprOr, ")":
+		return Filter{}, fmt.Errorf("expected a term before %s", tok)
+	}
+	start := p.pos
+	for p.pos < len(p.toks) && !isOperator(p.toks[p.pos]) {
+		p.pos++
+	}
+	return parseTerm(strings.Join(p.toks[start:p.pos], " ")), nil
+}
+
+// eval evaluates the filter, with term deciding each of its terms. Views
+// other than the process table match terms against their own rows.
+func (f Filter) eval(term func(Filter) bool) bool {
+	switch f.logic {
+	case "and":
+		for _, s := range f.sub {
+			if !s.eval(term) {
+				return false
+			}
+		}
+		return true
+	case "or":
+		for _, s := range f.sub {
+			if s.eval(term) {
+				return true
+			}
+		}
+		return false
+	case "not":
+		return !f.sub[0].eval(term)
+	}
+	return term(f)
+}
+
+// hasKey reports whether any term of the filter is a key:value, key>value
+// or key<value term rather than plain text.
+func (f Filter) hasKey() bool {
+	if f.logic == "" {
+		return f.key != ""
+	}
+	for _, s := range f.sub {
+		if s.hasKey() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestFilterExpr(t *testing.T) {
+	p := testProc() // firefox: TCP to google.com, UDP to dns.google, 1M up, 2M down
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{"proto:udp AND down>1M", true},
+		{"proto:udp AND down>3M", false},
+		{"host:akamai OR host:google", true},
+		{"host:akamai OR host:fastly", false},
+		{"NOT group:other", false},
+		{"NOT chrome", true},
+		{"NOT NOT firefox", true},
+
+		// NOT binds tighter than AND, AND tighter than OR
+		{"NOT firefox AND chrome", false},
+		{"NOT (firefox AND chrome)", true},
+		{"chrome AND firefox OR port:8080", true},
+		{"chrome AND (firefox OR port:8080)", false},
+		{"firefox OR chrome AND up>10M", true},
+		{"(firefox OR chrome) AND up>10M", false},
+
+		// Words between operators are one term; lower case is text
+		{"usr/bin/firefox AND proto:tcp", true},
+		{"firefox and chrome", false},
+	}
+	for _, tt := range tests {
+		f := ParseFilter(tt.filter)
+		if err := f.Err(); err != nil {
+			t.Errorf("%q: %v", tt.filter, err)
+		}
+		if got := f.Match(&p); got != tt.want {
+			t.Errorf("%q matched = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestFilterExprErrors(t *testing.T) {
+	tests := []struct {
+		filter, want string
+	}{
+		{"firefox AND", "expected a term at the end"},
+		{"AND firefox", "expected a term before AND"},
+		{"firefox OR OR chrome", "expected a term before OR"},
+		{"(firefox OR chrome", "missing )"},
+		{"firefox)", "unexpected )"},
+		{"()", "expected a term before )"},
+		{"NOT", "expected a term at the end"},
+	}
+	for _, tt := range tests {
+		f := ParseFilter(tt.filter)
+		if err := f.Err(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: err = %v, want %q", tt.filter, err, tt.want)
+		}
+	}
+
+	// An invalid expression is searched for as text
+	p := model.ProcessSummary{Name: "sd-pam", Cmdline: "(sd-pam"}
+	if f := ParseFilter("(sd-pam"); !f.Match(&p) {
+		t.Error("invalid expression should match as plain text")
+	}
+}
+
+func TestViewFilterExpr(t *testing.T) {
+	m := New(nil)
+	next, _ := m.Update(SnapshotMsg(testConnectionsSnapshot()))
+	m = next.(Model)
+	m.mode = ViewConnections
+
+	m.setViewFilter("proto:udp")
+	udp := len(m.connectionRows())
+	m.setViewFilter("NOT proto:udp")
+	notUDP := len(m.connectionRows())
+	m.setViewFilter("")
+	if all := len(m.connectionRows()); udp == 0 || notUDP == 0 || udp+notUDP != all {
+		t.Errorf("proto:udp %d + NOT proto:udp %d != %d connections", udp, notUDP, all)
+	}
+}
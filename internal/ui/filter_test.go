@@ -154,6 +154,44 @@ func TestFilterService(t *testing.T) {
 	}
 }
 
+func TestFilterUser(t *testing.T) {
+	p := testProc()
+	uid := uint32(1000)
+	p.UID = &uid
+	p.User = "alice"
+
+	for _, expr := range []string{"user:alice", "user:Alice", "user:1000"} {
+		if !ParseFilter(expr).Match(&p) {
+			t.Errorf("%s should match", expr)
+		}
+	}
+	if ParseFilter("user:bob").Match(&p) {
+		t.Error("user:bob should not match")
+	}
+
+	unknown := testProc()
+	if !ParseFilter("user:unknown").Match(&unknown) {
+		t.Error("user:unknown should match a process without owner info")
+	}
+	if ParseFilter("user:0").Match(&unknown) {
+		t.Error("user:0 should not match a process without owner info")
+	}
+}
+
+func TestFilterPID(t *testing.T) {
+	p := testProc()
+	for _, expr := range []string{"pid:1234", "pid:42,1234", "pid:42, 1234"} {
+		if !ParseFilter(expr).Match(&p) {
+			t.Errorf("%s should match", expr)
+		}
+	}
+	for _, expr := range []string{"pid:123", "pid:42,43", "pid:"} {
+		if ParseFilter(expr).Match(&p) {
+			t.Errorf("%s should not match", expr)
+		}
+	}
+}
+
 func TestFilterEmpty(t *testing.T) {
 	f := ParseFilter("")
 	if !f.IsEmpty() {
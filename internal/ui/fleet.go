@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// hostSnap is the latest snapshot from one host of the fleet and when it
+// arrived.
+type hostSnap struct {
+	snap model.Snapshot
+	at   time.Time
+}
+
+// SetHosts switches to the multi-host dashboard (sstop connect): snapshots
+// are kept per host (Snapshot.Host) and the first host is shown; H cycles.
+func (m *Model) SetHosts(hosts []string) {
+	m.hosts = hosts
+	m.hostSnaps = make(map[string]hostSnap)
+	if len(hosts) > 0 {
+		m.activeHost = hosts[0]
+	}
+}
+
+// keepHostSnap stores a fleet snapshot and reports whether it belongs to
+// the host on screen. Always true outside fleet mode.
+func (m *Model) keepHostSnap(snap model.Snapshot) bool {
+	if m.hosts == nil {
+		return true
+	}
+	m.hostSnaps[snap.Host] = hostSnap{snap: snap, at: time.Now()}
+	return snap.Host == m.activeHost
+}
+
+// cycleHost shows the next host of the fleet, from its latest snapshot.
+func (m *Model) cycleHost() {
+	if len(m.hosts) < 2 {
+		return
+	}
+	next := m.hosts[0]
+	for i, h := range m.hosts {
+		if h == m.activeHost && i+1 < len(m.hosts) {
+			next = m.hosts[i+1]
+			break
+		}
+	}
+	m.activeHost = next
+	m.releaseHeld()
+	m.detail.history = nil // connection graphs belong to the old host
+	m.detail.rates = model.RateSeries{}
+
+	hs, ok := m.hostSnaps[next]
+	if !ok {
+		m.snapshot, m.pausedSnapshot = model.Snapshot{}, model.Snapshot{}
+		m.lastSnapAt, m.staleAge = time.Time{}, 0
+		m.table.update(m.tableRows())
+		if m.viewingProcess() {
+			m.mode = ViewProcessTable
+		}
+		return
+	}
+	m.lastSnapAt = hs.at
+	m.staleAge = m.staleAgeAt(time.Now())
+	snap := hs.snap
+	if m.privacyOn {
+		snap = m.masker.Apply(snap)
+	}
+	m.updateIfaceList(snap.Interfaces)
+	snap.ActiveIface = m.activeIface
+	annotateNotes(&snap, m.notes)
+	annotateTags(&snap, m.groupRules)
+	m.snapshot = snap
+	if m.paused {
+		m.pausedSnapshot = snap
+	}
+	m.table.update(m.tableRows())
+	if m.viewingProcess() {
+		if proc := m.findProcess(m.detail.pid); proc == nil || !m.detail.follows(proc) {
+			m.mode = ViewProcessTable
+		}
+	}
+}
+
+// renderHostBar renders the host selector: every host with its total
+// rates, the one on screen highlighted and silent ones dimmed.
+func (m Model) renderHostBar(width int) string {
+	now := time.Now()
+	staleAfter := staleIntervals * intervalPresets[m.intervalIdx]
+	parts := []string{styleDetailLabel.Render(" H")}
+	for _, h := range m.hosts {
+		hs, ok := m.hostSnaps[h]
+		var part string
+		switch {
+		case !ok:
+			part = styleDetailLabel.Render(h + " –")
+		case now.Sub(hs.at) >= staleAfter:
+			part = styleDetailLabel.Render(h + " stale")
+		default:
+			part = h + " " + styleHeaderUp.Render("▲"+FormatRate(hs.snap.TotalUp)) + " " +
+				styleHeaderDown.Render("▼"+FormatRate(hs.snap.TotalDown))
+		}
+		if h == m.activeHost {
+			part = styleFooterKey.Render("[") + part + styleFooterKey.Render("]")
+		} else {
+			part = " " + part + " "
+		}
+		parts = append(parts, part)
+	}
+	return ansi.Truncate(strings.Join(parts, " "), width, "…")
+}
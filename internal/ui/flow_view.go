@@ -0,0 +1,297 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// flowNode is a process (left) or remote host (right) in the flow view.
+type flowNode struct {
+	label   string
+	pid     uint32 // processes
+	rate    float64
+	y, rows int // rows of the column it spans
+}
+
+// flowBand is a process's traffic to one host, drawn as a band rows thick
+// from row ly on the left to row ry on the right.
+type flowBand struct {
+	proc, host int // node indexes
+	rate       float64
+	rows       int
+	ly, ry     int
+}
+
+// flowLayout places processes, hosts and the bands between them in a
+// column of height rows.
+type flowLayout struct {
+	procs, hosts []flowNode
+	bands        []flowBand
+	total        float64 // rate of the bands drawn
+	hidden       int     // flows left out for lack of rows
+}
+
+// flowView draws process → remote host traffic as a Sankey diagram: band
+// thickness is proportional to rate.
+type flowView struct {
+	cursor int // selected process
+}
+
+func (v *flowView) moveUp() {
+	if v.cursor > 0 {
+		v.cursor--
+	}
+}
+
+func (v *flowView) moveDown(maxIdx int) {
+	if v.cursor < maxIdx {
+		v.cursor++
+	}
+}
+
+// layoutFlows lays out the remote host edges of the service graph in
+// height rows. The largest flows are kept while each still gets a row
+// and every node is a row apart from the next; the rest are counted as
+// hidden. Each band gets a row plus its share of the rows left over, and
+// a node is as tall as its bands.
+func layoutFlows(nodes []graphNode, height int) flowLayout {
+	type pending struct {
+		pid        uint32
+		proc, host string
+		rate       float64
+	}
+	var all []pending
+	for _, n := range nodes {
+		for _, e := range n.Edges {
+			if !e.Local && e.Rate > 0 {
+				all = append(all, pending{n.PID, n.Name, e.Peer, e.Rate})
+			}
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].rate > all[j].rate })
+
+	var l flowLayout
+	procIdx := make(map[uint32]int)
+	hostIdx := make(map[string]int)
+	for n, f := range all {
+		_, knownProc := procIdx[f.pid]
+		_, knownHost := hostIdx[f.host]
+		nProcs, nHosts := len(l.procs), len(l.hosts)
+		if !knownProc {
+			nProcs++
+		}
+		if !knownHost {
+			nHosts++
+		}
+		if len(l.bands)+1+max(nProcs, nHosts)-1 > height {
+			l.hidden = len(all) - n
+			break
+		}
+		if !knownProc {
+			procIdx[f.pid] = len(l.procs)
+			l.procs = append(l.procs, flowNode{label: f.proc, pid: f.pid})
+		}
+		if !knownHost {
+			hostIdx[f.host] = len(l.hosts)
+			l.hosts = append(l.hosts, flowNode{label: f.host})
+		}
+		l.bands = append(l.bands, flowBand{proc: procIdx[f.pid], host: hostIdx[f.host], rate: f.rate})
+		l.total += f.rate
+	}
+	if len(l.bands) == 0 {
+		return l
+	}
+
+	spare := height - len(l.bands) - (max(len(l.procs), len(l.hosts)) - 1)
+	for i := range l.bands {
+		b := &l.bands[i]
+		b.rows = 1 + int(b.rate*float64(spare)/l.total)
+		l.procs[b.proc].rate += b.rate
+		l.procs[b.proc].rows += b.rows
+		l.hosts[b.host].rate += b.rate
+		l.hosts[b.host].rows += b.rows
+	}
+
+	// Stack the nodes, largest first, a row apart
+	stack := func(nodes []flowNode) {
+		y := 0
+		for i := range nodes {
+			nodes[i].y = y
+			y += nodes[i].rows + 1
+		}
+	}
+	stack(l.procs)
+	stack(l.hosts)
+
+	// Within a node, bands go in the order of the nodes at their other
+	// end, which keeps crossings down
+	order := make([]int, len(l.bands))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return l.bands[order[a]].host < l.bands[order[b]].host })
+	next := make([]int, len(l.procs))
+	for i := range l.procs {
+		next[i] = l.procs[i].y
+	}
+	for _, i := range order {
+		b := &l.bands[i]
+		b.ly = next[b.proc]
+		next[b.proc] += b.rows
+	}
+	sort.SliceStable(order, func(a, b int) bool { return l.bands[order[a]].proc < l.bands[order[b]].proc })
+	next = make([]int, len(l.hosts))
+	for i := range l.hosts {
+		next[i] = l.hosts[i].y
+	}
+	for _, i := range order {
+		b := &l.bands[i]
+		b.ry = next[b.host]
+		next[b.host] += b.rows
+	}
+	return l
+}
+
+// flowNodeAt returns the node whose rows include row, -1 if none.
+func flowNodeAt(nodes []flowNode, row int) int {
+	for i, n := range nodes {
+		if row >= n.y && row < n.y+n.rows {
+			return i
+		}
+	}
+	return -1
+}
+
+// flowColumns returns the widths of the label columns and the band area
+// between them.
+func flowColumns(width int) (labelW, midW int) {
+	labelW = min(max(width/4, 12), 28)
+	return labelW, max(width-2*labelW-4, 4) // 2 node bars, 2 gaps
+}
+
+// openFlowProc opens the detail view of the flow view's selected process.
+func (m *Model) openFlowProc(procs []flowNode) {
+	if m.flows.cursor < len(procs) {
+		m.mode = ViewProcessDetail
+		m.detail = newProcessDetail(procs[m.flows.cursor].pid)
+	}
+}
+
+// flowLayoutFor lays out the flow view for a content area height rows tall.
+func (m *Model) flowLayoutFor(height int) flowLayout {
+	snap := m.viewSnapshot(ViewFlows)
+	return layoutFlows(buildGraph(snap.Processes, snap.ListenPorts, m.hostNames), max(height-1, 1))
+}
+
+// render draws the processes down the left, the hosts down the right and
+// a band per flow between them, coloured by process. Bands follow an
+// S-curve from one end to the other; the selected process's are drawn
+// last so they stay on top.
+func (v *flowView) render(l flowLayout, width, height int) string {
+	if len(l.procs) > 0 && v.cursor >= len(l.procs) {
+		v.cursor = len(l.procs) - 1
+	}
+
+	title := fmt.Sprintf("  Flows: process → remote host  %d flows  %s", len(l.bands), FormatRate(l.total))
+	if l.hidden > 0 {
+		title += fmt.Sprintf("  (+%d smaller not shown)", l.hidden)
+	}
+	lines := []string{styleTitle.Render(Truncate(title, width))}
+	if len(l.bands) == 0 {
+		lines = append(lines, styleDetailLabel.Render("  No traffic to remote hosts"))
+		return strings.Join(lines, "\n")
+	}
+
+	labelW, midW := flowColumns(width)
+	rows := max(height-1, 1)
+	owner := make([][]int, rows)
+	for y := range owner {
+		owner[y] = make([]int, midW)
+		for x := range owner[y] {
+			owner[y][x] = -1
+		}
+	}
+	order := make([]int, len(l.bands))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		// largest first, so thin bands stay visible over thick ones; the
+		// selected process's last of all
+		sa, sb := l.bands[order[a]].proc == v.cursor, l.bands[order[b]].proc == v.cursor
+		if sa != sb {
+			return sb
+		}
+		return l.bands[order[a]].rows > l.bands[order[b]].rows
+	})
+	for _, i := range order {
+		b := &l.bands[i]
+		prev := b.ly
+		for x := 0; x < midW; x++ {
+			t := float64(x) / float64(max(midW-1, 1))
+			s := t * t * (3 - 2*t) // smoothstep
+			y := int(math.Round(float64(b.ly) + float64(b.ry-b.ly)*s))
+			// Cover the rows between this column and the last so steep
+			// stretches stay connected
+			for r := min(prev, y); r < max(prev, y)+b.rows && r < rows; r++ {
+				owner[r][x] = i
+			}
+			prev = y
+		}
+	}
+
+	for y := 0; y < rows; y++ {
+		var b strings.Builder
+
+		// Process label and bar
+		p := flowNodeAt(l.procs, y)
+		label := ""
+		if p >= 0 && y == l.procs[p].y {
+			label = Truncate(l.procs[p].label+" "+FormatRate(l.procs[p].rate), labelW)
+		}
+		label = fmt.Sprintf("%*s", labelW, label)
+		if p == v.cursor && y == l.procs[p].y {
+			b.WriteString(styleTableRowSelected.Render(label))
+		} else {
+			b.WriteString(styleTableRow.Render(label))
+		}
+		b.WriteString(" ")
+		if p >= 0 {
+			b.WriteString(stylePaletteFill[p%len(stylePaletteFill)].Render("█"))
+		} else {
+			b.WriteString(" ")
+		}
+
+		// Bands, in runs of the same process
+		for x := 0; x < midW; {
+			i := owner[y][x]
+			end := x
+			for end < midW && owner[y][end] == i {
+				end++
+			}
+			if i < 0 {
+				b.WriteString(strings.Repeat(" ", end-x))
+			} else {
+				proc := l.bands[i].proc
+				b.WriteString(stylePaletteFill[proc%len(stylePaletteFill)].Render(strings.Repeat("█", end-x)))
+			}
+			x = end
+		}
+
+		// Host bar and label
+		h := flowNodeAt(l.hosts, y)
+		if h >= 0 {
+			b.WriteString(styleDetailLabel.Render("█"))
+		} else {
+			b.WriteString(" ")
+		}
+		b.WriteString(" ")
+		if h >= 0 && y == l.hosts[h].y {
+			b.WriteString(styleTableRow.Render(Truncate(l.hosts[h].label+" "+FormatRate(l.hosts[h].rate), labelW)))
+		}
+		lines = append(lines, b.String())
+	}
+	return strings.Join(lines, "\n")
+}
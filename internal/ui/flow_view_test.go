@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestLayoutFlows(t *testing.T) {
+	nodes := []graphNode{
+		{PID: 10, Name: "firefox", Edges: []graphEdge{{Peer: "youtube.com", Rate: 800}, {Peer: "cdn.net", Rate: 100}}},
+		{PID: 11, Name: "curl", Edges: []graphEdge{{Peer: "cdn.net", Rate: 100}, {Peer: "redis (3)", Local: true, Rate: 500}}},
+	}
+	l := layoutFlows(nodes, 20)
+
+	if len(l.bands) != 3 || l.hidden != 0 || l.total != 1000 {
+		t.Fatalf("layout = %+v, want the 3 remote flows", l)
+	}
+	if len(l.procs) != 2 || l.procs[0].label != "firefox" || len(l.hosts) != 2 || l.hosts[0].label != "youtube.com" {
+		t.Fatalf("nodes = %+v / %+v, want largest first", l.procs, l.hosts)
+	}
+	// 20 rows less a gap: a row each plus shares of the 16 spare
+	if b := l.bands[0]; b.rows != 1+12 {
+		t.Errorf("youtube band = %d rows, want 13", b.rows)
+	}
+	if p := l.procs[0]; p.rows != 13+2 || p.rate != 900 {
+		t.Errorf("firefox node = %+v, want its bands' 15 rows and 900 B/s", p)
+	}
+	// cdn.net's bands stack in process order, below youtube.com and a gap
+	cdn := l.hosts[1]
+	if cdn.y != 14 || l.bands[1].ry != 14 || l.bands[2].ry != 14+l.bands[1].rows {
+		t.Errorf("cdn.net at %d, bands at %d and %d", cdn.y, l.bands[1].ry, l.bands[2].ry)
+	}
+	if curl := l.procs[1]; curl.y+curl.rows > 20 || cdn.y+cdn.rows > 20 {
+		t.Errorf("layout overflows 20 rows: curl %+v, cdn.net %+v", curl, cdn)
+	}
+	if got := flowNodeAt(l.procs, l.procs[1].y); got != 1 {
+		t.Errorf("node at curl's row = %d, want 1", got)
+	}
+
+	// Too short for every flow: the smallest are left out
+	if short := layoutFlows(nodes, 3); len(short.bands) != 2 || short.hidden != 1 {
+		t.Errorf("3 rows: %d bands, %d hidden, want 2 and 1", len(short.bands), short.hidden)
+	}
+}
+
+func TestFlowView(t *testing.T) {
+	conn := func(host string, rate float64) model.Connection {
+		return model.Connection{Proto: model.ProtoTCP, SrcIP: net.ParseIP("10.0.0.2"), SrcPort: 40000,
+			DstIP: net.ParseIP("192.0.2.1"), DstPort: 443, DownRate: rate, RemoteHost: host}
+	}
+	m := New(nil)
+	m.width, m.height = 110, 26
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{
+		{PID: 10, Name: "firefox", Connections: []model.Connection{conn("youtube.com", 4000)}},
+		{PID: 11, Name: "rsync", Connections: []model.Connection{conn("backup.lan", 1000)}},
+	}}))
+	m = next.(Model)
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	if m.mode != ViewFlows {
+		t.Fatalf("f: mode = %v, want flows", m.mode)
+	}
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"2 flows", "firefox", "youtube.com", "rsync", "backup.lan", "█"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("flow view lacks %q:\n%s", want, view)
+		}
+	}
+	// Band thickness follows rate: firefox's is thicker
+	l := m.flowLayoutFor(m.contentHeight())
+	if fx, rs := l.bands[0].rows, l.bands[1].rows; fx <= rs {
+		t.Errorf("band rows firefox %d, rsync %d: want firefox thicker", fx, rs)
+	}
+
+	// Click rsync to select it, again to open it
+	header := strings.Count(m.renderTop(), "\n") + 1
+	click := tea.MouseMsg{X: 2, Y: header + 1 + l.procs[1].y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+	next, _ = m.handleMouse(click)
+	m = next.(Model)
+	if m.flows.cursor != 1 {
+		t.Fatalf("click: cursor = %d, want rsync", m.flows.cursor)
+	}
+	next, _ = m.handleMouse(click)
+	m = next.(Model)
+	if m.mode != ViewProcessDetail || m.detail.pid != 11 {
+		t.Errorf("second click: mode %v pid %d, want rsync's detail", m.mode, m.detail.pid)
+	}
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("f")})
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.detail.pid != 10 {
+		t.Errorf("k, enter: detail of %d, want firefox", m.detail.pid)
+	}
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -275,6 +276,34 @@ func FormatAge(d time.Duration) string {
 	}
 }
 
+// formatRTT formats a round-trip time compactly (at most 6 chars), e.g.
+// "0.4ms", "38ms", "1.2s".
+func formatRTT(d time.Duration) string {
+	ms := float64(d) / float64(time.Millisecond)
+	switch {
+	case ms < 10:
+		return fmt.Sprintf("%.1fms", ms)
+	case ms < 1000:
+		return fmt.Sprintf("%.0fms", ms)
+	case ms < 10000:
+		return fmt.Sprintf("%.1fs", ms/1000)
+	default:
+		return fmt.Sprintf("%.0fs", ms/1000)
+	}
+}
+
+// FormatCount formats an integer with thousands separators, e.g. 4832 → "4,832".
+func FormatCount(n int) string {
+	if n < 0 {
+		return "-" + FormatCount(-n)
+	}
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
 // lerpValue linearly interpolates between a and b.
 func lerpValue(a, b, t float64) float64 {
 	return a + (b-a)*t
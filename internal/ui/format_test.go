@@ -1,8 +1,12 @@
 package ui
 
 import (
+	"net"
 	"testing"
 	"time"
+
+	"github.com/googlesky/sstop/internal/hostfmt"
+	"github.com/googlesky/sstop/internal/model"
 )
 
 func TestFormatRateCompact_Width(t *testing.T) {
@@ -187,6 +191,47 @@ func TestFormatAge(t *testing.T) {
 	}
 }
 
+func TestFormatRTT(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{400 * time.Microsecond, "0.4ms"},
+		{38 * time.Millisecond, "38ms"},
+		{999 * time.Millisecond, "999ms"},
+		{1200 * time.Millisecond, "1.2s"},
+		{42 * time.Second, "42s"},
+	}
+	for _, tt := range tests {
+		got := formatRTT(tt.d)
+		if got != tt.want {
+			t.Errorf("formatRTT(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+		if len(got) > 6 {
+			t.Errorf("formatRTT(%v) = %q exceeds 6 chars", tt.d, got)
+		}
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1,000"},
+		{4832, "4,832"},
+		{1234567, "1,234,567"},
+		{-4832, "-4,832"},
+	}
+	for _, tt := range tests {
+		if got := FormatCount(tt.n); got != tt.want {
+			t.Errorf("FormatCount(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
 func TestFormatBytesCompact_Width(t *testing.T) {
 	testCases := []uint64{
 		0, 1, 42, 100, 999, 1023, 1024,
@@ -248,3 +293,31 @@ func TestTrendArrow(t *testing.T) {
 		t.Errorf("stable = %q, want →", a)
 	}
 }
+
+func TestFormatTopDest(t *testing.T) {
+	tests := []struct {
+		proc  model.ProcessSummary
+		hosts hostfmt.Style
+		want  string
+	}{
+		{model.ProcessSummary{}, hostfmt.FQDN, "-"},
+		{model.ProcessSummary{TopDest: "142.250.80.46"}, hostfmt.FQDN, "142.250.80.46"},
+		{model.ProcessSummary{TopDest: "dns.google", TopDestCountry: "US"}, hostfmt.FQDN, "US dns.google"},
+		{model.ProcessSummary{TopDest: "dns.google", TopDestCountry: "US"}, hostfmt.Short, "US dns"},
+		{model.ProcessSummary{TopDest: "dns.google", TopDestIP: net.ParseIP("8.8.8.8")}, hostfmt.IP, "8.8.8.8"},
+	}
+	for _, tt := range tests {
+		if got := formatTopDest(&tt.proc, tt.hosts); got != tt.want {
+			t.Errorf("formatTopDest(%+v) = %q, want %q", tt.proc, got, tt.want)
+		}
+	}
+}
+
+func TestSkippedPollsText(t *testing.T) {
+	if got := skippedPollsText(1); got != "1 poll skipped (collection slow)" {
+		t.Errorf("skippedPollsText(1) = %q", got)
+	}
+	if got := skippedPollsText(12); got != "12 polls skipped (collection slow)" {
+		t.Errorf("skippedPollsText(12) = %q", got)
+	}
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"flag"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/config"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// goldenKeys are the key names renderGolden takes besides single runes.
+var goldenKeys = map[string]tea.KeyType{
+	"enter": tea.KeyEnter, "esc": tea.KeyEsc, "tab": tea.KeyTab,
+	"up": tea.KeyUp, "down": tea.KeyDown, "left": tea.KeyLeft, "right": tea.KeyRight,
+}
+
+// renderGolden renders m at width×height in test mode after snap arrives
+// and keys are pressed, without colour: what a golden file holds.
+func renderGolden(t *testing.T, m Model, snap model.Snapshot, width, height int, keys ...string) string {
+	t.Helper()
+	m.SetTestMode(true)
+	m.width, m.height = width, height
+	next, _ := m.Update(SnapshotMsg(snap))
+	m = next.(Model)
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		if kt, ok := goldenKeys[k]; ok {
+			msg = tea.KeyMsg{Type: kt}
+		}
+		m = press(m, msg)
+	}
+	return ansi.Strip(m.View())
+}
+
+// checkGolden compares got with testdata/golden/<name>.golden; go test
+// -update rewrites the file instead.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name+".golden")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from %s (go test -update rewrites it):\n%s", name, path, got)
+	}
+}
+
+// goldenSnapshot is a small host at testClock: a busy browser, an SSH
+// session, a resolver and a process that is up but quiet.
+func goldenSnapshot() model.Snapshot {
+	snap := testConnectionsSnapshot()
+	snap.Timestamp = testClock
+	snap.Processes[0].Name, snap.Processes[0].Cmdline = "firefox", "/usr/lib/firefox/firefox"
+	snap.Processes[0].UpRate, snap.Processes[0].DownRate = 500, 2<<20
+	snap.Processes[0].ConnCount = 1
+	snap.Processes[0].FirstSeen = testClock.Add(-90 * time.Second)
+	snap.Processes[1].UpRate, snap.Processes[1].ConnCount = 2000, 2
+	snap.Processes[2].DownRate, snap.Processes[2].ConnCount = 100, 1
+	snap.Processes = append(snap.Processes, model.ProcessSummary{PID: 40, Name: "chronyd",
+		ListenPorts: []model.ListenPort{{Proto: model.ProtoUDP, IP: net.IPv4zero, Port: 123}}})
+	snap.TotalUp, snap.TotalDown = 2500, 2<<20+100
+	snap.RemoteHosts = []model.RemoteHostSummary{
+		{Host: "example.com", IP: net.ParseIP("93.184.216.34"), UpRate: 500, DownRate: 2 << 20, ConnCount: 1, Processes: []string{"firefox"}},
+		{Host: "198.51.100.7", IP: net.ParseIP("198.51.100.7"), UpRate: 2000, ConnCount: 1, Processes: []string{"sshd"}},
+	}
+	return snap
+}
+
+func TestGoldenViews(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+	}{
+		{"process_table", nil},
+		{"process_table_idle_hidden", []string{"z", "z"}},
+		{"remote_hosts", []string{"h"}},
+		{"connections", []string{"v"}},
+		{"process_detail", []string{"enter"}},
+		{"help", []string{"?"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(nil)
+			if err := m.SetIdle("", 1); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.SetColumns([]string{"pid", "name", "up", "down", "conns", "listen", "age"}); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.name, renderGolden(t, m, goldenSnapshot(), 100, 24, tt.keys...))
+		})
+	}
+}
+
+// TestGoldenAlert checks a firing rule's header tag and the alert log
+// line, both stamped by the snapshot rather than the wall clock.
+func TestGoldenAlert(t *testing.T) {
+	m := New(nil)
+	if err := m.SetAlertConfig(config.Alerts{Flash: config.FlashStrong, Rules: []config.AlertRule{{Name: "heavy", Threshold: "1M"}}}); err != nil {
+		t.Fatal(err)
+	}
+	out := renderGolden(t, m, goldenSnapshot(), 100, 24)
+	checkGolden(t, "alert_firing", out)
+	if again := renderGolden(t, m, goldenSnapshot(), 100, 24); again != out {
+		t.Errorf("a second poll drew differently in test mode:\n%s", again)
+	}
+}
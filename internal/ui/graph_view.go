@@ -0,0 +1,313 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/hostfmt"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// graphEdge is traffic from a process to a peer: another local process,
+// an unresolved local port, or an external host.
+type graphEdge struct {
+	Peer    string // "name (pid)", "localhost:port" or host
+	PeerPID uint32 // local peer process, 0 otherwise
+	Local   bool
+	Inbound bool // an external host connecting in
+	Port    uint16
+	Rate    float64 // up + down, bytes/sec
+	Conns   int
+}
+
+// graphNode is a process with its outgoing edges, heaviest first.
+type graphNode struct {
+	PID   uint32
+	Name  string
+	Rate  float64
+	Edges []graphEdge
+}
+
+// graphRow is one line of the graph view: a node, or one of its edges.
+type graphRow struct {
+	node *graphNode
+	edge *graphEdge // nil on the node's own line
+}
+
+// endpointKey identifies a socket endpoint across processes.
+func endpointKey(proto model.Protocol, ip net.IP, port uint16) string {
+	return proto.String() + " " + model.AddrPort(ip, port)
+}
+
+// buildGraph builds the service dependency graph. A connection to a local
+// endpoint is resolved to the process owning the other end (or listening
+// on its port) and counted once, from the connecting side. Remote hosts
+// are named in the hosts style.
+func buildGraph(procs []model.ProcessSummary, listens []model.ListenPortEntry, hosts hostfmt.Style) []graphNode {
+	owner := make(map[string]*model.ProcessSummary)
+	for i := range procs {
+		p := &procs[i]
+		for j := range p.Connections {
+			c := &p.Connections[j]
+			owner[endpointKey(c.Proto, c.SrcIP, c.SrcPort)] = p
+		}
+	}
+	listener := make(map[string]uint32) // "proto port" → PID
+	names := make(map[uint32]string, len(procs))
+	for i := range procs {
+		names[procs[i].PID] = procs[i].Name
+	}
+	for _, l := range listens {
+		listener[fmt.Sprintf("%s %d", l.Proto, l.Port)] = l.PID
+		if _, ok := names[l.PID]; !ok {
+			names[l.PID] = l.Process
+		}
+	}
+
+	var nodes []graphNode
+	for i := range procs {
+		p := &procs[i]
+		edges := make(map[string]*graphEdge)
+		var order []string
+		add := func(key string, e graphEdge, rate float64) {
+			cur, ok := edges[key]
+			if !ok {
+				cur = &e
+				edges[key] = cur
+				order = append(order, key)
+			}
+			cur.Rate += rate
+			cur.Conns++
+		}
+		for j := range p.Connections {
+			c := &p.Connections[j]
+			if c.DstIP == nil || c.DstPort == 0 {
+				continue
+			}
+			rate := c.UpRate + c.DownRate
+			peer := owner[endpointKey(c.Proto, c.DstIP, c.DstPort)]
+			local := peer != nil || c.DstIP.IsLoopback()
+			if local && c.Direction == model.DirInbound {
+				continue // counted from the client's side
+			}
+			switch {
+			case peer != nil:
+				add(fmt.Sprintf("pid %d", peer.PID), graphEdge{
+					Peer: fmt.Sprintf("%s (%d)", peer.Name, peer.PID), PeerPID: peer.PID,
+					Local: true, Port: c.DstPort,
+				}, rate)
+			case local:
+				if pid, ok := listener[fmt.Sprintf("%s %d", c.Proto, c.DstPort)]; ok {
+					add(fmt.Sprintf("pid %d", pid), graphEdge{
+						Peer: fmt.Sprintf("%s (%d)", names[pid], pid), PeerPID: pid,
+						Local: true, Port: c.DstPort,
+					}, rate)
+				} else {
+					addr := fmt.Sprintf("localhost:%d", c.DstPort)
+					add(addr, graphEdge{Peer: addr, Local: true, Port: c.DstPort}, rate)
+				}
+			default:
+				host := hosts.Format(c.RemoteHost, c.DstIP)
+				inbound := c.Direction == model.DirInbound
+				port := c.DstPort
+				if inbound {
+					port = c.SrcPort // the local service port
+				}
+				add(fmt.Sprintf("host %s %v", host, inbound), graphEdge{
+					Peer: host, Inbound: inbound, Port: port,
+				}, rate)
+			}
+		}
+		if len(order) == 0 {
+			continue
+		}
+		n := graphNode{PID: p.PID, Name: p.Name}
+		for _, key := range order {
+			n.Rate += edges[key].Rate
+			n.Edges = append(n.Edges, *edges[key])
+		}
+		sort.SliceStable(n.Edges, func(a, b int) bool { return n.Edges[a].Rate > n.Edges[b].Rate })
+		nodes = append(nodes, n)
+	}
+	sort.SliceStable(nodes, func(a, b int) bool {
+		if nodes[a].Rate != nodes[b].Rate {
+			return nodes[a].Rate > nodes[b].Rate
+		}
+		return nodes[a].Name < nodes[b].Name
+	})
+	return nodes
+}
+
+// graphRows flattens nodes into the lines of the view.
+func graphRows(nodes []graphNode) []graphRow {
+	var rows []graphRow
+	for i := range nodes {
+		n := &nodes[i]
+		rows = append(rows, graphRow{node: n})
+		for j := range n.Edges {
+			rows = append(rows, graphRow{node: n, edge: &n.Edges[j]})
+		}
+	}
+	return rows
+}
+
+// pid returns the process a row stands for: the node, or a local peer.
+func (r graphRow) pid() uint32 {
+	if r.edge != nil && r.edge.PeerPID != 0 {
+		return r.edge.PeerPID
+	}
+	return r.node.PID
+}
+
+// graphView shows which processes talk to each other and to which hosts,
+// as an adjacency list weighted by rate.
+type graphView struct {
+	cursor     int
+	offset     int
+	viewHeight int
+	window     listWindow // rows last drawn
+}
+
+func (v *graphView) moveUp() {
+	if v.cursor > 0 {
+		v.cursor--
+	}
+}
+
+func (v *graphView) moveDown(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	if v.cursor < maxIdx {
+		v.cursor++
+	}
+}
+
+func (v *graphView) pageUp() {
+	v.cursor -= v.viewHeight / 2
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+}
+
+func (v *graphView) pageDown(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	v.cursor += v.viewHeight / 2
+	if v.cursor > maxIdx {
+		v.cursor = maxIdx
+	}
+}
+
+func (v *graphView) goHome() {
+	v.cursor = 0
+}
+
+func (v *graphView) goEnd(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	v.cursor = maxIdx
+}
+
+func (v *graphView) render(rows []graphRow, width, height int) string {
+	v.viewHeight = height
+	v.window = listWindow{}
+	if len(rows) > 0 && v.cursor >= len(rows) {
+		v.cursor = len(rows) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+
+	titleLine := styleTitle.Render("  Service Graph")
+
+	rateW := 10
+	connsW := 6
+	nameW := rowsWidth(width, len(rows), height-2) - rateW - connsW - 4
+	if nameW < 20 {
+		nameW = 20
+	}
+	headerLine := fmt.Sprintf("  %-*s %*s %*s", nameW, "PROCESS → PEER", rateW, "RATE/s", connsW, "CONNS")
+	headerStyled := styleTableHeader.Render(headerLine)
+
+	if len(rows) == 0 {
+		empty := styleDetailLabel.Render("  No connections")
+		return strings.Join([]string{titleLine, headerStyled, empty}, "\n")
+	}
+
+	rowsAvail := height - 2 // title + header
+	if rowsAvail < 1 {
+		rowsAvail = 1
+	}
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+rowsAvail {
+		v.offset = v.cursor - rowsAvail + 1
+	}
+	end := v.offset + rowsAvail
+	if end > len(rows) {
+		end = len(rows)
+	}
+	v.window = listWindow{offset: v.offset, rows: end - v.offset, total: len(rows)}
+
+	var lines []string
+	for idx := v.offset; idx < end; idx++ {
+		r := rows[idx]
+		var label, conns string
+		rate := r.node.Rate
+		if r.edge == nil {
+			label = fmt.Sprintf("%s (%d)", r.node.Name, r.node.PID)
+		} else {
+			e := r.edge
+			arrow, kind := "→", "ext"
+			if e.Inbound {
+				arrow = "←"
+			}
+			if e.Local {
+				kind = "local"
+			}
+			label = fmt.Sprintf("  %s %s :%d  %s", arrow, e.Peer, e.Port, kind)
+			rate = e.Rate
+			conns = fmt.Sprint(e.Conns)
+		}
+		line := fmt.Sprintf("  %-*s %*s %*s",
+			nameW, truncateStr(label, nameW),
+			rateW, FormatRateCompact(rate),
+			connsW, conns,
+		)
+
+		var rowStyle lipgloss.Style
+		switch {
+		case idx == v.cursor:
+			rowStyle = styleTableRowSelected
+		case r.edge == nil:
+			rowStyle = styleHeaderValue
+		default:
+			rowStyle = styleTableRow
+		}
+		lines = append(lines, rowStyle.Render(line))
+	}
+	lines = append([]string{titleLine, headerStyled}, withScrollbar(lines, v.window, width)...)
+	return strings.Join(lines, "\n")
+}
+
+// serviceGraphRows returns the graph view's rows for the current snapshot.
+func (m *Model) serviceGraphRows() []graphRow {
+	snap := m.viewSnapshot(ViewGraph)
+	return graphRows(buildGraph(snap.Processes, snap.ListenPorts, m.hostNames))
+}
+
+// openGraphRow shows the detail view of the process a graph row stands for.
+func (m *Model) openGraphRow(r graphRow) {
+	if m.findProcess(r.pid()) == nil {
+		return // a listener with no connections of its own
+	}
+	m.mode = ViewProcessDetail
+	m.detail = newProcessDetail(r.pid())
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/googlesky/sstop/internal/hostfmt"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func testGraphSnapshot() model.Snapshot {
+	lo := net.ParseIP("127.0.0.1")
+	conn := func(src uint16, dstIP string, dst uint16, dir model.Direction, rate float64) model.Connection {
+		return model.Connection{
+			Proto: model.ProtoTCP, SrcIP: lo, SrcPort: src,
+			DstIP: net.ParseIP(dstIP), DstPort: dst, Direction: dir, UpRate: rate,
+		}
+	}
+	ext := conn(443, "203.0.113.9", 51000, model.DirInbound, 900)
+	ext.SrcIP = net.ParseIP("10.0.0.2")
+	return model.Snapshot{
+		Processes: []model.ProcessSummary{
+			{PID: 10, Name: "nginx", Connections: []model.Connection{
+				conn(40000, "127.0.0.1", 8080, model.DirOutbound, 300),
+				ext,
+			}},
+			{PID: 20, Name: "app", Connections: []model.Connection{
+				conn(8080, "127.0.0.1", 40000, model.DirInbound, 300), // nginx's, seen from app
+				conn(41000, "127.0.0.1", 6379, model.DirOutbound, 50),
+				conn(41001, "127.0.0.1", 6379, model.DirOutbound, 25),
+				{Proto: model.ProtoTCP, SrcIP: net.ParseIP("10.0.0.2"), SrcPort: 42000,
+					DstIP: net.ParseIP("198.51.100.7"), DstPort: 443, RemoteHost: "api.example.com",
+					Direction: model.DirOutbound, DownRate: 100},
+			}},
+		},
+		ListenPorts: []model.ListenPortEntry{
+			{Proto: model.ProtoTCP, IP: net.IPv4zero, Port: 6379, PID: 30, Process: "redis-server"},
+		},
+	}
+}
+
+func TestBuildGraph(t *testing.T) {
+	snap := testGraphSnapshot()
+	nodes := buildGraph(snap.Processes, snap.ListenPorts, hostfmt.FQDN)
+	if len(nodes) != 2 || nodes[0].Name != "nginx" || nodes[1].Name != "app" {
+		t.Fatalf("nodes = %+v, want nginx then app", nodes)
+	}
+
+	nginx := nodes[0]
+	if len(nginx.Edges) != 2 {
+		t.Fatalf("nginx edges = %+v", nginx.Edges)
+	}
+	if e := nginx.Edges[0]; e.Peer != "203.0.113.9" || !e.Inbound || e.Port != 443 {
+		t.Errorf("nginx heaviest edge = %+v, want inbound client on :443", e)
+	}
+	if e := nginx.Edges[1]; e.PeerPID != 20 || !e.Local || e.Rate != 300 {
+		t.Errorf("nginx → app edge = %+v", e)
+	}
+
+	// The inbound side of nginx → app isn't counted again; redis is found
+	// by its listening port and its two connections merge
+	app := nodes[1]
+	if len(app.Edges) != 2 {
+		t.Fatalf("app edges = %+v, want api.example.com and redis", app.Edges)
+	}
+	if e := app.Edges[0]; e.Peer != "api.example.com" || e.Local {
+		t.Errorf("app heaviest edge = %+v, want api.example.com", e)
+	}
+	if e := app.Edges[1]; e.Peer != "redis-server (30)" || e.Conns != 2 || e.Rate != 75 {
+		t.Errorf("app → redis edge = %+v", e)
+	}
+}
+
+func TestGraphViewRender(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 100, 20
+	next, _ := m.Update(SnapshotMsg(testGraphSnapshot()))
+	m = next.(Model)
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")})
+	if m.mode != ViewGraph {
+		t.Fatalf("S: mode %v, want the graph view", m.mode)
+	}
+
+	view := m.View()
+	for _, want := range []string{"Service Graph", "nginx (10)", "→ app (20) :8080  local", "← 203.0.113.9 :443  ext"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	// Enter on the nginx → app edge opens app
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	m = press(press(m, down), down)
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ViewProcessDetail || m.detail.pid != 20 {
+		t.Errorf("enter on edge: mode %v pid %d, want app's detail", m.mode, m.detail.pid)
+	}
+}
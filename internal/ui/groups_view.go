@@ -2,17 +2,20 @@ package ui
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/config"
 	"github.com/googlesky/sstop/internal/model"
 )
 
 // groupEntry represents an aggregated process group (container/service/user).
 type groupEntry struct {
 	Name      string  // display name
-	Type      string  // "docker", "podman", "systemd", "user"
+	Type      string  // "tag", "pod", "container", "systemd", "user"
 	ProcCount int     // number of processes in this group
 	UpRate    float64 // aggregate upload rate
 	DownRate  float64 // aggregate download rate
@@ -24,6 +27,8 @@ type groupsView struct {
 	cursor     int
 	offset     int
 	viewHeight int
+	window     listWindow // rows last drawn
+	filter     string     // / in this view
 }
 
 func (v *groupsView) moveUp() {
@@ -69,8 +74,100 @@ func (v *groupsView) goEnd(maxIdx int) {
 	v.cursor = maxIdx
 }
 
+// groupRule is a config [[groups]] rule, compiled.
+type groupRule struct {
+	name             string
+	process, cmdline *regexp.Regexp // nil: any
+	user             string
+	ports            []uint16
+}
+
+func newGroupRules(cfg []config.GroupRule) ([]groupRule, error) {
+	var rules []groupRule
+	for i, g := range cfg {
+		r := groupRule{name: g.Name, user: g.User, ports: g.Ports}
+		var err error
+		if g.Process != "" {
+			if r.process, err = regexp.Compile(g.Process); err != nil {
+				return nil, fmt.Errorf("groups[%d]: process: %w", i, err)
+			}
+		}
+		if g.Cmdline != "" {
+			if r.cmdline, err = regexp.Compile(g.Cmdline); err != nil {
+				return nil, fmt.Errorf("groups[%d]: cmdline: %w", i, err)
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// match reports whether the rule matches proc, listening on listen.
+func (r *groupRule) match(proc *model.ProcessSummary, listen []uint16) bool {
+	if r.process != nil && !r.process.MatchString(proc.Name) {
+		return false
+	}
+	if r.cmdline != nil && !r.cmdline.MatchString(proc.Cmdline) {
+		return false
+	}
+	if r.user != "" && r.user != proc.User && (proc.UID == nil || r.user != strconv.FormatUint(uint64(*proc.UID), 10)) {
+		return false
+	}
+	if len(r.ports) == 0 {
+		return true
+	}
+	for _, want := range r.ports {
+		for _, p := range listen {
+			if p == want {
+				return true
+			}
+		}
+		for _, c := range proc.Connections {
+			if c.SrcPort == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// annotateTags puts each of a snapshot's processes in the group of the
+// first rule it matches.
+func annotateTags(snap *model.Snapshot, rules []groupRule) {
+	if len(rules) == 0 {
+		return
+	}
+	listen := make(map[uint32][]uint16)
+	for _, lp := range snap.ListenPorts {
+		listen[lp.PID] = append(listen[lp.PID], lp.Port)
+	}
+	tag := func(p *model.ProcessSummary) {
+		p.Tag = ""
+		for i := range rules {
+			if rules[i].match(p, listen[p.PID]) {
+				p.Tag = rules[i].name
+				return
+			}
+		}
+	}
+	for i := range snap.Processes {
+		tag(&snap.Processes[i])
+	}
+	for i := range snap.Exited {
+		tag(&snap.Exited[i])
+	}
+}
+
 // classifyGroup determines the group name and type for a process.
 func classifyGroup(proc *model.ProcessSummary) (name, typ string) {
+	if proc.Tag != "" {
+		// Configured groups come first
+		return proc.Tag, "tag"
+	}
+	if proc.PodName != "" {
+		// All containers of a pod count together
+		return podLabel(proc), "pod"
+	}
 	if proc.ContainerID != "" {
 		// Docker or Podman — we can't easily distinguish without more info,
 		// so just call it "container"
@@ -82,6 +179,14 @@ func classifyGroup(proc *model.ProcessSummary) (name, typ string) {
 	return "other", "user"
 }
 
+// podLabel returns "namespace/pod" for a process in a Kubernetes pod.
+func podLabel(proc *model.ProcessSummary) string {
+	if proc.Namespace == "" {
+		return proc.PodName
+	}
+	return proc.Namespace + "/" + proc.PodName
+}
+
 // buildGroups aggregates processes into groups.
 func buildGroups(procs []model.ProcessSummary) []groupEntry {
 	type agg struct {
@@ -130,10 +235,9 @@ func buildGroups(procs []model.ProcessSummary) []groupEntry {
 	return result
 }
 
-func (v *groupsView) render(procs []model.ProcessSummary, width, height int) string {
-	groups := buildGroups(procs)
-
+func (v *groupsView) render(groups []groupEntry, width, height int) string {
 	v.viewHeight = height
+	v.window = listWindow{}
 
 	// Clamp cursor if groups count changed
 	if len(groups) > 0 && v.cursor >= len(groups) {
@@ -144,7 +248,7 @@ func (v *groupsView) render(procs []model.ProcessSummary, width, height int) str
 	}
 
 	// Title
-	title := styleTitle.Render("  Groups (Docker / Systemd)")
+	title := styleTitle.Render("  Groups (Tag / Pod / Container / Systemd)")
 	titleLine := title
 
 	// Column widths
@@ -155,7 +259,7 @@ func (v *groupsView) render(procs []model.ProcessSummary, width, height int) str
 	downW := 8
 	connsW := 6
 	fixedW := typeW + procsW + upW + downW + connsW + 7 // 7 for separators/padding
-	nameW := width - fixedW
+	nameW := rowsWidth(width, len(groups), height-2) - fixedW
 	if nameW < 10 {
 		nameW = 10
 	}
@@ -195,6 +299,7 @@ func (v *groupsView) render(procs []model.ProcessSummary, width, height int) str
 	if end > len(groups) {
 		end = len(groups)
 	}
+	v.window = listWindow{offset: v.offset, rows: end - v.offset, total: len(groups)}
 
 	for idx := v.offset; idx < end; idx++ {
 		g := groups[idx]
@@ -230,7 +335,7 @@ func (v *groupsView) render(procs []model.ProcessSummary, width, height int) str
 	var parts []string
 	parts = append(parts, titleLine)
 	parts = append(parts, headerStyled)
-	parts = append(parts, rows...)
+	parts = append(parts, withScrollbar(rows, v.window, width)...)
 
 	return strings.Join(parts, "\n")
 }
@@ -3,6 +3,7 @@ package ui
 import (
 	"testing"
 
+	"github.com/googlesky/sstop/internal/config"
 	"github.com/googlesky/sstop/internal/model"
 )
 
@@ -230,3 +231,69 @@ func TestClassifyGroup_ContainerPrecedence(t *testing.T) {
 		t.Errorf("typ = %q, want %q", typ, "container")
 	}
 }
+
+func TestBuildGroups_PodAcrossContainers(t *testing.T) {
+	// Sidecar and app containers of one pod form a single group
+	procs := []model.ProcessSummary{
+		{PID: 1, Name: "app", ContainerID: "aaa111", PodName: "web-7d4b9c", Namespace: "shop", UpRate: 100, ConnCount: 2},
+		{PID: 2, Name: "envoy", ContainerID: "bbb222", PodName: "web-7d4b9c", Namespace: "shop", UpRate: 50, ConnCount: 3},
+		{PID: 3, Name: "dockerd-app", ContainerID: "ccc333"},
+	}
+	groups := buildGroups(procs)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2: %+v", len(groups), groups)
+	}
+	g := groups[0]
+	if g.Name != "shop/web-7d4b9c" || g.Type != "pod" || g.ProcCount != 2 || g.ConnCount != 5 {
+		t.Errorf("pod group = %+v", g)
+	}
+
+	f := ParseFilter("group:shop/web")
+	if !f.Match(&procs[1]) || f.Match(&procs[2]) {
+		t.Error("group: filter should match pod members only")
+	}
+}
+
+func TestGroupRules(t *testing.T) {
+	rules, err := newGroupRules([]config.GroupRule{
+		{Name: "databases", Ports: []uint16{5432, 6379}},
+		{Name: "build agents", Process: "^(cargo|go)$", User: "ci"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := model.Snapshot{
+		Processes: []model.ProcessSummary{
+			{PID: 1, Name: "postgres", ServiceName: "postgresql.service", UpRate: 100},
+			{PID: 2, Name: "redis-server", ContainerID: "abc123", UpRate: 50,
+				Connections: []model.Connection{{SrcPort: 6379}}},
+			{PID: 3, Name: "cargo", User: "ci", UpRate: 10},
+			{PID: 4, Name: "go", User: "alice", ServiceName: "user@1000.service"},
+		},
+		ListenPorts: []model.ListenPortEntry{{Port: 5432, PID: 1}},
+	}
+	annotateTags(&snap, rules)
+
+	groups := buildGroups(snap.Processes)
+	byName := make(map[string]groupEntry)
+	for _, g := range groups {
+		byName[g.Name] = g
+	}
+	if g := byName["databases"]; g.Type != "tag" || g.ProcCount != 2 || g.UpRate != 150 {
+		t.Errorf("databases = %+v, want postgres (listening) and redis (connected)", g)
+	}
+	if g := byName["build agents"]; g.ProcCount != 1 {
+		t.Errorf("build agents = %+v, want only ci's cargo", g)
+	}
+	if _, ok := byName["user@1000.service"]; !ok {
+		t.Errorf("alice's go should stay in its systemd group: %+v", groups)
+	}
+
+	f := ParseFilter("group:databases")
+	if !f.Match(&snap.Processes[1]) || f.Match(&snap.Processes[2]) {
+		t.Error("group: filter should match the configured group's members")
+	}
+	if ParseFilter("group:other").Match(&snap.Processes[2]) {
+		t.Error("a tagged process isn't ungrouped")
+	}
+}
@@ -8,9 +8,13 @@ import (
 	"github.com/googlesky/sstop/internal/model"
 )
 
-func renderHeader(snap model.Snapshot, width int, paused bool, activeIface string, cumulativeMode bool, alertText string, playbackInfo string) string {
+func renderHeader(snap model.Snapshot, width int, paused bool, activeIface string, cumulativeMode bool, alertText string, sourceInfo string) string {
 	title := styleTitle.Render("sstop")
-	timestamp := styleDetailLabel.Render(snap.Timestamp.Format("15:04:05"))
+	clock := "--:--:--" // no snapshot yet, or test mode
+	if !snap.Timestamp.IsZero() {
+		clock = snap.Timestamp.Format("15:04:05")
+	}
+	timestamp := styleDetailLabel.Render(clock)
 
 	// Pause indicator
 	pauseTag := ""
@@ -47,10 +51,10 @@ func renderHeader(snap model.Snapshot, width int, paused bool, activeIface strin
 		cumTag = " " + stylePaused.Render(" CUM ")
 	}
 
-	// Playback badge
+	// Playback or stale-source badge
 	playbackTag := ""
-	if playbackInfo != "" {
-		playbackTag = " " + stylePaused.Render(" "+playbackInfo+" ")
+	if sourceInfo != "" {
+		playbackTag = " " + stylePaused.Render(" "+sourceInfo+" ")
 	}
 
 	var upLabel, downLabel string
@@ -58,14 +62,14 @@ func renderHeader(snap model.Snapshot, width int, paused bool, activeIface strin
 		// Sum cumulative bytes across all processes
 		var totalCumUp, totalCumDown uint64
 		for _, p := range snap.Processes {
-			totalCumUp += p.CumUp
-			totalCumDown += p.CumDown
+			totalCumUp += p.ProgramCumUp()
+			totalCumDown += p.ProgramCumDown()
 		}
 		upLabel = styleHeaderUp.Render("▲ " + FormatBytes(totalCumUp))
 		downLabel = styleHeaderDown.Render("▼ " + FormatBytes(totalCumDown))
 	} else {
 		// Single trend arrow for total bandwidth (up+down combined)
-		trendArrow := TrendArrow(snap.TotalRateHistory)
+		trendArrow := TrendArrow(totalRateHistory(snap))
 		trendStyled := ""
 		switch trendArrow {
 		case "↑":
@@ -83,15 +87,32 @@ func renderHeader(snap model.Snapshot, width int, paused bool, activeIface strin
 	// Alert tag
 	alertTag := ""
 	if alertText != "" {
-		alertTag = " " + styleAlertTag.Render(alertText)
+		alertTag = " " + alertText // pre-styled by the alert overlay
+	}
+
+	// Slow-collection indicator — explains gaps in the graphs
+	skippedTag := ""
+	if snap.SkippedPolls > 0 {
+		skippedTag = "  " + styleDetailLabel.Render(skippedPollsText(snap.SkippedPolls))
 	}
 
 	left := lipgloss.JoinHorizontal(lipgloss.Center,
-		title, "  ", timestamp, pauseTag, cumTag, playbackTag, alertTag, "  ", procCount,
+		title, "  ", timestamp, pauseTag, cumTag, playbackTag, alertTag, "  ", procCount, skippedTag,
 	)
 	right := lipgloss.JoinHorizontal(lipgloss.Center,
 		ifaceTag, upLabel, "  ", downLabel,
 	)
+	// Narrow terminals: drop the process count, then the interface tag and
+	// timestamp, rather than wrap the line
+	if lipgloss.Width(left)+lipgloss.Width(right) >= width {
+		left = lipgloss.JoinHorizontal(lipgloss.Center,
+			title, "  ", timestamp, pauseTag, cumTag, playbackTag, alertTag,
+		)
+	}
+	if lipgloss.Width(left)+lipgloss.Width(right) >= width {
+		left = lipgloss.JoinHorizontal(lipgloss.Center, title, pauseTag, cumTag, playbackTag, alertTag)
+		right = lipgloss.JoinHorizontal(lipgloss.Center, upLabel, " ", downLabel)
+	}
 
 	// Pad the space between left and right
 	gap := width - lipgloss.Width(left) - lipgloss.Width(right)
@@ -101,38 +122,47 @@ func renderHeader(snap model.Snapshot, width int, paused bool, activeIface strin
 
 	headerLine := left + strings.Repeat(" ", gap) + right
 
-	// Header sparkline — total bandwidth history
+	// Header sparkline — total bandwidth history, two-tone when the
+	// per-direction split is available
 	sparklineLine := ""
-	if len(snap.TotalRateHistory) > 0 {
-		sparkW := 30
-		if sparkW > width-4 {
-			sparkW = width - 4
-		}
-		if sparkW > 0 {
+	sparkW := 30
+	if sparkW > width-4 {
+		sparkW = width - 4
+	}
+	if sparkW > 0 {
+		if len(snap.UpRateHistory) > 0 && len(snap.DownRateHistory) > 0 {
+			var b strings.Builder
+			for _, run := range splitSparkline(snap.UpRateHistory, snap.DownRateHistory, sparkW) {
+				if run.up {
+					b.WriteString(styleHeaderUp.Render(run.text))
+				} else {
+					b.WriteString(styleHeaderDown.Render(run.text))
+				}
+			}
+			sparklineLine = "  " + b.String()
+		} else if len(snap.TotalRateHistory) > 0 {
 			sparkline := Sparkline(snap.TotalRateHistory, sparkW)
 			sparklineLine = "  " + styleSparklineActive.Render(sparkline)
 		}
 	}
 
-	// Interface stats line — show rates for each interface (skip zero-traffic unless active)
-	var ifaceParts []string
-	for _, iface := range snap.Interfaces {
-		// Skip interfaces with no traffic, unless it's the selected interface
-		if iface.SendRate == 0 && iface.RecvRate == 0 && activeIface != iface.Name {
-			continue
-		}
-		// Highlight the active interface
-		nameStyle := styleDetailLabel
-		if activeIface == iface.Name {
-			nameStyle = styleFooterKey
+	// Interface stats line — show rates for each interface (skip zero-traffic
+	// unless active), each with its own graph while they all fit
+	buildIfaceParts := func(graphs bool) []string {
+		var parts []string
+		for i := range snap.Interfaces {
+			iface := &snap.Interfaces[i]
+			// Skip interfaces with no traffic, unless it's the selected interface
+			if iface.SendRate == 0 && iface.RecvRate == 0 && activeIface != iface.Name {
+				continue
+			}
+			parts = append(parts, ifaceHeaderPart(iface, activeIface == iface.Name, graphs))
 		}
-		ifaceParts = append(ifaceParts,
-			nameStyle.Render(iface.Name+":")+
-				" "+styleHeaderUp.Render(FormatRate(iface.SendRate))+
-				styleDetailLabel.Render("↑ ")+
-				styleHeaderDown.Render(FormatRate(iface.RecvRate))+
-				styleDetailLabel.Render("↓"),
-		)
+		return parts
+	}
+	ifaceParts := buildIfaceParts(true)
+	if lipgloss.Width(strings.Join(ifaceParts, "  ")) > width {
+		ifaceParts = buildIfaceParts(false)
 	}
 	ifaceLine := ""
 	if len(ifaceParts) > 0 {
@@ -169,3 +199,84 @@ func renderHeader(snap model.Snapshot, width int, paused bool, activeIface strin
 
 	return strings.Join(parts, "\n")
 }
+
+// ifaceSparkW is the width of each interface's graph in the header.
+const ifaceSparkW = 8
+
+// ifaceHeaderPart renders an interface's entry on the header's interface
+// line: its name, optionally a graph of its recent traffic coloured by
+// the dominant direction, then its rates. The active one is highlighted.
+func ifaceHeaderPart(iface *model.InterfaceStats, active, graph bool) string {
+	nameStyle := styleDetailLabel
+	if active {
+		nameStyle = styleFooterKey
+	}
+	part := nameStyle.Render(iface.Name+":") + " "
+	if graph && len(iface.SendHistory) > 0 && len(iface.RecvHistory) > 0 {
+		for _, run := range splitSparkline(iface.SendHistory, iface.RecvHistory, ifaceSparkW) {
+			if run.up {
+				part += styleHeaderUp.Render(run.text)
+			} else {
+				part += styleHeaderDown.Render(run.text)
+			}
+		}
+		part += " "
+	}
+	return part + styleHeaderUp.Render(FormatRate(iface.SendRate)) +
+		styleDetailLabel.Render("↑ ") +
+		styleHeaderDown.Render(FormatRate(iface.RecvRate)) +
+		styleDetailLabel.Render("↓")
+}
+
+// skippedPollsText describes polls dropped because collection overran the interval.
+func skippedPollsText(n int) string {
+	if n == 1 {
+		return "1 poll skipped (collection slow)"
+	}
+	return fmt.Sprintf("%d polls skipped (collection slow)", n)
+}
+
+// totalRateHistory returns the up+down rate history, summing the
+// per-direction histories when only those are present (playback).
+func totalRateHistory(snap model.Snapshot) []float64 {
+	if len(snap.TotalRateHistory) > 0 || len(snap.UpRateHistory) != len(snap.DownRateHistory) {
+		return snap.TotalRateHistory
+	}
+	total := make([]float64, len(snap.UpRateHistory))
+	for i := range total {
+		total[i] = snap.UpRateHistory[i] + snap.DownRateHistory[i]
+	}
+	return total
+}
+
+// sparkRun is a stretch of header graph columns with the same dominant
+// direction.
+type sparkRun struct {
+	text string
+	up   bool // upload dominated (otherwise download, or idle)
+}
+
+// splitSparkline renders the combined up+down history as a sparkline whose
+// columns are tagged by dominant direction, so an upload burst and a
+// download burst of the same size look different.
+func splitSparkline(up, down []float64, width int) []sparkRun {
+	n := min(len(up), len(down), width)
+	up, down = up[len(up)-n:], down[len(down)-n:]
+	total := make([]float64, n)
+	for i := range total {
+		total[i] = up[i] + down[i]
+	}
+	graph := []rune(Sparkline(total, width))
+	pad := width - n
+
+	var runs []sparkRun
+	for i, r := range graph {
+		isUp := i >= pad && up[i-pad] > down[i-pad]
+		if len(runs) > 0 && runs[len(runs)-1].up == isUp {
+			runs[len(runs)-1].text += string(r)
+			continue
+		}
+		runs = append(runs, sparkRun{text: string(r), up: isUp})
+	}
+	return runs
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestSplitSparkline(t *testing.T) {
+	up := []float64{0, 100, 10, 10}
+	down := []float64{0, 10, 100, 10}
+	runs := splitSparkline(up, down, 6)
+
+	var text string
+	var dirs []bool
+	for _, r := range runs {
+		text += r.text
+		for range []rune(r.text) {
+			dirs = append(dirs, r.up)
+		}
+	}
+	if n := len([]rune(text)); n != 6 {
+		t.Fatalf("graph width = %d, want 6", n)
+	}
+	// Two pad columns and the idle sample, then upload burst, download
+	// burst, and a tie (not upload dominated)
+	want := []bool{false, false, false, true, false, false}
+	for i := range want {
+		if dirs[i] != want[i] {
+			t.Errorf("column %d upload = %v, want %v (runs %+v)", i, dirs[i], want[i], runs)
+		}
+	}
+	// Same-size bursts render at the same height
+	g := []rune(text)
+	if g[3] != g[4] {
+		t.Errorf("upload and download bursts differ in height: %q", text)
+	}
+
+	// Longer history than the graph keeps the newest samples
+	if runs := splitSparkline([]float64{9, 9, 1}, []float64{0, 0, 5}, 1); len(runs) != 1 || runs[0].up {
+		t.Errorf("truncated graph = %+v, want the last (download) column", runs)
+	}
+}
+
+func TestTotalRateHistoryFallback(t *testing.T) {
+	snap := model.Snapshot{UpRateHistory: []float64{1, 2}, DownRateHistory: []float64{3, 4}}
+	got := totalRateHistory(snap)
+	if len(got) != 2 || got[0] != 4 || got[1] != 6 {
+		t.Errorf("totalRateHistory = %v, want [4 6]", got)
+	}
+}
+
+func TestHeaderInterfaceGraphs(t *testing.T) {
+	snap := model.Snapshot{Interfaces: []model.InterfaceStats{
+		{Name: "eth0", RecvRate: 2048, RecvHistory: []float64{0, 1024, 2048}, SendHistory: []float64{0, 0, 0}},
+		{Name: "wg0", SendRate: 512, RecvHistory: []float64{0, 0, 0}, SendHistory: []float64{512, 0, 512}},
+	}}
+
+	line := func(width int) string {
+		lines := strings.Split(ansi.Strip(renderHeader(snap, width, false, "", false, "", "")), "\n")
+		for _, l := range lines {
+			if strings.Contains(l, "eth0:") {
+				return l
+			}
+		}
+		t.Fatalf("no interface line in header:\n%s", strings.Join(lines, "\n"))
+		return ""
+	}
+
+	// Each interface scales to its own peak
+	wide := line(160)
+	if !strings.Contains(wide, "eth0:       ▄█ ") || !strings.Contains(wide, "wg0:      █ █ ") {
+		t.Errorf("interface line lacks per-interface graphs: %q", wide)
+	}
+	// Too narrow for the graphs: rates only
+	if narrow := line(60); strings.ContainsAny(narrow, "▄█") || !strings.Contains(narrow, "wg0:") {
+		t.Errorf("narrow interface line = %q, want both interfaces without graphs", narrow)
+	}
+}
@@ -4,30 +4,40 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/version"
 )
 
 var (
+	styleHelpBorder  lipgloss.Style
+	styleHelpTitle   lipgloss.Style
+	styleHelpKey     lipgloss.Style
+	styleHelpDesc    lipgloss.Style
+	styleHelpSection lipgloss.Style
+)
+
+// buildHelpStyles is called from buildStyles.
+func buildHelpStyles() {
 	styleHelpBorder = lipgloss.NewStyle().
-			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(colorAccent).
-			Background(colorBg).
-			Padding(1, 2)
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(colorAccent).
+		Background(colorBg).
+		Padding(1, 2)
 
 	styleHelpTitle = lipgloss.NewStyle().
-			Foreground(colorAccent).
-			Bold(true)
+		Foreground(colorAccent).
+		Bold(true)
 
 	styleHelpKey = lipgloss.NewStyle().
-			Foreground(colorYellow).
-			Bold(true)
+		Foreground(colorYellow).
+		Bold(true)
 
 	styleHelpDesc = lipgloss.NewStyle().
-			Foreground(colorFg)
+		Foreground(colorFg)
 
 	styleHelpSection = lipgloss.NewStyle().
-				Foreground(colorCyan).
-				Bold(true)
-)
+		Foreground(colorCyan).
+		Bold(true)
+}
 
 func renderHelp(width, height int) string {
 	kv := func(key, desc string) string {
@@ -44,24 +54,63 @@ func renderHelp(width, height int) string {
 	leftCol = append(leftCol, styleHelpSection.Render("Process Table"))
 	leftCol = append(leftCol, kv("enter   ", "open detail"))
 	leftCol = append(leftCol, kv("s       ", "cycle sort"))
+	leftCol = append(leftCol, kv("R       ", "reverse sort"))
 	leftCol = append(leftCol, kv("/       ", "search/filter"))
 	leftCol = append(leftCol, kv("h       ", "remote hosts"))
 	leftCol = append(leftCol, kv("l       ", "listen ports"))
+	leftCol = append(leftCol, kv("v       ", "all connections"))
+	leftCol = append(leftCol, kv("I       ", "interfaces"))
 	leftCol = append(leftCol, kv("K       ", "kill process"))
+	leftCol = append(leftCol, kv("B       ", "firewall rules added"))
+	leftCol = append(leftCol, kv("L       ", "limit bandwidth (exp.)"))
 	leftCol = append(leftCol, kv("D       ", "group view"))
+	leftCol = append(leftCol, kv("J       ", "journal (systemd group)"))
+	leftCol = append(leftCol, kv("u       ", "users view"))
+	leftCol = append(leftCol, kv("U       ", "usage today/week/month"))
+	leftCol = append(leftCol, kv("C       ", "countries / AS"))
+	leftCol = append(leftCol, kv("S       ", "service graph"))
+	leftCol = append(leftCol, kv("m       ", "bandwidth treemap"))
+	leftCol = append(leftCol, kv("f       ", "process → host flows"))
+	leftCol = append(leftCol, kv("T       ", "top dest column"))
+	leftCol = append(leftCol, kv("p       ", "IPv6 by /64 (hosts)"))
+	leftCol = append(leftCol, kv("t       ", "process tree"))
+	leftCol = append(leftCol, kv("z       ", "idle: show/dim/hide"))
+	leftCol = append(leftCol, kv("V       ", "select: space/m mark"))
+	leftCol = append(leftCol, kv("e       ", "save marked as JSON"))
+	leftCol = append(leftCol, kv("O       ", "only marked rows"))
+	leftCol = append(leftCol, kv("Z       ", "baseline: show changes"))
+	leftCol = append(leftCol, kv("h/l ←→  ", "collapse/expand (tree)"))
+	leftCol = append(leftCol, kv("c       ", "cumulative totals"))
+	leftCol = append(leftCol, kv("x / X   ", "dismiss exited / all"))
+	leftCol = append(leftCol, kv("n / N   ", "note on PID / name"))
+	leftCol = append(leftCol, kv("o       ", "cycle netns (--netns)"))
 
 	// Right column: Detail + Global
 	var rightCol []string
 	rightCol = append(rightCol, styleHelpSection.Render("Process Detail"))
-	rightCol = append(rightCol, kv("d       ", "toggle DNS"))
+	rightCol = append(rightCol, kv("r       ", "TCP stats (RTT/retrans)"))
+	rightCol = append(rightCol, kv("ctrl+r  ", "refresh now"))
 	rightCol = append(rightCol, kv("K       ", "kill process"))
+	rightCol = append(rightCol, kv("x       ", "close selected conn"))
+	rightCol = append(rightCol, kv("B       ", "block/limit remote IP"))
+	rightCol = append(rightCol, kv("L       ", "limit bandwidth (exp.)"))
+	rightCol = append(rightCol, kv("w       ", "pcap capture on/off"))
+	rightCol = append(rightCol, kv("b       ", "rate chart (5 min)"))
 	rightCol = append(rightCol, kv("esc     ", "back to table"))
 	rightCol = append(rightCol, "")
 	rightCol = append(rightCol, styleHelpSection.Render("Global"))
 	rightCol = append(rightCol, kv("i / tab ", "cycle interface"))
 	rightCol = append(rightCol, kv("+ / -   ", "refresh speed"))
+	rightCol = append(rightCol, kv("r       ", "refresh now"))
 	rightCol = append(rightCol, kv("space   ", "pause/resume"))
 	rightCol = append(rightCol, kv("← / →   ", "playback speed"))
+	rightCol = append(rightCol, kv("F1-F12  ", "layout presets"))
+	rightCol = append(rightCol, kv("d       ", "hosts: name/short/IP"))
+	rightCol = append(rightCol, kv("F / 1-9 ", "saved filters"))
+	rightCol = append(rightCol, kv("P       ", "privacy mode"))
+	rightCol = append(rightCol, kv("M       ", "mouse capture"))
+	rightCol = append(rightCol, kv("`       ", "debug HUD (timing/coverage)"))
+	rightCol = append(rightCol, kv("H       ", "next host (connect)"))
 	rightCol = append(rightCol, kv("?       ", "toggle help"))
 	rightCol = append(rightCol, kv("q       ", "quit"))
 
@@ -74,7 +123,9 @@ func renderHelp(width, height int) string {
 
 	title := styleHelpTitle.Render("  Keyboard Shortcuts")
 
-	content := title + "\n\n" + columns
+	buildInfo := styleDetailLabel.Render(version.Short() + "  backends: " + version.BackendSummary())
+
+	content := title + "\n\n" + columns + "\n\n" + buildInfo
 
 	box := styleHelpBorder.Render(content)
 
@@ -0,0 +1,185 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// hudLen is how many polls the debug HUD keeps.
+const hudLen = 60
+
+// debugHUD is the header lines ` toggles: how long each poll took to
+// collect and how far apart snapshots arrive, to tell a stalled collector
+// from a quiet network, and how much of the interfaces' traffic the
+// processes account for. Recordings keep the latency, so playback shows it.
+type debugHUD struct {
+	on        bool
+	latencies []float64 // seconds per poll, oldest first; 0 = not recorded
+	lastAt    time.Time // the previous snapshot's time
+	gap       time.Duration
+	maxGap    time.Duration // the longest gap among the polls kept
+	gaps      []time.Duration
+	coverage  []attribution // per poll, oldest first
+}
+
+// attribution is a poll's traffic on the interfaces and the part of it the
+// processes account for, bytes/sec.
+type attribution struct {
+	procUp, procDown float64
+	ifUp, ifDown     float64
+}
+
+// minCoverageRate is the interface rate below which coverage isn't worked
+// out: a few packets of ARP or the poll's own timing swing it wildly.
+const minCoverageRate = 1024
+
+// attributionOf sums the snapshot's per-process rates against its
+// interface counters. Loopback connections are left out, as the
+// interfaces don't count them (and each end would count them once), and
+// so is kernel/unknown, which no process accounts for.
+func attributionOf(snap *model.Snapshot) attribution {
+	a := attribution{ifUp: snap.TotalUp, ifDown: snap.TotalDown}
+	for i := range snap.Processes {
+		p := &snap.Processes[i]
+		if p.PID == model.UnattributedPID {
+			continue
+		}
+		up, down := p.UpRate, p.DownRate
+		for j := range p.Connections {
+			if c := &p.Connections[j]; c.DstIP.IsLoopback() {
+				up -= c.UpRate
+				down -= c.DownRate
+			}
+		}
+		a.procUp += max(up, 0)
+		a.procDown += max(down, 0)
+	}
+	return a
+}
+
+// covered returns the fraction of the interface traffic attributed;
+// ok is false while the interfaces are close to idle.
+func covered(proc, iface float64) (frac float64, ok bool) {
+	if iface < minCoverageRate {
+		return 0, false
+	}
+	return proc / iface, true
+}
+
+// observe adds a snapshot. It runs whether or not the HUD is shown, so
+// turning it on shows the recent past.
+func (h *debugHUD) observe(snap model.Snapshot) {
+	h.latencies = append(h.latencies, snap.CollectLatency.Seconds())
+	if n := len(h.latencies) - hudLen; n > 0 {
+		h.latencies = h.latencies[n:]
+	}
+	if !h.lastAt.IsZero() && snap.Timestamp.After(h.lastAt) {
+		h.gap = snap.Timestamp.Sub(h.lastAt)
+		h.gaps = append(h.gaps, h.gap)
+		if n := len(h.gaps) - hudLen; n > 0 {
+			h.gaps = h.gaps[n:]
+		}
+		h.maxGap = 0
+		for _, g := range h.gaps {
+			h.maxGap = max(h.maxGap, g)
+		}
+	}
+	h.lastAt = snap.Timestamp
+
+	h.coverage = append(h.coverage, attributionOf(&snap))
+	if n := len(h.coverage) - hudLen; n > 0 {
+		h.coverage = h.coverage[n:]
+	}
+}
+
+// formatLatency formats a poll's duration, e.g. "850µs", "12ms", "1.2s".
+func formatLatency(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+// render draws the HUD lines. interval is the polling interval, 0 when
+// unknown (remote or playback); a poll taking longer is highlighted.
+func (h *debugHUD) render(width int, interval time.Duration) string {
+	line := styleDetailLabel.Render(" collect ")
+	var sum, peak float64
+	n := 0
+	for _, s := range h.latencies {
+		if s > 0 {
+			sum += s
+			peak = max(peak, s)
+			n++
+		}
+	}
+	if n == 0 {
+		line += styleDetailLabel.Render("latency not recorded")
+	} else {
+		last := time.Duration(h.latencies[len(h.latencies)-1] * float64(time.Second))
+		style := styleHeaderValue
+		if interval > 0 && last > interval {
+			style = styleAlertTag
+		}
+		line += style.Render(formatLatency(last)) + " " +
+			styleSparkline.Render(Sparkline(h.latencies, min(hudLen, max(width/4, 10)))) + " " +
+			styleDetailLabel.Render(fmt.Sprintf("avg %s  max %s",
+				formatLatency(time.Duration(sum/float64(n)*float64(time.Second))),
+				formatLatency(time.Duration(peak*float64(time.Second)))))
+	}
+	if h.gap > 0 {
+		line += styleDetailLabel.Render("  gap ") + styleHeaderValue.Render(formatLatency(h.gap)) +
+			styleDetailLabel.Render(" (max "+formatLatency(h.maxGap)+")")
+	}
+	if interval > 0 {
+		line += styleDetailLabel.Render("  interval " + formatLatency(interval))
+	}
+	return ansi.Truncate(line, width, "…") + "\n" + ansi.Truncate(h.renderCoverage(), width, "…")
+}
+
+// renderCoverage draws the attribution line: the share of each
+// direction's interface traffic the processes account for in the last
+// poll, and over the polls kept. Far from 100% is highlighted: traffic
+// sstop can't see (sockets of other users without root, raw sockets,
+// forwarded or bridged packets), or rates off in their units.
+func (h *debugHUD) renderCoverage() string {
+	line := styleDetailLabel.Render(" attributed ")
+	if len(h.coverage) == 0 {
+		return line + styleDetailLabel.Render("waiting for interface counters")
+	}
+	pct := func(proc, iface float64) string {
+		frac, ok := covered(proc, iface)
+		switch {
+		case !ok:
+			return styleDetailLabel.Render("idle")
+		case frac < 0.5 || frac > 1.5:
+			return styleAlertTag.Render(formatUtil(frac))
+		}
+		return styleHeaderValue.Render(formatUtil(frac))
+	}
+	last := h.coverage[len(h.coverage)-1]
+	line += styleHeaderUp.Render("▲ ") + pct(last.procUp, last.ifUp) + "  " +
+		styleHeaderDown.Render("▼ ") + pct(last.procDown, last.ifDown)
+
+	var sum attribution
+	for _, a := range h.coverage {
+		sum.procUp += a.procUp
+		sum.procDown += a.procDown
+		sum.ifUp += a.ifUp
+		sum.ifDown += a.ifDown
+	}
+	if frac, ok := covered(sum.procUp+sum.procDown, sum.ifUp+sum.ifDown); ok {
+		line += styleDetailLabel.Render(fmt.Sprintf("  over %d polls %s", len(h.coverage), formatUtil(frac)))
+	}
+	if _, ok := covered(0, last.ifUp+last.ifDown); ok {
+		line += styleDetailLabel.Render(fmt.Sprintf("  (processes %s of %s on the interfaces)",
+			FormatRate(last.procUp+last.procDown), FormatRate(last.ifUp+last.ifDown)))
+	}
+	return line
+}
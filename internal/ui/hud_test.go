@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestDebugHUD(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 120, 30
+	t0 := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, s := range []struct {
+		at      time.Duration
+		latency time.Duration
+	}{{0, 8 * time.Millisecond}, {time.Second, 12 * time.Millisecond}, {4 * time.Second, 2500 * time.Millisecond}} {
+		next, _ := m.Update(SnapshotMsg(model.Snapshot{Timestamp: t0.Add(s.at), CollectLatency: s.latency}))
+		m = next.(Model)
+		if i == 1 && strings.Contains(ansi.Strip(m.View()), "collect") {
+			t.Error("HUD shown before ` turned it on")
+		}
+	}
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("`")})
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"collect 2.5s", "max 2.5s", "gap 3.0s (max 3.0s)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("HUD missing %q:\n%s", want, view)
+		}
+	}
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("`")})
+	if strings.Contains(ansi.Strip(m.View()), "collect ") {
+		t.Error("` did not hide the HUD")
+	}
+}
+
+func TestDebugHUDNotRecorded(t *testing.T) {
+	var h debugHUD
+	h.observe(model.Snapshot{Timestamp: time.Now()})
+	if line := ansi.Strip(h.render(100, 0)); !strings.Contains(line, "latency not recorded") {
+		t.Errorf("HUD for an old recording = %q", line)
+	}
+}
+
+func TestAttributionCoverage(t *testing.T) {
+	lo := net.ParseIP("127.0.0.1")
+	snap := model.Snapshot{
+		TotalUp: 10000, TotalDown: 40000,
+		Processes: []model.ProcessSummary{
+			{PID: 10, Name: "curl", UpRate: 9000, DownRate: 30000},
+			// The loopback connection's rate is on no interface
+			{PID: 11, Name: "redis", UpRate: 5000, DownRate: 5000, Connections: []model.Connection{
+				{SrcIP: lo, DstIP: lo, UpRate: 5000, DownRate: 5000},
+			}},
+			{PID: model.UnattributedPID, Name: model.UnattributedName, UpRate: 500, DownRate: 500},
+		},
+	}
+	a := attributionOf(&snap)
+	if a.procUp != 9000 || a.procDown != 30000 {
+		t.Fatalf("attributed ▲ %v ▼ %v, want 9000 30000", a.procUp, a.procDown)
+	}
+
+	var h debugHUD
+	h.observe(snap)
+	snap.Processes[0].DownRate = 10000
+	h.observe(snap)
+	line := ansi.Strip(h.renderCoverage())
+	for _, want := range []string{"▲ 90%", "▼ 25%", "over 2 polls 58%", "(processes 18.6 KB/s of 48.8 KB/s"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("coverage line %q lacks %q", line, want)
+		}
+	}
+
+	// A near-idle interface has no meaningful coverage
+	h = debugHUD{}
+	h.observe(model.Snapshot{TotalUp: 100, Processes: []model.ProcessSummary{{PID: 10, UpRate: 300}}})
+	if line := ansi.Strip(h.renderCoverage()); !strings.Contains(line, "▲ idle") || strings.Contains(line, "polls") {
+		t.Errorf("idle coverage line = %q", line)
+	}
+}
+
+func TestFormatLatency(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		850 * time.Microsecond:  "850µs",
+		12 * time.Millisecond:   "12ms",
+		1200 * time.Millisecond: "1.2s",
+	} {
+		if got := formatLatency(d); got != want {
+			t.Errorf("formatLatency(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// idleMode is how the process table shows idle processes: those at zero
+// rate for the last idlePolls polls. z cycles it.
+type idleMode int
+
+const (
+	idleShow idleMode = iota // listed like the others
+	idleDim                  // listed, greyed out
+	idleHide                 // left out
+)
+
+// idleModes are the config names, in cycling order.
+var idleModes = []string{"show", "dim", "hide"}
+
+func parseIdleMode(s string) (idleMode, error) {
+	if s == "" {
+		return idleShow, nil
+	}
+	for i, name := range idleModes {
+		if strings.EqualFold(s, name) {
+			return idleMode(i), nil
+		}
+	}
+	return idleShow, fmt.Errorf("%q is not one of %s", s, strings.Join(idleModes, ", "))
+}
+
+func (i idleMode) String() string {
+	if i < 0 || int(i) >= len(idleModes) {
+		return "show"
+	}
+	return idleModes[i]
+}
+
+// next returns the mode after i: show → dim → hide → show.
+func (i idleMode) next() idleMode {
+	return (i + 1) % idleMode(len(idleModes))
+}
+
+// defaultIdlePolls is how many polls at zero rate make a process idle
+// unless [ui] idle_polls says otherwise.
+const defaultIdlePolls = 5
+
+// idleTracker counts each process's consecutive polls at zero rate.
+type idleTracker struct {
+	after int            // polls at zero before a process is idle
+	polls map[uint32]int // PID → consecutive polls at zero
+}
+
+// observe counts a poll's processes; those gone from it are forgotten.
+func (t *idleTracker) observe(procs []model.ProcessSummary) {
+	polls := make(map[uint32]int, len(procs))
+	for i := range procs {
+		p := &procs[i]
+		if p.UpRate+p.DownRate == 0 {
+			polls[p.PID] = t.polls[p.PID] + 1
+		}
+	}
+	t.polls = polls
+}
+
+// idle reports whether p has been at zero rate for the last polls. Exited
+// processes (cumulative mode) are greyed out already and never idle.
+func (t *idleTracker) idle(p *model.ProcessSummary) bool {
+	after := t.after
+	if after <= 0 {
+		after = defaultIdlePolls
+	}
+	return p.ExitedAt.IsZero() && t.polls[p.PID] >= after
+}
+
+// SetIdle sets how idle processes are shown at startup ([ui] idle: show,
+// dim or hide) and after how many polls at zero rate a process is idle
+// ([ui] idle_polls; 0 keeps the default).
+func (m *Model) SetIdle(mode string, polls int) error {
+	i, err := parseIdleMode(mode)
+	if err != nil {
+		return err
+	}
+	m.table.idleMode = i
+	m.table.idle.after = polls
+	return nil
+}
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestIdleProcesses(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 120, 30
+	if err := m.SetIdle("", 3); err != nil {
+		t.Fatal(err)
+	}
+	poll := func(cronRate float64) {
+		next, _ := m.Update(SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{
+			{PID: 1, Name: "nginx", DownRate: 5000},
+			{PID: 2, Name: "cron", UpRate: cronRate},
+			{PID: 3, Name: "chronyd"},
+		}}))
+		m = next.(Model)
+	}
+	for range 2 {
+		poll(0)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'z'}}) // dim
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'z'}}) // hide
+	if n := len(m.table.filtered); n != 3 {
+		t.Fatalf("after 2 polls at zero %d rows, want 3 (idle takes 3)", n)
+	}
+
+	poll(0)
+	if n := len(m.table.filtered); n != 1 || m.table.filtered[0].Name != "nginx" {
+		t.Fatalf("hide: rows %+v, want only nginx", m.table.filtered)
+	}
+	if out := m.View(); !strings.Contains(out, "idle:hide (2 hidden)") {
+		t.Errorf("footer should count the hidden processes:\n%s", out)
+	}
+
+	// Traffic resets the count
+	poll(100)
+	if n := len(m.table.filtered); n != 2 {
+		t.Errorf("cron sent traffic: %d rows, want 2", n)
+	}
+
+	// Dim keeps the rows; show drops the indicator
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'z'}})
+	if n := len(m.table.filtered); n != 3 || m.table.idleMode != idleShow {
+		t.Errorf("z from hide: mode %v, %d rows", m.table.idleMode, n)
+	}
+	if strings.Contains(m.View(), "idle:") {
+		t.Error("show mode should have no footer indicator")
+	}
+
+	if err := m.SetIdle("grey", 0); err == nil {
+		t.Error("SetIdle should reject an unknown mode")
+	}
+}
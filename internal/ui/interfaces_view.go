@@ -0,0 +1,300 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// ifaceSort defines the interfaces view sort order.
+type ifaceSort int
+
+const (
+	ifaceSortRate ifaceSort = iota // total bandwidth (default)
+	ifaceSortName                  // interface name
+	ifaceSortCount
+)
+
+// interfacesView lists the network interfaces with their rate graphs and
+// counters. Enter limits the process table to one interface's traffic.
+type interfacesView struct {
+	cursor     int
+	offset     int
+	viewHeight int
+	window     listWindow // rows last drawn
+	sortBy     ifaceSort
+}
+
+func (v *interfacesView) moveUp() {
+	if v.cursor > 0 {
+		v.cursor--
+	}
+}
+
+func (v *interfacesView) moveDown(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	if v.cursor < maxIdx {
+		v.cursor++
+	}
+}
+
+func (v *interfacesView) pageUp() {
+	v.cursor -= v.viewHeight / 2
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+}
+
+func (v *interfacesView) pageDown(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	v.cursor += v.viewHeight / 2
+	if v.cursor > maxIdx {
+		v.cursor = maxIdx
+	}
+}
+
+func (v *interfacesView) goHome() {
+	v.cursor = 0
+}
+
+func (v *interfacesView) goEnd(maxIdx int) {
+	if maxIdx < 0 {
+		v.cursor = 0
+		return
+	}
+	v.cursor = maxIdx
+}
+
+func (v *interfacesView) nextSort() {
+	v.sortBy = (v.sortBy + 1) % ifaceSortCount
+	v.cursor, v.offset = 0, 0
+}
+
+// sortInterfaces returns a sorted copy of ifaces.
+func sortInterfaces(ifaces []model.InterfaceStats, sortBy ifaceSort) []model.InterfaceStats {
+	out := append([]model.InterfaceStats(nil), ifaces...)
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := &out[i], &out[j]
+		if sortBy == ifaceSortRate {
+			if ra, rb := a.SendRate+a.RecvRate, b.SendRate+b.RecvRate; ra != rb {
+				return ra > rb
+			}
+		}
+		return a.Name < b.Name
+	})
+	return out
+}
+
+// interfaceRows returns the interfaces view's rows.
+func (m *Model) interfaceRows() []model.InterfaceStats {
+	return sortInterfaces(m.snapshot.Interfaces, m.interfaces.sortBy)
+}
+
+// selectInterface limits the process table to traffic on the interface
+// and shows its rates in the header; selecting it again lifts the limit.
+func (m *Model) selectInterface(name string) {
+	if m.ifaceFilter == name {
+		m.ifaceFilter = ""
+	} else {
+		m.ifaceFilter = name
+		m.activeIface = name
+		for i, n := range m.ifaceNames {
+			if n == name {
+				m.ifaceIdx = i
+			}
+		}
+	}
+	m.mode = ViewProcessTable
+	m.table.update(m.tableRows())
+}
+
+// onInterface keeps the processes with a connection or listener bound to
+// one of the interface's addresses. Wildcard listeners and unconnected
+// sockets could be on any interface and don't count.
+func onInterface(procs []model.ProcessSummary, ifaces []model.InterfaceStats, name string) []model.ProcessSummary {
+	var iface *model.InterfaceStats
+	for i := range ifaces {
+		if ifaces[i].Name == name {
+			iface = &ifaces[i]
+			break
+		}
+	}
+	if iface == nil {
+		return nil
+	}
+	var out []model.ProcessSummary
+	for i := range procs {
+		p := &procs[i]
+		if procOnInterface(p, iface) {
+			out = append(out, *p)
+		}
+	}
+	return out
+}
+
+func procOnInterface(p *model.ProcessSummary, iface *model.InterfaceStats) bool {
+	for j := range p.Connections {
+		if iface.HasAddr(p.Connections[j].SrcIP) {
+			return true
+		}
+	}
+	for j := range p.ListenPorts {
+		if iface.HasAddr(p.ListenPorts[j].IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatLinkSpeed formats a link speed in bits/sec: "1G", "2.5G", "100M".
+func formatLinkSpeed(bps uint64) string {
+	switch {
+	case bps == 0:
+		return "-"
+	case bps >= 1_000_000_000:
+		return strconv.FormatFloat(float64(bps)/1e9, 'f', -1, 64) + "G"
+	default:
+		return strconv.FormatFloat(float64(bps)/1e6, 'f', -1, 64) + "M"
+	}
+}
+
+// formatCountCompact formats a counter in at most 6 characters: "4832",
+// "12.3K", "4.1M".
+func formatCountCompact(n uint64) string {
+	switch {
+	case n < 10_000:
+		return strconv.FormatUint(n, 10)
+	case n < 1_000_000:
+		return fmt.Sprintf("%.1fK", float64(n)/1e3)
+	case n < 1_000_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	default:
+		return fmt.Sprintf("%.1fG", float64(n)/1e9)
+	}
+}
+
+// Column widths
+const (
+	ivNameW  = 14
+	ivSpeedW = 6
+	ivUtilW  = 5
+	ivRateW  = 8
+	ivPktsW  = 7
+	ivErrW   = 6
+)
+
+func (v *interfacesView) render(ifaces []model.InterfaceStats, filtered string, width, height int) string {
+	v.viewHeight = height
+	v.window = listWindow{}
+
+	if len(ifaces) > 0 && v.cursor >= len(ifaces) {
+		v.cursor = len(ifaces) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+
+	title := fmt.Sprintf("  Interfaces (%d)", len(ifaces))
+	if filtered != "" {
+		title += "  — process table limited to " + filtered
+	}
+	titleLine := styleTitle.Render(title)
+
+	// IFACE | SPEED | UTIL | UP/s | graph | DOWN/s | graph | RX PKTS | TX PKTS
+	// | ERRS | DROPS; the two graphs share what's left
+	fixedW := ivNameW + ivSpeedW + ivUtilW + 2*ivRateW + 2*ivPktsW + 2*ivErrW + 13 // indent + 11 gaps
+	graphW := (rowsWidth(width, len(ifaces), height-2) - fixedW) / 2
+	if graphW < 4 {
+		graphW = 4
+	}
+
+	label := func(name string, s ifaceSort) string {
+		if v.sortBy == s {
+			return name + "▾"
+		}
+		return name
+	}
+	headerLine := fmt.Sprintf("  %-*s %*s %*s %*s %-*s %*s %-*s %*s %*s %*s %*s",
+		ivNameW, label("IFACE", ifaceSortName),
+		ivSpeedW, "SPEED",
+		ivUtilW, "UTIL",
+		ivRateW, label("UP/s", ifaceSortRate),
+		graphW, "",
+		ivRateW, label("DOWN/s", ifaceSortRate),
+		graphW, "",
+		ivPktsW, "RX PKT",
+		ivPktsW, "TX PKT",
+		ivErrW, "ERRS",
+		ivErrW, "DROPS",
+	)
+	headerStyled := styleTableHeader.Render(headerLine)
+
+	rowsAvail := height - 2 // title + header
+	if rowsAvail < 1 {
+		rowsAvail = 1
+	}
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+rowsAvail {
+		v.offset = v.cursor - rowsAvail + 1
+	}
+
+	if len(ifaces) == 0 {
+		empty := styleDetailLabel.Render("  No interfaces")
+		return strings.Join([]string{titleLine, headerStyled, empty}, "\n")
+	}
+
+	var rows []string
+	end := v.offset + rowsAvail
+	if end > len(ifaces) {
+		end = len(ifaces)
+	}
+	v.window = listWindow{offset: v.offset, rows: end - v.offset, total: len(ifaces)}
+	for idx := v.offset; idx < end; idx++ {
+		ifc := &ifaces[idx]
+		name := ifc.Name
+		if name == filtered {
+			name = "● " + name
+		}
+		util := "-"
+		if ifc.Speed > 0 {
+			util = fmt.Sprintf("%.0f%%", ifc.Utilization()*100)
+		}
+		line := fmt.Sprintf("  %-*s %*s %*s %*s %-*s %*s %-*s %*s %*s %*s %*s",
+			ivNameW, truncateStr(name, ivNameW),
+			ivSpeedW, formatLinkSpeed(ifc.Speed),
+			ivUtilW, util,
+			ivRateW, FormatRateCompact(ifc.SendRate),
+			graphW, Sparkline(ifc.SendHistory, graphW),
+			ivRateW, FormatRateCompact(ifc.RecvRate),
+			graphW, Sparkline(ifc.RecvHistory, graphW),
+			ivPktsW, formatCountCompact(ifc.PacketsRecv),
+			ivPktsW, formatCountCompact(ifc.PacketsSent),
+			ivErrW, formatCountCompact(ifc.ErrorsRecv+ifc.ErrorsSent),
+			ivErrW, formatCountCompact(ifc.DropsRecv+ifc.DropsSent),
+		)
+
+		var rowStyle lipgloss.Style
+		if idx == v.cursor {
+			rowStyle = styleTableRowSelected
+		} else if idx%2 == 1 {
+			rowStyle = styleZebraRow
+		} else {
+			rowStyle = styleTableRow
+		}
+		rows = append(rows, rowStyle.Render(line))
+	}
+
+	parts := []string{titleLine, headerStyled}
+	parts = append(parts, withScrollbar(rows, v.window, width)...)
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func testInterfacesSnapshot() model.Snapshot {
+	return model.Snapshot{
+		Interfaces: []model.InterfaceStats{
+			{Name: "eth0", SendRate: 12_500_000, RecvRate: 1000, Speed: 1_000_000_000,
+				PacketsRecv: 123_456, PacketsSent: 42, ErrorsRecv: 3, DropsSent: 7,
+				Addrs:       []net.IP{net.ParseIP("10.0.0.2")},
+				SendHistory: []float64{0, 1, 2}, RecvHistory: []float64{2, 1, 0}},
+			{Name: "wg0", RecvRate: 500, Addrs: []net.IP{net.ParseIP("10.8.0.1")}},
+		},
+		Processes: []model.ProcessSummary{
+			{PID: 10, Name: "curl", Connections: []model.Connection{
+				{Proto: model.ProtoTCP, SrcIP: net.ParseIP("10.0.0.2"), SrcPort: 50000, DstIP: net.ParseIP("93.184.216.34"), DstPort: 443},
+			}},
+			{PID: 20, Name: "wireguard", Connections: []model.Connection{
+				{Proto: model.ProtoTCP, SrcIP: net.ParseIP("10.8.0.1"), SrcPort: 40000, DstIP: net.ParseIP("10.8.0.9"), DstPort: 22},
+			}},
+			{PID: 30, Name: "nginx", ListenPorts: []model.ListenPort{{Proto: model.ProtoTCP, IP: net.IPv4zero, Port: 80}}},
+		},
+	}
+}
+
+func TestFormatLinkSpeed(t *testing.T) {
+	for bps, want := range map[uint64]string{0: "-", 100_000_000: "100M", 1_000_000_000: "1G", 2_500_000_000: "2.5G"} {
+		if got := formatLinkSpeed(bps); got != want {
+			t.Errorf("formatLinkSpeed(%d) = %q, want %q", bps, got, want)
+		}
+	}
+}
+
+func TestInterfacesView(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 140, 20
+	next, _ := m.Update(SnapshotMsg(testInterfacesSnapshot()))
+	m = next.(Model)
+	key := func(k string) { m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}) }
+
+	key("I")
+	if m.mode != ViewInterfaces {
+		t.Fatalf("I: mode %v, want the interfaces view", m.mode)
+	}
+	view := m.View()
+	for _, want := range []string{"Interfaces (2)", "eth0", "1G", "10%", "123.5K", "wg0"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view missing %q:\n%s", want, view)
+		}
+	}
+
+	// Enter on wg0 (second by rate) limits the table to its processes
+	key("j")
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ViewProcessTable || m.ifaceFilter != "wg0" || m.activeIface != "wg0" {
+		t.Fatalf("enter: mode %v filter %q iface %q, want the table limited to wg0", m.mode, m.ifaceFilter, m.activeIface)
+	}
+	if rows := m.tableRows(); len(rows) != 1 || rows[0].PID != 20 {
+		t.Errorf("table rows = %+v, want only wireguard", rows)
+	}
+	if !strings.Contains(m.View(), "iface:") {
+		t.Error("footer should show the interface filter")
+	}
+
+	// Selecting it again lifts the limit
+	key("I")
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.ifaceFilter != "" || len(m.tableRows()) != 3 {
+		t.Errorf("second enter: filter %q, %d rows, want no limit", m.ifaceFilter, len(m.tableRows()))
+	}
+}
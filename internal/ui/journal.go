@@ -0,0 +1,153 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// JournalReader returns the last lines of a systemd unit's log, oldest
+// first (journal.Tail).
+type JournalReader func(unit string) ([]string, error)
+
+// journalMsg carries a unit's log, read in the background.
+type journalMsg struct {
+	unit  string
+	lines []string
+	err   error
+}
+
+// journalOverlay shows the log of the Groups view's selected systemd
+// service (J), to line up what the service logged with its traffic.
+type journalOverlay struct {
+	active  bool
+	unit    string
+	loading bool
+	lines   []string
+	err     string
+	back    int // lines scrolled back from the newest
+}
+
+// SetJournalReader enables J on systemd groups in the Groups view.
+func (m *Model) SetJournalReader(r JournalReader) {
+	m.journalReader = r
+}
+
+// open opens the overlay on g and starts reading its log, or says why it
+// can't.
+func (j *journalOverlay) open(g groupEntry, read JournalReader) tea.Cmd {
+	*j = journalOverlay{active: true, unit: g.Name}
+	switch {
+	case g.Type != "systemd":
+		j.err = fmt.Sprintf("%s is a %s group, not a systemd service", g.Name, g.Type)
+		return nil
+	case read == nil:
+		j.err = "the journal is only available when monitoring this machine"
+		return nil
+	}
+	return j.reload(read)
+}
+
+// reload reads the unit's log again.
+func (j *journalOverlay) reload(read JournalReader) tea.Cmd {
+	if read == nil || j.loading {
+		return nil
+	}
+	j.loading = true
+	unit := j.unit
+	return func() tea.Msg {
+		lines, err := read(unit)
+		return journalMsg{unit: unit, lines: lines, err: err}
+	}
+}
+
+// receive shows a read log, scrolled to the newest line.
+func (j *journalOverlay) receive(msg journalMsg) {
+	if !j.active || msg.unit != j.unit {
+		return // closed, or opened on another unit since
+	}
+	j.loading = false
+	j.lines, j.err, j.back = msg.lines, "", 0
+	if msg.err != nil {
+		j.err = msg.err.Error()
+	}
+}
+
+// update handles a key while the overlay is open; rows is how many lines
+// it shows.
+func (j *journalOverlay) update(msg tea.KeyMsg, rows int, read JournalReader) tea.Cmd {
+	maxBack := max(len(j.lines)-rows, 0)
+	switch msg.String() {
+	case "esc", "q", "J":
+		j.active = false
+	case "k", "up":
+		j.back = min(j.back+1, maxBack)
+	case "j", "down":
+		j.back = max(j.back-1, 0)
+	case "pgup", "ctrl+u":
+		j.back = min(j.back+rows/2, maxBack)
+	case "pgdown", "ctrl+d":
+		j.back = max(j.back-rows/2, 0)
+	case "g", "home":
+		j.back = maxBack
+	case "G", "end":
+		j.back = 0
+	case "r":
+		return j.reload(read)
+	}
+	return nil
+}
+
+// journalRows is how many log lines the overlay shows in a terminal of
+// height lines: less the border, padding, title, blank lines and hint.
+func journalRows(height int) int {
+	return max(height-10, 3)
+}
+
+func (j *journalOverlay) render(width, height int) string {
+	inner := max(width-8, 20)
+	rows := journalRows(height)
+
+	title := styleHelpTitle.Render("  Journal: " + j.unit)
+	var body []string
+	switch {
+	case j.err != "":
+		body = append(body, styleKillResultErr.Render("  "+j.err))
+	case j.loading && len(j.lines) == 0:
+		body = append(body, styleDetailLabel.Render("  reading the journal…"))
+	case len(j.lines) == 0:
+		body = append(body, styleDetailLabel.Render("  no entries"))
+	default:
+		end := len(j.lines) - min(j.back, max(len(j.lines)-rows, 0))
+		start := max(end-rows, 0)
+		for _, line := range j.lines[start:end] {
+			body = append(body, styleHelpDesc.Render(Truncate(line, inner)))
+		}
+		if j.back > 0 {
+			title += styleDetailLabel.Render(fmt.Sprintf("  (%d newer below)", j.back))
+		}
+	}
+
+	hint := "  j/k scroll  g/G oldest/newest  r reload  esc close"
+	if j.loading && len(j.lines) > 0 {
+		hint = "  reading the journal…"
+	}
+	content := title + "\n\n" + strings.Join(body, "\n") + "\n\n" + styleDetailLabel.Render(hint)
+	box := styleHelpBorder.Width(inner + 4).Render(content)
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// compactLine renders the overlay as the newest log line, for a short
+// terminal.
+func (j *journalOverlay) compactLine() string {
+	line := styleSortIndicator.Render(j.unit + ": ")
+	switch {
+	case j.err != "":
+		return line + styleKillResultErr.Render(j.err)
+	case len(j.lines) == 0:
+		return line + styleDetailLabel.Render("no entries; esc to close")
+	}
+	return line + styleHelpDesc.Render(j.lines[len(j.lines)-1])
+}
@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func journalModel(read JournalReader) Model {
+	m := New(nil)
+	m.width, m.height = 120, 30
+	m.SetJournalReader(read)
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{
+		{PID: 3, Name: "sshd", ServiceName: "sshd.service", UpRate: 1000, DownRate: 2000},
+		{PID: 4, Name: "firefox", UpRate: 10, DownRate: 20},
+	}}))
+	m = next.(Model)
+	m.mode = ViewGroups
+	return m
+}
+
+func TestJournalOverlay(t *testing.T) {
+	var units []string
+	m := journalModel(func(unit string) ([]string, error) {
+		units = append(units, unit)
+		return []string{"2026-10-17T09:00:00+0000 host sshd[3]: Accepted publickey", "2026-10-17T09:00:05+0000 host sshd[3]: session opened"}, nil
+	})
+
+	next, cmd := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'J'}})
+	m = next.(Model)
+	if !m.journal.active || cmd == nil {
+		t.Fatal("J on a systemd group should open the overlay and read the journal")
+	}
+	next, _ = m.Update(cmd())
+	m = next.(Model)
+	if len(units) != 1 || units[0] != "sshd.service" {
+		t.Fatalf("read units %v, want [sshd.service]", units)
+	}
+	view := m.View()
+	if !strings.Contains(view, "Journal: sshd.service") || !strings.Contains(view, "session opened") {
+		t.Errorf("overlay doesn't show the log:\n%s", view)
+	}
+
+	// r reads again; esc closes
+	next, cmd = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+	m = next.(Model)
+	if cmd == nil {
+		t.Fatal("r should read the journal again")
+	}
+	cmd()
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.journal.active || m.mode != ViewGroups {
+		t.Errorf("esc should close the overlay and stay in Groups (mode %v)", m.mode)
+	}
+}
+
+func TestJournalOverlayUnavailable(t *testing.T) {
+	m := journalModel(func(string) ([]string, error) {
+		return nil, errors.New("journalctl: No journal files were found.")
+	})
+	next, cmd := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'J'}})
+	m = next.(Model)
+	next, _ = m.Update(cmd())
+	m = next.(Model)
+	if !strings.Contains(m.View(), "No journal files were found.") {
+		t.Error("overlay should show the reader's error")
+	}
+
+	// Not a systemd group
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	m = press(m, tea.KeyMsg{Type: tea.KeyDown})
+	next, cmd = m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'J'}})
+	m = next.(Model)
+	if cmd != nil || !strings.Contains(m.journal.err, "not a systemd service") {
+		t.Errorf("J on a user group: err %q", m.journal.err)
+	}
+
+	// No reader (remote, playback)
+	m = journalModel(nil)
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'J'}})
+	if !strings.Contains(m.journal.err, "only available") {
+		t.Errorf("J without a reader: err %q", m.journal.err)
+	}
+}
@@ -12,6 +12,7 @@ const (
 	keyEnter
 	keyEsc
 	keySortNext
+	keySortReverse // flip the process table's sort direction
 	keySearch
 	keyHelp
 	keyPageUp
@@ -19,19 +20,54 @@ const (
 	keyHome
 	keyEnd
 	keyPause
-	keyToggleDNS
+	keyHostStyle // cycle host names: FQDN, short, IP
 	keyNextIface
 	keyRemoteHosts
 	keyListenPorts
 	keyKillProcess
-	keyIntervalUp      // faster refresh
-	keyIntervalDown    // slower refresh
-	keyCumulative      // toggle cumulative mode
-	keyTreeToggle      // toggle process tree view
-	keySetAlert        // set bandwidth alert
-	keySpeedUp         // playback speed up
-	keySpeedDown       // playback speed down
-	keyGroupView       // docker/systemd group view
+	keyIntervalUp   // faster refresh
+	keyIntervalDown // slower refresh
+	keyCumulative   // toggle cumulative mode
+	keyTreeToggle   // toggle process tree view
+	keySetAlert     // set bandwidth alert
+	keySpeedUp      // playback speed up
+	keySpeedDown    // playback speed down
+	keyGroupView    // pod/container/systemd group view
+	keyTopDest      // toggle TOP DEST column
+	keyTCPInfo      // toggle TCP stats columns in detail view
+	keyUsersView    // per-user aggregation view
+	keyPreset       // F1–F12 layout preset
+	keyPrivacy      // toggle privacy mode (mask IPs, hosts, cmdlines)
+	keyUsageView    // persisted day/week/month usage view
+	keyRefresh      // poll now instead of waiting for the next interval
+	keyDismiss      // dismiss the selected exited process (cumulative mode)
+	keyDismissAll   // dismiss all exited processes
+	keyCountries    // bandwidth by country / AS
+	keyToggleASN    // countries view: group by AS instead of country
+	keyNote         // note on the selected PID
+	keyNoteName     // note on every process with the selected name
+	keyNetNS        // cycle the network namespace shown in the table
+	keyMouse        // toggle mouse capture (off = terminal text selection)
+	keyNextHost     // multi-host dashboard: show the next host
+	keyGraphView    // service dependency graph
+	keyCapture      // detail view: start/stop a pcap of the process
+	keyConnections  // every connection of every process
+	keyInterfaces   // per-interface graphs and counters
+	keyRateChart    // detail view: full-screen rate chart of the process
+	keyTreemap      // bandwidth share as a treemap
+	keyFlows        // process → remote host flow diagram
+	keyBlock        // block or rate-limit the selected remote IP
+	keyLimit        // cap the selected process's bandwidth
+	keyHUD          // toggle the debug HUD (collection latency)
+	keyJournal      // systemd group's journal (Groups view)
+	keyFilterSlot   // 1–9: apply a saved filter
+	keyFilters      // saved filter picker
+	keyPrefixGroup  // remote hosts view: group IPv6 hosts by /64
+	keyIdle         // process table: show, dim or hide idle processes
+	keySelect       // process table: select mode, marking rows for batch actions
+	keyExport       // process table: save the marked processes as JSON
+	keyOnlyMarked   // process table: filter to the marked processes
+	keyBaseline     // process table: freeze a baseline to show changes since
 )
 
 func matchKey(msg tea.KeyMsg) keyAction {
@@ -48,6 +84,8 @@ func matchKey(msg tea.KeyMsg) keyAction {
 		return keyEsc
 	case "s":
 		return keySortNext
+	case "R":
+		return keySortReverse
 	case "/":
 		return keySearch
 	case "?":
@@ -63,7 +101,7 @@ func matchKey(msg tea.KeyMsg) keyAction {
 	case " ":
 		return keyPause
 	case "d":
-		return keyToggleDNS
+		return keyHostStyle
 	case "i", "tab":
 		return keyNextIface
 	case "h":
@@ -88,6 +126,74 @@ func matchKey(msg tea.KeyMsg) keyAction {
 		return keySpeedDown
 	case "D":
 		return keyGroupView
+	case "T":
+		return keyTopDest
+	case "r", "ctrl+r":
+		return keyRefresh
+	case "u":
+		return keyUsersView
+	case "P":
+		return keyPrivacy
+	case "U":
+		return keyUsageView
+	case "C":
+		return keyCountries
+	case "a":
+		return keyToggleASN
+	case "n":
+		return keyNote
+	case "N":
+		return keyNoteName
+	case "o":
+		return keyNetNS
+	case "M":
+		return keyMouse
+	case "H":
+		return keyNextHost
+	case "S":
+		return keyGraphView
+	case "w":
+		return keyCapture
+	case "v":
+		return keyConnections
+	case "I":
+		return keyInterfaces
+	case "b":
+		return keyRateChart
+	case "m":
+		return keyTreemap
+	case "f":
+		return keyFlows
+	case "B":
+		return keyBlock
+	case "L":
+		return keyLimit
+	case "`":
+		return keyHUD
+	case "J":
+		return keyJournal
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		return keyFilterSlot
+	case "F":
+		return keyFilters
+	case "p":
+		return keyPrefixGroup
+	case "z":
+		return keyIdle
+	case "V":
+		return keySelect
+	case "e":
+		return keyExport
+	case "O":
+		return keyOnlyMarked
+	case "Z":
+		return keyBaseline
+	case "x":
+		return keyDismiss
+	case "X":
+		return keyDismissAll
+	case "f1", "f2", "f3", "f4", "f5", "f6", "f7", "f8", "f9", "f10", "f11", "f12":
+		return keyPreset
 	}
 	return keyNone
 }
@@ -1,11 +1,16 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"syscall"
 
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/model"
 )
 
 // signalEntry represents a Unix signal option.
@@ -15,17 +20,6 @@ type signalEntry struct {
 	desc string
 }
 
-var signalList = []signalEntry{
-	{syscall.SIGTERM, "SIGTERM", "graceful termination"},
-	{syscall.SIGKILL, "SIGKILL", "force kill"},
-	{syscall.SIGINT, "SIGINT", "interrupt"},
-	{syscall.SIGHUP, "SIGHUP", "hangup"},
-	{syscall.SIGSTOP, "SIGSTOP", "stop process"},
-	{syscall.SIGCONT, "SIGCONT", "continue process"},
-	{syscall.SIGUSR1, "SIGUSR1", "user signal 1"},
-	{syscall.SIGUSR2, "SIGUSR2", "user signal 2"},
-}
-
 // killOverlay manages the kill signal selection state.
 type killOverlay struct {
 	active      bool
@@ -34,20 +28,217 @@ type killOverlay struct {
 	cursor      int
 	result      string // status message after kill attempt
 	showResult  bool
+	conn        *model.Connection // set: close this socket instead of signalling
+	batch       []uint32          // set: signal all of these PIDs (marked rows)
+
+	// Typing a signal on the list's last row (where signals exist)
+	custom bool
+	input  textinput.Model
+
+	// Init, systemd and system services get a second look: why, and the
+	// signal waiting for y. In a batch, which of the processes it is.
+	important    string
+	importantWho string
+	confirming   bool
+	pending      signalEntry
+}
+
+func newKillOverlay() killOverlay {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.Placeholder = "e.g. 9, TERM, SIGUSR1"
+	ti.CharLimit = 16
+	return killOverlay{input: ti}
+}
+
+// openProcess opens the signal list for p, with a confirmation step when
+// p is important.
+func (k *killOverlay) openProcess(p *model.ProcessSummary) {
+	k.open(p.PID, p.Name)
+	k.important = importantReason(p)
+}
+
+// openBatch opens the signal list for several processes at once, with a
+// confirmation step when any of them is important.
+func (k *killOverlay) openBatch(procs []model.ProcessSummary) {
+	if len(procs) == 1 {
+		k.openProcess(&procs[0])
+		return
+	}
+	k.reset(0, fmt.Sprintf("%d processes", len(procs)))
+	k.batch = make([]uint32, len(procs))
+	for i := range procs {
+		p := &procs[i]
+		k.batch[i] = p.PID
+		if reason := importantReason(p); reason != "" && k.important == "" {
+			k.important = reason
+			k.importantWho = fmt.Sprintf("%s (PID %d)", p.Name, p.PID)
+		}
+	}
 }
 
 func (k *killOverlay) open(pid uint32, name string) {
+	k.reset(pid, name)
+	if pid == model.UnattributedPID {
+		// Signalling PID 0 would hit sstop's own process group
+		k.result = "Failed: " + model.UnattributedName + " is not a process"
+		k.showResult = true
+	}
+}
+
+// reset shows the signal list for pid afresh.
+func (k *killOverlay) reset(pid uint32, name string) {
 	k.active = true
 	k.pid = pid
 	k.processName = name
 	k.cursor = 0
 	k.result = ""
 	k.showResult = false
+	k.conn = nil
+	k.batch = nil
+	k.custom, k.confirming, k.important, k.importantWho = false, false, "", ""
+}
+
+// openSocket asks to close one of the process's sockets, leaving the
+// process running.
+func (k *killOverlay) openSocket(pid uint32, name string, c model.Connection) {
+	k.active = true
+	k.pid = pid
+	k.processName = name
+	k.result = ""
+	k.showResult = false
+	k.conn = &c
+	k.batch = nil
+	k.custom, k.confirming, k.important, k.importantWho = false, false, "", ""
+}
+
+// openSocket opens the overlay on one of proc's connections. Masked
+// addresses aren't real ones, so privacy mode refuses.
+func (m *Model) openSocket(proc *model.ProcessSummary, c model.Connection) {
+	m.kill.openSocket(proc.PID, proc.Name, c)
+	if m.privacyOn {
+		m.kill.result = "Failed: addresses are masked; turn privacy mode off (P) to close a connection"
+		m.kill.showResult = true
+	}
+}
+
+// target names what the overlay acts on: "curl (PID 42)", or for a batch
+// "3 processes (PIDs 42, 43, 50)".
+func (k *killOverlay) target() string {
+	if k.batch == nil {
+		return fmt.Sprintf("%s (PID %d)", k.processName, k.pid)
+	}
+	pids := make([]string, len(k.batch))
+	for i, pid := range k.batch {
+		pids[i] = strconv.FormatUint(uint64(pid), 10)
+	}
+	return fmt.Sprintf("%s (PIDs %s)", k.processName, strings.Join(pids, ", "))
+}
+
+// warning says why the signal needs confirming: "part of systemd", or in
+// a batch "systemd (PID 1) is PID 1, init: ...".
+func (k *killOverlay) warning() string {
+	if k.importantWho == "" {
+		return k.important
+	}
+	return k.importantWho + " is " + k.important
 }
 
 func (k *killOverlay) close() {
 	k.active = false
 	k.showResult = false
+	k.custom, k.confirming = false, false
+	k.input.Blur()
+}
+
+// importantReason says why signalling p deserves a confirmation, "" when
+// it doesn't: init, systemd's own daemons and system services' processes
+// (a user's session runs under user@UID.service, which doesn't count).
+func importantReason(p *model.ProcessSummary) string {
+	switch {
+	case p.PID == 1:
+		return "PID 1, init: the whole system depends on it"
+	case strings.HasPrefix(p.Name, "systemd"):
+		return "part of systemd"
+	case p.ServiceName != "" && !strings.HasPrefix(p.ServiceName, "user@"):
+		return "part of " + p.ServiceName + ": systemd may restart it, or stop the service"
+	}
+	return ""
+}
+
+// rows is the number of rows in the signal list: the signals, and the
+// custom entry where signals exist.
+func (k *killOverlay) rows() int {
+	if customSignals {
+		return len(signalList) + 1
+	}
+	return len(signalList)
+}
+
+// update handles a key while the overlay is open and reports whether a
+// signal was sent or the socket closed.
+func (k *killOverlay) update(msg tea.KeyMsg, d SocketDestroyer) (done bool, cmd tea.Cmd) {
+	switch {
+	case k.showResult:
+		// Any key closes the result
+		k.close()
+		return false, nil
+	case k.confirming:
+		// y sends; anything else backs out to the list
+		k.confirming = false
+		if msg.String() == "y" {
+			return k.send(k.pending), nil
+		}
+		return false, nil
+	case k.custom:
+		switch msg.String() {
+		case "enter":
+			sig, err := parseSignal(k.input.Value())
+			if err != nil {
+				k.result = "Failed: " + err.Error()
+				k.showResult = true
+				return false, nil
+			}
+			k.custom = false
+			k.input.Blur()
+			return k.choose(sig), nil
+		case "esc":
+			k.custom = false
+			k.input.Blur()
+			return false, nil
+		}
+		k.input, cmd = k.input.Update(msg)
+		return false, cmd
+	}
+
+	switch matchKey(msg) {
+	case keyUp:
+		k.moveUp()
+	case keyDown:
+		k.moveDown()
+	case keyEsc:
+		k.close()
+	case keyEnter:
+		switch {
+		case k.conn != nil:
+			return k.destroySocket(d), nil
+		case k.cursor == len(signalList):
+			k.custom = true
+			k.input.SetValue("")
+			return false, k.input.Focus()
+		}
+		return k.choose(signalList[k.cursor]), nil
+	}
+	return false, nil
+}
+
+// choose sends sig, or asks to confirm it first for an important process.
+func (k *killOverlay) choose(sig signalEntry) bool {
+	if k.important != "" {
+		k.pending, k.confirming = sig, true
+		return false
+	}
+	return k.send(sig)
 }
 
 func (k *killOverlay) moveUp() {
@@ -57,61 +248,157 @@ func (k *killOverlay) moveUp() {
 }
 
 func (k *killOverlay) moveDown() {
-	if k.cursor < len(signalList)-1 {
+	if k.cursor < k.rows()-1 {
 		k.cursor++
 	}
 }
 
-func (k *killOverlay) sendSignal() {
-	if k.cursor < 0 || k.cursor >= len(signalList) {
-		k.result = "Error: invalid signal selection"
+// send sends sig and reports whether it was delivered.
+func (k *killOverlay) send(sig signalEntry) bool {
+	if k.batch != nil {
+		return k.sendBatch(sig)
+	}
+	if k.pid == model.UnattributedPID {
+		k.result = "Failed: " + model.UnattributedName + " is not a process"
 		k.showResult = true
-		return
+		return false
 	}
-	sig := signalList[k.cursor]
-	err := syscall.Kill(int(k.pid), sig.num)
+	err := sendSignal(k.pid, sig.num)
 	if err != nil {
-		k.result = fmt.Sprintf("Failed: %v", err)
+		k.result = "Failed: " + explainKillError(err, k.pid)
 	} else {
 		k.result = fmt.Sprintf("Sent %s to PID %d", sig.name, k.pid)
 	}
 	k.showResult = true
+	return err == nil
+}
+
+// sendBatch sends sig to every process of the batch and reports whether
+// it reached any. The result counts them, with the first failure.
+func (k *killOverlay) sendBatch(sig signalEntry) bool {
+	sent := 0
+	var failed []string
+	for _, pid := range k.batch {
+		if err := sendSignal(pid, sig.num); err != nil {
+			failed = append(failed, explainKillError(err, pid))
+			continue
+		}
+		sent++
+	}
+	k.result = fmt.Sprintf("Sent %s to %d processes", sig.name, sent)
+	if len(failed) > 0 {
+		k.result = fmt.Sprintf("Failed for %d of %d: %s", len(failed), len(k.batch), failed[0])
+		if len(failed) > 1 {
+			k.result += fmt.Sprintf(" (and %d more)", len(failed)-1)
+		}
+	}
+	k.showResult = true
+	return sent > 0
+}
+
+// compactLine renders the overlay as the one-line prompt of a short
+// terminal.
+func (k *killOverlay) compactLine() string {
+	switch {
+	case k.showResult:
+		return styleKillResult.Render(k.result) + styleDetailLabel.Render("  any key")
+	case k.confirming:
+		return styleKillTitle.Render(fmt.Sprintf("Send %s to %s, %s? ", k.pending.name, k.target(), k.warning())) +
+			styleDetailLabel.Render("y/n")
+	case k.conn != nil:
+		return styleKillTitle.Render("Close "+socketLabel(k.conn)+"? ") + styleDetailLabel.Render("enter esc")
+	case k.custom:
+		return styleKillTitle.Render(fmt.Sprintf("Kill %s, signal: ", k.target())) + k.input.View()
+	}
+	sel := styleKillSignalSelected.Render("other: number or name")
+	if k.cursor < len(signalList) {
+		sig := signalList[k.cursor]
+		sel = styleKillSignalSelected.Render(fmt.Sprintf("%s (%d)", sig.name, sig.num))
+	}
+	return styleKillTitle.Render(fmt.Sprintf("Kill %s: ", k.target())) + sel +
+		styleDetailLabel.Render("  j/k enter esc")
+}
+
+// explainKillError says what a failed signal means for the user.
+func explainKillError(err error, pid uint32) string {
+	switch {
+	case errors.Is(err, syscall.ESRCH):
+		return fmt.Sprintf("no process %d (ESRCH): it has already exited", pid)
+	case errors.Is(err, syscall.EPERM):
+		return fmt.Sprintf("not permitted (EPERM): process %d belongs to another user; run sstop as root", pid)
+	}
+	return err.Error()
+}
+
+// destroySocket closes the socket with d, nil when the data source can't,
+// and reports whether it was closed.
+func (k *killOverlay) destroySocket(d SocketDestroyer) bool {
+	var err error
+	if d == nil {
+		err = fmt.Errorf("closing sockets needs a live local capture")
+	} else {
+		err = d.DestroySocket(k.pid, *k.conn)
+	}
+	if err != nil {
+		k.result = fmt.Sprintf("Failed: %v", err)
+	} else {
+		k.result = fmt.Sprintf("Closed %s", socketLabel(k.conn))
+	}
+	k.showResult = true
+	return err == nil
+}
+
+// socketLabel names a connection in the overlay: protocol and addresses.
+func socketLabel(c *model.Connection) string {
+	return fmt.Sprintf("%s %s → %s", c.Proto, formatConnAddr(c.SrcIP, c.SrcPort), formatConnAddr(c.DstIP, c.DstPort))
 }
 
 var (
+	styleKillBorder         lipgloss.Style
+	styleKillTitle          lipgloss.Style
+	styleKillSignal         lipgloss.Style
+	styleKillSignalSelected lipgloss.Style
+	styleKillNum            lipgloss.Style
+	styleKillDesc           lipgloss.Style
+	styleKillResult         lipgloss.Style
+	styleKillResultErr      lipgloss.Style
+)
+
+// buildKillStyles is called from buildStyles.
+func buildKillStyles() {
 	styleKillBorder = lipgloss.NewStyle().
-			BorderStyle(lipgloss.RoundedBorder()).
-			BorderForeground(colorRed).
-			Background(colorBg).
-			Padding(1, 2)
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(colorRed).
+		Background(colorBg).
+		Padding(1, 2)
 
 	styleKillTitle = lipgloss.NewStyle().
-			Foreground(colorRed).
-			Bold(true)
+		Foreground(colorRed).
+		Bold(true)
 
 	styleKillSignal = lipgloss.NewStyle().
-			Foreground(colorFg)
+		Foreground(colorFg)
 
 	styleKillSignalSelected = lipgloss.NewStyle().
-				Background(colorSelection).
-				Foreground(colorFg).
-				Bold(true)
+		Background(colorSelection).
+		Foreground(colorFg).
+		Bold(true)
 
 	styleKillNum = lipgloss.NewStyle().
-			Foreground(colorYellow).
-			Bold(true)
+		Foreground(colorYellow).
+		Bold(true)
 
 	styleKillDesc = lipgloss.NewStyle().
-			Foreground(colorFgDim)
+		Foreground(colorFgDim)
 
 	styleKillResult = lipgloss.NewStyle().
-			Foreground(colorGreen).
-			Bold(true)
+		Foreground(colorGreen).
+		Bold(true)
 
 	styleKillResultErr = lipgloss.NewStyle().
-				Foreground(colorRed).
-				Bold(true)
-)
+		Foreground(colorRed).
+		Bold(true)
+}
 
 func (k *killOverlay) render(width, height int) string {
 	if k.showResult {
@@ -125,7 +412,27 @@ func (k *killOverlay) render(width, height int) string {
 		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
 	}
 
-	title := styleKillTitle.Render(fmt.Sprintf("  Kill: %s (PID %d)", k.processName, k.pid))
+	if k.confirming {
+		title := styleKillTitle.Render(fmt.Sprintf("  Send %s to %s?", k.pending.name, k.target()))
+		body := styleKillSignal.Render("  This is " + k.important + ".")
+		if k.importantWho != "" {
+			body = styleKillSignal.Render("  " + k.warning() + ".")
+		}
+		hint := styleDetailLabel.Render("  y send  any other key back")
+		box := styleKillBorder.Render(title + "\n\n" + body + "\n\n" + hint)
+		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+	}
+
+	if k.conn != nil {
+		title := styleKillTitle.Render(fmt.Sprintf("  Close socket of %s (PID %d)", k.processName, k.pid))
+		body := styleKillSignal.Render("  "+socketLabel(k.conn)) + "\n" +
+			styleKillDesc.Render("  The process sees the connection aborted and keeps running.")
+		hint := styleDetailLabel.Render("  enter close  esc cancel")
+		box := styleKillBorder.Render(title + "\n\n" + body + "\n\n" + hint)
+		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+	}
+
+	title := styleKillTitle.Render(fmt.Sprintf("  Kill: %s", k.target()))
 
 	var lines []string
 	for i, sig := range signalList {
@@ -150,9 +457,23 @@ func (k *killOverlay) render(width, height int) string {
 			lines = append(lines, line)
 		}
 	}
+	if customSignals {
+		other := "other: number or name"
+		switch {
+		case k.custom:
+			lines = append(lines, styleKillSignalSelected.Render(" ▸ ")+k.input.View())
+		case k.cursor == len(signalList):
+			lines = append(lines, styleKillSignalSelected.Render(fmt.Sprintf(" ▸ %s ", other)))
+		default:
+			lines = append(lines, "   "+styleKillDesc.Render(other))
+		}
+	}
 
 	signalRows := strings.Join(lines, "\n")
 	hint := styleDetailLabel.Render("  j/k navigate  enter send  esc cancel")
+	if k.important != "" {
+		hint = styleKillDesc.Render("  This is "+k.important+": signals ask to confirm") + "\n" + hint
+	}
 
 	content := title + "\n\n" + signalRows + "\n\n" + hint
 
@@ -0,0 +1,69 @@
+//go:build !windows
+
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+var signalList = []signalEntry{
+	{syscall.SIGTERM, "SIGTERM", "graceful termination"},
+	{syscall.SIGKILL, "SIGKILL", "force kill"},
+	{syscall.SIGINT, "SIGINT", "interrupt"},
+	{syscall.SIGHUP, "SIGHUP", "hangup"},
+	{syscall.SIGSTOP, "SIGSTOP", "stop process"},
+	{syscall.SIGCONT, "SIGCONT", "continue process"},
+	{syscall.SIGUSR1, "SIGUSR1", "user signal 1"},
+	{syscall.SIGUSR2, "SIGUSR2", "user signal 2"},
+}
+
+// customSignals offers a row for typing any signal by number or name.
+const customSignals = true
+
+// signalNames are the signals parseSignal knows by name, beyond the list.
+var signalNames = map[string]syscall.Signal{
+	"HUP": syscall.SIGHUP, "INT": syscall.SIGINT, "QUIT": syscall.SIGQUIT,
+	"ILL": syscall.SIGILL, "TRAP": syscall.SIGTRAP, "ABRT": syscall.SIGABRT,
+	"BUS": syscall.SIGBUS, "FPE": syscall.SIGFPE, "KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1, "SEGV": syscall.SIGSEGV, "USR2": syscall.SIGUSR2,
+	"PIPE": syscall.SIGPIPE, "ALRM": syscall.SIGALRM, "TERM": syscall.SIGTERM,
+	"CHLD": syscall.SIGCHLD, "CONT": syscall.SIGCONT, "STOP": syscall.SIGSTOP,
+	"TSTP": syscall.SIGTSTP, "TTIN": syscall.SIGTTIN, "TTOU": syscall.SIGTTOU,
+	"URG": syscall.SIGURG, "XCPU": syscall.SIGXCPU, "XFSZ": syscall.SIGXFSZ,
+	"VTALRM": syscall.SIGVTALRM, "PROF": syscall.SIGPROF, "WINCH": syscall.SIGWINCH,
+	"IO": syscall.SIGIO, "SYS": syscall.SIGSYS,
+}
+
+// parseSignal reads a signal typed as a number (1-64) or a name, with or
+// without SIG, in any case.
+func parseSignal(s string) (signalEntry, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return signalEntry{}, fmt.Errorf("no signal given")
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 || n > 64 {
+			return signalEntry{}, fmt.Errorf("signal %d out of range 1-64", n)
+		}
+		sig := syscall.Signal(n)
+		for name, known := range signalNames {
+			if known == sig {
+				return signalEntry{sig, "SIG" + name, sig.String()}, nil
+			}
+		}
+		return signalEntry{sig, fmt.Sprintf("signal %d", n), sig.String()}, nil
+	}
+	name := strings.TrimPrefix(s, "SIG")
+	sig, ok := signalNames[name]
+	if !ok {
+		return signalEntry{}, fmt.Errorf("unknown signal %q", s)
+	}
+	return signalEntry{sig, "SIG" + name, sig.String()}, nil
+}
+
+func sendSignal(pid uint32, sig syscall.Signal) error {
+	return syscall.Kill(int(pid), sig)
+}
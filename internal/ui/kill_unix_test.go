@@ -0,0 +1,75 @@
+//go:build !windows
+
+package ui
+
+import (
+	"strings"
+	"syscall"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestParseSignal(t *testing.T) {
+	for in, want := range map[string]syscall.Signal{
+		"9": syscall.SIGKILL, "TERM": syscall.SIGTERM, "sigusr1": syscall.SIGUSR1, " SIGWINCH ": syscall.SIGWINCH,
+	} {
+		sig, err := parseSignal(in)
+		if err != nil || sig.num != want {
+			t.Errorf("parseSignal(%q) = %v, %v, want %v", in, sig.num, err, want)
+		}
+	}
+	if sig, _ := parseSignal("15"); sig.name != "SIGTERM" {
+		t.Errorf("15 named %q, want SIGTERM", sig.name)
+	}
+	for _, in := range []string{"", "0", "65", "NOPE"} {
+		if _, err := parseSignal(in); err == nil {
+			t.Errorf("parseSignal(%q) should fail", in)
+		}
+	}
+}
+
+func TestKillCustomSignal(t *testing.T) {
+	k := newKillOverlay()
+	// Past any PID the kernel hands out: the signal fails with ESRCH
+	k.open(1<<30, "gone")
+	for range signalList {
+		k.update(tea.KeyMsg{Type: tea.KeyDown}, nil)
+	}
+	k.update(tea.KeyMsg{Type: tea.KeyEnter}, nil)
+	if !k.custom {
+		t.Fatalf("enter on the last row should open signal entry, cursor %d", k.cursor)
+	}
+	k.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("usr2")}, nil)
+	if done, _ := k.update(tea.KeyMsg{Type: tea.KeyEnter}, nil); done {
+		t.Error("signal to a missing process reported as sent")
+	}
+	if !k.showResult || !strings.Contains(k.result, "ESRCH") {
+		t.Errorf("result = %q, want an ESRCH explanation", k.result)
+	}
+
+	// A bad name is reported, not sent
+	k.open(1<<30, "gone")
+	k.cursor = len(signalList)
+	k.update(tea.KeyMsg{Type: tea.KeyEnter}, nil)
+	k.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("BOGUS")}, nil)
+	k.update(tea.KeyMsg{Type: tea.KeyEnter}, nil)
+	if !strings.Contains(k.result, "unknown signal") {
+		t.Errorf("result = %q, want unknown signal", k.result)
+	}
+}
+
+func TestKillBatchResult(t *testing.T) {
+	k := newKillOverlay()
+	k.openBatch([]model.ProcessSummary{{PID: 1 << 30, Name: "gone"}, {PID: 1<<30 + 1, Name: "gone too"}})
+	if k.showResult {
+		t.Fatalf("a batch opened on its result: %q", k.result)
+	}
+	if done, _ := k.update(tea.KeyMsg{Type: tea.KeyEnter}, nil); done {
+		t.Error("signals to missing processes reported as sent")
+	}
+	if !strings.HasPrefix(k.result, "Failed for 2 of 2: ") || !strings.HasSuffix(k.result, "(and 1 more)") {
+		t.Errorf("result = %q, want both failures counted", k.result)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package ui
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Windows has no POSIX signals; the only portable action is TerminateProcess.
+var signalList = []signalEntry{
+	{syscall.SIGKILL, "TERMINATE", "TerminateProcess"},
+}
+
+// customSignals: there are no other signals to type.
+const customSignals = false
+
+func parseSignal(string) (signalEntry, error) {
+	return signalEntry{}, errors.New("no signals on Windows")
+}
+
+func sendSignal(pid uint32, _ syscall.Signal) error {
+	p, err := os.FindProcess(int(pid))
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
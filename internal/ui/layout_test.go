@@ -2,7 +2,14 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/hostfmt"
+	"github.com/googlesky/sstop/internal/model"
 )
 
 // TestProcessTableLayout verifies that the process table column widths sum to
@@ -31,6 +38,29 @@ func TestProcessTableLayout(t *testing.T) {
 	}
 }
 
+// TestProcessTableLayoutTopDest verifies that enabling the optional TOP DEST
+// column still fills the terminal width exactly.
+func TestProcessTableLayoutTopDest(t *testing.T) {
+	fixedW := colPidW + colGraphW + colUpW + colDownW + colConnsW + colListenW + 6 + 2 + colDestW + 1
+
+	for _, width := range []int{110, 120, 160, 200} {
+		nameW := width - fixedW
+		if nameW < 10 {
+			nameW = 10
+		}
+
+		rowW := 2 + colPidW + 1 + nameW + 1 + colGraphW + 1 +
+			5 + 1 + 6 + 1 + // up section
+			5 + 1 + 6 + 1 + // down section
+			colConnsW + 1 + colListenW +
+			1 + colDestW // top dest section
+
+		if nameW >= 10 && rowW != width {
+			t.Errorf("ProcessTable+TopDest width=%d: rowW=%d (diff=%d)", width, rowW, rowW-width)
+		}
+	}
+}
+
 // TestRemoteHostsLayout verifies that the remote hosts table column widths
 // sum to the terminal width exactly.
 func TestRemoteHostsLayout(t *testing.T) {
@@ -91,11 +121,11 @@ func TestListenPortsLayout(t *testing.T) {
 // sum to the terminal width exactly.
 func TestProcessDetailLayout(t *testing.T) {
 	for _, width := range []int{80, 100, 120, 160, 200} {
-		lay := computeConnLayout(width)
+		lay := computeConnLayout(width, false)
 
 		// Data row: indicator(2) + proto(5)+space + local(localW)+space
 		//   + remote(remoteW)+space + state(10)+space + svc(6)+space
-		//   + age(7)+space + up(10)+space + down(10)
+		//   + age(7)+space + total(6)+space + up(10)+space + down(10)
 		rowW := 2 +
 			(lay.protoW + 1) +
 			(lay.localW + 1) +
@@ -103,11 +133,12 @@ func TestProcessDetailLayout(t *testing.T) {
 			(lay.stateW + 1) +
 			(lay.svcW + 1) +
 			(lay.ageW + 1) +
+			(lay.totalW + 1) +
 			(lay.upW + 1) +
 			lay.downW
 
 		// Only check when remaining >= 30 (normal case)
-		remaining := width - (lay.protoW + lay.stateW + lay.svcW + lay.ageW + lay.upW + lay.downW + 7 + 2)
+		remaining := width - (lay.protoW + lay.stateW + lay.svcW + lay.ageW + lay.totalW + lay.upW + lay.downW + 8 + 2)
 		if remaining >= 30 && rowW != width {
 			t.Errorf("ProcessDetail width=%d: rowW=%d localW=%d remoteW=%d (diff=%d)",
 				width, rowW, lay.localW, lay.remoteW, rowW-width)
@@ -124,6 +155,47 @@ func TestProcessDetailLayout(t *testing.T) {
 	}
 }
 
+// TestProcessDetailLayoutTCPInfo verifies the TCP stats column group keeps
+// rows at the terminal width.
+func TestProcessDetailLayoutTCPInfo(t *testing.T) {
+	for _, width := range []int{110, 120, 160, 200} {
+		lay := computeConnLayout(width, true)
+		rowW := 2 +
+			(lay.protoW + 1) +
+			(lay.localW + 1) +
+			(lay.remoteW + 1) +
+			(lay.stateW + 1) +
+			lay.midW() +
+			(lay.upW + 1) +
+			lay.downW
+		if rowW != width {
+			t.Errorf("ProcessDetail TCP width=%d: rowW=%d (diff=%d)", width, rowW, rowW-width)
+		}
+
+		c := &model.Connection{TCP: &model.TCPInfo{RTT: 38 * time.Millisecond, Retrans: 3, Cwnd: 10, DeliveryRate: 1 << 20}}
+		cells := renderConnMid(c, lay, styleHeaderValue)
+		if got := lipgloss.Width(lipgloss.JoinHorizontal(lipgloss.Top, cells...)); got != lay.midW() {
+			t.Errorf("TCP cells width=%d: got %d, want %d", width, got, lay.midW())
+		}
+	}
+}
+
+// TestOmittedConnsRowLayout verifies the "and N more" aggregate row lines up
+// with the connection rows (same total width).
+func TestOmittedConnsRowLayout(t *testing.T) {
+	proc := &model.ProcessSummary{
+		OmittedConns:    4832,
+		OmittedUpRate:   1536,
+		OmittedDownRate: 2 * 1024 * 1024,
+	}
+	for _, width := range []int{100, 120, 160, 200} {
+		lay := computeConnLayout(width, false)
+		if got := lipgloss.Width(renderOmittedConns(proc, lay)); got != width {
+			t.Errorf("omitted row width=%d: got %d", width, got)
+		}
+	}
+}
+
 // TestFormatRateCompactAlwaysSixChars ensures the fixed-width invariant holds
 // across a wide range of input values including edge cases.
 func TestFormatRateCompactAlwaysSixChars(t *testing.T) {
@@ -210,10 +282,10 @@ func TestLayoutConsistencyAcrossWidths(t *testing.T) {
 			}
 
 			// Process detail
-			lay := computeConnLayout(width)
-			remaining := width - (lay.protoW + lay.stateW + lay.svcW + lay.ageW + lay.upW + lay.downW + 7 + 2)
+			lay := computeConnLayout(width, false)
+			remaining := width - (lay.protoW + lay.stateW + lay.svcW + lay.ageW + lay.totalW + lay.upW + lay.downW + 8 + 2)
 			if remaining >= 30 {
-				rowW := 2 + (lay.protoW + 1) + (lay.localW + 1) + (lay.remoteW + 1) + (lay.stateW + 1) + (lay.svcW + 1) + (lay.ageW + 1) + (lay.upW + 1) + lay.downW
+				rowW := 2 + (lay.protoW + 1) + (lay.localW + 1) + (lay.remoteW + 1) + (lay.stateW + 1) + (lay.svcW + 1) + (lay.ageW + 1) + (lay.totalW + 1) + (lay.upW + 1) + lay.downW
 				if rowW != width {
 					t.Errorf("ProcessDetail: rowW=%d != width=%d", rowW, width)
 				}
@@ -221,3 +293,315 @@ func TestLayoutConsistencyAcrossWidths(t *testing.T) {
 		})
 	}
 }
+
+// TestProcessDetailServiceColumn renders the connection table and checks the
+// SVC values sit under the SVC header and rows keep the terminal width, also
+// with wide-glyph STATE badges (⚡ESTAB).
+func TestProcessDetailServiceColumn(t *testing.T) {
+	proc := &model.ProcessSummary{
+		PID:       42,
+		Name:      "curl",
+		ConnCount: 2,
+		Connections: []model.Connection{
+			{Proto: model.ProtoTCP, SrcPort: 50000, DstPort: 443, RemoteHost: "example.com", State: model.StateEstablished, Service: "HTTPS", Age: time.Minute},
+			{Proto: model.ProtoTCP, SrcPort: 50001, DstPort: 5432, RemoteHost: "db.internal", State: model.StateEstablished, Service: "PGSQL", Age: time.Second},
+		},
+	}
+	for _, width := range []int{100, 120, 160, 200} {
+		d := newProcessDetail(proc.PID)
+		out := d.render(proc, hostfmt.FQDN, width, 30)
+		lines := strings.Split(out, "\n")
+
+		svcCol := -1
+		for _, line := range lines {
+			if i := strings.Index(line, "SVC"); i >= 0 && strings.Contains(line, "REMOTE") {
+				svcCol = lipgloss.Width(line[:i])
+			}
+		}
+		if svcCol < 0 {
+			t.Fatalf("width=%d: no SVC header in\n%s", width, out)
+		}
+		for _, svc := range []string{"HTTPS", "PGSQL"} {
+			found := false
+			for _, line := range lines {
+				i := strings.Index(line, " "+svc+" ")
+				if i < 0 {
+					continue
+				}
+				found = true
+				if col := lipgloss.Width(line[:i+1]); col != svcCol {
+					t.Errorf("width=%d: %s at column %d, SVC header at %d", width, svc, col, svcCol)
+				}
+				if w := lipgloss.Width(line); w != width {
+					t.Errorf("width=%d: %s row width %d", width, svc, w)
+				}
+			}
+			if !found {
+				t.Errorf("width=%d: service %s not rendered", width, svc)
+			}
+		}
+	}
+}
+
+// TestNarrowLayoutBreakpoints checks the column drop order below the full
+// process table and remote hosts widths.
+func TestNarrowLayoutBreakpoints(t *testing.T) {
+	tests := []struct {
+		width                int
+		graph, counts, bars  bool
+		hostProcs, hostConns bool
+		hostBars             bool
+	}{
+		{78, true, true, true, true, true, true},
+		{77, false, true, true, true, true, true},
+		{71, false, true, true, true, true, true},
+		{70, false, true, true, false, true, true},
+		{61, false, true, true, false, true, true},
+		{60, false, false, true, false, true, true},
+		{50, false, false, true, false, true, true},
+		{49, false, false, true, false, false, true},
+		{47, false, false, true, false, false, true},
+		{46, false, false, false, false, false, true},
+		{43, false, false, false, false, false, true},
+		{42, false, false, false, false, false, false},
+		{40, false, false, false, false, false, false},
+	}
+	for _, tt := range tests {
+		l := computeTableLayout(tt.width, defaultColumns)
+		graph, counts := l.has(columnGraph), l.has(columnConns) && l.has(columnListen)
+		if graph != tt.graph || counts != tt.counts || l.bars != tt.bars {
+			t.Errorf("table width=%d: graph/counts/bars = %v/%v/%v, want %v/%v/%v",
+				tt.width, graph, counts, l.bars, tt.graph, tt.counts, tt.bars)
+		}
+		if l.nameW+l.fixedW() != tt.width {
+			t.Errorf("table width=%d: columns sum to %d", tt.width, l.nameW+l.fixedW())
+		}
+		h := computeHostsLayout(tt.width, false)
+		if h.procs != tt.hostProcs || h.conns != tt.hostConns || h.bars != tt.hostBars {
+			t.Errorf("hosts width=%d: procs/conns/bars = %v/%v/%v, want %v/%v/%v",
+				tt.width, h.procs, h.conns, h.bars, tt.hostProcs, tt.hostConns, tt.hostBars)
+		}
+		if h.hostW+h.fixedW() != tt.width {
+			t.Errorf("hosts width=%d: columns sum to %d", tt.width, h.hostW+h.fixedW())
+		}
+	}
+
+	// TOP DEST goes before GRAPH
+	withDest := append(defaultColumns[:len(defaultColumns):len(defaultColumns)], columnDest)
+	if l := computeTableLayout(100, withDest); l.has(columnDest) || !l.has(columnGraph) {
+		t.Errorf("width=100 with TOP DEST: topDest=%v graph=%v, want false true", l.has(columnDest), l.has(columnGraph))
+	}
+}
+
+// TestNarrowWidthsRender renders the main views at 40–60 columns and checks
+// no line is wider than the terminal (a wider line wraps and breaks the
+// screen).
+func TestNarrowWidthsRender(t *testing.T) {
+	procs := []model.ProcessSummary{
+		{PID: 1234567, Name: "firefox-with-a-long-name", UpRate: 1 << 20, DownRate: 30 << 20,
+			ConnCount: 120, ListenCount: 2, RateHistory: []float64{1, 2, 3}},
+		{PID: 2, Name: "sshd", UpRate: 100, DownRate: 200, ConnCount: 1},
+	}
+	hosts := []model.RemoteHostSummary{
+		{Host: "a-very-long-hostname.example.com", Country: "🇺🇸 US", CountryCode: "US",
+			UpRate: 5 << 20, DownRate: 1 << 20, ConnCount: 12, Processes: []string{"firefox", "curl"}},
+		{Host: "10.0.0.1", Country: "🏠 LAN", CountryCode: "LAN", ConnCount: 1},
+	}
+	snap := model.Snapshot{
+		Timestamp:       time.Now(),
+		Processes:       procs,
+		RemoteHosts:     hosts,
+		TotalUp:         123 << 20,
+		TotalDown:       456 << 20,
+		Interfaces:      []model.InterfaceStats{{Name: "wlp0s20f3", SendRate: 1 << 20, RecvRate: 2 << 20}},
+		UpRateHistory:   []float64{1, 2},
+		DownRateHistory: []float64{2, 1},
+	}
+
+	for width := 40; width <= 60; width++ {
+		m := New(nil)
+		m.width, m.height = width, 24
+		next, _ := m.Update(SnapshotMsg(snap))
+		m = next.(Model)
+
+		for _, mode := range []ViewMode{ViewProcessTable, ViewRemoteHosts} {
+			for _, cumulative := range []bool{false, true} {
+				m.mode = mode
+				m.cumulativeMode, m.table.cumulativeMode = cumulative, cumulative
+				for i, line := range strings.Split(m.View(), "\n") {
+					if w := lipgloss.Width(line); w > width {
+						t.Errorf("width=%d view=%d cum=%v line %d is %d cells: %q",
+							width, mode, cumulative, i, w, line)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestCompactLayout verifies that short terminals get exactly their height
+// in lines, none wider than the terminal, with overlays as one-line prompts.
+func TestCompactLayout(t *testing.T) {
+	m := New(nil)
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{
+		TotalUp: 1024,
+		Processes: []model.ProcessSummary{
+			{PID: 10, Name: "curl", UpRate: 1024},
+			{PID: 11, Name: "sshd"},
+		},
+	}))
+	m = next.(Model)
+
+	for h := 1; h < compactHeight; h++ {
+		m.width, m.height = 60, h
+		view := m.View()
+		if got := strings.Count(view, "\n") + 1; got != h {
+			t.Errorf("height %d: view has %d lines", h, got)
+		}
+		for i, line := range strings.Split(view, "\n") {
+			if w := lipgloss.Width(line); w > 60 {
+				t.Errorf("height %d line %d is %d wide", h, i, w)
+			}
+		}
+		if !strings.Contains(view, "▲ 1.0 KB/s") {
+			t.Errorf("height %d: compact header missing totals:\n%s", h, view)
+		}
+	}
+
+	m.height = 6
+	m.kill.open(10, "curl")
+	lines := strings.Split(m.View(), "\n")
+	if last := lines[len(lines)-1]; !strings.Contains(last, "Kill curl (PID 10)") || !strings.Contains(last, "SIGTERM") {
+		t.Errorf("kill prompt = %q", last)
+	}
+}
+
+// TestResizeDebounce verifies that a resize is laid out only once it
+// settles, with the old layout clipped to the new size meanwhile.
+func TestResizeDebounce(t *testing.T) {
+	m := New(nil)
+	next, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	m = next.(Model)
+	if m.width != 120 || m.height != 30 {
+		t.Fatalf("first size not applied: %dx%d", m.width, m.height)
+	}
+
+	next, cmd := m.Update(tea.WindowSizeMsg{Width: 70, Height: 20})
+	m = next.(Model)
+	if m.width != 120 || cmd == nil {
+		t.Fatalf("resize applied at once (width %d)", m.width)
+	}
+	view := m.View()
+	if got := strings.Count(view, "\n") + 1; got > 20 {
+		t.Errorf("mid-resize view has %d lines, want <= 20", got)
+	}
+	for i, line := range strings.Split(view, "\n") {
+		if w := lipgloss.Width(line); w > 70 {
+			t.Fatalf("mid-resize line %d is %d wide", i, w)
+		}
+	}
+
+	// A superseded settle is ignored; the latest one lays out
+	next, _ = m.Update(tea.WindowSizeMsg{Width: 80, Height: 24})
+	m = next.(Model)
+	next, _ = m.Update(resizeSettledMsg(m.resizeSeq - 1))
+	m = next.(Model)
+	if m.width != 120 {
+		t.Errorf("stale settle applied: width %d", m.width)
+	}
+	next, _ = m.Update(resizeSettledMsg(m.resizeSeq))
+	m = next.(Model)
+	if m.width != 80 || m.height != 24 {
+		t.Errorf("settled size = %dx%d, want 80x24", m.width, m.height)
+	}
+}
+
+// TestConfiguredColumns renders the table with a configured column order
+// and checks every line fills the width exactly, headers follow the order,
+// and the extras give way first on narrow terminals.
+func TestConfiguredColumns(t *testing.T) {
+	m := New(nil)
+	if err := m.SetColumns([]string{"pid", "name", "user", "up", "down", "country", "conns", "age", "cum_up", "container"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetColumns([]string{"pid", "up"}); err == nil {
+		t.Error("columns without name should be rejected")
+	}
+	procs := []model.ProcessSummary{
+		{PID: 4242, Name: "firefox", User: "alice", UpRate: 1 << 20, DownRate: 4 << 20, ConnCount: 12,
+			TopDestCountry: "DE", CumUp: 5 << 20, ContainerID: "3f2a9c1b", FirstSeen: time.Now().Add(-90 * time.Second)},
+		{PID: 7, Name: "sshd", UpRate: 100, ConnCount: 1},
+	}
+	m.table.update(procs)
+
+	for _, width := range []int{60, 80, 100, 120, 160} {
+		lay := computeTableLayout(width, m.table.visibleColumns())
+		if lay.nameW+lay.fixedW() != width {
+			t.Errorf("width=%d: columns sum to %d", width, lay.nameW+lay.fixedW())
+		}
+		out := m.table.render(width, 10, false)
+		for i, line := range strings.Split(out, "\n") {
+			if w := lipgloss.Width(line); w != width && i < 2 {
+				t.Errorf("width=%d line %d is %d cells: %q", width, i, w, line)
+			}
+		}
+	}
+
+	out := m.table.render(160, 10, false)
+	header := strings.Split(out, "\n")[0]
+	last := -1
+	for _, h := range []string{"PID", "PROCESS", "USER", "UPLOAD/s", "DOWNLOAD/s", "COUNTRY", "CONNS", "AGE", "CUM UP", "CONTAINER"} {
+		i := strings.Index(header, h)
+		if i <= last {
+			t.Errorf("header %q out of order: %q", h, header)
+		}
+		last = i
+	}
+	row := strings.Split(out, "\n")[1]
+	for _, want := range []string{"alice", "DE", "1m30s", "5.0M", "3f2a9c1b"} {
+		if !strings.Contains(row, want) {
+			t.Errorf("row missing %q: %q", want, row)
+		}
+	}
+
+	// The last extra listed drops first
+	if lay := computeTableLayout(100, m.table.visibleColumns()); lay.has(columnContainer) || !lay.has(columnUser) {
+		t.Errorf("width=100: container=%v user=%v, want false true", lay.has(columnContainer), lay.has(columnUser))
+	}
+}
+
+func TestTypeColumnAndFilter(t *testing.T) {
+	m := New(nil)
+	if err := m.SetColumns([]string{"pid", "name", "type", "up", "down"}); err != nil {
+		t.Fatal(err)
+	}
+	procs := []model.ProcessSummary{
+		{PID: 4242, Name: "firefox", Kind: model.KindApp},
+		{PID: 1200, Name: "sshd", Kind: model.KindDaemon},
+		{PID: 9001, Name: "curl", Kind: model.KindShell},
+		{PID: 77, Name: "remote"}, // a host that doesn't classify
+	}
+	m.table.update(procs)
+	out := m.table.render(120, 10, false)
+	if !strings.Contains(strings.Split(out, "\n")[0], "TYPE") {
+		t.Errorf("no TYPE header:\n%s", out)
+	}
+	for _, want := range []string{"app", "daemon", "shell"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table missing %q:\n%s", want, out)
+		}
+	}
+
+	for filter, want := range map[string]int{"type:app": 1, "type:d": 1, "type:SHELL": 1, "NOT type:daemon": 3, "type:gui": 0} {
+		n := 0
+		f := ParseFilter(filter)
+		for i := range procs {
+			if f.Match(&procs[i]) {
+				n++
+			}
+		}
+		if n != want {
+			t.Errorf("%q matched %d processes, want %d", filter, n, want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/firewall"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// limitOverlay caps a process's bandwidth (L, experimental): an nftables
+// policer on the process's cgroup, removed when sstop exits.
+type limitOverlay struct {
+	active     bool
+	pid        uint32
+	name       string
+	current    *firewall.Rule // the process's limit, nil if none
+	input      textinput.Model
+	result     string
+	showResult bool
+}
+
+func newLimitOverlay() limitOverlay {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.Placeholder = "e.g. 500K, 2M"
+	ti.CharLimit = 16
+	return limitOverlay{input: ti}
+}
+
+// open opens the overlay on p, or with why it can't when fw is nil.
+func (l *limitOverlay) open(p *model.ProcessSummary, fw Firewall) tea.Cmd {
+	l.active = true
+	l.pid, l.name = p.PID, p.Name
+	l.current = nil
+	l.result, l.showResult = "", false
+	switch {
+	case p.PID == model.UnattributedPID:
+		l.fail(fmt.Errorf("%s is not a process", model.UnattributedName))
+		return nil
+	case fw == nil:
+		l.fail(fmt.Errorf("limiting needs a live local capture (nftables, cgroup v2, root)"))
+		return nil
+	}
+	for _, r := range fw.Rules() {
+		if r.Action == firewall.LimitProcess && r.PID == p.PID {
+			r := r
+			l.current = &r
+		}
+	}
+	l.input.SetValue("")
+	if l.current != nil {
+		l.input.SetValue(formatThreshold(float64(l.current.Rate)))
+		l.input.CursorEnd()
+	}
+	return l.input.Focus()
+}
+
+func (l *limitOverlay) close() {
+	l.active = false
+	l.input.Blur()
+}
+
+func (l *limitOverlay) fail(err error) {
+	l.result = "Failed: " + err.Error()
+	l.showResult = true
+	l.input.Blur()
+}
+
+// update handles a key while the overlay is open.
+func (l *limitOverlay) update(msg tea.KeyMsg, fw Firewall) tea.Cmd {
+	if l.showResult {
+		// Any key closes the result
+		l.close()
+		return nil
+	}
+	switch msg.String() {
+	case "esc":
+		l.close()
+		return nil
+	case "enter":
+		value := strings.TrimSpace(l.input.Value())
+		if value == "" {
+			// Empty removes the limit
+			if l.current != nil {
+				if err := fw.Remove(l.current.ID); err != nil {
+					l.fail(err)
+					return nil
+				}
+				l.result = fmt.Sprintf("Removed the limit on %s", l.name)
+				l.showResult = true
+				l.input.Blur()
+				return nil
+			}
+			l.close()
+			return nil
+		}
+		rate := parseSize(value)
+		if rate < 1 {
+			l.fail(fmt.Errorf("invalid rate %q", value))
+			return nil
+		}
+		r, err := fw.LimitProcess(l.pid, uint64(rate))
+		if err != nil {
+			l.fail(err)
+			return nil
+		}
+		l.result = fmt.Sprintf("Limited %s to %s each way: cgroup %s (removed when sstop exits)",
+			l.name, strings.TrimSpace(FormatRate(float64(r.Rate))), r.Cgroup)
+		l.showResult = true
+		l.input.Blur()
+		return nil
+	}
+	var cmd tea.Cmd
+	l.input, cmd = l.input.Update(msg)
+	return cmd
+}
+
+func (l *limitOverlay) render(width, height int) string {
+	if l.showResult {
+		resultStyle := styleKillResult
+		if strings.HasPrefix(l.result, "Failed") {
+			resultStyle = styleKillResultErr
+		}
+		content := resultStyle.Render(l.result) + "\n\n" +
+			styleDetailLabel.Render("Press any key to close")
+		box := styleKillBorder.Render(content)
+		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+	}
+
+	title := styleKillTitle.Render(fmt.Sprintf("  Limit bandwidth: %s (PID %d)", l.name, l.pid))
+	body := styleKillSignal.Render("  Rate each way: ") + l.input.View()
+	notes := []string{
+		styleKillDesc.Render("  Experimental. Drops traffic over the rate for every process in"),
+		styleKillDesc.Render("  this one's cgroup; TCP slows down to match."),
+	}
+	if l.current != nil {
+		notes = append(notes, styleKillDesc.Render("  Now limited to "+strings.TrimSpace(FormatRate(float64(l.current.Rate)))+"; empty removes it."))
+	}
+	hint := styleDetailLabel.Render("  enter apply  esc cancel")
+	box := styleKillBorder.Render(title + "\n\n" + body + "\n\n" + strings.Join(notes, "\n") + "\n\n" + hint)
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// compactLine renders the overlay as the one-line prompt of a short
+// terminal.
+func (l *limitOverlay) compactLine() string {
+	if l.showResult {
+		return styleKillResult.Render(l.result) + styleDetailLabel.Render("  any key")
+	}
+	return styleKillTitle.Render(fmt.Sprintf("Limit %s (PID %d) to: ", l.name, l.pid)) + l.input.View() +
+		styleDetailLabel.Render(" /s  enter/esc")
+}
@@ -1,7 +1,11 @@
 package ui
 
 import (
+	"bytes"
 	"fmt"
+	"net"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -13,6 +17,198 @@ type listenPortsView struct {
 	cursor     int
 	offset     int
 	viewHeight int
+	window     listWindow       // rows last drawn
+	filter     string           // / in this view
+	expanded   map[portKey]bool // shared ports listing their sockets
+}
+
+// portKey identifies a port independent of the bound address.
+type portKey struct {
+	proto model.Protocol
+	port  uint16
+}
+
+// portShare is how the sockets on one port share it.
+type portShare int
+
+const (
+	shareNone    portShare = iota // different addresses, or IPv4 and IPv6
+	shareReuse                    // several sockets on one address: SO_REUSEPORT
+	shareOverlap                  // a wildcard and a specific address of a family
+)
+
+// portGroup is the listening sockets on one protocol and port.
+type portGroup struct {
+	ports    []model.ListenPortEntry // wildcards first, IPv4 before IPv6, then by address and PID
+	share    portShare
+	conflict []bool // per socket: part of the overlap or SO_REUSEPORT group
+}
+
+// groupListenPorts groups ports by protocol and port, in the order the
+// ports first appear.
+func groupListenPorts(ports []model.ListenPortEntry) []portGroup {
+	var groups []portGroup
+	index := make(map[portKey]int)
+	for _, lp := range ports {
+		k := portKey{lp.Proto, lp.Port}
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, portGroup{})
+		}
+		groups[i].ports = append(groups[i].ports, lp)
+	}
+	for i := range groups {
+		groups[i].classify()
+	}
+	return groups
+}
+
+// classify sorts the group's sockets and works out how they share the
+// port. A wildcard bind and a specific one of the same family overlap:
+// connections to the specific address go to its socket, the rest to the
+// wildcard's. Sockets on the same address share it with SO_REUSEPORT,
+// the kernel spreading connections across them. IPv4 and IPv6 binds are
+// told apart; whether [::] also takes IPv4 (IPV6_V6ONLY) isn't visible.
+func (g *portGroup) classify() {
+	sort.SliceStable(g.ports, func(i, j int) bool {
+		a, b := g.ports[i], g.ports[j]
+		if wa, wb := isWildcard(a.IP), isWildcard(b.IP); wa != wb {
+			return wa
+		}
+		if va, vb := isIPv4(a.IP), isIPv4(b.IP); va != vb {
+			return va
+		}
+		if c := bytes.Compare(a.IP.To16(), b.IP.To16()); c != 0 {
+			return c < 0
+		}
+		return a.PID < b.PID
+	})
+	g.share = shareNone
+	g.conflict = make([]bool, len(g.ports))
+	for i := range g.ports {
+		for j := i + 1; j < len(g.ports); j++ {
+			a, b := g.ports[i].IP, g.ports[j].IP
+			if isIPv4(a) != isIPv4(b) {
+				continue
+			}
+			switch {
+			case isWildcard(a) && isWildcard(b) || a.Equal(b):
+				g.share = max(g.share, shareReuse)
+			case isWildcard(a) || isWildcard(b):
+				g.share = shareOverlap
+			default:
+				continue
+			}
+			g.conflict[i], g.conflict[j] = true, true
+		}
+	}
+}
+
+func isWildcard(ip net.IP) bool { return ip == nil || ip.IsUnspecified() }
+
+func isIPv4(ip net.IP) bool { return ip == nil || ip.To4() != nil }
+
+// pids returns the distinct PIDs holding the port, in order.
+func (g *portGroup) pids() []uint32 {
+	var pids []uint32
+	for _, lp := range g.ports {
+		if !slices.Contains(pids, lp.PID) {
+			pids = append(pids, lp.PID)
+		}
+	}
+	return pids
+}
+
+// shared reports whether the port gets one row for all its sockets:
+// when their binds conflict or more than one process holds it. One
+// process on IPv4 and IPv6 keeps a row per socket.
+func (g *portGroup) shared() bool {
+	return len(g.ports) > 1 && (g.share != shareNone || len(g.pids()) > 1)
+}
+
+// summary describes a shared port for its row: "overlap: * and
+// 127.0.0.1", "SO_REUSEPORT: 4 sockets" or "2 processes".
+func (g *portGroup) summary() string {
+	switch g.share {
+	case shareOverlap:
+		var addrs []string
+		for i, lp := range g.ports {
+			if a := listenHost(&lp); g.conflict[i] && !slices.Contains(addrs, a) {
+				addrs = append(addrs, a)
+			}
+		}
+		return "overlap: " + joinAnd(addrs)
+	case shareReuse:
+		n := 0
+		for _, c := range g.conflict {
+			if c {
+				n++
+			}
+		}
+		return fmt.Sprintf("SO_REUSEPORT: %d sockets", n)
+	}
+	return fmt.Sprintf("%d processes", len(g.pids()))
+}
+
+// joinAnd joins words as "a and b", or "a, b and c".
+func joinAnd(words []string) string {
+	if len(words) < 2 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
+
+// listenRow is a row of the view: a socket, or the row of a shared port,
+// which enter expands to list its sockets below it.
+type listenRow struct {
+	lp       *model.ListenPortEntry // the socket; a shared port's first
+	group    *portGroup             // set on a shared port's row
+	child    bool                   // one of an expanded port's sockets
+	last     bool                   // the port's last socket
+	conflict bool                   // the socket's bind overlaps or shares another's
+}
+
+// rows lays out ports as the view's rows.
+func (v *listenPortsView) rows(ports []model.ListenPortEntry) []listenRow {
+	var rows []listenRow
+	groups := groupListenPorts(ports)
+	for gi := range groups {
+		g := &groups[gi]
+		if !g.shared() {
+			for i := range g.ports {
+				rows = append(rows, listenRow{lp: &g.ports[i]})
+			}
+			continue
+		}
+		rows = append(rows, listenRow{lp: &g.ports[0], group: g})
+		if !v.expanded[portKey{g.ports[0].Proto, g.ports[0].Port}] {
+			continue
+		}
+		for i := range g.ports {
+			rows = append(rows, listenRow{lp: &g.ports[i], child: true, last: i == len(g.ports)-1, conflict: g.conflict[i]})
+		}
+	}
+	return rows
+}
+
+// listenRows returns the view's rows, filtered.
+func (m *Model) listenRows() []listenRow {
+	return m.listenPorts.rows(m.listenPortRows())
+}
+
+// toggle expands or collapses a shared port's row.
+func (v *listenPortsView) toggle(r *listenRow) {
+	k := portKey{r.lp.Proto, r.lp.Port}
+	if v.expanded == nil {
+		v.expanded = make(map[portKey]bool)
+	}
+	if v.expanded[k] {
+		delete(v.expanded, k)
+	} else {
+		v.expanded[k] = true
+	}
 }
 
 func newListenPortsView() listenPortsView {
@@ -72,10 +268,12 @@ const (
 
 func (v *listenPortsView) render(ports []model.ListenPortEntry, width, height int) string {
 	v.viewHeight = height
+	v.window = listWindow{}
 
 	if len(ports) == 0 {
 		return styleDetailLabel.Render("  No listening ports")
 	}
+	lines := v.rows(ports)
 
 	// Dynamic address width
 	// 4 columns (PROTO, ADDR, PID, PROCESS) = 3 gaps + 2 indent
@@ -93,11 +291,17 @@ func (v *listenPortsView) render(ports []model.ListenPortEntry, width, height in
 
 	// Title + header
 	title := styleTitle.Render(fmt.Sprintf("  Listening Ports (%d)", len(ports)))
+	switch n := countConflicts(lines); {
+	case n == 1:
+		title += stylePortConflict.Render("  1 port with overlapping binds")
+	case n > 1:
+		title += stylePortConflict.Render(fmt.Sprintf("  %d ports with overlapping binds", n))
+	}
 	header := v.renderHeader(addrW, cmdW)
 
 	// Scroll
-	if v.cursor >= len(ports) {
-		v.cursor = len(ports) - 1
+	if v.cursor >= len(lines) {
+		v.cursor = len(lines) - 1
 	}
 	if v.cursor < 0 {
 		v.cursor = 0
@@ -113,38 +317,32 @@ func (v *listenPortsView) render(ports []model.ListenPortEntry, width, height in
 		v.offset = v.cursor - visibleRows + 1
 	}
 
-	var lines []string
-	lines = append(lines, title)
-	lines = append(lines, header)
-
 	end := v.offset + visibleRows
-	if end > len(ports) {
-		end = len(ports)
+	if end > len(lines) {
+		end = len(lines)
 	}
+	v.window = listWindow{offset: v.offset, rows: end - v.offset, total: len(lines)}
+
+	var rows []string
 
 	for i := v.offset; i < end; i++ {
-		lp := &ports[i]
+		r := &lines[i]
 		selected := i == v.cursor
 		isEvenRow := (i-v.offset)%2 == 1
 
-		proto := lp.Proto.String()
+		proto := r.lp.Proto.String()
+		c := r.cells()
+		conflict := r.conflict || r.group != nil && r.group.share != shareNone
 
-		// Format local address
-		addr := "*"
-		if lp.IP != nil && !lp.IP.IsUnspecified() {
-			addr = lp.IP.String()
-		}
-		addr = fmt.Sprintf("%s:%d", addr, lp.Port)
-		addr = Truncate(addr, addrW)
+		addr := Truncate(c.addr, addrW)
 		addr = fmt.Sprintf("%-*s", addrW, addr)
-
-		pid := fmt.Sprintf("%-*d", lpPidW, lp.PID)
-		proc := Truncate(lp.Process, lpProcW)
+		pid := fmt.Sprintf("%-*s", lpPidW, c.pid)
+		proc := Truncate(c.proc, lpProcW)
 		proc = fmt.Sprintf("%-*s", lpProcW, proc)
 
 		cmdline := ""
 		if cmdW > 0 {
-			cmdline = Truncate(lp.Cmdline, cmdW)
+			cmdline = Truncate(c.cmd, cmdW)
 			cmdline = fmt.Sprintf("%-*s", cmdW, cmdline)
 		}
 
@@ -152,6 +350,9 @@ func (v *listenPortsView) render(ports []model.ListenPortEntry, width, height in
 		if selected {
 			styledProto := styleTableRowSelected.Foreground(colorCyan).Render(fmt.Sprintf("%-*s", lpProtoW, proto))
 			styledAddr := styleTableRowSelected.Foreground(colorFg).Render(addr)
+			if conflict {
+				styledAddr = styleTableRowSelected.Foreground(colorYellow).Bold(true).Render(addr)
+			}
 			styledPid := styleTableRowSelected.Foreground(colorFgDim).Render(pid)
 			styledProc := styleTableRowSelected.Foreground(colorFg).Bold(true).Render(proc)
 			row = lipgloss.JoinHorizontal(lipgloss.Top,
@@ -162,7 +363,11 @@ func (v *listenPortsView) render(ports []model.ListenPortEntry, width, height in
 				styledProc,
 			)
 			if cmdW > 0 {
-				row += " " + styleTableRowSelected.Foreground(colorFgDim).Render(cmdline)
+				cmdStyle := styleTableRowSelected.Foreground(colorFgDim)
+				if r.group != nil && r.group.share != shareNone {
+					cmdStyle = styleTableRowSelected.Foreground(colorYellow).Bold(true)
+				}
+				row += " " + cmdStyle.Render(cmdline)
 			}
 			rowWidth := lipgloss.Width(row)
 			if rowWidth < width {
@@ -175,6 +380,12 @@ func (v *listenPortsView) render(ports []model.ListenPortEntry, width, height in
 			pidStyle := stylePID
 			procStyle := styleProcessName
 			cmdStyle := styleDetailLabel
+			if conflict {
+				addrStyle = stylePortConflict
+				if r.group != nil {
+					cmdStyle = stylePortConflict
+				}
+			}
 
 			if isEvenRow {
 				bgStyle = styleZebraRow
@@ -204,10 +415,99 @@ func (v *listenPortsView) render(ports []model.ListenPortEntry, width, height in
 			}
 		}
 
-		lines = append(lines, row)
+		rows = append(rows, row)
+	}
+
+	out := append([]string{title, header}, withScrollbar(rows, v.window, width)...)
+	return strings.Join(out, "\n")
+}
+
+// listenCells is a row's text, column by column.
+type listenCells struct {
+	addr, pid, proc, cmd string
+}
+
+// cells returns the row's text. A shared port's row shows how many
+// sockets it holds and who holds them; its sockets are drawn as a tree.
+func (r *listenRow) cells() listenCells {
+	if g := r.group; g != nil {
+		c := listenCells{addr: formatListenAddr(r.lp), cmd: g.summary()}
+		if n := len(g.ports) - 1; n > 0 {
+			c.addr += fmt.Sprintf(" +%d", n)
+		}
+		pids := g.pids()
+		c.pid = fmt.Sprint(pids[0])
+		if len(pids) > 1 {
+			c.pid = fmt.Sprintf("%d PIDs", len(pids))
+		}
+		var names []string
+		for _, lp := range g.ports {
+			if !slices.Contains(names, lp.Process) {
+				names = append(names, lp.Process)
+			}
+		}
+		c.proc = strings.Join(names, ", ")
+		return c
+	}
+	c := listenCells{addr: formatListenAddr(r.lp), pid: fmt.Sprint(r.lp.PID), proc: r.lp.Process, cmd: r.lp.Cmdline}
+	if r.lp.PID == 0 {
+		c.pid = "-" // NAT-only published port
+	}
+	if r.child {
+		branch := "├─ "
+		if r.last {
+			branch = "└─ "
+		}
+		c.addr = branch + c.addr
+	}
+	return c
+}
+
+// countConflicts counts the ports whose binds overlap or share an address.
+func countConflicts(rows []listenRow) int {
+	n := 0
+	for i := range rows {
+		if g := rows[i].group; g != nil && g.share != shareNone {
+			n++
+		}
 	}
+	return n
+}
+
+// openListenRow lists a port's clients, or expands or collapses a shared
+// port's row.
+func (m *Model) openListenRow(r *listenRow) {
+	if r.group != nil {
+		m.listenPorts.toggle(r)
+		return
+	}
+	m.openListenPort(r.lp)
+}
 
-	return strings.Join(lines, "\n")
+// openListenPort switches to the connections view scoped to the clients
+// of a listening port.
+func (m *Model) openListenPort(lp *model.ListenPortEntry) {
+	m.connections.scope = newConnScope(lp)
+	m.connections.cursor, m.connections.offset = 0, 0
+	m.mode = ViewConnections
+}
+
+// formatListenAddr formats the local address, followed by the container
+// target for published container ports: "*:8080 → web:80".
+func formatListenAddr(lp *model.ListenPortEntry) string {
+	addr := fmt.Sprintf("%s:%d", listenHost(lp), lp.Port)
+	if lp.Container != "" {
+		addr += fmt.Sprintf(" → %s:%d", lp.Container, lp.ContainerPort)
+	}
+	return addr
+}
+
+// listenHost is the address a port is bound to: "*" for any.
+func listenHost(lp *model.ListenPortEntry) string {
+	if isWildcard(lp.IP) {
+		return "*"
+	}
+	return lp.IP.String()
 }
 
 func (v *listenPortsView) renderHeader(addrW, cmdW int) string {
@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestFormatListenAddr(t *testing.T) {
+	tests := []struct {
+		lp   model.ListenPortEntry
+		want string
+	}{
+		{model.ListenPortEntry{IP: net.IPv4zero, Port: 22}, "*:22"},
+		{model.ListenPortEntry{IP: net.ParseIP("127.0.0.1"), Port: 631}, "127.0.0.1:631"},
+		{model.ListenPortEntry{IP: net.IPv4zero, Port: 8080, Container: "web", ContainerPort: 80}, "*:8080 → web:80"},
+	}
+	for _, tt := range tests {
+		if got := formatListenAddr(&tt.lp); got != tt.want {
+			t.Errorf("formatListenAddr(%+v) = %q, want %q", tt.lp, got, tt.want)
+		}
+	}
+}
+
+func TestListenPortClients(t *testing.T) {
+	inbound := func(local string, port uint16, peer string, rate float64) model.Connection {
+		return model.Connection{Proto: model.ProtoTCP, SrcIP: net.ParseIP(local), SrcPort: port,
+			DstIP: net.ParseIP(peer), DstPort: 50000, DownRate: rate, State: model.StateEstablished, Direction: model.DirInbound}
+	}
+	m := New(nil)
+	m.width, m.height = 120, 30
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{
+		Processes: []model.ProcessSummary{
+			{PID: 20, Name: "nginx", Connections: []model.Connection{
+				inbound("10.0.0.2", 443, "198.51.100.7", 2000),
+				inbound("10.0.0.2", 443, "203.0.113.9", 500),
+				inbound("10.0.0.2", 80, "198.51.100.8", 100),
+			}},
+			{PID: 21, Name: "curl", Connections: []model.Connection{{Proto: model.ProtoTCP, SrcIP: net.ParseIP("10.0.0.2"),
+				SrcPort: 443, DstIP: net.ParseIP("192.0.2.1"), DstPort: 443, Direction: model.DirOutbound}}},
+		},
+		ListenPorts: []model.ListenPortEntry{
+			{Proto: model.ProtoTCP, IP: net.IPv4zero, Port: 80, PID: 20, Process: "nginx"},
+			{Proto: model.ProtoTCP, IP: net.IPv4zero, Port: 443, PID: 20, Process: "nginx"},
+		},
+	}))
+	m = next.(Model)
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ViewConnections {
+		t.Fatalf("enter: mode = %v, want connections", m.mode)
+	}
+	conns := m.connectionRows()
+	if len(conns) != 2 || conns[0].DstIP.String() != "198.51.100.7" || conns[1].DstIP.String() != "203.0.113.9" {
+		t.Fatalf("clients of :443 = %+v, want the 2 inbound ones, busiest first", conns)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "clients of TCP *:443") {
+		t.Errorf("title should name the port:\n%s", view)
+	}
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.mode != ViewListenPorts || m.connections.scope.active {
+		t.Errorf("esc: mode %v, scope %+v, want back to listen ports unscoped", m.mode, m.connections.scope)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	if n := len(m.connectionRows()); n != 4 {
+		t.Errorf("v: %d connections, want all 4", n)
+	}
+}
+
+func TestGroupListenPorts(t *testing.T) {
+	lp := func(ip string, port uint16, pid uint32, name string) model.ListenPortEntry {
+		return model.ListenPortEntry{Proto: model.ProtoTCP, IP: net.ParseIP(ip), Port: port, PID: pid, Process: name}
+	}
+	groups := groupListenPorts([]model.ListenPortEntry{
+		lp("0.0.0.0", 22, 1, "sshd"), lp("::", 22, 1, "sshd"),
+		lp("127.0.0.1", 80, 30, "devserver"), lp("0.0.0.0", 80, 20, "nginx"), lp("::", 80, 20, "nginx"),
+		lp("0.0.0.0", 443, 20, "nginx"), lp("0.0.0.0", 443, 21, "nginx"),
+		lp("10.0.0.2", 8080, 40, "api"), lp("10.0.0.3", 8080, 41, "api"),
+	})
+	tests := []struct {
+		share    portShare
+		shared   bool
+		conflict []bool
+		summary  string
+	}{
+		{shareNone, false, []bool{false, false}, ""},
+		{shareOverlap, true, []bool{true, false, true}, "overlap: * and 127.0.0.1"},
+		{shareReuse, true, []bool{true, true}, "SO_REUSEPORT: 2 sockets"},
+		{shareNone, true, []bool{false, false}, "2 processes"},
+	}
+	if len(groups) != len(tests) {
+		t.Fatalf("%d groups, want %d", len(groups), len(tests))
+	}
+	for i, tt := range tests {
+		g := &groups[i]
+		port := g.ports[0].Port
+		if g.share != tt.share || g.shared() != tt.shared || !slices.Equal(g.conflict, tt.conflict) {
+			t.Errorf(":%d: share %v shared %v conflict %v, want %v %v %v", port, g.share, g.shared(), g.conflict, tt.share, tt.shared, tt.conflict)
+		}
+		if tt.shared && g.summary() != tt.summary {
+			t.Errorf(":%d: summary %q, want %q", port, g.summary(), tt.summary)
+		}
+	}
+	// The wildcard bind comes first
+	if g := groups[1]; !g.ports[0].IP.IsUnspecified() || g.ports[2].Process != "devserver" {
+		t.Errorf(":80 sockets in order %+v, want the wildcards first", g.ports)
+	}
+}
+
+func TestListenPortsSharedRow(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 140, 30
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{ListenPorts: []model.ListenPortEntry{
+		{Proto: model.ProtoTCP, IP: net.IPv4zero, Port: 22, PID: 1, Process: "sshd"},
+		{Proto: model.ProtoTCP, IP: net.IPv4zero, Port: 80, PID: 20, Process: "nginx"},
+		{Proto: model.ProtoTCP, IP: net.ParseIP("127.0.0.1"), Port: 80, PID: 30, Process: "devserver"},
+	}}))
+	m = next.(Model)
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"1 port with overlapping binds", "*:80 +1", "2 PIDs", "nginx, devserver", "overlap: * and 127.0.0.1"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+	if n := len(m.listenRows()); n != 2 {
+		t.Fatalf("%d rows, want sshd and the shared :80", n)
+	}
+
+	// Enter expands the shared port; enter on one of its sockets lists
+	// that socket's clients
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ViewListenPorts || len(m.listenRows()) != 4 {
+		t.Fatalf("enter on :80: mode %v, %d rows, want its 2 sockets listed", m.mode, len(m.listenRows()))
+	}
+	view = ansi.Strip(m.View())
+	if !strings.Contains(view, "├─ *:80") || !strings.Contains(view, "└─ 127.0.0.1:80") {
+		t.Errorf("sockets should be drawn as a tree:\n%s", view)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("G")})
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != ViewConnections || m.connections.scope.label != "TCP 127.0.0.1:80" {
+		t.Errorf("enter on a socket: mode %v, scope %q", m.mode, m.connections.scope.label)
+	}
+}
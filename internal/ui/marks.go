@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// markSet is the process table's select mode (V): rows marked with space
+// or m, for K, e and O to act on all at once. Marks follow processes, not
+// PIDs, so a process reusing a marked PID isn't marked.
+type markSet struct {
+	active bool
+	ids    map[model.ProcessID]bool
+}
+
+// startMarking enters select mode with nothing marked.
+func (t *processTable) startMarking() {
+	t.marks = markSet{active: true, ids: make(map[model.ProcessID]bool)}
+}
+
+// stopMarking leaves select mode, dropping the marks.
+func (t *processTable) stopMarking() {
+	t.marks = markSet{}
+}
+
+// toggleMark marks or unmarks the selected row and moves to the next, so
+// holding the key marks a run of rows.
+func (t *processTable) toggleMark() {
+	sel := t.selected()
+	if sel == nil || !t.marks.active {
+		return
+	}
+	id := sel.ID()
+	if t.marks.ids[id] {
+		delete(t.marks.ids, id)
+	} else {
+		t.marks.ids[id] = true
+	}
+	t.moveDown()
+}
+
+// isMarked reports whether p is marked.
+func (t *processTable) isMarked(p *model.ProcessSummary) bool {
+	return t.marks.ids[p.ID()]
+}
+
+// pruneMarks forgets marks on processes gone from the table.
+func (t *processTable) pruneMarks() {
+	if len(t.marks.ids) == 0 {
+		return
+	}
+	present := make(map[model.ProcessID]bool, len(t.processes))
+	for i := range t.processes {
+		present[t.processes[i].ID()] = true
+	}
+	for id := range t.marks.ids {
+		if !present[id] {
+			delete(t.marks.ids, id)
+		}
+	}
+}
+
+// targets returns the processes a batch action applies to: the marked
+// ones by PID, filtered out or not, else the selected one.
+func (t *processTable) targets() []model.ProcessSummary {
+	var procs []model.ProcessSummary
+	for i := range t.processes {
+		if t.isMarked(&t.processes[i]) {
+			procs = append(procs, t.processes[i])
+		}
+	}
+	if len(procs) == 0 {
+		if sel := t.selected(); sel != nil {
+			procs = append(procs, *sel)
+		}
+	}
+	sort.Slice(procs, func(i, j int) bool { return procs[i].PID < procs[j].PID })
+	return procs
+}
+
+// ProcessExporter saves processes to a file and returns its path.
+type ProcessExporter func(procs []model.ProcessSummary) (path string, err error)
+
+// exportState is e in the process table: where processes are saved, and
+// the result of the last save.
+type exportState struct {
+	save   ProcessExporter // nil when export isn't available
+	status string
+	failed bool
+}
+
+// SetExporter enables e in the process table.
+func (m *Model) SetExporter(save ProcessExporter) {
+	m.export.save = save
+}
+
+// killMarked opens the kill overlay for the marked processes that are
+// still running, or the selected one.
+func (m *Model) killMarked() {
+	procs := m.table.targets()
+	live := procs[:0]
+	for _, p := range procs {
+		if p.ExitedAt.IsZero() && p.PID != model.UnattributedPID {
+			live = append(live, p)
+		}
+	}
+	if len(live) > 0 {
+		m.kill.openBatch(live)
+	}
+}
+
+// exportMarked saves the marked processes, or the selected one, as JSON.
+func (m *Model) exportMarked() {
+	e := &m.export
+	procs := m.table.targets()
+	switch {
+	case len(procs) == 0:
+		return
+	case e.save == nil:
+		e.status, e.failed = "export is not available here", true
+		return
+	}
+	path, err := e.save(procs)
+	if err != nil {
+		e.status, e.failed = "export: "+err.Error(), true
+		return
+	}
+	noun := "processes"
+	if len(procs) == 1 {
+		noun = "process"
+	}
+	e.status, e.failed = fmt.Sprintf("%d %s saved: %s", len(procs), noun, path), false
+}
+
+// filterMarked narrows the table to the marked processes: pid:42,43,50.
+// Editing it with / and putting NOT in front hides them instead.
+func (m *Model) filterMarked() {
+	if len(m.table.marks.ids) == 0 {
+		return
+	}
+	procs := m.table.targets()
+	pids := make([]string, len(procs))
+	for i := range procs {
+		pids[i] = strconv.FormatUint(uint64(procs[i].PID), 10)
+	}
+	f := "pid:" + strings.Join(pids, ",")
+	m.setViewFilter(f)
+	m.filterHistory.push(f)
+}
+
+// exportFooterText is the footer's result of the last export, in the
+// process table.
+func (m Model) exportFooterText() string {
+	e := m.export
+	if e.status == "" || m.mode != ViewProcessTable {
+		return ""
+	}
+	if e.failed {
+		return styleKillResultErr.Render(e.status)
+	}
+	return styleFooter.Render(e.status)
+}
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func markedModel(t *testing.T) Model {
+	t.Helper()
+	m := New(nil)
+	m.width, m.height = 120, 30
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{
+		{PID: 50, Name: "curl", DownRate: 3000},
+		{PID: 42, Name: "wget", DownRate: 2000},
+		{PID: 43, Name: "rsync", DownRate: 1000},
+	}}))
+	m = next.(Model)
+
+	// V, then mark curl and rsync: space marks and moves down
+	m = typeKeys(m, "V")
+	m = press(m, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m = typeKeys(m, "m")
+	m = typeKeys(m, "m")
+	m = press(m, tea.KeyMsg{Type: tea.KeyUp})
+	m = typeKeys(m, "m") // unmark wget
+	if !m.table.marks.active || len(m.table.marks.ids) != 2 {
+		t.Fatalf("marks = %+v, want curl and rsync", m.table.marks)
+	}
+	return m
+}
+
+func TestSelectMode(t *testing.T) {
+	m := markedModel(t)
+	if m.paused {
+		t.Error("space in select mode marks the row, it should not pause")
+	}
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"● curl", "○ wget", "● rsync", "SELECT 2 marked"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+
+	// Marks follow the process: a new process on curl's PID isn't marked
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{Processes: []model.ProcessSummary{
+		{PID: 50, Name: "ssh", StartTime: testClock, DownRate: 3000},
+		{PID: 42, Name: "wget", DownRate: 2000},
+		{PID: 43, Name: "rsync", DownRate: 1000},
+	}}))
+	m = next.(Model)
+	if len(m.table.marks.ids) != 1 {
+		t.Errorf("marks = %+v, want only rsync after PID 50 was reused", m.table.marks)
+	}
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.table.marks.active || len(m.table.marks.ids) != 0 {
+		t.Errorf("esc should leave select mode and drop the marks: %+v", m.table.marks)
+	}
+	if strings.Contains(ansi.Strip(m.View()), "○ ") {
+		t.Error("rows keep their markers after select mode")
+	}
+}
+
+func TestKillMarked(t *testing.T) {
+	m := markedModel(t)
+	m = typeKeys(m, "K")
+	if !m.kill.active {
+		t.Fatal("K should open the kill overlay")
+	}
+	if got, want := m.kill.target(), "2 processes (PIDs 43, 50)"; got != want {
+		t.Errorf("target = %q, want %q", got, want)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "2 processes (PIDs 43, 50)") {
+		t.Errorf("overlay should name the batch:\n%s", view)
+	}
+
+	// An important process in the batch asks to confirm, naming it
+	k := newKillOverlay()
+	k.openBatch([]model.ProcessSummary{{PID: 1, Name: "systemd"}, {PID: 42, Name: "wget"}})
+	if k.important == "" || !strings.HasPrefix(k.warning(), "systemd (PID 1) is ") {
+		t.Errorf("warning = %q, want it to name systemd", k.warning())
+	}
+}
+
+func TestExportMarked(t *testing.T) {
+	m := markedModel(t)
+	var saved []model.ProcessSummary
+	m.SetExporter(func(procs []model.ProcessSummary) (string, error) {
+		saved = procs
+		return "out.json", nil
+	})
+	m = typeKeys(m, "e")
+	if len(saved) != 2 || saved[0].Name != "rsync" || saved[1].Name != "curl" {
+		t.Fatalf("exported %+v, want rsync and curl", saved)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "2 processes saved: out.json") {
+		t.Errorf("footer should report the export:\n%s", view)
+	}
+
+	m.SetExporter(func([]model.ProcessSummary) (string, error) { return "", errors.New("read-only file system") })
+	m = typeKeys(m, "e")
+	if !m.export.failed || !strings.Contains(m.export.status, "read-only") {
+		t.Errorf("status = %q, want the error", m.export.status)
+	}
+
+	// Without a mark e exports the selected row
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	m.SetExporter(func(procs []model.ProcessSummary) (string, error) {
+		saved = procs
+		return "out.json", nil
+	})
+	m = typeKeys(m, "e")
+	if len(saved) != 1 {
+		t.Errorf("exported %d processes, want the selected one", len(saved))
+	}
+}
+
+func TestFilterMarked(t *testing.T) {
+	m := markedModel(t)
+	m = typeKeys(m, "O")
+	if got, want := m.table.filter, "pid:43,50"; got != want {
+		t.Fatalf("filter = %q, want %q", got, want)
+	}
+	if n := len(m.table.filtered); n != 2 {
+		t.Errorf("%d rows, want the 2 marked", n)
+	}
+}
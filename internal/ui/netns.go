@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// netnsHost selects the processes in sstop's own network namespace.
+const netnsHost = "host"
+
+// netnsNames returns the other network namespaces processes run in, sorted.
+func netnsNames(procs []model.ProcessSummary) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for i := range procs {
+		ns := procs[i].NetNS
+		if ns != "" && !seen[ns] {
+			seen[ns] = true
+			names = append(names, ns)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// matchNetNS reports whether proc runs in the selected namespace: "host"
+// for sstop's own, else a (case-insensitive) part of the namespace name.
+func matchNetNS(proc *model.ProcessSummary, sel string) bool {
+	if strings.EqualFold(sel, netnsHost) {
+		return proc.NetNS == ""
+	}
+	return proc.NetNS != "" && strings.Contains(strings.ToLower(proc.NetNS), strings.ToLower(sel))
+}
+
+// cycleNetNS steps the process table's namespace selector: all → host →
+// each other namespace → all. A no-op until --netns finds namespaces.
+func (m *Model) cycleNetNS() {
+	names := netnsNames(m.snapshot.Processes)
+	if len(names) == 0 && m.activeNetNS == "" {
+		return
+	}
+	order := append([]string{"", netnsHost}, names...)
+	next := ""
+	for i, ns := range order {
+		if ns == m.activeNetNS && i+1 < len(order) {
+			next = order[i+1]
+			break
+		}
+	}
+	m.activeNetNS = next
+	m.table.update(m.tableRows())
+}
+
+// inNetNS keeps the processes in the selected namespace.
+func inNetNS(procs []model.ProcessSummary, sel string) []model.ProcessSummary {
+	var out []model.ProcessSummary
+	for i := range procs {
+		if procs[i].NetNS == sel || (sel == netnsHost && procs[i].NetNS == "") {
+			out = append(out, procs[i])
+		}
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/notes"
+)
+
+// noteOverlay edits the operator note on a process or on a process name.
+type noteOverlay struct {
+	active bool
+	byName bool // note every process with this name, not just this one
+	id     model.ProcessID
+	name   string
+	input  textinput.Model
+	err    error // last save error, shown until the overlay closes
+}
+
+func newNoteOverlay() noteOverlay {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.Placeholder = "e.g. investigating, known-good"
+	ti.CharLimit = notes.MaxLen
+	return noteOverlay{input: ti}
+}
+
+func (n *noteOverlay) open(id model.ProcessID, name, current string, byName bool) {
+	n.active = true
+	n.byName = byName
+	n.id = id
+	n.name = name
+	n.err = nil
+	n.input.SetValue(current)
+	n.input.CursorEnd()
+	n.input.Focus()
+}
+
+func (n *noteOverlay) close() {
+	n.active = false
+	n.input.Blur()
+}
+
+// save stores the note (empty clears it). The note takes effect even if
+// the state file can't be written; the error is shown and the overlay stays
+// open.
+func (n *noteOverlay) save(store *notes.Store) {
+	var err error
+	if n.byName {
+		err = store.SetName(n.name, n.input.Value())
+	} else {
+		err = store.SetPID(n.id, n.name, n.input.Value())
+	}
+	if err != nil {
+		n.err = err
+		return
+	}
+	n.close()
+}
+
+// update handles a key while the overlay is open. saved reports that the
+// note changed, so the table needs annotating again.
+func (n *noteOverlay) update(msg tea.KeyMsg, store *notes.Store) (cmd tea.Cmd, saved bool) {
+	switch msg.String() {
+	case "enter":
+		n.save(store)
+		return nil, true
+	case "esc":
+		n.close()
+		return nil, false
+	}
+	n.input, cmd = n.input.Update(msg)
+	return cmd, false
+}
+
+func (n *noteOverlay) render(width, height int) string {
+	boxW := 52
+	if boxW > width-4 {
+		boxW = width - 4
+	}
+
+	target := fmt.Sprintf("PID %d (%s)", n.id.PID, n.name)
+	if n.byName {
+		target = "every " + n.name + " process"
+	}
+	title := styleSortIndicator.Render(" Note ")
+	content := styleDetailLabel.Render("Note on "+target+":") + "\n\n"
+	content += "  " + n.input.View() + "\n\n"
+	content += styleDetailLabel.Render("  Enter to save (empty clears), Esc to cancel")
+	if n.err != nil {
+		content += "\n" + styleAlertTag.Render("  Not saved to disk: "+n.err.Error())
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorAccent).
+		Width(boxW).
+		Padding(1, 2).
+		Render(title + "\n\n" + content)
+
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// annotateNotes fills in the operator notes on a snapshot's processes.
+func annotateNotes(snap *model.Snapshot, store *notes.Store) {
+	for i := range snap.Processes {
+		p := &snap.Processes[i]
+		p.Note = store.Lookup(p.ID(), p.Name)
+	}
+	for i := range snap.Exited {
+		p := &snap.Exited[i]
+		p.Note = store.Lookup(p.ID(), p.Name)
+	}
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// configured reports whether any alert rule is set.
+func (a *alertOverlay) configured() bool {
+	return a.threshold > 0 || len(a.rules) > 0 || len(a.egress) > 0 || a.watch != nil
+}
+
+// renderPlaybackBar renders the playback timeline under the header: the
+// position in the recording with a ▲ where an alert fired, then how many
+// fired and the latest. The alert rules run against each frame as it
+// plays, so playback doubles as a look back at the thresholds.
+func (m Model) renderPlaybackBar(width int) string {
+	start, end := m.player.TimeRange()
+	barW := min(max(width/3, 10), 60)
+	span := end.Sub(start)
+	colAt := func(t time.Time) int {
+		if span <= 0 {
+			return 0
+		}
+		return int(float64(t.Sub(start)) / float64(span) * float64(barW-1))
+	}
+
+	at := m.snapshot.Timestamp
+	posCol := -1
+	if m.player.Position() >= 0 && !at.IsZero() {
+		posCol = colAt(at)
+	} else {
+		at = start
+	}
+	fired := make(map[int]bool)
+	for _, e := range m.alert.log {
+		if !e.at.Before(start) && !e.at.After(end) {
+			fired[colAt(e.at)] = true
+		}
+	}
+
+	var bar strings.Builder
+	for c := 0; c < barW; c++ {
+		switch {
+		case c == posCol:
+			bar.WriteString(styleScrollThumb.Render("●"))
+		case fired[c]:
+			bar.WriteString(styleAlertTag.Render("▲"))
+		case c < posCol:
+			bar.WriteString(styleScrollThumb.Render("━"))
+		default:
+			bar.WriteString(styleScrollTrack.Render("─"))
+		}
+	}
+
+	line := styleDetailLabel.Render(" ⏵ ") + styleHeaderValue.Render(at.Format("15:04:05")) + " " +
+		bar.String() + " " + styleDetailLabel.Render(end.Format("15:04:05")) + "  "
+	switch n := len(m.alert.log); {
+	case !m.alert.configured() && n == 0:
+		line += styleDetailLabel.Render("no alert rules (A sets one)")
+	case n == 0:
+		line += styleDetailLabel.Render("no alerts")
+	default:
+		last := m.alert.log[n-1]
+		line += styleAlertTag.Render(fmt.Sprintf("⚠ %d", n)) + " " +
+			styleDetailLabel.Render(last.at.Format("15:04:05")+" "+last.text)
+	}
+	return ansi.Truncate(line, width, "…")
+}
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/recorder"
+)
+
+func TestPlaybackAlerts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.ssrec")
+	rec, err := recorder.NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, rate := range []float64{10, 5000, 5000, 10, 8000, 10} {
+		snap := model.Snapshot{
+			Timestamp: start.Add(time.Duration(i) * time.Millisecond),
+			Processes: []model.ProcessSummary{{PID: 10, Name: "curl", DownRate: rate}},
+		}
+		if err := rec.Write(snap); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	player, err := recorder.NewPlayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(nil)
+	m.SetPlayback(player, "alerts.ssrec")
+	m.width, m.height = 120, 20
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "no alert rules") {
+		t.Errorf("timeline should say no rules are set:\n%s", view)
+	}
+
+	m.alert.threshold = 1024
+	for snap := range player.Play() {
+		next, _ := m.Update(SnapshotMsg(snap))
+		m = next.(Model)
+	}
+
+	// Crossing twice fires twice, stamped with the recorded times
+	log := m.AlertLog()
+	if len(log) != 2 || !strings.HasPrefix(log[0], "2026-03-01 12:00:00  curl (pid 10) 4.9 KB/s > 1K/s") {
+		t.Fatalf("alert log = %q, want the two crossings at recorded times", log)
+	}
+	view := ansi.Strip(m.View())
+	if !strings.Contains(view, "⚠ 2") || !strings.Contains(view, "curl (pid 10) 7.8 KB/s") || !strings.Contains(view, "▲") {
+		t.Errorf("timeline should mark the alerts and show the latest:\n%s", view)
+	}
+}
@@ -0,0 +1,198 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/googlesky/sstop/internal/config"
+)
+
+// layoutPreset bundles a view, columns, sort and alert threshold, applied
+// together with an F-key.
+type layoutPreset struct {
+	name       string
+	view       ViewMode
+	sort       SortColumn
+	filter     string
+	topDest    bool
+	tree       bool
+	cumulative bool
+	tcpInfo    bool
+	alert      float64 // per-process alert threshold, 0 = off
+}
+
+// builtinPresets are bound to F1–F3 unless replaced by name in the config.
+var builtinPresets = []layoutPreset{
+	{
+		// Who is using the link right now, and where is it going
+		name:    "bandwidth triage",
+		view:    ViewProcessTable,
+		sort:    SortByRate,
+		topDest: true,
+		alert:   10 * 1024 * 1024,
+	},
+	{
+		// Who talks to whom: destinations first, process tree for spawned children
+		name:    "security watch",
+		view:    ViewRemoteHosts,
+		sort:    SortByConns,
+		topDest: true,
+		tree:    true,
+		tcpInfo: true,
+	},
+	{
+		// Per-container/service totals since start
+		name:       "container ops",
+		view:       ViewGroups,
+		sort:       SortByRate,
+		cumulative: true,
+	},
+}
+
+var presetViews = map[string]ViewMode{
+	"processes": ViewProcessTable,
+	"hosts":     ViewRemoteHosts,
+	"ports":     ViewListenPorts,
+	"groups":    ViewGroups,
+	"users":     ViewUsers,
+	"usage":     ViewUsage,
+	"countries": ViewCountries,
+	"graph":     ViewGraph,
+	"conns":     ViewConnections,
+	"ifaces":    ViewInterfaces,
+	"treemap":   ViewTreemap,
+	"flows":     ViewFlows,
+}
+
+var presetSorts = map[string]SortColumn{
+	"rate":  SortByRate,
+	"down":  SortByDown,
+	"up":    SortByUp,
+	"pid":   SortByPID,
+	"name":  SortByName,
+	"conns": SortByConns,
+	"avg":   SortByAvg,
+	"peak":  SortByPeak,
+}
+
+// buildPresets merges config presets into the built-ins: a config preset
+// with a built-in's name replaces it in place, others are appended.
+func buildPresets(cfg []config.Preset) ([]layoutPreset, error) {
+	presets := make([]layoutPreset, len(builtinPresets))
+	copy(presets, builtinPresets)
+
+	for i, c := range cfg {
+		p := layoutPreset{
+			name:       c.Name,
+			view:       presetViews[c.View], // "" → process table
+			sort:       presetSorts[c.Sort], // "" → rate
+			filter:     c.Filter,
+			topDest:    c.TopDest,
+			tree:       c.Tree,
+			cumulative: c.Cumulative,
+			tcpInfo:    c.TCPInfo,
+		}
+		if c.Alert != "" {
+			p.alert = parseSize(c.Alert)
+			if p.alert <= 0 {
+				return nil, fmt.Errorf("presets[%d]: invalid alert threshold %q", i, c.Alert)
+			}
+		}
+
+		replaced := false
+		for j := range presets {
+			if strings.EqualFold(presets[j].name, p.name) {
+				presets[j] = p
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			presets = append(presets, p)
+		}
+	}
+
+	if len(presets) > config.MaxPresets {
+		return nil, fmt.Errorf("%d presets defined, at most %d fit on F1–F12", len(presets), config.MaxPresets)
+	}
+	return presets, nil
+}
+
+// presetKeyIndex maps "f1".."f12" to a preset index.
+func presetKeyIndex(key string) (int, bool) {
+	if !strings.HasPrefix(key, "f") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(key[1:])
+	if err != nil || n < 1 || n > config.MaxPresets {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+// SetPresets installs layout presets from the config file alongside the
+// built-in ones.
+func (m *Model) SetPresets(cfg []config.Preset) error {
+	presets, err := buildPresets(cfg)
+	if err != nil {
+		return err
+	}
+	m.presets = presets
+	return nil
+}
+
+// applyPreset switches to the layout bundle at index i.
+func (m *Model) applyPreset(i int) {
+	if i < 0 || i >= len(m.presets) {
+		return
+	}
+	p := m.presets[i]
+
+	m.mode = p.view
+	switch p.view {
+	case ViewRemoteHosts:
+		m.remoteHosts.cursor, m.remoteHosts.offset = 0, 0
+		m.remoteHosts.scope = hostScope{}
+	case ViewListenPorts:
+		m.listenPorts.cursor, m.listenPorts.offset = 0, 0
+	case ViewGroups:
+		m.groups.cursor, m.groups.offset = 0, 0
+	case ViewUsers:
+		m.users.cursor, m.users.offset = 0, 0
+	case ViewUsage:
+		m.usage.cursor, m.usage.offset = 0, 0
+	case ViewCountries:
+		m.countries.cursor, m.countries.offset = 0, 0
+	case ViewGraph:
+		m.graph.cursor, m.graph.offset = 0, 0
+	case ViewConnections:
+		m.connections.cursor, m.connections.offset = 0, 0
+		m.connections.scope = connScope{}
+	case ViewInterfaces:
+		m.interfaces.cursor, m.interfaces.offset = 0, 0
+	case ViewTreemap:
+		m.treemap.cursor = 0
+	case ViewFlows:
+		m.flows.cursor = 0
+	}
+
+	m.table.sortCol, m.table.sortReverse = p.sort, false
+	m.table.filter = p.filter
+	m.searchInput.SetValue(p.filter)
+	m.table.showTopDest = p.topDest
+	m.table.treeMode = p.tree
+	m.cumulativeMode = p.cumulative
+	m.table.cumulativeMode = p.cumulative
+	m.detail.showTCP = p.tcpInfo
+	m.table.update(m.tableRows())
+
+	if p.alert > 0 {
+		m.alert.threshold = p.alert
+		clear(m.alert.alertTriggered)
+	} else if m.alert.threshold > 0 {
+		m.alert.disable()
+	}
+
+	m.activePreset = p.name
+}
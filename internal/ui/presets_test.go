@@ -0,0 +1,93 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/googlesky/sstop/internal/config"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestPresetKeyIndex(t *testing.T) {
+	tests := []struct {
+		key  string
+		want int
+		ok   bool
+	}{
+		{"f1", 0, true},
+		{"f12", 11, true},
+		{"f13", 0, false},
+		{"f0", 0, false},
+		{"x", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := presetKeyIndex(tt.key)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("presetKeyIndex(%q) = %d, %v; want %d, %v", tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBuildPresets(t *testing.T) {
+	presets, err := buildPresets([]config.Preset{
+		{Name: "Container Ops", View: "users", Sort: "conns"},
+		{Name: "db", View: "ports", Filter: "postgres", Alert: "5M"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(presets) != len(builtinPresets)+1 {
+		t.Fatalf("expected %d presets, got %d", len(builtinPresets)+1, len(presets))
+	}
+	// Replaced in place, keeping its F-key
+	if presets[2].view != ViewUsers || presets[2].sort != SortByConns {
+		t.Errorf("container ops not replaced: %+v", presets[2])
+	}
+	db := presets[3]
+	if db.view != ViewListenPorts || db.filter != "postgres" || db.alert != 5*1024*1024 {
+		t.Errorf("db preset wrong: %+v", db)
+	}
+}
+
+func TestBuildPresetsErrors(t *testing.T) {
+	if _, err := buildPresets([]config.Preset{{Name: "x", Alert: "fast"}}); err == nil {
+		t.Error("expected error for invalid alert threshold")
+	}
+
+	var many []config.Preset
+	for i := 0; i < config.MaxPresets; i++ {
+		many = append(many, config.Preset{Name: string(rune('a' + i))})
+	}
+	if _, err := buildPresets(many); err == nil {
+		t.Error("expected error when presets exceed F1–F12")
+	}
+}
+
+func TestApplyPreset(t *testing.T) {
+	m := New(nil)
+	m.table.update([]model.ProcessSummary{{PID: 1, Name: "nginx"}, {PID: 2, Name: "curl"}})
+
+	m.applyPreset(1) // security watch
+	if m.mode != ViewRemoteHosts || m.table.sortCol != SortByConns || !m.table.treeMode || !m.detail.showTCP {
+		t.Errorf("security watch not applied: mode=%v sort=%v tree=%v tcp=%v",
+			m.mode, m.table.sortCol, m.table.treeMode, m.detail.showTCP)
+	}
+	if m.alert.threshold != 0 {
+		t.Errorf("security watch should have no alert, got %v", m.alert.threshold)
+	}
+
+	m.applyPreset(0) // bandwidth triage
+	if m.mode != ViewProcessTable || m.table.treeMode || !m.table.showTopDest {
+		t.Error("bandwidth triage should reset tree mode and show TOP DEST")
+	}
+	if m.alert.threshold != 10*1024*1024 {
+		t.Errorf("bandwidth triage alert = %v, want 10M", m.alert.threshold)
+	}
+	if m.activePreset != "bandwidth triage" {
+		t.Errorf("activePreset = %q", m.activePreset)
+	}
+
+	m.applyPreset(7) // unbound key is a no-op
+	if m.activePreset != "bandwidth triage" {
+		t.Error("unbound preset should not change state")
+	}
+}
@@ -3,23 +3,43 @@ package ui
 import (
 	"fmt"
 	"net"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/hostfmt"
 	"github.com/googlesky/sstop/internal/model"
 )
 
 // processDetail manages the detail view for a single process.
 type processDetail struct {
 	pid        uint32
+	start      int64 // the process's model.ProcessID.Start, once seen
 	cursor     int
 	offset     int
 	viewHeight int
-	showDNS    bool // toggle between hostname and raw IP
+	window     listWindow // connection rows last drawn
+	showTCP    bool       // show RTT/retransmits/cwnd instead of SVC/AGE/TOTAL
+
+	history map[string]*connSamples // connID → recent rates, for the inspector
+	rates   model.RateSeries        // the process's rates, for the chart without collector history
 }
 
 func newProcessDetail(pid uint32) processDetail {
-	return processDetail{pid: pid, showDNS: true}
+	return processDetail{pid: pid}
+}
+
+// follows reports whether p, running as the view's PID, is the process the
+// view was opened on rather than a later one reusing the PID. The first
+// snapshot's process pins the view.
+func (d *processDetail) follows(p *model.ProcessSummary) bool {
+	id := p.ID()
+	if d.start == 0 {
+		d.start = id.Start
+		return true
+	}
+	return id.Same(model.ProcessID{PID: d.pid, Start: d.start})
 }
 
 func (d *processDetail) moveUp() {
@@ -54,52 +74,118 @@ func (d *processDetail) pageDown(maxIdx int) {
 	}
 }
 
-func (d *processDetail) toggleDNS() {
-	d.showDNS = !d.showDNS
+func (d *processDetail) toggleTCP() {
+	d.showTCP = !d.showTCP
 }
 
 // connColumnLayout computes dynamic column widths based on terminal width.
+// In TCP stats mode the SVC/AGE/TOTAL columns are replaced by RTT, RTT
+// variance, retransmits, congestion window and delivery rate.
 type connColumnLayout struct {
+	tcpInfo bool
+
 	protoW  int
 	localW  int
 	remoteW int
 	stateW  int
 	svcW    int
 	ageW    int
+	totalW  int
+	rttW    int
+	rttVarW int
+	retrW   int
+	cwndW   int
+	dlvrW   int
 	upW     int
 	downW   int
 }
 
-func computeConnLayout(width int) connColumnLayout {
+func computeConnLayout(width int, tcpInfo bool) connColumnLayout {
 	const (
 		protoW = 5
 		stateW = 10 // shortened to fit badges
 		svcW   = 6  // service name (e.g. HTTPS)
 		ageW   = 7
+		totalW = 6 // bytes transferred since first seen (FormatBytesCompact)
 		upW    = 10
 		downW  = 10
-		fixed  = protoW + stateW + svcW + ageW + upW + downW + 7 + 2 // 7 gaps between 8 columns + 2 indent
+		fixed  = protoW + stateW + svcW + ageW + totalW + upW + downW + 8 + 2 // 8 gaps between 9 columns + 2 indent
+
+		rttW     = 6 // formatRTT
+		rttVarW  = 6
+		retrW    = 5
+		cwndW    = 5
+		dlvrW    = 6                                                                               // FormatRateCompact
+		tcpFixed = protoW + stateW + rttW + rttVarW + retrW + cwndW + dlvrW + upW + downW + 10 + 2 // 10 gaps between 11 columns + 2 indent
 	)
 
+	lay := connColumnLayout{
+		tcpInfo: tcpInfo,
+		protoW:  protoW,
+		stateW:  stateW,
+		upW:     upW,
+		downW:   downW,
+	}
+
 	remaining := width - fixed
+	if tcpInfo {
+		remaining = width - tcpFixed
+		lay.rttW, lay.rttVarW, lay.retrW, lay.cwndW, lay.dlvrW = rttW, rttVarW, retrW, cwndW, dlvrW
+	} else {
+		lay.svcW, lay.ageW, lay.totalW = svcW, ageW, totalW
+	}
 	if remaining < 30 {
 		remaining = 30
 	}
 
 	// REMOTE gets 60%, LOCAL gets 40% (remote hosts are typically longer)
-	remoteW := remaining * 60 / 100
-	localW := remaining - remoteW
+	lay.remoteW = remaining * 60 / 100
+	lay.localW = remaining - lay.remoteW
 
-	return connColumnLayout{
-		protoW:  protoW,
-		localW:  localW,
-		remoteW: remoteW,
-		stateW:  stateW,
-		svcW:    svcW,
-		ageW:    ageW,
-		upW:     upW,
-		downW:   downW,
+	return lay
+}
+
+// midW returns the width of the mode-dependent columns between STATE and
+// UP/s, including their trailing gaps.
+func (l connColumnLayout) midW() int {
+	if l.tcpInfo {
+		return l.rttW + l.rttVarW + l.retrW + l.cwndW + l.dlvrW + 5
 	}
+	return l.svcW + l.ageW + l.totalW + 3
+}
+
+// padCells right-pads s with spaces to w terminal cells. Unlike %-*s it
+// counts display width, so badges with wide glyphs (⚡, ⏳) line up.
+func padCells(s string, w int) string {
+	if n := lipgloss.Width(s); n < w {
+		return s + strings.Repeat(" ", w-n)
+	}
+	return s
+}
+
+// unattributedLines explains, for the kernel/unknown pseudo-process, why
+// no process could be found for its sockets: a count per reason.
+func unattributedLines(proc *model.ProcessSummary, width int) []string {
+	if proc.PID != model.UnattributedPID || len(proc.Unattributed) == 0 {
+		return nil
+	}
+	reasons := make([]string, 0, len(proc.Unattributed))
+	for r := range proc.Unattributed {
+		reasons = append(reasons, r)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		a, b := proc.Unattributed[reasons[i]], proc.Unattributed[reasons[j]]
+		if a != b {
+			return a > b
+		}
+		return reasons[i] < reasons[j]
+	})
+	lines := []string{styleDetailLabel.Render("  No owning process was found for these sockets:")}
+	for _, r := range reasons {
+		line := fmt.Sprintf("  %6d  %s", proc.Unattributed[r], model.UnattributedReason(r))
+		lines = append(lines, styleDetailLabel.Render(Truncate(line, width)))
+	}
+	return lines
 }
 
 // stateBadge returns a compact badge with icon for a TCP state.
@@ -132,25 +218,35 @@ func stateBadge(s model.SocketState) string {
 	}
 }
 
-func (d *processDetail) render(proc *model.ProcessSummary, width, height int) string {
+func (d *processDetail) render(proc *model.ProcessSummary, hosts hostfmt.Style, width, height int) string {
 	if proc == nil {
 		return styleDetailLabel.Render("  Process not found")
 	}
 
 	d.viewHeight = height
-	lay := computeConnLayout(width)
+	d.window = listWindow{}
 
 	var lines []string
 
 	// Process info header
+	pid := fmt.Sprint(proc.PID)
+	if proc.PID == model.UnattributedPID {
+		pid = "-"
+	}
 	infoLine := lipgloss.JoinHorizontal(lipgloss.Center,
 		styleTitle.Render(fmt.Sprintf(" %s", proc.Name)),
-		styleDetailLabel.Render(fmt.Sprintf("  PID: %d", proc.PID)),
+		styleDetailLabel.Render("  PID: "+pid),
 		"  ",
 		styleHeaderUp.Render("▲ "+FormatRate(proc.UpRate)),
 		"  ",
 		styleHeaderDown.Render("▼ "+FormatRate(proc.DownRate)),
 	)
+	if proc.Kind != "" {
+		infoLine += styleDetailLabel.Render("  type: ") + styleHeaderValue.Render(proc.Kind)
+	}
+	if proc.NetNS != "" {
+		infoLine += styleDetailLabel.Render("  netns: ") + styleHeaderValue.Render(proc.NetNS)
+	}
 	lines = append(lines, infoLine)
 
 	// Cmdline
@@ -159,6 +255,8 @@ func (d *processDetail) render(proc *model.ProcessSummary, width, height int) st
 		lines = append(lines, styleDetailLabel.Render("  "+cmdline))
 	}
 
+	lines = append(lines, unattributedLines(proc, width)...)
+
 	lines = append(lines, styleBorder.Render(strings.Repeat("─", width)))
 
 	// Listening ports
@@ -179,24 +277,19 @@ func (d *processDetail) render(proc *model.ProcessSummary, width, height int) st
 	// Connections table
 	if len(proc.Connections) > 0 {
 		lines = append(lines, styleTitle.Render(
-			fmt.Sprintf("  Connections (%d)", len(proc.Connections)),
+			fmt.Sprintf("  Connections (%s)", FormatCount(proc.ConnCount)),
 		))
 
-		// Connection table header with dynamic widths
-		connHeader := fmt.Sprintf("  %-*s %-*s %-*s %-*s %-*s %*s %*s %*s",
-			lay.protoW, "PROTO",
-			lay.localW, "LOCAL",
-			lay.remoteW, "REMOTE",
-			lay.stateW, "STATE",
-			lay.svcW, "SVC",
-			lay.ageW, "AGE",
-			lay.upW, "UP/s",
-			lay.downW, "DOWN/s")
-		lines = append(lines, styleTableHeader.Render(connHeader))
-
 		// Calculate scroll
-		headerLines := len(lines)
+		headerLines := len(lines) + 1
 		availRows := height - headerLines - 1
+		if proc.OmittedConns > 0 {
+			availRows-- // keep the "and N more" row visible
+		}
+		inspector := inspectorFits(availRows)
+		if inspector {
+			availRows -= inspectorLines
+		}
 		if availRows < 1 {
 			availRows = 1
 		}
@@ -220,17 +313,42 @@ func (d *processDetail) render(proc *model.ProcessSummary, width, height int) st
 		if end > len(proc.Connections) {
 			end = len(proc.Connections)
 		}
+		d.window = listWindow{offset: d.offset, rows: end - d.offset, total: len(proc.Connections)}
 
+		// Connection table header with dynamic widths
+		lay := computeConnLayout(rowsWidth(width, len(proc.Connections), availRows), d.showTCP)
+		connHeader := fmt.Sprintf("  %-*s %-*s %-*s %-*s ",
+			lay.protoW, "PROTO",
+			lay.localW, "LOCAL",
+			lay.remoteW, "REMOTE",
+			lay.stateW, "STATE")
+		if lay.tcpInfo {
+			connHeader += fmt.Sprintf("%*s %*s %*s %*s %*s ",
+				lay.rttW, "RTT",
+				lay.rttVarW, "±VAR",
+				lay.retrW, "RETR",
+				lay.cwndW, "CWND",
+				lay.dlvrW, "DLVR")
+		} else {
+			connHeader += fmt.Sprintf("%-*s %*s %*s ",
+				lay.svcW, "SVC",
+				lay.ageW, "AGE",
+				lay.totalW, "TOTAL")
+		}
+		connHeader += fmt.Sprintf("%*s %*s",
+			lay.upW, "UP/s",
+			lay.downW, "DOWN/s")
+		lines = append(lines, styleTableHeader.Render(connHeader))
+
+		var rows []string
 		for i := d.offset; i < end; i++ {
 			c := &proc.Connections[i]
 			selected := i == d.cursor
 
-			proto := c.Proto.String()
+			proto := protoLabel(c.Proto, c.SrcPort, c.DstPort)
 			local := formatConnAddr(c.SrcIP, c.SrcPort)
-			remote := d.formatRemote(c)
+			remote := formatRemote(c, hosts)
 			state := stateBadge(c.State)
-			svc := Truncate(c.Service, lay.svcW)
-			age := FormatAge(c.Age)
 			up := FormatRate(c.UpRate)
 			down := FormatRate(c.DownRate)
 
@@ -251,17 +369,19 @@ func (d *processDetail) render(proc *model.ProcessSummary, width, height int) st
 				svcStyle = rowStyle
 			}
 
-			row := lipgloss.JoinHorizontal(lipgloss.Top,
+			cells := []string{
 				rowStyle.Render(indicator),
-				rowStyle.Render(fmt.Sprintf("%-*s ", lay.protoW, proto)),
+				protoStyle(proto).Inherit(rowStyle).Render(fmt.Sprintf("%-*s ", lay.protoW, proto)),
 				rowStyle.Render(fmt.Sprintf("%-*s ", lay.localW, local)),
 				rowStyle.Render(fmt.Sprintf("%-*s ", lay.remoteW, remote)),
-				stateStyle.Render(fmt.Sprintf("%-*s ", lay.stateW, state)),
-				svcStyle.Render(fmt.Sprintf("%-*s ", lay.svcW, svc)),
-				styleDetailLabel.Render(fmt.Sprintf("%*s ", lay.ageW, age)),
+				stateStyle.Render(padCells(state, lay.stateW) + " "),
+			}
+			cells = append(cells, renderConnMid(c, lay, svcStyle)...)
+			cells = append(cells,
 				styleUpRate.Render(fmt.Sprintf("%*s ", lay.upW, up)),
 				styleDownRate.Render(fmt.Sprintf("%*s", lay.downW, down)),
 			)
+			row := lipgloss.JoinHorizontal(lipgloss.Top, cells...)
 
 			if selected {
 				rowWidth := lipgloss.Width(row)
@@ -270,7 +390,19 @@ func (d *processDetail) render(proc *model.ProcessSummary, width, height int) st
 				}
 			}
 
-			lines = append(lines, row)
+			rows = append(rows, row)
+		}
+		lines = append(lines, withScrollbar(rows, d.window, width)...)
+
+		if proc.OmittedConns > 0 {
+			lines = append(lines, renderOmittedConns(proc, lay))
+		}
+		if inspector {
+			// Keep the inspector at the bottom when the table is short
+			for i := end - d.offset; i < availRows; i++ {
+				lines = append(lines, "")
+			}
+			lines = append(lines, d.renderInspector(&proc.Connections[d.cursor], hosts, width)...)
 		}
 	} else if len(proc.ListenPorts) == 0 {
 		lines = append(lines, styleDetailLabel.Render("  No active connections"))
@@ -279,10 +411,58 @@ func (d *processDetail) render(proc *model.ProcessSummary, width, height int) st
 	return strings.Join(lines, "\n")
 }
 
-// formatRemote formats the remote address, preferring hostname when showDNS is on.
-func (d *processDetail) formatRemote(c *model.Connection) string {
-	if d.showDNS && c.RemoteHost != "" {
-		return fmt.Sprintf("%s:%d", c.RemoteHost, c.DstPort)
+// renderConnMid renders the mode-dependent cells of a connection row:
+// SVC/AGE/TOTAL, or the TCP stats group when lay.tcpInfo is set.
+func renderConnMid(c *model.Connection, lay connColumnLayout, svcStyle lipgloss.Style) []string {
+	if !lay.tcpInfo {
+		return []string{
+			svcStyle.Render(fmt.Sprintf("%-*s ", lay.svcW, Truncate(c.Service, lay.svcW))),
+			styleDetailLabel.Render(fmt.Sprintf("%*s ", lay.ageW, FormatAge(c.Age))),
+			styleHeaderValue.Render(fmt.Sprintf("%*s ", lay.totalW, FormatBytesCompact(c.BytesUp+c.BytesDown))),
+		}
+	}
+
+	rtt, rttVar, retr, cwnd, dlvr := "-", "-", "-", "-", "-"
+	if c.TCP != nil {
+		rtt = formatRTT(c.TCP.RTT)
+		rttVar = formatRTT(c.TCP.RTTVar)
+		retr = Truncate(strconv.FormatUint(uint64(c.TCP.Retrans), 10), lay.retrW)
+		cwnd = Truncate(strconv.FormatUint(uint64(c.TCP.Cwnd), 10), lay.cwndW)
+		if c.TCP.DeliveryRate > 0 {
+			dlvr = FormatRateCompact(c.TCP.DeliveryRate)
+		}
+	}
+
+	retrStyle := styleDetailLabel
+	if c.TCP != nil && c.TCP.Retrans > 0 {
+		retrStyle = styleStateClosing
+	}
+	return []string{
+		styleHeaderValue.Render(fmt.Sprintf("%*s ", lay.rttW, rtt)),
+		styleDetailLabel.Render(fmt.Sprintf("%*s ", lay.rttVarW, rttVar)),
+		retrStyle.Render(fmt.Sprintf("%*s ", lay.retrW, retr)),
+		styleDetailLabel.Render(fmt.Sprintf("%*s ", lay.cwndW, cwnd)),
+		styleHeaderValue.Render(fmt.Sprintf("%*s ", lay.dlvrW, dlvr)),
+	}
+}
+
+// renderOmittedConns renders the aggregate row for connections dropped by
+// the collector's per-process cap, aligned with the UP/DOWN columns.
+func renderOmittedConns(proc *model.ProcessSummary, lay connColumnLayout) string {
+	labelW := 2 + lay.protoW + lay.localW + lay.remoteW + lay.stateW + 4 + lay.midW()
+	label := Truncate(fmt.Sprintf("  … and %s more", FormatCount(proc.OmittedConns)), labelW)
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		styleDetailLabel.Render(fmt.Sprintf("%-*s", labelW, label)),
+		styleUpRate.Render(fmt.Sprintf("%*s ", lay.upW, FormatRate(proc.OmittedUpRate))),
+		styleDownRate.Render(fmt.Sprintf("%*s", lay.downW, FormatRate(proc.OmittedDownRate))),
+	)
+}
+
+// formatRemote formats the remote address: the host named in the style
+// (d) with the port, or the address when it has no name.
+func formatRemote(c *model.Connection, hosts hostfmt.Style) string {
+	if name := hosts.Name(c.RemoteHost); name != "" {
+		return fmt.Sprintf("%s:%d", name, c.DstPort)
 	}
 	return formatConnAddr(c.DstIP, c.DstPort)
 }
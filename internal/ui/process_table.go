@@ -2,10 +2,14 @@ package ui
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/hostfmt"
 	"github.com/googlesky/sstop/internal/model"
 )
 
@@ -19,11 +23,13 @@ const (
 	SortByPID                     // PID
 	SortByName                    // process name
 	SortByConns                   // connection count
+	SortByAvg                     // total bandwidth averaged over the last minute
+	SortByPeak                    // highest total bandwidth in the last minute
 	sortColumnCount
 )
 
 var sortColumnNames = [...]string{
-	"RATE", "DOWN", "UP", "PID", "NAME", "CONNS",
+	"RATE", "DOWN", "UP", "PID", "NAME", "CONNS", "AVG", "PEAK",
 }
 
 func (s SortColumn) String() string {
@@ -33,18 +39,39 @@ func (s SortColumn) String() string {
 	return "?"
 }
 
+// descending reports whether the column sorts highest first unless
+// reversed; PID and NAME sort ascending.
+func (s SortColumn) descending() bool {
+	return s != SortByPID && s != SortByName
+}
+
 // processTable manages the process list view state.
 type processTable struct {
 	cursor         int
 	offset         int // scroll offset
 	sortCol        SortColumn
+	sortReverse    bool // flip sortCol's natural direction
 	filter         string
 	processes      []model.ProcessSummary
 	filtered       []model.ProcessSummary
 	viewHeight     int
+	window         listWindow // rows last drawn
 	cumulativeMode bool
 	treeMode       bool
 	treePrefix     map[uint32]string // PID → tree drawing prefix
+	treeParents    map[uint32]bool   // PIDs with children in the tree, shown or not
+	collapsed      map[uint32]bool   // PIDs whose subtrees are collapsed
+	treeHidden     map[uint32]int    // collapsed PID → descendants rolled into its row
+	showTopDest    bool              // show the optional TOP DEST column
+	columns        []tableColumn     // [ui] columns; nil = defaultColumns
+	heat           bool              // shade rate cells instead of drawing bars
+	hosts          hostfmt.Style     // how TOP DEST names hosts
+	idle           idleTracker       // polls each process has been at zero rate
+	idleMode       idleMode          // z: show, dim or hide idle processes
+	idleHidden     int               // idle processes left out by idleHide
+	marks          markSet           // V: select mode and the marked rows
+	base           *baseline         // Z: what the delta columns compare with
+	clock          func() time.Time  // for ages; nil = time.Now (SetTestMode)
 }
 
 func newProcessTable() processTable {
@@ -55,6 +82,7 @@ func newProcessTable() processTable {
 
 func (t *processTable) update(processes []model.ProcessSummary) {
 	t.processes = processes
+	t.pruneMarks()
 	t.applyFilterAndSort()
 
 	// Keep cursor in bounds
@@ -80,18 +108,27 @@ func (t *processTable) applyFilterAndSort() {
 			}
 		}
 	}
+	t.idleHidden = 0
+	if t.idleMode == idleHide {
+		n := len(t.filtered)
+		t.filtered = slices.DeleteFunc(t.filtered, func(p model.ProcessSummary) bool { return t.idle.idle(&p) })
+		t.idleHidden = n - len(t.filtered)
+	}
 
 	// Sort
 	sort.SliceStable(t.filtered, func(i, j int) bool {
 		a, b := &t.filtered[i], &t.filtered[j]
+		if t.sortReverse {
+			a, b = b, a
+		}
 		if t.cumulativeMode {
 			switch t.sortCol {
-			case SortByRate:
-				return (a.CumUp + a.CumDown) > (b.CumUp + b.CumDown)
+			case SortByRate, SortByAvg, SortByPeak:
+				return (a.ProgramCumUp() + a.ProgramCumDown()) > (b.ProgramCumUp() + b.ProgramCumDown())
 			case SortByDown:
-				return a.CumDown > b.CumDown
+				return a.ProgramCumDown() > b.ProgramCumDown()
 			case SortByUp:
-				return a.CumUp > b.CumUp
+				return a.ProgramCumUp() > b.ProgramCumUp()
 			case SortByPID:
 				return a.PID < b.PID
 			case SortByName:
@@ -115,6 +152,10 @@ func (t *processTable) applyFilterAndSort() {
 			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
 		case SortByConns:
 			return a.ConnCount > b.ConnCount
+		case SortByAvg:
+			return avgRate(a) > avgRate(b)
+		case SortByPeak:
+			return peakRate(a) > peakRate(b)
 		default:
 			return false
 		}
@@ -124,6 +165,24 @@ func (t *processTable) applyFilterAndSort() {
 	t.buildTree()
 }
 
+// avgRate is the process's rate averaged over the last minute, its current
+// rate when there is no history (e.g. recordings made before it was kept).
+func avgRate(p *model.ProcessSummary) float64 {
+	if p.AvgRate == 0 {
+		return p.UpRate + p.DownRate
+	}
+	return p.AvgRate
+}
+
+// peakRate is the process's highest rate in the last minute, falling back
+// like avgRate.
+func peakRate(p *model.ProcessSummary) float64 {
+	if p.PeakRate == 0 {
+		return p.UpRate + p.DownRate
+	}
+	return p.PeakRate
+}
+
 // treeNode represents a process in the tree with its indentation info.
 type treeNode struct {
 	proc   model.ProcessSummary
@@ -162,6 +221,26 @@ func (t *processTable) buildTree() {
 	// DFS to build tree-ordered list
 	result := make([]model.ProcessSummary, 0, len(t.filtered))
 	treeInfo := make(map[uint32]string) // PID → prefix string
+	hidden := make(map[uint32]int)
+
+	// rollUp adds pid's descendants to row, returning how many there were
+	var rollUp func(pid uint32, row *model.ProcessSummary) int
+	rollUp = func(pid uint32, row *model.ProcessSummary) int {
+		n := 0
+		for _, kid := range children[pid] {
+			k := byPID[kid]
+			row.UpRate += k.UpRate
+			row.DownRate += k.DownRate
+			row.CumUp += k.CumUp
+			row.CumDown += k.CumDown
+			row.CarriedUp += k.CarriedUp
+			row.CarriedDown += k.CarriedDown
+			row.ConnCount += k.ConnCount
+			row.ListenCount += k.ListenCount
+			n += 1 + rollUp(kid, row)
+		}
+		return n
+	}
 
 	var walk func(pid uint32, depth int, prefix string, isLast bool)
 	walk = func(pid uint32, depth int, prefix string, isLast bool) {
@@ -180,10 +259,18 @@ func (t *processTable) buildTree() {
 			}
 		}
 		treeInfo[pid] = nodePrefix
+
+		// A collapsed subtree shows as its root, with the rest rolled in
+		kids := children[pid]
+		if t.collapsed[pid] && len(kids) > 0 {
+			row := *p
+			hidden[pid] = rollUp(pid, &row)
+			result = append(result, row)
+			return
+		}
 		result = append(result, *p)
 
 		// Walk children
-		kids := children[pid]
 		childPrefix := prefix
 		if depth > 0 {
 			if isLast {
@@ -203,13 +290,81 @@ func (t *processTable) buildTree() {
 
 	t.filtered = result
 	t.treePrefix = treeInfo
+	t.treeHidden = hidden
+	t.treeParents = make(map[uint32]bool, len(children))
+	for pid := range children {
+		t.treeParents[pid] = true
+	}
+}
+
+// collapseSelected collapses the selected process's subtree. On a process
+// with none to collapse, it collapses the parent's and selects the parent.
+func (t *processTable) collapseSelected() {
+	sel := t.selected()
+	if sel == nil {
+		return
+	}
+	pid := sel.PID
+	if !t.treeParents[pid] || t.collapsed[pid] {
+		if t.treePrefix[pid] == "" {
+			return // a root
+		}
+		pid = sel.PPID
+	}
+	if t.collapsed == nil {
+		t.collapsed = make(map[uint32]bool)
+	}
+	t.collapsed[pid] = true
+	t.applyFilterAndSort()
+	t.selectPID(pid)
+}
+
+// expandSelected expands the selected process's collapsed subtree.
+func (t *processTable) expandSelected() {
+	if sel := t.selected(); sel != nil && t.collapsed[sel.PID] {
+		delete(t.collapsed, sel.PID)
+		t.applyFilterAndSort()
+	}
+}
+
+// selectPID moves the cursor to pid's row, if shown.
+func (t *processTable) selectPID(pid uint32) {
+	for i := range t.filtered {
+		if t.filtered[i].PID == pid {
+			t.cursor = i
+			return
+		}
+	}
 }
 
 func (t *processTable) nextSort() {
 	t.sortCol = (t.sortCol + 1) % sortColumnCount
+	t.sortReverse = false
+	t.applyFilterAndSort()
+}
+
+// reverseSort flips the sort direction.
+func (t *processTable) reverseSort() {
+	t.sortReverse = !t.sortReverse
+	t.applyFilterAndSort()
+}
+
+// sortBy sorts by col in its natural direction, or flips the direction if
+// col is already the sort column.
+func (t *processTable) sortBy(col SortColumn) {
+	if col == t.sortCol {
+		t.reverseSort()
+		return
+	}
+	t.sortCol, t.sortReverse = col, false
 	t.applyFilterAndSort()
 }
 
+// sortDescending reports whether the table shows highest first.
+func (t *processTable) sortDescending() bool {
+	return t.sortCol.descending() != t.sortReverse
+}
+
 func (t *processTable) moveUp() {
 	if t.cursor > 0 {
 		t.cursor--
@@ -257,18 +412,21 @@ func (t *processTable) selected() *model.ProcessSummary {
 	return nil
 }
 
-// Column widths
-const (
-	colPidW    = 8
-	colUpW     = 12 // bar(5) + gap(1) + text(6)
-	colDownW   = 12 // bar(5) + gap(1) + text(6)
-	colConnsW  = 6
-	colListenW = 6
-	colGraphW  = 16 // sparkline width
-)
+// selectedLive returns the selected process, or nil if none is selected or
+// it has exited (nothing left to inspect or signal).
+func (t *processTable) selectedLive() *model.ProcessSummary {
+	if sel := t.selected(); sel != nil && sel.ExitedAt.IsZero() {
+		return sel
+	}
+	return nil
+}
+
+// colNameKeep is how much of the name a note/exited suffix must leave.
+const colNameKeep = 6
 
 func (t *processTable) render(width, height int, cumulativeMode bool) string {
 	t.viewHeight = height
+	t.window = listWindow{}
 
 	if len(t.filtered) == 0 {
 		return styleDetailLabel.Render("  No processes with network activity")
@@ -278,11 +436,11 @@ func (t *processTable) render(width, height int, cumulativeMode bool) string {
 	maxUp, maxDown := 0.0, 0.0
 	for i := range t.filtered {
 		if cumulativeMode {
-			if float64(t.filtered[i].CumUp) > maxUp {
-				maxUp = float64(t.filtered[i].CumUp)
+			if up := float64(t.filtered[i].ProgramCumUp()); up > maxUp {
+				maxUp = up
 			}
-			if float64(t.filtered[i].CumDown) > maxDown {
-				maxDown = float64(t.filtered[i].CumDown)
+			if down := float64(t.filtered[i].ProgramCumDown()); down > maxDown {
+				maxDown = down
 			}
 		} else {
 			if t.filtered[i].UpRate > maxUp {
@@ -294,17 +452,6 @@ func (t *processTable) render(width, height int, cumulativeMode bool) string {
 		}
 	}
 
-	// Dynamic name width: fill remaining space
-	// 6 gaps between 7 header columns + 2 indent
-	fixedW := colPidW + colGraphW + colUpW + colDownW + colConnsW + colListenW + 6 + 2
-	nameW := width - fixedW
-	if nameW < 10 {
-		nameW = 10
-	}
-
-	// Header
-	header := renderTableHeader(nameW, t.sortCol, cumulativeMode)
-
 	// Adjust scroll offset
 	if t.cursor < t.offset {
 		t.offset = t.cursor
@@ -316,68 +463,126 @@ func (t *processTable) render(width, height int, cumulativeMode bool) string {
 	if t.cursor >= t.offset+visibleRows {
 		t.offset = t.cursor - visibleRows + 1
 	}
-
-	var lines []string
-	lines = append(lines, header)
-
 	end := t.offset + visibleRows
 	if end > len(t.filtered) {
 		end = len(t.filtered)
 	}
+	t.window = listWindow{offset: t.offset, rows: end - t.offset, total: len(t.filtered)}
+	fullWidth := width
+	if t.window.overflows() {
+		width-- // the scrollbar's column
+	}
+
+	// Columns that fit; the name column takes the remaining space
+	lay := computeTableLayout(width, t.visibleColumns())
+	nameW := lay.nameW
+
+	// Header
+	header := renderTableHeader(lay, t.sortCol, t.sortDescending(), cumulativeMode)
+
+	var rows []string
 
 	for i := t.offset; i < end; i++ {
 		p := &t.filtered[i]
 		selected := i == t.cursor
 		isEvenRow := (i-t.offset)%2 == 1 // alternate rows for zebra striping
 
-		pid := fmt.Sprintf("%-*d", colPidW, p.PID)
 		displayName := p.Name
 		if t.treeMode {
 			if prefix, ok := t.treePrefix[p.PID]; ok && prefix != "" {
 				displayName = prefix + displayName
 			}
 		}
+		if t.marks.active {
+			mark := "○ "
+			if t.isMarked(p) {
+				mark = "● "
+			}
+			displayName = mark + displayName
+		}
+		exited := !p.ExitedAt.IsZero()
+		dimmed := t.idleMode == idleDim && t.idle.idle(p)
+		changed := t.base != nil && !exited && t.base.delta(p).changed()
+		var suffix string
+		if n := t.treeHidden[p.PID]; t.treeMode && n > 0 {
+			suffix = fmt.Sprintf(" +%d", n)
+		}
+		if p.Note != "" {
+			suffix += " [" + p.Note + "]"
+		}
+		if exited {
+			suffix += " (exited " + FormatAge(t.now().Sub(p.ExitedAt)) + " ago)"
+		}
 		name := Truncate(displayName, nameW)
+		if suffix != "" {
+			// The suffix gives way before the name drops below a few cells
+			base := Truncate(displayName, max(nameW-utf8.RuneCountInString(suffix), colNameKeep))
+			if room := nameW - utf8.RuneCountInString(base); room >= 4 {
+				name = base + Truncate(suffix, room)
+			}
+		}
 		name = fmt.Sprintf("%-*s", nameW, name)
 		graph := Sparkline(p.RateHistory, colGraphW)
 
 		// Bandwidth bars integrated with rate/cumulative text
-		barW := 5 // width for the bar portion
 		var upVal, downVal float64
 		var upText, downText string
 		if cumulativeMode {
-			upVal = float64(p.CumUp)
-			downVal = float64(p.CumDown)
-			upText = FormatBytesCompact(p.CumUp)
-			downText = FormatBytesCompact(p.CumDown)
+			upVal = float64(p.ProgramCumUp())
+			downVal = float64(p.ProgramCumDown())
+			upText = FormatBytesCompact(p.ProgramCumUp())
+			downText = FormatBytesCompact(p.ProgramCumDown())
 		} else {
 			upVal = p.UpRate
 			downVal = p.DownRate
 			upText = FormatRateCompact(p.UpRate)
 			downText = FormatRateCompact(p.DownRate)
 		}
-		upBar := BandwidthBar(upVal, maxUp, barW)
-		downBar := BandwidthBar(downVal, maxDown, barW)
-
-		conns := fmt.Sprintf("%*d", colConnsW, p.ConnCount)
-		listen := fmt.Sprintf("%*d", colListenW, p.ListenCount)
+		upBar := BandwidthBar(upVal, maxUp, colBarW)
+		downBar := downloadBar(downVal, maxDown, colBarW)
+		bars := lay.bars && !t.heat
+		if lay.bars && t.heat {
+			// The shaded cell takes the bar's place
+			upText = strings.Repeat(" ", colBarW+1) + upText
+			downText = strings.Repeat(" ", colBarW+1) + downText
+		}
 
 		var row string
 		if selected {
-			styledPid := styleTableRowSelected.Foreground(colorFgDim).Render(pid)
-			styledName := styleTableRowSelected.Foreground(colorFg).Bold(true).Render(name)
-			styledGraph := styleTableRowSelected.Foreground(colorCyan).Render(graph)
-			styledUp := styleTableRowSelected.Foreground(colorGreen).Render(upBar + " " + upText)
-			styledDown := styleTableRowSelected.Foreground(colorRed).Render(downBar + " " + downText)
-			styledConns := styleTableRowSelected.Foreground(colorCyan).Render(conns)
-			styledListen := styleTableRowSelected.Foreground(colorMagenta).Render(listen)
-			row = lipgloss.JoinHorizontal(lipgloss.Top,
-				styleTableRowSelected.Render("▸ "),
-				styledPid, " ", styledName, " ",
-				styledGraph, " ",
-				styledUp, " ", styledDown, " ",
-				styledConns, " ", styledListen,
-			)
+			sel := func(c lipgloss.TerminalColor) lipgloss.Style {
+				return styleTableRowSelected.Foreground(c)
+			}
+			gap := styleTableRowSelected.Render(" ")
+			cells := make([]string, 0, len(lay.cols))
+			for _, c := range lay.cols {
+				switch c {
+				case columnName:
+					nameColor := colorFg
+					if changed {
+						nameColor = colorYellow
+					}
+					cells = append(cells, sel(nameColor).Bold(true).Render(name))
+				case columnGraph:
+					cells = append(cells, sel(colorCyan).Render(graph))
+				case columnUp:
+					if bars {
+						upText = upBar + " " + upText
+					}
+					cells = append(cells, sel(colorGreen).Render(upText))
+				case columnDown:
+					if bars {
+						downText = downBar + " " + downText
+					}
+					cells = append(cells, sel(colorRed).Render(downText))
+				default:
+					color := columnSelColor(c)
+					if changed && slices.Contains(baselineColumns, c) {
+						color = colorYellow
+					}
+					cells = append(cells, sel(color).Render(t.columnText(c, p, lay.colW(c))))
+				}
+			}
+			row = styleTableRowSelected.Render("▸ ") + strings.Join(cells, gap)
 			// Pad to full width with selection background
 			rowWidth := lipgloss.Width(row)
 			if rowWidth < width {
@@ -391,40 +596,64 @@ func (t *processTable) render(width, height int, cumulativeMode bool) string {
 			}
 
 			// Rate-intensity colored bars
-			upBarStyled := barStyleUp(upVal, maxUp).Render(upBar)
-			downBarStyled := barStyleDown(downVal, maxDown).Render(downBar)
+			upBarStyle := barStyleUp(upVal, maxUp)
+			downBarStyle := barStyleDown(downVal, maxDown)
 
-			// Zebra striping
+			// Exited rows are greyed out: only the session totals still
+			// mean anything. So are idle ones when dimmed. Zebra striping
+			// on top.
 			bgStyle := lipgloss.NewStyle()
-			pidStyle := stylePID
-			nameStyle := styleProcessName
-			upTextStyle := styleUpRate
-			downTextStyle := styleDownRate
-			connsStyle := styleConnCount
-			listenStyle := styleListenCount
+			style := func(st lipgloss.Style) lipgloss.Style {
+				if exited || dimmed {
+					st = styleExited
+				}
+				if isEvenRow {
+					st = st.Background(colorZebraRow)
+				}
+				return st
+			}
 			if isEvenRow {
 				bgStyle = styleZebraRow
-				pidStyle = pidStyle.Background(colorZebraRow)
-				nameStyle = nameStyle.Background(colorZebraRow)
-				graphStyle = graphStyle.Background(colorZebraRow)
-				upTextStyle = upTextStyle.Background(colorZebraRow)
-				downTextStyle = downTextStyle.Background(colorZebraRow)
-				connsStyle = connsStyle.Background(colorZebraRow)
-				listenStyle = listenStyle.Background(colorZebraRow)
-				upBarStyled = barStyleUp(upVal, maxUp).Background(colorZebraRow).Render(upBar)
-				downBarStyled = barStyleDown(downVal, maxDown).Background(colorZebraRow).Render(downBar)
+			}
+			upTextStyle, downTextStyle := style(styleUpRate), style(styleDownRate)
+			if t.heat && !exited && !dimmed {
+				upTextStyle = heatStyle(upTextStyle, upVal, maxUp, hueGreen)
+				downTextStyle = heatStyle(downTextStyle, downVal, maxDown, hueRed)
 			}
 
-			row = lipgloss.JoinHorizontal(lipgloss.Top,
-				bgStyle.Render("  "),
-				pidStyle.Render(pid), bgStyle.Render(" "),
-				nameStyle.Render(name), bgStyle.Render(" "),
-				graphStyle.Render(graph), bgStyle.Render(" "),
-				upBarStyled, bgStyle.Render(" "), upTextStyle.Render(upText), bgStyle.Render(" "),
-				downBarStyled, bgStyle.Render(" "), downTextStyle.Render(downText), bgStyle.Render(" "),
-				connsStyle.Render(conns), bgStyle.Render(" "),
-				listenStyle.Render(listen),
-			)
+			gap := bgStyle.Render(" ")
+			cells := make([]string, 0, len(lay.cols))
+			for _, c := range lay.cols {
+				switch c {
+				case columnName:
+					nameStyle := styleProcessName
+					if changed {
+						nameStyle = styleBaselineChange.Bold(true)
+					}
+					cells = append(cells, style(nameStyle).Render(name))
+				case columnGraph:
+					cells = append(cells, style(graphStyle).Render(graph))
+				case columnUp:
+					cell := upTextStyle.Render(upText)
+					if bars {
+						cell = style(upBarStyle).Render(upBar) + gap + cell
+					}
+					cells = append(cells, cell)
+				case columnDown:
+					cell := downTextStyle.Render(downText)
+					if bars {
+						cell = style(downBarStyle).Render(downBar) + gap + cell
+					}
+					cells = append(cells, cell)
+				default:
+					st := columnStyle(c)
+					if changed && slices.Contains(baselineColumns, c) {
+						st = styleBaselineChange
+					}
+					cells = append(cells, style(st).Render(t.columnText(c, p, lay.colW(c))))
+				}
+			}
+			row = bgStyle.Render("  ") + strings.Join(cells, gap)
 
 			// Pad zebra rows to full width
 			if isEvenRow {
@@ -435,63 +664,78 @@ func (t *processTable) render(width, height int, cumulativeMode bool) string {
 			}
 		}
 
-		lines = append(lines, row)
+		rows = append(rows, row)
 	}
 
+	lines := append([]string{header}, withScrollbar(rows, t.window, fullWidth)...)
 	return strings.Join(lines, "\n")
 }
 
-func renderTableHeader(nameW int, sortCol SortColumn, cumulativeMode bool) string {
+// formatTopDest formats the dominant destination as "US host", the host
+// named in the style, or "-" if none.
+func formatTopDest(p *model.ProcessSummary, hosts hostfmt.Style) string {
+	if p.TopDest == "" {
+		return "-"
+	}
+	dest := hosts.Format(p.TopDest, p.TopDestIP)
+	if p.TopDestCountry != "" {
+		return p.TopDestCountry + " " + dest
+	}
+	return dest
+}
+
+func renderTableHeader(lay tableLayout, sortCol SortColumn, sortDesc, cumulativeMode bool) string {
 	upHeader, downHeader := "UPLOAD/s", "DOWNLOAD/s"
-	if cumulativeMode {
-		upHeader = "UP TOTAL"
-		downHeader = "DN TOTAL"
-	}
-
-	cols := []struct {
-		name  string
-		width int
-		col   SortColumn
-		align int // 0=left, 1=right
-	}{
-		{"PID", colPidW, SortByPID, 0},
-		{"PROCESS", nameW, SortByName, 0},
-		{"GRAPH", colGraphW, SortColumn(-1), 0},
-		{upHeader, colUpW, SortByUp, 1},
-		{downHeader, colDownW, SortByDown, 1},
-		{"CONNS", colConnsW, SortByConns, 1},
-		{"LISTEN", colListenW, SortColumn(-1), 1},
+	switch {
+	case cumulativeMode && lay.bars:
+		upHeader, downHeader = "UP TOTAL", "DN TOTAL"
+	case cumulativeMode:
+		upHeader, downHeader = "UP", "DN"
+	case !lay.bars:
+		// Room for the sort marker in a 6-wide column
+		upHeader, downHeader = "UP/s", "DN/s"
+	}
+	if lay.bars {
+		upHeader, downHeader = directionLabel(upHeader, true), directionLabel(downHeader, false)
 	}
 
 	var parts []string
 	parts = append(parts, "  ") // indent matching row "▸ "
 
-	for i, c := range cols {
-		var s string
-		if c.align == 1 {
-			// Right-aligned
-			label := c.name
-			if c.col == sortCol {
-				label = label + "▾"
-			}
-			formatted := fmt.Sprintf("%*s", c.width, label)
-			if c.col == sortCol {
-				s = styleSortIndicator.Render(formatted)
-			} else {
-				s = styleTableHeader.Render(formatted)
+	for i, c := range lay.cols {
+		spec := columnSpecs[c]
+		label := spec.header
+		sorted := spec.sort == sortCol
+		switch c {
+		case columnUp:
+			label = upHeader
+		case columnDown:
+			label = downHeader
+		case columnGraph:
+			// The history sorts are marked on the graph they're taken from
+			switch sortCol {
+			case SortByAvg:
+				label = "AVG 1m"
+			case SortByPeak:
+				label, sorted = "PEAK 1m", true
 			}
+		}
+		if sorted && sortDesc {
+			label = label + "▾"
+		} else if sorted {
+			label = label + "▴"
+		}
+		var formatted string
+		if spec.right {
+			formatted = fmt.Sprintf("%*s", lay.colW(c), label)
 		} else {
-			// Left-aligned
-			label := c.name
-			if c.col == sortCol {
-				label = label + "▾"
-			}
-			formatted := fmt.Sprintf("%-*s", c.width, label)
-			if c.col == sortCol {
-				s = styleSortIndicator.Render(formatted)
-			} else {
-				s = styleTableHeader.Render(formatted)
-			}
+			formatted = fmt.Sprintf("%-*s", lay.colW(c), label)
+		}
+		var s string
+		if sorted {
+			s = styleSortIndicator.Render(formatted)
+		} else {
+			s = styleTableHeader.Render(formatted)
 		}
 		if i > 0 {
 			parts = append(parts, " ")
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// quicPort is where QUIC (HTTP/3) runs. UDP to or from it is labelled
+// QUIC: sstop sees sockets, not packets, so this is a best guess.
+const quicPort = 443
+
+var styleProtoTCP, styleProtoUDP, styleProtoQUIC lipgloss.Style
+
+func buildProtoStyles() {
+	styleProtoTCP = lipgloss.NewStyle().Foreground(colorAccent)
+	styleProtoUDP = lipgloss.NewStyle().Foreground(colorMagenta)
+	styleProtoQUIC = lipgloss.NewStyle().Foreground(colorYellow)
+}
+
+// protoLabel is a connection's protocol as badged: TCP, UDP, or QUIC for
+// UDP on port 443.
+func protoLabel(proto model.Protocol, srcPort, dstPort uint16) string {
+	if proto == model.ProtoUDP && (srcPort == quicPort || dstPort == quicPort) {
+		return "QUIC"
+	}
+	return proto.String()
+}
+
+// protoStyle colours a protocol badge, so mixed TCP/UDP rows tell apart
+// at a glance.
+func protoStyle(label string) lipgloss.Style {
+	switch label {
+	case "UDP":
+		return styleProtoUDP
+	case "QUIC":
+		return styleProtoQUIC
+	}
+	return styleProtoTCP
+}
+
+// protoMatches reports whether a connection matches a proto: filter
+// value: "udp" matches all UDP, QUIC included; "quic" only UDP on 443.
+func protoMatches(want string, proto model.Protocol, srcPort, dstPort uint16) bool {
+	want = strings.ToUpper(strings.TrimSpace(want))
+	if want == "QUIC" {
+		return protoLabel(proto, srcPort, dstPort) == "QUIC"
+	}
+	return proto.String() == want
+}
+
+// protoTerm returns the protocol a term such as "proto:udp" asks for, ok
+// false for any other term or an expression.
+func (f Filter) protoTerm() (want string, ok bool) {
+	if f.logic != "" || f.key != "proto" || f.op != ":" {
+		return "", false
+	}
+	return f.value, true
+}
@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// rateChartLen is the number of samples the detail view records for the
+// chart when the collector keeps no per-process history (5 min at 1s).
+const rateChartLen = 300
+
+// RateHistorian is implemented by collectors that keep a longer upload
+// and download rate history per process than a snapshot carries.
+type RateHistorian interface {
+	ProcessRates(pid uint32) model.RateSeries
+}
+
+// recordRates appends the process's rates at t to the history the chart
+// falls back on. History builds up while the detail view is open.
+func (d *processDetail) recordRates(proc *model.ProcessSummary, t time.Time) {
+	r := &d.rates
+	r.Times = append(r.Times, t)
+	r.Up = append(r.Up, proc.UpRate)
+	r.Down = append(r.Down, proc.DownRate)
+	if n := len(r.Times) - rateChartLen; n > 0 {
+		r.Times, r.Up, r.Down = r.Times[n:], r.Up[n:], r.Down[n:]
+	}
+}
+
+// viewingProcess reports whether the detail view or its chart is open.
+func (m *Model) viewingProcess() bool {
+	return m.mode == ViewProcessDetail || m.mode == ViewRateChart
+}
+
+// processRates returns the chart's rate history for pid: the collector's
+// when it keeps one, else what the detail view recorded.
+func (m *Model) processRates(pid uint32) model.RateSeries {
+	if h, ok := m.collector.(RateHistorian); ok {
+		if s := h.ProcessRates(pid); len(s.Up) > 0 {
+			return s
+		}
+	}
+	return m.detail.rates
+}
+
+// rateStats is the min, average and max of values.
+func rateStats(values []float64) (lo, avg, hi float64) {
+	if len(values) == 0 {
+		return 0, 0, 0
+	}
+	lo = values[0]
+	sum := 0.0
+	for _, v := range values {
+		lo = min(lo, v)
+		hi = max(hi, v)
+		sum += v
+	}
+	return lo, sum / float64(len(values)), hi
+}
+
+// bucketMax fits values into width columns, each the largest of the
+// values it covers so short spikes stay visible.
+func bucketMax(values []float64, width int) []float64 {
+	if len(values) <= width || width <= 0 {
+		return values
+	}
+	out := make([]float64, width)
+	for i := range out {
+		from, to := i*len(values)/width, (i+1)*len(values)/width
+		for _, v := range values[from:to] {
+			out[i] = max(out[i], v)
+		}
+	}
+	return out
+}
+
+// renderRateChart renders the process's upload and download rates as two
+// stacked graphs with rate axes, a time axis and min/avg/max stats.
+func renderRateChart(proc *model.ProcessSummary, series model.RateSeries, width, height int) string {
+	if proc == nil {
+		return styleDetailLabel.Render("  Process not found")
+	}
+	n := len(series.Up)
+	var span time.Duration
+	if n > 1 {
+		span = series.Times[n-1].Sub(series.Times[0])
+	}
+	title := fmt.Sprintf("  %s (PID %d) — rates", proc.Name, proc.PID)
+	if span > 0 {
+		title += ", last " + FormatAge(span)
+	}
+	lines := []string{styleTitle.Render(Truncate(title, width))}
+	if n == 0 {
+		lines = append(lines, styleDetailLabel.Render("  No rate history yet"))
+		return strings.Join(lines, "\n")
+	}
+
+	// title, 2 stats lines and the time axis; the graphs share the rest
+	rows := max((height-4)/2, 1)
+
+	_, _, upPeak := rateStats(series.Up)
+	_, _, downPeak := rateStats(series.Down)
+	labelW := max(len(FormatRate(upPeak)), len(FormatRate(downPeak)))
+	graphW := max(width-labelW-5, 1) // indent + " ┤ "
+
+	section := func(arrow, name string, values []float64, style lipgloss.Style) {
+		lo, avg, hi := rateStats(values)
+		stats := fmt.Sprintf("  %s %-9s now %s   min %s   avg %s   max %s", arrow, name,
+			FormatRate(values[len(values)-1]), FormatRate(lo), FormatRate(avg), FormatRate(hi))
+		lines = append(lines, style.Render(Truncate(stats, width)))
+
+		for r, row := range tallSparkline(bucketMax(values, graphW), graphW, rows) {
+			label := ""
+			switch {
+			case r == 0:
+				label = FormatRate(hi)
+			case r == rows-1:
+				label = "0"
+			case r == rows/2:
+				label = FormatRate(hi * float64(rows-r) / float64(rows))
+			}
+			lines = append(lines,
+				styleDetailLabel.Render(fmt.Sprintf("  %*s ┤", labelW, label))+" "+style.Render(row))
+		}
+	}
+	section("▲", "upload", series.Up, styleHeaderUp)
+	section("▼", "download", series.Down, styleHeaderDown)
+
+	// Time axis under the graphs: the oldest sample, the midpoint and now
+	plotted := min(n, graphW)
+	axis := []rune(strings.Repeat(" ", graphW))
+	place := func(col int, label string) {
+		for i, r := range []rune(label) {
+			if col+i >= 0 && col+i < len(axis) {
+				axis[col+i] = r
+			}
+		}
+	}
+	start := graphW - plotted
+	if span > 0 {
+		place(start, "-"+FormatAge(span))
+		if mid := "-" + FormatAge(span/2); plotted >= 3*len(mid) {
+			place(start+plotted/2-len(mid)/2, mid)
+		}
+	}
+	place(graphW-3, "now")
+	lines = append(lines, styleDetailLabel.Render(strings.Repeat(" ", labelW+5)+string(axis)))
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// fakeRateHistorian serves a fixed rate history.
+type fakeRateHistorian struct {
+	series model.RateSeries
+}
+
+func (f fakeRateHistorian) SetInterval(time.Duration) {}
+
+func (f fakeRateHistorian) ProcessRates(pid uint32) model.RateSeries {
+	if pid != 10 {
+		return model.RateSeries{}
+	}
+	return f.series
+}
+
+func TestBucketMax(t *testing.T) {
+	got := bucketMax([]float64{1, 5, 2, 2, 9, 0}, 3)
+	if len(got) != 3 || got[0] != 5 || got[1] != 2 || got[2] != 9 {
+		t.Errorf("bucketMax = %v, want [5 2 9]", got)
+	}
+	if got := bucketMax([]float64{1, 2}, 10); len(got) != 2 {
+		t.Errorf("fewer values than columns should be kept as is: %v", got)
+	}
+}
+
+func TestRateChartView(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var series model.RateSeries
+	for i := 0; i < 300; i++ {
+		series.Times = append(series.Times, start.Add(time.Duration(i)*time.Second))
+		series.Up = append(series.Up, float64(i%10)*1024)
+		series.Down = append(series.Down, 2048)
+	}
+
+	m := New(nil)
+	m.SetCollector(fakeRateHistorian{series})
+	m.width, m.height = 100, 30
+	snap := model.Snapshot{Processes: []model.ProcessSummary{{PID: 10, Name: "curl", UpRate: 9 * 1024, DownRate: 2048}}}
+	next, _ := m.Update(SnapshotMsg(snap))
+	m = next.(Model)
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	if m.mode != ViewRateChart {
+		t.Fatalf("b in the detail view: mode %v, want the rate chart", m.mode)
+	}
+
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"curl (PID 10)", "last 4m59s", "▲ upload", "▼ download",
+		"min 0 B/s", "avg 4.5 KB/s", "max 9.0 KB/s", "-4m59s", "now"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("chart missing %q:\n%s", want, view)
+		}
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if w := ansi.StringWidth(line); w > m.width {
+			t.Errorf("line is %d cells wide, want at most %d: %q", w, m.width, line)
+		}
+	}
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.mode != ViewProcessDetail {
+		t.Errorf("esc: mode %v, want the detail view", m.mode)
+	}
+}
+
+func TestRateChartRecordedFallback(t *testing.T) {
+	// Without collector history (playback, remote) the chart shows what
+	// the detail view recorded while open
+	m := New(nil)
+	m.width, m.height = 100, 30
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	feed := func(up float64) {
+		snap := model.Snapshot{Timestamp: at, Processes: []model.ProcessSummary{{PID: 10, Name: "curl", UpRate: up}}}
+		next, _ := m.Update(SnapshotMsg(snap))
+		m = next.(Model)
+		at = at.Add(time.Second)
+	}
+	feed(0)
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	feed(1024)
+	feed(3072)
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+
+	if got := m.processRates(10); len(got.Up) != 2 || got.Up[1] != 3072 {
+		t.Fatalf("recorded rates = %+v, want the 2 snapshots since the detail view opened", got)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "max 3.0 KB/s") {
+		t.Errorf("chart should show the recorded samples:\n%s", view)
+	}
+}
@@ -2,17 +2,136 @@ package ui
 
 import (
 	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/hostfmt"
 	"github.com/googlesky/sstop/internal/model"
 )
 
 // remoteHostsView manages the remote hosts aggregation view.
 type remoteHostsView struct {
-	cursor     int
-	offset     int
-	viewHeight int
+	cursor      int
+	offset      int
+	viewHeight  int
+	window      listWindow // rows last drawn
+	showCountry bool       // show the optional COUNTRY/AS column
+	scope       hostScope  // drill-down from the Countries view
+	filter      string     // / in this view
+	byPrefix    bool       // IPv6 hosts grouped by /64
+}
+
+// hostScope limits the remote hosts view to one country or AS group.
+type hostScope struct {
+	active bool
+	byASN  bool
+	key    string // countryKey of the group
+	label  string
+}
+
+// filter returns the hosts in scope (all of them when no scope is set).
+func (s hostScope) filter(hosts []model.RemoteHostSummary) []model.RemoteHostSummary {
+	if !s.active {
+		return hosts
+	}
+	var out []model.RemoteHostSummary
+	for i := range hosts {
+		if countryKey(&hosts[i], s.byASN) == s.key {
+			out = append(out, hosts[i])
+		}
+	}
+	return out
+}
+
+// groupV6Prefixes merges global IPv6 hosts sharing a /64 into one row
+// named for the prefix, so a peer rotating privacy addresses reads as one
+// host. Rates and connections are summed and processes merged; a /64 with
+// a single address keeps its row. The merged rows have no IP, as no single
+// address stands for them.
+func groupV6Prefixes(hosts []model.RemoteHostSummary) []model.RemoteHostSummary {
+	type group struct {
+		row    int // index in out
+		prefix netip.Prefix
+		n      int
+	}
+	var (
+		out    []model.RemoteHostSummary
+		groups []*group
+		byKey  = make(map[netip.Prefix]*group)
+	)
+	for _, h := range hosts {
+		prefix, ok := v6Prefix(h.IP)
+		if !ok {
+			out = append(out, h)
+			continue
+		}
+		g := byKey[prefix]
+		if g == nil {
+			g = &group{row: len(out), prefix: prefix, n: 1}
+			byKey[prefix] = g
+			groups = append(groups, g)
+			out = append(out, h)
+			continue
+		}
+		r := &out[g.row]
+		if g.n == 1 {
+			r.Processes = slices.Clone(r.Processes)
+		}
+		g.n++
+		r.UpRate += h.UpRate
+		r.DownRate += h.DownRate
+		r.ConnCount += h.ConnCount
+		for _, p := range h.Processes {
+			if !slices.Contains(r.Processes, p) {
+				r.Processes = append(r.Processes, p)
+			}
+		}
+		if r.City != h.City {
+			r.City = ""
+		}
+	}
+
+	merged := false
+	for _, g := range groups {
+		if g.n > 1 {
+			out[g.row].Host = fmt.Sprintf("%s (%d addrs)", g.prefix, g.n)
+			out[g.row].IP = nil
+			merged = true
+		}
+	}
+	if merged {
+		sort.SliceStable(out, func(i, j int) bool {
+			return out[i].UpRate+out[i].DownRate > out[j].UpRate+out[j].DownRate
+		})
+	}
+	return out
+}
+
+// group returns the view's rows from its hosts, grouped by /64 when p is
+// on.
+func (v *remoteHostsView) group(hosts []model.RemoteHostSummary) []model.RemoteHostSummary {
+	if !v.byPrefix {
+		return hosts
+	}
+	return groupV6Prefixes(hosts)
+}
+
+// v6Prefix returns the /64 of a global IPv6 address. Link-local addresses
+// share fe80::/64 across every machine on the link, so they are left out.
+func v6Prefix(ip net.IP) (netip.Prefix, bool) {
+	if ip == nil || ip.To4() != nil || !ip.IsGlobalUnicast() {
+		return netip.Prefix{}, false
+	}
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return netip.Prefix{}, false
+	}
+	prefix, err := addr.Prefix(64)
+	return prefix, err == nil
 }
 
 func newRemoteHostsView() remoteHostsView {
@@ -65,14 +184,65 @@ func (v *remoteHostsView) goEnd(maxIdx int) {
 
 // Column widths for remote hosts table
 const (
-	rhUpW    = 12 // bar(5) + gap(1) + text(6)
-	rhDownW  = 12 // bar(5) + gap(1) + text(6)
-	rhConnsW = 6
-	rhProcsW = 20
+	rhUpW     = 12 // bar(5) + gap(1) + text(6)
+	rhDownW   = 12 // bar(5) + gap(1) + text(6)
+	rhConnsW  = 6
+	rhProcsW  = 20
+	rhGeoW    = 20 // optional COUNTRY/AS column: "🇺🇸 US GOOGLE"
+	rhHostMin = 15
 )
 
-func (v *remoteHostsView) render(hosts []model.RemoteHostSummary, width, height int) string {
+// hostsLayout is the set of remote hosts columns that fit the terminal.
+// Below the full width, columns drop in order: COUNTRY/AS, PROCESSES,
+// CONNS, then the bandwidth bars.
+type hostsLayout struct {
+	hostW int
+	geo   bool
+	procs bool
+	conns bool
+	bars  bool
+}
+
+func (l hostsLayout) rateW() int {
+	if l.bars {
+		return rhUpW
+	}
+	return colRateW
+}
+
+// fixedW is the width of everything except the HOST column.
+func (l hostsLayout) fixedW() int {
+	w := 2 + 1 + l.rateW() + 1 + l.rateW()
+	if l.geo {
+		w += rhGeoW + 1
+	}
+	if l.conns {
+		w += 1 + rhConnsW
+	}
+	if l.procs {
+		w += 1 + rhProcsW
+	}
+	return w
+}
+
+func computeHostsLayout(width int, showCountry bool) hostsLayout {
+	l := hostsLayout{geo: showCountry, procs: true, conns: true, bars: true}
+	for _, d := range []*bool{&l.geo, &l.procs, &l.conns, &l.bars} {
+		if width-l.fixedW() >= rhHostMin {
+			break
+		}
+		*d = false
+	}
+	l.hostW = width - l.fixedW()
+	if l.hostW < rhHostMin {
+		l.hostW = rhHostMin
+	}
+	return l
+}
+
+func (v *remoteHostsView) render(hosts []model.RemoteHostSummary, names hostfmt.Style, width, height int) string {
 	v.viewHeight = height
+	v.window = listWindow{}
 
 	if len(hosts) == 0 {
 		return styleDetailLabel.Render("  No remote host connections")
@@ -89,17 +259,6 @@ func (v *remoteHostsView) render(hosts []model.RemoteHostSummary, width, height
 		}
 	}
 
-	// Dynamic host width
-	// Layout: indent(2) + host + 4 gaps between 5 columns (HOST, UP, DOWN, CONNS, PROCS)
-	fixedW := 2 + rhUpW + rhDownW + rhConnsW + rhProcsW + 4
-	hostW := width - fixedW
-	if hostW < 15 {
-		hostW = 15
-	}
-
-	// Header
-	header := v.renderHeader(hostW)
-
 	// Scroll
 	if v.cursor < v.offset {
 		v.offset = v.cursor
@@ -119,56 +278,78 @@ func (v *remoteHostsView) render(hosts []model.RemoteHostSummary, width, height
 		v.cursor = 0
 	}
 
-	var lines []string
-	lines = append(lines, header)
-
 	end := v.offset + visibleRows
 	if end > len(hosts) {
 		end = len(hosts)
 	}
+	v.window = listWindow{offset: v.offset, rows: end - v.offset, total: len(hosts)}
+	fullWidth := width
+	if v.window.overflows() {
+		width-- // the scrollbar's column
+	}
+
+	// Columns that fit; the host column takes the remaining space
+	lay := computeHostsLayout(width, v.showCountry)
+	hostW := lay.hostW
+
+	// Header
+	header := v.renderHeader(lay)
+
+	var rows []string
 
 	for i := v.offset; i < end; i++ {
 		h := &hosts[i]
 		selected := i == v.cursor
 		isEvenRow := (i-v.offset)%2 == 1
 
-		hostName := h.Host
-		if hostName == "" && h.IP != nil {
-			hostName = h.IP.String()
-		}
+		hostName := names.Format(h.Host, h.IP)
 		if hostName == "" {
 			hostName = "unknown"
 		}
-		// Prepend country flag if available
-		if h.Country != "" {
+		// Prepend country flag if available (unless it has its own column)
+		if h.Country != "" && !lay.geo {
 			hostName = h.Country + " " + hostName
 		}
-		hostName = Truncate(hostName, hostW)
-		hostName = fmt.Sprintf("%-*s", hostW, hostName)
+		if h.City != "" {
+			hostName += " (" + h.City + ")"
+		}
+		// Flags are double-width: pad by cells, not runes
+		hostName = padCells(Truncate(hostName, hostW), hostW)
 
 		barW := 5
 		upBar := BandwidthBar(h.UpRate, maxUp, barW)
-		downBar := BandwidthBar(h.DownRate, maxDown, barW)
-		upText := FormatRateCompact(h.UpRate)   // always 6 chars
+		downBar := downloadBar(h.DownRate, maxDown, barW)
+		upText := FormatRateCompact(h.UpRate)     // always 6 chars
 		downText := FormatRateCompact(h.DownRate) // always 6 chars
 
 		conns := fmt.Sprintf("%*d", rhConnsW, h.ConnCount)
 		procs := Truncate(strings.Join(h.Processes, ","), rhProcsW)
 		procs = fmt.Sprintf("%-*s", rhProcsW, procs)
+		geo := padCells(Truncate(formatHostGeo(h), rhGeoW), rhGeoW)
 
 		var row string
 		if selected {
-			styledHost := styleTableRowSelected.Foreground(colorFg).Bold(true).Render(hostName)
-			styledUp := styleTableRowSelected.Foreground(colorGreen).Render(upBar + " " + upText)
-			styledDown := styleTableRowSelected.Foreground(colorRed).Render(downBar + " " + downText)
-			styledConns := styleTableRowSelected.Foreground(colorCyan).Render(conns)
-			styledProcs := styleTableRowSelected.Foreground(colorFgDim).Render(procs)
-			row = lipgloss.JoinHorizontal(lipgloss.Top,
-				styleTableRowSelected.Render("▸ "),
-				styledHost, " ",
-				styledUp, " ", styledDown, " ",
-				styledConns, " ", styledProcs,
-			)
+			sel := func(c lipgloss.TerminalColor) lipgloss.Style {
+				return styleTableRowSelected.Foreground(c)
+			}
+			gap := styleTableRowSelected.Render(" ")
+			row = styleTableRowSelected.Render("▸ ") +
+				sel(colorFg).Bold(true).Render(hostName) + gap
+			if lay.geo {
+				row += sel(colorFgDim).Render(geo) + gap
+			}
+			if lay.bars {
+				row += sel(colorGreen).Render(upBar+" "+upText) + gap +
+					sel(colorRed).Render(downBar+" "+downText)
+			} else {
+				row += sel(colorGreen).Render(upText) + gap + sel(colorRed).Render(downText)
+			}
+			if lay.conns {
+				row += gap + sel(colorCyan).Render(conns)
+			}
+			if lay.procs {
+				row += gap + sel(colorFgDim).Render(procs)
+			}
 			rowWidth := lipgloss.Width(row)
 			if rowWidth < width {
 				row += styleTableRowSelected.Render(strings.Repeat(" ", width-rowWidth))
@@ -180,6 +361,7 @@ func (v *remoteHostsView) render(hosts []model.RemoteHostSummary, width, height
 			downTextStyle := styleDownRate
 			connsStyle := styleConnCount
 			procsStyle := styleDetailLabel
+			geoStyle := styleDetailLabel
 			upBarStyled := barStyleUp(h.UpRate, maxUp).Render(upBar)
 			downBarStyled := barStyleDown(h.DownRate, maxDown).Render(downBar)
 
@@ -190,18 +372,28 @@ func (v *remoteHostsView) render(hosts []model.RemoteHostSummary, width, height
 				downTextStyle = downTextStyle.Background(colorZebraRow)
 				connsStyle = connsStyle.Background(colorZebraRow)
 				procsStyle = procsStyle.Background(colorZebraRow)
+				geoStyle = geoStyle.Background(colorZebraRow)
 				upBarStyled = barStyleUp(h.UpRate, maxUp).Background(colorZebraRow).Render(upBar)
 				downBarStyled = barStyleDown(h.DownRate, maxDown).Background(colorZebraRow).Render(downBar)
 			}
 
-			row = lipgloss.JoinHorizontal(lipgloss.Top,
-				bgStyle.Render("  "),
-				hostStyle.Render(hostName), bgStyle.Render(" "),
-				upBarStyled, bgStyle.Render(" "), upTextStyle.Render(upText), bgStyle.Render(" "),
-				downBarStyled, bgStyle.Render(" "), downTextStyle.Render(downText), bgStyle.Render(" "),
-				connsStyle.Render(conns), bgStyle.Render(" "),
-				procsStyle.Render(procs),
-			)
+			gap := bgStyle.Render(" ")
+			row = bgStyle.Render("  ") + hostStyle.Render(hostName) + gap
+			if lay.geo {
+				row += geoStyle.Render(geo) + gap
+			}
+			if lay.bars {
+				row += upBarStyled + gap + upTextStyle.Render(upText) + gap +
+					downBarStyled + gap + downTextStyle.Render(downText)
+			} else {
+				row += upTextStyle.Render(upText) + gap + downTextStyle.Render(downText)
+			}
+			if lay.conns {
+				row += gap + connsStyle.Render(conns)
+			}
+			if lay.procs {
+				row += gap + procsStyle.Render(procs)
+			}
 
 			if isEvenRow {
 				rowWidth := lipgloss.Width(row)
@@ -211,21 +403,47 @@ func (v *remoteHostsView) render(hosts []model.RemoteHostSummary, width, height
 			}
 		}
 
-		lines = append(lines, row)
+		rows = append(rows, row)
 	}
 
+	lines := append([]string{header}, withScrollbar(rows, v.window, fullWidth)...)
 	return strings.Join(lines, "\n")
 }
 
-func (v *remoteHostsView) renderHeader(hostW int) string {
-	title := styleTitle.Render("  Remote Hosts")
-	cols := lipgloss.JoinHorizontal(lipgloss.Top,
-		"  ",
-		styleTableHeader.Render(fmt.Sprintf("%-*s", hostW, "HOST")), " ",
-		styleTableHeader.Render(fmt.Sprintf("%*s", rhUpW, "UPLOAD/s")), " ",
-		styleTableHeader.Render(fmt.Sprintf("%*s", rhDownW, "DOWNLOAD/s")), " ",
-		styleTableHeader.Render(fmt.Sprintf("%*s", rhConnsW, "CONNS")), " ",
-		styleTableHeader.Render(fmt.Sprintf("%-*s", rhProcsW, "PROCESSES")),
-	)
+func (v *remoteHostsView) renderHeader(lay hostsLayout) string {
+	titleText := "  Remote Hosts"
+	if v.scope.active {
+		titleText += " — " + v.scope.label
+	}
+	if v.byPrefix {
+		titleText += " (IPv6 by /64)"
+	}
+	title := styleTitle.Render(titleText)
+
+	upHeader, downHeader := directionLabel("UPLOAD/s", true), directionLabel("DOWNLOAD/s", false)
+	if !lay.bars {
+		upHeader, downHeader = "UP/s", "DN/s"
+	}
+	cols := "  " + styleTableHeader.Render(fmt.Sprintf("%-*s", lay.hostW, "HOST")) + " "
+	if lay.geo {
+		cols += styleTableHeader.Render(fmt.Sprintf("%-*s", rhGeoW, "COUNTRY/AS")) + " "
+	}
+	cols += styleTableHeader.Render(fmt.Sprintf("%*s", lay.rateW(), upHeader)) + " " +
+		styleTableHeader.Render(fmt.Sprintf("%*s", lay.rateW(), downHeader))
+	if lay.conns {
+		cols += " " + styleTableHeader.Render(fmt.Sprintf("%*s", rhConnsW, "CONNS"))
+	}
+	if lay.procs {
+		cols += " " + styleTableHeader.Render(fmt.Sprintf("%-*s", rhProcsW, "PROCESSES"))
+	}
 	return title + "\n" + cols
 }
+
+// formatHostGeo formats the COUNTRY/AS cell: "🇺🇸 US GOOGLE", or "-".
+func formatHostGeo(h *model.RemoteHostSummary) string {
+	geo := strings.TrimSpace(h.Country + " " + h.ASOrg)
+	if geo == "" {
+		return "-"
+	}
+	return geo
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func v6Hosts() []model.RemoteHostSummary {
+	host := func(ip string, up float64, procs ...string) model.RemoteHostSummary {
+		return model.RemoteHostSummary{Host: ip, IP: net.ParseIP(ip), UpRate: up, ConnCount: 1, Processes: procs, CountryCode: "DE"}
+	}
+	return []model.RemoteHostSummary{
+		host("140.82.112.3", 5000, "git"),
+		host("2a02:1810:4d02:aa00:1c5e:9f0b:3e11:7a20", 3000, "firefox"),
+		host("2a02:1810:4d02:bb00::1", 2500, "ssh"),
+		host("2a02:1810:4d02:aa00:8d2:44f1:c0de:1", 2000, "firefox"),
+		host("fe80::1", 1500, "avahi"),
+		host("2a02:1810:4d02:aa00::53", 1000, "systemd-resolved"),
+		host("fe80::2", 500, "avahi"),
+	}
+}
+
+func TestGroupV6Prefixes(t *testing.T) {
+	hosts := v6Hosts()
+	got := groupV6Prefixes(hosts)
+
+	var names []string
+	for _, h := range got {
+		names = append(names, h.Host)
+	}
+	want := []string{"2a02:1810:4d02:aa00::/64 (3 addrs)", "140.82.112.3", "2a02:1810:4d02:bb00::1", "fe80::1", "fe80::2"}
+	if strings.Join(names, " | ") != strings.Join(want, " | ") {
+		t.Fatalf("rows = %q, want %q", names, want)
+	}
+	g := got[0]
+	if g.UpRate != 6000 || g.ConnCount != 3 || g.IP != nil || g.CountryCode != "DE" ||
+		strings.Join(g.Processes, ",") != "firefox,systemd-resolved" {
+		t.Errorf("group row = %+v", g)
+	}
+	if got[2].IP == nil {
+		t.Error("a /64 with one address should keep its IP")
+	}
+	if len(hosts[1].Processes) != 1 || hosts[1].UpRate != 3000 {
+		t.Errorf("grouping modified its input: %+v", hosts[1])
+	}
+}
+
+func TestRemoteHostsPrefixToggle(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 120, 30
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{RemoteHosts: v6Hosts()}))
+	m = next.(Model)
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'h'}})
+	if n := len(m.remoteHostRows()); n != 7 {
+		t.Fatalf("%d rows before grouping, want 7", n)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'p'}})
+	if n := len(m.remoteHostRows()); n != 5 {
+		t.Errorf("%d rows grouped, want 5", n)
+	}
+	if out := m.View(); !strings.Contains(out, "2a02:1810:4d02:aa00::/64 (3 addrs)") || !strings.Contains(out, "IPv6 by /64") {
+		t.Errorf("grouped view:\n%s", out)
+	}
+
+	// The filter applies to the addresses before they are grouped
+	m.setViewFilter("firefox")
+	if rows := m.remoteHostRows(); len(rows) != 1 || rows[0].Host != "2a02:1810:4d02:aa00::/64 (2 addrs)" {
+		t.Errorf("filtered rows = %+v", rows)
+	}
+}
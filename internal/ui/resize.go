@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// compactHeight is the terminal height below which the UI drops the
+	// multi-line header and overlay boxes for a one-line header and
+	// one-line prompts.
+	compactHeight = 10
+
+	// resizeSettle is how long the terminal size must hold still before
+	// the layout follows it; until then the last layout is clipped.
+	resizeSettle = 80 * time.Millisecond
+)
+
+// resizeSettledMsg fires resizeSettle after a resize; seq tells whether a
+// later resize superseded it.
+type resizeSettledMsg int
+
+// resize handles a terminal size change. The first size applies at once;
+// later ones are debounced so a window drag doesn't relayout per step.
+func (m Model) resize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
+	m.termWidth, m.termHeight = msg.Width, msg.Height
+	if m.width == 0 || m.height == 0 {
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	}
+	m.resizeSeq++
+	seq := m.resizeSeq
+	return m, tea.Tick(resizeSettle, func(time.Time) tea.Msg { return resizeSettledMsg(seq) })
+}
+
+// settleResize lays out for the terminal size once it stopped changing.
+func (m Model) settleResize(seq resizeSettledMsg) (tea.Model, tea.Cmd) {
+	if int(seq) == m.resizeSeq {
+		m.width, m.height = m.termWidth, m.termHeight
+	}
+	return m, nil
+}
+
+// fitTerminal clips a frame laid out for the previous size to the current
+// one while a resize settles, so lines never wrap into garbage.
+func (m Model) fitTerminal(frame string) string {
+	if m.termWidth == 0 || (m.termWidth >= m.width && m.termHeight >= m.height) {
+		return frame
+	}
+	lines := strings.Split(frame, "\n")
+	if len(lines) > m.termHeight {
+		lines = lines[:m.termHeight]
+	}
+	for i, line := range lines {
+		lines[i] = ansi.Truncate(line, m.termWidth, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// compact reports whether the terminal is too short for the full layout.
+func (m Model) compact() bool {
+	return m.height < compactHeight
+}
+
+// renderCompact renders a short terminal: a one-line header, as much of
+// the current view as fits, and the footer, or the open overlay as a
+// one-line prompt in its place.
+func (m Model) renderCompact() string {
+	lines := []string{m.compactHeader()}
+	if m.height > 2 {
+		// Content first: the footer shows where its list is scrolled to
+		contentHeight := m.height - 2
+		content := m.renderContent(contentHeight)
+		if m.staleAge > 0 {
+			content = greyOut(content)
+		}
+		body := strings.Split(content, "\n")
+		for len(body) < contentHeight {
+			body = append(body, "")
+		}
+		lines = append(lines, body[:contentHeight]...)
+	}
+	if m.height >= 2 {
+		bottom := m.compactOverlay()
+		if bottom == "" {
+			bottom = m.renderFooter()
+			if m.searching {
+				bottom = styleSearchPrompt.Render("Filter: ") + m.searchInput.View()
+			}
+		}
+		lines = append(lines, bottom)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// compactHeader is the header squeezed into one line: totals, process
+// count and any source or pause badges.
+func (m Model) compactHeader() string {
+	snap := m.snapshot
+	up, down := snap.TotalUp, snap.TotalDown
+	if m.activeIface != "" {
+		up, down = 0, 0
+		for _, iface := range snap.Interfaces {
+			if iface.Name == m.activeIface {
+				up, down = iface.SendRate, iface.RecvRate
+				break
+			}
+		}
+	}
+	parts := []string{
+		styleTitle.Render("sstop"),
+		styleHeaderUp.Render("▲ " + FormatRate(up)),
+		styleHeaderDown.Render("▼ " + FormatRate(down)),
+		styleHeaderValue.Render(fmt.Sprintf("%d procs", len(snap.Processes))),
+	}
+	if m.activeHost != "" {
+		parts = append(parts, styleFooterKey.Render("["+m.activeHost+"]"))
+	}
+	if m.paused {
+		parts = append(parts, stylePaused.Render(" PAUSED "))
+	}
+	if info := m.sourceInfoText(); info != "" {
+		parts = append(parts, stylePaused.Render(" "+info+" "))
+	}
+	return ansi.Truncate(strings.Join(parts, "  "), m.width, "…")
+}
+
+// compactOverlay renders the open overlay as a one-line prompt, "" when
+// none is open.
+func (m Model) compactOverlay() string {
+	var line string
+	switch {
+	case m.alert.active:
+		line = styleSortIndicator.Render("Alert above: ") + m.alert.input.View() + styleDetailLabel.Render(" /s  enter/esc")
+	case m.note.active:
+		line = styleSortIndicator.Render(fmt.Sprintf("Note on %s: ", m.note.name)) + m.note.input.View()
+	case m.kill.active:
+		line = m.kill.compactLine()
+	case m.block.active:
+		line = m.block.compactLine()
+	case m.limit.active:
+		line = m.limit.compactLine()
+	case m.journal.active:
+		line = m.journal.compactLine()
+	case m.filters.active:
+		line = m.filters.compactLine(m.savedFilters)
+	case m.showHelp:
+		line = styleDetailLabel.Render("Help needs at least ") + styleHeaderValue.Render(fmt.Sprint(compactHeight)) +
+			styleDetailLabel.Render(" rows; ? to close")
+	default:
+		return ""
+	}
+	return ansi.Truncate(line, m.width, "…")
+}
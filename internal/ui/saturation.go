@@ -0,0 +1,140 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/googlesky/sstop/internal/config"
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/notify"
+)
+
+// saturationRule alerts when an interface it covers stays above a
+// utilization, evaluated from the snapshot's interface stats each poll,
+// independently of which processes carry the traffic.
+type saturationRule struct {
+	rule      config.InterfaceRule
+	name      string
+	threshold float64 // utilization, 0–1
+	capacity  uint64  // bits/sec; 0 = each interface's link speed
+	hold      time.Duration
+	bell      bool
+	flash     bool
+	actions   notify.Channels
+
+	above  map[string]time.Time // interface → first poll above threshold
+	firing map[string]float64   // firing interface → utilization
+}
+
+// saturationEvent is an interface newly firing a rule.
+type saturationEvent struct {
+	iface     string
+	util      float64
+	rate      float64 // busier direction, bytes/sec
+	threshold float64 // bytes/sec at the threshold
+}
+
+func newSaturationRule(r config.InterfaceRule) *saturationRule {
+	s := &saturationRule{
+		rule:      r,
+		name:      r.Name,
+		threshold: r.Utilization(),
+		capacity:  r.CapacityBits(),
+		hold:      r.ForDuration(),
+		bell:      r.Notifies(config.NotifyBell),
+		flash:     r.Notifies(config.NotifyFlash),
+		actions:   alertChannels(r.Notifies),
+		above:     make(map[string]time.Time),
+		firing:    make(map[string]float64),
+	}
+	if s.name == "" {
+		s.name = "saturated"
+	}
+	return s
+}
+
+// capacityOf returns the capacity the rule measures ifc against, bits/sec.
+func (s *saturationRule) capacityOf(ifc *model.InterfaceStats) uint64 {
+	if s.capacity > 0 {
+		return s.capacity
+	}
+	return ifc.Speed
+}
+
+// observe evaluates one poll and returns the interfaces newly firing.
+func (s *saturationRule) observe(ifaces []model.InterfaceStats, now time.Time) []saturationEvent {
+	var events []saturationEvent
+	seen := make(map[string]bool, len(ifaces))
+	for i := range ifaces {
+		ifc := &ifaces[i]
+		capacity := s.capacityOf(ifc)
+		if capacity == 0 || !s.rule.Matches(ifc.Name) {
+			continue
+		}
+		seen[ifc.Name] = true
+		rate := max(ifc.RecvRate, ifc.SendRate)
+		util := rate * 8 / float64(capacity)
+		if util <= s.threshold {
+			delete(s.above, ifc.Name)
+			delete(s.firing, ifc.Name)
+			continue
+		}
+		since, ok := s.above[ifc.Name]
+		if !ok {
+			since = now
+			s.above[ifc.Name] = now
+		}
+		if now.Sub(since) < s.hold {
+			continue
+		}
+		if _, ok := s.firing[ifc.Name]; !ok {
+			events = append(events, saturationEvent{
+				iface:     ifc.Name,
+				util:      util,
+				rate:      rate,
+				threshold: s.threshold * float64(capacity) / 8,
+			})
+		}
+		s.firing[ifc.Name] = util
+	}
+
+	// Interfaces gone from the snapshot stop firing
+	for name := range s.above {
+		if !seen[name] {
+			delete(s.above, name)
+			delete(s.firing, name)
+		}
+	}
+	return events
+}
+
+// eventText is the alert log line for an interface newly firing, e.g.
+// "uplink: eth0 at 94% > 90%".
+func (s *saturationRule) eventText(e saturationEvent) string {
+	return fmt.Sprintf("%s: %s at %s > %s", s.name, e.iface, formatUtil(e.util), formatUtil(s.threshold))
+}
+
+// headerText returns the unstyled header tag while any interface fires,
+// e.g. " ⚠ uplink: eth0 94%, eth1 97% > 90% ", or "".
+func (s *saturationRule) headerText() string {
+	if len(s.firing) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(s.firing))
+	for name := range s.firing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + " " + formatUtil(s.firing[name])
+	}
+	return fmt.Sprintf(" ⚠ %s: %s > %s ", s.name, strings.Join(parts, ", "), formatUtil(s.threshold))
+}
+
+// formatUtil formats a utilization as a whole percentage: "94%".
+func formatUtil(u float64) string {
+	return fmt.Sprintf("%.0f%%", u*100)
+}
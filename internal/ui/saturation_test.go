@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/config"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestSaturationRule(t *testing.T) {
+	// eth0 is a 1 Gbit/s link; wlan0 reports no speed
+	ifaces := func(eth0, wlan0 float64) []model.InterfaceStats {
+		return []model.InterfaceStats{
+			{Name: "eth0", Speed: 1e9, SendRate: eth0, RecvRate: 1000},
+			{Name: "wlan0", RecvRate: wlan0},
+		}
+	}
+	s := newSaturationRule(config.InterfaceRule{Name: "uplink", Threshold: "90%", For: "2s"})
+	start := time.Now()
+
+	// 120 MB/s is 96% of 1 Gbit/s; it must hold for 2s before firing
+	if ev := s.observe(ifaces(120e6, 0), start); len(ev) != 0 {
+		t.Fatalf("fired before for: %+v", ev)
+	}
+	ev := s.observe(ifaces(120e6, 0), start.Add(2*time.Second))
+	if len(ev) != 1 || ev[0].iface != "eth0" || ev[0].threshold != 0.9*1e9/8 {
+		t.Fatalf("events = %+v, want eth0", ev)
+	}
+	if got := s.eventText(ev[0]); got != "uplink: eth0 at 96% > 90%" {
+		t.Errorf("event text = %q", got)
+	}
+	if ev := s.observe(ifaces(120e6, 0), start.Add(3*time.Second)); len(ev) != 0 {
+		t.Error("sustained saturation should not fire again")
+	}
+	if got := s.headerText(); !strings.Contains(got, "uplink: eth0 96% > 90%") {
+		t.Errorf("header = %q", got)
+	}
+
+	// Dropping below resets the hold
+	s.observe(ifaces(1e6, 0), start.Add(4*time.Second))
+	if s.headerText() != "" {
+		t.Errorf("header after recovery = %q", s.headerText())
+	}
+	if ev := s.observe(ifaces(120e6, 0), start.Add(5*time.Second)); len(ev) != 0 {
+		t.Error("fired again without holding for 2s")
+	}
+}
+
+func TestSaturationRuleCapacity(t *testing.T) {
+	// A configured capacity covers interfaces with no detected speed
+	s := newSaturationRule(config.InterfaceRule{Interface: "wl*", Threshold: "80", Capacity: "100M"})
+	ev := s.observe([]model.InterfaceStats{
+		{Name: "eth0", Speed: 1e9, RecvRate: 120e6},
+		{Name: "wlan0", RecvRate: 11e6}, // 88 Mbit/s
+	}, time.Now())
+	if len(ev) != 1 || ev[0].iface != "wlan0" {
+		t.Errorf("events = %+v, want only wlan0", ev)
+	}
+}
+
+func TestInterfaceAlertLogged(t *testing.T) {
+	m := New(nil)
+	if err := m.SetAlertConfig(config.Alerts{Interfaces: []config.InterfaceRule{{Name: "uplink", Interface: "eth0", Threshold: "50%"}}}); err != nil {
+		t.Fatal(err)
+	}
+	snap := model.Snapshot{Timestamp: time.Now(), Interfaces: []model.InterfaceStats{{Name: "eth0", Speed: 1e8, RecvRate: 10e6}}}
+	next, _ := m.Update(SnapshotMsg(snap))
+	m = next.(Model)
+	if log := m.alert.log; len(log) != 1 || log[0].text != "uplink: eth0 at 80% > 50%" {
+		t.Errorf("alert log = %+v", log)
+	}
+	if tag := m.alert.alertHeaderText(nil); !strings.Contains(tag, "uplink: eth0 80%") {
+		t.Errorf("header tag = %q", tag)
+	}
+}
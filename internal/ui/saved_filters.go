@@ -0,0 +1,277 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/config"
+)
+
+// FilterSaver persists a saved filter (config.AppendFilter on the config
+// file).
+type FilterSaver func(f config.SavedFilter) error
+
+// maxFilterHistory is how many filters up/down in the search input reach
+// back.
+const maxFilterHistory = 50
+
+// SetSavedFilters loads the config file's [[filters]], recalled with 1–9
+// and the F picker. save persists filters saved in the picker; nil keeps
+// them for this session only.
+func (m *Model) SetSavedFilters(filters []config.SavedFilter, save FilterSaver) {
+	m.savedFilters = nil
+	for _, f := range filters {
+		m.savedFilters = addSavedFilter(m.savedFilters, f)
+	}
+	m.filterSaver = save
+}
+
+// addSavedFilter adds f to the list, in place of a filter of the same name.
+func addSavedFilter(list []config.SavedFilter, f config.SavedFilter) []config.SavedFilter {
+	for i := range list {
+		if list[i].Name == f.Name {
+			list[i] = f
+			return list
+		}
+	}
+	return append(list, f)
+}
+
+// recallFilter applies the saved filter in slot i (0 for key 1) to the
+// current view.
+func (m *Model) recallFilter(i int) bool {
+	if i < 0 || i >= len(m.savedFilters) {
+		return false
+	}
+	if _, ok := m.viewFilter(); !ok {
+		return false
+	}
+	f := m.savedFilters[i].Filter
+	m.setViewFilter(f)
+	m.filterHistory.push(f)
+	return true
+}
+
+// filterHistory is the filters confirmed this session, for up/down in the
+// search input.
+type filterHistory struct {
+	entries []string // oldest first
+	pos     int      // entry shown while browsing; len(entries) = the line being typed
+	draft   string   // the line being typed, kept while browsing
+}
+
+// push records a confirmed filter as the newest, dropping an older copy.
+func (h *filterHistory) push(f string) {
+	if f = strings.TrimSpace(f); f != "" {
+		h.entries = slices.DeleteFunc(h.entries, func(e string) bool { return e == f })
+		h.entries = append(h.entries, f)
+		if len(h.entries) > maxFilterHistory {
+			h.entries = h.entries[len(h.entries)-maxFilterHistory:]
+		}
+	}
+	h.reset()
+}
+
+// reset starts browsing again from the line being typed.
+func (h *filterHistory) reset() {
+	h.pos = len(h.entries)
+	h.draft = ""
+}
+
+// prev steps back to an older filter, keeping current to come back to.
+func (h *filterHistory) prev(current string) (string, bool) {
+	if h.pos == 0 {
+		return "", false
+	}
+	if h.pos == len(h.entries) {
+		h.draft = current
+	}
+	h.pos--
+	return h.entries[h.pos], true
+}
+
+// next steps forward to a newer filter, then to the line being typed.
+func (h *filterHistory) next() (string, bool) {
+	if h.pos >= len(h.entries) {
+		return "", false
+	}
+	h.pos++
+	if h.pos == len(h.entries) {
+		return h.draft, true
+	}
+	return h.entries[h.pos], true
+}
+
+// filterPicker lists the saved filters (F): Enter or a number key applies
+// one to the current view, s saves the view's filter under a name.
+type filterPicker struct {
+	active  bool
+	cursor  int
+	current string // the view's filter when the picker opened
+	naming  bool   // typing a name to save current under
+	input   textinput.Model
+	err     error // last save error, shown until the picker closes
+}
+
+func newFilterPicker() filterPicker {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.Placeholder = "e.g. web, dns, ssh"
+	ti.CharLimit = 24
+	return filterPicker{input: ti}
+}
+
+func (p *filterPicker) open(current string) {
+	p.active = true
+	p.cursor = 0
+	p.current = current
+	p.naming = false
+	p.err = nil
+}
+
+func (p *filterPicker) close() {
+	p.active = false
+	p.naming = false
+	p.input.Blur()
+}
+
+// updateFilterPicker handles a key while the picker is open.
+func (m *Model) updateFilterPicker(msg tea.KeyMsg) tea.Cmd {
+	p := &m.filters
+	if p.naming {
+		switch msg.String() {
+		case "enter":
+			name := strings.TrimSpace(p.input.Value())
+			if name == "" {
+				return nil
+			}
+			f := config.SavedFilter{Name: name, Filter: p.current}
+			m.savedFilters = addSavedFilter(m.savedFilters, f)
+			p.naming = false
+			p.input.Blur()
+			p.cursor = slices.IndexFunc(m.savedFilters, func(s config.SavedFilter) bool { return s.Name == name })
+			if m.filterSaver != nil {
+				p.err = m.filterSaver(f)
+			}
+			return nil
+		case "esc":
+			p.naming = false
+			p.input.Blur()
+			return nil
+		}
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		return cmd
+	}
+
+	switch key := msg.String(); key {
+	case "esc", "q", "F":
+		p.close()
+	case "up", "k":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case "down", "j":
+		if p.cursor < len(m.savedFilters)-1 {
+			p.cursor++
+		}
+	case "enter":
+		if m.recallFilter(p.cursor) {
+			p.close()
+		}
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		if m.recallFilter(int(key[0] - '1')) {
+			p.close()
+		}
+	case "s":
+		if p.current == "" {
+			return nil
+		}
+		p.naming = true
+		p.err = nil
+		p.input.SetValue("")
+		p.input.Focus()
+		return p.input.Cursor.BlinkCmd()
+	}
+	return nil
+}
+
+func (p *filterPicker) render(saved []config.SavedFilter, persisted bool, width, height int) string {
+	boxW := 64
+	if boxW > width-4 {
+		boxW = width - 4
+	}
+	innerW := boxW - 4
+
+	title := styleSortIndicator.Render(" Saved Filters ")
+	var lines []string
+	if len(saved) == 0 {
+		lines = append(lines, styleDetailLabel.Render("No saved filters yet."))
+	}
+	nameW := 0
+	for _, f := range saved {
+		nameW = max(nameW, lipgloss.Width(f.Name))
+	}
+	nameW = min(nameW, 16)
+	for i, f := range saved {
+		slot := " "
+		if i < 9 {
+			slot = fmt.Sprint(i + 1)
+		}
+		line := Truncate(fmt.Sprintf("%s  %-*s  %s", slot, nameW, Truncate(f.Name, nameW), f.Filter), innerW)
+		if i == p.cursor {
+			lines = append(lines, styleSortIndicator.Render(line))
+		} else {
+			lines = append(lines, line)
+		}
+	}
+	lines = append(lines, "")
+
+	switch {
+	case p.naming:
+		lines = append(lines, styleDetailLabel.Render(Truncate("Save "+p.current+" as:", innerW)),
+			"  "+p.input.View(), "",
+			styleDetailLabel.Render("Enter to save, Esc to cancel"))
+	case p.current != "":
+		lines = append(lines, styleDetailLabel.Render(Truncate("Current: "+p.current, innerW)), "",
+			styleDetailLabel.Render("Enter/1–9 apply · s save current · Esc close"))
+	default:
+		lines = append(lines, styleDetailLabel.Render("Enter/1–9 apply · / then F s saves a filter · Esc close"))
+	}
+	if !persisted {
+		lines = append(lines, styleDetailLabel.Render("Saved for this session only (no config file location)"))
+	}
+	if p.err != nil {
+		lines = append(lines, styleAlertTag.Render(Truncate("Not saved to disk: "+p.err.Error(), innerW)))
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorAccent).
+		Width(boxW).
+		Padding(1, 2).
+		Render(title + "\n\n" + strings.Join(lines, "\n"))
+
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// compactLine renders the picker as one line: the first slots, or the
+// name prompt.
+func (p *filterPicker) compactLine(saved []config.SavedFilter) string {
+	if p.naming {
+		return styleSortIndicator.Render("Save filter as: ") + p.input.View()
+	}
+	parts := []string{styleSortIndicator.Render("Filters:")}
+	for i, f := range saved[:min(len(saved), 9)] {
+		parts = append(parts, styleFooterKey.Render(fmt.Sprint(i+1))+styleFooter.Render(" "+f.Name))
+	}
+	if p.current != "" {
+		parts = append(parts, styleFooterKey.Render("s")+styleFooter.Render(" save"))
+	}
+	parts = append(parts, styleFooterKey.Render("esc")+styleFooter.Render(" close"))
+	return strings.Join(parts, "  ")
+}
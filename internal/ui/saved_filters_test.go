@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/googlesky/sstop/internal/config"
+)
+
+func typeKeys(m Model, s string) Model {
+	for _, r := range s {
+		m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return m
+}
+
+func TestSavedFilterSlots(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 120, 30
+	m.SetSavedFilters([]config.SavedFilter{
+		{Name: "web", Filter: "port:443"},
+		{Name: "dns", Filter: "port:53"},
+		{Name: "web", Filter: "port:443 OR port:80"}, // a later one replaces it
+	}, nil)
+	if len(m.savedFilters) != 2 || m.savedFilters[0].Filter != "port:443 OR port:80" {
+		t.Fatalf("saved filters = %+v", m.savedFilters)
+	}
+
+	m = typeKeys(m, "2")
+	if m.table.filter != "port:53" {
+		t.Errorf("2: filter %q, want port:53", m.table.filter)
+	}
+	m = typeKeys(m, "9") // empty slot
+	if m.table.filter != "port:53" {
+		t.Errorf("an empty slot changed the filter to %q", m.table.filter)
+	}
+
+	// Slots apply to the current view's own filter
+	m = typeKeys(m, "v1")
+	if m.connections.filter != "port:443 OR port:80" || m.table.filter != "port:53" {
+		t.Errorf("connections filter %q, table filter %q", m.connections.filter, m.table.filter)
+	}
+}
+
+func TestFilterPickerSave(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 120, 30
+	var saved []config.SavedFilter
+	m.SetSavedFilters(nil, func(f config.SavedFilter) error {
+		saved = append(saved, f)
+		return nil
+	})
+
+	m = typeKeys(m, "/")
+	m = typeKeys(m, "user:root")
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	m = typeKeys(m, "F")
+	if !m.filters.active {
+		t.Fatal("F should open the picker")
+	}
+	if out := m.View(); !strings.Contains(out, "Current: user:root") {
+		t.Errorf("picker should show the current filter:\n%s", out)
+	}
+	m = typeKeys(m, "sroot")
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(saved) != 1 || saved[0] != (config.SavedFilter{Name: "root", Filter: "user:root"}) {
+		t.Fatalf("persisted %+v", saved)
+	}
+	if len(m.savedFilters) != 1 || m.filters.naming {
+		t.Fatalf("picker after save: %+v, naming %v", m.savedFilters, m.filters.naming)
+	}
+
+	// Esc closes; a cleared filter comes back with its slot
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	m.setViewFilter("")
+	m = typeKeys(m, "F1")
+	if m.filters.active || m.table.filter != "user:root" {
+		t.Errorf("F 1: picker open %v, filter %q", m.filters.active, m.table.filter)
+	}
+
+	// A failed write keeps the filter for the session and says so
+	m.SetSavedFilters(m.savedFilters, func(config.SavedFilter) error { return errors.New("read-only file system") })
+	m = typeKeys(m, "Fsmine")
+	m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if len(m.savedFilters) != 2 || !strings.Contains(m.View(), "read-only file system") {
+		t.Errorf("failed save: %+v\n%s", m.savedFilters, m.View())
+	}
+}
+
+func TestFilterHistory(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 120, 30
+	for _, f := range []string{"port:22", "name:curl", "port:22"} {
+		m = typeKeys(m, "/")
+		m.searchInput.SetValue(f)
+		m = press(m, tea.KeyMsg{Type: tea.KeyEnter})
+	}
+	if got := m.filterHistory.entries; len(got) != 2 || got[1] != "port:22" {
+		t.Fatalf("history = %q, want [name:curl port:22]", got)
+	}
+
+	m = typeKeys(m, "/")
+	m.searchInput.SetValue("draft")
+	up := tea.KeyMsg{Type: tea.KeyUp}
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	for _, step := range []struct {
+		key  tea.KeyMsg
+		want string
+	}{{up, "port:22"}, {up, "name:curl"}, {up, "name:curl"}, {down, "port:22"}, {down, "draft"}, {down, "draft"}} {
+		m = press(m, step.key)
+		if got := m.searchInput.Value(); got != step.want {
+			t.Fatalf("%s: input %q, want %q", step.key, got, step.want)
+		}
+	}
+	if m.table.filter != "draft" {
+		t.Errorf("browsing should filter live, table filter %q", m.table.filter)
+	}
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// listWindow is the part of a list a view last drew: rows offset up to
+// offset+rows of total.
+type listWindow struct {
+	offset, rows, total int
+}
+
+// overflows reports whether part of the list is off screen.
+func (w listWindow) overflows() bool {
+	return w.total > w.rows
+}
+
+// counter is the window's position for the footer, "21–40 of 143"; empty
+// for an empty list.
+func (w listWindow) counter() string {
+	if w.total == 0 || w.rows == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d–%d of %d", w.offset+1, w.offset+w.rows, w.total)
+}
+
+// thumb returns the scrollbar thumb's first row and length, in rows of the
+// window. It reaches the last row only when the list is scrolled to its end.
+func (w listWindow) thumb() (start, length int) {
+	if !w.overflows() || w.rows == 0 {
+		return 0, w.rows
+	}
+	length = max(1, w.rows*w.rows/w.total)
+	travel := w.rows - length
+	scrollable := w.total - w.rows
+	start = (w.offset*travel + scrollable/2) / scrollable
+	if start > travel {
+		start = travel
+	}
+	if travel >= 2 {
+		// Off the ends as soon as the list has scrolled from them
+		if w.offset > 0 && start == 0 {
+			start = 1
+		}
+		if w.offset < scrollable && start == travel {
+			start = travel - 1
+		}
+	}
+	return start, length
+}
+
+// rowsWidth is the width left for a list's rows beside the scrollbar,
+// which takes the last column when total rows don't fit in rows.
+func rowsWidth(width, total, rows int) int {
+	if total > max(rows, 1) && width >= 2 {
+		return width - 1
+	}
+	return width
+}
+
+// withScrollbar fits rows, the window's visible rows, to width with a
+// scrollbar in the last column when the list doesn't fit on screen.
+func withScrollbar(rows []string, w listWindow, width int) []string {
+	if !w.overflows() || width < 2 {
+		return rows
+	}
+	start, length := w.thumb()
+	out := make([]string, len(rows))
+	for i, row := range rows {
+		row = ansi.Truncate(row, width-1, "")
+		if pad := width - 1 - ansi.StringWidth(row); pad > 0 {
+			row += strings.Repeat(" ", pad)
+		}
+		if i >= start && i < start+length {
+			row += styleScrollThumb.Render("┃")
+		} else {
+			row += styleScrollTrack.Render("│")
+		}
+		out[i] = row
+	}
+	return out
+}
+
+// listWindow returns the rows the current view last drew, if it's a list.
+func (m *Model) listWindow() (listWindow, bool) {
+	switch m.mode {
+	case ViewProcessTable:
+		return m.table.window, true
+	case ViewProcessDetail:
+		return m.detail.window, true
+	case ViewRemoteHosts:
+		return m.remoteHosts.window, true
+	case ViewListenPorts:
+		return m.listenPorts.window, true
+	case ViewGroups:
+		return m.groups.window, true
+	case ViewUsers:
+		return m.users.window, true
+	case ViewUsage:
+		return m.usage.window, true
+	case ViewCountries:
+		return m.countries.window, true
+	case ViewGraph:
+		return m.graph.window, true
+	case ViewConnections:
+		return m.connections.window, true
+	case ViewInterfaces:
+		return m.interfaces.window, true
+	}
+	return listWindow{}, false
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestListWindowThumb(t *testing.T) {
+	tests := []struct {
+		w                  listWindow
+		wantStart, wantLen int
+	}{
+		{listWindow{offset: 0, rows: 10, total: 5}, 0, 10}, // fits: no bar
+		{listWindow{offset: 0, rows: 10, total: 100}, 0, 1},
+		{listWindow{offset: 90, rows: 10, total: 100}, 9, 1},
+		{listWindow{offset: 1, rows: 10, total: 100}, 1, 1},  // off the top once scrolled
+		{listWindow{offset: 89, rows: 10, total: 100}, 8, 1}, // not at the bottom before the end
+		{listWindow{offset: 10, rows: 10, total: 20}, 5, 5},
+	}
+	for _, tt := range tests {
+		start, length := tt.w.thumb()
+		if start != tt.wantStart || length != tt.wantLen {
+			t.Errorf("%+v: thumb %d+%d, want %d+%d", tt.w, start, length, tt.wantStart, tt.wantLen)
+		}
+	}
+
+	if got := (listWindow{offset: 20, rows: 20, total: 143}).counter(); got != "21–40 of 143" {
+		t.Errorf("counter = %q", got)
+	}
+	if got := (listWindow{}).counter(); got != "" {
+		t.Errorf("empty counter = %q", got)
+	}
+}
+
+func TestWithScrollbar(t *testing.T) {
+	rows := []string{"short", strings.Repeat("x", 30)}
+	if got := withScrollbar(rows, listWindow{rows: 2, total: 2}, 20); got[1] != rows[1] {
+		t.Error("a list that fits should be left alone")
+	}
+	got := withScrollbar(rows, listWindow{rows: 2, total: 4}, 20)
+	for i, row := range got {
+		plain := ansi.Strip(row)
+		if ansi.StringWidth(plain) != 20 {
+			t.Errorf("row %d is %d cells wide, want 20: %q", i, ansi.StringWidth(plain), plain)
+		}
+	}
+	if !strings.HasSuffix(ansi.Strip(got[0]), "┃") || !strings.HasSuffix(ansi.Strip(got[1]), "│") {
+		t.Errorf("want thumb then track: %q", got)
+	}
+}
+
+func TestProcessTableScrollIndicator(t *testing.T) {
+	var procs []model.ProcessSummary
+	for i := 1; i <= 50; i++ {
+		procs = append(procs, model.ProcessSummary{PID: uint32(i), Name: fmt.Sprintf("proc%d", i), UpRate: float64(100 - i)})
+	}
+	m := New(nil)
+	m.width, m.height = 100, 20
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{Processes: procs}))
+	m = next.(Model)
+
+	view := ansi.Strip(m.View())
+	if !strings.Contains(view, " of 50") || !strings.Contains(view, "┃") {
+		t.Fatalf("want a scrollbar and an x–y of 50 counter:\n%s", view)
+	}
+	for _, line := range strings.Split(view, "\n") {
+		if w := ansi.StringWidth(line); w > m.width {
+			t.Errorf("line is %d cells wide, want at most %d: %q", w, m.width, line)
+		}
+	}
+
+	// Filtered down to fit: no bar, the counter shows all rows
+	m.setViewFilter("proc1")
+	view = ansi.Strip(m.View())
+	if strings.Contains(view, "┃") || !strings.Contains(view, "1–11 of 11") {
+		t.Errorf("filtered to 11 rows: want no scrollbar and 1–11 of 11:\n%s", view)
+	}
+}
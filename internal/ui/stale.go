@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// staleIntervals is how many refresh intervals may pass without a
+	// snapshot before the data on screen is marked stale.
+	staleIntervals = 3
+	staleCheck     = time.Second
+)
+
+// staleTickMsg re-checks how old the last snapshot is.
+type staleTickMsg time.Time
+
+func staleTick() tea.Cmd {
+	return tea.Tick(staleCheck, func(t time.Time) tea.Msg { return staleTickMsg(t) })
+}
+
+// staleAgeAt returns how long the snapshot source has been silent at now,
+// or 0 while it is keeping up. The last snapshot stays on screen; a source
+// that recovers (a restarted collector, a reconnected link) clears it with
+// its next snapshot. Playback is paced by the recording, never stale.
+func (m Model) staleAgeAt(now time.Time) time.Duration {
+	if m.player != nil || m.lastSnapAt.IsZero() {
+		return 0
+	}
+	age := now.Sub(m.lastSnapAt)
+	if age < staleIntervals*intervalPresets[m.intervalIdx] {
+		return 0
+	}
+	return age
+}
+
+// staleText is the header badge for stale data, "" when fresh.
+func (m Model) staleText() string {
+	if m.staleAge == 0 {
+		return ""
+	}
+	return fmt.Sprintf("STALE %s", m.staleAge.Truncate(time.Second))
+}
+
+// sourceInfoText is the header badge describing the snapshot source:
+// playback position, the remote host, and how stale live data is.
+func (m Model) sourceInfoText() string {
+	if info := m.playbackInfoText(); info != "" {
+		return info
+	}
+	stale := m.staleText()
+	switch {
+	case m.remoteHost == "":
+		return stale
+	case stale == "":
+		return "⇄ " + m.remoteHost
+	default:
+		return "⇄ " + m.remoteHost + " " + stale
+	}
+}
+
+// greyOut renders already-styled content in the dim colour, so stale
+// numbers don't read as live.
+func greyOut(content string) string {
+	return styleDetailLabel.Render(ansi.Strip(content))
+}
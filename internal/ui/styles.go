@@ -23,95 +23,170 @@ var (
 )
 
 var (
+	styleHeaderValue      lipgloss.Style
+	styleHeaderUp         lipgloss.Style
+	styleHeaderDown       lipgloss.Style
+	styleTableHeader      lipgloss.Style
+	styleTableRow         lipgloss.Style
+	styleTableRowSelected lipgloss.Style
+	styleUpRate           lipgloss.Style
+	styleDownRate         lipgloss.Style
+	stylePID              lipgloss.Style
+	styleProcessName      lipgloss.Style
+	styleConnCount        lipgloss.Style
+	styleListenCount      lipgloss.Style
+	stylePortConflict     lipgloss.Style
+	styleBaselineChange   lipgloss.Style
+	styleExited           lipgloss.Style
+	styleSortIndicator    lipgloss.Style
+	styleFooter           lipgloss.Style
+	styleFooterKey        lipgloss.Style
+	styleBorder           lipgloss.Style
+	styleTitle            lipgloss.Style
+	styleDetailLabel      lipgloss.Style
+	styleStateEstablished lipgloss.Style
+	styleStateListen      lipgloss.Style
+	styleStateTimeWait    lipgloss.Style
+	styleStateClosing     lipgloss.Style
+	styleStateOther       lipgloss.Style
+	styleSearchPrompt     lipgloss.Style
+	styleSparkline        lipgloss.Style
+	styleSparklineActive  lipgloss.Style
+	stylePaused           lipgloss.Style
+	styleZebraRow         lipgloss.Style
+	styleAlertTag         lipgloss.Style
+	styleAlertFlash       lipgloss.Style
+	styleScrollTrack      lipgloss.Style
+	styleScrollThumb      lipgloss.Style
+)
+
+// buildStyles derives every style from the current palette. It runs at init
+// and again when SetTheme swaps the palette.
+func buildStyles() {
 	styleHeaderValue = lipgloss.NewStyle().
-				Foreground(colorFg)
+		Foreground(colorFg)
 
 	styleHeaderUp = lipgloss.NewStyle().
-			Foreground(colorGreen).
-			Bold(true)
+		Foreground(colorGreen).
+		Bold(true)
 
 	styleHeaderDown = lipgloss.NewStyle().
-			Foreground(colorRed).
-			Bold(true)
+		Foreground(colorRed).
+		Bold(true)
 
 	styleTableHeader = lipgloss.NewStyle().
-				Foreground(colorAccent).
-				Bold(true)
+		Foreground(colorAccent).
+		Bold(true)
 
 	styleTableRow = lipgloss.NewStyle().
-			Foreground(colorFg)
+		Foreground(colorFg)
 
 	styleTableRowSelected = lipgloss.NewStyle().
-				Background(colorSelection).
-				Foreground(colorFg)
+		Background(colorSelection).
+		Foreground(colorFg)
 
 	styleUpRate = lipgloss.NewStyle().
-			Foreground(colorGreen)
+		Foreground(colorGreen)
 
 	styleDownRate = lipgloss.NewStyle().
-			Foreground(colorRed)
+		Foreground(colorRed)
 
 	stylePID = lipgloss.NewStyle().
-			Foreground(colorFgDim)
+		Foreground(colorFgDim)
 
 	styleProcessName = lipgloss.NewStyle().
-				Foreground(colorFg).
-				Bold(true)
+		Foreground(colorFg).
+		Bold(true)
 
 	styleConnCount = lipgloss.NewStyle().
-			Foreground(colorCyan)
+		Foreground(colorCyan)
 
 	styleListenCount = lipgloss.NewStyle().
-				Foreground(colorMagenta)
+		Foreground(colorMagenta)
+
+	// Listen Ports view: binds overlapping or sharing another's
+	stylePortConflict = lipgloss.NewStyle().
+		Foreground(colorYellow).
+		Bold(true)
+
+	// Process table rows that changed since the baseline (Z)
+	styleBaselineChange = lipgloss.NewStyle().
+		Foreground(colorYellow)
+
+	styleExited = lipgloss.NewStyle().
+		Foreground(colorFgDim).
+		Faint(true)
 
 	styleSortIndicator = lipgloss.NewStyle().
-				Foreground(colorYellow).
-				Bold(true)
+		Foreground(colorYellow).
+		Bold(true)
 
 	styleFooter = lipgloss.NewStyle().
-			Foreground(colorFgDim)
+		Foreground(colorFgDim)
 
 	styleFooterKey = lipgloss.NewStyle().
-			Foreground(colorAccent).
-			Bold(true)
+		Foreground(colorAccent).
+		Bold(true)
 
 	styleBorder = lipgloss.NewStyle().
-			Foreground(colorBorder)
+		Foreground(colorBorder)
 
 	styleTitle = lipgloss.NewStyle().
-			Foreground(colorAccent).
-			Bold(true)
+		Foreground(colorAccent).
+		Bold(true)
 
 	styleDetailLabel = lipgloss.NewStyle().
-				Foreground(colorFgDim)
+		Foreground(colorFgDim)
 
 	styleStateEstablished = lipgloss.NewStyle().Foreground(colorGreen)
-	styleStateListen      = lipgloss.NewStyle().Foreground(colorCyan)
-	styleStateTimeWait    = lipgloss.NewStyle().Foreground(colorFgDim)
-	styleStateClosing     = lipgloss.NewStyle().Foreground(colorYellow)
-	styleStateOther       = lipgloss.NewStyle().Foreground(colorFg)
+	styleStateListen = lipgloss.NewStyle().Foreground(colorCyan)
+	styleStateTimeWait = lipgloss.NewStyle().Foreground(colorFgDim)
+	styleStateClosing = lipgloss.NewStyle().Foreground(colorYellow)
+	styleStateOther = lipgloss.NewStyle().Foreground(colorFg)
 
 	styleSearchPrompt = lipgloss.NewStyle().
-				Foreground(colorYellow).
-				Bold(true)
+		Foreground(colorYellow).
+		Bold(true)
 
 	styleSparkline = lipgloss.NewStyle().
-			Foreground(colorBorder)
+		Foreground(colorBorder)
 
 	styleSparklineActive = lipgloss.NewStyle().
-				Foreground(colorCyan)
+		Foreground(colorCyan)
 
 	stylePaused = lipgloss.NewStyle().
-			Foreground(colorYellow).
-			Bold(true)
+		Foreground(colorYellow).
+		Bold(true)
 
 	styleZebraRow = lipgloss.NewStyle().
-			Background(colorZebraRow)
+		Background(colorZebraRow)
 
 	styleAlertTag = lipgloss.NewStyle().
-			Foreground(colorRed).
-			Bold(true)
-)
+		Foreground(colorRed).
+		Bold(true)
+
+	// Alternate phase of the strong alert flash
+	styleAlertFlash = lipgloss.NewStyle().
+		Foreground(colorBg).
+		Background(colorRed).
+		Bold(true)
+
+	// Right-edge scrollbar of lists longer than the screen
+	styleScrollTrack = lipgloss.NewStyle().
+		Foreground(colorBorder)
+
+	styleScrollThumb = lipgloss.NewStyle().
+		Foreground(colorAccent)
+
+	buildHelpStyles()
+	buildKillStyles()
+	buildPaletteStyles()
+	buildProtoStyles()
+}
+
+func init() {
+	buildStyles()
+}
 
 // rateColorIntensity returns a lipgloss.Color that interpolates between dim and vivid
 // based on rate/maxRate ratio. baseH is the hue (green=96, red=354).
@@ -120,6 +195,15 @@ func rateColorIntensity(rate, maxRate float64, baseH float64) lipgloss.Color {
 		return colorFgDim
 	}
 	t := clamp01(rate / maxRate)
+	if accessibleTheme {
+		// Greyscale: only lightness encodes rate, download a step darker
+		top := 0.95
+		if baseH == hueRed {
+			top = 0.7
+		}
+		r, g, b := hslToRGB(0, 0, lerpValue(0.3, top, t))
+		return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", r, g, b))
+	}
 	// Dim: low saturation, low lightness → Vivid: high saturation, high lightness
 	s := lerpValue(0.2, 0.85, t)
 	l := lerpValue(0.35, 0.65, t)
@@ -127,6 +211,31 @@ func rateColorIntensity(rate, maxRate float64, baseH float64) lipgloss.Color {
 	return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", r, g, b))
 }
 
+// heatStyle shades a rate cell's background by rate/maxRate (heat mode),
+// from the row's own background at zero to a saturated mid-tone of baseH
+// at the maximum, so magnitudes read from across the room.
+func heatStyle(base lipgloss.Style, rate, maxRate float64, baseH float64) lipgloss.Style {
+	if maxRate <= 0 || rate <= 0 {
+		return base
+	}
+	t := clamp01(rate / maxRate)
+	s := lerpValue(0.3, 0.75, t)
+	l := lerpValue(0.18, 0.5, t)
+	if accessibleTheme {
+		// Greyscale, download topping out a step darker as with the bars
+		s = 0
+		if baseH == hueRed {
+			l = lerpValue(0.15, 0.38, t)
+		}
+	}
+	r, g, b := hslToRGB(baseH, s, l)
+	fg := colorFg
+	if l > 0.4 {
+		fg = colorBg // light cells need dark text
+	}
+	return base.Background(lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", r, g, b))).Foreground(fg)
+}
+
 // Green hue for upload, red hue for download
 const (
 	hueGreen = 96.0  // matches #9ece6a
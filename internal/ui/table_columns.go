@@ -0,0 +1,379 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// tableColumn is a process table column. Which columns show, and in what
+// order, comes from the [ui] columns config key.
+type tableColumn int
+
+const (
+	columnPID tableColumn = iota
+	columnName
+	columnGraph
+	columnUp
+	columnDown
+	columnConns
+	columnListen
+	columnDest
+	columnUser
+	columnCumUp
+	columnCumDown
+	columnContainer
+	columnAge
+	columnCountry
+	columnType
+	columnDelta    // with a baseline (Z): rate change
+	columnNewConns // with a baseline: connections opened since
+	columnNewHosts // with a baseline: remote hosts reached since
+	tableColumnCount
+)
+
+// Column widths
+const (
+	colPidW       = 8
+	colUpW        = 12 // bar(5) + gap(1) + text(6)
+	colDownW      = 12 // bar(5) + gap(1) + text(6)
+	colConnsW     = 6
+	colListenW    = 6
+	colGraphW     = 16 // sparkline width
+	colDestW      = 22 // optional TOP DEST column: "US " + host
+	colRateW      = 6  // rate text alone (FormatRateCompact), when bars are dropped
+	colBarW       = 5  // bandwidth bar beside the rate text
+	colUserW      = 10
+	colCumW       = 6 // FormatBytesCompact
+	colContainerW = 16
+	colAgeW       = 6 // FormatAge up to "99d23h"
+	colCountryW   = 7
+	colTypeW      = 6
+	colDeltaW     = 7 // "+1023K"
+	colNewW       = 6
+	colNameMin    = 10
+)
+
+// columnSpec describes a column: its config name, header and width (0 for
+// the PROCESS column, which takes the remaining space; UP and DOWN depend
+// on the layout).
+type columnSpec struct {
+	name   string
+	header string
+	width  int
+	right  bool       // right-aligned
+	sort   SortColumn // -1 = not a sort key
+}
+
+var columnSpecs = [tableColumnCount]columnSpec{
+	columnPID:       {"pid", "PID", colPidW, false, SortByPID},
+	columnName:      {"name", "PROCESS", 0, false, SortByName},
+	columnGraph:     {"graph", "GRAPH", colGraphW, false, SortByAvg},
+	columnUp:        {"up", "", colUpW, true, SortByUp},
+	columnDown:      {"down", "", colDownW, true, SortByDown},
+	columnConns:     {"conns", "CONNS", colConnsW, true, SortByConns},
+	columnListen:    {"listen", "LISTEN", colListenW, true, -1},
+	columnDest:      {"dest", "TOP DEST", colDestW, false, -1},
+	columnUser:      {"user", "USER", colUserW, false, -1},
+	columnCumUp:     {"cum_up", "CUM UP", colCumW, true, -1},
+	columnCumDown:   {"cum_down", "CUM DN", colCumW, true, -1},
+	columnContainer: {"container", "CONTAINER", colContainerW, false, -1},
+	columnAge:       {"age", "AGE", colAgeW, true, -1},
+	columnCountry:   {"country", "COUNTRY", colCountryW, false, -1},
+	columnType:      {"type", "TYPE", colTypeW, false, -1},
+	columnDelta:     {"delta", "ΔRATE", colDeltaW, true, -1},
+	columnNewConns:  {"new_conns", "+CONNS", colNewW, true, -1},
+	columnNewHosts:  {"new_hosts", "+HOSTS", colNewW, true, -1},
+}
+
+// baselineColumns are shown while a baseline is set, after the others
+// unless [ui] columns places them.
+var baselineColumns = []tableColumn{columnDelta, columnNewConns, columnNewHosts}
+
+// defaultColumns is the table without a [ui] columns setting. TOP DEST is
+// added at the end when T turns it on.
+var defaultColumns = []tableColumn{
+	columnPID, columnName, columnGraph, columnUp, columnDown, columnConns, columnListen,
+}
+
+// parseColumns resolves config column names. PROCESS must be among them.
+func parseColumns(names []string) ([]tableColumn, error) {
+	var cols []tableColumn
+	seen := make(map[tableColumn]bool)
+	for _, name := range names {
+		c, ok := columnByName(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		if seen[c] {
+			return nil, fmt.Errorf("column %q listed twice", name)
+		}
+		seen[c] = true
+		cols = append(cols, c)
+	}
+	if !seen[columnName] {
+		return nil, fmt.Errorf("columns must include \"name\"")
+	}
+	return cols, nil
+}
+
+func columnByName(name string) (tableColumn, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for c, spec := range columnSpecs {
+		if spec.name == name {
+			return tableColumn(c), true
+		}
+	}
+	return 0, false
+}
+
+// optional reports whether a column is one of the extras beyond the
+// default set; those are the first to give way on a narrow terminal.
+func (c tableColumn) optional() bool {
+	return c >= columnUser
+}
+
+// tableLayout is the set of process table columns that fit the terminal.
+// Below the full width, columns drop in order: the optional extras (last
+// listed first), TOP DEST, GRAPH, CONNS and LISTEN, then the bandwidth
+// bars (rates stay as text).
+type tableLayout struct {
+	nameW int
+	cols  []tableColumn // visible, in display order
+	bars  bool
+}
+
+// has reports whether column c is shown.
+func (l tableLayout) has(c tableColumn) bool {
+	for _, v := range l.cols {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
+
+// rateW is the width of the UP and DOWN columns.
+func (l tableLayout) rateW() int {
+	if l.bars {
+		return colUpW
+	}
+	return colRateW
+}
+
+// colW is the width of a visible column.
+func (l tableLayout) colW(c tableColumn) int {
+	switch c {
+	case columnName:
+		return l.nameW
+	case columnUp, columnDown:
+		return l.rateW()
+	}
+	return columnSpecs[c].width
+}
+
+// fixedW is the width of everything except the PROCESS column: indent,
+// columns and the gaps between them.
+func (l tableLayout) fixedW() int {
+	w := 2 + len(l.cols) - 1
+	for _, c := range l.cols {
+		if c != columnName {
+			w += l.colW(c)
+		}
+	}
+	return w
+}
+
+// columnAt returns the column under screen column x of a row.
+func (l tableLayout) columnAt(x int) (tableColumn, bool) {
+	left := 2 // row indent
+	for _, c := range l.cols {
+		right := left + l.colW(c)
+		if x >= left && x < right {
+			return c, true
+		}
+		left = right + 1
+	}
+	return 0, false
+}
+
+func (l *tableLayout) drop(cols ...tableColumn) {
+	kept := l.cols[:0:0]
+	for _, c := range l.cols {
+		dropped := false
+		for _, d := range cols {
+			dropped = dropped || c == d
+		}
+		if !dropped {
+			kept = append(kept, c)
+		}
+	}
+	l.cols = kept
+}
+
+// computeTableLayout fits the wanted columns into width.
+func computeTableLayout(width int, cols []tableColumn) tableLayout {
+	l := tableLayout{cols: cols, bars: true}
+	var drops [][]tableColumn
+	for i := len(cols) - 1; i >= 0; i-- {
+		if cols[i].optional() {
+			drops = append(drops, []tableColumn{cols[i]})
+		}
+	}
+	drops = append(drops,
+		[]tableColumn{columnDest},
+		[]tableColumn{columnGraph},
+		[]tableColumn{columnConns, columnListen},
+	)
+	for _, d := range drops {
+		if width-l.fixedW() >= colNameMin {
+			break
+		}
+		l.drop(d...)
+	}
+	if width-l.fixedW() < colNameMin {
+		l.bars = false
+	}
+	l.nameW = width - l.fixedW()
+	if l.nameW < colNameMin {
+		l.nameW = colNameMin
+	}
+	return l
+}
+
+// visibleColumns returns the wanted columns: the configured ones, with
+// TOP DEST shown or hidden by T and the baseline's columns by Z (appended
+// if they weren't configured).
+func (t *processTable) visibleColumns() []tableColumn {
+	cols := t.columns
+	if cols == nil {
+		cols = defaultColumns
+	}
+	out := make([]tableColumn, 0, len(cols)+1+len(baselineColumns))
+	hasDest := false
+	for _, c := range cols {
+		if c == columnDest {
+			hasDest = true
+			if !t.showTopDest {
+				continue
+			}
+		}
+		if slices.Contains(baselineColumns, c) && t.base == nil {
+			continue
+		}
+		out = append(out, c)
+	}
+	if t.showTopDest && !hasDest {
+		out = append(out, columnDest)
+	}
+	if t.base != nil {
+		for _, c := range baselineColumns {
+			if !slices.Contains(out, c) {
+				out = append(out, c)
+			}
+		}
+	}
+	return out
+}
+
+// columnText is a cell's text, padded to width, for the columns without
+// special rendering.
+func (t *processTable) columnText(c tableColumn, p *model.ProcessSummary, width int) string {
+	var s string
+	switch c {
+	case columnPID:
+		if p.PID == model.UnattributedPID {
+			return fmt.Sprintf("%-*s", width, "-")
+		}
+		return fmt.Sprintf("%-*d", width, p.PID)
+	case columnConns:
+		return fmt.Sprintf("%*d", width, p.ConnCount)
+	case columnListen:
+		return fmt.Sprintf("%*d", width, p.ListenCount)
+	case columnDest:
+		s = formatTopDest(p, t.hosts)
+	case columnUser:
+		s = p.User
+	case columnCumUp:
+		return FormatBytesCompact(p.ProgramCumUp())
+	case columnCumDown:
+		return FormatBytesCompact(p.ProgramCumDown())
+	case columnContainer:
+		s = p.PodName
+		if s == "" {
+			s = p.ContainerID
+		}
+	case columnAge:
+		if !p.FirstSeen.IsZero() {
+			s = FormatAge(t.now().Sub(p.FirstSeen))
+		}
+	case columnCountry:
+		s = p.TopDestCountry
+	case columnType:
+		s = p.Kind
+	case columnDelta, columnNewConns, columnNewHosts:
+		s = t.deltaText(c, p)
+	}
+	if s == "" {
+		s = "-"
+	}
+	if columnSpecs[c].right {
+		return fmt.Sprintf("%*s", width, Truncate(s, width))
+	}
+	return fmt.Sprintf("%-*s", width, Truncate(s, width))
+}
+
+// deltaText is a baseline column's text: "new" in ΔRATE for a process
+// that started since, "+3" for connections or hosts, "" for none.
+func (t *processTable) deltaText(c tableColumn, p *model.ProcessSummary) string {
+	if t.base == nil || !p.ExitedAt.IsZero() {
+		return ""
+	}
+	d := t.base.delta(p)
+	switch {
+	case c == columnDelta && d.isNew:
+		return "new"
+	case c == columnDelta:
+		return formatRateDelta(d.rate)
+	case c == columnNewConns && d.newConns > 0:
+		return fmt.Sprintf("+%d", d.newConns)
+	case c == columnNewHosts && d.newHosts > 0:
+		return fmt.Sprintf("+%d", d.newHosts)
+	}
+	return ""
+}
+
+// columnStyle is the unselected style of a plain column.
+func columnStyle(c tableColumn) lipgloss.Style {
+	switch c {
+	case columnPID:
+		return stylePID
+	case columnConns:
+		return styleConnCount
+	case columnListen:
+		return styleListenCount
+	case columnCumUp:
+		return styleUpRate
+	case columnCumDown:
+		return styleDownRate
+	}
+	return styleDetailLabel
+}
+
+// columnSelColor is a plain column's text colour on the selected row.
+func columnSelColor(c tableColumn) lipgloss.TerminalColor {
+	switch c {
+	case columnConns:
+		return colorCyan
+	case columnListen:
+		return colorMagenta
+	case columnCumUp:
+		return colorGreen
+	case columnCumDown:
+		return colorRed
+	}
+	return colorFgDim
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"os"
+	"time"
+)
+
+// TestModeEnv is the environment variable that runs the TUI in test mode
+// (SetTestMode) when set to anything but "" or "0", for scripted runs in CI.
+const TestModeEnv = "SSTOP_TEST_MODE"
+
+// testClock is the time test mode stops the clock at.
+var testClock = time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+
+// TestModeFromEnv reports whether SSTOP_TEST_MODE asks for test mode.
+func TestModeFromEnv() bool {
+	v := os.Getenv(TestModeEnv)
+	return v != "" && v != "0"
+}
+
+// SetTestMode makes what the TUI draws depend only on the snapshots and
+// keys it is given: the terminal bell stays silent, the header leaves out
+// the clock, ages ("exited 12s ago", AGE, the Usage view's periods) are
+// measured from a fixed time, strong alert flashing holds steady and the
+// stale data check stops.
+func (m *Model) SetTestMode(on bool) {
+	m.testMode = on
+	m.alert.steady = on
+	m.table.clock = nil
+	if on {
+		m.table.clock = func() time.Time { return testClock }
+	}
+}
+
+// now is the time ages are measured from: the clock, or testClock in test
+// mode.
+func (m *Model) now() time.Time {
+	if m.testMode {
+		return testClock
+	}
+	return time.Now()
+}
+
+// now is the time the table measures ages from.
+func (t *processTable) now() time.Time {
+	if t.clock != nil {
+		return t.clock()
+	}
+	return time.Now()
+}
@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme names accepted by SetTheme and the [ui] theme config key.
+const (
+	ThemeDefault    = "default"
+	ThemeAccessible = "accessible"
+)
+
+// accessibleTheme is set when the monochrome theme is active. Upload and
+// download then differ by brightness and glyph instead of green vs red.
+var accessibleTheme bool
+
+type palette struct {
+	bg, fg, fgDim, accent, green, red, yellow, cyan, magenta,
+	border, selection, zebraRow lipgloss.Color
+}
+
+// defaultPalette snapshots the Tokyo Night colours from styles.go.
+var defaultPalette = palette{
+	colorBg, colorFg, colorFgDim, colorAccent, colorGreen, colorRed, colorYellow,
+	colorCyan, colorMagenta, colorBorder, colorSelection, colorZebraRow,
+}
+
+// accessiblePalette is high-contrast greys: upload ("green") is the
+// brightest text on screen, download ("red") a clearly dimmer step below.
+var accessiblePalette = palette{
+	bg: "#000000", fg: "#d0d0d0", fgDim: "#8a8a8a", accent: "#ffffff",
+	green: "#ffffff", red: "#a8a8a8", yellow: "#ffffff",
+	cyan: "#d0d0d0", magenta: "#d0d0d0",
+	border: "#6c6c6c", selection: "#444444", zebraRow: "#1c1c1c",
+}
+
+// SetTheme switches the colour palette. Call it before the program starts;
+// styles are rebuilt, not re-rendered.
+func SetTheme(name string) error {
+	p := defaultPalette
+	switch name {
+	case "", ThemeDefault:
+		accessibleTheme = false
+	case ThemeAccessible:
+		accessibleTheme = true
+		p = accessiblePalette
+	default:
+		return fmt.Errorf("unknown theme %q (want %s or %s)", name, ThemeDefault, ThemeAccessible)
+	}
+
+	colorBg, colorFg, colorFgDim, colorAccent = p.bg, p.fg, p.fgDim, p.accent
+	colorGreen, colorRed, colorYellow = p.green, p.red, p.yellow
+	colorCyan, colorMagenta = p.cyan, p.magenta
+	colorBorder, colorSelection, colorZebraRow = p.border, p.selection, p.zebraRow
+
+	buildStyles()
+	if accessibleTheme {
+		// Shape and weight carry what colour used to: states keep their
+		// icons, and the emphasis differs per state
+		styleStateEstablished = lipgloss.NewStyle().Foreground(colorFg).Bold(true)
+		styleStateClosing = lipgloss.NewStyle().Foreground(colorFg).Bold(true).Underline(true)
+		styleStateTimeWait = lipgloss.NewStyle().Foreground(colorFgDim).Faint(true)
+		styleAlertTag = styleAlertTag.Reverse(true)
+		styleKillResultErr = styleKillResultErr.Underline(true)
+		stylePortConflict = stylePortConflict.Underline(true)
+		styleBaselineChange = styleBaselineChange.Bold(true)
+	}
+	return nil
+}
+
+// downloadBar is BandwidthBar for the download column. The accessible theme
+// draws it in a shade glyph so the two bars differ without colour.
+func downloadBar(rate, maxRate float64, width int) string {
+	bar := BandwidthBar(rate, maxRate, width)
+	if !accessibleTheme {
+		return bar
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ':
+			return r
+		case '█':
+			return '▓'
+		default:
+			return '▒'
+		}
+	}, bar)
+}
+
+// directionLabel prefixes a column label with ▲/▼ in the accessible theme.
+func directionLabel(label string, up bool) string {
+	if !accessibleTheme {
+		return label
+	}
+	if up {
+		return "▲" + label
+	}
+	return "▼" + label
+}
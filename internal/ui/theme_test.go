@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestAccessibleTheme(t *testing.T) {
+	if err := SetTheme(ThemeAccessible); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { SetTheme(ThemeDefault) })
+
+	if colorGreen == defaultPalette.green || colorRed == defaultPalette.red {
+		t.Error("accessible theme should replace the green/red palette")
+	}
+	if got := rateColorIntensity(50, 100, hueGreen); got == rateColorIntensity(50, 100, hueRed) {
+		t.Errorf("up and down bars should differ in brightness, both %s", got)
+	}
+
+	up, down := BandwidthBar(100, 100, 5), downloadBar(100, 100, 5)
+	if up == down {
+		t.Errorf("download bar should use a different glyph, both %q", up)
+	}
+	if strings.ContainsRune(down, '█') {
+		t.Errorf("download bar = %q, want shade glyphs", down)
+	}
+
+	header := renderTableHeader(computeTableLayout(120, defaultColumns), SortByUp, true, false)
+	if !strings.Contains(header, "▲") || !strings.Contains(header, "▼") {
+		t.Errorf("header should mark directions: %q", header)
+	}
+}
+
+func TestSetThemeDefault(t *testing.T) {
+	if err := SetTheme("solarized"); err == nil {
+		t.Error("unknown theme should be rejected")
+	}
+	if err := SetTheme(ThemeDefault); err != nil {
+		t.Fatal(err)
+	}
+	if downloadBar(100, 100, 5) != BandwidthBar(100, 100, 5) {
+		t.Error("default theme should draw both bars alike")
+	}
+	if colorGreen != defaultPalette.green {
+		t.Errorf("colorGreen = %s after reset", colorGreen)
+	}
+}
+
+func TestHeatCells(t *testing.T) {
+	base := lipgloss.NewStyle()
+	if got := heatStyle(base, 0, 100, hueGreen).GetBackground(); got != base.GetBackground() {
+		t.Errorf("idle cell background = %v, want the row's own", got)
+	}
+	low := heatStyle(base, 10, 100, hueGreen).GetBackground()
+	high := heatStyle(base, 100, 100, hueGreen).GetBackground()
+	if low == high {
+		t.Errorf("heat should scale with rate, both %v", low)
+	}
+
+	tbl := newProcessTable()
+	tbl.update([]model.ProcessSummary{
+		{PID: 1, Name: "curl", UpRate: 2048, DownRate: 1 << 20},
+		{PID: 2, Name: "sshd", UpRate: 100, DownRate: 10},
+	})
+	plain := tbl.render(120, 10, false)
+	tbl.heat = true
+	heat := tbl.render(120, 10, false)
+	if strings.ContainsAny(ansi.Strip(heat), "█▉▊▋▌▍▎▏") {
+		t.Errorf("heat mode should not draw bars:\n%s", ansi.Strip(heat))
+	}
+	plainLines, heatLines := strings.Split(plain, "\n"), strings.Split(heat, "\n")
+	for i := range heatLines {
+		if pw, hw := lipgloss.Width(plainLines[i]), lipgloss.Width(heatLines[i]); pw != hw {
+			t.Errorf("line %d: width %d in heat mode, %d with bars", i, hw, pw)
+		}
+	}
+	if !strings.Contains(ansi.Strip(heatLines[1]), "1.0M") {
+		t.Errorf("row should keep the rate text: %q", ansi.Strip(heatLines[1]))
+	}
+}
@@ -0,0 +1,349 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// treemapGroup is what the treemap's tiles stand for.
+type treemapGroup int
+
+const (
+	treemapByProcess treemapGroup = iota
+	treemapByGroup                // pod/container/systemd group
+	treemapByHost                 // remote host
+	treemapGroupCount
+)
+
+func (g treemapGroup) String() string {
+	switch g {
+	case treemapByGroup:
+		return "group"
+	case treemapByHost:
+		return "host"
+	}
+	return "process"
+}
+
+// maxTreemapTiles caps the tiles drawn; the rest share an "other" tile.
+const maxTreemapTiles = 24
+
+// treemapItem is one tile's subject and its share of the bandwidth.
+type treemapItem struct {
+	label string
+	rate  float64 // up + down
+	pid   uint32  // by process
+	key   string  // by group or host: what Enter filters on
+	other bool    // the items past maxTreemapTiles
+}
+
+// treemapTile is an item's rectangle, in cells of the map.
+type treemapTile struct {
+	item       int
+	x, y, w, h int
+}
+
+// treemapView shows each process's (or group's or host's) share of the
+// current bandwidth as a rectangle of proportional area.
+type treemapView struct {
+	by     treemapGroup
+	cursor int // index into the items, largest first
+}
+
+func (v *treemapView) moveUp() {
+	if v.cursor > 0 {
+		v.cursor--
+	}
+}
+
+func (v *treemapView) moveDown(maxIdx int) {
+	if v.cursor < maxIdx {
+		v.cursor++
+	}
+}
+
+func (v *treemapView) nextGroup() {
+	v.by = (v.by + 1) % treemapGroupCount
+	v.cursor = 0
+}
+
+// treemapItems returns the treemap's items with traffic, largest first,
+// those past maxTreemapTiles summed into one.
+func (m *Model) treemapItems() []treemapItem {
+	snap := m.viewSnapshot(ViewTreemap)
+	var items []treemapItem
+	switch m.treemap.by {
+	case treemapByProcess:
+		for _, p := range snap.Processes {
+			items = append(items, treemapItem{label: p.Name, rate: p.UpRate + p.DownRate, pid: p.PID})
+		}
+	case treemapByGroup:
+		for _, g := range buildGroups(snap.Processes) {
+			items = append(items, treemapItem{label: g.Name, rate: g.UpRate + g.DownRate, key: g.Name})
+		}
+	case treemapByHost:
+		for _, h := range snap.RemoteHosts {
+			items = append(items, treemapItem{label: m.hostNames.Format(h.Host, h.IP), rate: h.UpRate + h.DownRate, key: h.Host})
+		}
+	}
+
+	kept := items[:0]
+	for _, it := range items {
+		if it.rate > 0 {
+			kept = append(kept, it)
+		}
+	}
+	items = kept
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].rate != items[j].rate {
+			return items[i].rate > items[j].rate
+		}
+		return items[i].label < items[j].label
+	})
+
+	if len(items) > maxTreemapTiles {
+		rest := treemapItem{other: true}
+		for _, it := range items[maxTreemapTiles-1:] {
+			rest.rate += it.rate
+		}
+		rest.label = fmt.Sprintf("%d others", len(items)-maxTreemapTiles+1)
+		items = append(items[:maxTreemapTiles-1], rest)
+	}
+	return items
+}
+
+// openTreemapItem opens what a tile stands for: the process's detail view,
+// or the process table or remote hosts filtered to the group or host.
+func (m *Model) openTreemapItem(it treemapItem) {
+	switch {
+	case it.other:
+	case m.treemap.by == treemapByProcess:
+		m.mode = ViewProcessDetail
+		m.detail = newProcessDetail(it.pid)
+	case m.treemap.by == treemapByGroup:
+		filterStr := "group:" + it.key
+		m.table.filter = filterStr
+		m.searchInput.SetValue(filterStr)
+		m.table.applyFilterAndSort()
+		m.mode = ViewProcessTable
+	case m.treemap.by == treemapByHost:
+		m.remoteHosts.scope = hostScope{}
+		m.remoteHosts.filter = it.key
+		m.remoteHosts.cursor, m.remoteHosts.offset = 0, 0
+		m.mode = ViewRemoteHosts
+	}
+}
+
+// rect is a rectangle in fractional cells.
+type rect struct{ x, y, w, h float64 }
+
+// squarify lays out areas (largest first, all > 0) over r, filling rows
+// along its shorter side while that keeps the tiles closer to square
+// (Bruls, Huizing and van Wijk). Areas are scaled to fill r.
+func squarify(areas []float64, r rect) []rect {
+	total := 0.0
+	for _, a := range areas {
+		total += a
+	}
+	if total <= 0 || r.w <= 0 || r.h <= 0 {
+		return nil
+	}
+	scaled := make([]float64, len(areas))
+	for i, a := range areas {
+		scaled[i] = a * r.w * r.h / total
+	}
+
+	// worst is the row's most elongated aspect ratio along side
+	worst := func(row []float64, side float64) float64 {
+		sum, lo, hi := 0.0, math.Inf(1), 0.0
+		for _, a := range row {
+			sum += a
+			lo, hi = min(lo, a), max(hi, a)
+		}
+		return max(side*side*hi/(sum*sum), sum*sum/(side*side*lo))
+	}
+
+	out := make([]rect, 0, len(areas))
+	for i := 0; i < len(scaled); {
+		side := min(r.w, r.h)
+		j := i + 1
+		for j < len(scaled) && worst(scaled[i:j+1], side) <= worst(scaled[i:j], side) {
+			j++
+		}
+		sum := 0.0
+		for _, a := range scaled[i:j] {
+			sum += a
+		}
+		if r.w >= r.h {
+			// a column down the left edge
+			colW := sum / r.h
+			y := r.y
+			for _, a := range scaled[i:j] {
+				out = append(out, rect{r.x, y, colW, a / colW})
+				y += a / colW
+			}
+			r.x, r.w = r.x+colW, r.w-colW
+		} else {
+			// a row along the top
+			rowH := sum / r.w
+			x := r.x
+			for _, a := range scaled[i:j] {
+				out = append(out, rect{x, r.y, a / rowH, rowH})
+				x += a / rowH
+			}
+			r.y, r.h = r.y+rowH, r.h-rowH
+		}
+		i = j
+	}
+	return out
+}
+
+// layoutTreemap places the items in a width×height map. Cells are about
+// twice as tall as wide, so the layout runs on a map twice as tall and
+// halves it, for tiles that look square. Tiles too small to get a cell
+// are left out.
+func layoutTreemap(items []treemapItem, width, height int) []treemapTile {
+	areas := make([]float64, len(items))
+	for i, it := range items {
+		areas[i] = it.rate
+	}
+	var tiles []treemapTile
+	for i, r := range squarify(areas, rect{0, 0, float64(width), 2 * float64(height)}) {
+		// Rounding both edges keeps neighbours sharing one
+		x0, x1 := int(math.Round(r.x)), int(math.Round(r.x+r.w))
+		y0, y1 := int(math.Round(r.y/2)), int(math.Round((r.y+r.h)/2))
+		x1, y1 = min(x1, width), min(y1, height)
+		if x1 > x0 && y1 > y0 {
+			tiles = append(tiles, treemapTile{item: i, x: x0, y: y0, w: x1 - x0, h: y1 - y0})
+		}
+	}
+	return tiles
+}
+
+// treemapTileAt returns the item under cell (x, y) of the map, -1 if none.
+func treemapTileAt(tiles []treemapTile, x, y int) int {
+	for _, t := range tiles {
+		if x >= t.x && x < t.x+t.w && y >= t.y && y < t.y+t.h {
+			return t.item
+		}
+	}
+	return -1
+}
+
+// Colours handed out in turn to the treemap's tiles and the flow view's
+// processes: blocks in the colour, and text on it.
+var (
+	stylePaletteFill  []lipgloss.Style
+	stylePaletteLabel []lipgloss.Style
+)
+
+// buildPaletteStyles is called from buildStyles.
+func buildPaletteStyles() {
+	stylePaletteFill, stylePaletteLabel = nil, nil
+	for _, c := range []lipgloss.Color{colorAccent, colorGreen, colorMagenta, colorYellow, colorCyan, colorRed} {
+		stylePaletteFill = append(stylePaletteFill, lipgloss.NewStyle().Foreground(c))
+		stylePaletteLabel = append(stylePaletteLabel, lipgloss.NewStyle().Background(c).Foreground(colorBg).Bold(true))
+	}
+}
+
+// render draws the items as a treemap under a title naming the selected
+// tile. Each tile is filled with blocks in its colour, with a gap on its
+// right and bottom edges, and labelled with its name, rate and share.
+func (v *treemapView) render(items []treemapItem, width, height int) string {
+	if v.cursor >= len(items) {
+		v.cursor = max(len(items)-1, 0)
+	}
+	total := 0.0
+	for _, it := range items {
+		total += it.rate
+	}
+
+	title := fmt.Sprintf("  Bandwidth share by %s  %s total", v.by, FormatRate(total))
+	if len(items) > 0 {
+		sel := items[v.cursor]
+		title += fmt.Sprintf("  ▸ %s %s (%.0f%%)", sel.label, FormatRate(sel.rate), sel.rate/total*100)
+	}
+	lines := []string{styleTitle.Render(Truncate(title, width))}
+	if len(items) == 0 {
+		lines = append(lines, styleDetailLabel.Render("  No traffic"))
+		return strings.Join(lines, "\n")
+	}
+
+	mapH := max(height-1, 1)
+	tiles := layoutTreemap(items, width, mapH)
+
+	// Which tile each cell belongs to, and the label text over it
+	owner := make([][]int, mapH)
+	text := make([][]rune, mapH)
+	for y := range owner {
+		owner[y] = make([]int, width)
+		text[y] = make([]rune, width)
+		for x := range owner[y] {
+			owner[y][x] = -1
+		}
+	}
+	for n, t := range tiles {
+		innerW, innerH := t.w, t.h
+		if t.x+t.w < width && t.w > 1 {
+			innerW-- // gap to the right neighbour
+		}
+		if t.y+t.h < mapH && t.h > 1 {
+			innerH-- // gap to the one below
+		}
+		for y := t.y; y < t.y+innerH; y++ {
+			for x := t.x; x < t.x+innerW; x++ {
+				owner[y][x] = n
+			}
+		}
+
+		it := items[t.item]
+		share := fmt.Sprintf("%s %.0f%%", strings.TrimSpace(FormatRateCompact(it.rate)), it.rate/total*100)
+		label := []string{it.label, share}
+		if innerH == 1 {
+			label = []string{it.label + " " + share}
+		}
+		for i, l := range label {
+			if i >= innerH {
+				break
+			}
+			pad := min(innerW-1, 1) // a cell of fill before the text
+			for j, r := range []rune(Truncate(l, innerW-pad)) {
+				text[t.y+i][t.x+pad+j] = r
+			}
+		}
+	}
+
+	for y := 0; y < mapH; y++ {
+		var b strings.Builder
+		for x := 0; x < width; {
+			n := owner[y][x]
+			end := x
+			for end < width && owner[y][end] == n && (text[y][end] != 0) == (text[y][x] != 0) {
+				end++
+			}
+			switch {
+			case n < 0:
+				b.WriteString(strings.Repeat(" ", end-x))
+			case text[y][x] != 0:
+				s := stylePaletteLabel[tiles[n].item%len(stylePaletteLabel)]
+				if tiles[n].item == v.cursor {
+					s = s.Underline(true)
+				}
+				b.WriteString(s.Render(string(text[y][x:end])))
+			default:
+				fill := "█"
+				if tiles[n].item == v.cursor {
+					fill = "▓"
+				}
+				b.WriteString(stylePaletteFill[tiles[n].item%len(stylePaletteFill)].Render(strings.Repeat(fill, end-x)))
+			}
+			x = end
+		}
+		lines = append(lines, b.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestSquarifyFillsRect(t *testing.T) {
+	areas := []float64{6, 6, 4, 3, 2, 2, 1}
+	rects := squarify(areas, rect{0, 0, 6, 4})
+	if len(rects) != len(areas) {
+		t.Fatalf("got %d rects, want %d", len(rects), len(areas))
+	}
+	total := 0.0
+	for i, r := range rects {
+		// 24 cells of area for a total of 24
+		if got := r.w * r.h; math.Abs(got-areas[i]) > 1e-9 {
+			t.Errorf("rect %d area = %.3f, want %.0f", i, got, areas[i])
+		}
+		if r.x < -1e-9 || r.y < -1e-9 || r.x+r.w > 6+1e-9 || r.y+r.h > 4+1e-9 {
+			t.Errorf("rect %d = %+v, outside the 6×4 rect", i, r)
+		}
+		total += r.w * r.h
+	}
+	if math.Abs(total-24) > 1e-9 {
+		t.Errorf("total area = %.3f, want 24", total)
+	}
+}
+
+func TestLayoutTreemapTiles(t *testing.T) {
+	items := []treemapItem{{label: "a", rate: 600}, {label: "b", rate: 300}, {label: "c", rate: 100}}
+	tiles := layoutTreemap(items, 40, 10)
+	if len(tiles) != 3 {
+		t.Fatalf("tiles = %+v, want 3", tiles)
+	}
+	covered := 0
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 40; x++ {
+			n := 0
+			for _, tile := range tiles {
+				if x >= tile.x && x < tile.x+tile.w && y >= tile.y && y < tile.y+tile.h {
+					n++
+				}
+			}
+			if n > 1 {
+				t.Fatalf("cell %d,%d is in %d tiles", x, y, n)
+			}
+			covered += n
+		}
+	}
+	if covered != 400 {
+		t.Errorf("tiles cover %d of 400 cells", covered)
+	}
+	if a := tiles[0].w * tiles[0].h; a < 200 || a > 280 {
+		t.Errorf("a's tile has %d cells, want about 60%% of 400", a)
+	}
+	if got := treemapTileAt(tiles, tiles[2].x, tiles[2].y); got != 2 {
+		t.Errorf("tile at c's corner = %d, want 2", got)
+	}
+}
+
+func TestTreemapItemsCapped(t *testing.T) {
+	m := New(nil)
+	for i := 0; i < maxTreemapTiles+5; i++ {
+		m.snapshot.Processes = append(m.snapshot.Processes,
+			model.ProcessSummary{PID: uint32(100 + i), Name: fmt.Sprintf("p%d", i), UpRate: float64(1000 - i)})
+	}
+	m.snapshot.Processes = append(m.snapshot.Processes, model.ProcessSummary{PID: 1, Name: "idle"})
+
+	items := m.treemapItems()
+	if len(items) != maxTreemapTiles {
+		t.Fatalf("%d items, want %d", len(items), maxTreemapTiles)
+	}
+	if items[0].pid != 100 {
+		t.Errorf("first item = %+v, want the busiest", items[0])
+	}
+	rest := items[len(items)-1]
+	if !rest.other || rest.label != "6 others" || rest.rate != 977+976+975+974+973+972 {
+		t.Errorf("last item = %+v, want the 6 smallest summed", rest)
+	}
+}
+
+func TestTreemapView(t *testing.T) {
+	m := New(nil)
+	m.width, m.height = 100, 24
+	next, _ := m.Update(SnapshotMsg(model.Snapshot{
+		Processes: []model.ProcessSummary{
+			{PID: 10, Name: "firefox", DownRate: 3000, ServiceName: "user.slice"},
+			{PID: 11, Name: "rsync", UpRate: 1000, ServiceName: "backup.service"},
+		},
+		RemoteHosts: []model.RemoteHostSummary{{Host: "example.com", DownRate: 3000}},
+	}))
+	m = next.(Model)
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("m")})
+	if m.mode != ViewTreemap {
+		t.Fatalf("m: mode = %v, want treemap", m.mode)
+	}
+	view := ansi.Strip(m.View())
+	for _, want := range []string{"by process", "firefox", "75%", "rsync", "25%", "█"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("treemap lacks %q:\n%s", want, view)
+		}
+	}
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "by host") || !strings.Contains(view, "example.com") {
+		t.Errorf("s twice should show hosts:\n%s", view)
+	}
+	m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+
+	// Click rsync's tile to select it, again to open it
+	header := strings.Count(m.renderTop(), "\n") + 1
+	tiles := layoutTreemap(m.treemapItems(), m.width, m.height-header-2)
+	click := tea.MouseMsg{X: tiles[1].x, Y: header + 1 + tiles[1].y, Action: tea.MouseActionPress, Button: tea.MouseButtonLeft}
+	next, _ = m.handleMouse(click)
+	m = next.(Model)
+	if m.treemap.cursor != 1 {
+		t.Fatalf("click: cursor = %d, want rsync's tile", m.treemap.cursor)
+	}
+	next, _ = m.handleMouse(click)
+	m = next.(Model)
+	if m.mode != ViewProcessDetail || m.detail.pid != 11 {
+		t.Errorf("second click: mode %v pid %d, want rsync's detail", m.mode, m.detail.pid)
+	}
+}
@@ -0,0 +1,202 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/usage"
+)
+
+// usageView shows persisted per-process and per-interface byte totals for
+// today, this week or this month.
+type usageView struct {
+	cursor     int
+	offset     int
+	viewHeight int
+	window     listWindow // process rows last drawn
+	period     usage.Period
+}
+
+func (v *usageView) moveUp() {
+	if v.cursor > 0 {
+		v.cursor--
+	}
+}
+
+func (v *usageView) moveDown(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	if v.cursor < maxIdx {
+		v.cursor++
+	}
+}
+
+func (v *usageView) pageUp() {
+	v.cursor -= v.viewHeight / 2
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+}
+
+func (v *usageView) pageDown(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	v.cursor += v.viewHeight / 2
+	if v.cursor > maxIdx {
+		v.cursor = maxIdx
+	}
+}
+
+func (v *usageView) goHome() {
+	v.cursor = 0
+}
+
+func (v *usageView) goEnd(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	v.cursor = maxIdx
+}
+
+func (v *usageView) nextPeriod() {
+	v.period = v.period.Next()
+	v.cursor = 0
+	v.offset = 0
+}
+
+// usageRowsTop returns the number of lines above the first process row:
+// title, blank, interface header + rows + blank (if any), process header.
+func usageRowsTop(ifaces []usage.Entry) int {
+	n := 3
+	if len(ifaces) > 0 {
+		n += len(ifaces) + 2
+	}
+	return n
+}
+
+func (v *usageView) render(store *usage.Store, now time.Time, width, height int) string {
+	v.viewHeight = height
+	v.window = listWindow{}
+
+	if store == nil {
+		return strings.Join([]string{
+			styleTitle.Render("  Usage"),
+			"",
+			styleDetailLabel.Render("  Usage tracking is off (playback, or --usage-file \"\")"),
+		}, "\n")
+	}
+
+	procs := store.Processes(v.period, now)
+	ifaces := store.Interfaces(v.period, now)
+
+	if len(procs) > 0 && v.cursor >= len(procs) {
+		v.cursor = len(procs) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+
+	since := v.period.Start(now).Format("Mon Jan 2")
+	titleLine := styleTitle.Render("  Usage — "+v.period.String()) +
+		styleDetailLabel.Render("  since "+since)
+
+	// NAME | UP | DOWN | TOTAL | SHARE
+	upW := 10
+	downW := 10
+	totalW := 10
+	shareW := 12
+	fixedW := upW + downW + totalW + shareW + 6
+	nameW := width - fixedW
+	if nameW < 10 {
+		nameW = 10
+	}
+	header := func(first string) string {
+		return styleTableHeader.Render(fmt.Sprintf("  %-*s %*s %*s %*s %-*s",
+			nameW, first, upW, "UP", downW, "DOWN", totalW, "TOTAL▾", shareW, "SHARE"))
+	}
+	line := func(e usage.Entry, sum uint64) string {
+		return fmt.Sprintf("  %-*s %*s %*s %*s %-*s",
+			nameW, truncateStr(e.Name, nameW),
+			upW, FormatBytes(e.Up),
+			downW, FormatBytes(e.Down),
+			totalW, FormatBytes(e.Total()),
+			shareW, shareBar(e.Total(), sum, shareW),
+		)
+	}
+
+	parts := []string{titleLine, ""}
+
+	if len(ifaces) > 0 {
+		var sum uint64
+		for _, e := range ifaces {
+			sum += e.Total()
+		}
+		parts = append(parts, header("INTERFACE"))
+		for _, e := range ifaces {
+			parts = append(parts, styleTableRow.Render(line(e, sum)))
+		}
+		parts = append(parts, "")
+	}
+
+	parts = append(parts, header("PROCESS"))
+	if len(procs) == 0 {
+		parts = append(parts, styleDetailLabel.Render("  No traffic recorded "+v.period.String()))
+		return strings.Join(parts, "\n")
+	}
+
+	var sum uint64
+	for _, e := range procs {
+		sum += e.Total()
+	}
+
+	rowsAvail := height - len(parts)
+	if rowsAvail < 1 {
+		rowsAvail = 1
+	}
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+rowsAvail {
+		v.offset = v.cursor - rowsAvail + 1
+	}
+	end := v.offset + rowsAvail
+	if end > len(procs) {
+		end = len(procs)
+	}
+	v.window = listWindow{offset: v.offset, rows: end - v.offset, total: len(procs)}
+
+	var rows []string
+	for idx := v.offset; idx < end; idx++ {
+		var rowStyle lipgloss.Style
+		if idx == v.cursor {
+			rowStyle = styleTableRowSelected
+		} else if idx%2 == 1 {
+			rowStyle = styleZebraRow
+		} else {
+			rowStyle = styleTableRow
+		}
+		rows = append(rows, rowStyle.Render(line(procs[idx], sum)))
+	}
+	parts = append(parts, withScrollbar(rows, v.window, width)...)
+
+	return strings.Join(parts, "\n")
+}
+
+// shareBar renders part/total as a bar of width w followed by a percentage.
+func shareBar(part, total uint64, w int) string {
+	if total == 0 {
+		return ""
+	}
+	frac := float64(part) / float64(total)
+	pct := fmt.Sprintf(" %3.0f%%", frac*100)
+	barW := w - len(pct)
+	if barW < 1 {
+		return pct
+	}
+	filled := int(frac*float64(barW) + 0.5)
+	return strings.Repeat("█", filled) + strings.Repeat("░", barW-filled) + pct
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/usage"
+)
+
+func TestShareBar(t *testing.T) {
+	tests := []struct {
+		part, total uint64
+		want        string
+	}{
+		{50, 100, "███░░░  50%"},
+		{100, 100, "██████ 100%"},
+		{0, 100, "░░░░░░   0%"},
+		{1, 0, ""},
+	}
+	for _, tt := range tests {
+		if got := shareBar(tt.part, tt.total, 11); got != tt.want {
+			t.Errorf("shareBar(%d, %d) = %q, want %q", tt.part, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestUsageViewRender(t *testing.T) {
+	var v usageView
+	if out := v.render(nil, time.Now(), 100, 20); !strings.Contains(out, "tracking is off") {
+		t.Errorf("nil store render = %q", out)
+	}
+
+	store, err := usage.Open(filepath.Join(t.TempDir(), "usage.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	store.Observe(model.Snapshot{
+		Timestamp:  now,
+		Processes:  []model.ProcessSummary{{PID: 1, Name: "rsync", CumUp: 3 << 20}},
+		Interfaces: []model.InterfaceStats{{Name: "eth0"}},
+	})
+	store.Observe(model.Snapshot{
+		Timestamp:  now,
+		Interfaces: []model.InterfaceStats{{Name: "eth0", BytesSent: 3 << 20}},
+	})
+
+	out := v.render(store, now, 100, 20)
+	for _, want := range []string{"Usage — today", "INTERFACE", "eth0", "PROCESS", "rsync", "3.0 MB"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("render missing %q:\n%s", want, out)
+		}
+	}
+
+	// Process rows start after title, blank, interface header + 1 row + blank, process header
+	lines := strings.Split(out, "\n")
+	top := usageRowsTop(store.Interfaces(v.period, now))
+	if top >= len(lines) || !strings.Contains(lines[top], "rsync") {
+		t.Errorf("usageRowsTop = %d, line there = %q", top, lines[min(top, len(lines)-1)])
+	}
+
+	v.nextPeriod()
+	if v.period != usage.Week {
+		t.Errorf("nextPeriod = %v, want week", v.period)
+	}
+}
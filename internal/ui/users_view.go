@@ -0,0 +1,263 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// unknownUser is the bucket for processes whose owner could not be read
+// (non-Linux platforms, exited processes, unmapped sockets).
+const unknownUser = "unknown"
+
+// userEntry represents bandwidth aggregated by process owner.
+type userEntry struct {
+	Name      string // username, numeric UID, or unknownUser
+	UID       uint32
+	Known     bool // false for the unknownUser bucket
+	ProcCount int
+	UpRate    float64
+	DownRate  float64
+	ConnCount int
+}
+
+// userSort defines the users view sort order.
+type userSort int
+
+const (
+	userSortRate  userSort = iota // total bandwidth (default)
+	userSortConns                 // connection count
+	userSortProcs                 // process count
+	userSortName                  // username
+	userSortCount
+)
+
+// usersView manages the per-user aggregation view.
+type usersView struct {
+	cursor     int
+	offset     int
+	viewHeight int
+	window     listWindow // rows last drawn
+	sortBy     userSort
+}
+
+func (v *usersView) moveUp() {
+	if v.cursor > 0 {
+		v.cursor--
+	}
+}
+
+func (v *usersView) moveDown(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	if v.cursor < maxIdx {
+		v.cursor++
+	}
+}
+
+func (v *usersView) pageUp() {
+	v.cursor -= v.viewHeight / 2
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+}
+
+func (v *usersView) pageDown(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	v.cursor += v.viewHeight / 2
+	if v.cursor > maxIdx {
+		v.cursor = maxIdx
+	}
+}
+
+func (v *usersView) goHome() {
+	v.cursor = 0
+}
+
+func (v *usersView) goEnd(maxIdx int) {
+	if maxIdx < 0 {
+		return
+	}
+	v.cursor = maxIdx
+}
+
+func (v *usersView) nextSort() {
+	v.sortBy = (v.sortBy + 1) % userSortCount
+}
+
+// buildUsers aggregates processes by owner and sorts the result.
+func buildUsers(procs []model.ProcessSummary, sortBy userSort) []userEntry {
+	users := make(map[string]*userEntry)
+
+	for i := range procs {
+		p := &procs[i]
+		name := p.User
+		if name == "" {
+			name = unknownUser
+		}
+		u, ok := users[name]
+		if !ok {
+			u = &userEntry{Name: name}
+			if p.UID != nil {
+				u.UID, u.Known = *p.UID, true
+			}
+			users[name] = u
+		}
+		u.ProcCount++
+		u.UpRate += p.UpRate
+		u.DownRate += p.DownRate
+		u.ConnCount += p.ConnCount
+	}
+
+	result := make([]userEntry, 0, len(users))
+	for _, u := range users {
+		result = append(result, *u)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := &result[i], &result[j]
+		switch sortBy {
+		case userSortConns:
+			if a.ConnCount != b.ConnCount {
+				return a.ConnCount > b.ConnCount
+			}
+		case userSortProcs:
+			if a.ProcCount != b.ProcCount {
+				return a.ProcCount > b.ProcCount
+			}
+		case userSortName:
+			return a.Name < b.Name
+		default:
+			ta := a.UpRate + a.DownRate
+			tb := b.UpRate + b.DownRate
+			if ta != tb {
+				return ta > tb
+			}
+		}
+		return a.Name < b.Name
+	})
+
+	return result
+}
+
+func (v *usersView) render(procs []model.ProcessSummary, width, height int) string {
+	users := buildUsers(procs, v.sortBy)
+
+	v.viewHeight = height
+	v.window = listWindow{}
+
+	// Clamp cursor if user count changed
+	if len(users) > 0 && v.cursor >= len(users) {
+		v.cursor = len(users) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+
+	titleLine := styleTitle.Render("  Users")
+
+	// Column widths
+	// USER | UID | PROCS | UP/s | DOWN/s | CONNS
+	uidW := 8
+	procsW := 6
+	upW := 8
+	downW := 8
+	connsW := 6
+	fixedW := uidW + procsW + upW + downW + connsW + 7 // 7 for separators/padding
+	nameW := rowsWidth(width, len(users), height-2) - fixedW
+	if nameW < 10 {
+		nameW = 10
+	}
+
+	label := func(name string, s userSort) string {
+		if v.sortBy == s {
+			return name + "▾"
+		}
+		return name
+	}
+	rateLabel := func(name string) string {
+		if v.sortBy == userSortRate {
+			return name + "▾"
+		}
+		return name
+	}
+
+	headerLine := fmt.Sprintf("  %-*s %*s %*s %*s %*s %*s",
+		nameW, label("USER", userSortName),
+		uidW, "UID",
+		procsW, label("PROCS", userSortProcs),
+		upW, rateLabel("UP/s"),
+		downW, rateLabel("DOWN/s"),
+		connsW, label("CONNS", userSortConns),
+	)
+	headerStyled := styleTableHeader.Render(headerLine)
+
+	// Available rows
+	rowsAvail := height - 2 // title + header
+	if rowsAvail < 1 {
+		rowsAvail = 1
+	}
+
+	// Adjust offset
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+rowsAvail {
+		v.offset = v.cursor - rowsAvail + 1
+	}
+
+	if len(users) == 0 {
+		empty := styleDetailLabel.Render("  No active processes")
+		return strings.Join([]string{titleLine, headerStyled, empty}, "\n")
+	}
+
+	var rows []string
+	end := v.offset + rowsAvail
+	if end > len(users) {
+		end = len(users)
+	}
+	v.window = listWindow{offset: v.offset, rows: end - v.offset, total: len(users)}
+
+	for idx := v.offset; idx < end; idx++ {
+		u := users[idx]
+
+		uid := "-"
+		if u.Known {
+			uid = strconv.FormatUint(uint64(u.UID), 10)
+		}
+
+		line := fmt.Sprintf("  %-*s %*s %*d %*s %*s %*d",
+			nameW, truncateStr(u.Name, nameW),
+			uidW, truncateStr(uid, uidW),
+			procsW, u.ProcCount,
+			upW, FormatRateCompact(u.UpRate),
+			downW, FormatRateCompact(u.DownRate),
+			connsW, u.ConnCount,
+		)
+
+		var rowStyle lipgloss.Style
+		if idx == v.cursor {
+			rowStyle = styleTableRowSelected
+		} else if idx%2 == 1 {
+			rowStyle = styleZebraRow
+		} else {
+			rowStyle = styleTableRow
+		}
+
+		rows = append(rows, rowStyle.Render(line))
+	}
+
+	var parts []string
+	parts = append(parts, titleLine)
+	parts = append(parts, headerStyled)
+	parts = append(parts, withScrollbar(rows, v.window, width)...)
+
+	return strings.Join(parts, "\n")
+}
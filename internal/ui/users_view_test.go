@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func uidOf(uid uint32) *uint32 { return &uid }
+
+func testUserProcs() []model.ProcessSummary {
+	return []model.ProcessSummary{
+		{PID: 1, Name: "nginx", UID: uidOf(33), User: "www-data", UpRate: 500, DownRate: 100, ConnCount: 40},
+		{PID: 2, Name: "php-fpm", UID: uidOf(33), User: "www-data", UpRate: 50, DownRate: 50, ConnCount: 2},
+		{PID: 3, Name: "firefox", UID: uidOf(1000), User: "alice", UpRate: 100, DownRate: 900, ConnCount: 12},
+		{PID: 4, Name: "sshd", UID: uidOf(0), User: "root", UpRate: 1, DownRate: 1, ConnCount: 1},
+		{PID: 5, Name: "?", UpRate: 0, DownRate: 0, ConnCount: 3},
+	}
+}
+
+func TestBuildUsers_Aggregates(t *testing.T) {
+	users := buildUsers(testUserProcs(), userSortRate)
+
+	if len(users) != 4 {
+		t.Fatalf("got %d users, want 4", len(users))
+	}
+
+	// Sorted by total rate: alice 1000, www-data 700, root 2, unknown 0
+	wantOrder := []string{"alice", "www-data", "root", unknownUser}
+	for i, want := range wantOrder {
+		if users[i].Name != want {
+			t.Errorf("users[%d].Name = %q, want %q", i, users[i].Name, want)
+		}
+	}
+
+	www := users[1]
+	if www.UID != 33 || !www.Known {
+		t.Errorf("www-data UID = %d known=%v, want 33 true", www.UID, www.Known)
+	}
+	if www.ProcCount != 2 || www.ConnCount != 42 {
+		t.Errorf("www-data procs/conns = %d/%d, want 2/42", www.ProcCount, www.ConnCount)
+	}
+	if www.UpRate != 550 || www.DownRate != 150 {
+		t.Errorf("www-data rates = %.0f/%.0f, want 550/150", www.UpRate, www.DownRate)
+	}
+
+	if users[3].Known {
+		t.Error("unknown bucket should not be marked known")
+	}
+}
+
+func TestBuildUsers_Sorting(t *testing.T) {
+	tests := []struct {
+		sortBy userSort
+		want   []string
+	}{
+		{userSortConns, []string{"www-data", "alice", unknownUser, "root"}},
+		{userSortProcs, []string{"www-data", "alice", "root", unknownUser}},
+		{userSortName, []string{"alice", "root", unknownUser, "www-data"}},
+	}
+	for _, tt := range tests {
+		users := buildUsers(testUserProcs(), tt.sortBy)
+		var got []string
+		for _, u := range users {
+			got = append(got, u.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("sort %d: got %v, want %v", tt.sortBy, got, tt.want)
+		}
+	}
+}
+
+func TestUsersViewNextSortWraps(t *testing.T) {
+	var v usersView
+	for i := 0; i < int(userSortCount); i++ {
+		v.nextSort()
+	}
+	if v.sortBy != userSortRate {
+		t.Errorf("sortBy = %d after full cycle, want %d", v.sortBy, userSortRate)
+	}
+}
+
+func TestUsersViewRender(t *testing.T) {
+	var v usersView
+	out := v.render(testUserProcs(), 100, 20)
+	for _, want := range []string{"USER", "www-data", "alice", unknownUser} {
+		if !strings.Contains(out, want) {
+			t.Errorf("render output missing %q", want)
+		}
+	}
+
+	empty := v.render(nil, 100, 20)
+	if !strings.Contains(empty, "No active processes") {
+		t.Error("expected empty-state message")
+	}
+}
@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// viewFilter returns the current view's filter; ok is false for views
+// that can't be filtered. Each view keeps its own, so switching views and
+// coming back finds the list as it was left.
+func (m *Model) viewFilter() (filter string, ok bool) {
+	switch m.mode {
+	case ViewProcessTable:
+		return m.table.filter, true
+	case ViewRemoteHosts:
+		return m.remoteHosts.filter, true
+	case ViewListenPorts:
+		return m.listenPorts.filter, true
+	case ViewGroups:
+		return m.groups.filter, true
+	case ViewConnections:
+		return m.connections.filter, true
+	}
+	return "", false
+}
+
+// setViewFilter sets the current view's filter. The cursor goes back to
+// the top of a list whose rows changed under it.
+func (m *Model) setViewFilter(filter string) {
+	switch m.mode {
+	case ViewProcessTable:
+		m.table.filter = filter
+		m.table.applyFilterAndSort()
+	case ViewRemoteHosts:
+		if filter != m.remoteHosts.filter {
+			m.remoteHosts.filter = filter
+			m.remoteHosts.cursor, m.remoteHosts.offset = 0, 0
+		}
+	case ViewListenPorts:
+		if filter != m.listenPorts.filter {
+			m.listenPorts.filter = filter
+			m.listenPorts.cursor, m.listenPorts.offset = 0, 0
+		}
+	case ViewGroups:
+		if filter != m.groups.filter {
+			m.groups.filter = filter
+			m.groups.cursor, m.groups.offset = 0, 0
+		}
+	case ViewConnections:
+		if filter != m.connections.filter {
+			m.connections.filter = filter
+			m.connections.cursor, m.connections.offset = 0, 0
+		}
+	}
+}
+
+// containsFold reports whether any of fields contains the lower-cased
+// filter, ignoring case.
+func containsFold(filter string, fields ...string) bool {
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// textTerm is a term as the views other than the process table search
+// for it: lower-cased text.
+func textTerm(t Filter) string {
+	return strings.ToLower(t.raw)
+}
+
+// remoteHostRows returns the remote hosts view's rows, limited to the
+// country or AS drilled into from the Countries view and the view's filter
+// (host, IP, country, AS or process name; or a connection term, e.g.
+// proto:udp for hosts reached over UDP).
+func (m *Model) remoteHostRows() []model.RemoteHostSummary {
+	snap := m.viewSnapshot(ViewRemoteHosts)
+	hosts := m.remoteHosts.scope.filter(snap.RemoteHosts)
+	f := ParseFilter(m.remoteHosts.filter)
+	if f.IsEmpty() {
+		return m.remoteHosts.group(hosts)
+	}
+
+	// Hosts with a connection matching a connection term, by term
+	byTerm := make(map[string]map[string]bool)
+	reachedBy := func(t Filter) map[string]bool {
+		if ips, ok := byTerm[t.raw]; ok {
+			return ips
+		}
+		ips := make(map[string]bool)
+		for _, p := range snap.Processes {
+			for i := range p.Connections {
+				if c := &p.Connections[i]; c.DstIP != nil && t.matchConn(c) {
+					ips[c.DstIP.String()] = true
+				}
+			}
+		}
+		byTerm[t.raw] = ips
+		return ips
+	}
+
+	var out []model.RemoteHostSummary
+	for _, h := range hosts {
+		ip := ""
+		if h.IP != nil {
+			ip = h.IP.String()
+		}
+		if f.eval(func(t Filter) bool {
+			if t.isConnTerm() {
+				return ip != "" && reachedBy(t)[ip]
+			}
+			q := textTerm(t)
+			return containsFold(q, h.Host, ip, h.Country, h.City, h.ASOrg) || containsFold(q, h.Processes...)
+		}) {
+			out = append(out, h)
+		}
+	}
+	return m.remoteHosts.group(out)
+}
+
+// listenPortRows returns the ports view's rows matching its filter
+// (protocol, address, port, process, container or command line; or
+// proto:).
+func (m *Model) listenPortRows() []model.ListenPortEntry {
+	f := ParseFilter(m.listenPorts.filter)
+	if f.IsEmpty() {
+		return m.snapshot.ListenPorts
+	}
+	var out []model.ListenPortEntry
+	for _, p := range m.snapshot.ListenPorts {
+		if f.eval(func(t Filter) bool {
+			if want, ok := t.protoTerm(); ok {
+				return protoMatches(want, p.Proto, p.Port, 0)
+			}
+			return containsFold(textTerm(t), p.Proto.String(), model.AddrPort(p.IP, p.Port), fmt.Sprint(p.PID), p.Process, p.Container, p.Cmdline)
+		}) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// groupRows returns the groups view's rows matching its filter (name or
+// type). A filter with key terms, such as proto:udp or down>1M, instead
+// groups just the processes it matches.
+func (m *Model) groupRows() []groupEntry {
+	snap := m.viewSnapshot(ViewGroups)
+	f := ParseFilter(m.groups.filter)
+	if f.hasKey() {
+		// Groups of the processes matching
+		var procs []model.ProcessSummary
+		for i := range snap.Processes {
+			if f.Match(&snap.Processes[i]) {
+				procs = append(procs, snap.Processes[i])
+			}
+		}
+		return buildGroups(procs)
+	}
+	groups := buildGroups(snap.Processes)
+	if f.IsEmpty() {
+		return groups
+	}
+	var out []groupEntry
+	for _, g := range groups {
+		if f.eval(func(t Filter) bool { return containsFold(textTerm(t), g.Name, g.Type) }) {
+			out = append(out, g)
+		}
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// heldSnapshot is the snapshot a view slowed by [ui.refresh] shows until
+// its interval passes, so rebuilding groups, hosts or a graph from every
+// poll doesn't force a slower poll on the process table.
+type heldSnapshot struct {
+	every time.Duration
+	snap  model.Snapshot // zero: take the next one
+}
+
+// SetViewRefresh slows views to their own refresh interval: view name
+// (config.RefreshViews) to a duration.
+func (m *Model) SetViewRefresh(cfg map[string]string) error {
+	held := make(map[ViewMode]*heldSnapshot, len(cfg))
+	for name, every := range cfg {
+		v, ok := presetViews[name]
+		if !ok || v == ViewProcessTable {
+			return fmt.Errorf("%q is not a view with its own refresh", name)
+		}
+		d, err := time.ParseDuration(every)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%s: invalid interval %q", name, every)
+		}
+		held[v] = &heldSnapshot{every: d}
+	}
+	m.held = held
+	return nil
+}
+
+// holdSnapshots gives each slowed view snap if its interval has passed.
+// A snapshot older than the held one (a playback seek) is taken at once.
+func (m *Model) holdSnapshots(snap model.Snapshot) {
+	for _, h := range m.held {
+		age := snap.Timestamp.Sub(h.snap.Timestamp)
+		if h.snap.Timestamp.IsZero() || age >= h.every || age < 0 {
+			h.snap = snap
+		}
+	}
+}
+
+// releaseHeld makes slowed views show the current snapshot and take the
+// next one, after a refresh, privacy or host change.
+func (m *Model) releaseHeld() {
+	for _, h := range m.held {
+		h.snap = model.Snapshot{}
+	}
+}
+
+// viewSnapshot returns the snapshot view v shows: its held one if it is
+// slowed, else the current one.
+func (m *Model) viewSnapshot(v ViewMode) *model.Snapshot {
+	if h := m.held[v]; h != nil && !h.snap.Timestamp.IsZero() {
+		return &h.snap
+	}
+	return &m.snapshot
+}
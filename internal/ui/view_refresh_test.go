@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func TestViewRefresh(t *testing.T) {
+	m := New(nil)
+	if err := m.SetViewRefresh(map[string]string{"groups": "5s"}); err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	feed := func(at time.Duration, services ...string) {
+		snap := model.Snapshot{Timestamp: t0.Add(at)}
+		for i, s := range services {
+			snap.Processes = append(snap.Processes, model.ProcessSummary{PID: uint32(i + 1), Name: s, ServiceName: s + ".service"})
+		}
+		next, _ := m.Update(SnapshotMsg(snap))
+		m = next.(Model)
+	}
+	groups := func() int { return len(m.groupRows()) }
+
+	feed(0, "sshd")
+	feed(time.Second, "sshd", "nginx")
+	if got := len(m.snapshot.Processes); got != 2 {
+		t.Fatalf("process table has %d processes, want 2", got)
+	}
+	if groups() != 1 {
+		t.Errorf("groups refreshed after 1s: %d groups, want the held 1", groups())
+	}
+
+	feed(5*time.Second, "sshd", "nginx", "redis")
+	if groups() != 3 {
+		t.Errorf("groups after 5s: %d, want 3", groups())
+	}
+
+	// Refreshing shows the current snapshot at once
+	feed(6*time.Second, "sshd")
+	m.refreshNow()
+	if groups() != 1 {
+		t.Errorf("groups after refresh: %d, want 1", groups())
+	}
+
+	// Views not slowed follow every poll
+	if m.viewSnapshot(ViewRemoteHosts) != &m.snapshot {
+		t.Error("remote hosts should show the current snapshot")
+	}
+}
+
+func TestSetViewRefreshInvalid(t *testing.T) {
+	m := New(nil)
+	if err := m.SetViewRefresh(map[string]string{"processes": "5s"}); err == nil {
+		t.Error("want an error for the process table")
+	}
+	if err := m.SetViewRefresh(map[string]string{"hosts": "often"}); err == nil {
+		t.Error("want an error for a bad interval")
+	}
+}
@@ -0,0 +1,251 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+
+	"github.com/googlesky/sstop/internal/config"
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/notify"
+)
+
+// watcher is watch mode: a basic intrusion detector alerting when a
+// process opens a listening port that was not open when sstop started,
+// or connects to a destination on a deny list.
+type watcher struct {
+	listen  bool
+	bell    bool
+	flash   bool
+	actions notify.Channels
+
+	seen   map[string]bool // listeners seen so far; nil before the first snapshot
+	opened map[string]bool // new listeners still open, for the header
+
+	deny []*denyRule
+}
+
+// denyRule matches connections by remote country, ASN or address.
+type denyRule struct {
+	name      string
+	countries map[string]bool
+	asns      map[uint32]bool
+	prefixes  []netip.Prefix
+	bell      bool
+	flash     bool
+	actions   notify.Channels
+	triggered map[string]bool // connections alerted on, while still open
+}
+
+// newWatcher returns the watcher cfg configures, nil when watch mode is
+// off.
+func newWatcher(cfg config.Watch) *watcher {
+	if !cfg.Listen && len(cfg.Deny) == 0 {
+		return nil
+	}
+	w := &watcher{
+		listen:  cfg.Listen,
+		bell:    cfg.Notifies(config.NotifyBell),
+		flash:   cfg.Notifies(config.NotifyFlash),
+		actions: alertChannels(cfg.Notifies),
+		opened:  make(map[string]bool),
+	}
+	for _, r := range cfg.Deny {
+		d := &denyRule{
+			name:      r.Name,
+			countries: make(map[string]bool),
+			asns:      make(map[uint32]bool),
+			prefixes:  r.Prefixes(),
+			bell:      r.Notifies(config.NotifyBell),
+			flash:     r.Notifies(config.NotifyFlash),
+			actions:   alertChannels(r.Notifies),
+			triggered: make(map[string]bool),
+		}
+		if d.name == "" {
+			d.name = "deny"
+		}
+		for _, c := range config.ExpandCountries(r.Countries) {
+			d.countries[c] = true
+		}
+		for _, a := range r.ASNs {
+			d.asns[a] = true
+		}
+		w.deny = append(w.deny, d)
+	}
+	return w
+}
+
+// watchEvent is an alert the watcher raised.
+type watchEvent struct {
+	rule    string
+	pid     uint32
+	name    string
+	text    string
+	bell    bool
+	actions notify.Channels
+}
+
+// listenerKey identifies a listener across polls. The PID is left out so
+// a service restarting on its own port is not new.
+func listenerKey(lp *model.ListenPortEntry) string {
+	return lp.Proto.String() + " " + endpoint(lp.IP, lp.Port) + " " + lp.Process
+}
+
+// endpoint formats an address and port, "*" for an unspecified address.
+func endpoint(ip net.IP, port uint16) string {
+	host := "*"
+	if ip != nil {
+		host = ip.String()
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(port)))
+}
+
+// observe checks one snapshot and returns the alerts it raises. The first
+// snapshot's listeners are the baseline.
+func (w *watcher) observe(snap *model.Snapshot) []watchEvent {
+	var events []watchEvent
+	if w.listen {
+		events = append(events, w.observeListeners(snap.ListenPorts)...)
+	}
+	if len(w.deny) > 0 {
+		hosts := make(map[string]*model.RemoteHostSummary, len(snap.RemoteHosts))
+		for i := range snap.RemoteHosts {
+			if h := &snap.RemoteHosts[i]; h.IP != nil {
+				hosts[h.IP.String()] = h
+			}
+		}
+		for _, d := range w.deny {
+			events = append(events, d.observe(snap.Processes, hosts)...)
+		}
+	}
+	return events
+}
+
+func (w *watcher) observeListeners(ports []model.ListenPortEntry) []watchEvent {
+	baseline := w.seen == nil
+	if baseline {
+		w.seen = make(map[string]bool)
+	}
+	var events []watchEvent
+	open := make(map[string]bool, len(ports))
+	for i := range ports {
+		lp := &ports[i]
+		key := listenerKey(lp)
+		open[key] = true
+		if w.seen[key] {
+			continue
+		}
+		w.seen[key] = true
+		if baseline {
+			continue
+		}
+		w.opened[key] = true
+		events = append(events, watchEvent{
+			pid:     lp.PID,
+			name:    lp.Process,
+			text:    fmt.Sprintf("new listener: %s (pid %d) %s %s", lp.Process, lp.PID, lp.Proto, endpoint(lp.IP, lp.Port)),
+			bell:    w.bell,
+			actions: w.actions,
+		})
+	}
+	for key := range w.opened {
+		if !open[key] {
+			delete(w.opened, key)
+		}
+	}
+	return events
+}
+
+// matches reports whether a connection's remote end is denied. host is
+// its remote hosts entry, nil when it has none.
+func (d *denyRule) matches(ip net.IP, host *model.RemoteHostSummary) bool {
+	if addr, ok := netip.AddrFromSlice(ip); ok {
+		addr = addr.Unmap()
+		for _, p := range d.prefixes {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+	}
+	if host == nil {
+		return false
+	}
+	return d.countries[host.CountryCode] || (host.ASN != 0 && d.asns[host.ASN])
+}
+
+// observe alerts on each process's connections to denied destinations,
+// once per process and remote address while connected.
+func (d *denyRule) observe(procs []model.ProcessSummary, hosts map[string]*model.RemoteHostSummary) []watchEvent {
+	var events []watchEvent
+	open := make(map[string]bool)
+	for i := range procs {
+		p := &procs[i]
+		for j := range p.Connections {
+			c := &p.Connections[j]
+			if c.DstIP == nil {
+				continue
+			}
+			ip := c.DstIP.String()
+			host := hosts[ip]
+			if !d.matches(c.DstIP, host) {
+				continue
+			}
+			key := strconv.FormatUint(uint64(p.PID), 10) + " " + ip
+			open[key] = true
+			if d.triggered[key] {
+				continue
+			}
+			d.triggered[key] = true
+			arrow := "→"
+			if c.Direction == model.DirInbound {
+				arrow = "←"
+			}
+			text := fmt.Sprintf("%s: %s (pid %d) %s %s", d.name, p.Name, p.PID, arrow, endpoint(c.DstIP, c.DstPort))
+			if host != nil && host.CountryCode != "" {
+				text += " " + host.CountryCode
+			}
+			if host != nil && host.ASN != 0 {
+				text += fmt.Sprintf(" AS%d", host.ASN)
+			}
+			events = append(events, watchEvent{
+				rule:    d.name,
+				pid:     p.PID,
+				name:    p.Name,
+				text:    text,
+				bell:    d.bell,
+				actions: d.actions,
+			})
+		}
+	}
+	for key := range d.triggered {
+		if !open[key] {
+			delete(d.triggered, key)
+		}
+	}
+	return events
+}
+
+// headerTags returns the unstyled header tags for what is still open,
+// with whether each uses the flash channel.
+func (w *watcher) headerTags() (tags []string, flash []bool) {
+	if n := len(w.opened); n > 0 {
+		label := "new listener"
+		if n > 1 {
+			label += "s"
+		}
+		tags = append(tags, fmt.Sprintf(" ⚠ %d %s ", n, label))
+		flash = append(flash, w.flash)
+	}
+	for _, d := range w.deny {
+		if n := len(d.triggered); n > 0 {
+			label := "connection"
+			if n > 1 {
+				label += "s"
+			}
+			tags = append(tags, fmt.Sprintf(" ⚠ %s: %d %s ", d.name, n, label))
+			flash = append(flash, d.flash)
+		}
+	}
+	return tags, flash
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/config"
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func watchSnapshot(at time.Time, ports ...model.ListenPortEntry) *model.Snapshot {
+	return &model.Snapshot{Timestamp: at, ListenPorts: ports}
+}
+
+func TestWatchNewListeners(t *testing.T) {
+	a := newAlertOverlay()
+	if err := a.configure(config.Alerts{Watch: config.Watch{Listen: true}}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	sshd := model.ListenPortEntry{Proto: model.ProtoTCP, Port: 22, PID: 1, Process: "sshd"}
+	nc := model.ListenPortEntry{Proto: model.ProtoTCP, IP: net.IPv4(0, 0, 0, 0), Port: 4444, PID: 9, Process: "nc"}
+
+	// The first snapshot is the baseline
+	if a.checkWatch(watchSnapshot(now, sshd)) || len(a.log) != 0 {
+		t.Fatalf("baseline raised alerts: %v", a.log)
+	}
+	if !a.checkWatch(watchSnapshot(now, sshd, nc)) {
+		t.Error("a new listener should ring the bell")
+	}
+	if len(a.log) != 1 || a.log[0].text != "new listener: nc (pid 9) TCP 0.0.0.0:4444" {
+		t.Errorf("log = %v", a.log)
+	}
+	if text := a.alertHeaderText(nil); !strings.Contains(text, "1 new listener") {
+		t.Errorf("header = %q", text)
+	}
+
+	// Still open, restarted under another PID, or closed and reopened:
+	// not new again
+	a.checkWatch(watchSnapshot(now, sshd, nc))
+	sshd.PID = 2
+	a.checkWatch(watchSnapshot(now, sshd))
+	if text := a.alertHeaderText(nil); text != "" {
+		t.Errorf("header after the listener closed = %q", text)
+	}
+	a.checkWatch(watchSnapshot(now, sshd, nc))
+	if len(a.log) != 1 {
+		t.Errorf("log = %v, want one alert", a.log)
+	}
+}
+
+func TestWatchDenyList(t *testing.T) {
+	a := newAlertOverlay()
+	notifier := &fakeNotifier{}
+	a.notifier = notifier
+	err := a.configure(config.Alerts{Watch: config.Watch{Deny: []config.DenyRule{
+		{Name: "blocklist", Countries: []string{"KP"}, CIDRs: []string{"203.0.113.0/24"}, Notify: []string{"webhook"}},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap := &model.Snapshot{
+		Timestamp: time.Now(),
+		Processes: []model.ProcessSummary{{PID: 10, Name: "curl", Connections: []model.Connection{
+			{Proto: model.ProtoTCP, DstIP: net.ParseIP("203.0.113.5"), DstPort: 443},
+			{Proto: model.ProtoTCP, DstIP: net.ParseIP("175.45.176.1"), DstPort: 80},
+			{Proto: model.ProtoTCP, DstIP: net.ParseIP("8.8.8.8"), DstPort: 53},
+		}}},
+		RemoteHosts: []model.RemoteHostSummary{
+			{IP: net.ParseIP("175.45.176.1"), CountryCode: "KP"},
+			{IP: net.ParseIP("8.8.8.8"), CountryCode: "US", ASN: 15169},
+		},
+	}
+
+	if a.checkWatch(snap) {
+		t.Error("the rule doesn't use the bell")
+	}
+	want := []string{
+		"blocklist: curl (pid 10) → 203.0.113.5:443",
+		"blocklist: curl (pid 10) → 175.45.176.1:80 KP",
+	}
+	if len(a.log) != 2 || a.log[0].text != want[0] || a.log[1].text != want[1] {
+		t.Errorf("log = %v, want %q", a.log, want)
+	}
+	if len(notifier.events) != 2 || notifier.events[0].Rule != "blocklist" || notifier.events[0].PID != 10 ||
+		!notifier.channels[0].Webhook || notifier.channels[0].Command {
+		t.Errorf("notified %+v on %+v", notifier.events, notifier.channels)
+	}
+	if text := a.alertHeaderText(nil); !strings.Contains(text, "blocklist: 2 connections") {
+		t.Errorf("header = %q", text)
+	}
+
+	// Once per connection while it lasts
+	a.checkWatch(snap)
+	if len(a.log) != 2 {
+		t.Errorf("log = %v, want no repeats", a.log)
+	}
+}
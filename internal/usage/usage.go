@@ -0,0 +1,359 @@
+// Package usage keeps per-process and per-interface byte totals by day in a
+// state file, so usage survives restarts and can be reported for today, this
+// week and this month (like vnstat, but per process).
+package usage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+// dayFormat keys the per-day records (local time).
+const dayFormat = "2006-01-02"
+
+// retainDays is how long per-day records are kept.
+const retainDays = 93
+
+// saveEvery is how often Track writes the state file.
+const saveEvery = time.Minute
+
+// Period is a reporting window.
+type Period int
+
+const (
+	Day   Period = iota // today
+	Week                // since Monday
+	Month               // since the 1st
+	periodCount
+)
+
+func (p Period) String() string {
+	switch p {
+	case Week:
+		return "this week"
+	case Month:
+		return "this month"
+	}
+	return "today"
+}
+
+// Next returns the following period, wrapping around.
+func (p Period) Next() Period {
+	return (p + 1) % periodCount
+}
+
+// Start returns local midnight at the beginning of the period containing now.
+func (p Period) Start(now time.Time) time.Time {
+	y, m, d := now.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	switch p {
+	case Week:
+		back := (int(start.Weekday()) + 6) % 7 // days since Monday
+		start = start.AddDate(0, 0, -back)
+	case Month:
+		start = time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+	}
+	return start
+}
+
+// Totals is a byte count pair.
+type Totals struct {
+	Up   uint64 `json:"up"`
+	Down uint64 `json:"down"`
+}
+
+// Total returns up + down.
+func (t Totals) Total() uint64 {
+	return t.Up + t.Down
+}
+
+// Entry is the total for one process name or interface.
+type Entry struct {
+	Name string
+	Totals
+}
+
+type dayRecord struct {
+	Processes  map[string]*Totals `json:"processes"`
+	Interfaces map[string]*Totals `json:"interfaces"`
+}
+
+func newDayRecord() *dayRecord {
+	return &dayRecord{
+		Processes:  make(map[string]*Totals),
+		Interfaces: make(map[string]*Totals),
+	}
+}
+
+// stateFile is the on-disk format.
+type stateFile struct {
+	Version int                   `json:"version"`
+	Days    map[string]*dayRecord `json:"days"`
+}
+
+// Store accumulates byte totals from snapshots. Processes are keyed by name
+// so totals carry over restarts and PID changes.
+type Store struct {
+	mu    sync.Mutex
+	path  string
+	days  map[string]*dayRecord
+	dirty bool
+
+	// Previous counters, to turn the snapshot's running totals into deltas
+	prevProc  map[uint32]Totals
+	prevIface map[string]Totals
+}
+
+// DefaultPath returns $XDG_STATE_HOME/sstop/usage.json, falling back to
+// ~/.local/state. Returns "" if neither can be determined.
+func DefaultPath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "sstop", "usage.json")
+}
+
+// New returns an empty store saving to path.
+func New(path string) *Store {
+	return &Store{
+		path:      path,
+		days:      make(map[string]*dayRecord),
+		prevProc:  make(map[uint32]Totals),
+		prevIface: make(map[string]Totals),
+	}
+}
+
+// Open loads the state file at path. A missing file starts empty. A file
+// that doesn't parse (cut short by a crash or a full disk) is moved aside
+// to path.bad, so the next save doesn't overwrite it, and reported.
+func Open(path string) (*Store, error) {
+	s := New(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f stateFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		bad := path + ".bad"
+		if os.Rename(path, bad) == nil {
+			err = fmt.Errorf("%w (moved to %s)", err, bad)
+		}
+		return nil, &os.PathError{Op: "parse", Path: path, Err: err}
+	}
+	for day, rec := range f.Days {
+		if rec == nil {
+			continue
+		}
+		if rec.Processes == nil {
+			rec.Processes = make(map[string]*Totals)
+		}
+		if rec.Interfaces == nil {
+			rec.Interfaces = make(map[string]*Totals)
+		}
+		s.days[day] = rec
+	}
+	return s, nil
+}
+
+// Observe adds the traffic since the previous snapshot to the totals for the
+// snapshot's day. Process deltas come from the per-PID session totals
+// (CumUp/CumDown), which start at zero, so a new PID counts in full; the
+// bytes a restarted program carries from its earlier runs were counted then.
+// Interface deltas come from the kernel counters, which run since boot, so
+// a new interface only sets the baseline.
+func (s *Store) Observe(snap model.Snapshot) {
+	ts := snap.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec := s.day(ts)
+	seen := make(map[uint32]bool, len(snap.Processes))
+	for i := range snap.Processes {
+		p := &snap.Processes[i]
+		seen[p.PID] = true
+		cur := Totals{Up: p.CumUp, Down: p.CumDown}
+		prev := s.prevProc[p.PID]
+		s.prevProc[p.PID] = cur
+		if cur.Up < prev.Up || cur.Down < prev.Down {
+			prev = Totals{} // counters restarted (PID reused)
+		}
+		if d := (Totals{cur.Up - prev.Up, cur.Down - prev.Down}); d.Total() > 0 && p.Name != "" {
+			rec.add(rec.Processes, p.Name, d)
+			s.dirty = true
+		}
+	}
+	for pid := range s.prevProc {
+		if !seen[pid] {
+			delete(s.prevProc, pid)
+		}
+	}
+
+	for i := range snap.Interfaces {
+		ifc := &snap.Interfaces[i]
+		cur := Totals{Up: ifc.BytesSent, Down: ifc.BytesRecv}
+		prev, ok := s.prevIface[ifc.Name]
+		s.prevIface[ifc.Name] = cur
+		if !ok || cur.Up < prev.Up || cur.Down < prev.Down {
+			continue
+		}
+		if d := (Totals{cur.Up - prev.Up, cur.Down - prev.Down}); d.Total() > 0 {
+			rec.add(rec.Interfaces, ifc.Name, d)
+			s.dirty = true
+		}
+	}
+}
+
+// day returns the record for t's local date, creating it. Must hold s.mu.
+func (s *Store) day(t time.Time) *dayRecord {
+	key := t.Local().Format(dayFormat)
+	rec, ok := s.days[key]
+	if !ok {
+		rec = newDayRecord()
+		s.days[key] = rec
+	}
+	return rec
+}
+
+func (r *dayRecord) add(m map[string]*Totals, name string, d Totals) {
+	t, ok := m[name]
+	if !ok {
+		t = &Totals{}
+		m[name] = t
+	}
+	t.Up += d.Up
+	t.Down += d.Down
+}
+
+// Processes returns per-process-name totals for the period containing now,
+// heaviest first.
+func (s *Store) Processes(p Period, now time.Time) []Entry {
+	return s.sum(p, now, func(r *dayRecord) map[string]*Totals { return r.Processes })
+}
+
+// Interfaces returns per-interface totals for the period containing now,
+// heaviest first.
+func (s *Store) Interfaces(p Period, now time.Time) []Entry {
+	return s.sum(p, now, func(r *dayRecord) map[string]*Totals { return r.Interfaces })
+}
+
+func (s *Store) sum(p Period, now time.Time, pick func(*dayRecord) map[string]*Totals) []Entry {
+	now = now.Local()
+	from := p.Start(now).Format(dayFormat)
+	to := now.Format(dayFormat)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	acc := make(map[string]*Totals)
+	for key, rec := range s.days {
+		if key < from || key > to {
+			continue
+		}
+		for name, t := range pick(rec) {
+			rec.add(acc, name, *t)
+		}
+	}
+
+	out := make([]Entry, 0, len(acc))
+	for name, t := range acc {
+		out = append(out, Entry{Name: name, Totals: *t})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total() != out[j].Total() {
+			return out[i].Total() > out[j].Total()
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// Save writes the state file if anything changed, dropping records older
+// than the retention window. The file is replaced atomically.
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -retainDays).Format(dayFormat)
+	for key := range s.days {
+		if key < cutoff {
+			delete(s.days, key)
+		}
+	}
+
+	data, err := json.Marshal(stateFile{Version: 1, Days: s.days})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".usage-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// Track observes every snapshot passing through the channel and saves the
+// state file every minute and when the channel closes. Save errors go to
+// onErr.
+func (s *Store) Track(snapCh <-chan model.Snapshot, onErr func(error)) <-chan model.Snapshot {
+	out := make(chan model.Snapshot, 1)
+	go func() {
+		defer close(out)
+		lastSave := time.Now()
+		save := func() {
+			lastSave = time.Now()
+			if err := s.Save(); err != nil && onErr != nil {
+				onErr(err)
+			}
+		}
+		defer save()
+		for snap := range snapCh {
+			s.Observe(snap)
+			if time.Since(lastSave) >= saveEvery {
+				save()
+			}
+			out <- snap
+		}
+	}()
+	return out
+}
@@ -0,0 +1,155 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+)
+
+func snap(ts time.Time, procs []model.ProcessSummary, ifaces []model.InterfaceStats) model.Snapshot {
+	return model.Snapshot{Timestamp: ts, Processes: procs, Interfaces: ifaces}
+}
+
+func TestObserveDeltas(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "usage.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 18, 12, 0, 0, 0, time.Local)
+
+	s.Observe(snap(now,
+		[]model.ProcessSummary{{PID: 1, Name: "curl", CumUp: 100, CumDown: 1000}},
+		[]model.InterfaceStats{{Name: "eth0", BytesSent: 5000, BytesRecv: 9000}}))
+	s.Observe(snap(now.Add(time.Second),
+		[]model.ProcessSummary{
+			{PID: 1, Name: "curl", CumUp: 150, CumDown: 1500},
+			{PID: 2, Name: "curl", CumDown: 10}, // second instance adds to the name
+		},
+		[]model.InterfaceStats{{Name: "eth0", BytesSent: 5100, BytesRecv: 9900}}))
+
+	procs := s.Processes(Day, now)
+	if len(procs) != 1 || procs[0].Name != "curl" || procs[0].Up != 150 || procs[0].Down != 1510 {
+		t.Errorf("processes = %+v, want curl 150/1510", procs)
+	}
+	// First interface sample is only a baseline (kernel counters run since boot)
+	ifaces := s.Interfaces(Day, now)
+	if len(ifaces) != 1 || ifaces[0].Up != 100 || ifaces[0].Down != 900 {
+		t.Errorf("interfaces = %+v, want eth0 100/900", ifaces)
+	}
+
+	// Counter reset (PID reused after the collector dropped it) counts from zero
+	s.Observe(snap(now.Add(2*time.Second),
+		[]model.ProcessSummary{{PID: 1, Name: "curl", CumUp: 5}}, nil))
+	if procs := s.Processes(Day, now); procs[0].Up != 155 {
+		t.Errorf("after reset up = %d, want 155", procs[0].Up)
+	}
+}
+
+func TestPeriods(t *testing.T) {
+	s, _ := Open(filepath.Join(t.TempDir(), "usage.json"))
+	// Wednesday 18 March 2026
+	wed := time.Date(2026, 3, 18, 12, 0, 0, 0, time.Local)
+	for _, d := range []time.Time{
+		wed,                    // today
+		wed.AddDate(0, 0, -2),  // Monday, same week
+		wed.AddDate(0, 0, -3),  // Sunday, previous week
+		wed.AddDate(0, 0, -17), // 1 March, same month
+		wed.AddDate(0, 0, -18), // February
+	} {
+		s.day(d).add(s.day(d).Processes, "sync", Totals{Up: 1})
+	}
+
+	tests := []struct {
+		p    Period
+		want uint64
+	}{
+		{Day, 1},
+		{Week, 2},
+		{Month, 4},
+	}
+	for _, tt := range tests {
+		got := s.Processes(tt.p, wed)
+		if len(got) != 1 || got[0].Up != tt.want {
+			t.Errorf("%s = %+v, want %d", tt.p, got, tt.want)
+		}
+	}
+
+	if got := Week.Start(time.Date(2026, 3, 22, 23, 0, 0, 0, time.Local)); got.Day() != 16 {
+		t.Errorf("week of Sunday 22nd starts on %v, want Monday 16th", got)
+	}
+}
+
+func TestSaveAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "usage.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	s.Observe(snap(now, []model.ProcessSummary{{PID: 7, Name: "rsync", CumUp: 42}}, nil))
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	s2, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if procs := s2.Processes(Day, now); len(procs) != 1 || procs[0].Up != 42 {
+		t.Errorf("reloaded = %+v, want rsync 42 up", procs)
+	}
+
+	// A fresh session continues the totals
+	s2.Observe(snap(now, []model.ProcessSummary{{PID: 9, Name: "rsync", CumUp: 8}}, nil))
+	if procs := s2.Processes(Day, now); procs[0].Up != 50 {
+		t.Errorf("continued = %d, want 50", procs[0].Up)
+	}
+}
+
+func TestOpenTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	if err := os.WriteFile(path, []byte(`{"days":{"2026-10-17":{"processes":{"rs`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil || !strings.Contains(err.Error(), "usage.json.bad") {
+		t.Fatalf("Open = %v, want a parse error naming the moved file", err)
+	}
+	if _, err := os.Stat(path + ".bad"); err != nil {
+		t.Errorf("damaged file not moved aside: %v", err)
+	}
+
+	// The empty store started instead saves over nothing
+	s := New(path)
+	s.Observe(snap(time.Now(), []model.ProcessSummary{{PID: 1, Name: "curl", CumDown: 3}}, nil))
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if s2, err := Open(path); err != nil || len(s2.Processes(Day, time.Now())) != 1 {
+		t.Errorf("reopen after starting over: %v", err)
+	}
+}
+
+func TestTrackSavesOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.json")
+	s, _ := Open(path)
+
+	in := make(chan model.Snapshot, 1)
+	out := s.Track(in, func(err error) { t.Error(err) })
+	in <- snap(time.Now(), []model.ProcessSummary{{PID: 1, Name: "curl", CumDown: 3}}, nil)
+	<-out
+	close(in)
+	for range out {
+	}
+
+	s2, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if procs := s2.Processes(Day, time.Now()); len(procs) != 1 {
+		t.Errorf("not saved on close: %+v", procs)
+	}
+}
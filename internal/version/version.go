@@ -0,0 +1,142 @@
+// Package version holds build-time version information.
+//
+// The variables are overridden at link time, e.g.:
+//
+//	go build -ldflags "-X github.com/googlesky/sstop/internal/version.Version=v1.2.3"
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Build information, set via -ldflags -X.
+var (
+	Version = "dev"
+	Commit  = "unknown"
+	Date    = "unknown"
+)
+
+// knownBackends lists the optional backends reported in the feature matrix,
+// in display order. Backends not compiled into this binary show as "no".
+var knownBackends = []string{"ebpf", "pcap", "mmdb"}
+
+var (
+	backendsMu sync.Mutex
+	backends   = make(map[string]bool)
+)
+
+// RegisterBackend marks an optional backend as compiled into this binary.
+// Called from init() in the packages (or build-tagged files) implementing it.
+func RegisterBackend(name string) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends[name] = true
+}
+
+// Backend is one entry of the feature matrix.
+type Backend struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Backends returns the feature matrix: every known optional backend plus any
+// other registered one, with whether it was compiled in.
+func Backends() []Backend {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	result := make([]Backend, 0, len(knownBackends))
+	seen := make(map[string]bool)
+	for _, name := range knownBackends {
+		result = append(result, Backend{Name: name, Enabled: backends[name]})
+		seen[name] = true
+	}
+	var extra []string
+	for name := range backends {
+		if !seen[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		result = append(result, Backend{Name: name, Enabled: true})
+	}
+	return result
+}
+
+// Info is a JSON-friendly view of the build information.
+type Info struct {
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit"`
+	Date      string    `json:"date"`
+	GoVersion string    `json:"go_version"`
+	Platform  string    `json:"platform"`
+	Backends  []Backend `json:"backends"`
+}
+
+// Get returns the current build information.
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    commit(),
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Backends:  Backends(),
+	}
+}
+
+// commit returns the linked-in commit, falling back to the VCS revision
+// recorded by the Go toolchain for `go build` / `go install` from a checkout.
+func commit() string {
+	if Commit != "unknown" {
+		return Commit
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.revision" && s.Value != "" {
+				if len(s.Value) > 12 {
+					return s.Value[:12]
+				}
+				return s.Value
+			}
+		}
+	}
+	return Commit
+}
+
+// Short returns a one-line version string, e.g. "sstop v1.2.3 (abc123, 2025-01-01)".
+func Short() string {
+	info := Get()
+	return fmt.Sprintf("sstop %s (%s, %s)", info.Version, info.Commit, info.Date)
+}
+
+// BackendSummary returns the feature matrix as "ebpf:no pcap:yes mmdb:no".
+func BackendSummary() string {
+	var parts []string
+	for _, b := range Backends() {
+		state := "no"
+		if b.Enabled {
+			state = "yes"
+		}
+		parts = append(parts, b.Name+":"+state)
+	}
+	return strings.Join(parts, " ")
+}
+
+// String returns the full multi-line --version output.
+func String() string {
+	info := Get()
+	var b strings.Builder
+	fmt.Fprintf(&b, "sstop %s\n", info.Version)
+	fmt.Fprintf(&b, "  commit:   %s\n", info.Commit)
+	fmt.Fprintf(&b, "  built:    %s\n", info.Date)
+	fmt.Fprintf(&b, "  go:       %s %s\n", info.GoVersion, info.Platform)
+	fmt.Fprintf(&b, "  backends: %s\n", BackendSummary())
+	return b.String()
+}
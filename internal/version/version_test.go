@@ -0,0 +1,46 @@
+package version
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBackendsIncludesKnown(t *testing.T) {
+	got := Backends()
+	if len(got) < len(knownBackends) {
+		t.Fatalf("got %d backends, want at least %d", len(got), len(knownBackends))
+	}
+	for i, name := range knownBackends {
+		if got[i].Name != name {
+			t.Errorf("backend[%d] = %q, want %q", i, got[i].Name, name)
+		}
+	}
+}
+
+func TestRegisterBackend(t *testing.T) {
+	RegisterBackend("mmdb")
+	RegisterBackend("zz-test")
+	defer func() {
+		backendsMu.Lock()
+		delete(backends, "mmdb")
+		delete(backends, "zz-test")
+		backendsMu.Unlock()
+	}()
+
+	summary := BackendSummary()
+	if !strings.Contains(summary, "mmdb:yes") {
+		t.Errorf("summary %q missing mmdb:yes", summary)
+	}
+	if !strings.HasSuffix(summary, "zz-test:yes") {
+		t.Errorf("summary %q should list extra backend last", summary)
+	}
+}
+
+func TestStringContainsFields(t *testing.T) {
+	s := String()
+	for _, want := range []string{"sstop " + Version, "commit:", "built:", "backends:"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("String() missing %q:\n%s", want, s)
+		}
+	}
+}
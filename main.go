@@ -1,50 +1,216 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"image/color"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/googlesky/sstop/internal/chart"
 	"github.com/googlesky/sstop/internal/collector"
+	"github.com/googlesky/sstop/internal/config"
+	"github.com/googlesky/sstop/internal/crash"
+	"github.com/googlesky/sstop/internal/dnssniff"
+	"github.com/googlesky/sstop/internal/doctor"
+	"github.com/googlesky/sstop/internal/firewall"
+	"github.com/googlesky/sstop/internal/geo"
+	"github.com/googlesky/sstop/internal/health"
+	"github.com/googlesky/sstop/internal/history"
+	"github.com/googlesky/sstop/internal/hostfmt"
+	"github.com/googlesky/sstop/internal/journal"
 	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/notes"
+	"github.com/googlesky/sstop/internal/notify"
 	"github.com/googlesky/sstop/internal/output"
 	"github.com/googlesky/sstop/internal/platform"
+	"github.com/googlesky/sstop/internal/portlog"
+	"github.com/googlesky/sstop/internal/privacy"
 	"github.com/googlesky/sstop/internal/recorder"
+	"github.com/googlesky/sstop/internal/remote"
+	"github.com/googlesky/sstop/internal/report"
 	"github.com/googlesky/sstop/internal/ui"
+	"github.com/googlesky/sstop/internal/usage"
+	"github.com/googlesky/sstop/internal/version"
 )
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "query" {
+		runQuery(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		runReport(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "ports-diff" {
+		runPortsDiff(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "connect" {
+		runConnect(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		runDoctor(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rec" {
+		runRec(os.Args[2:])
+		return
+	}
+	// "sstop serve" collects like sstop itself, so it takes the same flags
+	serveMode := len(os.Args) > 1 && os.Args[1] == "serve"
+	if serveMode {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	// Parse flags
 	jsonFlag := flag.Bool("json", false, "Output JSONL (one JSON object per snapshot)")
 	csvFlag := flag.Bool("csv", false, "Output CSV (header + rows per poll)")
+	influxFlag := flag.Bool("influx", false, "Output InfluxDB line protocol (for Telegraf/InfluxDB)")
 	onceFlag := flag.Bool("once", false, "Single snapshot then exit")
 	intervalFlag := flag.Duration("interval", 1*time.Second, "Poll interval (e.g. 2s, 500ms)")
 	recordFlag := flag.String("record", "", "Record session to file (e.g. traffic.ssrec)")
-	playbackFlag := flag.String("playback", "", "Playback a recorded session file")
+	recordOnAlertFlag := flag.Duration("record-on-alert", 0, "Keep this much history in memory and, when an alert fires, record it and as much after to sstop-alert-<time>.ssrec (e.g. 2m)")
+	playbackFlag := flag.String("playback", "", "Playback a recorded session file, or every recording in a directory as one timeline")
+	remoteFlag := flag.String("remote", "", "Show [user@]host's traffic: runs sstop --json there over SSH (key or agent auth)")
+	remoteCmdFlag := flag.String("remote-cmd", remote.DefaultCommand, "Command --remote runs on the host (e.g. \"sudo sstop --netns\")")
+	versionFlag := flag.Bool("version", false, "Print version, build info and compiled-in backends, then exit")
+	maxSnapBytesFlag := flag.Int("max-snapshot-bytes", 0, "With --json, cap each line at N bytes: drop connection detail, then low-rate processes (0 = no cap)")
+	maxConnsFlag := flag.Int("max-conns", collector.DefaultMaxConns, "Max connections per process in snapshots, busiest first (0 = no cap)")
+	statsdFlag := flag.String("statsd", "", "Send per-process rates as statsd gauges to this UDP address each poll (e.g. localhost:8125)")
+	graphiteFlag := flag.String("graphite", "", "Send per-process rates to this Graphite plaintext TCP address each poll (e.g. localhost:2003)")
+	notesFileFlag := flag.String("notes-file", notes.DefaultPath(), "Persist process notes (n / N in the TUI) here (empty = this session only)")
+	usageFileFlag := flag.String("usage-file", usage.DefaultPath(), "Persist per-process/interface day totals here for the usage view (empty = off)")
+	dbFlag := flag.String("db", "", "Store per-process and per-host traffic in this SQLite file each poll (see: sstop query)")
+	portsLogFlag := flag.String("ports-log", "", "Log the listening-port inventory to this file when it changes (see: sstop ports-diff)")
+	portsEveryFlag := flag.Duration("ports-every", portlog.DefaultEvery, "How often --ports-log samples the inventory")
+	metricPrefixFlag := flag.String("metric-prefix", output.DefaultMetricPrefix, "Metric name prefix for --statsd / --graphite")
+	accessibleFlag := flag.Bool("accessible", false, "Monochrome high-contrast theme; up/down shown by glyph and brightness (overrides [ui] theme)")
+	watchFlag := flag.Bool("watch", false, "Watch mode: alert when a process opens a listening port that wasn't open at start (as [alerts.watch] listen)")
+	hostFormatFlag := flag.String("host-format", "", "Name remote hosts fqdn, short (first label) or ip in the TUI and --json/--csv/--influx output (overrides [ui] hosts)")
+	heatFlag := flag.Bool("heat", false, "Shade the upload/download cells by rate instead of drawing bars, for dashboards read at a distance")
+	noMouseFlag := flag.Bool("no-mouse", false, "Start without mouse capture so the terminal's own text selection works (M toggles it in the TUI)")
+	noAltScreenFlag := flag.Bool("no-altscreen", false, "Draw inline instead of on the alternate screen, leaving the last frame in scrollback on exit")
+	privacyFlag := flag.Bool("privacy", false, "Mask IPs, hostnames and cmdlines with stable pseudonyms (TUI, streaming output and recordings)")
+	configFlag := flag.String("config", "", "Config file (default: $XDG_CONFIG_HOME/sstop/config.toml if present)")
+	geoipDBFlag := flag.String("geoip-db", "", "GeoLite2/GeoIP2 Country or City .mmdb file (default: first found in /usr/share/GeoIP, /var/lib/GeoIP, ...)")
+	dnsSniffFlag := flag.Bool("dns-sniff", false, "Name remote hosts by the DNS answers applications receive, plus /etc/hosts, instead of reverse DNS alone (Linux, root)")
+	netnsFlag := flag.Bool("netns", false, "Also monitor the host's other network namespaces: containers and ip netns (Linux, root)")
+	kubeletURLFlag := flag.String("kubelet-url", collector.DefaultKubeletURL, "Kubelet pod list for naming Kubernetes pods the container runtime's state doesn't cover (empty = off)")
+	tags := output.Tags{}
+	flag.Var(tags, "tag", "Fleet label key=value added to JSON snapshots and --influx/--statsd/--graphite metrics (repeatable)")
+	healthAddrFlag := flag.String("health-addr", "", "Serve /healthz, /readyz and /version on this address (e.g. :9102)")
+	listenFlag := flag.String("listen", "127.0.0.1:"+remote.DefaultAgentPort, "sstop serve: address to stream snapshots on for sstop connect; beyond loopback it needs --token")
+	tokenFlag := flag.String("token", "", "sstop serve: require clients to send this token")
+	capturePIDFlag := flag.Uint("capture-pid", 0, "Capture this process's packets to a pcap file, following its connections (Linux, root; w in the detail view does the same)")
+	captureFileFlag := flag.String("capture-file", "", "File for --capture-pid (default: sstop-<pid>-<time>.pcap)")
+	quietFlag := flag.Bool("quiet", false, "Write nothing to stderr, not even errors; only the exit status reports failure")
+	noSummaryFlag := flag.Bool("no-summary", false, "Don't print the session totals on exit")
 	flag.Parse()
 
-	if *jsonFlag && *csvFlag {
-		fmt.Fprintln(os.Stderr, "error: --json and --csv are mutually exclusive")
+	if *quietFlag {
+		silenceStderr()
+	}
+
+	if *versionFlag {
+		fmt.Print(version.String())
+		return
+	}
+	tui := tuiOptions{mouse: !*noMouseFlag, altScreen: !*noAltScreenFlag}
+
+	streamModes := 0
+	for _, on := range []bool{*jsonFlag, *csvFlag, *influxFlag} {
+		if on {
+			streamModes++
+		}
+	}
+	if streamModes > 1 {
+		fmt.Fprintln(os.Stderr, "error: --json, --csv and --influx are mutually exclusive")
+		os.Exit(1)
+	}
+	if serveMode && (streamModes > 0 || *remoteFlag != "" || *playbackFlag != "") {
+		fmt.Fprintln(os.Stderr, "error: sstop serve streams to sstop connect; it can't be combined with --json, --csv, --influx, --remote or --playback")
+		os.Exit(1)
+	}
+	if *capturePIDFlag != 0 && (*remoteFlag != "" || *playbackFlag != "") {
+		fmt.Fprintln(os.Stderr, "error: --capture-pid captures on this machine; it can't be combined with --remote or --playback")
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load(*configFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *heatFlag {
+		cfg.UI.Heat = true
+	}
+	if *hostFormatFlag != "" {
+		if _, err := hostfmt.Parse(*hostFormatFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "error: --host-format: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.UI.Hosts = strings.ToLower(*hostFormatFlag)
+	}
+	if *watchFlag {
+		cfg.Alerts.Watch.Listen = true
+	}
+	theme := cfg.UI.Theme
+	if *accessibleFlag {
+		theme = ui.ThemeAccessible
+	}
+	if err := ui.SetTheme(theme); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Playback mode — no platform/collector needed
 	if *playbackFlag != "" {
-		runPlayback(*playbackFlag)
+		runPlayback(*playbackFlag, cfg, *privacyFlag, tui)
 		return
 	}
 
 	// Redirect log output to a file so it doesn't interfere with TUI
-	logFile, err := os.CreateTemp("", "sstop-*.log")
-	if err == nil {
-		log.SetOutput(logFile)
-		defer logFile.Close()
+	if !*quietFlag {
+		logFile, err := os.CreateTemp("", "sstop-*.log")
+		if err == nil {
+			log.SetOutput(logFile)
+			defer logFile.Close()
+		}
+	}
+
+	// Remote mode — the host's own sstop collects
+	if *remoteFlag != "" {
+		if streamModes > 0 {
+			fmt.Fprintln(os.Stderr, "error: --remote is interactive; run sstop --json on the host over ssh instead")
+			os.Exit(1)
+		}
+		runRemote(*remoteFlag, *remoteCmdFlag, *intervalFlag, *recordFlag, *recordOnAlertFlag, cfg, *privacyFlag, tui)
+		return
+	}
+
+	// MaxMind database for country/city lookups; embedded ranges otherwise
+	if *geoipDBFlag != "" {
+		if err := geo.OpenDB(*geoipDBFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open GeoIP database: %v\n", err)
+			os.Exit(1)
+		}
+	} else if path := geo.OpenDefaultDB(); path != "" {
+		log.Printf("sstop: using GeoIP database %s", path)
 	}
+	defer geo.CloseDB()
 
 	p, err := platform.NewPlatform()
 	if err != nil {
@@ -52,61 +218,484 @@ func main() {
 		os.Exit(1)
 	}
 	defer p.Close()
+	if *netnsFlag {
+		nc, ok := p.(platform.NamespaceCollector)
+		if !ok {
+			fmt.Fprintln(os.Stderr, "--netns is only supported on Linux")
+			os.Exit(1)
+		}
+		nc.SetNamespaces(true)
+	}
 
 	interval := *intervalFlag
 	if interval < 100*time.Millisecond {
 		interval = 100 * time.Millisecond
 	}
 
+	redactor, err := collector.NewRedactor(cfg.Redact.DefaultsEnabled(), cfg.Redact.Patterns)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: %v\n", err)
+		os.Exit(1)
+	}
+
 	c := collector.New(p, interval)
+	c.SetMaxConns(*maxConnsFlag)
+	c.SetRedactor(redactor)
+	c.SetKubeletURL(*kubeletURLFlag)
+	if len(cfg.Alerts.Watch.Appear) > 0 {
+		if err := watchAppear(c, cfg.Alerts); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid config: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *dnsSniffFlag {
+		if f, err := os.Open("/etc/hosts"); err == nil {
+			for _, a := range dnssniff.ParseHosts(f) {
+				c.LearnHostname(a.IP, a.Name, a.TTL)
+			}
+			f.Close()
+		}
+		sniffer, err := dnssniff.Start(func(a dnssniff.Answer) {
+			c.LearnHostname(a.IP, a.Name, a.TTL)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to start DNS sniffing: %v\n", err)
+			os.Exit(1)
+		}
+		defer sniffer.Close()
+	}
 	snapCh := c.Start()
 	defer c.Stop()
+	if len(tags) > 0 {
+		snapCh = output.Tag(snapCh, tags)
+	}
+
+	// Health endpoints for supervised long-running modes
+	if *healthAddrFlag != "" {
+		hs := health.New(healthMaxAge(interval))
+		if err := hs.ListenAndServe(*healthAddrFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to start health server: %v\n", err)
+			os.Exit(1)
+		}
+		defer hs.Close()
+		snapCh = hs.Track(snapCh)
+	}
+
+	// Persisted usage totals (day/week/month view), kept across restarts.
+	// Streaming to stdout is for scripts, which have no use for them.
+	var usageStore *usage.Store
+	if *usageFileFlag != "" && streamModes == 0 {
+		usageStore, err = usage.Open(*usageFileFlag)
+		if err != nil {
+			// The totals are a convenience: don't refuse to start over them
+			fmt.Fprintf(os.Stderr, "warning: usage totals: %v (starting empty)\n", err)
+			usageStore = usage.New(*usageFileFlag)
+		}
+		defer usageStore.Save()
+		snapCh = usageStore.Track(snapCh, logSinkError("usage"))
+	}
+
+	// Packet capture matches real addresses, so it taps the pipeline ahead
+	// of privacy masking
+	captures := &captureSink{}
+	defer captures.Close()
+	if *capturePIDFlag != 0 {
+		path := *captureFileFlag
+		if path == "" {
+			path = platform.CaptureFileName("", uint32(*capturePIDFlag), time.Now())
+		}
+		if _, err := captures.start(path, uint32(*capturePIDFlag)); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to start packet capture: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	snapCh = output.Tee(snapCh, captures, logSinkError("capture"))
+
+	// Privacy mode masks before anything leaves the process
+	masker := privacy.New()
+	if *privacyFlag {
+		snapCh = masker.Filter(snapCh)
+	}
+
+	// Metrics sinks run alongside any mode, their cardinality bounded by
+	// the config's [metrics] limits
+	limits, err := output.NewLimits(cfg.Metrics)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid metrics limits: %v\n", err)
+		os.Exit(1)
+	}
+	if *statsdFlag != "" {
+		sw, err := output.NewStatsdWriter(*statsdFlag, *metricPrefixFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open statsd endpoint: %v\n", err)
+			os.Exit(1)
+		}
+		defer sw.Close()
+		snapCh = output.Tee(snapCh, output.Limit(sw, limits), logSinkError("statsd"))
+	}
+	if *graphiteFlag != "" {
+		gw, err := output.NewGraphiteWriter(*graphiteFlag, *metricPrefixFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to connect to graphite: %v\n", err)
+			os.Exit(1)
+		}
+		defer gw.Close()
+		snapCh = output.Tee(snapCh, output.Limit(gw, limits), logSinkError("graphite"))
+	}
+	if *dbFlag != "" {
+		db, err := history.Open(*dbFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open history database: %v\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+		snapCh = output.Tee(snapCh, db, logSinkError("history"))
+	}
+	if *portsLogFlag != "" {
+		pl, err := portlog.Open(*portsLogFlag, *portsEveryFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open ports log: %v\n", err)
+			os.Exit(1)
+		}
+		defer pl.Close()
+		snapCh = output.Tee(snapCh, pl, logSinkError("ports-log"))
+	}
+
+	// Agent mode: stream to sstop connect clients
+	if serveMode {
+		srv := remote.NewServer(*tokenFlag)
+		if err := srv.ListenAndServe(*listenFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to listen: %v\n", err)
+			os.Exit(1)
+		}
+		defer srv.Close()
+		for snap := range snapCh {
+			srv.Write(snap)
+		}
+		return
+	}
 
 	// Non-interactive streaming mode
-	if *jsonFlag || *csvFlag {
-		runStreaming(snapCh, *jsonFlag, *onceFlag)
+	if streamModes > 0 {
+		var w output.SnapshotWriter
+		switch {
+		case *jsonFlag:
+			w = output.NewJSONWriter(os.Stdout, *maxSnapBytesFlag)
+		case *influxFlag:
+			w = output.Limit(output.NewInfluxWriter(os.Stdout), limits)
+		default:
+			w = output.NewCSVWriter(os.Stdout)
+		}
+		hosts, _ := hostfmt.Parse(cfg.UI.Hosts) // validated with the config
+		runStreaming(snapCh, output.HostNames(w, hosts), *onceFlag)
 		return
 	}
 
 	// Record mode — wrap snapshot channel
+	saved := crashState{stats: c.SessionStats}
 	if *recordFlag != "" {
-		recCh, _, err := recorder.RecordSession(snapCh, *recordFlag)
+		recCh, rec, err := recorder.RecordSession(snapCh, *recordFlag)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "failed to open record file: %v\n", err)
 			os.Exit(1)
 		}
 		snapCh = recCh
+		saved.rec, saved.recPath = rec, *recordFlag
+	}
+	if *recordOnAlertFlag > 0 {
+		snapCh, saved.alertRec = recorder.RecordOnAlert(snapCh, ".", *recordOnAlertFlag)
+		defer saved.alertRec.Close()
 	}
 
 	// Smart detect the main outbound interface
 	defaultIface := platform.DetectDefaultInterface()
 
+	noteStore, err := notes.Open(*notesFileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load notes: %v\n", err)
+		os.Exit(1)
+	}
+
 	m := ui.New(snapCh)
 	m.SetDefaultInterface(defaultIface)
 	m.SetCollector(c)
+	m.SetMasker(masker)
+	m.SetPrivacy(*privacyFlag)
+	m.SetUsage(usageStore)
+	m.SetNotes(noteStore)
+	m.SetMouse(tui.mouse)
+	if saved.alertRec != nil {
+		m.SetAlertTrigger(alertTrigger(saved.alertRec))
+	}
+	// Rules added with B are removed on the way out
+	fw := firewall.New()
+	saved.fw = fw
+	defer closeFirewall(fw)
+	m.SetFirewall(fw)
+	if c := captures.current(); c != nil {
+		m.SetCapture(c)
+	}
+	if !*privacyFlag {
+		// The TUI's snapshots are masked under --privacy; no flows to follow
+		m.SetCaptureStarter(func(pid uint32, name string) (ui.PacketCapture, error) {
+			return captures.start(platform.CaptureFileName(name, pid, time.Now()), pid)
+		})
+	}
+	m.SetJournalReader(readJournal)
+	applyConfig(&m, cfg)
 
-	prog := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	runTUI(m, tui, saved)
 
+	// Print exit summary
+	if *noSummaryFlag {
+		return
+	}
+	stats := c.SessionStats()
+	if summary := stats.Summary(); summary != "" {
+		fmt.Print(summary)
+	}
+}
+
+// silenceStderr sends everything sstop writes to stderr, the log included,
+// to the null device, for pipelines that treat any stderr output as an error.
+func silenceStderr() {
+	if null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+		os.Stderr = null
+	}
+	log.SetOutput(io.Discard)
+}
+
+// healthMaxAge returns how stale the latest snapshot may be before /readyz
+// reports not ready. Allows a few missed polls (the interval can also be
+// slowed down at runtime from the TUI).
+func healthMaxAge(interval time.Duration) time.Duration {
+	maxAge := 3 * interval
+	if maxAge < 30*time.Second {
+		maxAge = 30 * time.Second
+	}
+	return maxAge
+}
+
+// tuiOptions are the terminal settings shared by every TUI mode.
+type tuiOptions struct {
+	mouse     bool // capture the mouse (off: --no-mouse)
+	altScreen bool // draw on the alternate screen (off: --no-altscreen)
+}
+
+// programOptions returns the bubbletea options for these settings.
+func (o tuiOptions) programOptions() []tea.ProgramOption {
+	var opts []tea.ProgramOption
+	if o.altScreen {
+		opts = append(opts, tea.WithAltScreen())
+	}
+	if o.mouse {
+		opts = append(opts, tea.WithMouseCellMotion())
+	}
+	return opts
+}
+
+// crashState is the session state a TUI panic saves before exiting.
+type crashState struct {
+	stats    func() model.SessionStats // nil without a local collector
+	rec      *recorder.Recorder        // nil when not recording
+	recPath  string
+	alertRec *recorder.AlertRecorder // nil without --record-on-alert
+	fw       io.Closer               // nil without a local collector
+}
+
+// release ends the recordings and removes the firewall rules, as the
+// callers' defers would, for an exit that skips them. It returns the
+// error flushing the session recording.
+func (s crashState) release() error {
+	var err error
+	if s.rec != nil {
+		err = s.rec.Close()
+	}
+	if s.alertRec != nil {
+		// An alert recording in progress ends here
+		s.alertRec.Close()
+	}
+	if s.fw != nil {
+		// Don't leave blocks behind
+		closeFirewall(s.fw)
+	}
+	return err
+}
+
+// closeFirewall deletes sstop's nftables table, reporting a failure.
+func closeFirewall(fw io.Closer) {
+	if err := fw.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "removing firewall rules (nft table inet %s): %v\n", firewall.Table, err)
+	}
+}
+
+// alertTrigger starts a --record-on-alert recording when an alert fires,
+// noting its file in the alert log.
+func alertTrigger(a *recorder.AlertRecorder) ui.AlertTrigger {
+	return func(at time.Time) string {
+		path, err := a.Trigger(at)
+		switch {
+		case err != nil:
+			return "record-on-alert failed: " + err.Error()
+		case path != "":
+			return "recording the alert to " + path
+		}
+		return ""
+	}
+}
+
+// readJournal tails a systemd unit's log for J in the Groups view.
+func readJournal(unit string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return journal.Tail(ctx, unit, journal.DefaultLines)
+}
+
+// exportProcesses saves processes for e in the process table, as a JSON
+// array in sstop-processes-<time>.json in the current directory.
+func exportProcesses(procs []model.ProcessSummary) (string, error) {
+	path := "sstop-processes-" + time.Now().Format("20060102-150405") + ".json"
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := output.WriteProcesses(f, procs); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// runTUI runs the TUI until it quits. When it panics, the session totals,
+// the alert log and the recording are saved first: the recording is
+// flushed and the rest goes to a crash report whose path is printed. On
+// any other error (an interrupt included) the recordings and firewall
+// rules are still released before exiting.
+func runTUI(m ui.Model, tui tuiOptions, saved crashState) {
+	if ui.TestModeFromEnv() {
+		m.SetTestMode(true)
+	}
+	m.SetExporter(exportProcesses)
+	guard := ui.Guard(m)
+	prog := tea.NewProgram(guard, tui.programOptions()...)
 	if _, err := prog.Run(); err != nil {
+		if c := guard.Crash(); c != nil {
+			reportCrash(c, saved)
+		} else {
+			saved.release()
+		}
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+}
 
-	// Print exit summary
-	stats := c.SessionStats()
-	if summary := stats.Summary(); summary != "" {
-		fmt.Print(summary)
+func reportCrash(c *ui.Crash, saved crashState) {
+	r := crash.Report{Time: time.Now(), Panic: c.Value, Stack: c.Stack, Alerts: c.Last.AlertLog()}
+	if saved.stats != nil {
+		r.Session = saved.stats().Summary()
+	}
+	flushErr := saved.release()
+	if saved.rec != nil {
+		r.Recording = saved.recPath
+		if flushErr != nil {
+			r.Recording += fmt.Sprintf(" (flush failed: %v)", flushErr)
+		}
+	}
+	path, err := crash.Write("", r)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "sstop crashed: %v; saving the crash report failed: %v\n", c.Value, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "sstop crashed: %v\ncrash report (session totals, alert log): %s\n", c.Value, path)
+	if saved.rec != nil && flushErr == nil {
+		fmt.Fprintf(os.Stderr, "recording saved: %s\n", saved.recPath)
 	}
 }
 
-// runPlayback plays back a recorded session file.
-func runPlayback(path string) {
+// applyConfig applies config file settings to the TUI model.
+func applyConfig(m *ui.Model, cfg *config.Config) {
+	if err := m.SetAlertConfig(cfg.Alerts); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := m.SetGroupRules(cfg.Groups); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := m.SetPresets(cfg.Presets); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := m.SetColumns(cfg.UI.Columns); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: ui.columns: %v\n", err)
+		os.Exit(1)
+	}
+	m.SetHeat(cfg.UI.Heat)
+	if err := m.SetIdle(cfg.UI.Idle, cfg.UI.IdlePolls); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: ui.idle: %v\n", err)
+		os.Exit(1)
+	}
+	hosts, _ := hostfmt.Parse(cfg.UI.Hosts) // validated with the config
+	m.SetHostStyle(hosts)
+	var saveFilter ui.FilterSaver
+	if cfg.Path != "" {
+		saveFilter = func(f config.SavedFilter) error { return config.AppendFilter(cfg.Path, f) }
+	}
+	m.SetSavedFilters(cfg.Filters, saveFilter)
+	if err := m.SetViewRefresh(cfg.UI.Refresh); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: ui.refresh: %v\n", err)
+		os.Exit(1)
+	}
+	n, err := notify.New(cfg.Alerts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: %v\n", err)
+		os.Exit(1)
+	}
+	if n != nil {
+		m.SetAlertNotifier(n)
+	}
+}
+
+// watchAppear runs the [[alerts.watch.appear]] rules' actions from the
+// collector, so they fire in every mode, headless ones included.
+func watchAppear(c *collector.Collector, cfg config.Alerts) error {
+	n, err := notify.New(cfg)
+	if err != nil {
+		return err
+	}
+	rules := make([]collector.AppearRule, len(cfg.Watch.Appear))
+	for i, r := range cfg.Watch.Appear {
+		rules[i] = collector.AppearRule{Name: r.Name, Process: r.Process, Ports: r.Ports}
+	}
+	return c.WatchAppear(rules, func(a collector.Appearance) {
+		r := cfg.Watch.Appear[a.Rule]
+		text := fmt.Sprintf("%s (pid %d) appeared", a.Process, a.PID)
+		if a.Port != 0 {
+			text += fmt.Sprintf(" listening on %d", a.Port)
+		}
+		if r.Name != "" {
+			text = r.Name + ": " + text
+		}
+		log.Printf("watch: %s", text)
+		n.Notify(notify.Event{At: a.At, Rule: r.Name, PID: a.PID, Name: a.Process, Text: text}, notify.Channels{
+			Command: r.Notifies(config.NotifyCommand),
+			Webhook: r.Notifies(config.NotifyWebhook),
+			Desktop: r.Notifies(config.NotifyDesktop),
+		})
+	})
+}
+
+// runPlayback plays back a recorded session file or directory.
+func runPlayback(path string, cfg *config.Config, privacyMode bool, tui tuiOptions) {
 	player, err := recorder.NewPlayer(path)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to open playback file: %v\n", err)
 		os.Exit(1)
 	}
 	defer player.Close()
+	for _, w := range player.Warnings() {
+		fmt.Fprintf(os.Stderr, "warning: %s (playing the rest)\n", w)
+	}
 
 	if player.Len() == 0 {
 		fmt.Fprintln(os.Stderr, "recording is empty, nothing to play")
@@ -118,40 +707,520 @@ func runPlayback(path string) {
 
 	m := ui.New(snapCh)
 	m.SetPlayback(player, filename)
+	m.SetPrivacy(privacyMode)
+	m.SetMouse(tui.mouse)
+	applyConfig(&m, cfg)
+	m.SetAlertNotifier(nil) // a recording's alerts fired long ago; don't act on them
 
-	prog := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
-	if _, err := prog.Run(); err != nil {
+	runTUI(m, tui, crashState{})
+}
+
+// runRemote shows the snapshots of sstop running on target over SSH,
+// optionally recording them for later playback.
+func runRemote(target, command string, interval time.Duration, recordPath string, alertWindow time.Duration, cfg *config.Config, privacyMode bool, tui tuiOptions) {
+	src := remote.New(target, max(interval, 100*time.Millisecond))
+	src.SetCommand(command)
+	snapCh := src.Start()
+	defer src.Stop()
+
+	masker := privacy.New()
+	if privacyMode {
+		snapCh = masker.Filter(snapCh)
+	}
+	var saved crashState
+	if recordPath != "" {
+		recCh, rec, err := recorder.RecordSession(snapCh, recordPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open record file: %v\n", err)
+			os.Exit(1)
+		}
+		snapCh = recCh
+		saved.rec, saved.recPath = rec, recordPath
+	}
+	if alertWindow > 0 {
+		snapCh, saved.alertRec = recorder.RecordOnAlert(snapCh, ".", alertWindow)
+		defer saved.alertRec.Close()
+	}
+
+	m := ui.New(snapCh)
+	m.SetCollector(src)
+	m.SetRemote(remoteHost(target))
+	m.SetMasker(masker)
+	m.SetPrivacy(privacyMode)
+	m.SetMouse(tui.mouse)
+	if saved.alertRec != nil {
+		m.SetAlertTrigger(alertTrigger(saved.alertRec))
+	}
+	applyConfig(&m, cfg)
+
+	runTUI(m, tui, saved)
+}
+
+// runDoctor checks the kernel features, permissions, terminal and config
+// sstop depends on and prints what to fix.
+func runDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	configPath := fs.String("config", "", "Config file to validate (default: $XDG_CONFIG_HOME/sstop/config.toml if present)")
+	fs.Parse(args)
+
+	if doctor.Write(os.Stdout, doctor.Run(doctor.Options{ConfigPath: *configPath})) {
+		os.Exit(1)
+	}
+}
+
+func runRec(args []string) {
+	if len(args) == 0 || args[0] != "graph" {
+		fmt.Fprintln(os.Stderr, "usage: sstop rec graph [flags] recording.ssrec")
+		os.Exit(2)
+	}
+	runRecGraph(args[1:])
+}
+
+// runRecGraph draws a process's (or the machine's) rates over a recording
+// as an SVG or PNG chart.
+func runRecGraph(args []string) {
+	fs := flag.NewFlagSet("rec graph", flag.ExitOnError)
+	process := fs.String("process", "", "Chart the processes of this name, summed (default: all traffic)")
+	pid := fs.Uint("pid", 0, "Chart the process with this PID")
+	out := fs.String("o", "", "Output file, .svg or .png (default: <process>.svg)")
+	width := fs.Int("width", chart.DefaultWidth, "Image width in pixels")
+	height := fs.Int("height", chart.DefaultHeight, "Image height in pixels")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: sstop rec graph [flags] recording.ssrec|dir")
+		fs.PrintDefaults()
+	}
+	// The recording may come before the flags, as in "rec graph x.ssrec -o x.svg"
+	var path string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		path, args = args[0], args[1:]
+	}
+	fs.Parse(args)
+	if path == "" && fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+	if path == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	subject := "all traffic"
+	var match func(*model.ProcessSummary) bool
+	switch {
+	case *pid != 0:
+		subject = fmt.Sprintf("pid %d", *pid)
+		match = func(p *model.ProcessSummary) bool { return p.PID == uint32(*pid) }
+	case *process != "":
+		subject = *process
+		match = func(p *model.ProcessSummary) bool { return p.Name == *process }
+	}
+	if *out == "" {
+		name := "sstop"
+		if *process != "" {
+			name = *process
+		} else if *pid != 0 {
+			name = fmt.Sprintf("pid%d", *pid)
+		}
+		*out = name + ".svg"
+	}
+	ext := strings.ToLower(filepath.Ext(*out))
+	if ext != ".svg" && ext != ".png" {
+		fmt.Fprintf(os.Stderr, "error: -o %s: want a .svg or .png file\n", *out)
+		os.Exit(2)
+	}
+
+	player, err := recorder.NewPlayer(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open recording: %v\n", err)
+		os.Exit(1)
+	}
+	for _, w := range player.Warnings() {
+		fmt.Fprintf(os.Stderr, "warning: %s (charting the rest)\n", w)
+	}
+	rates, found := report.Rates(player.Snapshots(), match)
+	if !found || len(rates) == 0 {
+		fmt.Fprintf(os.Stderr, "error: no snapshots of %s in %s\n", subject, path)
+		os.Exit(1)
+	}
+
+	var up, down []chart.Point
+	for _, r := range rates {
+		at := r.At.Local()
+		up = append(up, chart.Point{At: at, Value: r.Up})
+		down = append(down, chart.Point{At: at, Value: r.Down})
+	}
+	c := chart.Chart{
+		Title: fmt.Sprintf("%s  %s – %s", subject,
+			rates[0].At.Local().Format("2006-01-02 15:04"), rates[len(rates)-1].At.Local().Format("15:04")),
+		Series: []chart.Series{
+			{Name: "up", Color: color.RGBA{0x2f, 0x9e, 0x44, 0xff}, Points: up},
+			{Name: "down", Color: color.RGBA{0xe0, 0x31, 0x31, 0xff}, Points: down},
+		},
+		Format: func(v float64) string { return strings.TrimSpace(ui.FormatRate(v)) },
+		Width:  *width,
+		Height: *height,
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
+	if ext == ".png" {
+		err = c.WritePNG(f)
+	} else {
+		err = c.WriteSVG(f)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(*out)
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("wrote %s (%d snapshots)\n", *out, len(rates))
 }
 
-// runStreaming handles --json / --csv non-interactive output.
-func runStreaming(snapCh <-chan model.Snapshot, jsonMode bool, once bool) {
-	// Need at least 2 polls for rate deltas: first poll gives no rates
-	pollCount := 0
+// runPortsDiff implements "sstop ports-diff": the listening ports opened
+// and closed according to a --ports-log file.
+func runPortsDiff(args []string) {
+	fs := flag.NewFlagSet("ports-diff", flag.ExitOnError)
+	logPath := fs.String("log", "ports.jsonl", "Inventory log written by --ports-log")
+	since := fs.Duration("since", 0, "Only report changes from this long ago until now (e.g. 24h; 0 = all)")
+	fs.Parse(args)
+
+	f, err := os.Open(*logPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	recs, err := portlog.ReadRecords(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %s: %v (reporting the records before it)\n", *logPath, err)
+	}
+	var from time.Time
+	if *since > 0 {
+		from = time.Now().Add(-*since)
+	}
+
+	changes := portlog.Diff(recs, from)
+	if len(changes) == 0 {
+		fmt.Println("no changes")
+		return
+	}
+	for _, c := range changes {
+		sign := "-"
+		if c.Opened {
+			sign = "+"
+		}
+		owner := c.Port.Process
+		if c.Port.PID != 0 {
+			owner = fmt.Sprintf("%s (pid %d)", owner, c.Port.PID)
+		}
+		if c.Port.Container != "" {
+			owner += " container " + c.Port.Container
+		}
+		fmt.Printf("%s  %s %-4s %-24s %s\n", c.Time.Local().Format("2006-01-02 15:04"), sign, c.Port.Proto, c.Port.Addr, owner)
+	}
+}
+
+// runConnect implements "sstop connect host1,host2": one TUI over the
+// snapshots of several "sstop serve" agents, H switching between hosts.
+func runConnect(args []string) {
+	fs := flag.NewFlagSet("connect", flag.ExitOnError)
+	token := fs.String("token", "", "Token the agents were started with (sstop serve --token)")
+	configPath := fs.String("config", "", "Config file (default: $XDG_CONFIG_HOME/sstop/config.toml if present)")
+	privacyMode := fs.Bool("privacy", false, "Mask IPs, hostnames and cmdlines with stable pseudonyms")
+	noMouse := fs.Bool("no-mouse", false, "Start without mouse capture")
+	noAltScreen := fs.Bool("no-altscreen", false, "Draw inline instead of on the alternate screen")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: sstop connect [flags] host[:port],host[:port],...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var hosts []string
+	for _, arg := range fs.Args() {
+		for _, h := range strings.Split(arg, ",") {
+			if h = strings.TrimSpace(h); h != "" {
+				hosts = append(hosts, h)
+			}
+		}
+	}
+	if len(hosts) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	if err := ui.SetTheme(cfg.UI.Theme); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config: %v\n", err)
+		os.Exit(1)
+	}
+	if logFile, err := os.CreateTemp("", "sstop-*.log"); err == nil {
+		log.SetOutput(logFile)
+		defer logFile.Close()
+	}
+
+	var chs []<-chan model.Snapshot
+	for _, h := range hosts {
+		src := remote.NewAgent(h, *token)
+		chs = append(chs, src.Start())
+		defer src.Stop()
+	}
+
+	m := ui.New(remote.Merge(chs...))
+	m.SetHosts(hosts)
+	m.SetPrivacy(*privacyMode)
+	m.SetMouse(!*noMouse)
+	applyConfig(&m, cfg)
+
+	tui := tuiOptions{mouse: !*noMouse, altScreen: !*noAltScreen}
+	runTUI(m, tui, crashState{})
+}
+
+// remoteHost returns the host part of an ssh [user@]host target.
+func remoteHost(target string) string {
+	if i := strings.LastIndex(target, "@"); i >= 0 {
+		return target[i+1:]
+	}
+	return target
+}
+
+// runQuery implements "sstop query": top processes or hosts by traffic
+// from a --db history file.
+func runQuery(args []string) {
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	dbPath := fs.String("db", "traffic.sqlite", "History database written by --db")
+	since := fs.Duration("since", time.Hour, "Report traffic from this long ago until now (e.g. 30m, 24h)")
+	top := fs.Int("top", 10, "Number of rows to show")
+	hosts := fs.Bool("hosts", false, "Report remote hosts instead of processes")
+	fs.Parse(args)
 
-	var csvWriter *output.CSVWriter
-	if !jsonMode {
-		csvWriter = output.NewCSVWriter(os.Stdout)
+	// Don't let a typo create an empty database
+	if _, err := os.Stat(*dbPath); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	db, err := history.Open(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open history database: %v\n", err)
+		os.Exit(1)
 	}
+	defer db.Close()
 
+	from := time.Now().Add(-*since)
+	var rows []history.Usage
+	if *hosts {
+		rows, err = db.TopHosts(from, *top)
+	} else {
+		rows, err = db.TopProcesses(from, *top)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "query failed: %v\n", err)
+		os.Exit(1)
+	}
+	if len(rows) == 0 {
+		fmt.Printf("No traffic recorded in the last %s\n", *since)
+		return
+	}
+
+	extra := "PIDS"
+	if *hosts {
+		extra = "CC"
+	}
+	fmt.Printf("Top %d by traffic, last %s\n\n", len(rows), *since)
+	fmt.Printf("%-32s %5s %10s %10s %10s %12s\n", "NAME", extra, "UP", "DOWN", "TOTAL", "PEAK")
+	for _, u := range rows {
+		col := fmt.Sprint(u.PIDs)
+		if *hosts {
+			col = u.Country
+		}
+		fmt.Printf("%-32s %5s %10s %10s %10s %12s\n",
+			truncateName(u.Name, 32), col,
+			ui.FormatBytes(u.BytesUp), ui.FormatBytes(u.BytesDn), ui.FormatBytes(u.Total()),
+			ui.FormatRate(u.PeakRate))
+	}
+}
+
+// runReport implements "sstop report --by-hour": traffic by hour of day
+// and process, from a --db history file or a --record recording.
+func runReport(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	byHour := fs.Bool("by-hour", false, "Break traffic down by local hour of day, summed over the days covered")
+	dbPath := fs.String("db", "", "History database written by --db")
+	recPath := fs.String("recording", "", "Recording written by --record (instead of --db)")
+	since := fs.Duration("since", 0, "Only count traffic from this long ago until now (e.g. 24h, 168h; 0 = all)")
+	top := fs.Int("top", 3, "Processes listed per hour")
+	csvOut := fs.Bool("csv", false, "Write hour,process,bytes_up,bytes_down CSV rows instead of the table")
+	fs.Parse(args)
+
+	if !*byHour {
+		fmt.Fprintln(os.Stderr, "error: choose a breakdown (--by-hour)")
+		os.Exit(2)
+	}
+	if (*dbPath == "") == (*recPath == "") {
+		fmt.Fprintln(os.Stderr, "error: give one of --db or --recording")
+		os.Exit(2)
+	}
+	var from time.Time
+	if *since > 0 {
+		from = time.Now().Add(-*since)
+	}
+
+	rep := report.NewHourly()
+	if *dbPath != "" {
+		// Don't let a typo create an empty database
+		if _, err := os.Stat(*dbPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		db, err := history.Open(*dbPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open history database: %v\n", err)
+			os.Exit(1)
+		}
+		err = db.ProcessTraffic(from, rep.Add)
+		db.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "report failed: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		player, err := recorder.NewPlayer(*recPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open recording: %v\n", err)
+			os.Exit(1)
+		}
+		for _, w := range player.Warnings() {
+			fmt.Fprintf(os.Stderr, "warning: %s (reporting the rest)\n", w)
+		}
+		rep.AddSnapshots(player.Snapshots(), from)
+	}
+
+	if *csvOut {
+		if err := rep.WriteCSV(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if rep.Empty() {
+		fmt.Println("No traffic recorded")
+		return
+	}
+
+	var peak uint64
+	for hour := 0; hour < 24; hour++ {
+		total, _ := rep.Hour(hour)
+		peak = max(peak, total.Total())
+	}
+	const barW = 16
+	fmt.Printf("Traffic by hour of day, %s – %s\n\n",
+		rep.First.Format("2006-01-02 15:04"), rep.Last.Format("2006-01-02 15:04"))
+	fmt.Printf("%-5s %10s %10s %10s  %-*s  %s\n", "HOUR", "UP", "DOWN", "TOTAL", barW, "", "TOP PROCESSES")
+	for hour := 0; hour < 24; hour++ {
+		total, procs := rep.Hour(hour)
+		var names []string
+		for _, p := range procs[:min(len(procs), *top)] {
+			names = append(names, fmt.Sprintf("%s %s", p.Name, ui.FormatBytes(p.Total())))
+		}
+		fmt.Printf("%02d:00 %10s %10s %10s  %s  %s\n", hour,
+			ui.FormatBytes(total.Up), ui.FormatBytes(total.Down), ui.FormatBytes(total.Total()),
+			ui.BandwidthBar(float64(total.Total()), float64(peak), barW), strings.Join(names, ", "))
+	}
+}
+
+// truncateName shortens s to n runes with an ellipsis.
+func truncateName(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// logSinkError returns an error callback for a metrics sink. Errors go to
+// the log (a temp file in TUI mode) so a flaky endpoint doesn't stop sstop.
+func logSinkError(sink string) func(error) {
+	return func(err error) {
+		log.Printf("sstop: %s: %v", sink, err)
+	}
+}
+
+// captureSink feeds the running packet capture, from --capture-pid or the
+// TUI, from the snapshot pipeline. One capture runs at a time.
+type captureSink struct {
+	mu sync.Mutex
+	c  platform.PacketCapture
+}
+
+func (s *captureSink) start(path string, pid uint32) (platform.PacketCapture, error) {
+	c, err := platform.StartCapture(path, pid)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.c != nil {
+		s.c.Close()
+	}
+	s.c = c
+	return c, nil
+}
+
+func (s *captureSink) current() platform.PacketCapture {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c
+}
+
+// Write refreshes the capture's connections; a capture stopped from the
+// TUI ignores it.
+func (s *captureSink) Write(snap model.Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.c == nil {
+		return nil
+	}
+	return s.c.Write(snap)
+}
+
+func (s *captureSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.c == nil {
+		return nil
+	}
+	return s.c.Close()
+}
+
+// runStreaming handles --json / --csv / --influx non-interactive output.
+func runStreaming(snapCh <-chan model.Snapshot, w output.SnapshotWriter, once bool) {
+	// Need at least 2 polls for rate deltas: first poll gives no rates
+	pollCount := 0
+
+	skipped := 0
 	for snap := range snapCh {
 		pollCount++
 
+		// Report collection overruns on stderr so they don't corrupt the stream
+		if snap.SkippedPolls > skipped {
+			fmt.Fprintf(os.Stderr, "sstop: %d polls skipped (collection slow)\n", snap.SkippedPolls)
+			skipped = snap.SkippedPolls
+		}
+
 		// Skip first poll — rates are all zero (no delta yet)
 		if pollCount < 2 {
 			continue
 		}
 
-		var err error
-		if jsonMode {
-			err = output.WriteJSON(os.Stdout, snap)
-		} else {
-			err = csvWriter.Write(snap)
-		}
-
-		if err != nil {
+		if err := w.Write(snap); err != nil {
 			fmt.Fprintf(os.Stderr, "write error: %v\n", err)
 			os.Exit(1)
 		}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/googlesky/sstop/internal/model"
+	"github.com/googlesky/sstop/internal/recorder"
+)
+
+type fakeFirewall struct{ closed int }
+
+func (f *fakeFirewall) Close() error {
+	f.closed++
+	return nil
+}
+
+func TestCrashStateRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.rec")
+	rec, err := recorder.NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Write(model.Snapshot{Timestamp: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	fw := &fakeFirewall{}
+	saved := crashState{rec: rec, recPath: path, alertRec: recorder.NewAlertRecorder(t.TempDir(), time.Minute), fw: fw}
+
+	// What runTUI does before exiting on an error such as an interrupt
+	if err := saved.release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if fw.closed != 1 {
+		t.Errorf("firewall closed %d times, want 1", fw.closed)
+	}
+	p, err := recorder.NewPlayer(path)
+	if err != nil {
+		t.Fatalf("recording not flushed: %v", err)
+	}
+	defer p.Close()
+	if p.Len() != 1 {
+		t.Errorf("recording has %d snapshots, want 1", p.Len())
+	}
+
+	// Nothing to release for playback
+	if err := (crashState{}).release(); err != nil {
+		t.Errorf("empty release: %v", err)
+	}
+}